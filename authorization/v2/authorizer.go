@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/unicsmcr/hs_auth/authorization/v2/common"
 	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
 	"github.com/unicsmcr/hs_auth/environment"
 	"github.com/unicsmcr/hs_auth/services"
 	"github.com/unicsmcr/hs_auth/utils"
@@ -18,6 +19,8 @@ import (
 
 const unknownTokenTypeErrTemplate = "'%s' is not a valid token type"
 
+const actorContextKey = "hs_auth_actor"
+
 var jwtSigningMethod = jwt.SigningMethodHS256
 
 // Authorizer provides an interface for creating auth tokens and checking their permissions
@@ -44,7 +47,7 @@ type Authorizer interface {
 }
 
 func NewAuthorizer(provider utils.TimeProvider, cfg *config.AppConfig, env *environment.Env, logger *zap.Logger,
-	tokenService services.TokenService, userService services.UserService) Authorizer {
+	tokenService services.TokenService, userService services.UserService, auditService services.AuditService) Authorizer {
 	return &authorizer{
 		timeProvider: provider,
 		cfg:          cfg,
@@ -52,9 +55,21 @@ func NewAuthorizer(provider utils.TimeProvider, cfg *config.AppConfig, env *envi
 		logger:       logger,
 		tokenService: tokenService,
 		userService:  userService,
+		auditService: auditService,
 	}
 }
 
+// GetActor returns the holder of the token that was authorized for the request by WithAuthMiddleware.
+// Returns false if the request has not been authorized by the middleware.
+func GetActor(ctx *gin.Context) (Actor, bool) {
+	actor, exists := ctx.Get(actorContextKey)
+	if !exists {
+		return Actor{}, false
+	}
+
+	return actor.(Actor), true
+}
+
 type authorizer struct {
 	timeProvider utils.TimeProvider
 	cfg          *config.AppConfig
@@ -62,6 +77,7 @@ type authorizer struct {
 	logger       *zap.Logger
 	tokenService services.TokenService
 	userService  services.UserService
+	auditService services.AuditService
 }
 
 func (a *authorizer) CreateUserToken(userId primitive.ObjectID, expirationDate int64) (string, error) {
@@ -195,16 +211,48 @@ func (a *authorizer) WithAuthMiddleware(router common.RouterResource, operationH
 			}
 		}
 
+		actor := a.getActorFromToken(token)
 		if len(authorizedUris) == 0 {
+			a.logAccessDenied(ctx, actor, requestedUri)
 			router.HandleUnauthorized(ctx)
 			return
 		}
 
+		ctx.Set(actorContextKey, actor)
 		operationHandler(ctx)
 		return
 	}
 }
 
+func (a *authorizer) getActorFromToken(token string) Actor {
+	claims, err := getTokenClaims(token, a.env.Get(environment.JWTSecret))
+	if err != nil {
+		return Actor{}
+	}
+
+	// the id of both user and service tokens is an object id, so any error means the token is malformed
+	id, _ := primitive.ObjectIDFromHex(claims.Id)
+	return Actor{
+		ID:        id,
+		TokenType: claims.TokenType,
+	}
+}
+
+func (a *authorizer) logAccessDenied(ctx *gin.Context, actor Actor, requestedUri common.UniformResourceIdentifier) {
+	// the request's arguments are left out of the event since they may contain secrets, e.g. passwords
+	err := a.auditService.LogEvent(ctx, entities.AuditEvent{
+		Action:    entities.AuditActionAccessDenied,
+		Actor:     actor.ID,
+		ActorType: string(actor.TokenType),
+		Target:    requestedUri.GetPath(),
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	})
+	if err != nil {
+		a.logger.Error("could not log denied access", zap.Error(err))
+	}
+}
+
 func (a *authorizer) GetUserIdFromToken(token string) (primitive.ObjectID, error) {
 	claims, err := getTokenClaims(token, a.env.Get(environment.JWTSecret))
 	if err != nil {
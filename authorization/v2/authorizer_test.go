@@ -11,6 +11,7 @@ import (
 	"github.com/unicsmcr/hs_auth/repositories"
 	"github.com/unicsmcr/hs_auth/services"
 	"github.com/unicsmcr/hs_auth/services/mongo"
+	"github.com/unicsmcr/hs_auth/services/password"
 	"github.com/unicsmcr/hs_auth/utils"
 	"net/http"
 	"net/http/httptest"
@@ -40,6 +41,7 @@ type authorizerTestSetup struct {
 	mockRouterResource *mock_resources.MockRouterResource
 	mockTokenService   *mock_services.MockTokenService
 	mockUserService    *mock_services.MockUserService
+	mockAuditService   *mock_services.MockAuditService
 	testCtx            *gin.Context
 	testCfg            *config.AppConfig
 	ctrl               *gomock.Controller
@@ -69,6 +71,7 @@ func setupAuthorizerTests(t *testing.T, jwtSecret string) authorizerTestSetup {
 	mockRouterResource := mock_resources.NewMockRouterResource(ctrl)
 	mockTokenService := mock_services.NewMockTokenService(ctrl)
 	mockUserService := mock_services.NewMockUserService(ctrl)
+	mockAuditService := mock_services.NewMockAuditService(ctrl)
 
 	w := httptest.NewRecorder()
 	testCtx, _ := gin.CreateTestContext(w)
@@ -86,11 +89,12 @@ func setupAuthorizerTests(t *testing.T, jwtSecret string) authorizerTestSetup {
 	}
 
 	return authorizerTestSetup{
-		authorizer:         NewAuthorizer(mockTimeProvider, appCfg, env, zap.NewNop(), mockTokenService, mockUserService),
+		authorizer:         NewAuthorizer(mockTimeProvider, appCfg, env, zap.NewNop(), mockTokenService, mockUserService, mockAuditService),
 		mockTimeProvider:   mockTimeProvider,
 		mockRouterResource: mockRouterResource,
 		mockTokenService:   mockTokenService,
 		mockUserService:    mockUserService,
+		mockAuditService:   mockAuditService,
 		testCtx:            testCtx,
 		testCfg:            appCfg,
 		ctrl:               ctrl,
@@ -124,7 +128,17 @@ func setupAuthorizerBenchmarks(b *testing.B, jwtSecret string) authorizerBenchma
 	if err != nil {
 		panic(err)
 	}
-	userService := mongo.NewMongoUserService(zap.NewNop(), env, nil, userRepository)
+	passwordPolicy, err := password.NewPolicy(&config.AppConfig{})
+	if err != nil {
+		panic(err)
+	}
+	userService := mongo.NewMongoUserService(zap.NewNop(), env, nil, userRepository, nil, passwordPolicy)
+
+	auditEventRepository, err := repositories.NewAuditEventRepository(db)
+	if err != nil {
+		panic(err)
+	}
+	auditService := mongo.NewMongoAuditService(zap.NewNop(), timeProvider, auditEventRepository)
 
 	w := httptest.NewRecorder()
 	testCtx, _ := gin.CreateTestContext(w)
@@ -137,7 +151,7 @@ func setupAuthorizerBenchmarks(b *testing.B, jwtSecret string) authorizerBenchma
 		},
 	}
 	return authorizerBenchmarkSetup{
-		authorizer:         NewAuthorizer(timeProvider, appCfg, env, zap.NewNop(), tokenService, userService),
+		authorizer:         NewAuthorizer(timeProvider, appCfg, env, zap.NewNop(), tokenService, userService, auditService),
 		timeProvider:       timeProvider,
 		mockRouterResource: mockRouterResource,
 		tRepo:              tokenRepository,
@@ -659,6 +673,16 @@ func TestAuthorizer_WithAuthMiddleware_should_call_HandleUnauthorized(t *testing
 				token := createToken(t, "test_token", nil, int64(10000), Service, "")
 				setup.mockRouterResource.EXPECT().GetAuthToken(gomock.Any()).Return(token).Times(1)
 				setup.mockRouterResource.EXPECT().GetResourcePath().Return("resource").Times(1)
+				setup.mockAuditService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+		},
+		{
+			name: "when logging the denied access fails",
+			prep: func(setup *authorizerTestSetup) {
+				token := createToken(t, "test_token", nil, int64(10000), Service, "")
+				setup.mockRouterResource.EXPECT().GetAuthToken(gomock.Any()).Return(token).Times(1)
+				setup.mockRouterResource.EXPECT().GetResourcePath().Return("resource").Times(1)
+				setup.mockAuditService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(errors.New("service err")).Times(1)
 			},
 		},
 	}
@@ -697,6 +721,50 @@ func TestAuthorizer_WithAuthMiddleware_should_call_handler_when_request_is_autho
 	assert.True(t, mockHandlerCalled)
 }
 
+func TestAuthorizer_WithAuthMiddleware_should_log_denied_access(t *testing.T) {
+	setup := setupAuthorizerTests(t, "")
+	defer setup.ctrl.Finish()
+	mockHandler := func(*gin.Context) {}
+	token := createToken(t, testUserId.Hex(), nil, int64(10000), Service, "")
+	setup.mockRouterResource.EXPECT().GetAuthToken(gomock.Any()).Return(token).Times(1)
+	setup.mockRouterResource.EXPECT().GetResourcePath().Return("resource").Times(1)
+	setup.mockRouterResource.EXPECT().HandleUnauthorized(gomock.Any()).Times(1)
+	setup.testCtx.Request.Header.Set("User-Agent", "test agent")
+
+	var loggedEvent entities.AuditEvent
+	setup.mockAuditService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, event entities.AuditEvent) { loggedEvent = event }).Return(nil).Times(1)
+
+	wrappedHandler := setup.authorizer.WithAuthMiddleware(setup.mockRouterResource, mockHandler)
+
+	wrappedHandler(setup.testCtx)
+
+	assert.Equal(t, entities.AuditActionAccessDenied, loggedEvent.Action)
+	assert.Equal(t, testUserId, loggedEvent.Actor)
+	assert.Equal(t, string(Service), loggedEvent.ActorType)
+	assert.Equal(t, "resource:func1", loggedEvent.Target)
+	assert.Equal(t, "test agent", loggedEvent.UserAgent)
+	_, exists := GetActor(setup.testCtx)
+	assert.False(t, exists)
+}
+
+func TestAuthorizer_WithAuthMiddleware_should_store_actor_when_request_is_authorized(t *testing.T) {
+	setup := setupAuthorizerTests(t, "")
+	defer setup.ctrl.Finish()
+	var actorInHandler Actor
+	mockHandler := func(ctx *gin.Context) { actorInHandler, _ = GetActor(ctx) }
+	testURI := createTestURI("resource")
+	token := createToken(t, testUserId.Hex(), []common.UniformResourceIdentifier{testURI}, int64(10000), Service, "")
+	setup.mockRouterResource.EXPECT().GetAuthToken(gomock.Any()).Return(token).Times(1)
+	setup.mockRouterResource.EXPECT().GetResourcePath().Return("resource").Times(1)
+
+	wrappedHandler := setup.authorizer.WithAuthMiddleware(setup.mockRouterResource, mockHandler)
+
+	wrappedHandler(setup.testCtx)
+
+	assert.Equal(t, Actor{ID: testUserId, TokenType: Service}, actorInHandler)
+}
+
 func TestAuthorizer_GetUserIdFromToken__should_return_error(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -25,9 +26,15 @@ type UniformResourceIdentifiers []UniformResourceIdentifier
 
 // NewUriFromRequest creates a UniformResourceIdentifier from a gin request to the given resource and handler
 func NewUriFromRequest(resource Resource, handler gin.HandlerFunc, ctx *gin.Context) UniformResourceIdentifier {
+	uri := NewURIFromHandler(resource, handler)
+	uri.arguments = getRequestArguments(ctx)
+	return uri
+}
+
+// NewURIFromHandler creates a UniformResourceIdentifier without any arguments for the given resource and handler
+func NewURIFromHandler(resource Resource, handler gin.HandlerFunc) UniformResourceIdentifier {
 	return UniformResourceIdentifier{
-		path:      fmt.Sprintf("%s:%s", resource.GetResourcePath(), getHandlerName(handler)),
-		arguments: getRequestArguments(ctx),
+		path: fmt.Sprintf("%s:%s", resource.GetResourcePath(), getHandlerName(handler)),
 	}
 }
 
@@ -147,24 +154,54 @@ func (uris *UniformResourceIdentifiers) UnmarshalJSON(data []byte) error {
 }
 
 // Implements the ValueMarshaler interface of the mongo pkg.
+// The URIs are stored as an array of their string representations.
 func (uris UniformResourceIdentifiers) MarshalBSONValue() (bsontype.Type, []byte, error) {
-	marshalledURIs := make([]string, len(uris))
+	idx, arr := bsoncore.AppendArrayStart(nil)
 	for i, uri := range uris {
 		// Ignore the error since we are guaranteed to get a valid string
 		marshalledURI, _ := uri.MarshalJSON()
 
 		// MarshalJSON en-quotes the marshalled URI, so we unquote it here
-		marshalledURIs[i] = string(marshalledURI[1 : len(marshalledURI)-1])
+		arr = bsoncore.AppendStringElement(arr, strconv.Itoa(i), string(marshalledURI[1:len(marshalledURI)-1]))
+	}
+	arr, err := bsoncore.AppendArrayEnd(arr, idx)
+	if err != nil {
+		return bsontype.Null, nil, errors.Wrap(err, "failed to marshal uris")
 	}
 
-	allURIs := strings.Join(marshalledURIs, ",")
-	return bsontype.String, bsoncore.AppendString(nil, allURIs), nil
+	return bsontype.Array, arr, nil
 }
 
 // Implements the ValueUnmarshaler interface of the mongo pkg.
-func (uris *UniformResourceIdentifiers) UnmarshalBSONValue(_ bsontype.Type, bytes []byte) error {
-	urisCombined, _, _ := bsoncore.ReadString(bytes)
-	allURIStrings := strings.Split(urisCombined, ",")
+// Besides arrays, the comma separated strings URI lists used to be stored as are accepted too.
+func (uris *UniformResourceIdentifiers) UnmarshalBSONValue(t bsontype.Type, bytes []byte) error {
+	var allURIStrings []string
+	switch t {
+	case bsontype.Array:
+		arr, _, ok := bsoncore.ReadArray(bytes)
+		if !ok {
+			return errors.New("failed to read uri array")
+		}
+		values, err := arr.Values()
+		if err != nil {
+			return errors.Wrap(err, "failed to read uri array")
+		}
+		for _, value := range values {
+			uriString, ok := value.StringValueOK()
+			if !ok {
+				return errors.New(fmt.Sprintf("uri array contains a value of type %s", value.Type))
+			}
+			allURIStrings = append(allURIStrings, uriString)
+		}
+	case bsontype.String:
+		urisCombined, _, _ := bsoncore.ReadString(bytes)
+		allURIStrings = SplitLegacyURIList(urisCombined)
+	case bsontype.Null:
+		*uris = nil
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("cannot unmarshal uris from bson type %s", t))
+	}
 
 	unmarshalledURIs := make(UniformResourceIdentifiers, len(allURIStrings))
 	for i, uriString := range allURIStrings {
@@ -179,6 +216,15 @@ func (uris *UniformResourceIdentifiers) UnmarshalBSONValue(_ bsontype.Type, byte
 	return nil
 }
 
+// SplitLegacyURIList splits a URI list stored in the legacy comma separated format into the individual URIs.
+// Commas in URI arguments were query escaped in that format, so only the separators get split on.
+func SplitLegacyURIList(urisCombined string) []string {
+	if len(urisCombined) == 0 {
+		return []string{}
+	}
+	return strings.Split(urisCombined, ",")
+}
+
 // Implements the Unmarshal interface of the yaml pkg.
 func (uris *UniformResourceIdentifiers) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	yamlURISequence := make([]string, 0)
@@ -187,14 +233,15 @@ func (uris *UniformResourceIdentifiers) UnmarshalYAML(unmarshal func(interface{}
 		return err
 	}
 
-	*uris = make([]UniformResourceIdentifier, len(yamlURISequence))
+	parsedURIs := make(UniformResourceIdentifiers, len(yamlURISequence))
 	for i, uri := range yamlURISequence {
-		parsedURI, err := NewURIFromString(uri)
-		if err == nil {
-			(*uris)[i] = parsedURI
+		parsedURIs[i], err = NewURIFromString(uri)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to unmarshal uri %s", uri))
 		}
 	}
 
+	*uris = parsedURIs
 	return nil
 }
 
@@ -266,6 +313,9 @@ func (uri UniformResourceIdentifier) isSupersetOf(target UniformResourceIdentifi
 	// Compare URI path
 	sourcePathComponents := strings.Split(uri.path, ":")
 	targetPathComponents := strings.Split(target.path, ":")
+	if len(sourcePathComponents) > len(targetPathComponents) {
+		return false
+	}
 	for i, pathComponent := range sourcePathComponents {
 		if pathComponent != targetPathComponents[i] {
 			return false
@@ -328,3 +378,13 @@ func (uri UniformResourceIdentifier) IsSupersetOfAtLeastOne(targets []UniformRes
 func (uri UniformResourceIdentifier) GetMetadata() map[string]string {
 	return uri.metadata
 }
+
+// GetPath returns the path of the URI, i.e. the URI without its arguments and metadata
+func (uri UniformResourceIdentifier) GetPath() string {
+	return uri.path
+}
+
+// GetArguments returns the argument limitations of the URI
+func (uri UniformResourceIdentifier) GetArguments() map[string]string {
+	return uri.arguments
+}
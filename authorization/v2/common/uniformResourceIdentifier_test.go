@@ -397,6 +397,16 @@ func Test_UnmarshalYAML__should_return_err_with_invalid_uri(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_UnmarshalYAML__should_return_err_when_uri_cannot_be_parsed(t *testing.T) {
+	uriSequence := &UniformResourceIdentifiers{}
+	err := uriSequence.UnmarshalYAML(func(a interface{}) error {
+		sl := reflect.ValueOf(a).Elem()
+		sl.Set(reflect.Append(sl, reflect.ValueOf("hs:hs_auth?a=b?c=d")))
+		return nil
+	})
+	assert.Error(t, err)
+}
+
 func Test_MarshalBSONValue_should_marshal_with_valid_uri(t *testing.T) {
 	expectedURI := "hs:test"
 	var allURIs = UniformResourceIdentifiers{
@@ -406,29 +416,113 @@ func Test_MarshalBSONValue_should_marshal_with_valid_uri(t *testing.T) {
 	}
 
 	bsonType, data, err := allURIs.MarshalBSONValue()
-	actualResult, _, _ := bsoncore.ReadString(data)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedURI, actualResult)
-	assert.Equal(t, bsontype.String, bsonType)
+	assert.Equal(t, bsontype.Array, bsonType)
+
+	values, err := bsoncore.Array(data).Values()
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, expectedURI, values[0].StringValue())
+}
+
+func Test_MarshalBSONValue_should_marshal_empty_list_as_empty_array(t *testing.T) {
+	bsonType, data, err := UniformResourceIdentifiers{}.MarshalBSONValue()
+	assert.NoError(t, err)
+	assert.Equal(t, bsontype.Array, bsonType)
+
+	values, err := bsoncore.Array(data).Values()
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func Test_BSONValue_should_round_trip_uri_with_comma_in_argument(t *testing.T) {
+	uri, err := NewURIFromString("hs:hs_auth:api:v2:GetUser?path_id=a%7B1%2C3%7D")
+	assert.NoError(t, err)
+	otherURI, err := NewURIFromString("hs:hs_auth:frontend")
+	assert.NoError(t, err)
+	uris := UniformResourceIdentifiers{uri, otherURI}
+
+	bsonType, data, err := uris.MarshalBSONValue()
+	assert.NoError(t, err)
+
+	var unmarshalledURIs UniformResourceIdentifiers
+	err = unmarshalledURIs.UnmarshalBSONValue(bsonType, data)
+	assert.NoError(t, err)
+	assert.Equal(t, uris, unmarshalledURIs)
+	assert.Equal(t, "a{1,3}", unmarshalledURIs[0].arguments["path_id"])
 }
 
 func Test_UnmarshalBSONValue_should_unmarshal_with_valid_uri(t *testing.T) {
 	testURI := "hs:test"
-	uriBytes := bsoncore.AppendString(nil, testURI)
+	uriBytes := bsoncore.BuildArray(nil, bsoncore.Value{Type: bsontype.String, Data: bsoncore.AppendString(nil, testURI)})
 	var allURIs = UniformResourceIdentifiers{}
 
-	err := allURIs.UnmarshalBSONValue(bsontype.String, uriBytes)
+	err := allURIs.UnmarshalBSONValue(bsontype.Array, uriBytes)
 	assert.NoError(t, err)
 	assert.Equal(t, testURI, allURIs[0].path)
 }
 
-func Test_UnmarshalBSONValue_should_return_error_with_invalid_uri(t *testing.T) {
-	testURI := "#hs:test??####"
-	uriBytes := bsoncore.AppendString(nil, testURI)
+func Test_UnmarshalBSONValue_should_unmarshal_legacy_string(t *testing.T) {
+	uriBytes := bsoncore.AppendString(nil, "hs:test,hs:other")
 	var allURIs = UniformResourceIdentifiers{}
 
 	err := allURIs.UnmarshalBSONValue(bsontype.String, uriBytes)
-	assert.Error(t, err)
+	assert.NoError(t, err)
+	assert.Len(t, allURIs, 2)
+	assert.Equal(t, "hs:test", allURIs[0].path)
+	assert.Equal(t, "hs:other", allURIs[1].path)
+}
+
+func Test_UnmarshalBSONValue_should_unmarshal_empty_legacy_string_as_empty_list(t *testing.T) {
+	uriBytes := bsoncore.AppendString(nil, "")
+	var allURIs UniformResourceIdentifiers
+
+	err := allURIs.UnmarshalBSONValue(bsontype.String, uriBytes)
+	assert.NoError(t, err)
+	assert.Empty(t, allURIs)
+}
+
+func Test_UnmarshalBSONValue_should_return_error(t *testing.T) {
+	tests := []struct {
+		name     string
+		bsonType bsontype.Type
+		data     []byte
+	}{
+		{
+			name:     "with invalid uri",
+			bsonType: bsontype.Array,
+			data:     bsoncore.BuildArray(nil, bsoncore.Value{Type: bsontype.String, Data: bsoncore.AppendString(nil, "#hs:test??####")}),
+		},
+		{
+			name:     "with invalid legacy uri",
+			bsonType: bsontype.String,
+			data:     bsoncore.AppendString(nil, "#hs:test??####"),
+		},
+		{
+			name:     "with non string array value",
+			bsonType: bsontype.Array,
+			data:     bsoncore.BuildArray(nil, bsoncore.Value{Type: bsontype.Int32, Data: bsoncore.AppendInt32(nil, 1)}),
+		},
+		{
+			name:     "with unsupported type",
+			bsonType: bsontype.Int32,
+			data:     bsoncore.AppendInt32(nil, 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var allURIs UniformResourceIdentifiers
+			err := allURIs.UnmarshalBSONValue(tt.bsonType, tt.data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_SplitLegacyURIList(t *testing.T) {
+	assert.Equal(t, []string{}, SplitLegacyURIList(""))
+	assert.Equal(t, []string{"hs:a"}, SplitLegacyURIList("hs:a"))
+	assert.Equal(t, []string{"hs:a?b=c%2Cd", "hs:e"}, SplitLegacyURIList("hs:a?b=c%2Cd,hs:e"))
 }
 
 func Test_isSupersetOf__should_return_true_with_source_in_target_set(t *testing.T) {
@@ -559,6 +653,15 @@ func Test_isSupersetOf__should_return_false_with_source_not_in_target_set(t *tes
 				path: "hs:hs_application:user",
 			},
 		},
+		{
+			name: "shorter path with more components",
+			source: UniformResourceIdentifier{
+				path: "hs:a:b:c:d",
+			},
+			target: UniformResourceIdentifier{
+				path: "hs:hs_application",
+			},
+		},
 		{
 			name: "only path with target longer than source",
 			source: UniformResourceIdentifier{
@@ -732,3 +835,33 @@ func TestUniformResourceIdentifier_GetMetadata(t *testing.T) {
 
 	assert.Equal(t, map[string]string{"testKey": "testValue"}, testUri.GetMetadata())
 }
+
+func TestUniformResourceIdentifier_GetPath(t *testing.T) {
+	testUri := UniformResourceIdentifier{
+		path: "hs:hs_auth",
+	}
+
+	assert.Equal(t, "hs:hs_auth", testUri.GetPath())
+}
+
+func TestUniformResourceIdentifier_GetArguments(t *testing.T) {
+	testUri := UniformResourceIdentifier{
+		arguments: map[string]string{"path_id": "me"},
+	}
+
+	assert.Equal(t, map[string]string{"path_id": "me"}, testUri.GetArguments())
+}
+
+func TestNewURIFromHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRouterResource := mock_resources.NewMockRouterResource(ctrl)
+	mockRouterResource.EXPECT().GetResourcePath().Return("test_router").Times(1)
+
+	uri := NewURIFromHandler(mockRouterResource, testHandlerm)
+
+	assert.Equal(t, "test_router:testHandlerm", uri.path)
+	assert.Nil(t, uri.arguments)
+	assert.Nil(t, uri.metadata)
+}
@@ -3,6 +3,7 @@ package v2
 import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type TokenType string
@@ -10,6 +11,12 @@ type TokenType string
 const User TokenType = "user"
 const Service TokenType = "service"
 
+// Actor identifies the holder of a token authorized by the auth middleware
+type Actor struct {
+	ID        primitive.ObjectID
+	TokenType TokenType
+}
+
 type tokenClaims struct {
 	jwt.StandardClaims
 	TokenType        `json:"token_type"`
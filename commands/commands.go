@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+)
+
+// Exit codes returned by the commands
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+type command func(args []string, stdout, stderr io.Writer) int
+
+var registeredCommands = map[string]command{
+	"roles": runRolesCommand,
+	"teams": runTeamsCommand,
+}
+
+// Run executes the command named by the first element of args and returns the exit code for the process
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	cmd, exists := registeredCommands[args[0]]
+	if !exists {
+		fmt.Fprintf(stderr, "unknown command %s\n", args[0])
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	return cmd(args[1:], stdout, stderr)
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: hs_auth [command]")
+	fmt.Fprintln(w, "starts the server when no command is given")
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  roles    validate and inspect the role configuration")
+	fmt.Fprintln(w, "  teams    repair inconsistent teams")
+}
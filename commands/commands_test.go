@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Run__should_return_usage_exit_code_when_no_command_is_given(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Run(nil, &stdout, &stderr)
+
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Contains(t, stderr.String(), "usage")
+}
+
+func Test_Run__should_return_usage_exit_code_when_command_is_unknown(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Run([]string{"unknown"}, &stdout, &stderr)
+
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Contains(t, stderr.String(), "unknown command unknown")
+}
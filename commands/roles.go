@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/routers"
+)
+
+const (
+	defaultRoleConfigFile = "./config/role/role.yaml"
+	// URIs in this namespace are expected to match an operation or component registered by the routers
+	hsAuthNamespace = "hs:hs_auth"
+)
+
+func runRolesCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printRolesUsage(stderr)
+		return exitUsage
+	}
+
+	// the routers are only inspected, gin's route logging would clutter the output
+	gin.SetMode(gin.ReleaseMode)
+
+	switch args[0] {
+	case "validate":
+		return runRolesValidate(args[1:], stdout, stderr)
+	case "uris":
+		return runRolesURIs(stdout)
+	case "check":
+		return runRolesCheck(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown roles subcommand %s\n", args[0])
+		printRolesUsage(stderr)
+		return exitUsage
+	}
+}
+
+func printRolesUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: hs_auth roles <subcommand>")
+	fmt.Fprintln(w, "subcommands:")
+	fmt.Fprintln(w, "  validate [-file path]               strictly validates the role configuration")
+	fmt.Fprintln(w, "  uris                                lists the URIs of all operations and components registered by hs_auth")
+	fmt.Fprintln(w, "  check [-file path] <role> <uri>     checks whether the given role can access the given URI")
+}
+
+func runRolesValidate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("file", defaultRoleConfigFile, "path to the role configuration file")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		printRolesUsage(stderr)
+		return exitUsage
+	}
+
+	issues, err := role.LintUserRoleConfigFile(*file, hsAuthNamespace, routers.RegisteredURIs(&config.AppConfig{}))
+	if err != nil {
+		fmt.Fprintf(stderr, "could not validate %s: %s\n", *file, err)
+		return exitError
+	}
+
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			fmt.Fprintln(stdout, issue)
+		}
+		fmt.Fprintf(stdout, "%s: %d problem(s) found\n", *file, len(issues))
+		return exitError
+	}
+
+	fmt.Fprintf(stdout, "%s: no problems found\n", *file)
+	return exitOK
+}
+
+func runRolesURIs(stdout io.Writer) int {
+	for _, uri := range routers.RegisteredURIs(&config.AppConfig{}) {
+		fmt.Fprintln(stdout, uri.GetPath())
+	}
+
+	return exitOK
+}
+
+func runRolesCheck(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("file", defaultRoleConfigFile, "path to the role configuration file")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		printRolesUsage(stderr)
+		return exitUsage
+	}
+	roleName, uriString := flags.Arg(0), flags.Arg(1)
+
+	uri, err := common.NewURIFromString(uriString)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not parse uri %s: %s\n", uriString, err)
+		return exitError
+	}
+
+	roleConfig, err := role.LoadUserRoleConfig(*file)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not load %s: %s\n", *file, err)
+		return exitError
+	}
+
+	permissions, err := roleConfig.GetRolePermissions(role.UserRole(roleName))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	var grantedBy common.UniformResourceIdentifiers
+	for _, permission := range permissions {
+		if permission.IsSupersetOfAtLeastOne(common.UniformResourceIdentifiers{uri}) {
+			grantedBy = append(grantedBy, permission)
+		}
+	}
+
+	if len(grantedBy) == 0 {
+		fmt.Fprintf(stdout, "denied: role %s cannot access %s\n", roleName, uriString)
+		return exitError
+	}
+
+	fmt.Fprintf(stdout, "allowed: role %s can access %s, granted by:\n", roleName, uriString)
+	for _, permission := range grantedBy {
+		// MarshalJSON en-quotes the marshalled URI, so we unquote it here
+		marshalledPermission, _ := permission.MarshalJSON()
+		fmt.Fprintf(stdout, "  %s\n", marshalledPermission[1:len(marshalledPermission)-1])
+	}
+	return exitOK
+}
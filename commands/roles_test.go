@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRoleConfigFile = "../config/role/role.yaml"
+
+func writeTestRoleConfigFile(t *testing.T, content string) (path string, cleanup func()) {
+	file, err := ioutil.TempFile("", "role*.yaml")
+	assert.NoError(t, err)
+
+	_, err = file.WriteString(content)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	return file.Name(), func() {
+		os.Remove(file.Name())
+	}
+}
+
+func Test_runRolesCommand(t *testing.T) {
+	invalidRoleConfigFile, cleanup := writeTestRoleConfigFile(t, `
+role:
+  organiser:
+    - "hs:hs_auth:api:v2:GetUserz"
+    - "hs?a=b?c=d"
+`)
+	defer cleanup()
+
+	tests := []struct {
+		name             string
+		args             []string
+		wantExitCode     int
+		wantStdoutSubstr string
+		wantStderrSubstr string
+	}{
+		{
+			name:             "should return usage exit code when no subcommand is given",
+			args:             []string{},
+			wantExitCode:     exitUsage,
+			wantStderrSubstr: "usage",
+		},
+		{
+			name:             "should return usage exit code when subcommand is unknown",
+			args:             []string{"unknown"},
+			wantExitCode:     exitUsage,
+			wantStderrSubstr: "unknown roles subcommand",
+		},
+		{
+			name:             "validate should return 0 when role config is valid",
+			args:             []string{"validate", "-file", testRoleConfigFile},
+			wantExitCode:     exitOK,
+			wantStdoutSubstr: "no problems found",
+		},
+		{
+			name:             "validate should report problems and return 1 when role config is invalid",
+			args:             []string{"validate", "-file", invalidRoleConfigFile},
+			wantExitCode:     exitError,
+			wantStdoutSubstr: "uri hs:hs_auth:api:v2:GetUserz: uri does not match any registered operation or component",
+		},
+		{
+			name:             "validate should return 1 when role config file does not exist",
+			args:             []string{"validate", "-file", "does_not_exist.yaml"},
+			wantExitCode:     exitError,
+			wantStderrSubstr: "could not validate",
+		},
+		{
+			name:             "uris should list registered uris",
+			args:             []string{"uris"},
+			wantExitCode:     exitOK,
+			wantStdoutSubstr: "hs:hs_auth:api:v2:GetUser\n",
+		},
+		{
+			name:             "check should return 0 when role can access uri",
+			args:             []string{"check", "-file", testRoleConfigFile, "applicant", "hs:hs_auth:api:v2:GetUser?path_id=me"},
+			wantExitCode:     exitOK,
+			wantStdoutSubstr: "allowed",
+		},
+		{
+			name:             "check should return 1 when role cannot access uri",
+			args:             []string{"check", "-file", testRoleConfigFile, "applicant", "hs:hs_auth:api:v2:GetUser?path_id=123"},
+			wantExitCode:     exitError,
+			wantStdoutSubstr: "denied",
+		},
+		{
+			name:             "check should return 1 when role does not exist",
+			args:             []string{"check", "-file", testRoleConfigFile, "sponsor", "hs:hs_auth:api:v2:GetUser"},
+			wantExitCode:     exitError,
+			wantStderrSubstr: "role sponsor does not exist",
+		},
+		{
+			name:             "check should return 1 when uri is invalid",
+			args:             []string{"check", "-file", testRoleConfigFile, "applicant", "hs?a=b?c=d"},
+			wantExitCode:     exitError,
+			wantStderrSubstr: "could not parse uri",
+		},
+		{
+			name:             "check should return 1 when role config is invalid",
+			args:             []string{"check", "-file", invalidRoleConfigFile, "organiser", "hs"},
+			wantExitCode:     exitError,
+			wantStderrSubstr: "could not load",
+		},
+		{
+			name:             "check should return usage exit code when arguments are missing",
+			args:             []string{"check", "applicant"},
+			wantExitCode:     exitUsage,
+			wantStderrSubstr: "usage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			exitCode := runRolesCommand(tt.args, &stdout, &stderr)
+
+			assert.Equal(t, tt.wantExitCode, exitCode)
+			assert.Contains(t, stdout.String(), tt.wantStdoutSubstr)
+			assert.Contains(t, stderr.String(), tt.wantStderrSubstr)
+		})
+	}
+}
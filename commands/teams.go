@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/environment"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+func runTeamsCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printTeamsUsage(stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "repair":
+		return runTeamsRepair(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown teams subcommand %s\n", args[0])
+		printTeamsUsage(stderr)
+		return exitUsage
+	}
+}
+
+func printTeamsUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: hs_auth teams <subcommand>")
+	fmt.Fprintln(w, "subcommands:")
+	fmt.Fprintln(w, "  repair [-dry-run]    fixes teams left without members or a creator and users in teams that do not exist")
+}
+
+func runTeamsRepair(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("repair", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dryRun := flags.Bool("dry-run", false, "only report the problems, without fixing them")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		printTeamsUsage(stderr)
+		return exitUsage
+	}
+
+	logger := zap.NewNop()
+	db, err := utils.NewDatabase(logger, environment.NewEnv(logger))
+	if err != nil {
+		fmt.Fprintf(stderr, "could not connect to database: %s\n", err)
+		return exitError
+	}
+	defer db.Client().Disconnect(context.Background())
+
+	problems, err := repairTeams(context.Background(), db, *dryRun, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not repair teams: %s\n", err)
+		return exitError
+	}
+
+	if *dryRun {
+		fmt.Fprintf(stdout, "%d problem(s) found\n", problems)
+		if problems > 0 {
+			return exitError
+		}
+		return exitOK
+	}
+
+	fmt.Fprintf(stdout, "%d problem(s) fixed\n", problems)
+	return exitOK
+}
+
+// repairTeams fixes the inconsistencies between teams and their members which team membership changes
+// interrupted before they ran in transactions could leave behind. Users in teams that do not exist are removed
+// from them, teams without members are deleted, teams whose creator is not a member get their oldest member as
+// their creator and member counts are recounted. Returns the number of problems found
+func repairTeams(ctx context.Context, db *mongo.Database, dryRun bool, stdout io.Writer) (int, error) {
+	teams := db.Collection("teams")
+	users := db.Collection("users")
+
+	cur, err := teams.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, errors.Wrap(err, "could not query for teams")
+	}
+	var allTeams []entities.Team
+	err = cur.All(ctx, &allTeams)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not decode teams")
+	}
+
+	problems := 0
+	// repair reports the problem and, unless it is a dry run, fixes it
+	repair := func(problem, fixDescription string, fix func() error) error {
+		problems++
+		if dryRun {
+			fmt.Fprintln(stdout, problem)
+			return nil
+		}
+
+		err := fix()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%s: %s\n", problem, fixDescription)
+		return nil
+	}
+
+	teamIDs := make([]primitive.ObjectID, 0, len(allTeams))
+	for _, team := range allTeams {
+		teamIDs = append(teamIDs, team.ID)
+	}
+
+	orphanedUsers := bson.M{
+		string(entities.UserTeam): bson.M{
+			"$exists": true,
+			"$nin":    append(teamIDs, primitive.NilObjectID),
+		},
+	}
+	orphanedUserCount, err := users.CountDocuments(ctx, orphanedUsers)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not count users in teams that do not exist")
+	}
+	if orphanedUserCount > 0 {
+		err = repair(fmt.Sprintf("%d user(s) are in teams that do not exist", orphanedUserCount), "removed them from the teams", func() error {
+			_, err := users.UpdateMany(ctx, orphanedUsers, bson.M{
+				"$set": bson.M{string(entities.UserTeam): primitive.NilObjectID},
+			})
+			return errors.Wrap(err, "could not remove users from teams that do not exist")
+		})
+		if err != nil {
+			return problems, err
+		}
+	}
+
+	for _, team := range allTeams {
+		cur, err := users.Find(ctx, bson.M{
+			string(entities.UserTeam): team.ID,
+		}, options.Find().SetSort(bson.M{string(entities.UserID): 1}))
+		if err != nil {
+			return problems, errors.Wrap(err, "could not query for team's members")
+		}
+		var members []entities.User
+		err = cur.All(ctx, &members)
+		if err != nil {
+			return problems, errors.Wrap(err, "could not decode team's members")
+		}
+
+		teamDescription := fmt.Sprintf("team %s (%s)", team.ID.Hex(), team.Name)
+		if len(members) == 0 {
+			err = repair(teamDescription+" has no members", "deleted the team", func() error {
+				_, err := teams.DeleteOne(ctx, bson.M{string(entities.TeamID): team.ID})
+				return errors.Wrap(err, "could not delete team without members")
+			})
+			if err != nil {
+				return problems, err
+			}
+			continue
+		}
+
+		creatorIsMember := false
+		for _, member := range members {
+			if member.ID == team.Creator {
+				creatorIsMember = true
+			}
+		}
+		if !creatorIsMember {
+			err = repair(teamDescription+"'s creator is not a member", "made "+members[0].ID.Hex()+" its creator", func() error {
+				_, err := teams.UpdateOne(ctx, bson.M{string(entities.TeamID): team.ID}, bson.M{
+					"$set": bson.M{string(entities.TeamCreator): members[0].ID},
+				})
+				return errors.Wrap(err, "could not set team's creator")
+			})
+			if err != nil {
+				return problems, err
+			}
+		}
+
+		if team.MemberCount != len(members) {
+			err = repair(fmt.Sprintf("%s has %d member(s) but counts %d", teamDescription, len(members), team.MemberCount), "recounted its members", func() error {
+				_, err := teams.UpdateOne(ctx, bson.M{string(entities.TeamID): team.ID}, bson.M{
+					"$set": bson.M{string(entities.TeamMemberCount): len(members)},
+				})
+				return errors.Wrap(err, "could not set team's member count")
+			})
+			if err != nil {
+				return problems, err
+			}
+		}
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,106 @@
+// +build integration
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type teamsRepairTestData struct {
+	consistentTeam entities.Team
+	emptyTeam      entities.Team
+	creatorless    entities.Team
+	newCreator     entities.User
+	orphanedUser   entities.User
+}
+
+func setupTeamsRepairTest(t *testing.T) (*mongo.Database, teamsRepairTestData, func()) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	data := teamsRepairTestData{
+		consistentTeam: entities.Team{ID: primitive.NewObjectID(), Name: "Bobs", Creator: primitive.NewObjectID(), MemberCount: 1},
+		emptyTeam:      entities.Team{ID: primitive.NewObjectID(), Name: "Nobodies", Creator: primitive.NewObjectID(), MemberCount: 1},
+		creatorless:    entities.Team{ID: primitive.NewObjectID(), Name: "Robs", Creator: primitive.NewObjectID(), MemberCount: 1},
+	}
+	data.newCreator = entities.User{ID: primitive.NewObjectID(), Name: "Rob", Email: "rob@test.com", Team: data.creatorless.ID}
+	data.orphanedUser = entities.User{ID: primitive.NewObjectID(), Name: "Amy", Email: "amy@test.com", Team: primitive.NewObjectID()}
+
+	_, err := db.Collection("teams").InsertMany(context.Background(), []interface{}{data.consistentTeam, data.emptyTeam, data.creatorless})
+	assert.NoError(t, err)
+	_, err = db.Collection("users").InsertMany(context.Background(), []interface{}{
+		entities.User{ID: data.consistentTeam.Creator, Name: "Bob", Email: "bob@test.com", Team: data.consistentTeam.ID},
+		entities.User{ID: primitive.NewObjectID(), Name: "Rob 2", Email: "rob2@test.com", Team: data.creatorless.ID},
+		data.newCreator,
+		data.orphanedUser,
+	})
+	assert.NoError(t, err)
+
+	return db, data, func() {
+		db.Collection("users").Drop(context.Background())
+		db.Collection("teams").Drop(context.Background())
+	}
+}
+
+func Test_repairTeams__should_only_report_problems_in_dry_run(t *testing.T) {
+	db, data, cleanup := setupTeamsRepairTest(t)
+	defer cleanup()
+
+	var stdout bytes.Buffer
+	problems, err := repairTeams(context.Background(), db, true, &stdout)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, problems)
+	assert.Contains(t, stdout.String(), "1 user(s) are in teams that do not exist")
+
+	teamCount, err := db.Collection("teams").CountDocuments(context.Background(), bson.M{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), teamCount)
+
+	var orphanedUser entities.User
+	err = db.Collection("users").FindOne(context.Background(), bson.M{"_id": data.orphanedUser.ID}).Decode(&orphanedUser)
+	assert.NoError(t, err)
+	assert.Equal(t, data.orphanedUser.Team, orphanedUser.Team)
+}
+
+func Test_repairTeams__should_fix_inconsistent_teams_and_users(t *testing.T) {
+	db, data, cleanup := setupTeamsRepairTest(t)
+	defer cleanup()
+
+	var stdout bytes.Buffer
+	problems, err := repairTeams(context.Background(), db, false, &stdout)
+	assert.NoError(t, err)
+	// users in teams that do not exist, the empty team, the creatorless team's creator and its member count
+	assert.Equal(t, 4, problems)
+
+	var orphanedUser entities.User
+	err = db.Collection("users").FindOne(context.Background(), bson.M{"_id": data.orphanedUser.ID}).Decode(&orphanedUser)
+	assert.NoError(t, err)
+	assert.Equal(t, primitive.NilObjectID, orphanedUser.Team)
+
+	err = db.Collection("teams").FindOne(context.Background(), bson.M{"_id": data.emptyTeam.ID}).Err()
+	assert.Equal(t, mongo.ErrNoDocuments, err)
+
+	var creatorless entities.Team
+	err = db.Collection("teams").FindOne(context.Background(), bson.M{"_id": data.creatorless.ID}).Decode(&creatorless)
+	assert.NoError(t, err)
+	assert.Equal(t, data.newCreator.ID, creatorless.Creator)
+	assert.Equal(t, 2, creatorless.MemberCount)
+
+	var consistentTeam entities.Team
+	err = db.Collection("teams").FindOne(context.Background(), bson.M{"_id": data.consistentTeam.ID}).Decode(&consistentTeam)
+	assert.NoError(t, err)
+	assert.Equal(t, data.consistentTeam, consistentTeam)
+
+	// running the repair again finds nothing left to fix
+	problems, err = repairTeams(context.Background(), db, false, &stdout)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, problems)
+}
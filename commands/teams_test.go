@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_runTeamsCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		wantExitCode     int
+		wantStderrSubstr string
+	}{
+		{
+			name:             "should return usage exit code when no subcommand is given",
+			args:             []string{},
+			wantExitCode:     exitUsage,
+			wantStderrSubstr: "usage",
+		},
+		{
+			name:             "should return usage exit code when subcommand is unknown",
+			args:             []string{"unknown"},
+			wantExitCode:     exitUsage,
+			wantStderrSubstr: "unknown teams subcommand",
+		},
+		{
+			name:             "repair should return usage exit code when flag is unknown",
+			args:             []string{"repair", "-force"},
+			wantExitCode:     exitUsage,
+			wantStderrSubstr: "usage",
+		},
+		{
+			name:             "repair should return usage exit code when arguments are given",
+			args:             []string{"repair", "teams"},
+			wantExitCode:     exitUsage,
+			wantStderrSubstr: "usage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			exitCode := runTeamsCommand(tt.args, &stdout, &stderr)
+
+			assert.Equal(t, tt.wantExitCode, exitCode)
+			assert.Contains(t, stderr.String(), tt.wantStderrSubstr)
+		})
+	}
+}
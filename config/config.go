@@ -24,6 +24,14 @@ type EmailConfig struct {
 	NoreplyEmailName           string                      `yaml:"noreply_email_name"`
 	EmailVerificationEmailSubj string                      `yaml:"email_verification_email_subj"`
 	PasswordResetEmailSubj     string                      `yaml:"password_reset_email_subj"`
+	AccountLockedEmailSubj     string                      `yaml:"account_locked_email_subj"`
+	LoginLinkEmailSubj         string                      `yaml:"login_link_email_subj"`
+	EmailChangeEmailSubj       string                      `yaml:"email_change_email_subj"`
+	EmailChangeNoticeEmailSubj string                      `yaml:"email_change_notice_email_subj"`
+	InvitationEmailSubj        string                      `yaml:"invitation_email_subj"`
+	TeamJoinRequestEmailSubj   string                      `yaml:"team_join_request_email_subj"`
+	TeamJoinApprovedEmailSubj  string                      `yaml:"team_join_approved_email_subj"`
+	TeamJoinRejectedEmailSubj  string                      `yaml:"team_join_rejected_email_subj"`
 	TokenLifetime              int64                       `yaml:"token_lifetime"`
 }
 
@@ -34,19 +42,102 @@ type AuthConfig struct {
 	EmailVerificationRequired bool          `yaml:"email_verification_required"`
 	// The role that gets assigned to the user after they verify their email
 	DefaultEmailVerifiedRole role.UserRole `yaml:"default_email_verified_role"`
+	// Users with these roles have to set up two-factor authentication before they can log in
+	TwoFactorRequiredRoles []role.UserRole `yaml:"two_factor_required_roles"`
+	// How long the user has to enter their two-factor code after entering their password, in seconds
+	TwoFactorChallengeLifetime int64 `yaml:"two_factor_challenge_lifetime"`
+	// How long the links sent to users who want to log in without their password stay valid for, in seconds
+	LoginLinkLifetime int64 `yaml:"login_link_lifetime"`
+	// How long invitations stay valid for when no expiry is given, in seconds
+	InvitationLifetime int64 `yaml:"invitation_lifetime"`
+	// Rules the users' passwords have to follow
+	PasswordPolicy PasswordPolicyConfig `yaml:"password_policy"`
+}
+
+// PasswordPolicyConfig stores the rules the users' passwords have to follow
+type PasswordPolicyConfig struct {
+	MinLength int `yaml:"min_length"`
+	// Classes of characters passwords have to contain. Currently supported classes: lowercase, uppercase, letter, digit, symbol
+	RequiredCharacterClasses []string `yaml:"required_character_classes"`
+	// Stops users from using their name or email in their password
+	DisallowPersonalInfo bool `yaml:"disallow_personal_info"`
+	// Path from the project's root folder to a file with the SHA-1 hashes of breached passwords, one per line.
+	// Passwords in the file cannot be used. The check is skipped when no file is set
+	BreachedPasswordsFile string `yaml:"breached_passwords_file"`
+}
+
+// WebhookConfig stores the configuration to be used for webhook deliveries
+type WebhookConfig struct {
+	// How often pending deliveries are sent, in seconds
+	DeliveryInterval int64 `yaml:"delivery_interval"`
+	// How long to wait for a webhook's URL to respond, in seconds
+	DeliveryTimeout int64 `yaml:"delivery_timeout"`
+	// Number of times a delivery is attempted before it is marked as failed
+	MaxDeliveryAttempts int `yaml:"max_delivery_attempts"`
+	// Delay before the first retry, in seconds. The delay doubles after every failed attempt
+	RetryBackoff int64 `yaml:"retry_backoff"`
+}
+
+// TeamConfig stores the configuration of teams
+type TeamConfig struct {
+	// How long team join codes stay valid for after they are generated, in seconds. Codes do not expire when set to 0
+	JoinCodeLifetime int64 `yaml:"join_code_lifetime"`
+	// Number of wrong join codes a user can enter before they have to wait for the failure window to pass
+	MaxJoinCodeFailures int `yaml:"max_join_code_failures"`
+	// How long wrong join codes are remembered for, in seconds
+	JoinCodeFailureWindow int64 `yaml:"join_code_failure_window"`
+	// Most members a team can have, unless organisers set a different limit for it. Teams have no limit when set to 0
+	MaxMembers int `yaml:"max_members"`
+	// Judging tracks teams can enter. Teams can enter any track when empty
+	Tracks []string `yaml:"tracks"`
+}
+
+// LoginProtectionConfig stores the configuration of the brute-force protection on login
+type LoginProtectionConfig struct {
+	// Number of failed logins on an account after which further attempts on it get delayed
+	DelayAfterFailures int `yaml:"delay_after_failures"`
+	// Delay after the first delayed failure, in seconds. The delay doubles after every further failure
+	BaseDelay int64 `yaml:"base_delay"`
+	// Longest delay between login attempts, in seconds
+	MaxDelay int64 `yaml:"max_delay"`
+	// Number of failed logins after which an account gets locked
+	MaxAccountFailures int `yaml:"max_account_failures"`
+	// Number of failed logins after which an IP address gets locked
+	MaxIPFailures int `yaml:"max_ip_failures"`
+	// How long accounts and IP addresses stay locked for, in seconds
+	LockoutDuration int64 `yaml:"lockout_duration"`
+	// How long failed logins are remembered for, in seconds
+	FailureWindow int64 `yaml:"failure_window"`
+	// How long to wait before sending another login link to the same email, in seconds. 0 disables the cooldown
+	LoginLinkCooldown int64 `yaml:"login_link_cooldown"`
+}
+
+// WebAuthnConfig stores the configuration of passkey logins
+type WebAuthnConfig struct {
+	// The domain passkeys are registered for, it has to be the domain hs_auth is served from or one of its parents
+	RPID string `yaml:"rp_id"`
+	// The origins of the pages that passkeys can be used on, e.g. https://auth.unicsmcr.com
+	RPOrigins []string `yaml:"rp_origins"`
+	// How long the user has to complete the registration of a passkey or a login with it, in seconds
+	ChallengeLifetime int64 `yaml:"challenge_lifetime"`
 }
 
 // AppConfig is a struct to store non-private configuration for the project
 type AppConfig struct {
-	Name                 string              `yaml:"name"`
-	DomainName           string              `yaml:"domain_name"` // this is the domain under which all cookies will be stored
-	UseSecureCookies     bool                `yaml:"use_secure_cookies"`
-	AppURL               string              `yaml:"app_url"`
-	Email                EmailConfig         `yaml:"email"`
-	UserRole             role.UserRoleConfig `yaml:"role"`
-	DataPolicyURL        string              `yaml:"data_policy_url"`
-	TeamMembersSoftLimit uint                `yaml:"team_members_soft_limit"`
-	Auth                 AuthConfig          `yaml:"auth"`
+	Name                 string                `yaml:"name"`
+	DomainName           string                `yaml:"domain_name"` // this is the domain under which all cookies will be stored
+	UseSecureCookies     bool                  `yaml:"use_secure_cookies"`
+	AppURL               string                `yaml:"app_url"`
+	Email                EmailConfig           `yaml:"email"`
+	UserRole             role.UserRoleConfig   `yaml:"role"`
+	DataPolicyURL        string                `yaml:"data_policy_url"`
+	TeamMembersSoftLimit uint                  `yaml:"team_members_soft_limit"`
+	Auth                 AuthConfig            `yaml:"auth"`
+	Teams                TeamConfig            `yaml:"teams"`
+	Webhooks             WebhookConfig         `yaml:"webhooks"`
+	LoginProtection      LoginProtectionConfig `yaml:"login_protection"`
+	WebAuthn             WebAuthnConfig        `yaml:"webauthn"`
+	OAuthProviders       []OAuthProviderConfig `yaml:"oauth_providers"`
 }
 
 // NewAppConfig loads the project config from the config files based on the environment
@@ -67,5 +158,16 @@ func NewAppConfig(env *environment.Env) (*AppConfig, error) {
 	var cfg AppConfig
 
 	err = configProvider.Get("").Populate(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cfg.OAuthProviders {
+		err = cfg.OAuthProviders[i].applyPreset()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &cfg, nil
 }
@@ -54,3 +54,30 @@ func Test_NewAppConfig__should_return_correct_config_when_ENVIRONMENT_is_dev(t *
 
 	assert.Equal(t, expectedConfig.Name, actualConfig.Name)
 }
+
+func Test_applyPreset__should_fill_in_unset_fields(t *testing.T) {
+	cfg := OAuthProviderConfig{
+		Name:      "github",
+		Preset:    "github",
+		ClientID:  "testid",
+		NameField: "name",
+	}
+
+	err := cfg.applyPreset()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "GitHub", cfg.DisplayName)
+	assert.Equal(t, "testid", cfg.ClientID)
+	assert.Equal(t, oauthProviderPresets["github"].AuthURL, cfg.AuthURL)
+	assert.Equal(t, oauthProviderPresets["github"].Scopes, cfg.Scopes)
+	assert.Equal(t, "name", cfg.NameField)
+}
+
+func Test_applyPreset__should_return_err_when_preset_is_unknown(t *testing.T) {
+	cfg := OAuthProviderConfig{
+		Name:   "unknown",
+		Preset: "unknown",
+	}
+
+	assert.Error(t, cfg.applyPreset())
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+)
+
+// OAuthProviderConfig stores the configuration of an external OAuth2 identity provider
+// users can link to their accounts and log in with
+type OAuthProviderConfig struct {
+	// Identifies the provider in URLs and in the linked accounts, e.g. github
+	Name string `yaml:"name"`
+	// Name of the provider shown to users, e.g. GitHub
+	DisplayName string `yaml:"display_name"`
+	// Name of a preset that fills in the URLs, scopes and user info fields that are not set.
+	// Currently supported presets: github, discord
+	Preset string `yaml:"preset"`
+	// The provider's client id of hs_auth. The client secret is read from the OAUTH_<NAME>_CLIENT_SECRET env var
+	ClientID string `yaml:"client_id"`
+	AuthURL  string `yaml:"auth_url"`
+	TokenURL string `yaml:"token_url"`
+	// URL returning a JSON object with the user's account when requested with their access token
+	UserInfoURL string   `yaml:"user_info_url"`
+	Scopes      []string `yaml:"scopes"`
+	// Fields of the user info object holding the account's id, email and name
+	IDField    string `yaml:"id_field"`
+	EmailField string `yaml:"email_field"`
+	NameField  string `yaml:"name_field"`
+}
+
+var oauthProviderPresets = map[string]OAuthProviderConfig{
+	"github": {
+		DisplayName: "GitHub",
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		Scopes:      []string{"read:user", "user:email"},
+		IDField:     "id",
+		EmailField:  "email",
+		NameField:   "login",
+	},
+	"discord": {
+		DisplayName: "Discord",
+		AuthURL:     "https://discord.com/api/oauth2/authorize",
+		TokenURL:    "https://discord.com/api/oauth2/token",
+		UserInfoURL: "https://discord.com/api/users/@me",
+		Scopes:      []string{"identify", "email"},
+		IDField:     "id",
+		EmailField:  "email",
+		NameField:   "username",
+	},
+}
+
+// applyPreset fills in the fields that are not set with the values from the provider's preset
+func (c *OAuthProviderConfig) applyPreset() error {
+	if len(c.Preset) == 0 {
+		return nil
+	}
+
+	preset, ok := oauthProviderPresets[c.Preset]
+	if !ok {
+		return fmt.Errorf("unknown preset %s for OAuth provider %s", c.Preset, c.Name)
+	}
+
+	setIfEmpty(&c.DisplayName, preset.DisplayName)
+	setIfEmpty(&c.AuthURL, preset.AuthURL)
+	setIfEmpty(&c.TokenURL, preset.TokenURL)
+	setIfEmpty(&c.UserInfoURL, preset.UserInfoURL)
+	setIfEmpty(&c.IDField, preset.IDField)
+	setIfEmpty(&c.EmailField, preset.EmailField)
+	setIfEmpty(&c.NameField, preset.NameField)
+	if len(c.Scopes) == 0 {
+		c.Scopes = preset.Scopes
+	}
+
+	return nil
+}
+
+func setIfEmpty(field *string, value string) {
+	if len(*field) == 0 {
+		*field = value
+	}
+}
@@ -0,0 +1,134 @@
+package role
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"go.uber.org/config"
+)
+
+const roleConfigKey = "role"
+
+// LintIssue describes a problem found in a role configuration file
+type LintIssue struct {
+	Role    string
+	URI     string
+	Problem string
+}
+
+func (i LintIssue) String() string {
+	if len(i.URI) == 0 {
+		return fmt.Sprintf("role %s: %s", i.Role, i.Problem)
+	}
+	return fmt.Sprintf("role %s: uri %s: %s", i.Role, i.URI, i.Problem)
+}
+
+// LoadUserRoleConfig loads the role configuration stored in the given YAML file.
+// Returns an error if any of the URIs in the file cannot be parsed.
+func LoadUserRoleConfig(path string) (UserRoleConfig, error) {
+	provider, err := config.NewYAML(config.File(path))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read role config file")
+	}
+
+	var cfg UserRoleConfig
+	err = provider.Get(roleConfigKey).Populate(&cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse role config")
+	}
+
+	return cfg, nil
+}
+
+// LintUserRoleConfigFile strictly validates the role configuration in the given YAML file.
+// URIs within namespace that do not match any of registeredURIs are reported as unused, since they
+// will never grant access to anything. Returns an error only if the file itself cannot be read.
+func LintUserRoleConfigFile(path string, namespace string, registeredURIs common.UniformResourceIdentifiers) ([]LintIssue, error) {
+	provider, err := config.NewYAML(config.File(path))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read role config file")
+	}
+
+	var rawCfg map[string][]string
+	err = provider.Get(roleConfigKey).Populate(&rawCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse role config")
+	}
+
+	return lintRawUserRoleConfig(rawCfg, namespace, registeredURIs), nil
+}
+
+func lintRawUserRoleConfig(rawCfg map[string][]string, namespace string, registeredURIs common.UniformResourceIdentifiers) []LintIssue {
+	var issues []LintIssue
+
+	for _, knownRole := range knownRoles {
+		if _, exists := rawCfg[string(knownRole)]; !exists {
+			issues = append(issues, LintIssue{Role: string(knownRole), Problem: "role is not configured"})
+		}
+	}
+
+	roleNames := make([]string, 0, len(rawCfg))
+	for roleName := range rawCfg {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+
+	for _, roleName := range roleNames {
+		if !isKnownRole(UserRole(roleName)) {
+			issues = append(issues, LintIssue{Role: roleName, Problem: "role is not a known role"})
+		}
+
+		seenURIs := map[string]bool{}
+		for _, uriString := range rawCfg[roleName] {
+			if seenURIs[uriString] {
+				issues = append(issues, LintIssue{Role: roleName, URI: uriString, Problem: "uri is listed more than once"})
+				continue
+			}
+			seenURIs[uriString] = true
+
+			for _, problem := range lintURI(uriString, namespace, registeredURIs) {
+				issues = append(issues, LintIssue{Role: roleName, URI: uriString, Problem: problem})
+			}
+		}
+	}
+
+	return issues
+}
+
+func lintURI(uriString string, namespace string, registeredURIs common.UniformResourceIdentifiers) []string {
+	uri, err := common.NewURIFromString(uriString)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	pathComponents := strings.Split(uri.GetPath(), ":")
+	if pathComponents[0] != "hs" {
+		problems = append(problems, "path must start with hs")
+	}
+	for _, component := range pathComponents {
+		if len(component) == 0 {
+			problems = append(problems, "path contains an empty component")
+			break
+		}
+	}
+
+	for key, value := range uri.GetArguments() {
+		if _, err := regexp.Compile(value); err != nil {
+			problems = append(problems, fmt.Sprintf("argument %s is not a valid regular expression", key))
+		}
+	}
+
+	if len(namespace) > 0 && len(problems) == 0 && strings.HasPrefix(uri.GetPath()+":", namespace+":") {
+		pathOnlyURI, _ := common.NewURIFromString(uri.GetPath())
+		if !pathOnlyURI.IsSupersetOfAtLeastOne(registeredURIs) {
+			problems = append(problems, "uri does not match any registered operation or component")
+		}
+	}
+
+	return problems
+}
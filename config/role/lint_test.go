@@ -0,0 +1,188 @@
+package role
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+)
+
+const testNamespace = "hs:hs_auth"
+
+func testRegisteredURIs() common.UniformResourceIdentifiers {
+	getUser, _ := common.NewURIFromString("hs:hs_auth:api:v2:GetUser")
+	profilePage, _ := common.NewURIFromString("hs:hs_auth:frontend:ProfilePage")
+	return common.UniformResourceIdentifiers{getUser, profilePage}
+}
+
+func testValidRawRoleConfig() map[string][]string {
+	return map[string][]string{
+		"unverified": {"hs:hs_auth:frontend:ProfilePage"},
+		"applicant":  {"hs:hs_auth:api:v2:GetUser?path_id=me"},
+		"attendee":   {"hs:hs_apply:Dashboard"},
+		"volunteer":  {"hs:hs_auth:api"},
+		"organiser":  {"hs"},
+	}
+}
+
+func writeTestRoleConfigFile(t *testing.T, content string) (path string, cleanup func()) {
+	file, err := ioutil.TempFile("", "role*.yaml")
+	assert.NoError(t, err)
+
+	_, err = file.WriteString(content)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	return file.Name(), func() {
+		os.Remove(file.Name())
+	}
+}
+
+func Test_lintRawUserRoleConfig__should_return_no_issues_for_valid_config(t *testing.T) {
+	issues := lintRawUserRoleConfig(testValidRawRoleConfig(), testNamespace, testRegisteredURIs())
+	assert.Empty(t, issues)
+}
+
+func Test_lintRawUserRoleConfig__should_return_issue(t *testing.T) {
+	tests := []struct {
+		name          string
+		prep          func(cfg map[string][]string)
+		expectedIssue LintIssue
+	}{
+		{
+			name: "when known role is missing",
+			prep: func(cfg map[string][]string) {
+				delete(cfg, "volunteer")
+			},
+			expectedIssue: LintIssue{Role: "volunteer", Problem: "role is not configured"},
+		},
+		{
+			name: "when role is unknown",
+			prep: func(cfg map[string][]string) {
+				cfg["sponsor"] = []string{"hs"}
+			},
+			expectedIssue: LintIssue{Role: "sponsor", Problem: "role is not a known role"},
+		},
+		{
+			name: "when uri is duplicated",
+			prep: func(cfg map[string][]string) {
+				cfg["organiser"] = []string{"hs", "hs"}
+			},
+			expectedIssue: LintIssue{Role: "organiser", URI: "hs", Problem: "uri is listed more than once"},
+		},
+		{
+			name: "when uri does not start with hs",
+			prep: func(cfg map[string][]string) {
+				cfg["organiser"] = []string{"sh:hs_auth"}
+			},
+			expectedIssue: LintIssue{Role: "organiser", URI: "sh:hs_auth", Problem: "path must start with hs"},
+		},
+		{
+			name: "when uri has empty path component",
+			prep: func(cfg map[string][]string) {
+				cfg["organiser"] = []string{"hs::hs_auth"}
+			},
+			expectedIssue: LintIssue{Role: "organiser", URI: "hs::hs_auth", Problem: "path contains an empty component"},
+		},
+		{
+			name: "when uri argument is not a valid regex",
+			prep: func(cfg map[string][]string) {
+				cfg["organiser"] = []string{"hs:hs_apply?path_id=%28"}
+			},
+			expectedIssue: LintIssue{Role: "organiser", URI: "hs:hs_apply?path_id=%28", Problem: "argument path_id is not a valid regular expression"},
+		},
+		{
+			name: "when uri in namespace does not match registered uris",
+			prep: func(cfg map[string][]string) {
+				cfg["organiser"] = []string{"hs:hs_auth:api:v2:GetUserz"}
+			},
+			expectedIssue: LintIssue{Role: "organiser", URI: "hs:hs_auth:api:v2:GetUserz", Problem: "uri does not match any registered operation or component"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testValidRawRoleConfig()
+			tt.prep(cfg)
+
+			issues := lintRawUserRoleConfig(cfg, testNamespace, testRegisteredURIs())
+
+			assert.Equal(t, []LintIssue{tt.expectedIssue}, issues)
+		})
+	}
+}
+
+func Test_lintRawUserRoleConfig__should_return_issue_when_uri_cannot_be_parsed(t *testing.T) {
+	cfg := testValidRawRoleConfig()
+	cfg["organiser"] = []string{"hs?a=b?c=d"}
+
+	issues := lintRawUserRoleConfig(cfg, testNamespace, testRegisteredURIs())
+
+	assert.Len(t, issues, 1)
+	assert.Equal(t, "hs?a=b?c=d", issues[0].URI)
+}
+
+func Test_LintUserRoleConfigFile__should_lint_role_config_in_file(t *testing.T) {
+	path, cleanup := writeTestRoleConfigFile(t, `
+role:
+  unverified:
+    - "hs:hs_auth:frontend:ProfilePage"
+  applicant:
+    - "hs:hs_auth:frontend:ProfilePag"
+  attendee:
+    - "hs"
+  volunteer:
+    - "hs"
+  organiser:
+    - "hs"
+`)
+	defer cleanup()
+
+	issues, err := LintUserRoleConfigFile(path, testNamespace, testRegisteredURIs())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []LintIssue{
+		{Role: "applicant", URI: "hs:hs_auth:frontend:ProfilePag", Problem: "uri does not match any registered operation or component"},
+	}, issues)
+}
+
+func Test_LintUserRoleConfigFile__should_return_error_when_file_does_not_exist(t *testing.T) {
+	_, err := LintUserRoleConfigFile("does_not_exist.yaml", testNamespace, nil)
+	assert.Error(t, err)
+}
+
+func Test_LoadUserRoleConfig__should_return_error_when_uri_is_invalid(t *testing.T) {
+	path, cleanup := writeTestRoleConfigFile(t, `
+role:
+  organiser:
+    - "hs?a=b?c=d"
+`)
+	defer cleanup()
+
+	_, err := LoadUserRoleConfig(path)
+	assert.Error(t, err)
+}
+
+func Test_LoadUserRoleConfig__should_return_role_config(t *testing.T) {
+	path, cleanup := writeTestRoleConfigFile(t, `
+role:
+  organiser:
+    - "hs"
+`)
+	defer cleanup()
+
+	cfg, err := LoadUserRoleConfig(path)
+	assert.NoError(t, err)
+
+	expectedURI, _ := common.NewURIFromString("hs")
+	assert.Equal(t, UserRoleConfig{Organiser: common.UniformResourceIdentifiers{expectedURI}}, cfg)
+}
+
+func TestLintIssue_String(t *testing.T) {
+	assert.Equal(t, "role volunteer: role is not configured",
+		LintIssue{Role: "volunteer", Problem: "role is not configured"}.String())
+	assert.Equal(t, "role organiser: uri hs: uri is listed more than once",
+		LintIssue{Role: "organiser", URI: "hs", Problem: "uri is listed more than once"}.String())
+}
@@ -15,6 +15,16 @@ const Attendee UserRole = "attendee"
 const Volunteer UserRole = "volunteer"
 const Organiser UserRole = "organiser"
 
+// knownRoles are all the roles a user can be assigned
+var knownRoles = []UserRole{Unverified, Applicant, Attendee, Volunteer, Organiser}
+
+// KnownRoles returns all the roles a user can be assigned
+func KnownRoles() []UserRole {
+	roles := make([]UserRole, len(knownRoles))
+	copy(roles, knownRoles)
+	return roles
+}
+
 // RoleConfig stores the configuration to be used by the v2 authorizer
 type UserRoleConfig map[UserRole]common.UniformResourceIdentifiers
 
@@ -37,11 +47,19 @@ func (r *UserRole) UnmarshalJSON(data []byte) error {
 	// Ignore the error from Unquote as invalid roles will be caught below
 	role, _ := strconv.Unquote(string(data))
 
-	switch UserRole(role) {
-	case Unverified, Applicant, Attendee, Volunteer, Organiser:
+	if isKnownRole(UserRole(role)) {
 		*r = UserRole(role)
 		return nil
 	}
 
 	return common.ErrUnknownRole
 }
+
+func isKnownRole(role UserRole) bool {
+	for _, knownRole := range knownRoles {
+		if role == knownRole {
+			return true
+		}
+	}
+	return false
+}
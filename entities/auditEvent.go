@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditEventField string
+
+const (
+	AuditEventID        AuditEventField = "_id"
+	AuditEventTimestamp AuditEventField = "timestamp"
+	AuditEventAction    AuditEventField = "action"
+	AuditEventActor     AuditEventField = "actor"
+	AuditEventActorType AuditEventField = "actor_type"
+	AuditEventTarget    AuditEventField = "target"
+	AuditEventBefore    AuditEventField = "before"
+	AuditEventAfter     AuditEventField = "after"
+	AuditEventIP        AuditEventField = "ip"
+	AuditEventUserAgent AuditEventField = "user_agent"
+)
+
+// AuditAction is the type of a security-relevant event recorded in the audit log
+type AuditAction string
+
+const (
+	AuditActionLogin                   AuditAction = "login"
+	AuditActionLoginFailed             AuditAction = "login_failed"
+	AuditActionRoleSet                 AuditAction = "role_set"
+	AuditActionSpecialPermissionsSet   AuditAction = "special_permissions_set"
+	AuditActionPasswordSet             AuditAction = "password_set"
+	AuditActionUserUpdated             AuditAction = "user_updated"
+	AuditActionServiceTokenCreated     AuditAction = "service_token_created"
+	AuditActionServiceTokenInvalidated AuditAction = "service_token_invalidated"
+	AuditActionAccessDenied            AuditAction = "access_denied"
+	AuditActionWebhookCreated          AuditAction = "webhook_created"
+	AuditActionWebhookDeleted          AuditAction = "webhook_deleted"
+	AuditActionAccountUnlocked         AuditAction = "account_unlocked"
+	AuditActionTwoFactorEnabled        AuditAction = "two_factor_enabled"
+	AuditActionTwoFactorDisabled       AuditAction = "two_factor_disabled"
+	AuditActionTwoFactorFailed         AuditAction = "two_factor_failed"
+	AuditActionPasskeyRegistered       AuditAction = "passkey_registered"
+	AuditActionPasskeyDeleted          AuditAction = "passkey_deleted"
+	AuditActionIdentityLinked          AuditAction = "identity_linked"
+	AuditActionIdentityUnlinked        AuditAction = "identity_unlinked"
+	AuditActionEmailChangeRequested    AuditAction = "email_change_requested"
+	AuditActionEmailChanged            AuditAction = "email_changed"
+	AuditActionUserDataExported        AuditAction = "user_data_exported"
+	AuditActionUserDeleted             AuditAction = "user_deleted"
+	AuditActionUsersExported           AuditAction = "users_exported"
+	AuditActionTeamsExported           AuditAction = "teams_exported"
+	AuditActionUserImported            AuditAction = "user_imported"
+	AuditActionInvitationCreated       AuditAction = "invitation_created"
+	AuditActionInvitationRevoked       AuditAction = "invitation_revoked"
+	AuditActionTeamMemberLimitSet      AuditAction = "team_member_limit_set"
+)
+
+// AuditValues are the values of an audited object's fields, keyed by the fields' names
+type AuditValues map[string]interface{}
+
+// AuditEvent is the struct to store security-relevant events.
+// Actor is the id of the user or service token that performed the action, Target is
+// what the action was performed on, e.g. a user id, an email or a URI.
+// Before and After hold the values of the changed fields, keyed by the fields' names.
+type AuditEvent struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	Timestamp time.Time          `json:"timestamp" bson:"timestamp"`
+	Action    AuditAction        `json:"action" bson:"action"`
+	Actor     primitive.ObjectID `json:"actor,omitempty" bson:"actor,omitempty"`
+	ActorType string             `json:"actor_type,omitempty" bson:"actor_type,omitempty"`
+	Target    string             `json:"target,omitempty" bson:"target,omitempty"`
+	Before    AuditValues        `json:"before,omitempty" bson:"before,omitempty"`
+	After     AuditValues        `json:"after,omitempty" bson:"after,omitempty"`
+	IP        string             `json:"ip,omitempty" bson:"ip,omitempty"`
+	UserAgent string             `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+}
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExternalIdentityField string
+
+const (
+	ExternalIdentityID         ExternalIdentityField = "_id"
+	ExternalIdentityUserID     ExternalIdentityField = "user_id"
+	ExternalIdentityProvider   ExternalIdentityField = "provider"
+	ExternalIdentityExternalID ExternalIdentityField = "external_id"
+	ExternalIdentityEmail      ExternalIdentityField = "email"
+	ExternalIdentityName       ExternalIdentityField = "name"
+	ExternalIdentityLinkedAt   ExternalIdentityField = "linked_at"
+)
+
+// ExternalIdentity is the struct to store the accounts with external identity providers (e.g. GitHub)
+// that users linked to their accounts and can log in with.
+// Provider is the name of the provider in the config, ExternalID is the id of the account assigned by the provider.
+type ExternalIdentity struct {
+	ID         primitive.ObjectID `json:"_id" bson:"_id"`
+	UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Provider   string             `json:"provider" bson:"provider"`
+	ExternalID string             `json:"external_id" bson:"external_id"`
+	Email      string             `json:"email,omitempty" bson:"email,omitempty"`
+	Name       string             `json:"name,omitempty" bson:"name,omitempty"`
+	LinkedAt   time.Time          `json:"linked_at" bson:"linked_at"`
+}
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/unicsmcr/hs_auth/config/role"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InvitationField string
+
+const (
+	InvitationID        InvitationField = "_id"
+	InvitationCode      InvitationField = "code"
+	InvitationRole      InvitationField = "role"
+	InvitationTeam      InvitationField = "team"
+	InvitationMaxUses   InvitationField = "max_uses"
+	InvitationUses      InvitationField = "uses"
+	InvitationCreatedBy InvitationField = "created_by"
+	InvitationCreatedAt InvitationField = "created_at"
+	InvitationExpiresAt InvitationField = "expires_at"
+)
+
+// Invitation is the struct to store the links organisers share to let people register with a given role.
+// Code is the secret part of the link. Users registering with the invitation get Role instead of
+// the default role and are added to Team, if it is set. The invitation can be used MaxUses times
+// and is removed once ExpiresAt has passed.
+type Invitation struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	Code      string             `json:"code" bson:"code"`
+	Role      role.UserRole      `json:"role" bson:"role"`
+	Team      primitive.ObjectID `json:"team,omitempty" bson:"team,omitempty"`
+	MaxUses   int                `json:"max_uses" bson:"max_uses"`
+	Uses      int                `json:"uses" bson:"uses"`
+	CreatedBy primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
+}
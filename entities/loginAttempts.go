@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+type LoginAttemptsField string
+
+const (
+	LoginAttemptsKey           LoginAttemptsField = "_id"
+	LoginAttemptsFailures      LoginAttemptsField = "failures"
+	LoginAttemptsLastFailureAt LoginAttemptsField = "last_failure_at"
+	LoginAttemptsLockedUntil   LoginAttemptsField = "locked_until"
+	LoginAttemptsExpiresAt     LoginAttemptsField = "expires_at"
+)
+
+// LoginAttempts is the struct to store the recent failed logins of a single account or IP address,
+// the wrong team join codes recently entered by a single user and the last login link sent to an email.
+// Key identifies the account, IP address or user the failures were made by.
+// The failures are forgotten once ExpiresAt has passed.
+type LoginAttempts struct {
+	Key           string    `json:"key" bson:"_id"`
+	Failures      int       `json:"failures" bson:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at" bson:"last_failure_at"`
+	LockedUntil   time.Time `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MatchmakingProfileField string
+
+const (
+	MatchmakingProfileID        MatchmakingProfileField = "_id"
+	MatchmakingProfileUser      MatchmakingProfileField = "user"
+	MatchmakingProfileSkills    MatchmakingProfileField = "skills"
+	MatchmakingProfileInterests MatchmakingProfileField = "interests"
+	MatchmakingProfilePitch     MatchmakingProfileField = "pitch"
+	MatchmakingProfileUpdatedAt MatchmakingProfileField = "updated_at"
+)
+
+// MatchmakingProfile is the struct to store the profiles of users without a team who are looking for one.
+// Skills and interests are stored in lower case.
+type MatchmakingProfile struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	User      primitive.ObjectID `json:"user" bson:"user"`
+	Skills    []string           `json:"skills" bson:"skills"`
+	Interests []string           `json:"interests" bson:"interests"`
+	Pitch     string             `json:"pitch" bson:"pitch"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type TeamAdvertField string
+
+const (
+	TeamAdvertID        TeamAdvertField = "_id"
+	TeamAdvertTeam      TeamAdvertField = "team"
+	TeamAdvertSkills    TeamAdvertField = "skills"
+	TeamAdvertPitch     TeamAdvertField = "pitch"
+	TeamAdvertUpdatedAt TeamAdvertField = "updated_at"
+)
+
+// TeamAdvert is the struct to store the adverts of teams looking for more members.
+// Skills are the skills the team is looking for, stored in lower case.
+type TeamAdvert struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	Team      primitive.ObjectID `json:"team" bson:"team"`
+	Skills    []string           `json:"skills" bson:"skills"`
+	Pitch     string             `json:"pitch" bson:"pitch"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// TeamMatch is an advertised team suggested to a user looking for a team.
+// Score is the number of the user's skills the team is looking for.
+type TeamMatch struct {
+	Advert       TeamAdvert `json:"advert"`
+	TeamName     string     `json:"team_name"`
+	OpenSlots    int        `json:"open_slots,omitempty"`
+	SharedSkills []string   `json:"shared_skills"`
+	Score        int        `json:"score"`
+}
+
+// ProfileMatch is a user looking for a team suggested to an advertised team.
+// Score is the number of the skills the team is looking for that the user has.
+type ProfileMatch struct {
+	Profile      MatchmakingProfile `json:"profile"`
+	UserName     string             `json:"user_name"`
+	SharedSkills []string           `json:"shared_skills"`
+	Score        int                `json:"score"`
+}
@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+type MigrationField string
+
+const (
+	MigrationVersion     MigrationField = "_id"
+	MigrationDescription MigrationField = "description"
+	MigrationAppliedAt   MigrationField = "applied_at"
+)
+
+// Migration is the struct to store records of applied schema migrations
+type Migration struct {
+	Version     int       `json:"version" bson:"_id"`
+	Description string    `json:"description" bson:"description"`
+	AppliedAt   time.Time `json:"applied_at" bson:"applied_at"`
+}
@@ -1,20 +1,60 @@
 package entities
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type TeamField string
 
 const (
-	TeamID      TeamField = "_id"
-	TeamName    TeamField = "name"
-	TeamCreator TeamField = "creator"
+	TeamID                TeamField = "_id"
+	TeamName              TeamField = "name"
+	TeamCreator           TeamField = "creator"
+	TeamJoinCode          TeamField = "join_code"
+	TeamJoinCodeExpiresAt TeamField = "join_code_expires_at"
+	TeamPrivacy           TeamField = "privacy"
+	TeamMemberCount       TeamField = "member_count"
+	TeamMaxMembers        TeamField = "max_members"
+	TeamDescription       TeamField = "description"
+	TeamRepositoryURL     TeamField = "repository_url"
+	TeamDemoURL           TeamField = "demo_url"
+	TeamTrack             TeamField = "track"
+	TeamTableNumber       TeamField = "table_number"
+	TeamTags              TeamField = "tags"
+)
+
+// TeamPrivacySetting decides how users can join a team
+type TeamPrivacySetting string
+
+const (
+	// TeamOpen teams can be joined by anyone with the team's join code
+	TeamOpen TeamPrivacySetting = "open"
+	// TeamClosed teams can only be joined by requesting to join with the team's join code
+	// and having the request approved by the team's creator
+	TeamClosed TeamPrivacySetting = "closed"
 )
 
-// Team is the struct to store teams
+// Team is the struct to store teams.
+// Users join the team by entering JoinCode, which stops working once JoinCodeExpiresAt has passed, if it is set.
+// Teams without a Privacy setting are open.
+// MemberCount is kept up to date by the TeamService, so that teams cannot grow past MaxMembers members,
+// or the configured limit when MaxMembers is 0, even when users join them at the same time.
+// Track is the judging track the team has entered and TableNumber is 0 until the team is given a table.
 type Team struct {
-	ID      primitive.ObjectID `json:"_id" bson:"_id"`
-	Name    string             `json:"name"  bson:"name" validate:"required"`
-	Creator primitive.ObjectID `json:"creator" bson:"creator" validate:"required"`
+	ID                primitive.ObjectID `json:"_id" bson:"_id"`
+	Name              string             `json:"name"  bson:"name" validate:"required"`
+	Creator           primitive.ObjectID `json:"creator" bson:"creator" validate:"required"`
+	JoinCode          string             `json:"join_code,omitempty" bson:"join_code,omitempty"`
+	JoinCodeExpiresAt time.Time          `json:"join_code_expires_at,omitempty" bson:"join_code_expires_at,omitempty"`
+	Privacy           TeamPrivacySetting `json:"privacy,omitempty" bson:"privacy,omitempty"`
+	MemberCount       int                `json:"member_count" bson:"member_count"`
+	MaxMembers        int                `json:"max_members,omitempty" bson:"max_members,omitempty"`
+	Description       string             `json:"description,omitempty" bson:"description,omitempty"`
+	RepositoryURL     string             `json:"repository_url,omitempty" bson:"repository_url,omitempty"`
+	DemoURL           string             `json:"demo_url,omitempty" bson:"demo_url,omitempty"`
+	Track             string             `json:"track,omitempty" bson:"track,omitempty"`
+	TableNumber       int                `json:"table_number,omitempty" bson:"table_number,omitempty"`
+	Tags              []string           `json:"tags,omitempty" bson:"tags,omitempty"`
 }
@@ -0,0 +1,25 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TeamJoinRequestField string
+
+const (
+	TeamJoinRequestID        TeamJoinRequestField = "_id"
+	TeamJoinRequestTeam      TeamJoinRequestField = "team"
+	TeamJoinRequestUser      TeamJoinRequestField = "user"
+	TeamJoinRequestCreatedAt TeamJoinRequestField = "created_at"
+)
+
+// TeamJoinRequest is the struct to store requests of users to join closed teams.
+// The request is removed once the team's creator approves or rejects it.
+type TeamJoinRequest struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	Team      primitive.ObjectID `json:"team" bson:"team"`
+	User      primitive.ObjectID `json:"user" bson:"user"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -14,7 +14,7 @@ const (
 
 // ServiceToken is the struct to store tokens
 type ServiceToken struct {
-	ID      primitive.ObjectID `bson:"_id"`
-	JWT     string             `bson:"jwt" validate:"required"`
-	Creator primitive.ObjectID `bson:"creator" validate:"required"`
+	ID      primitive.ObjectID `json:"_id" bson:"_id"`
+	JWT     string             `json:"-" bson:"jwt" validate:"required"`
+	Creator primitive.ObjectID `json:"creator" bson:"creator" validate:"required"`
 }
@@ -12,11 +12,19 @@ const (
 	UserID                 UserField = "_id"
 	UserName               UserField = "name"
 	UserEmail              UserField = "email"
+	UserEmailVerified      UserField = "email_verified"
+	UserPendingEmail       UserField = "pending_email"
+	UserPendingEmailNonce  UserField = "pending_email_nonce"
 	UserPassword           UserField = "password"
 	UserAuthLevel          UserField = "auth_level"
 	UserRole               UserField = "role"
 	UserTeam               UserField = "team"
 	UserSpecialPermissions UserField = "special_permissions"
+
+	UserTwoFactorEnabled       UserField = "two_factor_enabled"
+	UserTwoFactorSecret        UserField = "two_factor_secret"
+	UserTwoFactorRecoveryCodes UserField = "two_factor_recovery_codes"
+	UserTwoFactorLastUsedStep  UserField = "two_factor_last_used_step"
 )
 
 // User is the struct to store registered users
@@ -30,4 +38,16 @@ type User struct {
 	// TODO: omit team from JSON when team is primitive.NilObjectID
 	Team               primitive.ObjectID                `json:"team,omitempty" bson:"team,omitempty"`
 	SpecialPermissions common.UniformResourceIdentifiers `json:"special_permissions" bson:"special_permissions,omitempty" validate:"required"`
+	TwoFactorEnabled   bool                              `json:"two_factor_enabled,omitempty" bson:"two_factor_enabled,omitempty"`
+	// The TOTP secret is set when the user starts enrolling into two-factor authentication
+	// and is only used for logins once TwoFactorEnabled is set
+	TwoFactorSecret string `json:"-" bson:"two_factor_secret,omitempty"`
+	// SHA-256 hashes of the unused recovery codes
+	TwoFactorRecoveryCodes []string `json:"-" bson:"two_factor_recovery_codes,omitempty"`
+	// The time step of the last accepted TOTP code, so that codes cannot be reused
+	TwoFactorLastUsedStep int64 `json:"-" bson:"two_factor_last_used_step,omitempty"`
+	// The address the user has asked to change their email to, which becomes their email once confirmed
+	PendingEmail string `json:"pending_email,omitempty" bson:"pending_email,omitempty"`
+	// A random value bound to the links confirming the pending email, so links sent for an earlier change cannot confirm it
+	PendingEmailNonce string `json:"-" bson:"pending_email_nonce,omitempty"`
 }
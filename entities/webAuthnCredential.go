@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebAuthnCredentialField string
+
+const (
+	WebAuthnCredentialID           WebAuthnCredentialField = "_id"
+	WebAuthnCredentialUserID       WebAuthnCredentialField = "user_id"
+	WebAuthnCredentialCredentialID WebAuthnCredentialField = "credential_id"
+	WebAuthnCredentialPublicKey    WebAuthnCredentialField = "public_key"
+	WebAuthnCredentialSignCount    WebAuthnCredentialField = "sign_count"
+	WebAuthnCredentialName         WebAuthnCredentialField = "name"
+	WebAuthnCredentialCreatedAt    WebAuthnCredentialField = "created_at"
+	WebAuthnCredentialLastUsedAt   WebAuthnCredentialField = "last_used_at"
+)
+
+// WebAuthnCredential is the struct to store the passkeys users registered with WebAuthn.
+// CredentialID is the base64url encoded id assigned to the credential by the authenticator,
+// PublicKey is the credential's PKIX, ASN.1 DER encoded public key.
+type WebAuthnCredential struct {
+	ID           primitive.ObjectID `json:"_id" bson:"_id"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+	CredentialID string             `json:"credential_id" bson:"credential_id"`
+	PublicKey    []byte             `json:"-" bson:"public_key"`
+	// The signature counter reported by the authenticator on the last use of the credential,
+	// a counter that does not increase indicates a cloned authenticator
+	SignCount  int64     `json:"-" bson:"sign_count"`
+	Name       string    `json:"name" bson:"name"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+}
+
+type WebAuthnChallengeField string
+
+const (
+	WebAuthnChallengeID        WebAuthnChallengeField = "_id"
+	WebAuthnChallengeChallenge WebAuthnChallengeField = "challenge"
+	WebAuthnChallengeCeremony  WebAuthnChallengeField = "ceremony"
+	WebAuthnChallengeUserID    WebAuthnChallengeField = "user_id"
+	WebAuthnChallengeExpiresAt WebAuthnChallengeField = "expires_at"
+)
+
+// WebAuthnCeremony is the type of a WebAuthn ceremony
+type WebAuthnCeremony string
+
+const (
+	WebAuthnCeremonyRegistration WebAuthnCeremony = "registration"
+	WebAuthnCeremonyLogin        WebAuthnCeremony = "login"
+)
+
+// WebAuthnChallenge is the struct to store the challenges of WebAuthn ceremonies that have not been completed yet.
+// Challenge is base64url encoded, UserID is not set for logins in which the user is not known in advance.
+// Every challenge can only be used once and is removed once ExpiresAt has passed.
+type WebAuthnChallenge struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	Challenge string             `json:"challenge" bson:"challenge"`
+	Ceremony  WebAuthnCeremony   `json:"ceremony" bson:"ceremony"`
+	UserID    primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookField string
+
+const (
+	WebhookID        WebhookField = "_id"
+	WebhookURL       WebhookField = "url"
+	WebhookSecret    WebhookField = "secret"
+	WebhookEvents    WebhookField = "events"
+	WebhookCreatedAt WebhookField = "created_at"
+)
+
+// WebhookEvent is the type of a user or team lifecycle event that can be delivered to webhooks
+type WebhookEvent string
+
+const (
+	WebhookEventUserRegistered    WebhookEvent = "user.registered"
+	WebhookEventUserEmailVerified WebhookEvent = "user.email_verified"
+	WebhookEventUserRoleChanged   WebhookEvent = "user.role_changed"
+	WebhookEventUserDeleted       WebhookEvent = "user.deleted"
+	WebhookEventTeamCreated       WebhookEvent = "team.created"
+	WebhookEventTeamMemberJoined  WebhookEvent = "team.member_joined"
+	WebhookEventTeamMemberLeft    WebhookEvent = "team.member_left"
+	WebhookEventTeamUpdated       WebhookEvent = "team.updated"
+	WebhookEventTeamDeleted       WebhookEvent = "team.deleted"
+)
+
+// KnownWebhookEvents are all the events webhooks can subscribe to
+var KnownWebhookEvents = []WebhookEvent{
+	WebhookEventUserRegistered,
+	WebhookEventUserEmailVerified,
+	WebhookEventUserRoleChanged,
+	WebhookEventUserDeleted,
+	WebhookEventTeamCreated,
+	WebhookEventTeamMemberJoined,
+	WebhookEventTeamMemberLeft,
+	WebhookEventTeamUpdated,
+	WebhookEventTeamDeleted,
+}
+
+// IsKnownWebhookEvent checks whether webhooks can subscribe to the given event
+func IsKnownWebhookEvent(event WebhookEvent) bool {
+	for _, knownEvent := range KnownWebhookEvents {
+		if event == knownEvent {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is the struct to store webhook subscriptions.
+// Secret is used to sign the deliveries sent to URL, it is only
+// returned to the client when the webhook is created.
+type Webhook struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id"`
+	URL       string             `json:"url" bson:"url" validate:"required,url"`
+	Secret    string             `json:"secret,omitempty" bson:"secret"`
+	Events    []WebhookEvent     `json:"events" bson:"events" validate:"required"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type WebhookDeliveryField string
+
+const (
+	WebhookDeliveryID             WebhookDeliveryField = "_id"
+	WebhookDeliveryWebhookID      WebhookDeliveryField = "webhook_id"
+	WebhookDeliveryEvent          WebhookDeliveryField = "event"
+	WebhookDeliveryPayload        WebhookDeliveryField = "payload"
+	WebhookDeliveryStatus         WebhookDeliveryField = "status"
+	WebhookDeliveryAttempts       WebhookDeliveryField = "attempts"
+	WebhookDeliveryNextAttemptAt  WebhookDeliveryField = "next_attempt_at"
+	WebhookDeliveryLastStatusCode WebhookDeliveryField = "last_status_code"
+	WebhookDeliveryLastError      WebhookDeliveryField = "last_error"
+	WebhookDeliveryCreatedAt      WebhookDeliveryField = "created_at"
+	WebhookDeliveredAt            WebhookDeliveryField = "delivered_at"
+)
+
+// WebhookDeliveryState is the state of a webhook delivery
+type WebhookDeliveryState string
+
+const (
+	// WebhookDeliveryPending deliveries are waiting to be (re)sent
+	WebhookDeliveryPending WebhookDeliveryState = "pending"
+	// WebhookDeliveryDelivered deliveries were accepted by the webhook's URL
+	WebhookDeliveryDelivered WebhookDeliveryState = "delivered"
+	// WebhookDeliveryFailed deliveries will not be retried any more
+	WebhookDeliveryFailed WebhookDeliveryState = "failed"
+)
+
+// WebhookDelivery is the struct to store a single event sent to a webhook.
+// Deliveries are kept after they are sent and make up the delivery log.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID   `json:"_id" bson:"_id"`
+	WebhookID      primitive.ObjectID   `json:"webhook_id" bson:"webhook_id"`
+	Event          WebhookEvent         `json:"event" bson:"event"`
+	Payload        string               `json:"payload" bson:"payload"`
+	Status         WebhookDeliveryState `json:"status" bson:"status"`
+	Attempts       int                  `json:"attempts" bson:"attempts"`
+	NextAttemptAt  time.Time            `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	LastStatusCode int                  `json:"last_status_code,omitempty" bson:"last_status_code,omitempty"`
+	LastError      string               `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt      time.Time            `json:"created_at" bson:"created_at"`
+	DeliveredAt    time.Time            `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+}
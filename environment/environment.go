@@ -1,7 +1,9 @@
 package environment
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"go.uber.org/zap"
 )
@@ -25,6 +27,14 @@ const (
 	SMTPPort       = "SMTP_PORT"
 )
 
+// oauthEnvVarPrefix is the prefix of the env vars storing the secrets of OAuth providers
+const oauthEnvVarPrefix = "OAUTH_"
+
+// OAuthClientSecret returns the name of the env var storing the client secret of the OAuth provider with the given name
+func OAuthClientSecret(providerName string) string {
+	return fmt.Sprintf("%s%s_CLIENT_SECRET", oauthEnvVarPrefix, strings.ToUpper(providerName))
+}
+
 // NewEnv creates an Env with loaded environment variables
 func NewEnv(logger *zap.Logger) *Env {
 	env := Env{
@@ -43,6 +53,16 @@ func NewEnv(logger *zap.Logger) *Env {
 			SMTPPort:       valueOfEnvVar(logger, SMTPPort),
 		},
 	}
+
+	// the OAuth providers are configurable, so their secrets are loaded by prefix
+	for _, envVar := range os.Environ() {
+		if !strings.HasPrefix(envVar, oauthEnvVarPrefix) {
+			continue
+		}
+		parts := strings.SplitN(envVar, "=", 2)
+		env.vars[parts[0]] = parts[1]
+	}
+
 	return &env
 }
 
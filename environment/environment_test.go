@@ -152,3 +152,12 @@ func Test_Get__should_return_not_set_when_env_var_is_not_set(t *testing.T) {
 
 	assert.Equal(t, DefaultEnvVarValue, env.Get("not set var"))
 }
+
+func Test_NewEnv__should_load_oauth_client_secrets(t *testing.T) {
+	restoreVars := testutils.SetEnvVars(map[string]string{OAuthClientSecret("github"): "testsecret"})
+	defer restoreVars()
+
+	env := NewEnv(zap.NewNop())
+
+	assert.Equal(t, "testsecret", env.Get("OAUTH_GITHUB_CLIENT_SECRET"))
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
 
+	"github.com/unicsmcr/hs_auth/commands"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -16,11 +19,17 @@ type heartbeatResponse struct {
 var db *mongo.Database
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(commands.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	server, err := InitializeServer()
 	if err != nil {
 		log.Fatal(fmt.Sprintf("could not create server: %s", err))
 	}
 
+	go server.WebhookService.RunDeliveryWorker(context.Background())
+
 	err = server.Run(fmt.Sprintf(":%s", server.Port))
 	if err != nil {
 		log.Fatal(fmt.Sprintf("could not start server: %s", err))
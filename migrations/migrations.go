@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const duplicateKeyErrorCode = 11000
+
+// Migration is a versioned change to the data stored in the database.
+// Up must be idempotent, since several instances of the app starting at the same time may apply it concurrently.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, logger *zap.Logger, db *mongo.Database) error
+}
+
+// SchemaVersion is the version of the last migration applied to the database
+type SchemaVersion int
+
+// registeredMigrations are all migrations known to the app, in the order they get applied.
+// New migrations must be appended with a version greater than that of the last one.
+var registeredMigrations = []Migration{
+	specialPermissionsToArrayMigration,
+	teamJoinCodesMigration,
+	teamMemberCountsMigration,
+}
+
+// ApplyMigrations applies all registered migrations that have not been recorded in the migrations collection yet
+// and returns the resulting schema version
+func ApplyMigrations(logger *zap.Logger, db *mongo.Database, migrationRepository *repositories.MigrationRepository, timeProvider utils.TimeProvider) (SchemaVersion, error) {
+	return applyMigrations(context.Background(), logger, db, migrationRepository, timeProvider, registeredMigrations)
+}
+
+func applyMigrations(ctx context.Context, logger *zap.Logger, db *mongo.Database, migrationRepository *repositories.MigrationRepository,
+	timeProvider utils.TimeProvider, migrations []Migration) (SchemaVersion, error) {
+	err := validateMigrations(migrations)
+	if err != nil {
+		return 0, err
+	}
+
+	appliedVersions, err := getAppliedVersions(ctx, migrationRepository)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, migration := range pendingMigrations(migrations, appliedVersions) {
+		logger.Info("applying migration", zap.Int("version", migration.Version), zap.String("description", migration.Description))
+
+		err = migration.Up(ctx, logger, db)
+		if err != nil {
+			return 0, errors.Wrap(err, fmt.Sprintf("could not apply migration %d", migration.Version))
+		}
+
+		_, err = migrationRepository.InsertOne(ctx, entities.Migration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   timeProvider.Now(),
+		})
+		if isDuplicateKeyError(err) {
+			logger.Info("migration was recorded by another instance", zap.Int("version", migration.Version))
+		} else if err != nil {
+			return 0, errors.Wrap(err, fmt.Sprintf("could not record migration %d", migration.Version))
+		}
+	}
+
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return SchemaVersion(migrations[len(migrations)-1].Version), nil
+}
+
+func getAppliedVersions(ctx context.Context, migrationRepository *repositories.MigrationRepository) (map[int]bool, error) {
+	cur, err := migrationRepository.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for applied migrations")
+	}
+	defer cur.Close(ctx)
+
+	appliedVersions := map[int]bool{}
+	for cur.Next(ctx) {
+		var migration entities.Migration
+		err = cur.Decode(&migration)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode applied migration")
+		}
+		appliedVersions[migration.Version] = true
+	}
+
+	return appliedVersions, nil
+}
+
+func validateMigrations(migrations []Migration) error {
+	previousVersion := 0
+	for _, migration := range migrations {
+		if migration.Version <= previousVersion {
+			return errors.New(fmt.Sprintf("migration %d must have a version greater than %d", migration.Version, previousVersion))
+		}
+		previousVersion = migration.Version
+	}
+
+	return nil
+}
+
+func pendingMigrations(migrations []Migration, appliedVersions map[int]bool) []Migration {
+	var pending []Migration
+	for _, migration := range migrations {
+		if !appliedVersions[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending
+}
+
+func isDuplicateKeyError(err error) bool {
+	writeException, ok := err.(mongo.WriteException)
+	if !ok {
+		return false
+	}
+
+	for _, writeError := range writeException.WriteErrors {
+		if writeError.Code == duplicateKeyErrorCode {
+			return true
+		}
+	}
+	return false
+}
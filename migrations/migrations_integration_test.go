@@ -0,0 +1,179 @@
+// +build integration
+
+package migrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func setupMigrationsTest(t *testing.T) (*mongo.Database, *repositories.MigrationRepository, func()) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	mRepo, err := repositories.NewMigrationRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	return db, mRepo, func() {
+		db.Collection("migrations").Drop(context.Background())
+		db.Collection("users").Drop(context.Background())
+		db.Collection("teams").Drop(context.Background())
+	}
+}
+
+func Test_applyMigrations__should_apply_pending_migrations_and_record_them(t *testing.T) {
+	db, mRepo, cleanup := setupMigrationsTest(t)
+	defer cleanup()
+
+	var appliedVersions []int
+	migrationUp := func(version int) func(context.Context, *zap.Logger, *mongo.Database) error {
+		return func(context.Context, *zap.Logger, *mongo.Database) error {
+			appliedVersions = append(appliedVersions, version)
+			return nil
+		}
+	}
+	_, err := mRepo.InsertOne(context.Background(), entities.Migration{Version: 1})
+	assert.NoError(t, err)
+
+	version, err := applyMigrations(context.Background(), zap.NewNop(), db, mRepo, utils.NewTimeProvider(), []Migration{
+		{Version: 1, Up: migrationUp(1)},
+		{Version: 2, Up: migrationUp(2)},
+		{Version: 3, Up: migrationUp(3)},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, SchemaVersion(3), version)
+	assert.Equal(t, []int{2, 3}, appliedVersions)
+	count, err := mRepo.CountDocuments(context.Background(), bson.M{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func Test_applyMigrations__should_not_record_failed_migration(t *testing.T) {
+	db, mRepo, cleanup := setupMigrationsTest(t)
+	defer cleanup()
+
+	_, err := applyMigrations(context.Background(), zap.NewNop(), db, mRepo, utils.NewTimeProvider(), []Migration{
+		{Version: 1, Up: func(context.Context, *zap.Logger, *mongo.Database) error {
+			return errors.New("random error")
+		}},
+	})
+	assert.Error(t, err)
+
+	count, err := mRepo.CountDocuments(context.Background(), bson.M{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func Test_convertSpecialPermissionsToArray__should_convert_legacy_special_permissions(t *testing.T) {
+	db, _, cleanup := setupMigrationsTest(t)
+	defer cleanup()
+
+	legacyUserID := primitive.NewObjectID()
+	emptyUserID := primitive.NewObjectID()
+	_, err := db.Collection("users").InsertMany(context.Background(), []interface{}{
+		bson.M{"_id": legacyUserID, "special_permissions": "hs:hs_auth:api:v2:GetUser?path_id=a%7B1%2C3%7D,hs:hs_auth:frontend"},
+		bson.M{"_id": emptyUserID, "special_permissions": ""},
+	})
+	assert.NoError(t, err)
+
+	err = convertSpecialPermissionsToArray(context.Background(), zap.NewNop(), db)
+	assert.NoError(t, err)
+	// applying the migration again should be a no-op
+	err = convertSpecialPermissionsToArray(context.Background(), zap.NewNop(), db)
+	assert.NoError(t, err)
+
+	var legacyUser entities.User
+	err = db.Collection("users").FindOne(context.Background(), bson.M{"_id": legacyUserID}).Decode(&legacyUser)
+	assert.NoError(t, err)
+	uri1, _ := common.NewURIFromString("hs:hs_auth:api:v2:GetUser?path_id=a%7B1%2C3%7D")
+	uri2, _ := common.NewURIFromString("hs:hs_auth:frontend")
+	assert.Equal(t, common.UniformResourceIdentifiers{uri1, uri2}, legacyUser.SpecialPermissions)
+
+	count, err := db.Collection("users").CountDocuments(context.Background(), bson.M{"special_permissions": bson.M{"$type": "string"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	count, err = db.Collection("users").CountDocuments(context.Background(), bson.M{"_id": emptyUserID, "special_permissions": bson.M{"$exists": false}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func Test_generateTeamJoinCodes__should_generate_join_codes_for_teams_without_one(t *testing.T) {
+	db, _, cleanup := setupMigrationsTest(t)
+	defer cleanup()
+
+	legacyTeamID := primitive.NewObjectID()
+	_, err := db.Collection("teams").InsertMany(context.Background(), []interface{}{
+		bson.M{"_id": legacyTeamID, "name": "legacy team"},
+		bson.M{"_id": primitive.NewObjectID(), "name": "new team", "join_code": "ABCD2345"},
+	})
+	assert.NoError(t, err)
+
+	err = generateTeamJoinCodes(context.Background(), zap.NewNop(), db)
+	assert.NoError(t, err)
+
+	var legacyTeam entities.Team
+	err = db.Collection("teams").FindOne(context.Background(), bson.M{"_id": legacyTeamID}).Decode(&legacyTeam)
+	assert.NoError(t, err)
+	assert.Len(t, legacyTeam.JoinCode, teamJoinCodeLength)
+
+	// applying the migration again should be a no-op
+	err = generateTeamJoinCodes(context.Background(), zap.NewNop(), db)
+	assert.NoError(t, err)
+
+	var unchangedTeam entities.Team
+	err = db.Collection("teams").FindOne(context.Background(), bson.M{"_id": legacyTeamID}).Decode(&unchangedTeam)
+	assert.NoError(t, err)
+	assert.Equal(t, legacyTeam.JoinCode, unchangedTeam.JoinCode)
+
+	count, err := db.Collection("teams").CountDocuments(context.Background(), bson.M{"join_code": "ABCD2345"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func Test_countTeamMembers__should_count_members_of_teams_without_member_count(t *testing.T) {
+	db, _, cleanup := setupMigrationsTest(t)
+	defer cleanup()
+
+	legacyTeamID := primitive.NewObjectID()
+	countedTeamID := primitive.NewObjectID()
+	_, err := db.Collection("teams").InsertMany(context.Background(), []interface{}{
+		bson.M{"_id": legacyTeamID, "name": "legacy team"},
+		bson.M{"_id": countedTeamID, "name": "new team", "member_count": 5},
+	})
+	assert.NoError(t, err)
+
+	_, err = db.Collection("users").InsertMany(context.Background(), []interface{}{
+		bson.M{"_id": primitive.NewObjectID(), "team": legacyTeamID},
+		bson.M{"_id": primitive.NewObjectID(), "team": legacyTeamID},
+		bson.M{"_id": primitive.NewObjectID(), "team": countedTeamID},
+	})
+	assert.NoError(t, err)
+
+	err = countTeamMembers(context.Background(), zap.NewNop(), db)
+	assert.NoError(t, err)
+
+	var legacyTeam entities.Team
+	err = db.Collection("teams").FindOne(context.Background(), bson.M{"_id": legacyTeamID}).Decode(&legacyTeam)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, legacyTeam.MemberCount)
+
+	var countedTeam entities.Team
+	err = db.Collection("teams").FindOne(context.Background(), bson.M{"_id": countedTeamID}).Decode(&countedTeam)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, countedTeam.MemberCount)
+}
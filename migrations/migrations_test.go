@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func Test_registeredMigrations__should_be_valid(t *testing.T) {
+	assert.NoError(t, validateMigrations(registeredMigrations))
+}
+
+func Test_validateMigrations__should_return_error(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []Migration
+	}{
+		{
+			name:       "when version is not positive",
+			migrations: []Migration{{Version: 0}},
+		},
+		{
+			name:       "when versions are duplicated",
+			migrations: []Migration{{Version: 1}, {Version: 1}},
+		},
+		{
+			name:       "when versions are not in order",
+			migrations: []Migration{{Version: 2}, {Version: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, validateMigrations(tt.migrations))
+		})
+	}
+}
+
+func Test_pendingMigrations__should_return_migrations_not_applied_yet_in_order(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+
+	pending := pendingMigrations(migrations, map[int]bool{2: true})
+
+	assert.Equal(t, []Migration{{Version: 1}, {Version: 3}}, pending)
+}
+
+func Test_isDuplicateKeyError(t *testing.T) {
+	assert.True(t, isDuplicateKeyError(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: duplicateKeyErrorCode}}}))
+	assert.False(t, isDuplicateKeyError(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 1}}}))
+	assert.False(t, isDuplicateKeyError(errors.New("random error")))
+	assert.False(t, isDuplicateKeyError(nil))
+}
+
+func Test_parseLegacySpecialPermissions(t *testing.T) {
+	uri1, _ := common.NewURIFromString("hs:hs_auth:api:v2:GetUser?path_id=a%7B1%2C3%7D")
+	uri2, _ := common.NewURIFromString("hs:hs_auth:frontend")
+
+	tests := []struct {
+		name                string
+		specialPermissions  string
+		expectedPermissions common.UniformResourceIdentifiers
+	}{
+		{
+			name:                "empty string",
+			specialPermissions:  "",
+			expectedPermissions: common.UniformResourceIdentifiers{},
+		},
+		{
+			name:                "multiple uris",
+			specialPermissions:  "hs:hs_auth:api:v2:GetUser?path_id=a%7B1%2C3%7D,hs:hs_auth:frontend",
+			expectedPermissions: common.UniformResourceIdentifiers{uri1, uri2},
+		},
+		{
+			name:                "invalid uri is dropped",
+			specialPermissions:  "#hs:test??####,hs:hs_auth:frontend",
+			expectedPermissions: common.UniformResourceIdentifiers{uri2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := parseLegacySpecialPermissions(zap.NewNop(), primitive.NewObjectID(), tt.specialPermissions)
+			assert.Equal(t, tt.expectedPermissions, permissions)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/entities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// specialPermissionsToArrayMigration converts users' special permissions from the legacy
+// comma separated string to an array of URIs
+var specialPermissionsToArrayMigration = Migration{
+	Version:     1,
+	Description: "store special permissions as an array of URIs",
+	Up:          convertSpecialPermissionsToArray,
+}
+
+type legacySpecialPermissions struct {
+	ID                 primitive.ObjectID `bson:"_id"`
+	SpecialPermissions string             `bson:"special_permissions"`
+}
+
+func convertSpecialPermissionsToArray(ctx context.Context, logger *zap.Logger, db *mongo.Database) error {
+	users := db.Collection("users")
+
+	cur, err := users.Find(ctx, bson.M{
+		string(entities.UserSpecialPermissions): bson.M{"$type": "string"},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not query for users with legacy special permissions")
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var user legacySpecialPermissions
+		err = cur.Decode(&user)
+		if err != nil {
+			return errors.Wrap(err, "could not decode user with legacy special permissions")
+		}
+
+		update := bson.M{"$unset": bson.M{string(entities.UserSpecialPermissions): ""}}
+		permissions := parseLegacySpecialPermissions(logger, user.ID, user.SpecialPermissions)
+		if len(permissions) > 0 {
+			update = bson.M{"$set": bson.M{string(entities.UserSpecialPermissions): permissions}}
+		}
+
+		_, err = users.UpdateOne(ctx, bson.M{string(entities.UserID): user.ID}, update)
+		if err != nil {
+			return errors.Wrap(err, "could not update user's special permissions")
+		}
+	}
+
+	return cur.Err()
+}
+
+// parseLegacySpecialPermissions parses the URIs in a legacy special permissions string.
+// URIs that cannot be parsed would have stopped the user from being read at all, so they are dropped.
+func parseLegacySpecialPermissions(logger *zap.Logger, userID primitive.ObjectID, specialPermissions string) common.UniformResourceIdentifiers {
+	permissions := common.UniformResourceIdentifiers{}
+	for _, uriString := range common.SplitLegacyURIList(specialPermissions) {
+		uri, err := common.NewURIFromString(uriString)
+		if err != nil {
+			logger.Warn("dropping invalid special permission", zap.String("user id", userID.Hex()), zap.String("uri", uriString), zap.Error(err))
+			continue
+		}
+		permissions = append(permissions, uri)
+	}
+
+	return permissions
+}
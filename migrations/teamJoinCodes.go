@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// teamJoinCodeLength is the length of the join codes generated by the team service
+const teamJoinCodeLength = 8
+
+// teamJoinCodesMigration gives a join code to the teams created before users joined teams with codes.
+// The codes do not expire, their teams' creators can regenerate them to get one that does
+var teamJoinCodesMigration = Migration{
+	Version:     2,
+	Description: "generate join codes for existing teams",
+	Up:          generateTeamJoinCodes,
+}
+
+func generateTeamJoinCodes(ctx context.Context, logger *zap.Logger, db *mongo.Database) error {
+	teams := db.Collection("teams")
+
+	cur, err := teams.Find(ctx, bson.M{
+		string(entities.TeamJoinCode): bson.M{"$exists": false},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not query for teams without join codes")
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var team entities.Team
+		err = cur.Decode(&team)
+		if err != nil {
+			return errors.Wrap(err, "could not decode team without join code")
+		}
+
+		joinCode, err := utils.GenerateRandomCode(teamJoinCodeLength)
+		if err != nil {
+			return errors.Wrap(err, "could not generate join code")
+		}
+
+		// the join code is only set if another instance applying the migration has not set one already
+		_, err = teams.UpdateOne(ctx, bson.M{
+			string(entities.TeamID):       team.ID,
+			string(entities.TeamJoinCode): bson.M{"$exists": false},
+		}, bson.M{
+			"$set": bson.M{string(entities.TeamJoinCode): joinCode},
+		})
+		if isDuplicateKeyError(err) {
+			// codes are random, so a collision is very unlikely and the migration can simply be retried
+			return errors.Wrap(err, "generated join code is already in use")
+		} else if err != nil {
+			return errors.Wrap(err, "could not set team's join code")
+		}
+		logger.Debug("generated join code for team", zap.String("team id", team.ID.Hex()))
+	}
+
+	return cur.Err()
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// teamMemberCountsMigration counts the members of the teams created before team sizes were limited,
+// so that the limit applies to them too
+var teamMemberCountsMigration = Migration{
+	Version:     3,
+	Description: "count members of existing teams",
+	Up:          countTeamMembers,
+}
+
+func countTeamMembers(ctx context.Context, logger *zap.Logger, db *mongo.Database) error {
+	teams := db.Collection("teams")
+	users := db.Collection("users")
+
+	cur, err := teams.Find(ctx, bson.M{
+		string(entities.TeamMemberCount): bson.M{"$exists": false},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not query for teams without member counts")
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var team entities.Team
+		err = cur.Decode(&team)
+		if err != nil {
+			return errors.Wrap(err, "could not decode team without member count")
+		}
+
+		memberCount, err := users.CountDocuments(ctx, bson.M{
+			string(entities.UserTeam): team.ID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not count team's members")
+		}
+
+		// the count is only set if another instance applying the migration has not set one already,
+		// since users may have joined the team in the meantime
+		_, err = teams.UpdateOne(ctx, bson.M{
+			string(entities.TeamID):          team.ID,
+			string(entities.TeamMemberCount): bson.M{"$exists": false},
+		}, bson.M{
+			"$set": bson.M{string(entities.TeamMemberCount): memberCount},
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not set team's member count")
+		}
+		logger.Debug("counted team's members", zap.String("team id", team.ID.Hex()), zap.Int64("members", memberCount))
+	}
+
+	return cur.Err()
+}
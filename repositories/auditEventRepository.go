@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// AuditEventRepository is the repository for AuditEvent objects
+type AuditEventRepository struct {
+	*mongo.Collection
+}
+
+// NewAuditEventRepository creates a new AuditEventRepository
+func NewAuditEventRepository(db *mongo.Database) (*AuditEventRepository, error) {
+	_, err := db.Collection("audit_events").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bsonx.Doc{{"timestamp", bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{"actor", bsonx.Int32(1)}, {"timestamp", bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{"target", bsonx.Int32(1)}, {"timestamp", bsonx.Int32(-1)}}},
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEventRepository{
+		Collection: db.Collection("audit_events"),
+	}, nil
+}
@@ -0,0 +1,44 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_NewAuditEventRepository__should_return_audit_events_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	aRepo, err := NewAuditEventRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "audit_events", aRepo.Name())
+	db.Collection("audit_events").Drop(context.Background())
+}
+
+func Test_NewAuditEventRepository__create_required_number_of_indexes(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	_, err := NewAuditEventRepository(db)
+	assert.NoError(t, err)
+
+	cur, err := db.Collection("audit_events").Indexes().List(context.Background())
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var noOfIndexes int
+	for cur.Next(context.Background()) {
+		var index mongo.IndexModel
+		err = cur.Decode(&index)
+		assert.NoError(t, err)
+		noOfIndexes++
+	}
+
+	assert.Equal(t, 4, noOfIndexes)
+	db.Collection("audit_events").Drop(context.Background())
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// ExternalIdentityRepository is the repository for ExternalIdentity objects
+type ExternalIdentityRepository struct {
+	*mongo.Collection
+}
+
+// NewExternalIdentityRepository creates a new ExternalIdentityRepository
+func NewExternalIdentityRepository(db *mongo.Database) (*ExternalIdentityRepository, error) {
+	_, err := db.Collection("external_identities").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bsonx.Doc{{"provider", bsonx.Int32(1)}, {"external_id", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bsonx.Doc{{"user_id", bsonx.Int32(1)}, {"provider", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExternalIdentityRepository{
+		Collection: db.Collection("external_identities"),
+	}, nil
+}
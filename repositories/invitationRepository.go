@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// InvitationRepository is the repository for Invitation objects
+type InvitationRepository struct {
+	*mongo.Collection
+}
+
+// NewInvitationRepository creates a new InvitationRepository
+func NewInvitationRepository(db *mongo.Database) (*InvitationRepository, error) {
+	// expired invitations get removed by MongoDB's TTL monitor
+	_, err := db.Collection("invitations").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bsonx.Doc{{"code", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bsonx.Doc{{"expires_at", bsonx.Int32(1)}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvitationRepository{
+		Collection: db.Collection("invitations"),
+	}, nil
+}
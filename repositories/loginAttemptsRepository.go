@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// LoginAttemptsRepository is the repository for LoginAttempts objects
+type LoginAttemptsRepository struct {
+	*mongo.Collection
+}
+
+// NewLoginAttemptsRepository creates a new LoginAttemptsRepository
+func NewLoginAttemptsRepository(db *mongo.Database) (*LoginAttemptsRepository, error) {
+	// expired failures get removed by MongoDB's TTL monitor
+	_, err := db.Collection("login_attempts").Indexes().CreateOne(
+		context.Background(),
+		mongo.IndexModel{
+			Keys:    bsonx.Doc{{"expires_at", bsonx.Int32(1)}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginAttemptsRepository{
+		Collection: db.Collection("login_attempts"),
+	}, nil
+}
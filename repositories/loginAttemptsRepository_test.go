@@ -0,0 +1,44 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_NewLoginAttemptsRepository__should_return_login_attempts_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	laRepo, err := NewLoginAttemptsRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "login_attempts", laRepo.Name())
+	db.Collection("login_attempts").Drop(context.Background())
+}
+
+func Test_NewLoginAttemptsRepository__create_required_number_of_indexes(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	_, err := NewLoginAttemptsRepository(db)
+	assert.NoError(t, err)
+
+	cur, err := db.Collection("login_attempts").Indexes().List(context.Background())
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var noOfIndexes int
+	for cur.Next(context.Background()) {
+		var index mongo.IndexModel
+		err = cur.Decode(&index)
+		assert.NoError(t, err)
+		noOfIndexes++
+	}
+
+	assert.Equal(t, 2, noOfIndexes)
+	db.Collection("login_attempts").Drop(context.Background())
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// MatchmakingProfileRepository is the repository for MatchmakingProfile objects
+type MatchmakingProfileRepository struct {
+	*mongo.Collection
+}
+
+// NewMatchmakingProfileRepository creates a new MatchmakingProfileRepository
+func NewMatchmakingProfileRepository(db *mongo.Database) (*MatchmakingProfileRepository, error) {
+	// a user can only have one profile
+	_, err := db.Collection("matchmaking_profiles").Indexes().CreateOne(
+		context.Background(),
+		mongo.IndexModel{
+			Keys:    bsonx.Doc{{"user", bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MatchmakingProfileRepository{
+		Collection: db.Collection("matchmaking_profiles"),
+	}, nil
+}
@@ -0,0 +1,44 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_NewMatchmakingProfileRepository__should_return_matchmaking_profiles_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	mpRepo, err := NewMatchmakingProfileRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "matchmaking_profiles", mpRepo.Name())
+	db.Collection("matchmaking_profiles").Drop(context.Background())
+}
+
+func Test_NewMatchmakingProfileRepository__create_required_number_of_indexes(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	_, err := NewMatchmakingProfileRepository(db)
+	assert.NoError(t, err)
+
+	cur, err := db.Collection("matchmaking_profiles").Indexes().List(context.Background())
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var noOfIndexes int
+	for cur.Next(context.Background()) {
+		var index mongo.IndexModel
+		err = cur.Decode(&index)
+		assert.NoError(t, err)
+		noOfIndexes++
+	}
+
+	assert.Equal(t, 2, noOfIndexes)
+	db.Collection("matchmaking_profiles").Drop(context.Background())
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrationRepository is the repository for records of applied schema migrations
+type MigrationRepository struct {
+	*mongo.Collection
+}
+
+// NewMigrationRepository creates a new MigrationRepository
+func NewMigrationRepository(db *mongo.Database) (*MigrationRepository, error) {
+	return &MigrationRepository{
+		Collection: db.Collection("migrations"),
+	}, nil
+}
@@ -0,0 +1,21 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+)
+
+func Test_NewMigrationRepository__should_return_migrations_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	mRepo, err := NewMigrationRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "migrations", mRepo.Name())
+	db.Collection("migrations").Drop(context.Background())
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// TeamAdvertRepository is the repository for TeamAdvert objects
+type TeamAdvertRepository struct {
+	*mongo.Collection
+}
+
+// NewTeamAdvertRepository creates a new TeamAdvertRepository
+func NewTeamAdvertRepository(db *mongo.Database) (*TeamAdvertRepository, error) {
+	// a team can only have one advert
+	_, err := db.Collection("team_adverts").Indexes().CreateOne(
+		context.Background(),
+		mongo.IndexModel{
+			Keys:    bsonx.Doc{{"team", bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &TeamAdvertRepository{
+		Collection: db.Collection("team_adverts"),
+	}, nil
+}
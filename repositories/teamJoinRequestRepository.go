@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// TeamJoinRequestRepository is the repository for TeamJoinRequest objects
+type TeamJoinRequestRepository struct {
+	*mongo.Collection
+}
+
+// NewTeamJoinRequestRepository creates a new TeamJoinRequestRepository
+func NewTeamJoinRequestRepository(db *mongo.Database) (*TeamJoinRequestRepository, error) {
+	// a user can only have one request for each team
+	_, err := db.Collection("team_join_requests").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bsonx.Doc{{"team", bsonx.Int32(1)}, {"user", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bsonx.Doc{{"user", bsonx.Int32(1)}},
+			},
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &TeamJoinRequestRepository{
+		Collection: db.Collection("team_join_requests"),
+	}, nil
+}
@@ -0,0 +1,44 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_NewTeamJoinRequestRepository__should_return_team_join_requests_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	tjrRepo, err := NewTeamJoinRequestRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "team_join_requests", tjrRepo.Name())
+	db.Collection("team_join_requests").Drop(context.Background())
+}
+
+func Test_NewTeamJoinRequestRepository__create_required_number_of_indexes(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	_, err := NewTeamJoinRequestRepository(db)
+	assert.NoError(t, err)
+
+	cur, err := db.Collection("team_join_requests").Indexes().List(context.Background())
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var noOfIndexes int
+	for cur.Next(context.Background()) {
+		var index mongo.IndexModel
+		err = cur.Decode(&index)
+		assert.NoError(t, err)
+		noOfIndexes++
+	}
+
+	assert.Equal(t, 3, noOfIndexes)
+	db.Collection("team_join_requests").Drop(context.Background())
+}
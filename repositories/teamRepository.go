@@ -15,11 +15,25 @@ type TeamRepository struct {
 
 // NewTeamRepository creates a new TeamRepository
 func NewTeamRepository(db *mongo.Database) (*TeamRepository, error) {
-	_, err := db.Collection("teams").Indexes().CreateOne(
+	_, err := db.Collection("teams").Indexes().CreateMany(
 		context.Background(),
-		mongo.IndexModel{
-			Keys:    bsonx.Doc{{"name", bsonx.Int32(1)}},
-			Options: options.Index().SetUnique(true),
+		[]mongo.IndexModel{
+			{
+				Keys:    bsonx.Doc{{"name", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true),
+			},
+			// teams created before join codes were introduced may not have one yet
+			{
+				Keys:    bsonx.Doc{{"join_code", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true).SetSparse(true),
+			},
+			// index for sorting pages of teams by name
+			{Keys: bsonx.Doc{{"name", bsonx.Int32(1)}, {"_id", bsonx.Int32(1)}}},
+			// indexes for filtering teams by their judging track or tags
+			{Keys: bsonx.Doc{{"track", bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{"tags", bsonx.Int32(1)}}},
+			// index for searching teams by the words in their names, descriptions and tags
+			{Keys: bsonx.Doc{{"name", bsonx.String("text")}, {"description", bsonx.String("text")}, {"tags", bsonx.String("text")}}},
 		},
 	)
 
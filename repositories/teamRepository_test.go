@@ -39,6 +39,6 @@ func Test_NewTeamRepository__create_required_number_of_indexes(t *testing.T) {
 		noOfIndexes++
 	}
 
-	assert.Equal(t, 2, noOfIndexes)
+	assert.Equal(t, 7, noOfIndexes)
 	db.Collection("teams").Drop(context.Background())
 }
@@ -15,11 +15,19 @@ type UserRepository struct {
 
 // NewUserRepository creates a new UserRepository
 func NewUserRepository(db *mongo.Database) (*UserRepository, error) {
-	_, err := db.Collection("users").Indexes().CreateOne(
+	_, err := db.Collection("users").Indexes().CreateMany(
 		context.Background(),
-		mongo.IndexModel{
-			Keys:    bsonx.Doc{{"email", bsonx.Int32(1)}},
-			Options: options.Index().SetUnique(true),
+		[]mongo.IndexModel{
+			{
+				Keys:    bsonx.Doc{{"email", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true),
+			},
+			// indexes for filtering and sorting pages of users
+			{Keys: bsonx.Doc{{"email", bsonx.Int32(1)}, {"_id", bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{"name", bsonx.Int32(1)}, {"_id", bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{"role", bsonx.Int32(1)}, {"_id", bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{"team", bsonx.Int32(1)}, {"_id", bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{"email_verified", bsonx.Int32(1)}, {"_id", bsonx.Int32(1)}}},
 		},
 	)
 
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// WebAuthnChallengeRepository is the repository for WebAuthnChallenge objects
+type WebAuthnChallengeRepository struct {
+	*mongo.Collection
+}
+
+// NewWebAuthnChallengeRepository creates a new WebAuthnChallengeRepository
+func NewWebAuthnChallengeRepository(db *mongo.Database) (*WebAuthnChallengeRepository, error) {
+	// expired challenges get removed by MongoDB's TTL monitor
+	_, err := db.Collection("webauthn_challenges").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bsonx.Doc{{"challenge", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bsonx.Doc{{"expires_at", bsonx.Int32(1)}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnChallengeRepository{
+		Collection: db.Collection("webauthn_challenges"),
+	}, nil
+}
@@ -0,0 +1,44 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_NewWebAuthnChallengeRepository__should_return_webauthn_challenges_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	wchRepo, err := NewWebAuthnChallengeRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "webauthn_challenges", wchRepo.Name())
+	db.Collection("webauthn_challenges").Drop(context.Background())
+}
+
+func Test_NewWebAuthnChallengeRepository__create_required_number_of_indexes(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	_, err := NewWebAuthnChallengeRepository(db)
+	assert.NoError(t, err)
+
+	cur, err := db.Collection("webauthn_challenges").Indexes().List(context.Background())
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var noOfIndexes int
+	for cur.Next(context.Background()) {
+		var index mongo.IndexModel
+		err = cur.Decode(&index)
+		assert.NoError(t, err)
+		noOfIndexes++
+	}
+
+	assert.Equal(t, 3, noOfIndexes)
+	db.Collection("webauthn_challenges").Drop(context.Background())
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// WebAuthnCredentialRepository is the repository for WebAuthnCredential objects
+type WebAuthnCredentialRepository struct {
+	*mongo.Collection
+}
+
+// NewWebAuthnCredentialRepository creates a new WebAuthnCredentialRepository
+func NewWebAuthnCredentialRepository(db *mongo.Database) (*WebAuthnCredentialRepository, error) {
+	_, err := db.Collection("webauthn_credentials").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bsonx.Doc{{"credential_id", bsonx.Int32(1)}},
+				Options: options.Index().SetUnique(true),
+			},
+			{Keys: bsonx.Doc{{"user_id", bsonx.Int32(1)}}},
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnCredentialRepository{
+		Collection: db.Collection("webauthn_credentials"),
+	}, nil
+}
@@ -0,0 +1,44 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_NewWebAuthnCredentialRepository__should_return_webauthn_credentials_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	wcRepo, err := NewWebAuthnCredentialRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "webauthn_credentials", wcRepo.Name())
+	db.Collection("webauthn_credentials").Drop(context.Background())
+}
+
+func Test_NewWebAuthnCredentialRepository__create_required_number_of_indexes(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	_, err := NewWebAuthnCredentialRepository(db)
+	assert.NoError(t, err)
+
+	cur, err := db.Collection("webauthn_credentials").Indexes().List(context.Background())
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var noOfIndexes int
+	for cur.Next(context.Background()) {
+		var index mongo.IndexModel
+		err = cur.Decode(&index)
+		assert.NoError(t, err)
+		noOfIndexes++
+	}
+
+	assert.Equal(t, 3, noOfIndexes)
+	db.Collection("webauthn_credentials").Drop(context.Background())
+}
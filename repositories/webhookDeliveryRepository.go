@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// WebhookDeliveryRepository is the repository for WebhookDelivery objects
+type WebhookDeliveryRepository struct {
+	*mongo.Collection
+}
+
+// NewWebhookDeliveryRepository creates a new WebhookDeliveryRepository
+func NewWebhookDeliveryRepository(db *mongo.Database) (*WebhookDeliveryRepository, error) {
+	_, err := db.Collection("webhook_deliveries").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bsonx.Doc{{"status", bsonx.Int32(1)}, {"next_attempt_at", bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{"webhook_id", bsonx.Int32(1)}, {"created_at", bsonx.Int32(-1)}}},
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookDeliveryRepository{
+		Collection: db.Collection("webhook_deliveries"),
+	}, nil
+}
@@ -0,0 +1,44 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_NewWebhookDeliveryRepository__should_return_webhook_deliveries_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	wRepo, err := NewWebhookDeliveryRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "webhook_deliveries", wRepo.Name())
+	db.Collection("webhook_deliveries").Drop(context.Background())
+}
+
+func Test_NewWebhookDeliveryRepository__create_required_number_of_indexes(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	_, err := NewWebhookDeliveryRepository(db)
+	assert.NoError(t, err)
+
+	cur, err := db.Collection("webhook_deliveries").Indexes().List(context.Background())
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var noOfIndexes int
+	for cur.Next(context.Background()) {
+		var index mongo.IndexModel
+		err = cur.Decode(&index)
+		assert.NoError(t, err)
+		noOfIndexes++
+	}
+
+	assert.Equal(t, 3, noOfIndexes)
+	db.Collection("webhook_deliveries").Drop(context.Background())
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// WebhookRepository is the repository for Webhook objects
+type WebhookRepository struct {
+	*mongo.Collection
+}
+
+// NewWebhookRepository creates a new WebhookRepository
+func NewWebhookRepository(db *mongo.Database) (*WebhookRepository, error) {
+	_, err := db.Collection("webhooks").Indexes().CreateOne(
+		context.Background(),
+		mongo.IndexModel{
+			Keys: bsonx.Doc{{"events", bsonx.Int32(1)}},
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookRepository{
+		Collection: db.Collection("webhooks"),
+	}, nil
+}
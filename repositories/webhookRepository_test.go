@@ -0,0 +1,44 @@
+// +build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_NewWebhookRepository__should_return_webhooks_mongo_collection(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	wRepo, err := NewWebhookRepository(db)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "webhooks", wRepo.Name())
+	db.Collection("webhooks").Drop(context.Background())
+}
+
+func Test_NewWebhookRepository__create_required_number_of_indexes(t *testing.T) {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	_, err := NewWebhookRepository(db)
+	assert.NoError(t, err)
+
+	cur, err := db.Collection("webhooks").Indexes().List(context.Background())
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var noOfIndexes int
+	for cur.Next(context.Background()) {
+		var index mongo.IndexModel
+		err = cur.Decode(&index)
+		assert.NoError(t, err)
+		noOfIndexes++
+	}
+
+	assert.Equal(t, 2, noOfIndexes)
+	db.Collection("webhooks").Drop(context.Background())
+}
@@ -0,0 +1,94 @@
+package v2
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
+	"github.com/unicsmcr/hs_auth/services"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAuditEventsLimit = 100
+	maxAuditEventsLimit     = 1000
+)
+
+// GET: /api/v2/audit?action={action}&actor={actorId}&target={target}&from={from}&to={to}&limit={limit}
+// Request:  (Optional) action entities.AuditAction
+//           (Optional) actorId string
+//           (Optional) target string
+//           (Optional) from int64, unix timestamp
+//           (Optional) to int64, unix timestamp
+//           (Optional) limit int64, defaults to 100, at most 1000
+// Response: events []entities.AuditEvent, newest first
+// Headers:  Authorization -> token
+func (r *apiV2Router) GetAuditEvents(ctx *gin.Context) {
+	filter := services.AuditEventFilter{
+		Action:  entities.AuditAction(ctx.Query("action")),
+		ActorID: ctx.Query("actor"),
+		Target:  ctx.Query("target"),
+		Limit:   defaultAuditEventsLimit,
+	}
+
+	var err error
+	filter.From, err = parseUnixTimestampQuery(ctx, "from")
+	if err != nil {
+		r.logger.Debug("invalid from timestamp", zap.String("from", ctx.Query("from")), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, "from must be a unix timestamp")
+		return
+	}
+
+	filter.To, err = parseUnixTimestampQuery(ctx, "to")
+	if err != nil {
+		r.logger.Debug("invalid to timestamp", zap.String("to", ctx.Query("to")), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, "to must be a unix timestamp")
+		return
+	}
+
+	if len(ctx.Query("limit")) > 0 {
+		limit, err := strconv.ParseInt(ctx.Query("limit"), 10, 64)
+		if err != nil || limit <= 0 || limit > maxAuditEventsLimit {
+			r.logger.Debug("invalid limit", zap.String("limit", ctx.Query("limit")))
+			models.SendAPIError(ctx, http.StatusBadRequest, "limit must be a number between 1 and "+strconv.Itoa(maxAuditEventsLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	events, err := r.auditService.GetEvents(ctx, filter)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid actor id", zap.String("actor", filter.ActorID))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid actor id")
+		default:
+			r.logger.Error("could not fetch audit events", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getAuditEventsRes{
+		Events: events,
+	})
+}
+
+// parseUnixTimestampQuery parses the given query parameter as a unix timestamp.
+// Returns the zero time if the parameter is not provided.
+func parseUnixTimestampQuery(ctx *gin.Context, param string) (time.Time, error) {
+	if len(ctx.Query(param)) == 0 {
+		return time.Time{}, nil
+	}
+
+	timestamp, err := strconv.ParseInt(ctx.Query(param), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(timestamp, 0), nil
+}
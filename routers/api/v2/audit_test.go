@@ -0,0 +1,183 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_v2 "github.com/unicsmcr/hs_auth/mocks/authorization/v2"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.uber.org/zap"
+)
+
+// auditEventMatcher matches audit events equal to the expected event,
+// ignoring the fields filled in from the request
+type auditEventMatcher struct {
+	expectedEvent entities.AuditEvent
+}
+
+func newAuditEventMatcher(expectedEvent entities.AuditEvent) auditEventMatcher {
+	return auditEventMatcher{expectedEvent: expectedEvent}
+}
+
+// Matches implements the gomock.Matcher interface
+func (m auditEventMatcher) Matches(x interface{}) bool {
+	event, ok := x.(entities.AuditEvent)
+	if !ok {
+		return false
+	}
+	event.IP = m.expectedEvent.IP
+	event.UserAgent = m.expectedEvent.UserAgent
+	return reflect.DeepEqual(m.expectedEvent, event)
+}
+
+// String implements the gomock.Matcher interface
+func (m auditEventMatcher) String() string {
+	return fmt.Sprintf("audit event equal to %v", m.expectedEvent)
+}
+
+type auditTestSetup struct {
+	ctrl         *gomock.Controller
+	router       APIV2Router
+	mockAService *mock_services.MockAuditService
+	testCtx      *gin.Context
+	w            *httptest.ResponseRecorder
+}
+
+func setupAuditTest(t *testing.T) *auditTestSetup {
+	ctrl := gomock.NewController(t)
+	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
+
+	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{}, mockAuthorizer, nil, nil, nil, nil, mockAService, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	testCtx, _ := gin.CreateTestContext(w)
+
+	return &auditTestSetup{
+		ctrl:         ctrl,
+		router:       router,
+		mockAService: mockAService,
+		testCtx:      testCtx,
+		w:            w,
+	}
+}
+
+func TestApiV2Router_GetAuditEvents(t *testing.T) {
+	testEvents := []entities.AuditEvent{
+		{
+			ID:     testUserId,
+			Action: entities.AuditActionLogin,
+			Actor:  testUserId,
+		},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		prep        func(*auditTestSetup)
+		wantResCode int
+		wantRes     *getAuditEventsRes
+	}{
+		{
+			name:  "should return 200 and events matching default filter",
+			query: "",
+			prep: func(setup *auditTestSetup) {
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Limit: defaultAuditEventsLimit}).
+					Return(testEvents, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes:     &getAuditEventsRes{Events: testEvents},
+		},
+		{
+			name:  "should return 200 and events matching provided filter",
+			query: "action=login&actor=" + testUserId.Hex() + "&target=bob&from=100&to=200&limit=10",
+			prep: func(setup *auditTestSetup) {
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{
+					Action:  entities.AuditActionLogin,
+					ActorID: testUserId.Hex(),
+					Target:  "bob",
+					From:    time.Unix(100, 0),
+					To:      time.Unix(200, 0),
+					Limit:   10,
+				}).Return(testEvents, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes:     &getAuditEventsRes{Events: testEvents},
+		},
+		{
+			name:        "should return 400 when from is not a timestamp",
+			query:       "from=yesterday",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when to is not a timestamp",
+			query:       "to=today",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when limit is not a number",
+			query:       "limit=ten",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when limit is too large",
+			query:       "limit=1001",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when limit is not positive",
+			query:       "limit=0",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 400 when audit service returns ErrInvalidID",
+			query: "actor=invalid",
+			prep: func(setup *auditTestSetup) {
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, gomock.Any()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when audit service returns unknown error",
+			prep: func(setup *auditTestSetup) {
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, gomock.Any()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupAuditTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/audit?"+tt.query, nil)
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.GetAuditEvents(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				var actualRes getAuditEventsRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package v2
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// exportFormat is a file format tables can be exported in
+type exportFormat struct {
+	extension   string
+	contentType string
+	newWriter   func(w io.Writer, sheetName string) (utils.TableWriter, error)
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv": {
+		extension:   "csv",
+		contentType: "text/csv; charset=utf-8",
+		newWriter: func(w io.Writer, _ string) (utils.TableWriter, error) {
+			return utils.NewCSVTableWriter(w), nil
+		},
+	},
+	"xlsx": {
+		extension:   "xlsx",
+		contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		newWriter:   utils.NewXLSXTableWriter,
+	},
+}
+
+// userExportRow is the data available to the columns of a user export
+type userExportRow struct {
+	user entities.User
+	team *entities.Team
+}
+
+// userExportColumns are the columns users can be exported with, in their default order
+var userExportColumns = []string{"id", "name", "email", "email_verified", "role", "team_id", "team_name"}
+
+var userExportColumnValues = map[string]func(row userExportRow) string{
+	"id":             func(row userExportRow) string { return row.user.ID.Hex() },
+	"name":           func(row userExportRow) string { return row.user.Name },
+	"email":          func(row userExportRow) string { return row.user.Email },
+	"email_verified": func(row userExportRow) string { return strconv.FormatBool(row.user.EmailVerified) },
+	"role":           func(row userExportRow) string { return string(row.user.Role) },
+	"team_id": func(row userExportRow) string {
+		if row.user.Team == primitive.NilObjectID {
+			return ""
+		}
+		return row.user.Team.Hex()
+	},
+	"team_name": func(row userExportRow) string {
+		if row.team == nil {
+			return ""
+		}
+		return row.team.Name
+	},
+}
+
+// teamExportRow is the data available to the columns of a team export
+type teamExportRow struct {
+	team    entities.Team
+	members []entities.User
+}
+
+// teamExportColumns are the columns teams can be exported with, in their default order
+var teamExportColumns = []string{"id", "name", "creator_id", "member_count", "member_ids", "member_names", "member_emails"}
+
+var teamExportColumnValues = map[string]func(row teamExportRow) string{
+	"id":           func(row teamExportRow) string { return row.team.ID.Hex() },
+	"name":         func(row teamExportRow) string { return row.team.Name },
+	"creator_id":   func(row teamExportRow) string { return row.team.Creator.Hex() },
+	"member_count": func(row teamExportRow) string { return strconv.Itoa(len(row.members)) },
+	"member_ids": func(row teamExportRow) string {
+		return joinMemberFields(row.members, func(member entities.User) string { return member.ID.Hex() })
+	},
+	"member_names": func(row teamExportRow) string {
+		return joinMemberFields(row.members, func(member entities.User) string { return member.Name })
+	},
+	"member_emails": func(row teamExportRow) string {
+		return joinMemberFields(row.members, func(member entities.User) string { return member.Email })
+	},
+}
+
+func joinMemberFields(members []entities.User, field func(member entities.User) string) string {
+	values := make([]string, len(members))
+	for i, member := range members {
+		values[i] = field(member)
+	}
+	return strings.Join(values, "; ")
+}
+
+// parseExportQuery parses the format and columns query parameters.
+// The returned error's message can be sent to the client
+func parseExportQuery(ctx *gin.Context, knownColumns []string) (exportFormat, []string, error) {
+	format, ok := exportFormats[ctx.DefaultQuery("format", "csv")]
+	if !ok {
+		return exportFormat{}, nil, errors.New("format must be csv or xlsx")
+	}
+
+	if len(ctx.Query("columns")) == 0 {
+		return format, knownColumns, nil
+	}
+
+	columns := strings.Split(ctx.Query("columns"), ",")
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		if !containsString(knownColumns, columns[i]) {
+			return exportFormat{}, nil, errors.Errorf("unknown column %s, columns must be some of %s",
+				columns[i], strings.Join(knownColumns, ","))
+		}
+	}
+
+	return format, columns, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GET: /api/v2/users/export?format=<csv|xlsx>&columns=<column,...>
+// Request:  format string (Optional, csv or xlsx, defaults to csv), columns string (Optional, comma-separated)
+// Columns:  id, name, email, email_verified, role, team_id, team_name (all by default)
+// Filters:  role, team, email_verified and name, as for GET: /api/v2/users
+// Response: a CSV file or an XLSX workbook with a row for every user
+// Headers:  Authorization -> token
+func (r *apiV2Router) ExportUsers(ctx *gin.Context) {
+	format, columns, err := parseExportQuery(ctx, userExportColumns)
+	if err != nil {
+		r.logger.Debug("invalid export parameters", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseUserFilterQuery(ctx)
+	if err != nil {
+		r.logger.Debug("invalid user filter", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(filter.TeamID) > 0 && filter.TeamID != services.NoTeam {
+		if _, err := primitive.ObjectIDFromHex(filter.TeamID); err != nil {
+			r.logger.Debug("invalid team id", zap.String("team", filter.TeamID))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+			return
+		}
+	}
+
+	// there are far fewer teams than users, so they are kept in memory while the users are streamed
+	teams := map[primitive.ObjectID]*entities.Team{}
+	if containsString(columns, "team_name") {
+		err = r.teamService.StreamTeams(ctx, services.TeamFilter{}, func(team entities.Team) error {
+			teams[team.ID] = &team
+			return nil
+		})
+		if err != nil {
+			r.logger.Error("could not fetch teams", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+			return
+		}
+	}
+
+	r.writeExport(ctx, format, "users", columns, entities.AuditActionUsersExported, func(writeRow func([]string) error) error {
+		return r.userService.StreamUsers(ctx, filter, func(user entities.User) error {
+			row := userExportRow{user: user, team: teams[user.Team]}
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = userExportColumnValues[column](row)
+			}
+			return writeRow(cells)
+		})
+	})
+}
+
+// GET: /api/v2/teams/export?format=<csv|xlsx>&columns=<column,...>
+// Request:  format string (Optional, csv or xlsx, defaults to csv), columns string (Optional, comma-separated)
+// Columns:  id, name, creator_id, member_count, member_ids, member_names, member_emails (all by default)
+// Filters:  name, track, tag and search, as for GET: /api/v2/teams
+// Response: a CSV file or an XLSX workbook with a row for every team
+// Headers:  Authorization -> token
+func (r *apiV2Router) ExportTeams(ctx *gin.Context) {
+	format, columns, err := parseExportQuery(ctx, teamExportColumns)
+	if err != nil {
+		r.logger.Debug("invalid export parameters", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// only the members' ids, names and emails are exported, so only those are kept in memory while the teams are streamed
+	members := map[primitive.ObjectID][]entities.User{}
+	err = r.userService.StreamUsers(ctx, services.UserFilter{}, func(user entities.User) error {
+		if user.Team != primitive.NilObjectID {
+			members[user.Team] = append(members[user.Team], entities.User{ID: user.ID, Name: user.Name, Email: user.Email})
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("could not fetch team members", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	filter := parseTeamFilterQuery(ctx)
+	r.writeExport(ctx, format, "teams", columns, entities.AuditActionTeamsExported, func(writeRow func([]string) error) error {
+		return r.teamService.StreamTeams(ctx, filter, func(team entities.Team) error {
+			row := teamExportRow{team: team, members: members[team.ID]}
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = teamExportColumnValues[column](row)
+			}
+			return writeRow(cells)
+		})
+	})
+}
+
+// writeExport sends a file with the given name and format, made of a header row with the column names
+// followed by the rows written by writeRows. Since the response is streamed, errors after the file
+// has been started cannot be sent to the client and are only logged
+func (r *apiV2Router) writeExport(ctx *gin.Context, format exportFormat, name string, columns []string,
+	action entities.AuditAction, writeRows func(writeRow func([]string) error) error) {
+	ctx.Header("Content-Type", format.contentType)
+	ctx.Header("Content-Disposition", `attachment; filename="hs_auth_`+name+`.`+format.extension+`"`)
+	ctx.Status(http.StatusOK)
+
+	writer, err := format.newWriter(ctx.Writer, name)
+	if err != nil {
+		r.logger.Error("could not start export", zap.String("export", name), zap.Error(err))
+		return
+	}
+
+	err = writer.WriteRow(columns)
+	if err == nil {
+		err = writeRows(writer.WriteRow)
+	}
+	if err != nil {
+		r.logger.Error("could not write export", zap.String("export", name), zap.Error(err))
+		return
+	}
+
+	err = writer.Close()
+	if err != nil {
+		r.logger.Error("could not finish export", zap.String("export", name), zap.Error(err))
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: action,
+		Target: ctx.Request.URL.RawQuery,
+	})
+}
@@ -0,0 +1,249 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func streamUsers(users ...entities.User) func(interface{}, services.UserFilter, func(entities.User) error) error {
+	return func(_ interface{}, _ services.UserFilter, callback func(entities.User) error) error {
+		for _, user := range users {
+			if err := callback(user); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func streamTeams(teams ...entities.Team) func(interface{}, services.TeamFilter, func(entities.Team) error) error {
+	return func(_ interface{}, _ services.TeamFilter, callback func(entities.Team) error) error {
+		for _, team := range teams {
+			if err := callback(team); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestApiV2Router_ExportUsers(t *testing.T) {
+	testTeam := entities.Team{ID: testTeamId, Name: "Bobs"}
+	teamMember := entities.User{ID: testUserId, Name: "Bob", Email: "bob@test.com", EmailVerified: true,
+		Role: role.Applicant, Team: testTeamId}
+	teamlessUser := entities.User{ID: testUserId, Name: "=Rob", Email: "rob@test.com", Role: role.Unverified}
+
+	tests := []struct {
+		name            string
+		query           string
+		prep            func(*usersTestSetup)
+		wantResCode     int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:        "should return 400 when format is unknown",
+			query:       "format=pdf",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when column is unknown",
+			query:       "columns=name,password",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when email_verified is invalid",
+			query:       "email_verified=maybe",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when team is invalid",
+			query:       "team=me",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when team service returns error",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().StreamTeams(setup.testCtx, services.TeamFilter{}, gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and users with teams as CSV",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().StreamTeams(setup.testCtx, services.TeamFilter{}, gomock.Any()).
+					DoAndReturn(streamTeams(testTeam)).Times(1)
+				setup.mockUService.EXPECT().StreamUsers(setup.testCtx, services.UserFilter{}, gomock.Any()).
+					DoAndReturn(streamUsers(teamMember, teamlessUser)).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionUsersExported,
+				})).Return(nil).Times(1)
+			},
+			wantResCode:     http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody: "id,name,email,email_verified,role,team_id,team_name\n" +
+				testUserId.Hex() + ",Bob,bob@test.com,true,applicant," + testTeamId.Hex() + ",Bobs\n" +
+				testUserId.Hex() + ",'=Rob,rob@test.com,false,unverified,,\n",
+		},
+		{
+			name:  "should return 200 and only requested columns without fetching teams",
+			query: "columns=email,team_id&role=applicant&team=none",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().StreamUsers(setup.testCtx,
+					services.UserFilter{Role: role.Applicant, TeamID: services.NoTeam}, gomock.Any()).
+					DoAndReturn(streamUsers(teamMember)).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode:     http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody:        "email,team_id\nbob@test.com," + testTeamId.Hex() + "\n",
+		},
+		{
+			name:  "should return 200 and XLSX workbook",
+			query: "format=xlsx&columns=name",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().StreamUsers(setup.testCtx, services.UserFilter{}, gomock.Any()).
+					DoAndReturn(streamUsers(teamMember)).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode:     http.StatusOK,
+			wantContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		},
+		{
+			name:  "should not log audit event when user service returns error",
+			query: "columns=name",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().StreamUsers(setup.testCtx, services.UserFilter{}, gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode:     http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/test?"+tt.query, nil)
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.ExportUsers(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantContentType != "" {
+				assert.Equal(t, tt.wantContentType, setup.w.Header().Get("Content-Type"))
+			}
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, setup.w.Body.String())
+			}
+		})
+	}
+}
+
+func TestApiV2Router_ExportTeams(t *testing.T) {
+	emptyTeamId := primitive.NewObjectID()
+	testTeam := entities.Team{ID: testTeamId, Name: "Bobs", Creator: testUserId}
+	emptyTeam := entities.Team{ID: emptyTeamId, Name: "Robs", Creator: testUserId}
+	firstMember := entities.User{ID: testUserId, Name: "Bob", Email: "bob@test.com", Team: testTeamId}
+	secondMember := entities.User{ID: testUserId, Name: "Amy", Email: "amy@test.com", Team: testTeamId}
+	teamlessUser := entities.User{ID: testUserId, Name: "Rob", Email: "rob@test.com"}
+
+	tests := []struct {
+		name        string
+		query       string
+		prep        func(*usersTestSetup)
+		wantResCode int
+		wantBody    string
+	}{
+		{
+			name:        "should return 400 when column is unknown",
+			query:       "columns=creator",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when user service returns error",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().StreamUsers(setup.testCtx, services.UserFilter{}, gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 200 and teams with their members",
+			query: "name=B",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().StreamUsers(setup.testCtx, services.UserFilter{}, gomock.Any()).
+					DoAndReturn(streamUsers(firstMember, teamlessUser, secondMember)).Times(1)
+				setup.mockTService.EXPECT().StreamTeams(setup.testCtx, services.TeamFilter{NamePrefix: "B"}, gomock.Any()).
+					DoAndReturn(streamTeams(testTeam, emptyTeam)).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionTeamsExported,
+					Target: "name=B",
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantBody: "id,name,creator_id,member_count,member_ids,member_names,member_emails\n" +
+				testTeamId.Hex() + ",Bobs," + testUserId.Hex() + ",2," + testUserId.Hex() + "; " + testUserId.Hex() +
+				",Bob; Amy,bob@test.com; amy@test.com\n" +
+				emptyTeamId.Hex() + ",Robs," + testUserId.Hex() + ",0,,,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/test?"+tt.query, nil)
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.ExportTeams(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, setup.w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_routeStaticSegment(t *testing.T) {
+	tests := []struct {
+		id         string
+		wantStatic bool
+	}{
+		{id: "export", wantStatic: true},
+		{id: "me", wantStatic: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			var calledStatic, calledParam bool
+			handler := routeStaticSegment("id", "export",
+				func(*gin.Context) { calledStatic = true }, func(*gin.Context) { calledParam = true })
+			handler(ctx)
+
+			assert.Equal(t, tt.wantStatic, calledStatic)
+			assert.Equal(t, !tt.wantStatic, calledParam)
+		})
+	}
+}
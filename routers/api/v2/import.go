@@ -0,0 +1,311 @@
+package v2
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	maxImportSize = 1 << 20
+	// an invitation email is sent for every imported user, so imports are kept small enough to finish in one request
+	maxImportRows = 1000
+	// length of the random passwords of imported users, who set their own password through the invitation email
+	generatedPasswordBytes = 24
+)
+
+type importedUserStatus string
+
+const (
+	importedUserCreated importedUserStatus = "created"
+	importedUserValid   importedUserStatus = "valid"
+	importedUserFailed  importedUserStatus = "failed"
+)
+
+// importColumns are the columns of imported CSVs, mapped to whether they are required
+var importColumns = map[string]bool{
+	"name":  true,
+	"email": true,
+	"role":  true,
+	"team":  false,
+}
+
+// importRow is a row of an imported CSV
+type importRow struct {
+	name  string
+	email string
+	role  role.UserRole
+	// team is the name or id of the team the user is added to
+	team string
+}
+
+// POST: /api/v2/users/import?dry_run=<bool>
+// multipart/form-data with the CSV in file, or the CSV as the request body
+// Request:  CSV with a header row and the columns name, email, role and team (Optional, team name or id)
+// Query:    dry_run bool (Optional, validates the rows without creating any users)
+// Response: importUsersRes
+// Headers:  Authorization -> token
+func (r *apiV2Router) ImportUsers(ctx *gin.Context) {
+	dryRun := false
+	if len(ctx.Query("dry_run")) > 0 {
+		var err error
+		dryRun, err = strconv.ParseBool(ctx.Query("dry_run"))
+		if err != nil {
+			r.logger.Debug("invalid dry_run", zap.String("dry_run", ctx.Query("dry_run")))
+			models.SendAPIError(ctx, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	file, err := getImportedCSV(ctx)
+	if err != nil {
+		r.logger.Debug("could not read imported CSV", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	rows, err := parseImportedCSV(file)
+	if err != nil {
+		r.logger.Debug("invalid imported CSV", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res := importUsersRes{
+		DryRun: dryRun,
+		Rows:   make([]importedUserRow, 0, len(rows)),
+	}
+	seenEmails := map[string]bool{}
+	teams := map[string]*entities.Team{}
+	for i, row := range rows {
+		result := r.importUser(ctx, row, dryRun, seenEmails, teams)
+		// the header is the first row
+		result.Row = i + 2
+
+		switch result.Status {
+		case importedUserCreated:
+			res.Created++
+		case importedUserFailed:
+			res.Failed++
+		}
+		res.Rows = append(res.Rows, result)
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// importUser validates the row and, unless dryRun is set, creates the user and sends them an invitation email.
+// seenEmails and teams are shared between the rows of an import, to detect duplicate emails and avoid
+// fetching the same team more than once
+func (r *apiV2Router) importUser(ctx *gin.Context, row importRow, dryRun bool, seenEmails map[string]bool,
+	teams map[string]*entities.Team) importedUserRow {
+	result := importedUserRow{Email: row.email, Status: importedUserFailed}
+
+	if len(row.name) == 0 || len(row.email) == 0 || len(row.role) == 0 {
+		result.Error = "name, email and role must be provided"
+		return result
+	}
+	if address, err := mail.ParseAddress(row.email); err != nil || address.Address != row.email {
+		result.Error = "invalid email"
+		return result
+	}
+	email := strings.ToLower(row.email)
+	if seenEmails[email] {
+		result.Error = "email appears more than once in the import"
+		return result
+	}
+	seenEmails[email] = true
+	if err := r.cfg.UserRole.ValidateRole(row.role); err != nil {
+		result.Error = "unknown role"
+		return result
+	}
+
+	var team *entities.Team
+	if len(row.team) > 0 {
+		var err error
+		team, err = r.getImportedTeam(ctx, row.team, teams)
+		if err != nil {
+			if errors.Cause(err) == services.ErrNotFound {
+				result.Error = "team not found"
+			} else {
+				r.logger.Error("could not fetch team", zap.String("team", row.team), zap.Error(err))
+				result.Error = "could not fetch team"
+			}
+			return result
+		}
+	}
+
+	if dryRun {
+		_, err := r.userService.GetUserWithEmail(ctx, email)
+		if err == nil {
+			result.Error = "user with given email already exists"
+			return result
+		} else if errors.Cause(err) != services.ErrNotFound {
+			r.logger.Error("could not fetch user", zap.String("email", email), zap.Error(err))
+			result.Error = "could not check whether email is taken"
+			return result
+		}
+
+		result.Status = importedUserValid
+		return result
+	}
+
+	password, err := utils.GenerateRandomPassword(generatedPasswordBytes)
+	if err != nil {
+		r.logger.Error("could not generate password", zap.Error(err))
+		result.Error = "could not create user"
+		return result
+	}
+
+	user, err := r.userService.CreateUser(ctx, row.name, email, password, row.role)
+	if err != nil {
+		if errors.Cause(err) == services.ErrEmailTaken {
+			result.Error = "user with given email already exists"
+		} else {
+			r.logger.Error("could not create user", zap.String("email", email), zap.Error(err))
+			result.Error = "could not create user"
+		}
+		return result
+	}
+	result.Status = importedUserCreated
+	result.UserID = user.ID.Hex()
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionUserImported,
+		Target: user.ID.Hex(),
+		After:  entities.AuditValues{string(entities.UserRole): user.Role},
+	})
+
+	// the user exists from here on, so later failures are reported without failing the row
+	var warnings []string
+	if team != nil {
+		err = r.teamService.AddUserWithIDToTeamWithID(ctx, user.ID.Hex(), team.ID.Hex())
+		if errors.Cause(err) == services.ErrTeamFull {
+			warnings = append(warnings, "user could not be added to the team because it is full")
+		} else if err != nil {
+			r.logger.Error("could not add imported user to team", zap.String("userId", user.ID.Hex()),
+				zap.String("teamId", team.ID.Hex()), zap.Error(err))
+			warnings = append(warnings, "user could not be added to the team")
+		}
+	}
+
+	err = r.emailService.SendInvitationEmail(ctx, *user, rcommon.MakePasswordResetURIs(*user))
+	if err != nil {
+		r.logger.Warn("could not send invitation email", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		warnings = append(warnings, "invitation email could not be sent")
+	}
+	result.Error = strings.Join(warnings, "; ")
+
+	return result
+}
+
+// getImportedTeam returns the team with the given id or name, caching it in teams
+func (r *apiV2Router) getImportedTeam(ctx *gin.Context, idOrName string, teams map[string]*entities.Team) (*entities.Team, error) {
+	if team, ok := teams[idOrName]; ok {
+		return team, nil
+	}
+
+	var (
+		team *entities.Team
+		err  error
+	)
+	if _, idErr := primitive.ObjectIDFromHex(idOrName); idErr == nil {
+		team, err = r.teamService.GetTeamWithID(ctx, idOrName)
+	} else {
+		team, err = r.teamService.GetTeamWithName(ctx, idOrName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	teams[idOrName] = team
+	return team, nil
+}
+
+// getImportedCSV returns the CSV uploaded in the file form field or sent as the request body.
+// The returned error's message can be sent to the client
+func getImportedCSV(ctx *gin.Context) (io.ReadCloser, error) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+
+	if ctx.ContentType() != gin.MIMEMultipartPOSTForm {
+		return ctx.Request.Body, nil
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, errors.New("the CSV must be uploaded in the file field and be at most 1MB")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, errors.New("could not read the uploaded CSV")
+	}
+	return file, nil
+}
+
+// parseImportedCSV parses a CSV whose first row names its columns.
+// The returned error's message can be sent to the client
+func parseImportedCSV(file io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse CSV")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the CSV must have a header row")
+	}
+	if len(records)-1 > maxImportRows {
+		return nil, errors.Errorf("at most %d users can be imported at once", maxImportRows)
+	}
+
+	columnIndexes := map[string]int{}
+	for i, column := range records[0] {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := importColumns[column]; !ok {
+			return nil, errors.Errorf("unknown column %s", column)
+		}
+		columnIndexes[column] = i
+	}
+	for column, required := range importColumns {
+		if _, ok := columnIndexes[column]; required && !ok {
+			return nil, errors.Errorf("the CSV must have a %s column", column)
+		}
+	}
+
+	rows := make([]importRow, len(records)-1)
+	for i, record := range records[1:] {
+		value := func(column string) string {
+			index, ok := columnIndexes[column]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		rows[i] = importRow{
+			name:  value("name"),
+			email: value("email"),
+			role:  role.UserRole(value("role")),
+			team:  value("team"),
+		}
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,195 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
+	"github.com/unicsmcr/hs_auth/services"
+)
+
+func TestApiV2Router_ImportUsers(t *testing.T) {
+	testTeam := entities.Team{ID: testTeamId, Name: "Bobs"}
+	createdUser := entities.User{ID: testUserId, Name: "Bob", Email: "bob@test.com", Role: role.Applicant}
+
+	tests := []struct {
+		name        string
+		query       string
+		body        string
+		prep        func(*usersTestSetup)
+		wantResCode int
+		wantRes     *importUsersRes
+	}{
+		{
+			name:        "should return 400 when dry_run is invalid",
+			query:       "dry_run=maybe",
+			body:        "name,email,role\n",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when CSV is empty",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when column is unknown",
+			body:        "name,email,role,password\n",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when required column is missing",
+			body:        "name,role\n",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when CSV is malformed",
+			body:        "name,email,role\n\"Bob,bob@test.com,applicant\n",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should report invalid rows without creating users",
+			body: "Name,Email,Role,Team\n" +
+				",rob@test.com,applicant,\n" +
+				"Rob,rob,applicant,\n" +
+				"Rob,rob@test.com,wizard,\n" +
+				"Tom,tom@test.com,applicant,Robs\n" +
+				"Amy,ROB@test.com,applicant,\n",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().GetTeamWithName(setup.testCtx, "Robs").
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &importUsersRes{
+				Failed: 5,
+				Rows: []importedUserRow{
+					{Row: 2, Email: "rob@test.com", Status: importedUserFailed, Error: "name, email and role must be provided"},
+					{Row: 3, Email: "rob", Status: importedUserFailed, Error: "invalid email"},
+					{Row: 4, Email: "rob@test.com", Status: importedUserFailed, Error: "unknown role"},
+					{Row: 5, Email: "tom@test.com", Status: importedUserFailed, Error: "team not found"},
+					{Row: 6, Email: "ROB@test.com", Status: importedUserFailed, Error: "email appears more than once in the import"},
+				},
+			},
+		},
+		{
+			name:  "should validate rows without creating users when dry_run is set",
+			query: "dry_run=true",
+			body: "name,email,role,team\n" +
+				"Bob,bob@test.com,applicant,Bobs\n" +
+				"Rob,rob@test.com,applicant,Bobs\n",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().GetTeamWithName(setup.testCtx, "Bobs").
+					Return(&testTeam, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "rob@test.com").
+					Return(&entities.User{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &importUsersRes{
+				DryRun: true,
+				Failed: 1,
+				Rows: []importedUserRow{
+					{Row: 2, Email: "bob@test.com", Status: importedUserValid},
+					{Row: 3, Email: "rob@test.com", Status: importedUserFailed, Error: "user with given email already exists"},
+				},
+			},
+		},
+		{
+			name: "should create users, add them to teams and send invitations",
+			body: "name,email,role,team\n" +
+				"Bob,bob@test.com,applicant," + testTeamId.Hex() + "\n" +
+				"Rob,rob@test.com,applicant,\n" +
+				"Amy,amy@test.com,applicant,\n",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(&testTeam, nil).Times(1)
+				setup.mockUService.EXPECT().CreateUser(setup.testCtx, "Bob", "bob@test.com", gomock.Any(), role.Applicant).
+					Return(&createdUser, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionUserImported,
+					Target: testUserId.Hex(),
+					After:  entities.AuditValues{string(entities.UserRole): role.Applicant},
+				})).Return(nil).Times(2)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEService.EXPECT().SendInvitationEmail(setup.testCtx, createdUser,
+					rcommon.MakePasswordResetURIs(createdUser)).Return(nil).Times(1)
+
+				setup.mockUService.EXPECT().CreateUser(setup.testCtx, "Rob", "rob@test.com", gomock.Any(), role.Applicant).
+					Return(&createdUser, nil).Times(1)
+				setup.mockEService.EXPECT().SendInvitationEmail(setup.testCtx, createdUser, gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+
+				setup.mockUService.EXPECT().CreateUser(setup.testCtx, "Amy", "amy@test.com", gomock.Any(), role.Applicant).
+					Return(nil, services.ErrEmailTaken).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &importUsersRes{
+				Created: 2,
+				Failed:  1,
+				Rows: []importedUserRow{
+					{Row: 2, Email: "bob@test.com", Status: importedUserCreated, UserID: testUserId.Hex()},
+					{Row: 3, Email: "rob@test.com", Status: importedUserCreated, UserID: testUserId.Hex(),
+						Error: "invitation email could not be sent"},
+					{Row: 4, Email: "amy@test.com", Status: importedUserFailed, Error: "user with given email already exists"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodPost, "/test?"+tt.query, strings.NewReader(tt.body))
+			setup.testCtx.Request.Header.Set("Content-Type", "text/csv")
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.ImportUsers(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				var actualRes importUsersRes
+				err := json.Unmarshal(setup.w.Body.Bytes(), &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_ImportUsers__should_read_CSV_from_file_field(t *testing.T) {
+	setup := setupUsersTest(t)
+	defer setup.ctrl.Finish()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "users.csv")
+	assert.NoError(t, err)
+	_, err = file.Write([]byte("name,email,role\nBob,bob@test.com,applicant\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, form.Close())
+
+	setup.testCtx.Request = httptest.NewRequest(http.MethodPost, "/test?dry_run=true", &body)
+	setup.testCtx.Request.Header.Set("Content-Type", form.FormDataContentType())
+	setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+		Return(nil, services.ErrNotFound).Times(1)
+
+	setup.router.ImportUsers(setup.testCtx)
+
+	assert.Equal(t, http.StatusOK, setup.w.Code)
+	var actualRes importUsersRes
+	assert.NoError(t, json.Unmarshal(setup.w.Body.Bytes(), &actualRes))
+	assert.Equal(t, []importedUserRow{{Row: 2, Email: "bob@test.com", Status: importedUserValid}}, actualRes.Rows)
+}
@@ -0,0 +1,174 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
+	"github.com/unicsmcr/hs_auth/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// GET: /api/v2/invitations
+// Response: invitations []entities.Invitation, the invitations which have not expired and have uses left, newest first
+// Headers:  Authorization -> token
+func (r *apiV2Router) GetInvitations(ctx *gin.Context) {
+	invitations, err := r.invitationService.GetOutstandingInvitations(ctx)
+	if err != nil {
+		r.logger.Error("could not fetch invitations", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getInvitationsRes{
+		Invitations: invitations,
+	})
+}
+
+// POST: /api/v2/invitations
+// x-www-form-urlencoded
+// Request:  role role.UserRole, the role users registering with the invitation get
+// Optional: team string, id of the team users registering with the invitation are added to
+// Optional: max_uses int, defaults to 1
+// Optional: expires_at int64, unix timestamp, defaults to the configured invitation lifetime from now
+// Response: invitation entities.Invitation, link string to register with the invitation
+// Headers:  Authorization -> token
+func (r *apiV2Router) CreateInvitation(ctx *gin.Context) {
+	var req struct {
+		Role      role.UserRole `form:"role"`
+		Team      string        `form:"team"`
+		MaxUses   string        `form:"max_uses"`
+		ExpiresAt string        `form:"expires_at"`
+	}
+	err := ctx.Bind(&req)
+	if err != nil {
+		r.logger.Debug("could not parse create invitation request", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, "failed to parse request")
+		return
+	}
+
+	if len(req.Role) == 0 {
+		r.logger.Debug("role not provided in request")
+		models.SendAPIError(ctx, http.StatusBadRequest, "role must be provided")
+		return
+	}
+	err = r.cfg.UserRole.ValidateRole(req.Role)
+	if err != nil {
+		r.logger.Debug("invalid role", zap.String("role", string(req.Role)), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, "unknown role")
+		return
+	}
+
+	maxUses := 1
+	if len(req.MaxUses) > 0 {
+		maxUses, err = strconv.Atoi(req.MaxUses)
+		if err != nil || maxUses <= 0 {
+			r.logger.Debug("invalid max_uses", zap.String("max_uses", req.MaxUses))
+			models.SendAPIError(ctx, http.StatusBadRequest, "max_uses must be a positive number")
+			return
+		}
+	}
+
+	now := r.timeProvider.Now()
+	expiresAt := now.Add(time.Duration(r.cfg.Auth.InvitationLifetime) * time.Second)
+	if len(req.ExpiresAt) > 0 {
+		timestamp, err := strconv.ParseInt(req.ExpiresAt, 10, 64)
+		if err != nil || !time.Unix(timestamp, 0).After(now) {
+			r.logger.Debug("invalid expires_at", zap.String("expires_at", req.ExpiresAt))
+			models.SendAPIError(ctx, http.StatusBadRequest, "expires_at must be a unix timestamp in the future")
+			return
+		}
+		expiresAt = time.Unix(timestamp, 0)
+	}
+
+	if len(req.Team) > 0 {
+		_, err = r.teamService.GetTeamWithID(ctx, req.Team)
+		if err != nil {
+			switch errors.Cause(err) {
+			case services.ErrInvalidID:
+				r.logger.Debug("invalid team id", zap.String("team", req.Team))
+				models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+			case services.ErrNotFound:
+				r.logger.Debug("team not found", zap.String("team", req.Team))
+				models.SendAPIError(ctx, http.StatusNotFound, "team not found")
+			default:
+				r.logger.Error("could not fetch team", zap.String("team", req.Team), zap.Error(err))
+				models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+			}
+			return
+		}
+	}
+
+	// invitations created with service tokens have no creator
+	creatorID := primitive.NilObjectID
+	userID, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err == nil {
+		creatorID = userID
+	} else if errors.Cause(err) != common.ErrInvalidTokenType {
+		r.logger.Debug("invalid token", zap.Error(err))
+		r.HandleUnauthorized(ctx)
+		return
+	}
+
+	invitation, err := r.invitationService.CreateInvitation(ctx, req.Role, req.Team, maxUses, expiresAt, creatorID.Hex())
+	if err != nil {
+		r.logger.Error("could not create invitation", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionInvitationCreated,
+		Target: invitation.ID.Hex(),
+		After: entities.AuditValues{
+			string(entities.InvitationRole):      invitation.Role,
+			string(entities.InvitationTeam):      req.Team,
+			string(entities.InvitationMaxUses):   invitation.MaxUses,
+			string(entities.InvitationExpiresAt): invitation.ExpiresAt,
+		},
+	})
+
+	ctx.JSON(http.StatusOK, createInvitationRes{
+		Invitation: *invitation,
+		Link:       fmt.Sprintf("http://%s/register?invite=%s", r.cfg.AppURL, invitation.Code),
+	})
+}
+
+// DELETE: /api/v2/invitations/:id
+// Response:
+// Headers:  Authorization -> token
+func (r *apiV2Router) DeleteInvitation(ctx *gin.Context) {
+	invitationID := ctx.Param("id")
+
+	err := r.invitationService.DeleteInvitationWithID(ctx, invitationID)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid invitation id", zap.String("id", invitationID))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid id")
+		case services.ErrNotFound:
+			r.logger.Debug("invitation not found", zap.String("id", invitationID))
+			models.SendAPIError(ctx, http.StatusNotFound, "invitation not found")
+		default:
+			r.logger.Error("could not delete invitation", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionInvitationRevoked,
+		Target: invitationID,
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,346 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_v2 "github.com/unicsmcr/hs_auth/mocks/authorization/v2"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const testInvitationLifetime = 3600
+
+type invitationTestSetup struct {
+	ctrl             *gomock.Controller
+	router           APIV2Router
+	mockTService     *mock_services.MockTeamService
+	mockAService     *mock_services.MockAuditService
+	mockIService     *mock_services.MockInvitationService
+	mockAuthorizer   *mock_v2.MockAuthorizer
+	mockTimeProvider *mock_utils.MockTimeProvider
+	testCtx          *gin.Context
+	w                *httptest.ResponseRecorder
+}
+
+func setupInvitationTest(t *testing.T) *invitationTestSetup {
+	ctrl := gomock.NewController(t)
+	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
+	mockTService := mock_services.NewMockTeamService(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
+	mockIService := mock_services.NewMockInvitationService(ctrl)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+
+	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{
+		AppURL:   "auth.unicsmcr.com",
+		UserRole: testRoleConfig,
+		Auth: config.AuthConfig{
+			InvitationLifetime: testInvitationLifetime,
+		},
+	}, mockAuthorizer, nil, mockTService, nil, nil, mockAService, nil, nil, nil, nil, nil, mockIService, nil, nil, mockTimeProvider)
+
+	w := httptest.NewRecorder()
+	testCtx, _ := gin.CreateTestContext(w)
+
+	return &invitationTestSetup{
+		ctrl:             ctrl,
+		router:           router,
+		mockTService:     mockTService,
+		mockAService:     mockAService,
+		mockIService:     mockIService,
+		mockAuthorizer:   mockAuthorizer,
+		mockTimeProvider: mockTimeProvider,
+		testCtx:          testCtx,
+		w:                w,
+	}
+}
+
+var (
+	testInvitationTime = time.Unix(1600000000, 0)
+	testInvitation     = entities.Invitation{
+		ID:        testTeamId,
+		Code:      "0123456789abcdef",
+		Role:      role.Volunteer,
+		MaxUses:   1,
+		CreatedBy: testUserId,
+		ExpiresAt: testInvitationTime.Add(testInvitationLifetime * time.Second),
+	}
+)
+
+func TestApiV2Router_GetInvitations(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*invitationTestSetup)
+		wantResCode int
+		wantRes     *getInvitationsRes
+	}{
+		{
+			name: "should return 500 when invitation service returns error",
+			prep: func(setup *invitationTestSetup) {
+				setup.mockIService.EXPECT().GetOutstandingInvitations(setup.testCtx).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and outstanding invitations",
+			prep: func(setup *invitationTestSetup) {
+				setup.mockIService.EXPECT().GetOutstandingInvitations(setup.testCtx).
+					Return([]entities.Invitation{testInvitation}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes:     &getInvitationsRes{Invitations: []entities.Invitation{testInvitation}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupInvitationTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/invitations", nil)
+			tt.prep(setup)
+
+			setup.router.GetInvitations(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				var actualRes getInvitationsRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantRes.Invitations[0].Code, actualRes.Invitations[0].Code)
+				assert.Equal(t, tt.wantRes.Invitations[0].Role, actualRes.Invitations[0].Role)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_CreateInvitation(t *testing.T) {
+	futureTimestamp := strconv.FormatInt(testInvitationTime.Add(time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name        string
+		role        string
+		team        string
+		maxUses     string
+		expiresAt   string
+		prep        func(*invitationTestSetup)
+		wantResCode int
+		wantLink    string
+	}{
+		{
+			name:        "should return 400 when role is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when role is unknown",
+			role:        "admin",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when max_uses is not positive",
+			role:        string(role.Volunteer),
+			maxUses:     "0",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:      "should return 400 when expires_at is in the past",
+			role:      string(role.Volunteer),
+			expiresAt: strconv.FormatInt(testInvitationTime.Add(-time.Hour).Unix(), 10),
+			prep: func(setup *invitationTestSetup) {
+				setup.mockTimeProvider.EXPECT().Now().Return(testInvitationTime).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when team id is invalid",
+			role: string(role.Volunteer),
+			team: "team",
+			prep: func(setup *invitationTestSetup) {
+				setup.mockTimeProvider.EXPECT().Now().Return(testInvitationTime).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, "team").
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when team does not exist",
+			role: string(role.Volunteer),
+			team: testTeamId.Hex(),
+			prep: func(setup *invitationTestSetup) {
+				setup.mockTimeProvider.EXPECT().Now().Return(testInvitationTime).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 401 when token is invalid",
+			role: string(role.Volunteer),
+			prep: func(setup *invitationTestSetup) {
+				setup.mockTimeProvider.EXPECT().Now().Return(testInvitationTime).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, common.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 500 when invitation service returns error",
+			role: string(role.Volunteer),
+			prep: func(setup *invitationTestSetup) {
+				setup.mockTimeProvider.EXPECT().Now().Return(testInvitationTime).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockIService.EXPECT().CreateInvitation(setup.testCtx, role.Volunteer, "", 1,
+					testInvitationTime.Add(testInvitationLifetime*time.Second), testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:      "should return 200 and create invitation with given options",
+			role:      string(role.Volunteer),
+			team:      testTeamId.Hex(),
+			maxUses:   "5",
+			expiresAt: futureTimestamp,
+			prep: func(setup *invitationTestSetup) {
+				setup.mockTimeProvider.EXPECT().Now().Return(testInvitationTime).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(&entities.Team{ID: testTeamId}, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockIService.EXPECT().CreateInvitation(setup.testCtx, role.Volunteer, testTeamId.Hex(), 5,
+					testInvitationTime.Add(time.Hour), testUserId.Hex()).
+					Return(&testInvitation, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionInvitationCreated,
+					Target: testInvitation.ID.Hex(),
+					After: entities.AuditValues{
+						string(entities.InvitationRole):      testInvitation.Role,
+						string(entities.InvitationTeam):      testTeamId.Hex(),
+						string(entities.InvitationMaxUses):   testInvitation.MaxUses,
+						string(entities.InvitationExpiresAt): testInvitation.ExpiresAt,
+					},
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantLink:    "http://auth.unicsmcr.com/register?invite=" + testInvitation.Code,
+		},
+		{
+			name: "should return 200 and create invitation without creator when using service token",
+			role: string(role.Volunteer),
+			prep: func(setup *invitationTestSetup) {
+				setup.mockTimeProvider.EXPECT().Now().Return(testInvitationTime).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, common.ErrInvalidTokenType).Times(1)
+				setup.mockIService.EXPECT().CreateInvitation(setup.testCtx, role.Volunteer, "", 1,
+					testInvitationTime.Add(testInvitationLifetime*time.Second), primitive.NilObjectID.Hex()).
+					Return(&testInvitation, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantLink:    "http://auth.unicsmcr.com/register?invite=" + testInvitation.Code,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupInvitationTest(t)
+			defer setup.ctrl.Finish()
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"role":       tt.role,
+				"team":       tt.team,
+				"max_uses":   tt.maxUses,
+				"expires_at": tt.expiresAt,
+			})
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.CreateInvitation(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if len(tt.wantLink) > 0 {
+				var actualRes createInvitationRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantLink, actualRes.Link)
+				assert.Equal(t, testInvitation.Code, actualRes.Invitation.Code)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_DeleteInvitation(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*invitationTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when invitation service returns ErrInvalidID",
+			prep: func(setup *invitationTestSetup) {
+				setup.mockIService.EXPECT().DeleteInvitationWithID(setup.testCtx, testInvitation.ID.Hex()).
+					Return(services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when invitation service returns ErrNotFound",
+			prep: func(setup *invitationTestSetup) {
+				setup.mockIService.EXPECT().DeleteInvitationWithID(setup.testCtx, testInvitation.ID.Hex()).
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when invitation service returns unknown error",
+			prep: func(setup *invitationTestSetup) {
+				setup.mockIService.EXPECT().DeleteInvitationWithID(setup.testCtx, testInvitation.ID.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 204",
+			prep: func(setup *invitationTestSetup) {
+				setup.mockIService.EXPECT().DeleteInvitationWithID(setup.testCtx, testInvitation.ID.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionInvitationRevoked,
+					Target: testInvitation.ID.Hex(),
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupInvitationTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodDelete, "/invitations/"+testInvitation.ID.Hex(), nil)
+			setup.testCtx.Params = gin.Params{{Key: "id", Value: testInvitation.ID.Hex()}}
+			tt.prep(setup)
+
+			setup.router.DeleteInvitation(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package v2
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
+	"github.com/unicsmcr/hs_auth/services"
+	"go.uber.org/zap"
+)
+
+// GET: /api/v2/users/(:id|me)/matchmaking
+// Response: profile entities.MatchmakingProfile
+// Headers:  Authorization -> token
+func (r *apiV2Router) GetMatchmakingProfile(ctx *gin.Context) {
+	userId, ok := r.resolveUserId(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	profile, err := r.matchmakingService.GetProfileForUserWithID(ctx, userId)
+	if err != nil {
+		r.handleMatchmakingProfileError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getMatchmakingProfileRes{
+		Profile: *profile,
+	})
+}
+
+// PUT: /api/v2/users/(:id|me)/matchmaking
+// x-www-form-urlencoded
+// Request:  skills string, comma separated list of the user's skills
+//           interests string, comma separated list of the user's interests
+//           pitch string
+// Response: profile entities.MatchmakingProfile
+// Headers:  Authorization -> token
+// Only users without a team can have a matchmaking profile
+func (r *apiV2Router) SetMatchmakingProfile(ctx *gin.Context) {
+	userId, ok := r.resolveUserId(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	profile, err := r.matchmakingService.SetProfileForUserWithID(ctx, userId, splitMatchmakingTags(ctx.PostForm("skills")),
+		splitMatchmakingTags(ctx.PostForm("interests")), ctx.PostForm("pitch"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidMatchmakingProfile:
+			r.logger.Debug("invalid matchmaking profile", zap.String("userId", userId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "at least one skill must be provided and the profile must not be too long")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user is already in team", zap.String("userId", userId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user is already in a team")
+		default:
+			r.handleMatchmakingProfileError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, setMatchmakingProfileRes{
+		Profile: *profile,
+	})
+}
+
+// DELETE: /api/v2/users/(:id|me)/matchmaking
+// Headers:  Authorization -> token
+func (r *apiV2Router) DeleteMatchmakingProfile(ctx *gin.Context) {
+	userId, ok := r.resolveUserId(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := r.matchmakingService.DeleteProfileForUserWithID(ctx, userId)
+	if err != nil {
+		r.handleMatchmakingProfileError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GET: /api/v2/users/(:id|me)/matchmaking/matches
+// Response: matches []entities.TeamMatch, best matches first
+// Headers:  Authorization -> token
+func (r *apiV2Router) GetTeamMatches(ctx *gin.Context) {
+	userId, ok := r.resolveUserId(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	matches, err := r.matchmakingService.GetTeamMatchesForUserWithID(ctx, userId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrUserInTeam:
+			r.logger.Debug("user is already in team", zap.String("userId", userId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user is already in a team")
+		default:
+			r.handleMatchmakingProfileError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getTeamMatchesRes{
+		Matches: matches,
+	})
+}
+
+// PUT: /api/v2/users/(:id|me)/matchmaking/matches/:teamId
+// Response: join_request entities.TeamJoinRequest, with status 202, when the team is closed
+// Headers:  Authorization -> token
+func (r *apiV2Router) AcceptTeamMatch(ctx *gin.Context) {
+	userId, ok := r.resolveUserId(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	request, err := r.matchmakingService.AcceptTeamMatchForUserWithID(ctx, userId, ctx.Param("teamId"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("user or team id is invalid", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user or team id is invalid")
+		case services.ErrNotFound:
+			r.logger.Debug("user or advertised team not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "user or advertised team with given id not found")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user is already in team", zap.String("userId", userId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user is already in a team")
+		case services.ErrTeamFull:
+			r.logger.Debug("team is full", zap.String("userId", userId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "team is full")
+		case services.ErrJoinRequestExists:
+			r.logger.Debug("user has already requested to join team", zap.String("userId", userId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user has already requested to join the team")
+		default:
+			r.logger.Error("could not accept team match", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	if request != nil {
+		ctx.JSON(http.StatusAccepted, createTeamJoinRequestRes{
+			JoinRequest: *request,
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GET: /api/v2/teams/(:id|me)/advert
+// Response: advert entities.TeamAdvert
+// Headers:  Authorization -> token
+// Only the team's creator can see their own team's advert through me
+func (r *apiV2Router) GetTeamAdvert(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can manage its advert")
+	if !ok {
+		return
+	}
+
+	advert, err := r.matchmakingService.GetAdvertForTeamWithID(ctx, teamId)
+	if err != nil {
+		r.handleTeamAdvertError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getTeamAdvertRes{
+		Advert: *advert,
+	})
+}
+
+// PUT: /api/v2/teams/(:id|me)/advert
+// x-www-form-urlencoded
+// Request:  skills string, comma separated list of the skills the team is looking for
+//           pitch string
+// Response: advert entities.TeamAdvert
+// Headers:  Authorization -> token
+// Only the team's creator can advertise their own team, and only while it has open slots
+func (r *apiV2Router) SetTeamAdvert(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can manage its advert")
+	if !ok {
+		return
+	}
+
+	advert, err := r.matchmakingService.SetAdvertForTeamWithID(ctx, teamId, splitMatchmakingTags(ctx.PostForm("skills")), ctx.PostForm("pitch"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidTeamAdvert:
+			r.logger.Debug("invalid team advert", zap.String("teamId", teamId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "at least one skill must be provided and the advert must not be too long")
+		case services.ErrTeamFull:
+			r.logger.Debug("team is full", zap.String("teamId", teamId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "team is full")
+		default:
+			r.handleTeamAdvertError(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, setTeamAdvertRes{
+		Advert: *advert,
+	})
+}
+
+// DELETE: /api/v2/teams/(:id|me)/advert
+// Headers:  Authorization -> token
+// Only the team's creator can remove their own team's advert
+func (r *apiV2Router) DeleteTeamAdvert(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can manage its advert")
+	if !ok {
+		return
+	}
+
+	err := r.matchmakingService.DeleteAdvertForTeamWithID(ctx, teamId)
+	if err != nil {
+		r.handleTeamAdvertError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GET: /api/v2/teams/(:id|me)/matches
+// Response: matches []entities.ProfileMatch, best matches first
+// Headers:  Authorization -> token
+// Only the team's creator can see matches for their own team
+func (r *apiV2Router) GetProfileMatches(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can see its matches")
+	if !ok {
+		return
+	}
+
+	matches, err := r.matchmakingService.GetProfileMatchesForTeamWithID(ctx, teamId)
+	if err != nil {
+		r.handleTeamAdvertError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getProfileMatchesRes{
+		Matches: matches,
+	})
+}
+
+func (r *apiV2Router) handleMatchmakingProfileError(ctx *gin.Context, err error) {
+	switch errors.Cause(err) {
+	case services.ErrInvalidID:
+		r.logger.Debug("invalid user id", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, "invalid user id")
+	case services.ErrNotFound:
+		r.logger.Debug("user or matchmaking profile not found", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusNotFound, "user or matchmaking profile not found")
+	default:
+		r.logger.Error("could not handle matchmaking profile", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+	}
+}
+
+func (r *apiV2Router) handleTeamAdvertError(ctx *gin.Context, err error) {
+	switch errors.Cause(err) {
+	case services.ErrInvalidID:
+		r.logger.Debug("invalid team id", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+	case services.ErrNotFound:
+		r.logger.Debug("team or team advert not found", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusNotFound, "team or team advert not found")
+	default:
+		r.logger.Error("could not handle team advert", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+	}
+}
+
+// resolveUserId returns the id of the user the operation is for. When userId is "me", the user is the one
+// making the request. Sends the error response and returns false when the user cannot be resolved
+func (r *apiV2Router) resolveUserId(ctx *gin.Context, userId string) (string, bool) {
+	if userId != "me" {
+		return userId, true
+	}
+
+	userIdObj, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		switch errors.Cause(err) {
+		case common.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		case common.ErrInvalidTokenType:
+			r.logger.Debug("invalid token type", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "provided token is of invalid type for the requested operation")
+		default:
+			r.logger.Error("could not extract token type", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return "", false
+	}
+
+	return userIdObj.Hex(), true
+}
+
+func splitMatchmakingTags(tags string) []string {
+	if len(strings.TrimSpace(tags)) == 0 {
+		return nil
+	}
+
+	return strings.Split(tags, ",")
+}
@@ -0,0 +1,722 @@
+package v2
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_v2 "github.com/unicsmcr/hs_auth/mocks/authorization/v2"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type matchmakingTestSetup struct {
+	ctrl           *gomock.Controller
+	router         APIV2Router
+	mockAuthorizer *mock_v2.MockAuthorizer
+	mockTService   *mock_services.MockTeamService
+	mockMMService  *mock_services.MockMatchmakingService
+	testTeam       *entities.Team
+	testProfile    *entities.MatchmakingProfile
+	testAdvert     *entities.TeamAdvert
+	testCtx        *gin.Context
+	w              *httptest.ResponseRecorder
+}
+
+func setupMatchmakingTest(t *testing.T) *matchmakingTestSetup {
+	ctrl := gomock.NewController(t)
+	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTService := mock_services.NewMockTeamService(ctrl)
+	mockMMService := mock_services.NewMockMatchmakingService(ctrl)
+
+	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{}, mockAuthorizer, nil, mockTService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, mockMMService, mockTimeProvider)
+
+	w := httptest.NewRecorder()
+	testCtx, _ := gin.CreateTestContext(w)
+
+	return &matchmakingTestSetup{
+		ctrl:           ctrl,
+		router:         router,
+		mockAuthorizer: mockAuthorizer,
+		mockTService:   mockTService,
+		mockMMService:  mockMMService,
+		testTeam: &entities.Team{
+			ID:      testTeamId,
+			Name:    "Bobs the Testers",
+			Creator: testUserId,
+		},
+		testProfile: &entities.MatchmakingProfile{
+			User:   testUserId,
+			Skills: []string{"go", "design"},
+			Pitch:  "I like building things",
+		},
+		testAdvert: &entities.TeamAdvert{
+			Team:   testTeamId,
+			Skills: []string{"go"},
+			Pitch:  "We need a backend developer",
+		},
+		testCtx: testCtx,
+		w:       w,
+	}
+}
+
+func TestApiV2Router_GetMatchmakingProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		userId      string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+		wantRes     *getMatchmakingProfileRes
+	}{
+		{
+			name:   "should return 401 when user id is me and authorizer returns ErrInvalidToken",
+			userId: "me",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, common.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "should return 400 when user id is me and authorizer returns ErrInvalidTokenType",
+			userId: "me",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, common.ErrInvalidTokenType).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 400 when matchmaking service returns ErrInvalidID",
+			userId: "invalid",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, "invalid").
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when matchmaking service returns ErrNotFound",
+			userId: testUserId.Hex(),
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 500 when matchmaking service returns unknown error",
+			userId: testUserId.Hex(),
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 200 and profile of user making the request when user id is me",
+			userId: "me",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testProfile, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &getMatchmakingProfileRes{
+				Profile: entities.MatchmakingProfile{
+					User:   testUserId,
+					Skills: []string{"go", "design"},
+					Pitch:  "I like building things",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.userId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.GetMatchmakingProfile(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes getMatchmakingProfileRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_SetMatchmakingProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      map[string]string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when matchmaking service returns ErrInvalidMatchmakingProfile",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), nil, nil, "").
+					Return(nil, services.ErrInvalidMatchmakingProfile).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 400 when matchmaking service returns ErrUserInTeam",
+			params: map[string]string{"skills": "go"},
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), []string{"go"}, nil, "").
+					Return(nil, services.ErrUserInTeam).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when matchmaking service returns ErrNotFound",
+			params: map[string]string{"skills": "go"},
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), []string{"go"}, nil, "").
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 500 when matchmaking service returns unknown error",
+			params: map[string]string{"skills": "go"},
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), []string{"go"}, nil, "").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and pass comma separated skills and interests to matchmaking service",
+			params: map[string]string{
+				"skills":    "go,design",
+				"interests": "fintech",
+				"pitch":     "I like building things",
+			},
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), []string{"go", "design"},
+					[]string{"fintech"}, "I like building things").Return(setup.testProfile, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, tt.params)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.SetMatchmakingProfile(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func TestApiV2Router_DeleteMatchmakingProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 404 when matchmaking service returns ErrNotFound",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when matchmaking service returns unknown error",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 204 when profile is deleted",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodDelete, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.DeleteMatchmakingProfile(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
+func TestApiV2Router_GetTeamMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+		wantRes     *getTeamMatchesRes
+	}{
+		{
+			name: "should return 400 when matchmaking service returns ErrUserInTeam",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetTeamMatchesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrUserInTeam).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when matchmaking service returns ErrNotFound",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetTeamMatchesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 200 and matches",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetTeamMatchesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return([]entities.TeamMatch{
+						{
+							Advert:       *setup.testAdvert,
+							TeamName:     "Bobs the Testers",
+							OpenSlots:    2,
+							SharedSkills: []string{"go"},
+							Score:        1,
+						},
+					}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &getTeamMatchesRes{
+				Matches: []entities.TeamMatch{
+					{
+						Advert: entities.TeamAdvert{
+							Team:   testTeamId,
+							Skills: []string{"go"},
+							Pitch:  "We need a backend developer",
+						},
+						TeamName:     "Bobs the Testers",
+						OpenSlots:    2,
+						SharedSkills: []string{"go"},
+						Score:        1,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.GetTeamMatches(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes getTeamMatchesRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_AcceptTeamMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when matchmaking service returns ErrInvalidID",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when matchmaking service returns ErrNotFound",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 400 when matchmaking service returns ErrUserInTeam",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, services.ErrUserInTeam).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when matchmaking service returns ErrTeamFull",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, services.ErrTeamFull).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when matchmaking service returns ErrJoinRequestExists",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, services.ErrJoinRequestExists).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when matchmaking service returns unknown error",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 202 when join request is created for closed team",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(&entities.TeamJoinRequest{User: testUserId, Team: testTeamId}, nil).Times(1)
+			},
+			wantResCode: http.StatusAccepted,
+		},
+		{
+			name: "should return 204 when user joins open team",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex(), "teamId": testTeamId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.AcceptTeamMatch(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
+func TestApiV2Router_GetTeamAdvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		teamId      string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+	}{
+		{
+			name:   "should return 403 when team id is me and user is not the team's creator",
+			teamId: "me",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 404 when matchmaking service returns ErrNotFound",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 200 when team id is me and user is the team's creator",
+			teamId: "me",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockMMService.EXPECT().GetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(setup.testAdvert, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.GetTeamAdvert(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func TestApiV2Router_SetTeamAdvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      map[string]string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+		wantRes     *setTeamAdvertRes
+	}{
+		{
+			name: "should return 400 when matchmaking service returns ErrInvalidTeamAdvert",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex(), nil, "").
+					Return(nil, services.ErrInvalidTeamAdvert).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 400 when matchmaking service returns ErrTeamFull",
+			params: map[string]string{"skills": "go"},
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex(), []string{"go"}, "").
+					Return(nil, services.ErrTeamFull).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 500 when matchmaking service returns unknown error",
+			params: map[string]string{"skills": "go"},
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex(), []string{"go"}, "").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 200 and advert",
+			params: map[string]string{"skills": "go", "pitch": "We need a backend developer"},
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().SetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex(), []string{"go"}, "We need a backend developer").
+					Return(setup.testAdvert, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &setTeamAdvertRes{
+				Advert: entities.TeamAdvert{
+					Team:   testTeamId,
+					Skills: []string{"go"},
+					Pitch:  "We need a backend developer",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, tt.params)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testTeamId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.SetTeamAdvert(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes setTeamAdvertRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_DeleteTeamAdvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 404 when matchmaking service returns ErrNotFound",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().DeleteAdvertForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 204 when advert is deleted",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().DeleteAdvertForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodDelete, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testTeamId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.DeleteTeamAdvert(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
+func TestApiV2Router_GetProfileMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(setup *matchmakingTestSetup)
+		wantResCode int
+		wantRes     *getProfileMatchesRes
+	}{
+		{
+			name: "should return 404 when matchmaking service returns ErrNotFound",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetProfileMatchesForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when matchmaking service returns unknown error",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetProfileMatchesForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and matches",
+			prep: func(setup *matchmakingTestSetup) {
+				setup.mockMMService.EXPECT().GetProfileMatchesForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return([]entities.ProfileMatch{
+						{
+							Profile:      *setup.testProfile,
+							UserName:     "Bob the Tester",
+							SharedSkills: []string{"go"},
+							Score:        1,
+						},
+					}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &getProfileMatchesRes{
+				Matches: []entities.ProfileMatch{
+					{
+						Profile: entities.MatchmakingProfile{
+							User:   testUserId,
+							Skills: []string{"go", "design"},
+							Pitch:  "I like building things",
+						},
+						UserName:     "Bob the Tester",
+						SharedSkills: []string{"go"},
+						Score:        1,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testTeamId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.GetProfileMatches(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes getProfileMatchesRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
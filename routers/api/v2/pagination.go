@@ -0,0 +1,77 @@
+package v2
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/services"
+)
+
+const (
+	// defaultPageLimit is the size of the pages requested with after but without limit
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// parsePaginationQuery parses the limit, after, sort and order query parameters.
+// Requests without limit and after are not paginated, so clients which predate pagination still get all objects.
+// The returned error's message can be sent to the client
+func parsePaginationQuery(ctx *gin.Context) (services.Pagination, error) {
+	page := services.Pagination{
+		After:  ctx.Query("after"),
+		SortBy: ctx.Query("sort"),
+	}
+	if len(page.After) > 0 {
+		page.Limit = defaultPageLimit
+	}
+
+	if len(ctx.Query("limit")) > 0 {
+		limit, err := strconv.ParseInt(ctx.Query("limit"), 10, 64)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return services.Pagination{}, errors.New("limit must be a number between 1 and " + strconv.Itoa(maxPageLimit))
+		}
+		page.Limit = limit
+	}
+
+	switch ctx.Query("order") {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		return services.Pagination{}, errors.New("order must be asc or desc")
+	}
+
+	return page, nil
+}
+
+// parseUserFilterQuery parses the role, team, email_verified and name query parameters.
+// The returned error's message can be sent to the client
+func parseUserFilterQuery(ctx *gin.Context) (services.UserFilter, error) {
+	filter := services.UserFilter{
+		Role:       role.UserRole(ctx.Query("role")),
+		TeamID:     ctx.Query("team"),
+		NamePrefix: ctx.Query("name"),
+	}
+
+	if len(ctx.Query("email_verified")) > 0 {
+		emailVerified, err := strconv.ParseBool(ctx.Query("email_verified"))
+		if err != nil {
+			return services.UserFilter{}, errors.New("email_verified must be true or false")
+		}
+		filter.EmailVerified = &emailVerified
+	}
+
+	return filter, nil
+}
+
+// parseTeamFilterQuery parses the name, track, tag and search query parameters
+func parseTeamFilterQuery(ctx *gin.Context) services.TeamFilter {
+	return services.TeamFilter{
+		NamePrefix: ctx.Query("name"),
+		Track:      ctx.Query("track"),
+		Tag:        ctx.Query("tag"),
+		Search:     ctx.Query("search"),
+	}
+}
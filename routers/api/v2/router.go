@@ -21,46 +21,110 @@ type APIV2Router interface {
 	Register(ctx *gin.Context)
 	GetUsers(ctx *gin.Context)
 	GetUser(ctx *gin.Context)
+	ExportUsers(ctx *gin.Context)
+	ImportUsers(ctx *gin.Context)
 	SetRole(ctx *gin.Context)
 	SetSpecialPermissions(ctx *gin.Context)
 	SetPassword(ctx *gin.Context)
 	GetPasswordResetEmail(ctx *gin.Context)
 	ResendEmailVerification(ctx *gin.Context)
 	VerifyEmail(ctx *gin.Context)
+	RequestEmailChange(ctx *gin.Context)
+	ConfirmEmailChange(ctx *gin.Context)
+	ExportUserData(ctx *gin.Context)
+	DeleteUser(ctx *gin.Context)
 	GetAuthorizedResources(ctx *gin.Context)
 	CreateServiceToken(ctx *gin.Context)
 	InvalidateServiceToken(ctx *gin.Context)
 	CreateTeam(ctx *gin.Context)
 	GetTeams(ctx *gin.Context)
 	GetTeam(ctx *gin.Context)
+	ExportTeams(ctx *gin.Context)
+	RegenerateTeamJoinCode(ctx *gin.Context)
+	SetTeamPrivacy(ctx *gin.Context)
+	SetTeamMemberLimit(ctx *gin.Context)
+	GetTeamJoinRequests(ctx *gin.Context)
+	ApproveTeamJoinRequest(ctx *gin.Context)
+	RejectTeamJoinRequest(ctx *gin.Context)
+	UpdateTeam(ctx *gin.Context)
+	RemoveTeamMember(ctx *gin.Context)
+	SetTeamCreator(ctx *gin.Context)
+	DeleteTeam(ctx *gin.Context)
+	GetMatchmakingProfile(ctx *gin.Context)
+	SetMatchmakingProfile(ctx *gin.Context)
+	DeleteMatchmakingProfile(ctx *gin.Context)
+	GetTeamMatches(ctx *gin.Context)
+	AcceptTeamMatch(ctx *gin.Context)
+	GetTeamAdvert(ctx *gin.Context)
+	SetTeamAdvert(ctx *gin.Context)
+	DeleteTeamAdvert(ctx *gin.Context)
+	GetProfileMatches(ctx *gin.Context)
 	SetTeam(ctx *gin.Context)
 	RemoveFromTeam(ctx *gin.Context)
+	GetAuditEvents(ctx *gin.Context)
+	GetWebhooks(ctx *gin.Context)
+	CreateWebhook(ctx *gin.Context)
+	DeleteWebhook(ctx *gin.Context)
+	GetWebhookDeliveries(ctx *gin.Context)
+	GetInvitations(ctx *gin.Context)
+	CreateInvitation(ctx *gin.Context)
+	DeleteInvitation(ctx *gin.Context)
+	UnlockUser(ctx *gin.Context)
+	ResetTwoFactor(ctx *gin.Context)
+	BeginWebAuthnRegistration(ctx *gin.Context)
+	FinishWebAuthnRegistration(ctx *gin.Context)
+	BeginWebAuthnLogin(ctx *gin.Context)
+	FinishWebAuthnLogin(ctx *gin.Context)
+	GetWebAuthnCredentials(ctx *gin.Context)
+	DeleteWebAuthnCredential(ctx *gin.Context)
 }
 
 type apiV2Router struct {
 	models.BaseRouter
-	logger       *zap.Logger
-	cfg          *config.AppConfig
-	authorizer   v2.Authorizer
-	userService  services.UserService
-	tokenService services.TokenService
-	teamService  services.TeamService
-	emailService services.EmailServiceV2
-	timeProvider utils.TimeProvider
+	logger              *zap.Logger
+	cfg                 *config.AppConfig
+	authorizer          v2.Authorizer
+	userService         services.UserService
+	tokenService        services.TokenService
+	teamService         services.TeamService
+	emailService        services.EmailServiceV2
+	auditService        services.AuditService
+	webhookService      services.WebhookService
+	loginAttemptService services.LoginAttemptService
+	twoFactorService    services.TwoFactorService
+	webAuthnService     services.WebAuthnService
+	identityService     services.ExternalIdentityService
+	invitationService   services.InvitationService
+	joinRequestService  services.TeamJoinRequestService
+	matchmakingService  services.MatchmakingService
+	timeProvider        utils.TimeProvider
 }
 
 func NewAPIV2Router(logger *zap.Logger, cfg *config.AppConfig, authorizer v2.Authorizer,
 	userService services.UserService, teamService services.TeamService, tokenService services.TokenService,
-	emailService services.EmailServiceV2, timeProvider utils.TimeProvider) APIV2Router {
+	emailService services.EmailServiceV2, auditService services.AuditService, webhookService services.WebhookService,
+	loginAttemptService services.LoginAttemptService, twoFactorService services.TwoFactorService,
+	webAuthnService services.WebAuthnService, identityService services.ExternalIdentityService,
+	invitationService services.InvitationService, joinRequestService services.TeamJoinRequestService,
+	matchmakingService services.MatchmakingService, timeProvider utils.TimeProvider) APIV2Router {
 	return &apiV2Router{
-		logger:       logger,
-		cfg:          cfg,
-		authorizer:   authorizer,
-		userService:  userService,
-		tokenService: tokenService,
-		teamService:  teamService,
-		emailService: emailService,
-		timeProvider: timeProvider,
+		logger:              logger,
+		cfg:                 cfg,
+		authorizer:          authorizer,
+		userService:         userService,
+		tokenService:        tokenService,
+		teamService:         teamService,
+		emailService:        emailService,
+		auditService:        auditService,
+		webhookService:      webhookService,
+		loginAttemptService: loginAttemptService,
+		twoFactorService:    twoFactorService,
+		webAuthnService:     webAuthnService,
+		identityService:     identityService,
+		invitationService:   invitationService,
+		joinRequestService:  joinRequestService,
+		matchmakingService:  matchmakingService,
+		timeProvider:        timeProvider,
 	}
 }
 
@@ -69,17 +133,30 @@ func (r *apiV2Router) RegisterRoutes(routerGroup *gin.RouterGroup) {
 
 	usersGroup := routerGroup.Group("/users")
 	usersGroup.GET("/", r.authorizer.WithAuthMiddleware(r, r.GetUsers))
-	usersGroup.GET("/:id", r.authorizer.WithAuthMiddleware(r, r.GetUser))
+	usersGroup.GET("/:id", routeStaticSegment("id", "export",
+		r.authorizer.WithAuthMiddleware(r, r.ExportUsers), r.authorizer.WithAuthMiddleware(r, r.GetUser)))
+	usersGroup.DELETE("/:id", r.authorizer.WithAuthMiddleware(r, r.DeleteUser))
+	usersGroup.GET("/:id/export", r.authorizer.WithAuthMiddleware(r, r.ExportUserData))
 	usersGroup.PUT("/:id/team", r.authorizer.WithAuthMiddleware(r, r.SetTeam))
 	usersGroup.DELETE("/:id/team", r.authorizer.WithAuthMiddleware(r, r.RemoveFromTeam))
+	usersGroup.GET("/:id/matchmaking", r.authorizer.WithAuthMiddleware(r, r.GetMatchmakingProfile))
+	usersGroup.PUT("/:id/matchmaking", r.authorizer.WithAuthMiddleware(r, r.SetMatchmakingProfile))
+	usersGroup.DELETE("/:id/matchmaking", r.authorizer.WithAuthMiddleware(r, r.DeleteMatchmakingProfile))
+	usersGroup.GET("/:id/matchmaking/matches", r.authorizer.WithAuthMiddleware(r, r.GetTeamMatches))
+	usersGroup.PUT("/:id/matchmaking/matches/:teamId", r.authorizer.WithAuthMiddleware(r, r.AcceptTeamMatch))
 	usersGroup.POST("/", r.Register)
 	usersGroup.POST("/login", r.Login)
+	usersGroup.POST("/import", r.authorizer.WithAuthMiddleware(r, r.ImportUsers))
 	usersGroup.PUT("/:id/role", r.authorizer.WithAuthMiddleware(r, r.SetRole))
 	usersGroup.PUT("/:id/permissions", r.authorizer.WithAuthMiddleware(r, r.SetSpecialPermissions))
 	usersGroup.PUT("/:id/password", r.authorizer.WithAuthMiddleware(r, r.SetPassword))
 	usersGroup.GET("/:id/password/resetEmail", r.GetPasswordResetEmail)
 	usersGroup.PUT("/:id/email/verify", r.authorizer.WithAuthMiddleware(r, r.VerifyEmail))
 	usersGroup.GET("/:id/email/verify", r.authorizer.WithAuthMiddleware(r, r.ResendEmailVerification))
+	usersGroup.PUT("/:id/email", r.authorizer.WithAuthMiddleware(r, r.RequestEmailChange))
+	usersGroup.PUT("/:id/email/confirm", r.authorizer.WithAuthMiddleware(r, r.ConfirmEmailChange))
+	usersGroup.DELETE("/:id/lockout", r.authorizer.WithAuthMiddleware(r, r.UnlockUser))
+	usersGroup.DELETE("/:id/twofactor", r.authorizer.WithAuthMiddleware(r, r.ResetTwoFactor))
 
 	tokensGroup := routerGroup.Group("/tokens")
 	tokensGroup.GET("/resources/authorized", r.authorizer.WithAuthMiddleware(r, r.GetAuthorizedResources))
@@ -88,8 +165,45 @@ func (r *apiV2Router) RegisterRoutes(routerGroup *gin.RouterGroup) {
 
 	teamsGroups := routerGroup.Group("/teams")
 	teamsGroups.GET("/", r.authorizer.WithAuthMiddleware(r, r.GetTeams))
-	teamsGroups.GET("/:id", r.authorizer.WithAuthMiddleware(r, r.GetTeam))
+	teamsGroups.GET("/:id", routeStaticSegment("id", "export",
+		r.authorizer.WithAuthMiddleware(r, r.ExportTeams), r.authorizer.WithAuthMiddleware(r, r.GetTeam)))
 	teamsGroups.POST("/", r.authorizer.WithAuthMiddleware(r, r.CreateTeam))
+	teamsGroups.PUT("/:id", r.authorizer.WithAuthMiddleware(r, r.UpdateTeam))
+	teamsGroups.DELETE("/:id", r.authorizer.WithAuthMiddleware(r, r.DeleteTeam))
+	teamsGroups.DELETE("/:id/members/:userId", r.authorizer.WithAuthMiddleware(r, r.RemoveTeamMember))
+	teamsGroups.PUT("/:id/creator", r.authorizer.WithAuthMiddleware(r, r.SetTeamCreator))
+	teamsGroups.PUT("/:id/code", r.authorizer.WithAuthMiddleware(r, r.RegenerateTeamJoinCode))
+	teamsGroups.PUT("/:id/privacy", r.authorizer.WithAuthMiddleware(r, r.SetTeamPrivacy))
+	teamsGroups.PUT("/:id/limit", r.authorizer.WithAuthMiddleware(r, r.SetTeamMemberLimit))
+	teamsGroups.GET("/:id/advert", r.authorizer.WithAuthMiddleware(r, r.GetTeamAdvert))
+	teamsGroups.PUT("/:id/advert", r.authorizer.WithAuthMiddleware(r, r.SetTeamAdvert))
+	teamsGroups.DELETE("/:id/advert", r.authorizer.WithAuthMiddleware(r, r.DeleteTeamAdvert))
+	teamsGroups.GET("/:id/matches", r.authorizer.WithAuthMiddleware(r, r.GetProfileMatches))
+	teamsGroups.GET("/:id/requests", r.authorizer.WithAuthMiddleware(r, r.GetTeamJoinRequests))
+	teamsGroups.PUT("/:id/requests/:requestId/approve", r.authorizer.WithAuthMiddleware(r, r.ApproveTeamJoinRequest))
+	teamsGroups.PUT("/:id/requests/:requestId/reject", r.authorizer.WithAuthMiddleware(r, r.RejectTeamJoinRequest))
+
+	auditGroup := routerGroup.Group("/audit")
+	auditGroup.GET("/", r.authorizer.WithAuthMiddleware(r, r.GetAuditEvents))
+
+	webhooksGroup := routerGroup.Group("/webhooks")
+	webhooksGroup.GET("/", r.authorizer.WithAuthMiddleware(r, r.GetWebhooks))
+	webhooksGroup.POST("/", r.authorizer.WithAuthMiddleware(r, r.CreateWebhook))
+	webhooksGroup.DELETE("/:id", r.authorizer.WithAuthMiddleware(r, r.DeleteWebhook))
+	webhooksGroup.GET("/:id/deliveries", r.authorizer.WithAuthMiddleware(r, r.GetWebhookDeliveries))
+
+	invitationsGroup := routerGroup.Group("/invitations")
+	invitationsGroup.GET("/", r.authorizer.WithAuthMiddleware(r, r.GetInvitations))
+	invitationsGroup.POST("/", r.authorizer.WithAuthMiddleware(r, r.CreateInvitation))
+	invitationsGroup.DELETE("/:id", r.authorizer.WithAuthMiddleware(r, r.DeleteInvitation))
+
+	webAuthnGroup := routerGroup.Group("/webauthn")
+	webAuthnGroup.POST("/register/begin", r.authorizer.WithAuthMiddleware(r, r.BeginWebAuthnRegistration))
+	webAuthnGroup.POST("/register/finish", r.authorizer.WithAuthMiddleware(r, r.FinishWebAuthnRegistration))
+	webAuthnGroup.POST("/login/begin", r.BeginWebAuthnLogin)
+	webAuthnGroup.POST("/login/finish", r.FinishWebAuthnLogin)
+	webAuthnGroup.GET("/credentials", r.authorizer.WithAuthMiddleware(r, r.GetWebAuthnCredentials))
+	webAuthnGroup.DELETE("/credentials/:id", r.authorizer.WithAuthMiddleware(r, r.DeleteWebAuthnCredential))
 }
 
 func (r *apiV2Router) GetResourcePath() string {
@@ -103,3 +217,16 @@ func (r *apiV2Router) GetAuthToken(ctx *gin.Context) string {
 func (r *apiV2Router) HandleUnauthorized(ctx *gin.Context) {
 	models.SendAPIError(ctx, http.StatusUnauthorized, "you are not authorized to use this operation")
 }
+
+// routeStaticSegment returns a handler calling staticHandler when the path parameter param is equal to segment
+// and paramHandler otherwise. gin does not allow static path segments next to path parameters,
+// so routes like /users/export have to be handled by the /users/:id route
+func routeStaticSegment(param, segment string, staticHandler, paramHandler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Param(param) == segment {
+			staticHandler(ctx)
+			return
+		}
+		paramHandler(ctx)
+	}
+}
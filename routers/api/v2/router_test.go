@@ -27,12 +27,43 @@ func TestApiV2Router_RegisterRoutes(t *testing.T) {
 	mockTService := mock_services.NewMockTeamService(ctrl)
 	mockTokenService := mock_services.NewMockTokenService(ctrl)
 	mockEService := mock_services.NewMockEmailServiceV2(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
 	mockUService.EXPECT().GetUserWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidToken).AnyTimes()
 	mockTService.EXPECT().GetTeamWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidToken)
 	mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(primitive.ObjectID{}, common.ErrInvalidTokenType)
 	mockTokenService.EXPECT().CreateServiceToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidToken)
 	mockAuthorizer.EXPECT().InvalidateServiceToken(gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
 	mockUService.EXPECT().UpdateUserWithID(gomock.Any(), gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockAService.EXPECT().GetEvents(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockWService := mock_services.NewMockWebhookService(ctrl)
+	mockWService.EXPECT().GetWebhooks(gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockWService.EXPECT().DeleteWebhookWithID(gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockWService.EXPECT().GetDeliveriesForWebhookWithID(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(primitive.ObjectID{}, common.ErrInvalidTokenType).Times(4)
+	mockWAService := mock_services.NewMockWebAuthnService(ctrl)
+	mockWAService.EXPECT().BeginLogin(gomock.Any(), "").Return(nil, services.ErrInvalidID)
+	mockTService.EXPECT().StreamTeams(gomock.Any(), gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockTService.EXPECT().RegenerateJoinCodeForTeamWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockTService.EXPECT().DisbandTeamWithID(gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(gomock.Any(), gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockUService.EXPECT().StreamUsers(gomock.Any(), gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockIService := mock_services.NewMockInvitationService(ctrl)
+	mockIService.EXPECT().GetOutstandingInvitations(gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockIService.EXPECT().DeleteInvitationWithID(gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockJRService := mock_services.NewMockTeamJoinRequestService(ctrl)
+	mockJRService.EXPECT().GetJoinRequestsForTeamWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockJRService.EXPECT().ApproveJoinRequestWithID(gomock.Any(), gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockJRService.EXPECT().RejectJoinRequestWithID(gomock.Any(), gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockMMService := mock_services.NewMockMatchmakingService(ctrl)
+	mockMMService.EXPECT().GetProfileForUserWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockMMService.EXPECT().SetProfileForUserWithID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockMMService.EXPECT().DeleteProfileForUserWithID(gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockMMService.EXPECT().GetTeamMatchesForUserWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockMMService.EXPECT().AcceptTeamMatchForUserWithID(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockMMService.EXPECT().GetAdvertForTeamWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockMMService.EXPECT().SetAdvertForTeamWithID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
+	mockMMService.EXPECT().DeleteAdvertForTeamWithID(gomock.Any(), gomock.Any()).Return(services.ErrInvalidID)
+	mockMMService.EXPECT().GetProfileMatchesForTeamWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID)
 
 	tests := []struct {
 		route  string
@@ -90,6 +121,38 @@ func TestApiV2Router_RegisterRoutes(t *testing.T) {
 			route:  "/users/123/email/verify",
 			method: http.MethodGet,
 		},
+		{
+			route:  "/users/123/email",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/users/123/email/confirm",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/users/123",
+			method: http.MethodDelete,
+		},
+		{
+			route:  "/users/123/export",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/users/export",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/users/import",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/users/123/lockout",
+			method: http.MethodDelete,
+		},
+		{
+			route:  "/users/123/twofactor",
+			method: http.MethodDelete,
+		},
 		{
 			route:  "/tokens/service",
 			method: http.MethodPost,
@@ -106,28 +169,172 @@ func TestApiV2Router_RegisterRoutes(t *testing.T) {
 			route:  "/teams/123",
 			method: http.MethodGet,
 		},
+		{
+			route:  "/teams/export",
+			method: http.MethodGet,
+		},
 		{
 			route:  "/teams",
 			method: http.MethodPost,
 		},
+		{
+			route:  "/teams/123/code",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/teams/123/privacy",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/teams/123/limit",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/teams/123",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/teams/123",
+			method: http.MethodDelete,
+		},
+		{
+			route:  "/teams/123/members/456",
+			method: http.MethodDelete,
+		},
+		{
+			route:  "/teams/123/creator",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/teams/123/requests",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/teams/123/requests/456/approve",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/teams/123/requests/456/reject",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/users/123/matchmaking",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/users/123/matchmaking",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/users/123/matchmaking",
+			method: http.MethodDelete,
+		},
+		{
+			route:  "/users/123/matchmaking/matches",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/users/123/matchmaking/matches/456",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/teams/123/advert",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/teams/123/advert",
+			method: http.MethodPut,
+		},
+		{
+			route:  "/teams/123/advert",
+			method: http.MethodDelete,
+		},
+		{
+			route:  "/teams/123/matches",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/audit",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/webhooks",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/webhooks",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/webhooks/123",
+			method: http.MethodDelete,
+		},
+		{
+			route:  "/webhooks/123/deliveries",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/invitations",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/invitations",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/invitations/123",
+			method: http.MethodDelete,
+		},
+		{
+			route:  "/webauthn/register/begin",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/webauthn/register/finish",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/webauthn/login/begin",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/webauthn/login/finish",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/webauthn/credentials",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/webauthn/credentials/123",
+			method: http.MethodDelete,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s:%s", tt.method, tt.route), func(t *testing.T) {
 			router := &apiV2Router{
-				logger:       zap.NewNop(),
-				authorizer:   mockAuthorizer,
-				userService:  mockUService,
-				teamService:  mockTService,
-				tokenService: mockTokenService,
-				emailService: mockEService,
-				cfg:          &config.AppConfig{},
+				logger:             zap.NewNop(),
+				authorizer:         mockAuthorizer,
+				userService:        mockUService,
+				teamService:        mockTService,
+				tokenService:       mockTokenService,
+				emailService:       mockEService,
+				auditService:       mockAService,
+				webhookService:     mockWService,
+				webAuthnService:    mockWAService,
+				invitationService:  mockIService,
+				joinRequestService: mockJRService,
+				matchmakingService: mockMMService,
+				cfg:                &config.AppConfig{},
 			}
 			w := httptest.NewRecorder()
 			_, testServer := gin.CreateTestContext(w)
 
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetUsers)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetUser)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ExportUsers)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ImportUsers)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetRole)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetSpecialPermissions)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetPassword)
@@ -137,11 +344,49 @@ func TestApiV2Router_RegisterRoutes(t *testing.T) {
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.InvalidateServiceToken)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetTeams)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetTeam)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ExportTeams)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.CreateTeam)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RegenerateTeamJoinCode)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetTeamPrivacy)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetTeamMemberLimit)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.UpdateTeam)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RemoveTeamMember)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetTeamCreator)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteTeam)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetTeamJoinRequests)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ApproveTeamJoinRequest)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RejectTeamJoinRequest)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetMatchmakingProfile)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetMatchmakingProfile)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteMatchmakingProfile)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetTeamMatches)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.AcceptTeamMatch)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetTeamAdvert)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetTeamAdvert)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteTeamAdvert)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetProfileMatches)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetAuditEvents)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetWebhooks)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.CreateWebhook)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteWebhook)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetWebhookDeliveries)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetInvitations)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.CreateInvitation)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteInvitation)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.UnlockUser)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ResetTwoFactor)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetTeam)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.RemoveFromTeam)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.VerifyEmail)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.ResendEmailVerification)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RequestEmailChange)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ConfirmEmailChange)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ExportUserData)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteUser)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.BeginWebAuthnRegistration)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.FinishWebAuthnRegistration)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.GetWebAuthnCredentials)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteWebAuthnCredential)
 
 			router.RegisterRoutes(&testServer.RouterGroup)
 
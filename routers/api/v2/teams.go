@@ -7,10 +7,12 @@ import (
 	"github.com/unicsmcr/hs_auth/authorization/v2/common"
 	"github.com/unicsmcr/hs_auth/entities"
 	"github.com/unicsmcr/hs_auth/routers/api/models"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
 	"github.com/unicsmcr/hs_auth/services"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
 )
 
 // POST: /api/v2/teams
@@ -89,18 +91,39 @@ func (r *apiV2Router) CreateTeam(ctx *gin.Context) {
 	})
 }
 
-// GET: /api/v2/teams
-// Response: teams []entities.Team
+// GET: /api/v2/teams?name={name}&track={track}&tag={tag}&search={search}&sort={sort}&order={order}&limit={limit}&after={after}
+// Request:  (Optional) name string, start of the teams' names, case-sensitive; track string, judging track of the teams;
+//           tag string, one of the teams' tags; search string, words in the teams' names, descriptions or tags;
+//           sort (_id or name), order, limit and after as for GET /api/v2/users
+// Response: teams []entities.Team, pagination services.PageInfo
 // Headers:  Authorization -> token
 func (r *apiV2Router) GetTeams(ctx *gin.Context) {
-	teams, err := r.teamService.GetTeams(ctx)
+	page, err := parsePaginationQuery(ctx)
 	if err != nil {
-		r.logger.Error("could not fetch teams", zap.Error(err))
-		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		r.logger.Debug("invalid pagination parameters", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	teams, pageInfo, err := r.teamService.GetTeamsPage(ctx, parseTeamFilterQuery(ctx), page)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidCursor:
+			r.logger.Debug("invalid pagination cursor", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid cursor")
+		case services.ErrInvalidSortField:
+			r.logger.Debug("invalid sort field", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "teams cannot be sorted by the given field")
+		default:
+			r.logger.Error("could not fetch teams", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
 	}
 
 	ctx.JSON(http.StatusOK, getTeamsRes{
-		Teams: teams,
+		Teams:      teams,
+		Pagination: pageInfo,
 	})
 }
 
@@ -135,6 +158,431 @@ func (r *apiV2Router) GetTeam(ctx *gin.Context) {
 	})
 }
 
+// PUT: /api/v2/teams/(:id|me)/code
+// Response: team entities.Team, with its new join code
+// Headers:  Authorization -> token
+// Only the team's creator can regenerate the join code of their own team
+func (r *apiV2Router) RegenerateTeamJoinCode(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can regenerate its join code")
+	if !ok {
+		return
+	}
+
+	team, err := r.teamService.RegenerateJoinCodeForTeamWithID(ctx, teamId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+		case services.ErrNotFound:
+			r.logger.Debug("team not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "team not found")
+		default:
+			r.logger.Error("could not regenerate team's join code", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, regenerateTeamJoinCodeRes{
+		Team: *team,
+	})
+}
+
+// PUT: /api/v2/teams/(:id|me)/privacy
+// x-www-form-urlencoded
+// Request:  privacy string, either "open" or "closed"
+// Response: team entities.Team
+// Headers:  Authorization -> token
+// Only the team's creator can change the privacy of their own team
+func (r *apiV2Router) SetTeamPrivacy(ctx *gin.Context) {
+	privacy := ctx.PostForm("privacy")
+	if len(privacy) == 0 {
+		r.logger.Debug("privacy not provided")
+		models.SendAPIError(ctx, http.StatusBadRequest, "privacy must be provided")
+		return
+	}
+
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can change its privacy")
+	if !ok {
+		return
+	}
+
+	team, err := r.teamService.SetPrivacyForTeamWithID(ctx, teamId, entities.TeamPrivacySetting(privacy))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+		case services.ErrInvalidTeamPrivacy:
+			r.logger.Debug("invalid team privacy", zap.String("privacy", privacy))
+			models.SendAPIError(ctx, http.StatusBadRequest, "privacy must be either open or closed")
+		case services.ErrNotFound:
+			r.logger.Debug("team not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "team not found")
+		default:
+			r.logger.Error("could not set team's privacy", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, setTeamPrivacyRes{
+		Team: *team,
+	})
+}
+
+// PUT: /api/v2/teams/:id/limit
+// x-www-form-urlencoded
+// Request:  max_members int, the most members the team can have, 0 to use the configured limit
+// Response: team entities.Team
+// Headers:  Authorization -> token
+func (r *apiV2Router) SetTeamMemberLimit(ctx *gin.Context) {
+	maxMembers, err := strconv.Atoi(ctx.PostForm("max_members"))
+	if err != nil {
+		r.logger.Debug("invalid max members", zap.String("maxMembers", ctx.PostForm("max_members")))
+		models.SendAPIError(ctx, http.StatusBadRequest, "max_members must be a number")
+		return
+	}
+
+	team, err := r.teamService.SetMemberLimitForTeamWithID(ctx, ctx.Param("id"), maxMembers)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+		case services.ErrInvalidMemberLimit:
+			r.logger.Debug("invalid member limit", zap.Int("maxMembers", maxMembers))
+			models.SendAPIError(ctx, http.StatusBadRequest, "max_members cannot be negative")
+		case services.ErrNotFound:
+			r.logger.Debug("team not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "team not found")
+		default:
+			r.logger.Error("could not set team's member limit", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionTeamMemberLimitSet,
+		Target: team.ID.Hex(),
+		After:  entities.AuditValues{string(entities.TeamMaxMembers): team.MaxMembers},
+	})
+
+	ctx.JSON(http.StatusOK, setTeamMemberLimitRes{
+		Team: *team,
+	})
+}
+
+// GET: /api/v2/teams/(:id|me)/requests
+// Response: join_requests []entities.TeamJoinRequest
+// Headers:  Authorization -> token
+// Only the team's creator can see the pending requests to join their own team
+func (r *apiV2Router) GetTeamJoinRequests(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can see requests to join it")
+	if !ok {
+		return
+	}
+
+	requests, err := r.joinRequestService.GetJoinRequestsForTeamWithID(ctx, teamId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+		default:
+			r.logger.Error("could not fetch team's join requests", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getTeamJoinRequestsRes{
+		JoinRequests: requests,
+	})
+}
+
+// PUT: /api/v2/teams/(:id|me)/requests/:requestId/approve
+// Headers:  Authorization -> token
+// Only the team's creator can approve requests to join their own team
+func (r *apiV2Router) ApproveTeamJoinRequest(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can approve requests to join it")
+	if !ok {
+		return
+	}
+
+	err := r.joinRequestService.ApproveJoinRequestWithID(ctx, teamId, ctx.Param("requestId"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team or request id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team or request id")
+		case services.ErrNotFound:
+			r.logger.Debug("join request not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "join request not found")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user who made join request is already in team", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user is already in a team")
+		case services.ErrTeamFull:
+			r.logger.Debug("team is full", zap.String("teamId", teamId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "team is full")
+		default:
+			r.logger.Error("could not approve join request", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// PUT: /api/v2/teams/(:id|me)/requests/:requestId/reject
+// Headers:  Authorization -> token
+// Only the team's creator can reject requests to join their own team
+func (r *apiV2Router) RejectTeamJoinRequest(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can reject requests to join it")
+	if !ok {
+		return
+	}
+
+	err := r.joinRequestService.RejectJoinRequestWithID(ctx, teamId, ctx.Param("requestId"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team or request id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team or request id")
+		case services.ErrNotFound:
+			r.logger.Debug("join request not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "join request not found")
+		default:
+			r.logger.Error("could not reject join request", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// updatableTeamFields are the fields of teams which can be changed with PUT: /api/v2/teams/(:id|me)
+var updatableTeamFields = []entities.TeamField{entities.TeamName, entities.TeamDescription, entities.TeamRepositoryURL,
+	entities.TeamDemoURL, entities.TeamTrack, entities.TeamTableNumber, entities.TeamTags}
+
+// PUT: /api/v2/teams/(:id|me)
+// x-www-form-urlencoded
+// Request:  (Optional) name string
+//           (Optional) description string
+//           (Optional) repository_url string, http or https URL
+//           (Optional) demo_url string, http or https URL
+//           (Optional) track string, one of the configured judging tracks
+//           (Optional) table_number int
+//           (Optional) tags string, comma separated
+// Response: team entities.Team
+// Headers:  Authorization -> token
+// Only the given fields are updated, and empty values clear them, except for name.
+// Only the team's creator can update their own team
+func (r *apiV2Router) UpdateTeam(ctx *gin.Context) {
+	updatedFields := map[entities.TeamField]string{}
+	for _, field := range updatableTeamFields {
+		if value, exists := ctx.GetPostForm(string(field)); exists {
+			updatedFields[field] = value
+		}
+	}
+	if len(updatedFields) == 0 {
+		r.logger.Debug("no team fields to update provided")
+		models.SendAPIError(ctx, http.StatusBadRequest, "at least one field to update must be provided")
+		return
+	}
+
+	params, err := services.BuildTeamUpdateParams(r.cfg, updatedFields)
+	if err != nil {
+		r.logger.Debug("invalid team update params", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can update it")
+	if !ok {
+		return
+	}
+
+	team, err := r.teamService.UpdateTeamWithID(ctx, teamId, params)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+		case services.ErrNameTaken:
+			r.logger.Debug("team name taken", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "given team name is already taken")
+		case services.ErrNotFound:
+			r.logger.Debug("team not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "team not found")
+		default:
+			r.logger.Error("could not update team", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updateTeamRes{
+		Team: *team,
+	})
+}
+
+// DELETE: /api/v2/teams/(:id|me)/members/:userId
+// Headers:  Authorization -> token
+// Only the team's creator can remove members from their own team. The creator cannot remove themselves,
+// they have to hand the team over or leave it instead
+func (r *apiV2Router) RemoveTeamMember(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can remove its members")
+	if !ok {
+		return
+	}
+
+	err := r.teamService.RemoveUserWithIDFromTeamWithID(ctx, teamId, ctx.Param("userId"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team or user id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team or user id")
+		case services.ErrNotFound:
+			r.logger.Debug("team or user not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "team or user not found")
+		case services.ErrUserNotInTeam:
+			r.logger.Debug("user is not a member of team", zap.String("userId", ctx.Param("userId")))
+			models.SendAPIError(ctx, http.StatusNotFound, "user is not a member of the team")
+		case services.ErrUserIsTeamCreator:
+			r.logger.Debug("team's creator cannot be removed", zap.String("userId", ctx.Param("userId")))
+			models.SendAPIError(ctx, http.StatusBadRequest, "the team's creator cannot be removed from the team")
+		default:
+			r.logger.Error("could not remove member from team", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// PUT: /api/v2/teams/(:id|me)/creator
+// x-www-form-urlencoded
+// Request:  creator primitive.ObjectId, the id of the team member to hand the team over to
+// Response: team entities.Team
+// Headers:  Authorization -> token
+// Only the team's creator can hand over their own team
+func (r *apiV2Router) SetTeamCreator(ctx *gin.Context) {
+	creatorId := ctx.PostForm("creator")
+	if len(creatorId) == 0 {
+		r.logger.Debug("creator not provided")
+		models.SendAPIError(ctx, http.StatusBadRequest, "creator must be provided")
+		return
+	}
+
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can hand it over")
+	if !ok {
+		return
+	}
+
+	team, err := r.teamService.SetCreatorForTeamWithID(ctx, teamId, creatorId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team or user id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team or user id")
+		case services.ErrNotFound:
+			r.logger.Debug("team or user not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "team or user not found")
+		case services.ErrUserNotInTeam:
+			r.logger.Debug("user is not a member of team", zap.String("userId", creatorId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "the new creator must be a member of the team")
+		default:
+			r.logger.Error("could not set team's creator", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, setTeamCreatorRes{
+		Team: *team,
+	})
+}
+
+// DELETE: /api/v2/teams/(:id|me)
+// Headers:  Authorization -> token
+// Only the team's creator can disband their own team. All of its members are removed from it
+func (r *apiV2Router) DeleteTeam(ctx *gin.Context) {
+	teamId, ok := r.resolveTeamIdForCreator(ctx, ctx.Param("id"), "only the team's creator can disband it")
+	if !ok {
+		return
+	}
+
+	err := r.teamService.DisbandTeamWithID(ctx, teamId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid team id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid team id")
+		case services.ErrNotFound:
+			r.logger.Debug("team not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "team not found")
+		default:
+			r.logger.Error("could not disband team", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// resolveTeamIdForCreator returns the id of the team the operation is for. When teamId is "me", the team is
+// the one of the user making the request, who has to be its creator. Sends the error response and returns
+// false when the team cannot be resolved
+func (r *apiV2Router) resolveTeamIdForCreator(ctx *gin.Context, teamId string, forbiddenMessage string) (string, bool) {
+	if teamId != "me" {
+		return teamId, true
+	}
+
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		switch errors.Cause(err) {
+		case common.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		case common.ErrInvalidTokenType:
+			r.logger.Debug("invalid token type", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "provided token is of invalid type for the requested operation")
+		default:
+			r.logger.Error("could not extract token type", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return "", false
+	}
+
+	team, err := r.teamService.GetTeamForUserWithID(ctx, userId.Hex())
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNotFound:
+			r.logger.Debug("team not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "team not found")
+		default:
+			r.logger.Error("could not fetch team", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return "", false
+	}
+
+	if team.Creator != userId {
+		r.logger.Debug("user is not the team's creator", zap.String("userId", userId.Hex()), zap.String("teamId", team.ID.Hex()))
+		models.SendAPIError(ctx, http.StatusForbidden, forbiddenMessage)
+		return "", false
+	}
+
+	return team.ID.Hex(), true
+}
+
 func (r *apiV2Router) getTeamCtxAware(ctx *gin.Context, teamId string) (*entities.Team, error) {
 	var (
 		team *entities.Team
@@ -26,6 +26,8 @@ type teamsTestSetup struct {
 	router         APIV2Router
 	mockAuthorizer *mock_v2.MockAuthorizer
 	mockTService   *mock_services.MockTeamService
+	mockJRService  *mock_services.MockTeamJoinRequestService
+	mockAService   *mock_services.MockAuditService
 	testTeam       *entities.Team
 	testCtx        *gin.Context
 	w              *httptest.ResponseRecorder
@@ -36,8 +38,10 @@ func setupTeamsTest(t *testing.T) *teamsTestSetup {
 	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
 	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
 	mockTService := mock_services.NewMockTeamService(ctrl)
+	mockJRService := mock_services.NewMockTeamJoinRequestService(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
 
-	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{}, mockAuthorizer, nil, mockTService, nil, nil, mockTimeProvider)
+	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{}, mockAuthorizer, nil, mockTService, nil, nil, mockAService, nil, nil, nil, nil, nil, nil, mockJRService, nil, mockTimeProvider)
 
 	w := httptest.NewRecorder()
 	testCtx, _ := gin.CreateTestContext(w)
@@ -56,12 +60,15 @@ func setupTeamsTest(t *testing.T) *teamsTestSetup {
 		w:              w,
 		testTeam:       &testTeam,
 		mockTService:   mockTService,
+		mockJRService:  mockJRService,
+		mockAService:   mockAService,
 	}
 }
 
 func TestApiV2Router_GetTeams(t *testing.T) {
 	tests := []struct {
 		name        string
+		query       string
 		prep        func(setup *teamsTestSetup)
 		wantResCode int
 		wantRes     *getTeamsRes
@@ -69,22 +76,46 @@ func TestApiV2Router_GetTeams(t *testing.T) {
 		{
 			name: "should return 500 when team service returns error",
 			prep: func(setup *teamsTestSetup) {
-				setup.mockTService.EXPECT().GetTeams(setup.testCtx).Return(nil, errors.New("service err")).
-					Times(1)
+				setup.mockTService.EXPECT().GetTeamsPage(setup.testCtx, gomock.Any(), gomock.Any()).
+					Return(nil, nil, errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
+		{
+			name:        "should return 400 when limit is invalid",
+			query:       "limit=abc",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 400 when team service returns ErrInvalidCursor",
+			query: "after=invalid",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().GetTeamsPage(setup.testCtx, gomock.Any(), gomock.Any()).
+					Return(nil, nil, services.ErrInvalidCursor).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 400 when team service returns ErrInvalidSortField",
+			query: "sort=creator",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().GetTeamsPage(setup.testCtx, gomock.Any(), gomock.Any()).
+					Return(nil, nil, services.ErrInvalidSortField).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
 		{
 			name: "should return 200 and expected teams",
 			prep: func(setup *teamsTestSetup) {
-				setup.mockTService.EXPECT().GetTeams(setup.testCtx).Return([]entities.Team{
+				setup.mockTService.EXPECT().GetTeamsPage(setup.testCtx, services.TeamFilter{},
+					services.Pagination{}).Return([]entities.Team{
 					{
 						Name: "Bobs the Testers",
 					},
 					{
 						Name: "Robs the Testers",
 					},
-				}, nil).Times(1)
+				}, &services.PageInfo{}, nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 			wantRes: &getTeamsRes{
@@ -96,7 +127,41 @@ func TestApiV2Router_GetTeams(t *testing.T) {
 						Name: "Robs the Testers",
 					},
 				},
+				Pagination: &services.PageInfo{},
+			},
+		},
+		{
+			name:  "should pass filter and pagination to team service",
+			query: "name=Bobs&sort=name&limit=1&after=cursor",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().GetTeamsPage(setup.testCtx, services.TeamFilter{NamePrefix: "Bobs"},
+					services.Pagination{Limit: 1, After: "cursor", SortBy: "name"}).
+					Return([]entities.Team{{Name: "Bobs the Testers"}}, &services.PageInfo{NextCursor: "next", HasMore: true}, nil).
+					Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &getTeamsRes{
+				Teams:      []entities.Team{{Name: "Bobs the Testers"}},
+				Pagination: &services.PageInfo{NextCursor: "next", HasMore: true},
+			},
+		},
+		{
+			name:  "should use default page limit when only after is given",
+			query: "after=cursor",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().GetTeamsPage(setup.testCtx, services.TeamFilter{},
+					services.Pagination{Limit: defaultPageLimit, After: "cursor"}).Return([]entities.Team{}, &services.PageInfo{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:  "should pass track, tag and search filters to team service",
+			query: "track=Fintech&tag=robots&search=robot+arm",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().GetTeamsPage(setup.testCtx, services.TeamFilter{Track: "Fintech", Tag: "robots", Search: "robot arm"},
+					services.Pagination{}).Return([]entities.Team{}, &services.PageInfo{}, nil).Times(1)
 			},
+			wantResCode: http.StatusOK,
 		},
 	}
 
@@ -104,6 +169,7 @@ func TestApiV2Router_GetTeams(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			setup := setupTeamsTest(t)
 			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/test?"+tt.query, nil)
 			if tt.prep != nil {
 				tt.prep(setup)
 			}
@@ -455,3 +521,1010 @@ func TestApiV2Router_CreateTeam(t *testing.T) {
 		})
 	}
 }
+
+func TestApiV2Router_RegenerateTeamJoinCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		teamId      string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+		wantRes     *regenerateTeamJoinCodeRes
+	}{
+		{
+			name:   "should return 401 when team id is me and authorizer returns ErrInvalidToken",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, common.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "should return 400 when team id is me and authorizer returns ErrInvalidTokenType",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, common.ErrInvalidTokenType).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when team id is me and user is not in a team",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 403 when team id is me and user is not the team's creator",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 400 when team service returns ErrInvalidID",
+			teamId: "invalid",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().RegenerateJoinCodeForTeamWithID(setup.testCtx, "invalid").
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when team service returns ErrNotFound",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().RegenerateJoinCodeForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 500 when team service returns unknown error",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().RegenerateJoinCodeForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 200 and team with new join code when team id is specified",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.testTeam.JoinCode = "ABCD2345"
+				setup.mockTService.EXPECT().RegenerateJoinCodeForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &regenerateTeamJoinCodeRes{
+				Team: entities.Team{
+					ID:       testTeamId,
+					Name:     "Bobs the Testers",
+					Creator:  testUserId,
+					JoinCode: "ABCD2345",
+				},
+			},
+		},
+		{
+			name:   "should return 200 when team id is me and user is the team's creator",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockTService.EXPECT().RegenerateJoinCodeForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.RegenerateTeamJoinCode(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes regenerateTeamJoinCodeRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_SetTeamPrivacy(t *testing.T) {
+	tests := []struct {
+		name        string
+		teamId      string
+		privacy     string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+		wantRes     *setTeamPrivacyRes
+	}{
+		{
+			name:        "should return 400 when privacy is not provided",
+			teamId:      testTeamId.Hex(),
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should return 403 when team id is me and user is not the team's creator",
+			teamId:  "me",
+			privacy: "closed",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:    "should return 400 when team service returns ErrInvalidTeamPrivacy",
+			teamId:  testTeamId.Hex(),
+			privacy: "secret",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetPrivacyForTeamWithID(setup.testCtx, testTeamId.Hex(), entities.TeamPrivacySetting("secret")).
+					Return(nil, services.ErrInvalidTeamPrivacy).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should return 404 when team service returns ErrNotFound",
+			teamId:  testTeamId.Hex(),
+			privacy: "closed",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetPrivacyForTeamWithID(setup.testCtx, testTeamId.Hex(), entities.TeamClosed).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:    "should return 500 when team service returns unknown error",
+			teamId:  testTeamId.Hex(),
+			privacy: "closed",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetPrivacyForTeamWithID(setup.testCtx, testTeamId.Hex(), entities.TeamClosed).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 200 and updated team",
+			teamId:  "me",
+			privacy: "closed",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockTService.EXPECT().SetPrivacyForTeamWithID(setup.testCtx, testTeamId.Hex(), entities.TeamClosed).
+					Return(&entities.Team{ID: testTeamId, Name: "Bobs the Testers", Creator: testUserId, Privacy: entities.TeamClosed}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &setTeamPrivacyRes{
+				Team: entities.Team{
+					ID:      testTeamId,
+					Name:    "Bobs the Testers",
+					Creator: testUserId,
+					Privacy: entities.TeamClosed,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, map[string]string{
+				"privacy": tt.privacy,
+			})
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.SetTeamPrivacy(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes setTeamPrivacyRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_GetTeamJoinRequests(t *testing.T) {
+	testRequest := entities.TeamJoinRequest{
+		ID:   primitive.NewObjectID(),
+		Team: testTeamId,
+		User: primitive.NewObjectID(),
+	}
+
+	tests := []struct {
+		name        string
+		teamId      string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+		wantRes     *getTeamJoinRequestsRes
+	}{
+		{
+			name:   "should return 403 when team id is me and user is not the team's creator",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 400 when join request service returns ErrInvalidID",
+			teamId: "invalid",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().GetJoinRequestsForTeamWithID(setup.testCtx, "invalid").
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 500 when join request service returns unknown error",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().GetJoinRequestsForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 200 and team's join requests",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().GetJoinRequestsForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return([]entities.TeamJoinRequest{testRequest}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &getTeamJoinRequestsRes{
+				JoinRequests: []entities.TeamJoinRequest{testRequest},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.GetTeamJoinRequests(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes getTeamJoinRequestsRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_ApproveTeamJoinRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		teamId      string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+	}{
+		{
+			name:   "should return 403 when team id is me and user is not the team's creator",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 400 when join request service returns ErrInvalidID",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when join request service returns ErrNotFound",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 400 when join request service returns ErrUserInTeam",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrUserInTeam).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 500 when join request service returns unknown error",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 204 when team id is me and user is the team's creator",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId, "requestId": "test_request"})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.ApproveTeamJoinRequest(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
+func TestApiV2Router_RejectTeamJoinRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		teamId      string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+	}{
+		{
+			name:   "should return 403 when team id is me and user is not the team's creator",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 400 when join request service returns ErrInvalidID",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().RejectJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when join request service returns ErrNotFound",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().RejectJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 500 when join request service returns unknown error",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().RejectJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 204",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockJRService.EXPECT().RejectJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId, "requestId": "test_request"})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.RejectTeamJoinRequest(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
+func TestApiV2Router_SetTeamMemberLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		teamId      string
+		maxMembers  string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+		wantRes     *setTeamMemberLimitRes
+	}{
+		{
+			name:        "should return 400 when max_members is not a number",
+			teamId:      testTeamId.Hex(),
+			maxMembers:  "many",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 400 when team service returns ErrInvalidID",
+			teamId:     "invalid",
+			maxMembers: "8",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetMemberLimitForTeamWithID(setup.testCtx, "invalid", 8).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 400 when team service returns ErrInvalidMemberLimit",
+			teamId:     testTeamId.Hex(),
+			maxMembers: "-1",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetMemberLimitForTeamWithID(setup.testCtx, testTeamId.Hex(), -1).
+					Return(nil, services.ErrInvalidMemberLimit).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 404 when team service returns ErrNotFound",
+			teamId:     testTeamId.Hex(),
+			maxMembers: "8",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetMemberLimitForTeamWithID(setup.testCtx, testTeamId.Hex(), 8).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:       "should return 500 when team service returns unknown error",
+			teamId:     testTeamId.Hex(),
+			maxMembers: "8",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetMemberLimitForTeamWithID(setup.testCtx, testTeamId.Hex(), 8).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "should return 200 and updated team",
+			teamId:     testTeamId.Hex(),
+			maxMembers: "8",
+			prep: func(setup *teamsTestSetup) {
+				setup.testTeam.MaxMembers = 8
+				setup.mockTService.EXPECT().SetMemberLimitForTeamWithID(setup.testCtx, testTeamId.Hex(), 8).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionTeamMemberLimitSet,
+					Target: testTeamId.Hex(),
+					After:  entities.AuditValues{string(entities.TeamMaxMembers): 8},
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &setTeamMemberLimitRes{
+				Team: entities.Team{
+					ID:         testTeamId,
+					Name:       "Bobs the Testers",
+					Creator:    testUserId,
+					MaxMembers: 8,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, map[string]string{
+				"max_members": tt.maxMembers,
+			})
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.SetTeamMemberLimit(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes setTeamMemberLimitRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_UpdateTeam(t *testing.T) {
+	renameParams := services.TeamUpdateParams{entities.TeamName: "Bobs the Renamed"}
+
+	tests := []struct {
+		name        string
+		teamId      string
+		params      map[string]string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+		wantRes     *updateTeamRes
+	}{
+		{
+			name:        "should return 400 when no fields are provided",
+			teamId:      testTeamId.Hex(),
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when name is empty",
+			teamId:      testTeamId.Hex(),
+			params:      map[string]string{"name": ""},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when repository url is not a http url",
+			teamId:      testTeamId.Hex(),
+			params:      map[string]string{"repository_url": "ftp://example.com/repo"},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when table number is invalid",
+			teamId:      testTeamId.Hex(),
+			params:      map[string]string{"table_number": "-1"},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 403 when team id is me and user is not the team's creator",
+			teamId: "me",
+			params: map[string]string{"name": "Bobs the Renamed"},
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 400 when team service returns ErrNameTaken",
+			teamId: testTeamId.Hex(),
+			params: map[string]string{"name": "Bobs the Renamed"},
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().UpdateTeamWithID(setup.testCtx, testTeamId.Hex(), renameParams).
+					Return(nil, services.ErrNameTaken).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when team service returns ErrNotFound",
+			teamId: testTeamId.Hex(),
+			params: map[string]string{"name": "Bobs the Renamed"},
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().UpdateTeamWithID(setup.testCtx, testTeamId.Hex(), renameParams).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 500 when team service returns unknown error",
+			teamId: testTeamId.Hex(),
+			params: map[string]string{"name": "Bobs the Renamed"},
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().UpdateTeamWithID(setup.testCtx, testTeamId.Hex(), renameParams).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 200 and renamed team",
+			teamId: "me",
+			params: map[string]string{"name": "Bobs the Renamed"},
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockTService.EXPECT().UpdateTeamWithID(setup.testCtx, testTeamId.Hex(), renameParams).
+					Return(&entities.Team{ID: testTeamId, Name: "Bobs the Renamed", Creator: testUserId}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &updateTeamRes{
+				Team: entities.Team{
+					ID:      testTeamId,
+					Name:    "Bobs the Renamed",
+					Creator: testUserId,
+				},
+			},
+		},
+		{
+			name:   "should return 200 and update only the given fields",
+			teamId: testTeamId.Hex(),
+			params: map[string]string{
+				"description":  "We make robots",
+				"demo_url":     "https://example.com/demo",
+				"table_number": "12",
+				"tags":         "Robots, hardware,robots",
+			},
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().UpdateTeamWithID(setup.testCtx, testTeamId.Hex(), services.TeamUpdateParams{
+					entities.TeamDescription: "We make robots",
+					entities.TeamDemoURL:     "https://example.com/demo",
+					entities.TeamTableNumber: 12,
+					entities.TeamTags:        []string{"robots", "hardware"},
+				}).Return(&entities.Team{ID: testTeamId, Name: "Bobs the Testers", Creator: testUserId, TableNumber: 12}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &updateTeamRes{
+				Team: entities.Team{
+					ID:          testTeamId,
+					Name:        "Bobs the Testers",
+					Creator:     testUserId,
+					TableNumber: 12,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, tt.params)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.UpdateTeam(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes updateTeamRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_RemoveTeamMember(t *testing.T) {
+	memberId := primitive.NewObjectID().Hex()
+	tests := []struct {
+		name        string
+		teamId      string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+	}{
+		{
+			name:   "should return 403 when team id is me and user is not the team's creator",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 400 when team service returns ErrInvalidID",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), memberId).
+					Return(services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when team service returns ErrUserNotInTeam",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), memberId).
+					Return(services.ErrUserNotInTeam).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 400 when team service returns ErrUserIsTeamCreator",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), memberId).
+					Return(services.ErrUserIsTeamCreator).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 500 when team service returns unknown error",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), memberId).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 204 when member is removed",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), memberId).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodDelete, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId, "userId": memberId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.RemoveTeamMember(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
+func TestApiV2Router_SetTeamCreator(t *testing.T) {
+	newCreatorId := primitive.NewObjectID()
+	tests := []struct {
+		name        string
+		teamId      string
+		creator     string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+		wantRes     *setTeamCreatorRes
+	}{
+		{
+			name:        "should return 400 when creator is not provided",
+			teamId:      testTeamId.Hex(),
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should return 403 when team id is me and user is not the team's creator",
+			teamId:  "me",
+			creator: newCreatorId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:    "should return 400 when team service returns ErrUserNotInTeam",
+			teamId:  testTeamId.Hex(),
+			creator: newCreatorId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetCreatorForTeamWithID(setup.testCtx, testTeamId.Hex(), newCreatorId.Hex()).
+					Return(nil, services.ErrUserNotInTeam).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should return 404 when team service returns ErrNotFound",
+			teamId:  testTeamId.Hex(),
+			creator: newCreatorId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetCreatorForTeamWithID(setup.testCtx, testTeamId.Hex(), newCreatorId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:    "should return 500 when team service returns unknown error",
+			teamId:  testTeamId.Hex(),
+			creator: newCreatorId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().SetCreatorForTeamWithID(setup.testCtx, testTeamId.Hex(), newCreatorId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 200 and updated team",
+			teamId:  "me",
+			creator: newCreatorId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockTService.EXPECT().SetCreatorForTeamWithID(setup.testCtx, testTeamId.Hex(), newCreatorId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Name: "Bobs the Testers", Creator: newCreatorId}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &setTeamCreatorRes{
+				Team: entities.Team{
+					ID:      testTeamId,
+					Name:    "Bobs the Testers",
+					Creator: newCreatorId,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, map[string]string{
+				"creator": tt.creator,
+			})
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.SetTeamCreator(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+
+			if tt.wantRes != nil {
+				var actualRes setTeamCreatorRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_DeleteTeam(t *testing.T) {
+	tests := []struct {
+		name        string
+		teamId      string
+		prep        func(setup *teamsTestSetup)
+		wantResCode int
+	}{
+		{
+			name:   "should return 403 when team id is me and user is not the team's creator",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.testTeam.Creator = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 400 when team service returns ErrInvalidID",
+			teamId: "invalid",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().DisbandTeamWithID(setup.testCtx, "invalid").
+					Return(services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when team service returns ErrNotFound",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().DisbandTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 500 when team service returns unknown error",
+			teamId: testTeamId.Hex(),
+			prep: func(setup *teamsTestSetup) {
+				setup.mockTService.EXPECT().DisbandTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 204 when team is disbanded",
+			teamId: "me",
+			prep: func(setup *teamsTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testTeam, nil).Times(1)
+				setup.mockTService.EXPECT().DisbandTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamsTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodDelete, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": tt.teamId})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.DeleteTeam(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
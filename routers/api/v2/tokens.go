@@ -10,7 +10,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/entities"
 	"github.com/unicsmcr/hs_auth/routers/api/models"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
 	"github.com/unicsmcr/hs_auth/services"
 	"go.uber.org/zap"
 )
@@ -74,6 +76,14 @@ func (r *apiV2Router) CreateServiceToken(ctx *gin.Context) {
 		return
 	}
 
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionServiceTokenCreated,
+		After: entities.AuditValues{
+			"allowed_uris": common.UniformResourceIdentifiers(parsedURIs),
+			"expires_at":   req.ExpiresAt,
+		},
+	})
+
 	ctx.JSON(http.StatusOK, serviceTokenRes{
 		Token: token,
 	})
@@ -106,6 +116,10 @@ func (r *apiV2Router) InvalidateServiceToken(ctx *gin.Context) {
 		return
 	}
 
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionServiceTokenInvalidated,
+	})
+
 	ctx.Status(http.StatusNoContent)
 }
 
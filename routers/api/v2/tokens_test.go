@@ -32,6 +32,7 @@ type tokensTestSetup struct {
 	mockTService     *mock_services.MockTokenService
 	mockAuthorizer   *mock_v2.MockAuthorizer
 	mockTimeProvider *mock_utils.MockTimeProvider
+	mockAService     *mock_services.MockAuditService
 	testToken        *entities.ServiceToken
 	testCtx          *gin.Context
 	w                *httptest.ResponseRecorder
@@ -42,8 +43,9 @@ func setupTokensTest(t *testing.T) *tokensTestSetup {
 	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
 	mockTService := mock_services.NewMockTokenService(ctrl)
 	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
 
-	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{}, mockAuthorizer, nil, nil, mockTService, nil, mockTimeProvider)
+	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{}, mockAuthorizer, nil, nil, mockTService, nil, mockAService, nil, nil, nil, nil, nil, nil, nil, nil, mockTimeProvider)
 
 	w := httptest.NewRecorder()
 	testCtx, _ := gin.CreateTestContext(w)
@@ -58,6 +60,7 @@ func setupTokensTest(t *testing.T) *tokensTestSetup {
 		mockTService:     mockTService,
 		mockAuthorizer:   mockAuthorizer,
 		mockTimeProvider: mockTimeProvider,
+		mockAService:     mockAService,
 		testToken:        &testToken,
 		testCtx:          testCtx,
 		w:                w,
@@ -84,6 +87,7 @@ func TestApiV2Router_CreateServiceToken(t *testing.T) {
 					Return(testUserId, nil).Times(1)
 				setup.mockTService.EXPECT().CreateServiceToken(setup.testCtx, gomock.Any(), gomock.Any(), setup.testToken.JWT).
 					Return(setup.testToken, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 			wantRes: &serviceTokenRes{
@@ -100,6 +104,7 @@ func TestApiV2Router_CreateServiceToken(t *testing.T) {
 					Return(testUserId, nil).Times(1)
 				setup.mockTService.EXPECT().CreateServiceToken(setup.testCtx, gomock.Any(), gomock.Any(), setup.testToken.JWT).
 					Return(setup.testToken, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 			wantRes: &serviceTokenRes{
@@ -213,6 +218,9 @@ func TestApiV2Router_InvalidateServiceToken(t *testing.T) {
 			prep: func(setup *tokensTestSetup) {
 				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testTokenId.Hex()).
 					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionServiceTokenInvalidated,
+				})).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
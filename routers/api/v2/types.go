@@ -1,8 +1,12 @@
 package v2
 
 import (
+	"time"
+
 	"github.com/unicsmcr/hs_auth/authorization/v2/common"
 	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
 )
 
 type loginRes struct {
@@ -14,19 +18,46 @@ type serviceTokenRes struct {
 }
 
 type getUsersRes struct {
-	Users []entities.User `json:"users"`
+	Users      []entities.User    `json:"users"`
+	Pagination *services.PageInfo `json:"pagination,omitempty"`
 }
 
 type getUserRes struct {
 	User entities.User `json:"user"`
 }
 
+type exportUserDataRes struct {
+	User               entities.User                `json:"user"`
+	Team               *entities.Team               `json:"team"`
+	MatchmakingProfile *entities.MatchmakingProfile `json:"matchmaking_profile"`
+	Tokens             []entities.ServiceToken      `json:"tokens"`
+	AuditEvents        []entities.AuditEvent        `json:"audit_events"`
+	ExportedAt         time.Time                    `json:"exported_at"`
+}
+
+type importUsersRes struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Rows    []importedUserRow `json:"rows"`
+}
+
+// importedUserRow is the result of importing one row of the CSV, where the header is row 1
+type importedUserRow struct {
+	Row    int                `json:"row"`
+	Email  string             `json:"email"`
+	Status importedUserStatus `json:"status"`
+	UserID string             `json:"user_id,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
 type getAuthorizedResourcesRes struct {
 	AuthorizedUris []common.UniformResourceIdentifier `json:"authorizedUris"`
 }
 
 type getTeamsRes struct {
-	Teams []entities.Team `json:"teams"`
+	Teams      []entities.Team    `json:"teams"`
+	Pagination *services.PageInfo `json:"pagination,omitempty"`
 }
 
 type getTeamRes struct {
@@ -36,3 +67,97 @@ type getTeamRes struct {
 type createTeamRes struct {
 	Team entities.Team `json:"team"`
 }
+
+type regenerateTeamJoinCodeRes struct {
+	Team entities.Team `json:"team"`
+}
+
+type setTeamPrivacyRes struct {
+	Team entities.Team `json:"team"`
+}
+
+type updateTeamRes struct {
+	Team entities.Team `json:"team"`
+}
+
+type setTeamCreatorRes struct {
+	Team entities.Team `json:"team"`
+}
+
+type setTeamMemberLimitRes struct {
+	Team entities.Team `json:"team"`
+}
+
+type getTeamJoinRequestsRes struct {
+	JoinRequests []entities.TeamJoinRequest `json:"join_requests"`
+}
+
+type createTeamJoinRequestRes struct {
+	JoinRequest entities.TeamJoinRequest `json:"join_request"`
+}
+
+type getAuditEventsRes struct {
+	Events []entities.AuditEvent `json:"events"`
+}
+
+type getInvitationsRes struct {
+	Invitations []entities.Invitation `json:"invitations"`
+}
+
+type createInvitationRes struct {
+	Invitation entities.Invitation `json:"invitation"`
+	// Link is the registration page's URL with the invitation
+	Link string `json:"link"`
+}
+
+type getWebhooksRes struct {
+	Webhooks []entities.Webhook `json:"webhooks"`
+}
+
+type createWebhookRes struct {
+	Webhook entities.Webhook `json:"webhook"`
+}
+
+type getWebhookDeliveriesRes struct {
+	Deliveries []entities.WebhookDelivery `json:"deliveries"`
+}
+
+type beginWebAuthnRegistrationRes struct {
+	PublicKey utils.WebAuthnCreationOptions `json:"publicKey"`
+}
+
+type finishWebAuthnRegistrationRes struct {
+	Credential entities.WebAuthnCredential `json:"credential"`
+}
+
+type beginWebAuthnLoginRes struct {
+	PublicKey utils.WebAuthnRequestOptions `json:"publicKey"`
+}
+
+type getWebAuthnCredentialsRes struct {
+	Credentials []entities.WebAuthnCredential `json:"credentials"`
+}
+
+type getMatchmakingProfileRes struct {
+	Profile entities.MatchmakingProfile `json:"profile"`
+}
+
+type setMatchmakingProfileRes struct {
+	Profile entities.MatchmakingProfile `json:"profile"`
+}
+
+type getTeamMatchesRes struct {
+	Matches []entities.TeamMatch `json:"matches"`
+}
+
+type getTeamAdvertRes struct {
+	Advert entities.TeamAdvert `json:"advert"`
+}
+
+type setTeamAdvertRes struct {
+	Advert entities.TeamAdvert `json:"advert"`
+}
+
+type getProfileMatchesRes struct {
+	Matches []entities.ProfileMatch `json:"matches"`
+}
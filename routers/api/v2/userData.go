@@ -0,0 +1,271 @@
+package v2
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	v2 "github.com/unicsmcr/hs_auth/authorization/v2"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
+	"github.com/unicsmcr/hs_auth/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// GET: /api/v2/users/(:id|me)/export
+// Response: exportUserDataRes
+// Headers:  Authorization -> token
+func (r *apiV2Router) ExportUserData(ctx *gin.Context) {
+	user, err := r.getUserCtxAware(ctx, ctx.Param("id"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case common.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		case common.ErrInvalidTokenType:
+			r.logger.Debug("invalid token type", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "provided token is of invalid type for the requested operation")
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "user not found")
+		default:
+			r.logger.Error("could not fetch user", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	var team *entities.Team
+	if user.Team != primitive.NilObjectID {
+		team, err = r.teamService.GetTeamWithID(ctx, user.Team.Hex())
+		if err != nil && errors.Cause(err) != services.ErrNotFound {
+			r.logger.Error("could not fetch user's team", zap.String("userId", user.ID.Hex()), zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+			return
+		}
+	}
+
+	profile, err := r.matchmakingService.GetProfileForUserWithID(ctx, user.ID.Hex())
+	if err != nil && errors.Cause(err) != services.ErrNotFound {
+		r.logger.Error("could not fetch user's matchmaking profile", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	tokens, err := r.tokenService.GetServiceTokensWithCreator(ctx, user.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not fetch user's service tokens", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	auditEvents, err := r.getAuditEventsForUser(ctx, *user)
+	if err != nil {
+		r.logger.Error("could not fetch user's audit events", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionUserDataExported,
+		Target: user.ID.Hex(),
+	})
+
+	ctx.Header("Content-Disposition", `attachment; filename="hs_auth_export.json"`)
+	ctx.JSON(http.StatusOK, exportUserDataRes{
+		User:               *user,
+		Team:               team,
+		MatchmakingProfile: profile,
+		Tokens:             tokens,
+		AuditEvents:        auditEvents,
+		ExportedAt:         r.timeProvider.Now(),
+	})
+}
+
+// getAuditEventsForUser returns the events performed by or on the user, newest first.
+// Failed logins are recorded against the email that was entered, so events targeting
+// the user's current and previous emails are included too
+func (r *apiV2Router) getAuditEventsForUser(ctx *gin.Context, user entities.User) ([]entities.AuditEvent, error) {
+	performedEvents, err := r.auditService.GetEvents(ctx, services.AuditEventFilter{ActorID: user.ID.Hex()})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch events performed by user")
+	}
+	targetedEvents, err := r.auditService.GetEvents(ctx, services.AuditEventFilter{Target: user.ID.Hex()})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch events targeting user")
+	}
+
+	eventLists := [][]entities.AuditEvent{performedEvents, targetedEvents}
+	for _, email := range emailsOfUser(user, targetedEvents) {
+		emailEvents, err := r.auditService.GetEvents(ctx, services.AuditEventFilter{Target: email})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not fetch events targeting user's email")
+		}
+		eventLists = append(eventLists, emailEvents)
+	}
+
+	// the lists overlap, e.g. on the events the user performed on themselves
+	events := []entities.AuditEvent{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, eventList := range eventLists {
+		for _, event := range eventList {
+			if !seen[event.ID] {
+				seen[event.ID] = true
+				events = append(events, event)
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.After(events[j].Timestamp)
+	})
+
+	return events, nil
+}
+
+// emailsOfUser returns the user's current email and the previous ones recorded by
+// the email changes among the given events targeting the user
+func emailsOfUser(user entities.User, targetedEvents []entities.AuditEvent) []string {
+	emails := []string{user.Email}
+	for _, event := range targetedEvents {
+		if event.Action != entities.AuditActionEmailChanged {
+			continue
+		}
+		if email, ok := event.Before[string(entities.UserEmail)].(string); ok && !containsString(emails, email) {
+			emails = append(emails, email)
+		}
+	}
+
+	return emails
+}
+
+// DELETE: /api/v2/users/(:id|me)?confirm=<email>
+// Request:  confirm string (the user's email)
+// Headers:  Authorization -> token
+func (r *apiV2Router) DeleteUser(ctx *gin.Context) {
+	user, err := r.getUserCtxAware(ctx, ctx.Param("id"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case common.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		case common.ErrInvalidTokenType:
+			r.logger.Debug("invalid token type", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "provided token is of invalid type for the requested operation")
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "user not found")
+		default:
+			r.logger.Error("could not fetch user", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	if !strings.EqualFold(ctx.Query("confirm"), user.Email) {
+		r.logger.Debug("user deletion not confirmed", zap.String("userId", user.ID.Hex()))
+		models.SendAPIError(ctx, http.StatusBadRequest, "the user's email must be provided in confirm to delete the user")
+		return
+	}
+
+	if user.Team != primitive.NilObjectID {
+		err = r.teamService.RemoveUserWithIDFromTheirTeam(ctx, user.ID.Hex())
+		if err != nil && errors.Cause(err) != services.ErrUserNotInTeam {
+			r.logger.Error("could not remove user from their team", zap.String("userId", user.ID.Hex()), zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+			return
+		}
+	}
+
+	err = r.tokenService.DeleteServiceTokensWithCreator(ctx, user.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not delete user's service tokens", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	err = r.webAuthnService.DeleteCredentialsForUser(ctx, user.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not delete user's passkeys", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	err = r.identityService.UnlinkIdentitiesForUser(ctx, user.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not unlink user's accounts", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	err = r.joinRequestService.DeleteJoinRequestsForUserWithID(ctx, user.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not delete user's team join requests", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	err = r.matchmakingService.DeleteProfileForUserWithID(ctx, user.ID.Hex())
+	if err != nil && errors.Cause(err) != services.ErrNotFound {
+		r.logger.Error("could not delete user's matchmaking profile", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	targetedEvents, err := r.auditService.GetEvents(ctx, services.AuditEventFilter{Target: user.ID.Hex()})
+	if err != nil {
+		r.logger.Error("could not fetch events targeting user", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	emails := emailsOfUser(*user, targetedEvents)
+	for _, email := range emails {
+		err = r.loginAttemptService.DeleteLoginAttemptsForEmail(ctx, email)
+		if err != nil {
+			r.logger.Error("could not delete user's login attempts", zap.String("userId", user.ID.Hex()), zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+			return
+		}
+	}
+
+	err = r.auditService.AnonymiseEventsForUser(ctx, user.ID.Hex(), emails)
+	if err != nil {
+		r.logger.Error("could not anonymise user's audit events", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	err = r.userService.DeleteUserWithID(ctx, user.ID.Hex())
+	if err != nil {
+		r.handleUserUpdateError(ctx, err)
+		return
+	}
+
+	// the user's events no longer hold their emails, IP addresses or user agents, so unlike
+	// other events the deletion is recorded without the request's IP address and user agent
+	event := entities.AuditEvent{
+		Action: entities.AuditActionUserDeleted,
+		Target: user.ID.Hex(),
+	}
+	if actor, exists := v2.GetActor(ctx); exists {
+		event.Actor = actor.ID
+		event.ActorType = string(actor.TokenType)
+	}
+	err = r.auditService.LogEvent(ctx, event)
+	if err != nil {
+		r.logger.Error("could not log audit event", zap.String("action", string(event.Action)), zap.Error(err))
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
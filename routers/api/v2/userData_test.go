@@ -0,0 +1,505 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApiV2Router_ExportUserData(t *testing.T) {
+	testTeam := entities.Team{ID: testTeamId, Name: "Bobs"}
+	testProfile := entities.MatchmakingProfile{ID: primitive.NewObjectID(), User: testUserId, Skills: []string{"go"}}
+	testToken := entities.ServiceToken{ID: primitive.NewObjectID(), JWT: "jwt", Creator: testUserId}
+	newerEvent := entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(300, 0).UTC(), Action: entities.AuditActionLogin}
+	sharedEvent := entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(200, 0).UTC(), Action: entities.AuditActionPasswordSet}
+	olderEvent := entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(100, 0).UTC(), Action: entities.AuditActionRoleSet}
+	emailChangedEvent := entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(250, 0).UTC(), Action: entities.AuditActionEmailChanged,
+		Before: entities.AuditValues{string(entities.UserEmail): "old@email.com"}, After: entities.AuditValues{string(entities.UserEmail): "test@email.com"}}
+	failedLoginEvent := entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(400, 0).UTC(), Action: entities.AuditActionLoginFailed, Target: "test@email.com"}
+	oldFailedLoginEvent := entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(150, 0).UTC(), Action: entities.AuditActionLoginFailed, Target: "old@email.com"}
+
+	tests := []struct {
+		name        string
+		prep        func(*usersTestSetup)
+		wantResCode int
+		wantRes     *exportUserDataRes
+	}{
+		{
+			name: "should return 404 when user service returns ErrNotFound",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when team service returns unknown error",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 500 when matchmaking service returns unknown error",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(&testTeam, nil).Times(1)
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 500 when token service returns error",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(&testTeam, nil).Times(1)
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockTokService.EXPECT().GetServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 500 when audit service returns error",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(&testTeam, nil).Times(1)
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockTokService.EXPECT().GetServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return([]entities.ServiceToken{testToken}, nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{ActorID: testUserId.Hex()}).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and export user's data when user's team no longer exists",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockTokService.EXPECT().GetServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return([]entities.ServiceToken{}, nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, gomock.Any()).
+					Return([]entities.AuditEvent{}, nil).Times(3)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(0, 0).UTC()).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &exportUserDataRes{
+				Tokens:      []entities.ServiceToken{},
+				AuditEvents: []entities.AuditEvent{},
+				ExportedAt:  time.Unix(0, 0).UTC(),
+			},
+		},
+		{
+			name: "should return 200 and export user's data including failed logins with their emails",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(&testTeam, nil).Times(1)
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&testProfile, nil).Times(1)
+				setup.mockTokService.EXPECT().GetServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return([]entities.ServiceToken{testToken}, nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{ActorID: testUserId.Hex()}).
+					Return([]entities.AuditEvent{newerEvent, sharedEvent}, nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: testUserId.Hex()}).
+					Return([]entities.AuditEvent{emailChangedEvent, sharedEvent, olderEvent}, nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: "test@email.com"}).
+					Return([]entities.AuditEvent{failedLoginEvent}, nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: "old@email.com"}).
+					Return([]entities.AuditEvent{oldFailedLoginEvent}, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionUserDataExported,
+					Target: testUserId.Hex(),
+				})).Return(nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(0, 0).UTC()).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &exportUserDataRes{
+				Team:               &testTeam,
+				MatchmakingProfile: &testProfile,
+				Tokens:             []entities.ServiceToken{{ID: testToken.ID, Creator: testUserId}},
+				AuditEvents:        []entities.AuditEvent{failedLoginEvent, newerEvent, emailChangedEvent, sharedEvent, oldFailedLoginEvent, olderEvent},
+				ExportedAt:         time.Unix(0, 0).UTC(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, nil)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.ExportUserData(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				tt.wantRes.User = *setup.testUser
+				tt.wantRes.User.Password = ""
+
+				var actualRes exportUserDataRes
+				err := json.Unmarshal(setup.w.Body.Bytes(), &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+				assert.Equal(t, `attachment; filename="hs_auth_export.json"`, setup.w.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
+
+func TestApiV2Router_DeleteUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		confirm     string
+		prep        func(*usersTestSetup)
+		wantResCode int
+	}{
+		{
+			name:    "should return 404 when user service returns ErrNotFound",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 400 when deletion is not confirmed",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should return 400 when confirmation does not match user's email",
+			confirm: "other@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should return 500 when user cannot be removed from their team",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when service tokens cannot be deleted",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when passkeys cannot be deleted",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when linked accounts cannot be unlinked",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when team join requests cannot be deleted",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockJRService.EXPECT().DeleteJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when matchmaking profile cannot be deleted",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockJRService.EXPECT().DeleteJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when events targeting user cannot be fetched",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockJRService.EXPECT().DeleteJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: testUserId.Hex()}).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when login attempts cannot be deleted",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockJRService.EXPECT().DeleteJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: testUserId.Hex()}).
+					Return([]entities.AuditEvent{}, nil).Times(1)
+				setup.mockLService.EXPECT().DeleteLoginAttemptsForEmail(setup.testCtx, "test@email.com").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when audit events cannot be anonymised",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockJRService.EXPECT().DeleteJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: testUserId.Hex()}).
+					Return([]entities.AuditEvent{}, nil).Times(1)
+				setup.mockLService.EXPECT().DeleteLoginAttemptsForEmail(setup.testCtx, "test@email.com").
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().AnonymiseEventsForUser(setup.testCtx, testUserId.Hex(), []string{"test@email.com"}).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 500 when user cannot be deleted",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockJRService.EXPECT().DeleteJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: testUserId.Hex()}).
+					Return([]entities.AuditEvent{}, nil).Times(1)
+				setup.mockLService.EXPECT().DeleteLoginAttemptsForEmail(setup.testCtx, "test@email.com").
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().AnonymiseEventsForUser(setup.testCtx, testUserId.Hex(), []string{"test@email.com"}).
+					Return(nil).Times(1)
+				setup.mockUService.EXPECT().DeleteUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 2xx and skip team removal when user is not in a team",
+			confirm: "TEST@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.Team = primitive.NilObjectID
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockJRService.EXPECT().DeleteJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(services.ErrNotFound).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: testUserId.Hex()}).
+					Return([]entities.AuditEvent{}, nil).Times(1)
+				setup.mockLService.EXPECT().DeleteLoginAttemptsForEmail(setup.testCtx, "test@email.com").
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().AnonymiseEventsForUser(setup.testCtx, testUserId.Hex(), []string{"test@email.com"}).
+					Return(nil).Times(1)
+				setup.mockUService.EXPECT().DeleteUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:    "should return 2xx, anonymise user's audit events and log audit event when user is deleted",
+			confirm: "test@email.com",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockTokService.EXPECT().DeleteServiceTokensWithCreator(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockJRService.EXPECT().DeleteJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().GetEvents(setup.testCtx, services.AuditEventFilter{Target: testUserId.Hex()}).
+					Return([]entities.AuditEvent{{
+						Action: entities.AuditActionEmailChanged,
+						Target: testUserId.Hex(),
+						Before: entities.AuditValues{string(entities.UserEmail): "old@email.com"},
+					}}, nil).Times(1)
+				setup.mockLService.EXPECT().DeleteLoginAttemptsForEmail(setup.testCtx, "test@email.com").
+					Return(nil).Times(1)
+				setup.mockLService.EXPECT().DeleteLoginAttemptsForEmail(setup.testCtx, "old@email.com").
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().AnonymiseEventsForUser(setup.testCtx, testUserId.Hex(), []string{"test@email.com", "old@email.com"}).
+					Return(nil).Times(1)
+				setup.mockUService.EXPECT().DeleteUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				// the deletion is logged without the request's IP address and user agent
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, entities.AuditEvent{
+					Action: entities.AuditActionUserDeleted,
+					Target: testUserId.Hex(),
+				}).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodDelete, nil)
+			setup.testCtx.Request.URL.RawQuery = fmt.Sprintf("confirm=%s", tt.confirm)
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.DeleteUser(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
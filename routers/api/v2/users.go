@@ -3,6 +3,7 @@ package v2
 import (
 	"encoding/json"
 	"github.com/gin-gonic/gin"
+	v2 "github.com/unicsmcr/hs_auth/authorization/v2"
 	"github.com/pkg/errors"
 	"github.com/unicsmcr/hs_auth/authorization/v2/common"
 	"github.com/unicsmcr/hs_auth/config/role"
@@ -10,7 +11,6 @@ import (
 	"github.com/unicsmcr/hs_auth/routers/api/models"
 	rcommon "github.com/unicsmcr/hs_auth/routers/common"
 	"github.com/unicsmcr/hs_auth/services"
-	"github.com/unicsmcr/hs_auth/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	"net/http"
@@ -21,12 +21,16 @@ import (
 // x-www-form-urlencoded
 // Request:  email string
 //           password string
+//           code string (TOTP or recovery code, required when the user has two-factor authentication enabled)
+//           credential string (JSON serialised passkey assertion, can be used instead of code)
 // Response: token string
 // Headers:  Authorization <- token
 func (r *apiV2Router) Login(ctx *gin.Context) {
 	var req struct {
-		Email    string `form:"email"`
-		Password string `form:"password"`
+		Email      string `form:"email"`
+		Password   string `form:"password"`
+		Code       string `form:"code"`
+		Credential string `form:"credential"`
 	}
 	_ = ctx.Bind(&req)
 
@@ -42,11 +46,33 @@ func (r *apiV2Router) Login(ctx *gin.Context) {
 		return
 	}
 
+	retryAfter, err := r.loginAttemptService.CheckLoginAllowed(ctx, req.Email, ctx.ClientIP())
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrLoginThrottled, services.ErrLoginLocked:
+			r.logger.Debug("login attempt rejected", zap.String("email", req.Email), zap.Error(err))
+			rcommon.SetRetryAfterHeader(ctx, retryAfter)
+			models.SendAPIError(ctx, http.StatusTooManyRequests, "too many failed login attempts, try again later")
+		default:
+			r.logger.Error("could not check if login is allowed", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
 	user, err := r.userService.GetUserWithEmailAndPwd(ctx, req.Email, req.Password)
 	if err != nil {
 		switch errors.Cause(err) {
 		case services.ErrNotFound:
 			r.logger.Debug("user not found", zap.String("email", req.Email), zap.Error(err))
+			rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+				Action: entities.AuditActionLoginFailed,
+				Target: req.Email,
+			})
+			err = r.loginAttemptService.RecordFailedLogin(ctx, req.Email, ctx.ClientIP())
+			if err != nil {
+				r.logger.Error("could not record failed login", zap.Error(err))
+			}
 			models.SendAPIError(ctx, http.StatusUnauthorized, "user not found")
 		default:
 			r.logger.Error("could not fetch user", zap.Error(err))
@@ -55,6 +81,10 @@ func (r *apiV2Router) Login(ctx *gin.Context) {
 		return
 	}
 
+	if !r.checkTwoFactorCode(ctx, *user, req.Code, req.Credential) {
+		return
+	}
+
 	token, err := r.authorizer.CreateUserToken(user.ID, r.cfg.Auth.UserTokenLifetime+r.timeProvider.Now().Unix())
 	if err != nil {
 		r.logger.Error("could not create JWT", zap.Error(err))
@@ -62,6 +92,18 @@ func (r *apiV2Router) Login(ctx *gin.Context) {
 		return
 	}
 
+	err = r.loginAttemptService.RecordSuccessfulLogin(ctx, req.Email)
+	if err != nil {
+		r.logger.Error("could not clear failed logins", zap.Error(err))
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action:    entities.AuditActionLogin,
+		Actor:     user.ID,
+		ActorType: string(v2.User),
+		Target:    user.ID.Hex(),
+	})
+
 	ctx.Header(authTokenHeader, token)
 	ctx.JSON(http.StatusOK, loginRes{
 		Token: token,
@@ -73,12 +115,14 @@ func (r *apiV2Router) Login(ctx *gin.Context) {
 // Request:  name string
 //           email string
 //           password string
+//           (Optional) invite string, code of the invitation the user registers with
 // Response:
 func (r *apiV2Router) Register(ctx *gin.Context) {
 	var req struct {
 		Name     string `form:"name"`
 		Email    string `form:"email"`
 		Password string `form:"password"`
+		Invite   string `form:"invite"`
 	}
 	_ = ctx.Bind(&req)
 
@@ -88,12 +132,42 @@ func (r *apiV2Router) Register(ctx *gin.Context) {
 		return
 	}
 
-	user, err := r.userService.CreateUser(ctx, req.Name, req.Email, req.Password, r.cfg.Auth.DefaultRole)
+	userRole := r.cfg.Auth.DefaultRole
+	var invitation *entities.Invitation
+	if len(req.Invite) > 0 {
+		var err error
+		invitation, err = r.invitationService.UseInvitationWithCode(ctx, req.Invite)
+		if err != nil {
+			switch errors.Cause(err) {
+			case services.ErrNotFound:
+				r.logger.Debug("invitation not found", zap.Error(err))
+				models.SendAPIError(ctx, http.StatusBadRequest, "invitation is invalid, has expired or has been used up")
+			default:
+				r.logger.Error("could not use invitation", zap.Error(err))
+				models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+			}
+			return
+		}
+		userRole = invitation.Role
+	}
+
+	user, err := r.userService.CreateUser(ctx, req.Name, req.Email, req.Password, userRole)
 	if err != nil {
+		if invitation != nil {
+			releaseErr := r.invitationService.ReleaseInvitationUse(ctx, invitation.ID.Hex())
+			if releaseErr != nil {
+				r.logger.Warn("could not release invitation use", zap.String("invitation id", invitation.ID.Hex()), zap.Error(releaseErr))
+			}
+		}
+
 		switch errors.Cause(err) {
 		case services.ErrEmailTaken:
 			r.logger.Debug("email taken", zap.String("email", req.Email), zap.Error(err))
 			models.SendAPIError(ctx, http.StatusBadRequest, "user with given email already exists")
+		case services.ErrPasswordTooShort, services.ErrPasswordMissingCharacterClass,
+			services.ErrPasswordContainsPersonalInfo, services.ErrPasswordBreached:
+			r.logger.Debug("password rejected by password policy", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, rcommon.PasswordPolicyErrorMessage(r.cfg.Auth.PasswordPolicy, err))
 		default:
 			r.logger.Error("could not create user", zap.Error(err))
 			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
@@ -103,6 +177,17 @@ func (r *apiV2Router) Register(ctx *gin.Context) {
 
 	ctx.Status(http.StatusOK)
 
+	if invitation != nil {
+		// the user got their role from the invitation, so their email does not have to be verified to get one
+		if invitation.Team != primitive.NilObjectID {
+			err = r.teamService.AddUserWithIDToTeamWithID(ctx, user.ID.Hex(), invitation.Team.Hex())
+			if err != nil {
+				r.logger.Warn("could not add invited user to team", zap.String("team id", invitation.Team.Hex()), zap.Error(err))
+			}
+		}
+		return
+	}
+
 	if r.cfg.Auth.EmailVerificationRequired {
 		err = r.emailService.SendEmailVerificationEmail(ctx, *user, rcommon.MakeEmailVerificationURIs(*user))
 		if err != nil {
@@ -111,15 +196,26 @@ func (r *apiV2Router) Register(ctx *gin.Context) {
 	}
 }
 
-// GET: /api/v2/users
+// GET: /api/v2/users?team={teamId}&role={role}&email_verified={emailVerified}&name={name}&sort={sort}&order={order}&limit={limit}&after={after}
+// Request:  (Optional) teamId string, id of the team whose members are returned, "me" for the user's team
+//                      or "none" for users without a team
+//           (Optional) role role.UserRole
+//           (Optional) emailVerified bool
+//           (Optional) name string, start of the users' names, case-sensitive
+//           (Optional) sort string, one of _id (default), name or email
+//           (Optional) order string, asc (default) or desc
+//           (Optional) limit int64, defaults to 100, at most 1000
+//           (Optional) after string, pagination.next_cursor of the previous page
 // Response: users []entities.User
+//           pagination services.PageInfo, except when the members of a team are returned
 // Headers:  Authorization -> token
 func (r *apiV2Router) GetUsers(ctx *gin.Context) {
 	var (
-		users []entities.User
-		err   error
+		users    []entities.User
+		pageInfo *services.PageInfo
+		err      error
 	)
-	if ctx.Query("team") != "" {
+	if ctx.Query("team") != "" && ctx.Query("team") != services.NoTeam {
 		users, err = r.getTeamMembersCtxAware(ctx, ctx.Query("team"))
 		if err == nil && len(users) == 0 {
 			r.logger.Debug("team not found", zap.String("team id", ctx.Query("team")))
@@ -127,7 +223,23 @@ func (r *apiV2Router) GetUsers(ctx *gin.Context) {
 			return
 		}
 	} else {
-		users, err = r.userService.GetUsers(ctx)
+		var filter services.UserFilter
+		filter, err = parseUserFilterQuery(ctx)
+		if err != nil {
+			r.logger.Debug("invalid user filter", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var page services.Pagination
+		page, err = parsePaginationQuery(ctx)
+		if err != nil {
+			r.logger.Debug("invalid pagination parameters", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		users, pageInfo, err = r.userService.GetUsersPage(ctx, filter, page)
 	}
 
 	if err != nil {
@@ -147,6 +259,12 @@ func (r *apiV2Router) GetUsers(ctx *gin.Context) {
 		case services.ErrUserNotInTeam:
 			r.logger.Debug("user is not in a team", zap.Error(err))
 			models.SendAPIError(ctx, http.StatusBadRequest, "user not found")
+		case services.ErrInvalidCursor:
+			r.logger.Debug("invalid pagination cursor", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid cursor")
+		case services.ErrInvalidSortField:
+			r.logger.Debug("invalid sort field", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "users cannot be sorted by the given field")
 		default:
 			r.logger.Error("could not fetch user", zap.Error(err))
 			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
@@ -154,7 +272,7 @@ func (r *apiV2Router) GetUsers(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, getUsersRes{Users: users})
+	ctx.JSON(http.StatusOK, getUsersRes{Users: users, Pagination: pageInfo})
 }
 
 // GET: /api/v2/users/(:id|me)
@@ -194,13 +312,15 @@ func (r *apiV2Router) GetUser(ctx *gin.Context) {
 
 // PUT: /api/v2/users/:id/team
 // x-www-form-urlencoded
-// Request:  team primitive.ObjectId
+// Request:  team primitive.ObjectId or code string, the team's join code
+// Response: join_request entities.TeamJoinRequest, with status 202, when the team with the code is closed
 // Headers:  Authorization -> token
 func (r *apiV2Router) SetTeam(ctx *gin.Context) {
 	teamId := ctx.PostForm("team")
-	if len(teamId) == 0 {
-		r.logger.Debug("team id not provided")
-		models.SendAPIError(ctx, http.StatusBadRequest, "team id must be provided")
+	joinCode := ctx.PostForm("code")
+	if len(teamId) == 0 && len(joinCode) == 0 {
+		r.logger.Debug("team id or join code not provided")
+		models.SendAPIError(ctx, http.StatusBadRequest, "team id or join code must be provided")
 		return
 	}
 
@@ -225,7 +345,16 @@ func (r *apiV2Router) SetTeam(ctx *gin.Context) {
 		userId = userIdObj.Hex()
 	}
 
-	err := r.teamService.AddUserWithIDToTeamWithID(ctx, userId, teamId)
+	var err error
+	if len(teamId) > 0 {
+		err = r.teamService.AddUserWithIDToTeamWithID(ctx, userId, teamId)
+	} else {
+		err = r.teamService.AddUserWithIDToTeamWithJoinCode(ctx, userId, joinCode)
+		if errors.Cause(err) == services.ErrTeamClosed {
+			r.requestToJoinTeam(ctx, userId, joinCode)
+			return
+		}
+	}
 	if err != nil {
 		switch errors.Cause(err) {
 		case services.ErrInvalidID:
@@ -233,10 +362,16 @@ func (r *apiV2Router) SetTeam(ctx *gin.Context) {
 			models.SendAPIError(ctx, http.StatusBadRequest, "user or team id is invalid")
 		case services.ErrNotFound:
 			r.logger.Debug("user or team not found", zap.Error(err))
-			models.SendAPIError(ctx, http.StatusNotFound, "user or team with given id not found")
+			models.SendAPIError(ctx, http.StatusNotFound, "user or team with given id or join code not found")
+		case services.ErrJoinCodeThrottled:
+			r.logger.Debug("too many wrong join codes", zap.String("userId", userId), zap.Error(err))
+			models.SendAPIError(ctx, http.StatusTooManyRequests, "too many wrong join codes entered, try again later")
 		case services.ErrUserInTeam:
 			r.logger.Debug("user is already in team", zap.Error(err))
 			models.SendAPIError(ctx, http.StatusBadRequest, "user is already in a team")
+		case services.ErrTeamFull:
+			r.logger.Debug("team is full", zap.String("userId", userId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "team is full")
 		default:
 			r.logger.Error("could not add user to team", zap.Error(err))
 			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
@@ -247,6 +382,38 @@ func (r *apiV2Router) SetTeam(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+// requestToJoinTeam creates a request of the user to join the closed team with the given join code
+func (r *apiV2Router) requestToJoinTeam(ctx *gin.Context, userId string, joinCode string) {
+	request, err := r.joinRequestService.CreateJoinRequestWithJoinCode(ctx, userId, joinCode)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("user id is invalid", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user or team id is invalid")
+		case services.ErrNotFound:
+			r.logger.Debug("user or team not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "user or team with given id or join code not found")
+		case services.ErrJoinCodeThrottled:
+			r.logger.Debug("too many wrong join codes", zap.String("userId", userId), zap.Error(err))
+			models.SendAPIError(ctx, http.StatusTooManyRequests, "too many wrong join codes entered, try again later")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user is already in team", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user is already in a team")
+		case services.ErrJoinRequestExists:
+			r.logger.Debug("user has already requested to join team", zap.String("userId", userId))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user has already requested to join the team")
+		default:
+			r.logger.Error("could not create join request", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, createTeamJoinRequestRes{
+		JoinRequest: *request,
+	})
+}
+
 // DELETE: /api/v2/users/:id/team
 // x-www-form-urlencoded
 // Headers:  Authorization -> token
@@ -330,18 +497,13 @@ func (r *apiV2Router) SetPassword(ctx *gin.Context) {
 		userId = userIdObj.Hex()
 	}
 
-	pwdHash, err := utils.GetHashForPassword(req.Password)
-	if err != nil {
-		r.logger.Error("failed to hash password", zap.Error(err))
-		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
-		return
-	}
-
-	err = r.userService.UpdateUserWithID(ctx, userId, services.UserUpdateParams{
-		entities.UserPassword: pwdHash,
-	})
+	err := r.userService.SetPasswordForUserWithID(ctx, userId, req.Password)
 	if err != nil {
 		switch errors.Cause(err) {
+		case services.ErrPasswordTooShort, services.ErrPasswordMissingCharacterClass,
+			services.ErrPasswordContainsPersonalInfo, services.ErrPasswordBreached:
+			r.logger.Debug("password rejected by password policy", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, rcommon.PasswordPolicyErrorMessage(r.cfg.Auth.PasswordPolicy, err))
 		case services.ErrInvalidID:
 			r.logger.Debug("invalid user id", zap.Error(err))
 			models.SendAPIError(ctx, http.StatusBadRequest, "invalid user id")
@@ -355,6 +517,11 @@ func (r *apiV2Router) SetPassword(ctx *gin.Context) {
 		return
 	}
 
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionPasswordSet,
+		Target: userId,
+	})
+
 	ctx.Status(http.StatusOK)
 
 	err = r.authorizer.InvalidateServiceToken(ctx, r.GetAuthToken(ctx))
@@ -422,24 +589,26 @@ func (r *apiV2Router) SetRole(ctx *gin.Context) {
 		return
 	}
 
-	err = r.userService.UpdateUserWithID(ctx, ctx.Param("id"), services.UserUpdateParams{
+	user, ok := r.getUserForUpdate(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	err = r.userService.UpdateUserWithID(ctx, user.ID.Hex(), services.UserUpdateParams{
 		entities.UserRole: userRole,
 	})
 	if err != nil {
-		switch err {
-		case services.ErrInvalidID:
-			r.logger.Debug("invalid user id")
-			models.SendAPIError(ctx, http.StatusBadRequest, "invalid user id provided")
-		case services.ErrNotFound:
-			r.logger.Debug("user not found")
-			models.SendAPIError(ctx, http.StatusNotFound, "user not found")
-		default:
-			r.logger.Error("could not update user with id", zap.Error(err))
-			models.SendAPIError(ctx, http.StatusInternalServerError, "there was a problem when updating the user")
-		}
+		r.handleUserUpdateError(ctx, err)
 		return
 	}
 
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionRoleSet,
+		Target: user.ID.Hex(),
+		Before: entities.AuditValues{string(entities.UserRole): user.Role},
+		After:  entities.AuditValues{string(entities.UserRole): userRole},
+	})
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -461,33 +630,155 @@ func (r *apiV2Router) SetSpecialPermissions(ctx *gin.Context) {
 		return
 	}
 
-	err = r.userService.UpdateUserWithID(ctx, ctx.Param("id"), services.UserUpdateParams{
+	user, ok := r.getUserForUpdate(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	err = r.userService.UpdateUserWithID(ctx, user.ID.Hex(), services.UserUpdateParams{
 		entities.UserSpecialPermissions: req.Permissions,
 	})
 	if err != nil {
-		switch err {
-		case services.ErrInvalidID:
-			r.logger.Debug("invalid user id")
-			models.SendAPIError(ctx, http.StatusBadRequest, "invalid user id provided")
-		case services.ErrNotFound:
-			r.logger.Debug("user not found")
-			models.SendAPIError(ctx, http.StatusNotFound, "user not found")
-		default:
-			r.logger.Error("could not update user with id", zap.Error(err))
-			models.SendAPIError(ctx, http.StatusInternalServerError, "there was a problem when updating the user")
-		}
+		r.handleUserUpdateError(ctx, err)
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionSpecialPermissionsSet,
+		Target: user.ID.Hex(),
+		Before: entities.AuditValues{string(entities.UserSpecialPermissions): user.SpecialPermissions},
+		After:  entities.AuditValues{string(entities.UserSpecialPermissions): req.Permissions},
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// DELETE: /api/v2/users/:id/lockout
+// Headers:  Authorization -> token
+func (r *apiV2Router) UnlockUser(ctx *gin.Context) {
+	user, ok := r.getUserForUpdate(ctx, ctx.Param("id"))
+	if !ok {
 		return
 	}
 
+	err := r.loginAttemptService.UnlockAccount(ctx, user.Email)
+	if err != nil {
+		r.logger.Error("could not unlock user", zap.String("user id", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "there was a problem when unlocking the user")
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionAccountUnlocked,
+		Target: user.ID.Hex(),
+	})
+
 	ctx.Status(http.StatusNoContent)
 }
 
+// DELETE: /api/v2/users/:id/twofactor
+// Headers:  Authorization -> token
+func (r *apiV2Router) ResetTwoFactor(ctx *gin.Context) {
+	user, ok := r.getUserForUpdate(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := r.twoFactorService.Disable(ctx, user.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not disable two-factor authentication", zap.String("user id", user.ID.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "there was a problem when resetting the user's two-factor authentication")
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionTwoFactorDisabled,
+		Target: user.ID.Hex(),
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// checkTwoFactorCode is the second step of logging in the user, whose password has already been checked.
+// The user can use a passkey instead of the two-factor code.
+// Sends an API error and returns false if the user cannot log in with the given two-factor code or passkey.
+func (r *apiV2Router) checkTwoFactorCode(ctx *gin.Context, user entities.User, code, credential string) bool {
+	if !user.TwoFactorEnabled {
+		if r.twoFactorService.IsRequiredForRole(user.Role) {
+			r.logger.Debug("two-factor authentication required but not set up", zap.String("user id", user.ID.Hex()))
+			models.SendAPIError(ctx, http.StatusForbidden, "two-factor authentication must be set up before logging in")
+			return false
+		}
+		return true
+	}
+
+	if len(credential) > 0 {
+		return r.checkPasskey(ctx, user, credential)
+	}
+
+	if len(code) == 0 {
+		r.logger.Debug("two-factor code was not provided", zap.String("user id", user.ID.Hex()))
+		models.SendAPIError(ctx, http.StatusUnauthorized, "two-factor code must be provided")
+		return false
+	}
+
+	err := r.twoFactorService.VerifyCode(ctx, user.ID.Hex(), code)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidTwoFactorCode:
+			r.logger.Debug("invalid two-factor code", zap.String("user id", user.ID.Hex()))
+			rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+				Action: entities.AuditActionTwoFactorFailed,
+				Target: user.ID.Hex(),
+			})
+			err = r.loginAttemptService.RecordFailedLogin(ctx, user.Email, ctx.ClientIP())
+			if err != nil {
+				r.logger.Error("could not record failed login", zap.Error(err))
+			}
+			models.SendAPIError(ctx, http.StatusUnauthorized, "invalid two-factor code")
+		default:
+			r.logger.Error("could not verify two-factor code", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return false
+	}
+
+	return true
+}
+
+// getUserForUpdate fetches the user with the given id, so that the user's values before an update
+// can be recorded in the audit log. Sends an API error and returns false if the user cannot be fetched.
+func (r *apiV2Router) getUserForUpdate(ctx *gin.Context, userId string) (*entities.User, bool) {
+	user, err := r.userService.GetUserWithID(ctx, userId)
+	if err != nil {
+		r.handleUserUpdateError(ctx, err)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (r *apiV2Router) handleUserUpdateError(ctx *gin.Context, err error) {
+	switch errors.Cause(err) {
+	case services.ErrInvalidID:
+		r.logger.Debug("invalid user id")
+		models.SendAPIError(ctx, http.StatusBadRequest, "invalid user id provided")
+	case services.ErrNotFound:
+		r.logger.Debug("user not found")
+		models.SendAPIError(ctx, http.StatusNotFound, "user not found")
+	default:
+		r.logger.Error("could not update user with id", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "there was a problem when updating the user")
+	}
+}
+
 // PUT: /api/v2/users/(:id|me)/email/verify
 // x-www-form-urlencoded
 // Headers:  Authorization -> token
 func (r *apiV2Router) VerifyEmail(ctx *gin.Context) {
 	err := r.userService.UpdateUserWithID(ctx, ctx.Param("id"), services.UserUpdateParams{
-		entities.UserRole: r.cfg.Auth.DefaultEmailVerifiedRole,
+		entities.UserRole:          r.cfg.Auth.DefaultEmailVerifiedRole,
+		entities.UserEmailVerified: true,
 	})
 	if err != nil {
 		switch err {
@@ -547,6 +838,139 @@ func (r *apiV2Router) ResendEmailVerification(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+// PUT: /api/v2/users/(:id|me)/email
+// x-www-form-urlencoded
+// Request:  email string
+// Response:
+// Headers:  Authorization -> token
+func (r *apiV2Router) RequestEmailChange(ctx *gin.Context) {
+	var req struct {
+		Email string `form:"email"`
+	}
+	_ = ctx.Bind(&req)
+	if len(req.Email) == 0 {
+		r.logger.Debug("email not specified")
+		models.SendAPIError(ctx, http.StatusBadRequest, "request must include the new email")
+		return
+	}
+
+	user, err := r.getUserCtxAware(ctx, ctx.Param("id"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case common.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		case common.ErrInvalidTokenType:
+			r.logger.Debug("invalid token type", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "provided token is of invalid type for the requested operation")
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusNotFound, "user not found")
+		default:
+			r.logger.Error("could not fetch user", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	user, err = r.userService.RequestEmailChangeForUserWithID(ctx, user.ID.Hex(), req.Email)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrEmailTaken:
+			r.logger.Debug("email taken", zap.String("email", req.Email))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user with given email already exists")
+		default:
+			r.handleUserUpdateError(ctx, err)
+		}
+		return
+	}
+
+	err = r.emailService.SendEmailChangeConfirmationEmail(ctx, *user, user.PendingEmail, rcommon.MakeEmailChangeConfirmationURIs(*user))
+	if err != nil {
+		r.logger.Error("could not send email change confirmation email", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	// the change cannot be made without the confirmation, so failing to notify the current address is not fatal
+	err = r.emailService.SendEmailChangeNoticeEmail(ctx, *user, user.PendingEmail)
+	if err != nil {
+		r.logger.Warn("could not send email change notice email", zap.Error(err))
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionEmailChangeRequested,
+		Target: user.ID.Hex(),
+		After: entities.AuditValues{
+			string(entities.UserPendingEmail): user.PendingEmail,
+		},
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// PUT: /api/v2/users/:id/email/confirm
+// x-www-form-urlencoded
+// Request:  nonce string
+// Response:
+// Headers:  Authorization -> token
+func (r *apiV2Router) ConfirmEmailChange(ctx *gin.Context) {
+	var req struct {
+		Nonce string `form:"nonce"`
+	}
+	_ = ctx.Bind(&req)
+	if len(req.Nonce) == 0 {
+		r.logger.Debug("nonce not specified")
+		models.SendAPIError(ctx, http.StatusBadRequest, "request must include the nonce from the confirmation link")
+		return
+	}
+
+	previousUser, ok := r.getUserForUpdate(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+
+	user, err := r.userService.ConfirmEmailChangeForUserWithID(ctx, previousUser.ID.Hex(), req.Nonce)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNoPendingEmailChange:
+			r.logger.Debug("nonce does not match pending email change", zap.String("userId", previousUser.ID.Hex()))
+			models.SendAPIError(ctx, http.StatusBadRequest, "the confirmation is not for the user's pending email change")
+		case services.ErrEmailTaken:
+			r.logger.Debug("email taken", zap.String("email", previousUser.PendingEmail))
+			models.SendAPIError(ctx, http.StatusBadRequest, "user with given email already exists")
+		default:
+			r.handleUserUpdateError(ctx, err)
+		}
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionEmailChanged,
+		Target: user.ID.Hex(),
+		Before: entities.AuditValues{
+			string(entities.UserEmail): previousUser.Email,
+			string(entities.UserRole):  previousUser.Role,
+		},
+		After: entities.AuditValues{
+			string(entities.UserEmail): user.Email,
+			string(entities.UserRole):  user.Role,
+		},
+	})
+
+	ctx.Status(http.StatusNoContent)
+
+	// the confirmation link can only be used once
+	err = r.authorizer.InvalidateServiceToken(ctx, r.GetAuthToken(ctx))
+	if err != nil &&
+		err != common.ErrInvalidTokenType { // ignoring error when operation was not called with a service token
+		r.logger.Warn("could not invalidate token after email change", zap.Error(err))
+	}
+}
+
 // getUserCtxAware fetches user with the given id. If id is "me", getUserCtxAware tries to extract the user from the ctx
 func (r *apiV2Router) getUserCtxAware(ctx *gin.Context, userId string) (*entities.User, error) {
 	if userId == "me" {
@@ -8,6 +8,7 @@ import (
 	"github.com/unicsmcr/hs_auth/environment"
 	"github.com/unicsmcr/hs_auth/repositories"
 	"github.com/unicsmcr/hs_auth/services/mongo"
+	"github.com/unicsmcr/hs_auth/services/password"
 	"github.com/unicsmcr/hs_auth/utils"
 	mongod "go.mongodb.org/mongo-driver/mongo"
 	"net/http"
@@ -53,7 +54,16 @@ type usersTestSetup struct {
 	router           APIV2Router
 	mockUService     *mock_services.MockUserService
 	mockTService     *mock_services.MockTeamService
+	mockTokService   *mock_services.MockTokenService
 	mockEService     *mock_services.MockEmailServiceV2
+	mockAService     *mock_services.MockAuditService
+	mockLService     *mock_services.MockLoginAttemptService
+	mockTFService    *mock_services.MockTwoFactorService
+	mockWAService    *mock_services.MockWebAuthnService
+	mockEIService    *mock_services.MockExternalIdentityService
+	mockIService     *mock_services.MockInvitationService
+	mockJRService    *mock_services.MockTeamJoinRequestService
+	mockMMService    *mock_services.MockMatchmakingService
 	mockAuthorizer   *mock_v2.MockAuthorizer
 	mockTimeProvider *mock_utils.MockTimeProvider
 	testUser         *entities.User
@@ -78,7 +88,16 @@ func setupUsersTest(t *testing.T) *usersTestSetup {
 	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
 	mockUService := mock_services.NewMockUserService(ctrl)
 	mockTService := mock_services.NewMockTeamService(ctrl)
+	mockTokService := mock_services.NewMockTokenService(ctrl)
 	mockEService := mock_services.NewMockEmailServiceV2(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
+	mockLService := mock_services.NewMockLoginAttemptService(ctrl)
+	mockTFService := mock_services.NewMockTwoFactorService(ctrl)
+	mockWAService := mock_services.NewMockWebAuthnService(ctrl)
+	mockEIService := mock_services.NewMockExternalIdentityService(ctrl)
+	mockIService := mock_services.NewMockInvitationService(ctrl)
+	mockJRService := mock_services.NewMockTeamJoinRequestService(ctrl)
+	mockMMService := mock_services.NewMockMatchmakingService(ctrl)
 	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
 
 	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{
@@ -89,7 +108,8 @@ func setupUsersTest(t *testing.T) *usersTestSetup {
 			DefaultEmailVerifiedRole:  role.Applicant,
 			EmailVerificationRequired: true,
 		},
-	}, mockAuthorizer, mockUService, mockTService, nil, mockEService, mockTimeProvider)
+	}, mockAuthorizer, mockUService, mockTService, mockTokService, mockEService, mockAService, nil, mockLService, mockTFService,
+		mockWAService, mockEIService, mockIService, mockJRService, mockMMService, mockTimeProvider)
 
 	w := httptest.NewRecorder()
 	testCtx, _ := gin.CreateTestContext(w)
@@ -107,7 +127,16 @@ func setupUsersTest(t *testing.T) *usersTestSetup {
 		router:           router,
 		mockUService:     mockUService,
 		mockTService:     mockTService,
+		mockTokService:   mockTokService,
 		mockEService:     mockEService,
+		mockAService:     mockAService,
+		mockLService:     mockLService,
+		mockTFService:    mockTFService,
+		mockWAService:    mockWAService,
+		mockEIService:    mockEIService,
+		mockIService:     mockIService,
+		mockJRService:    mockJRService,
+		mockMMService:    mockMMService,
 		mockAuthorizer:   mockAuthorizer,
 		mockTimeProvider: mockTimeProvider,
 		testUser:         &testUser,
@@ -144,13 +173,22 @@ func setupUserBenchmark(b *testing.B) *usersBenchmarkSetup {
 	resetEnv()
 
 	tokenService := mongo.NewMongoTokenService(zap.NewNop(), env, tokenRepository)
-	userService := mongo.NewMongoUserService(zap.NewNop(), env, &config.AppConfig{}, userRepository)
+	passwordPolicy, err := password.NewPolicy(&config.AppConfig{})
+	if err != nil {
+		panic(err)
+	}
+	userService := mongo.NewMongoUserService(zap.NewNop(), env, &config.AppConfig{}, userRepository, nil, passwordPolicy)
+	auditEventRepository, err := repositories.NewAuditEventRepository(db)
+	if err != nil {
+		panic(err)
+	}
 
 	testCfg := &config.AppConfig{}
 	ctrl := gomock.NewController(b)
 	timeProvider := utils.NewTimeProvider()
-	authorizer := v2.NewAuthorizer(timeProvider, testCfg, env, zap.NewNop(), tokenService, userService)
-	router := NewAPIV2Router(zap.NewNop(), testCfg, authorizer, userService, nil, tokenService, nil, timeProvider)
+	auditService := mongo.NewMongoAuditService(zap.NewNop(), timeProvider, auditEventRepository)
+	authorizer := v2.NewAuthorizer(timeProvider, testCfg, env, zap.NewNop(), tokenService, userService, auditService)
+	router := NewAPIV2Router(zap.NewNop(), testCfg, authorizer, userService, nil, tokenService, nil, auditService, nil, nil, nil, nil, nil, nil, nil, nil, timeProvider)
 
 	w := httptest.NewRecorder()
 	testCtx, _ := gin.CreateTestContext(w)
@@ -175,6 +213,7 @@ func setupUserBenchmark(b *testing.B) *usersBenchmarkSetup {
 		cleanup: func() {
 			_ = userRepository.Drop(context.Background())
 			_ = tokenRepository.Drop(context.Background())
+			_ = auditEventRepository.Drop(context.Background())
 		},
 	}
 }
@@ -237,6 +276,8 @@ func TestApiV2Router_Login(t *testing.T) {
 		name        string
 		email       string
 		password    string
+		code        string
+		credential  string
 		prep        func(*usersTestSetup)
 		wantResCode int
 		wantRes     *loginRes
@@ -253,13 +294,51 @@ func TestApiV2Router_Login(t *testing.T) {
 			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:        "should return 401 when user service returns ErrNotFound",
+			name:        "should return 429 when login attempt service returns ErrLoginThrottled",
+			email:       "test@email.com",
+			password:    "password123",
+			wantResCode: http.StatusTooManyRequests,
+			prep: func(setup *usersTestSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(2*time.Second, services.ErrLoginThrottled).Times(1)
+			},
+		},
+		{
+			name:        "should return 429 when login attempt service returns ErrLoginLocked",
+			email:       "test@email.com",
+			password:    "password123",
+			wantResCode: http.StatusTooManyRequests,
+			prep: func(setup *usersTestSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Minute, services.ErrLoginLocked).Times(1)
+			},
+		},
+		{
+			name:        "should return 500 when login attempt service returns unknown error",
+			email:       "test@email.com",
+			password:    "password123",
+			wantResCode: http.StatusInternalServerError,
+			prep: func(setup *usersTestSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), errors.New("service err")).Times(1)
+			},
+		},
+		{
+			name:        "should return 401 and record failed login when user service returns ErrNotFound",
 			email:       "test@email.com",
 			password:    "password123",
 			wantResCode: http.StatusUnauthorized,
 			prep: func(setup *usersTestSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
 					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionLoginFailed,
+					Target: "test@email.com",
+				})).Return(nil).Times(1)
+				setup.mockLService.EXPECT().RecordFailedLogin(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(nil).Times(1)
 			},
 		},
 		{
@@ -268,6 +347,8 @@ func TestApiV2Router_Login(t *testing.T) {
 			password:    "password123",
 			wantResCode: http.StatusInternalServerError,
 			prep: func(setup *usersTestSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
 					Return(nil, errors.New("service err")).Times(1)
 			},
@@ -278,23 +359,173 @@ func TestApiV2Router_Login(t *testing.T) {
 			password:    "password123",
 			wantResCode: http.StatusInternalServerError,
 			prep: func(setup *usersTestSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
 					Return(setup.testUser, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(setup.testUser.Role).Return(false).Times(1)
 				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(0, 0)).Times(1)
 				setup.mockAuthorizer.EXPECT().CreateUserToken(setup.testUser.ID, int64(testAuthTokenLifetime)).
 					Return("", errors.New("authorizer err")).Times(1)
 			},
 		},
+		{
+			name:        "should return 403 when two-factor authentication is required but not set up",
+			email:       "test@email.com",
+			password:    "password123",
+			wantResCode: http.StatusForbidden,
+			prep: func(setup *usersTestSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(setup.testUser.Role).Return(true).Times(1)
+			},
+		},
+		{
+			name:        "should return 401 when two-factor code is not provided",
+			email:       "test@email.com",
+			password:    "password123",
+			wantResCode: http.StatusUnauthorized,
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.TwoFactorEnabled = true
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
+					Return(setup.testUser, nil).Times(1)
+			},
+		},
+		{
+			name:        "should return 401 and record failed login when two-factor code is invalid",
+			email:       "test@email.com",
+			password:    "password123",
+			code:        "123456",
+			wantResCode: http.StatusUnauthorized,
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.TwoFactorEnabled = true
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(gomock.Any(), setup.testUser.ID.Hex(), "123456").
+					Return(services.ErrInvalidTwoFactorCode).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionTwoFactorFailed,
+					Target: setup.testUser.ID.Hex(),
+				})).Return(nil).Times(1)
+				setup.mockLService.EXPECT().RecordFailedLogin(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(nil).Times(1)
+			},
+		},
+		{
+			name:        "should return 500 when verifying two-factor code fails",
+			email:       "test@email.com",
+			password:    "password123",
+			code:        "123456",
+			wantResCode: http.StatusInternalServerError,
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.TwoFactorEnabled = true
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(gomock.Any(), setup.testUser.ID.Hex(), "123456").
+					Return(errors.New("service err")).Times(1)
+			},
+		},
+		{
+			name:     "should return 200 when two-factor code is valid",
+			email:    "test@email.com",
+			password: "password123",
+			code:     "123456",
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.TwoFactorEnabled = true
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(gomock.Any(), setup.testUser.ID.Hex(), "123456").
+					Return(nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(0, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(setup.testUser.ID, int64(testAuthTokenLifetime)).
+					Return("test_token", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &loginRes{
+				Token: "test_token",
+			},
+		},
+		{
+			name:        "should return 401 and record failed login when passkey is invalid",
+			email:       "test@email.com",
+			password:    "password123",
+			credential:  testPasskeyAssertion,
+			wantResCode: http.StatusUnauthorized,
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.TwoFactorEnabled = true
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
+					Return(setup.testUser, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishLogin(gomock.Any(), setup.testUser.ID.Hex(), gomock.Any()).
+					Return(nil, services.ErrInvalidWebAuthnCredential).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionTwoFactorFailed,
+					Target: setup.testUser.ID.Hex(),
+				})).Return(nil).Times(1)
+				setup.mockLService.EXPECT().RecordFailedLogin(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(nil).Times(1)
+			},
+		},
+		{
+			name:       "should return 200 when passkey is used instead of two-factor code",
+			email:      "test@email.com",
+			password:   "password123",
+			credential: testPasskeyAssertion,
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.TwoFactorEnabled = true
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
+					Return(setup.testUser, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishLogin(gomock.Any(), setup.testUser.ID.Hex(), gomock.Any()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(0, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(setup.testUser.ID, int64(testAuthTokenLifetime)).
+					Return("test_token", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &loginRes{
+				Token: "test_token",
+			},
+		},
 		{
 			name:     "should return 200 and correct token when logging in succeeds",
 			email:    "test@email.com",
 			password: "password123",
 			prep: func(setup *usersTestSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "password123").
 					Return(setup.testUser, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(setup.testUser.Role).Return(false).Times(1)
 				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(0, 0)).Times(1)
 				setup.mockAuthorizer.EXPECT().CreateUserToken(setup.testUser.ID, int64(testAuthTokenLifetime)).
 					Return("test_token", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), newAuditEventMatcher(entities.AuditEvent{
+					Action:    entities.AuditActionLogin,
+					Actor:     setup.testUser.ID,
+					ActorType: "user",
+					Target:    setup.testUser.ID.Hex(),
+				})).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 			wantRes: &loginRes{
@@ -314,14 +545,19 @@ func TestApiV2Router_Login(t *testing.T) {
 			testutils.AddRequestWithFormParamsToCtx(setup.testCtx,
 				http.MethodPost,
 				map[string]string{
-					"email":    tt.email,
-					"password": tt.password,
+					"email":      tt.email,
+					"password":   tt.password,
+					"code":       tt.code,
+					"credential": tt.credential,
 				},
 			)
 
 			setup.router.Login(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantResCode == http.StatusTooManyRequests {
+				assert.NotEmpty(t, setup.w.Header().Get("Retry-After"))
+			}
 
 			if tt.wantRes != nil {
 				var actualRes loginRes
@@ -340,6 +576,7 @@ func TestApiV2Router_Register(t *testing.T) {
 		testName     string
 		testEmail    string
 		testPassword string
+		testInvite   string
 		wantResCode  int
 	}{
 		{
@@ -371,6 +608,17 @@ func TestApiV2Router_Register(t *testing.T) {
 			},
 			wantResCode: http.StatusBadRequest,
 		},
+		{
+			name:         "should return 400 when user service rejects password",
+			testName:     "Bob the Tester",
+			testEmail:    "test@email.com",
+			testPassword: "password123",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "Bob the Tester", "test@email.com", "password123", role.Unverified).
+					Return(nil, services.ErrPasswordContainsPersonalInfo).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
 		{
 			name:         "should return 500 when user service returns unknown error",
 			testName:     "Bob the Tester",
@@ -408,6 +656,61 @@ func TestApiV2Router_Register(t *testing.T) {
 			},
 			wantResCode: http.StatusOK,
 		},
+		{
+			name:         "should return 400 when invitation is not usable",
+			testName:     "Bob the Tester",
+			testEmail:    "test@email.com",
+			testPassword: "password123",
+			testInvite:   "invite",
+			prep: func(setup *usersTestSetup) {
+				setup.mockIService.EXPECT().UseInvitationWithCode(setup.testCtx, "invite").
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:         "should return 500 when invitation service returns unknown error",
+			testName:     "Bob the Tester",
+			testEmail:    "test@email.com",
+			testPassword: "password123",
+			testInvite:   "invite",
+			prep: func(setup *usersTestSetup) {
+				setup.mockIService.EXPECT().UseInvitationWithCode(setup.testCtx, "invite").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:         "should release invitation use when user cannot be created",
+			testName:     "Bob the Tester",
+			testEmail:    "test@email.com",
+			testPassword: "password123",
+			testInvite:   "invite",
+			prep: func(setup *usersTestSetup) {
+				setup.mockIService.EXPECT().UseInvitationWithCode(setup.testCtx, "invite").
+					Return(&entities.Invitation{ID: testTeamId, Role: role.Volunteer}, nil).Times(1)
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "Bob the Tester", "test@email.com", "password123", role.Volunteer).
+					Return(nil, services.ErrEmailTaken).Times(1)
+				setup.mockIService.EXPECT().ReleaseInvitationUse(setup.testCtx, testTeamId.Hex()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:         "should return 200 and create user with invitation's role and team",
+			testName:     "Bob the Tester",
+			testEmail:    "test@email.com",
+			testPassword: "password123",
+			testInvite:   "invite",
+			prep: func(setup *usersTestSetup) {
+				setup.mockIService.EXPECT().UseInvitationWithCode(setup.testCtx, "invite").
+					Return(&entities.Invitation{Role: role.Volunteer, Team: testTeamId}, nil).Times(1)
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "Bob the Tester", "test@email.com", "password123", role.Volunteer).
+					Return(&entities.User{ID: testUserId, Name: "Bob the Tester"}, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -424,6 +727,7 @@ func TestApiV2Router_Register(t *testing.T) {
 					"name":     tt.testName,
 					"email":    tt.testEmail,
 					"password": tt.testPassword,
+					"invite":   tt.testInvite,
 				},
 			)
 
@@ -438,6 +742,7 @@ func TestApiV2Router_GetUsers(t *testing.T) {
 	tests := []struct {
 		name        string
 		teamId      string
+		query       string
 		prep        func(*usersTestSetup)
 		wantResCode int
 		wantRes     *getUsersRes
@@ -445,11 +750,73 @@ func TestApiV2Router_GetUsers(t *testing.T) {
 		{
 			name: "should return 500 when team id not specified and users service returns err",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().GetUsers(gomock.Any()).Return(nil, errors.New("service err")).
-					Times(1)
+				setup.mockUService.EXPECT().GetUsersPage(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, nil, errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
+		{
+			name:        "should return 400 when email_verified is not a bool",
+			query:       "email_verified=maybe",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when limit is invalid",
+			query:       "limit=0",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when limit is too large",
+			query:       "limit=1001",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when order is invalid",
+			query:       "order=random",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 400 when user service returns ErrInvalidCursor",
+			query: "after=invalid",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUsersPage(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, nil, services.ErrInvalidCursor).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 400 when user service returns ErrInvalidSortField",
+			query: "sort=password",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUsersPage(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, nil, services.ErrInvalidSortField).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should pass filters and pagination to user service",
+			query: "role=applicant&email_verified=false&name=Bob&team=none&sort=name&order=desc&limit=10&after=cursor",
+			prep: func(setup *usersTestSetup) {
+				emailVerified := false
+				setup.mockUService.EXPECT().GetUsersPage(gomock.Any(), services.UserFilter{
+					Role:          role.Applicant,
+					TeamID:        services.NoTeam,
+					EmailVerified: &emailVerified,
+					NamePrefix:    "Bob",
+				}, services.Pagination{
+					Limit:      10,
+					After:      "cursor",
+					SortBy:     "name",
+					Descending: true,
+				}).Return([]entities.User{{Name: "Bob the Tester"}}, &services.PageInfo{NextCursor: "next", HasMore: true}, nil).
+					Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &getUsersRes{
+				Users:      []entities.User{{Name: "Bob the Tester"}},
+				Pagination: &services.PageInfo{NextCursor: "next", HasMore: true},
+			},
+		},
 		{
 			name:   "should return 401 when team id is me and authorizer returns ErrInvalidToken",
 			teamId: "me",
@@ -596,14 +963,15 @@ func TestApiV2Router_GetUsers(t *testing.T) {
 		{
 			name: "should return 200 and expected result when team id not specified",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().GetUsers(gomock.Any()).Return([]entities.User{
+				setup.mockUService.EXPECT().GetUsersPage(gomock.Any(), services.UserFilter{},
+					services.Pagination{}).Return([]entities.User{
 					{
 						Name: "Bob the Tester",
 					},
 					{
 						Name: "Rob the Tester",
 					},
-				}, nil).Times(1)
+				}, &services.PageInfo{}, nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 			wantRes: &getUsersRes{
@@ -615,6 +983,7 @@ func TestApiV2Router_GetUsers(t *testing.T) {
 						Name: "Rob the Tester",
 					},
 				},
+				Pagination: &services.PageInfo{},
 			},
 		},
 	}
@@ -627,7 +996,7 @@ func TestApiV2Router_GetUsers(t *testing.T) {
 			if tt.teamId != "" {
 				req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/test?team=%s", tt.teamId), nil)
 			} else {
-				req = httptest.NewRequest(http.MethodGet, "/test", nil)
+				req = httptest.NewRequest(http.MethodGet, "/test?"+tt.query, nil)
 			}
 			setup.testCtx.Request = req
 			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
@@ -781,10 +1150,11 @@ func TestApiV2Router_SetTeam(t *testing.T) {
 		prep        func(*usersTestSetup)
 		testUserId  string
 		testTeamId  string
+		testCode    string
 		wantResCode int
 	}{
 		{
-			name:        "should return 400 when team id is not provided",
+			name:        "should return 400 when neither team id nor join code is provided",
 			testUserId:  testUserId.Hex(),
 			wantResCode: http.StatusBadRequest,
 		},
@@ -858,6 +1228,16 @@ func TestApiV2Router_SetTeam(t *testing.T) {
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
+		{
+			name:       "should return 400 when teamService.AddUserWithIDToTeamWithID returns ErrTeamFull",
+			testUserId: testUserId.Hex(),
+			testTeamId: testTeamId.Hex(),
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(services.ErrTeamFull).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
 		{
 			name:       "should return 200 when user id is provided",
 			testUserId: testUserId.Hex(),
@@ -868,6 +1248,72 @@ func TestApiV2Router_SetTeam(t *testing.T) {
 			},
 			wantResCode: http.StatusOK,
 		},
+		{
+			name:       "should return 404 when teamService.AddUserWithIDToTeamWithJoinCode returns ErrNotFound",
+			testUserId: testUserId.Hex(),
+			testCode:   "ABCD2345",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:       "should return 429 when teamService.AddUserWithIDToTeamWithJoinCode returns ErrJoinCodeThrottled",
+			testUserId: testUserId.Hex(),
+			testCode:   "ABCD2345",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrJoinCodeThrottled).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name:       "should return 400 when team is closed and user has already requested to join it",
+			testUserId: testUserId.Hex(),
+			testCode:   "ABCD2345",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrTeamClosed).Times(1)
+				setup.mockJRService.EXPECT().CreateJoinRequestWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(nil, services.ErrJoinRequestExists).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 500 when team is closed and join request service returns unknown error",
+			testUserId: testUserId.Hex(),
+			testCode:   "ABCD2345",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrTeamClosed).Times(1)
+				setup.mockJRService.EXPECT().CreateJoinRequestWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "should return 202 when team is closed and join request is created",
+			testUserId: testUserId.Hex(),
+			testCode:   "ABCD2345",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrTeamClosed).Times(1)
+				setup.mockJRService.EXPECT().CreateJoinRequestWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(&entities.TeamJoinRequest{Team: testTeamId, User: testUserId}, nil).Times(1)
+			},
+			wantResCode: http.StatusAccepted,
+		},
+		{
+			name:       "should return 200 when join code is provided",
+			testUserId: testUserId.Hex(),
+			testCode:   "ABCD2345",
+			prep: func(setup *usersTestSetup) {
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
 		{
 			name:       "should return 200 when user id is me",
 			testUserId: "me",
@@ -894,6 +1340,7 @@ func TestApiV2Router_SetTeam(t *testing.T) {
 				http.MethodPost,
 				map[string]string{
 					"team": tt.testTeamId,
+					"code": tt.testCode,
 				},
 			)
 			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
@@ -1065,17 +1512,27 @@ func TestApiV2Router_SetPassword(t *testing.T) {
 			userId:   testUserId.Hex(),
 			password: "test",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "test").
 					Return(services.ErrInvalidID).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
 		},
+		{
+			name:     "should return 400 when password is rejected by the password policy",
+			userId:   testUserId.Hex(),
+			password: "test",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "test").
+					Return(services.ErrPasswordTooShort).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
 		{
 			name:     "should return 404 when user service returns StatusNotFound",
 			userId:   testUserId.Hex(),
 			password: "test",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "test").
 					Return(services.ErrNotFound).Times(1)
 			},
 			wantResCode: http.StatusNotFound,
@@ -1085,7 +1542,7 @@ func TestApiV2Router_SetPassword(t *testing.T) {
 			userId:   testUserId.Hex(),
 			password: "test",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "test").
 					Return(errors.New("random error")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
@@ -1097,8 +1554,12 @@ func TestApiV2Router_SetPassword(t *testing.T) {
 			prep: func(setup *usersTestSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "test").
 					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionPasswordSet,
+					Target: testUserId.Hex(),
+				})).Return(nil).Times(1)
 				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
 					Return(nil).Times(1)
 			},
@@ -1109,8 +1570,12 @@ func TestApiV2Router_SetPassword(t *testing.T) {
 			userId:   testUserId.Hex(),
 			password: "test",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "test").
 					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionPasswordSet,
+					Target: testUserId.Hex(),
+				})).Return(nil).Times(1)
 				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
 					Return(nil).Times(1)
 			},
@@ -1121,8 +1586,12 @@ func TestApiV2Router_SetPassword(t *testing.T) {
 			userId:   testUserId.Hex(),
 			password: "test",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "test").
 					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionPasswordSet,
+					Target: testUserId.Hex(),
+				})).Return(nil).Times(1)
 				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
 					Return(common.ErrInvalidToken).Times(1)
 			},
@@ -1280,8 +1749,8 @@ func TestApiV2Router_SetRole(t *testing.T) {
 			name: "should return 400 when user service returns ErrInvalidID",
 			role: "attendee",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(services.ErrInvalidID).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
 		},
@@ -1289,26 +1758,49 @@ func TestApiV2Router_SetRole(t *testing.T) {
 			name: "should return 404 when user service returns ErrNotFound",
 			role: "attendee",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(services.ErrNotFound).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
 			},
 			wantResCode: http.StatusNotFound,
 		},
 		{
-			name: "should return 500 when user service returns unknown error",
+			name: "should return 404 when user is deleted before the update",
 			role: "attendee",
 			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(errors.New("random error")).Times(1)
+					Return(services.ErrNotFound).Times(1)
 			},
-			wantResCode: http.StatusInternalServerError,
+			wantResCode: http.StatusNotFound,
 		},
 		{
-			name: "should return 2xx when correct role is provided",
+			name: "should return 500 when user service returns unknown error",
 			role: "attendee",
 			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(nil).Times(1)
+					Return(errors.New("random error")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 2xx and log audit event when correct role is provided",
+			role: "attendee",
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.Role = role.Applicant
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), services.UserUpdateParams{
+					entities.UserRole: role.Attendee,
+				}).Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionRoleSet,
+					Target: testUserId.Hex(),
+					Before: entities.AuditValues{"role": role.Applicant},
+					After:  entities.AuditValues{"role": role.Attendee},
+				})).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -1334,6 +1826,136 @@ func TestApiV2Router_SetRole(t *testing.T) {
 	}
 }
 
+func TestApiV2Router_UnlockUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*usersTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when user service returns ErrInvalidID",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when user service returns ErrNotFound",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when login attempt service returns error",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockLService.EXPECT().UnlockAccount(setup.testCtx, setup.testUser.Email).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 204 and log audit event when account is unlocked",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockLService.EXPECT().UnlockAccount(setup.testCtx, setup.testUser.Email).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionAccountUnlocked,
+					Target: testUserId.Hex(),
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodDelete, "/users/"+testUserId.Hex()+"/lockout", nil)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.UnlockUser(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
+func TestApiV2Router_ResetTwoFactor(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*usersTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when user service returns ErrInvalidID",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when user service returns ErrNotFound",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when two-factor service returns error",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTFService.EXPECT().Disable(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 204 and log audit event when two-factor authentication is reset",
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockTFService.EXPECT().Disable(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionTwoFactorDisabled,
+					Target: testUserId.Hex(),
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodDelete, "/users/"+testUserId.Hex()+"/twofactor", nil)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.ResetTwoFactor(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
 func TestApiV2Router_SetSpecialPermissions(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1354,8 +1976,8 @@ func TestApiV2Router_SetSpecialPermissions(t *testing.T) {
 			name:        "should return 400 when user service returns ErrInvalidID",
 			permissions: "{\"permissions\":[\"hs:hs_auth\"]}",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(services.ErrInvalidID).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
 		},
@@ -1363,8 +1985,8 @@ func TestApiV2Router_SetSpecialPermissions(t *testing.T) {
 			name:        "should return 404 when user service returns ErrNotFound",
 			permissions: "{\"permissions\":[\"hs:hs_auth\"]}",
 			prep: func(setup *usersTestSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(services.ErrNotFound).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
 			},
 			wantResCode: http.StatusNotFound,
 		},
@@ -1372,17 +1994,30 @@ func TestApiV2Router_SetSpecialPermissions(t *testing.T) {
 			name:        "should return 500 when user service returns unknown error",
 			permissions: "{\"permissions\":[\"hs:hs_auth\"]}",
 			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
 					Return(errors.New("random error")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:        "should return 2xx when valid permission is provided",
+			name:        "should return 2xx and log audit event when valid permission is provided",
 			permissions: "{\"permissions\":[\"hs:hs_auth\"]}",
 			prep: func(setup *usersTestSetup) {
+				hubURI, _ := common.NewURIFromString("hs:hs_hub")
+				authURI, _ := common.NewURIFromString("hs:hs_auth")
+				setup.testUser.SpecialPermissions = common.UniformResourceIdentifiers{hubURI}
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
 					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionSpecialPermissionsSet,
+					Target: testUserId.Hex(),
+					Before: entities.AuditValues{"special_permissions": common.UniformResourceIdentifiers{hubURI}},
+					After:  entities.AuditValues{"special_permissions": common.UniformResourceIdentifiers{authURI}},
+				})).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -1390,8 +2025,11 @@ func TestApiV2Router_SetSpecialPermissions(t *testing.T) {
 			name:        "should return 2xx when multiple valid permissions are provided",
 			permissions: "{\"permissions\":[\"hs:hs_auth\",\"hs:notify\"]}",
 			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
 					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -1425,7 +2063,8 @@ func TestApiV2Router_VerifyEmail(t *testing.T) {
 			name: "should return 400 when user service returns ErrInvalidID",
 			prep: func(setup *usersTestSetup) {
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), services.UserUpdateParams{
-					entities.UserRole: role.Applicant,
+					entities.UserRole:          role.Applicant,
+					entities.UserEmailVerified: true,
 				}).Return(services.ErrInvalidID).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
@@ -1434,7 +2073,8 @@ func TestApiV2Router_VerifyEmail(t *testing.T) {
 			name: "should return 404 when user service returns ErrNotFound",
 			prep: func(setup *usersTestSetup) {
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), services.UserUpdateParams{
-					entities.UserRole: role.Applicant,
+					entities.UserRole:          role.Applicant,
+					entities.UserEmailVerified: true,
 				}).Return(services.ErrNotFound).Times(1)
 			},
 			wantResCode: http.StatusNotFound,
@@ -1443,7 +2083,8 @@ func TestApiV2Router_VerifyEmail(t *testing.T) {
 			name: "should return 500 when user service returns unknown error",
 			prep: func(setup *usersTestSetup) {
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), services.UserUpdateParams{
-					entities.UserRole: role.Applicant,
+					entities.UserRole:          role.Applicant,
+					entities.UserEmailVerified: true,
 				}).Return(errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
@@ -1452,7 +2093,8 @@ func TestApiV2Router_VerifyEmail(t *testing.T) {
 			name: "should return 200 when user's role gets updated",
 			prep: func(setup *usersTestSetup) {
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), services.UserUpdateParams{
-					entities.UserRole: role.Applicant,
+					entities.UserRole:          role.Applicant,
+					entities.UserEmailVerified: true,
 				}).Return(nil).Times(1)
 				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).Return(nil).
 					Times(1)
@@ -1463,7 +2105,8 @@ func TestApiV2Router_VerifyEmail(t *testing.T) {
 			name: "should return 200 when user's role gets updated but invalidating the email token fails",
 			prep: func(setup *usersTestSetup) {
 				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), services.UserUpdateParams{
-					entities.UserRole: role.Applicant,
+					entities.UserRole:          role.Applicant,
+					entities.UserEmailVerified: true,
 				}).Return(nil).Times(1)
 				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
 					Return(common.ErrInvalidToken).Times(1)
@@ -1575,6 +2218,235 @@ func TestApiV2Router_ResendEmailVerification(t *testing.T) {
 	}
 }
 
+func TestApiV2Router_RequestEmailChange(t *testing.T) {
+	newEmail := "new@email.com"
+	tests := []struct {
+		name        string
+		email       string
+		prep        func(*usersTestSetup)
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when email is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 400 when user service returns ErrInvalidID",
+			email: newEmail,
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 404 when user service returns ErrNotFound",
+			email: newEmail,
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:  "should return 400 when email is taken",
+			email: newEmail,
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(nil, services.ErrEmailTaken).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 500 when user service returns unknown error",
+			email: newEmail,
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 500 when confirmation email cannot be sent",
+			email: newEmail,
+			prep: func(setup *usersTestSetup) {
+				updatedUser := *setup.testUser
+				updatedUser.PendingEmail = newEmail
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(&updatedUser, nil).Times(1)
+				setup.mockEService.EXPECT().SendEmailChangeConfirmationEmail(setup.testCtx, updatedUser, newEmail, gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 2xx when notice email cannot be sent",
+			email: newEmail,
+			prep: func(setup *usersTestSetup) {
+				updatedUser := *setup.testUser
+				updatedUser.PendingEmail = newEmail
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(&updatedUser, nil).Times(1)
+				setup.mockEService.EXPECT().SendEmailChangeConfirmationEmail(setup.testCtx, updatedUser, newEmail, gomock.Any()).
+					Return(nil).Times(1)
+				setup.mockEService.EXPECT().SendEmailChangeNoticeEmail(setup.testCtx, updatedUser, newEmail).
+					Return(errors.New("service err")).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:  "should return 2xx and log audit event when email change is requested",
+			email: newEmail,
+			prep: func(setup *usersTestSetup) {
+				updatedUser := *setup.testUser
+				updatedUser.PendingEmail = newEmail
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(&updatedUser, nil).Times(1)
+				setup.mockEService.EXPECT().SendEmailChangeConfirmationEmail(setup.testCtx, updatedUser, newEmail, gomock.Any()).
+					Return(nil).Times(1)
+				setup.mockEService.EXPECT().SendEmailChangeNoticeEmail(setup.testCtx, updatedUser, newEmail).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionEmailChangeRequested,
+					Target: testUserId.Hex(),
+					After:  entities.AuditValues{"pending_email": newEmail},
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, map[string]string{
+				"email": tt.email,
+			})
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.RequestEmailChange(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func TestApiV2Router_ConfirmEmailChange(t *testing.T) {
+	newEmail := "new@email.com"
+	nonce := "abc123"
+	tests := []struct {
+		name        string
+		nonce       string
+		prep        func(*usersTestSetup)
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when nonce is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 404 when user service returns ErrNotFound",
+			nonce: nonce,
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:  "should return 400 when nonce does not match pending email change",
+			nonce: nonce,
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().ConfirmEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), nonce).
+					Return(nil, services.ErrNoPendingEmailChange).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 400 when email has been taken since the request",
+			nonce: nonce,
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().ConfirmEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), nonce).
+					Return(nil, services.ErrEmailTaken).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 500 when user service returns unknown error",
+			nonce: nonce,
+			prep: func(setup *usersTestSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().ConfirmEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), nonce).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 2xx, log audit event and invalidate token when email change is confirmed",
+			nonce: nonce,
+			prep: func(setup *usersTestSetup) {
+				setup.testUser.Role = role.Unverified
+				updatedUser := *setup.testUser
+				updatedUser.Email = newEmail
+				updatedUser.Role = role.Applicant
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().ConfirmEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), nonce).
+					Return(&updatedUser, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionEmailChanged,
+					Target: testUserId.Hex(),
+					Before: entities.AuditValues{"email": setup.testUser.Email, "role": role.Unverified},
+					After:  entities.AuditValues{"email": newEmail, "role": role.Applicant},
+				})).Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).Return(nil).
+					Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupUsersTest(t)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPut, map[string]string{
+				"nonce": tt.nonce,
+			})
+			setup.testCtx.Request.Header.Set(authTokenHeader, testAuthToken)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testUserId.Hex()})
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.ConfirmEmailChange(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
 func BenchmarkApiV2Router_GetUser(b *testing.B) {
 	b.StopTimer()
 
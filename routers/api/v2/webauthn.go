@@ -0,0 +1,316 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	v2 "github.com/unicsmcr/hs_auth/authorization/v2"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.uber.org/zap"
+)
+
+// POST: /api/v2/webauthn/register/begin
+// Response: publicKey utils.WebAuthnCreationOptions, to be passed to navigator.credentials.create()
+// Headers:  Authorization -> token
+func (r *apiV2Router) BeginWebAuthnRegistration(ctx *gin.Context) {
+	userID, ok := r.getUserIDFromToken(ctx)
+	if !ok {
+		return
+	}
+
+	options, err := r.webAuthnService.BeginRegistration(ctx, userID)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("user id", userID))
+			models.SendAPIError(ctx, http.StatusNotFound, "user not found")
+		default:
+			r.logger.Error("could not begin passkey registration", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, beginWebAuthnRegistrationRes{
+		PublicKey: *options,
+	})
+}
+
+// POST: /api/v2/webauthn/register/finish
+// x-www-form-urlencoded
+// Request:  name string (optional)
+//           credential string (JSON serialised PublicKeyCredential returned by navigator.credentials.create())
+// Response: credential entities.WebAuthnCredential
+// Headers:  Authorization -> token
+func (r *apiV2Router) FinishWebAuthnRegistration(ctx *gin.Context) {
+	var req struct {
+		Name       string `form:"name"`
+		Credential string `form:"credential"`
+	}
+	_ = ctx.Bind(&req)
+
+	credential, ok := r.parseWebAuthnCredential(ctx, req.Credential)
+	if !ok {
+		return
+	}
+
+	userID, ok := r.getUserIDFromToken(ctx)
+	if !ok {
+		return
+	}
+
+	storedCredential, err := r.webAuthnService.FinishRegistration(ctx, userID, req.Name, *credential)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidWebAuthnCredential:
+			r.logger.Debug("invalid passkey", zap.String("user id", userID), zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "passkey could not be verified")
+		case services.ErrWebAuthnCredentialExists:
+			r.logger.Debug("passkey already registered", zap.String("user id", userID))
+			models.SendAPIError(ctx, http.StatusBadRequest, "passkey is already registered")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("user id", userID))
+			models.SendAPIError(ctx, http.StatusNotFound, "user not found")
+		default:
+			r.logger.Error("could not register passkey", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionPasskeyRegistered,
+		Target: userID,
+		After: entities.AuditValues{
+			string(entities.WebAuthnCredentialID):   storedCredential.ID.Hex(),
+			string(entities.WebAuthnCredentialName): storedCredential.Name,
+		},
+	})
+
+	ctx.JSON(http.StatusOK, finishWebAuthnRegistrationRes{
+		Credential: *storedCredential,
+	})
+}
+
+// POST: /api/v2/webauthn/login/begin
+// Response: publicKey utils.WebAuthnRequestOptions, to be passed to navigator.credentials.get()
+func (r *apiV2Router) BeginWebAuthnLogin(ctx *gin.Context) {
+	// the login is not limited to a user's passkeys, since listing them in allowCredentials would reveal
+	// whether an email is registered. The authenticator offers the user's discoverable passkeys instead
+	options, err := r.webAuthnService.BeginLogin(ctx, "")
+	if err != nil {
+		r.logger.Error("could not begin passkey login", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, beginWebAuthnLoginRes{
+		PublicKey: *options,
+	})
+}
+
+// POST: /api/v2/webauthn/login/finish
+// x-www-form-urlencoded
+// Request:  credential string (JSON serialised PublicKeyCredential returned by navigator.credentials.get())
+// Response: token string
+// Headers:  Authorization <- token
+func (r *apiV2Router) FinishWebAuthnLogin(ctx *gin.Context) {
+	credential, ok := r.parseWebAuthnCredential(ctx, ctx.PostForm("credential"))
+	if !ok {
+		return
+	}
+
+	user, err := r.webAuthnService.FinishLogin(ctx, "", *credential)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidWebAuthnCredential:
+			r.logger.Debug("invalid passkey", zap.Error(err))
+			rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+				Action: entities.AuditActionLoginFailed,
+				Target: utils.EncodeWebAuthnBytes(credential.RawID),
+			})
+			models.SendAPIError(ctx, http.StatusUnauthorized, "passkey could not be verified")
+		default:
+			r.logger.Error("could not verify passkey", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	// a locked account stays locked, whichever way the user logs in
+	retryAfter, err := r.loginAttemptService.CheckLoginAllowed(ctx, user.Email, ctx.ClientIP())
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrLoginThrottled, services.ErrLoginLocked:
+			r.logger.Debug("login attempt rejected", zap.String("user id", user.ID.Hex()), zap.Error(err))
+			rcommon.SetRetryAfterHeader(ctx, retryAfter)
+			models.SendAPIError(ctx, http.StatusTooManyRequests, "too many failed login attempts, try again later")
+		default:
+			r.logger.Error("could not check if login is allowed", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	token, err := r.authorizer.CreateUserToken(user.ID, r.cfg.Auth.UserTokenLifetime+r.timeProvider.Now().Unix())
+	if err != nil {
+		r.logger.Error("could not create JWT", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	err = r.loginAttemptService.RecordSuccessfulLogin(ctx, user.Email)
+	if err != nil {
+		r.logger.Error("could not clear failed logins", zap.Error(err))
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action:    entities.AuditActionLogin,
+		Actor:     user.ID,
+		ActorType: string(v2.User),
+		Target:    user.ID.Hex(),
+	})
+
+	ctx.Header(authTokenHeader, token)
+	ctx.JSON(http.StatusOK, loginRes{
+		Token: token,
+	})
+}
+
+// GET: /api/v2/webauthn/credentials
+// Response: credentials []entities.WebAuthnCredential, the passkeys of the current user
+// Headers:  Authorization -> token
+func (r *apiV2Router) GetWebAuthnCredentials(ctx *gin.Context) {
+	userID, ok := r.getUserIDFromToken(ctx)
+	if !ok {
+		return
+	}
+
+	credentials, err := r.webAuthnService.GetCredentialsForUser(ctx, userID)
+	if err != nil {
+		r.logger.Error("could not fetch passkeys", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getWebAuthnCredentialsRes{
+		Credentials: credentials,
+	})
+}
+
+// DELETE: /api/v2/webauthn/credentials/:id
+// Response:
+// Headers:  Authorization -> token
+func (r *apiV2Router) DeleteWebAuthnCredential(ctx *gin.Context) {
+	credentialID := ctx.Param("id")
+
+	userID, ok := r.getUserIDFromToken(ctx)
+	if !ok {
+		return
+	}
+
+	err := r.webAuthnService.DeleteCredentialForUser(ctx, userID, credentialID)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid passkey id", zap.String("id", credentialID))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid id")
+		case services.ErrNotFound:
+			r.logger.Debug("passkey not found", zap.String("id", credentialID))
+			models.SendAPIError(ctx, http.StatusNotFound, "passkey not found")
+		default:
+			r.logger.Error("could not delete passkey", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionPasskeyDeleted,
+		Target: userID,
+		Before: entities.AuditValues{
+			string(entities.WebAuthnCredentialID): credentialID,
+		},
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// checkPasskey verifies the passkey used instead of a two-factor code by the user, whose password has already been checked.
+// Sends an API error and returns false if the user cannot log in with the given passkey.
+func (r *apiV2Router) checkPasskey(ctx *gin.Context, user entities.User, rawCredential string) bool {
+	credential, ok := r.parseWebAuthnCredential(ctx, rawCredential)
+	if !ok {
+		return false
+	}
+
+	_, err := r.webAuthnService.FinishLogin(ctx, user.ID.Hex(), *credential)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidWebAuthnCredential:
+			r.logger.Debug("invalid passkey", zap.String("user id", user.ID.Hex()), zap.Error(err))
+			rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+				Action: entities.AuditActionTwoFactorFailed,
+				Target: user.ID.Hex(),
+			})
+			err = r.loginAttemptService.RecordFailedLogin(ctx, user.Email, ctx.ClientIP())
+			if err != nil {
+				r.logger.Error("could not record failed login", zap.Error(err))
+			}
+			models.SendAPIError(ctx, http.StatusUnauthorized, "passkey could not be verified")
+		default:
+			r.logger.Error("could not verify passkey", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return false
+	}
+
+	return true
+}
+
+// parseWebAuthnCredential parses the JSON serialised PublicKeyCredential sent by the browser.
+// Sends an API error and returns false if the credential is missing or malformed.
+func (r *apiV2Router) parseWebAuthnCredential(ctx *gin.Context, rawCredential string) (*utils.WebAuthnCredentialResponse, bool) {
+	if len(rawCredential) == 0 {
+		r.logger.Debug("passkey credential was not provided")
+		models.SendAPIError(ctx, http.StatusBadRequest, "credential must be provided")
+		return nil, false
+	}
+
+	credential, err := utils.ParseWebAuthnCredentialResponse(rawCredential)
+	if err != nil {
+		r.logger.Debug("could not parse passkey credential", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, "failed to parse credential")
+		return nil, false
+	}
+
+	return credential, true
+}
+
+// getUserIDFromToken extracts the id of the current user from the auth token.
+// Sends an API error and returns false if the token is not a valid user token.
+func (r *apiV2Router) getUserIDFromToken(ctx *gin.Context) (string, bool) {
+	userID, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		switch errors.Cause(err) {
+		case common.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		case common.ErrInvalidTokenType:
+			r.logger.Debug("invalid token type", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, "provided token is of invalid type for the requested operation")
+		default:
+			r.logger.Error("could not extract user id from token", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return "", false
+	}
+
+	return userID.Hex(), true
+}
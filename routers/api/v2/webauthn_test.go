@@ -0,0 +1,470 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_v2 "github.com/unicsmcr/hs_auth/mocks/authorization/v2"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var (
+	testPasskeyAuthenticator = testutils.NewWebAuthnAuthenticator()
+	testPasskeyAssertion     = testPasskeyAuthenticator.GetAssertion("localhost", "http://localhost:8000",
+		[]byte("challenge"), testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified, nil)
+	testPasskeyAttestation = testPasskeyAuthenticator.MakeCredential("localhost", "http://localhost:8000",
+		[]byte("challenge"), testutils.WebAuthnFlagUserPresent)
+	testPasskey = entities.WebAuthnCredential{
+		ID:           primitive.NewObjectID(),
+		UserID:       testUserId,
+		CredentialID: testPasskeyAuthenticator.EncodedCredentialID(),
+		Name:         "My key",
+	}
+)
+
+type webAuthnTestSetup struct {
+	ctrl             *gomock.Controller
+	router           APIV2Router
+	mockAuthorizer   *mock_v2.MockAuthorizer
+	mockUService     *mock_services.MockUserService
+	mockAService     *mock_services.MockAuditService
+	mockLService     *mock_services.MockLoginAttemptService
+	mockWAService    *mock_services.MockWebAuthnService
+	mockTimeProvider *mock_utils.MockTimeProvider
+	testUser         *entities.User
+	testCtx          *gin.Context
+	w                *httptest.ResponseRecorder
+}
+
+func setupWebAuthnTest(t *testing.T) *webAuthnTestSetup {
+	ctrl := gomock.NewController(t)
+	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
+	mockUService := mock_services.NewMockUserService(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
+	mockLService := mock_services.NewMockLoginAttemptService(ctrl)
+	mockWAService := mock_services.NewMockWebAuthnService(ctrl)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+
+	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{
+		Auth: config.AuthConfig{
+			UserTokenLifetime: testAuthTokenLifetime,
+		},
+	}, mockAuthorizer, mockUService, nil, nil, nil, mockAService, nil, mockLService, nil, mockWAService, nil, nil, nil, nil, mockTimeProvider)
+
+	w := httptest.NewRecorder()
+	testCtx, _ := gin.CreateTestContext(w)
+
+	return &webAuthnTestSetup{
+		ctrl:             ctrl,
+		router:           router,
+		mockAuthorizer:   mockAuthorizer,
+		mockUService:     mockUService,
+		mockAService:     mockAService,
+		mockLService:     mockLService,
+		mockWAService:    mockWAService,
+		mockTimeProvider: mockTimeProvider,
+		testUser: &entities.User{
+			ID:    testUserId,
+			Name:  "Bob the Tester",
+			Email: "test@email.com",
+		},
+		testCtx: testCtx,
+		w:       w,
+	}
+}
+
+func TestApiV2Router_BeginWebAuthnRegistration(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*webAuthnTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when token is invalid",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).
+					Return(primitive.ObjectID{}, common.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 500 when webauthn service returns error",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().BeginRegistration(gomock.Any(), testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and options",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().BeginRegistration(gomock.Any(), testUserId.Hex()).
+					Return(&utils.WebAuthnCreationOptions{Challenge: []byte("challenge")}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebAuthnTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodPost, "/webauthn/register/begin", nil)
+			tt.prep(setup)
+
+			setup.router.BeginWebAuthnRegistration(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantResCode == http.StatusOK {
+				var actualRes beginWebAuthnRegistrationRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("challenge"), []byte(actualRes.PublicKey.Challenge))
+			}
+		})
+	}
+}
+
+func TestApiV2Router_FinishWebAuthnRegistration(t *testing.T) {
+	tests := []struct {
+		name        string
+		credential  string
+		prep        func(*webAuthnTestSetup)
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when credential is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when credential is malformed",
+			credential:  "not json",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 400 when webauthn service returns ErrInvalidWebAuthnCredential",
+			credential: testPasskeyAttestation,
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishRegistration(gomock.Any(), testUserId.Hex(), "My key", gomock.Any()).
+					Return(nil, services.ErrInvalidWebAuthnCredential).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 400 when webauthn service returns ErrWebAuthnCredentialExists",
+			credential: testPasskeyAttestation,
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishRegistration(gomock.Any(), testUserId.Hex(), "My key", gomock.Any()).
+					Return(nil, services.ErrWebAuthnCredentialExists).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 500 when webauthn service returns unknown error",
+			credential: testPasskeyAttestation,
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishRegistration(gomock.Any(), testUserId.Hex(), "My key", gomock.Any()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "should return 200 and log audit event when passkey is registered",
+			credential: testPasskeyAttestation,
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishRegistration(gomock.Any(), testUserId.Hex(), "My key", gomock.Any()).
+					Return(&testPasskey, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionPasskeyRegistered,
+					Target: testUserId.Hex(),
+					After: entities.AuditValues{
+						string(entities.WebAuthnCredentialID):   testPasskey.ID.Hex(),
+						string(entities.WebAuthnCredentialName): testPasskey.Name,
+					},
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebAuthnTest(t)
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"name":       "My key",
+				"credential": tt.credential,
+			})
+
+			setup.router.FinishWebAuthnRegistration(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantResCode == http.StatusOK {
+				var actualRes finishWebAuthnRegistrationRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, testPasskey.ID, actualRes.Credential.ID)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_BeginWebAuthnLogin(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*webAuthnTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should allow any passkey",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockWAService.EXPECT().BeginLogin(gomock.Any(), "").
+					Return(&utils.WebAuthnRequestOptions{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name: "should return 500 when webauthn service returns error",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockWAService.EXPECT().BeginLogin(gomock.Any(), "").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebAuthnTest(t)
+			defer setup.ctrl.Finish()
+			tt.prep(setup)
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+
+			setup.router.BeginWebAuthnLogin(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func TestApiV2Router_FinishWebAuthnLogin(t *testing.T) {
+	tests := []struct {
+		name        string
+		credential  string
+		prep        func(*webAuthnTestSetup)
+		wantResCode int
+		wantRes     *loginRes
+	}{
+		{
+			name:        "should return 400 when credential is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 401 and log failed login when passkey is invalid",
+			credential: testPasskeyAssertion,
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockWAService.EXPECT().FinishLogin(gomock.Any(), "", gomock.Any()).
+					Return(nil, services.ErrInvalidWebAuthnCredential).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionLoginFailed,
+					Target: testPasskeyAuthenticator.EncodedCredentialID(),
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "should return 429 when account is locked",
+			credential: testPasskeyAssertion,
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockWAService.EXPECT().FinishLogin(gomock.Any(), "", gomock.Any()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Minute, services.ErrLoginLocked).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name:       "should return 200 and token when passkey is valid",
+			credential: testPasskeyAssertion,
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockWAService.EXPECT().FinishLogin(gomock.Any(), "", gomock.Any()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(0, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, int64(testAuthTokenLifetime)).
+					Return("test_token", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), newAuditEventMatcher(entities.AuditEvent{
+					Action:    entities.AuditActionLogin,
+					Actor:     testUserId,
+					ActorType: "user",
+					Target:    testUserId.Hex(),
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &loginRes{
+				Token: "test_token",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebAuthnTest(t)
+			defer setup.ctrl.Finish()
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"credential": tt.credential,
+			})
+
+			setup.router.FinishWebAuthnLogin(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				var actualRes loginRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+				assert.Equal(t, "test_token", setup.w.Header().Get(authTokenHeader))
+			}
+		})
+	}
+}
+
+func TestApiV2Router_GetWebAuthnCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*webAuthnTestSetup)
+		wantResCode int
+		wantRes     *getWebAuthnCredentialsRes
+	}{
+		{
+			name: "should return 500 when webauthn service returns error",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().GetCredentialsForUser(gomock.Any(), testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and user's passkeys",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().GetCredentialsForUser(gomock.Any(), testUserId.Hex()).
+					Return([]entities.WebAuthnCredential{testPasskey}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &getWebAuthnCredentialsRes{
+				Credentials: []entities.WebAuthnCredential{testPasskey},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebAuthnTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/webauthn/credentials", nil)
+			tt.prep(setup)
+
+			setup.router.GetWebAuthnCredentials(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				var actualRes getWebAuthnCredentialsRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_DeleteWebAuthnCredential(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*webAuthnTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when webauthn service returns ErrInvalidID",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialForUser(gomock.Any(), testUserId.Hex(), testPasskey.ID.Hex()).
+					Return(services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when webauthn service returns ErrNotFound",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialForUser(gomock.Any(), testUserId.Hex(), testPasskey.ID.Hex()).
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 204 and log audit event when passkey is deleted",
+			prep: func(setup *webAuthnTestSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialForUser(gomock.Any(), testUserId.Hex(), testPasskey.ID.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionPasskeyDeleted,
+					Target: testUserId.Hex(),
+					Before: entities.AuditValues{
+						string(entities.WebAuthnCredentialID): testPasskey.ID.Hex(),
+					},
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebAuthnTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodDelete, "/webauthn/credentials/"+testPasskey.ID.Hex(), nil)
+			setup.testCtx.Params = gin.Params{{Key: "id", Value: testPasskey.ID.Hex()}}
+			tt.prep(setup)
+
+			setup.router.DeleteWebAuthnCredential(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
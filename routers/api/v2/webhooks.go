@@ -0,0 +1,163 @@
+package v2
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
+	rcommon "github.com/unicsmcr/hs_auth/routers/common"
+	"github.com/unicsmcr/hs_auth/services"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWebhookDeliveriesLimit = 100
+	maxWebhookDeliveriesLimit     = 1000
+)
+
+// GET: /api/v2/webhooks
+// Response: webhooks []entities.Webhook, without their secrets
+// Headers:  Authorization -> token
+func (r *apiV2Router) GetWebhooks(ctx *gin.Context) {
+	webhooks, err := r.webhookService.GetWebhooks(ctx)
+	if err != nil {
+		r.logger.Error("could not fetch webhooks", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	ctx.JSON(http.StatusOK, getWebhooksRes{
+		Webhooks: webhooks,
+	})
+}
+
+// POST: /api/v2/webhooks
+// x-www-form-urlencoded
+// Request:  url string
+//           events string, comma separated list of entities.WebhookEvent
+// Response: webhook entities.Webhook, the webhook's secret is only returned here
+// Headers:  Authorization -> token
+func (r *apiV2Router) CreateWebhook(ctx *gin.Context) {
+	var req struct {
+		URL    string `form:"url"`
+		Events string `form:"events"`
+	}
+	err := ctx.Bind(&req)
+	if err != nil {
+		r.logger.Debug("could not parse create webhook request", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusBadRequest, "failed to parse request")
+		return
+	}
+
+	if len(req.URL) == 0 || len(req.Events) == 0 {
+		r.logger.Debug("url or events not provided in request")
+		models.SendAPIError(ctx, http.StatusBadRequest, "url and events must be provided")
+		return
+	}
+
+	var events []entities.WebhookEvent
+	for _, event := range strings.Split(req.Events, ",") {
+		events = append(events, entities.WebhookEvent(strings.TrimSpace(event)))
+	}
+
+	webhook, err := r.webhookService.CreateWebhook(ctx, req.URL, events)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidWebhookURL:
+			r.logger.Debug("invalid webhook url", zap.String("url", req.URL))
+			models.SendAPIError(ctx, http.StatusBadRequest, "url must be an absolute http or https url")
+		case services.ErrInvalidWebhookEvent:
+			r.logger.Debug("invalid webhook event", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusBadRequest, err.Error())
+		default:
+			r.logger.Error("could not create webhook", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionWebhookCreated,
+		Target: webhook.ID.Hex(),
+		After: entities.AuditValues{
+			string(entities.WebhookURL):    webhook.URL,
+			string(entities.WebhookEvents): webhook.Events,
+		},
+	})
+
+	ctx.JSON(http.StatusOK, createWebhookRes{
+		Webhook: *webhook,
+	})
+}
+
+// DELETE: /api/v2/webhooks/:id
+// Response:
+// Headers:  Authorization -> token
+func (r *apiV2Router) DeleteWebhook(ctx *gin.Context) {
+	webhookID := ctx.Param("id")
+
+	err := r.webhookService.DeleteWebhookWithID(ctx, webhookID)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid webhook id", zap.String("id", webhookID))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid id")
+		case services.ErrNotFound:
+			r.logger.Debug("webhook not found", zap.String("id", webhookID))
+			models.SendAPIError(ctx, http.StatusNotFound, "webhook not found")
+		default:
+			r.logger.Error("could not delete webhook", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	rcommon.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionWebhookDeleted,
+		Target: webhookID,
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GET: /api/v2/webhooks/:id/deliveries?limit={limit}
+// Request:  (Optional) limit int64, defaults to 100, at most 1000
+// Response: deliveries []entities.WebhookDelivery, newest first
+// Headers:  Authorization -> token
+func (r *apiV2Router) GetWebhookDeliveries(ctx *gin.Context) {
+	limit := int64(defaultWebhookDeliveriesLimit)
+	if len(ctx.Query("limit")) > 0 {
+		var err error
+		limit, err = strconv.ParseInt(ctx.Query("limit"), 10, 64)
+		if err != nil || limit <= 0 || limit > maxWebhookDeliveriesLimit {
+			r.logger.Debug("invalid limit", zap.String("limit", ctx.Query("limit")))
+			models.SendAPIError(ctx, http.StatusBadRequest, "limit must be a number between 1 and "+strconv.Itoa(maxWebhookDeliveriesLimit))
+			return
+		}
+	}
+
+	deliveries, err := r.webhookService.GetDeliveriesForWebhookWithID(ctx, ctx.Param("id"), limit)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid webhook id", zap.String("id", ctx.Param("id")))
+			models.SendAPIError(ctx, http.StatusBadRequest, "invalid id")
+		default:
+			r.logger.Error("could not fetch webhook deliveries", zap.Error(err))
+			models.SendAPIError(ctx, http.StatusInternalServerError, "something went wrong")
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getWebhookDeliveriesRes{
+		Deliveries: deliveries,
+	})
+}
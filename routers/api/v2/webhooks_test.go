@@ -0,0 +1,349 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_v2 "github.com/unicsmcr/hs_auth/mocks/authorization/v2"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.uber.org/zap"
+)
+
+type webhookTestSetup struct {
+	ctrl         *gomock.Controller
+	router       APIV2Router
+	mockAService *mock_services.MockAuditService
+	mockWService *mock_services.MockWebhookService
+	testCtx      *gin.Context
+	w            *httptest.ResponseRecorder
+}
+
+func setupWebhookTest(t *testing.T) *webhookTestSetup {
+	ctrl := gomock.NewController(t)
+	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
+	mockWService := mock_services.NewMockWebhookService(ctrl)
+
+	router := NewAPIV2Router(zap.NewNop(), &config.AppConfig{}, mockAuthorizer, nil, nil, nil, nil, mockAService, mockWService, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	testCtx, _ := gin.CreateTestContext(w)
+
+	return &webhookTestSetup{
+		ctrl:         ctrl,
+		router:       router,
+		mockAService: mockAService,
+		mockWService: mockWService,
+		testCtx:      testCtx,
+		w:            w,
+	}
+}
+
+var testWebhook = entities.Webhook{
+	ID:     testUserId,
+	URL:    "https://hs_apply.unicsmcr.com/webhooks/hs_auth",
+	Secret: "supersecret",
+	Events: []entities.WebhookEvent{entities.WebhookEventUserRegistered, entities.WebhookEventTeamCreated},
+}
+
+func TestApiV2Router_GetWebhooks(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*webhookTestSetup)
+		wantResCode int
+		wantRes     *getWebhooksRes
+	}{
+		{
+			name: "should return 200 and webhooks without their secrets",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().GetWebhooks(setup.testCtx).
+					Return([]entities.Webhook{testWebhook}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes: &getWebhooksRes{Webhooks: []entities.Webhook{{
+				ID:     testWebhook.ID,
+				URL:    testWebhook.URL,
+				Events: testWebhook.Events,
+			}}},
+		},
+		{
+			name: "should return 500 when webhook service returns error",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().GetWebhooks(setup.testCtx).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebhookTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/webhooks", nil)
+			tt.prep(setup)
+
+			setup.router.GetWebhooks(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				var actualRes getWebhooksRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_CreateWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		events      string
+		prep        func(*webhookTestSetup)
+		wantResCode int
+		wantRes     *createWebhookRes
+	}{
+		{
+			name:        "should return 400 when url is not provided",
+			events:      "user.registered",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when events are not provided",
+			url:         testWebhook.URL,
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 400 when webhook service returns ErrInvalidWebhookURL",
+			url:    "not a url",
+			events: "user.registered",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().CreateWebhook(setup.testCtx, "not a url", gomock.Any()).
+					Return(nil, services.ErrInvalidWebhookURL).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 400 when webhook service returns ErrInvalidWebhookEvent",
+			url:    testWebhook.URL,
+			events: "user.deleted",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().CreateWebhook(setup.testCtx, testWebhook.URL, gomock.Any()).
+					Return(nil, services.ErrInvalidWebhookEvent).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 500 when webhook service returns unknown error",
+			url:    testWebhook.URL,
+			events: "user.registered",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().CreateWebhook(setup.testCtx, testWebhook.URL, gomock.Any()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 200 and created webhook with its secret",
+			url:    testWebhook.URL,
+			events: "user.registered, team.created",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().CreateWebhook(setup.testCtx, testWebhook.URL, testWebhook.Events).
+					Return(&testWebhook, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionWebhookCreated,
+					Target: testWebhook.ID.Hex(),
+					After: entities.AuditValues{
+						string(entities.WebhookURL):    testWebhook.URL,
+						string(entities.WebhookEvents): testWebhook.Events,
+					},
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes:     &createWebhookRes{Webhook: testWebhook},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebhookTest(t)
+			defer setup.ctrl.Finish()
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"url":    tt.url,
+				"events": tt.events,
+			})
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.CreateWebhook(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				var actualRes createWebhookRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
+
+func TestApiV2Router_DeleteWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*webhookTestSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when webhook service returns ErrInvalidID",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().DeleteWebhookWithID(setup.testCtx, testWebhook.ID.Hex()).
+					Return(services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when webhook service returns ErrNotFound",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().DeleteWebhookWithID(setup.testCtx, testWebhook.ID.Hex()).
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when webhook service returns unknown error",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().DeleteWebhookWithID(setup.testCtx, testWebhook.ID.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 204",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().DeleteWebhookWithID(setup.testCtx, testWebhook.ID.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(setup.testCtx, newAuditEventMatcher(entities.AuditEvent{
+					Action: entities.AuditActionWebhookDeleted,
+					Target: testWebhook.ID.Hex(),
+				})).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebhookTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodDelete, "/webhooks/"+testWebhook.ID.Hex(), nil)
+			setup.testCtx.Params = gin.Params{{Key: "id", Value: testWebhook.ID.Hex()}}
+			tt.prep(setup)
+
+			setup.router.DeleteWebhook(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.testCtx.Writer.Status())
+		})
+	}
+}
+
+func TestApiV2Router_GetWebhookDeliveries(t *testing.T) {
+	testDeliveries := []entities.WebhookDelivery{
+		{
+			ID:        testUserId,
+			WebhookID: testWebhook.ID,
+			Event:     entities.WebhookEventUserRegistered,
+			Payload:   "{}",
+			Status:    entities.WebhookDeliveryDelivered,
+			Attempts:  1,
+		},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		prep        func(*webhookTestSetup)
+		wantResCode int
+		wantRes     *getWebhookDeliveriesRes
+	}{
+		{
+			name: "should return 200 and deliveries with default limit",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().GetDeliveriesForWebhookWithID(setup.testCtx, testWebhook.ID.Hex(), int64(defaultWebhookDeliveriesLimit)).
+					Return(testDeliveries, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes:     &getWebhookDeliveriesRes{Deliveries: testDeliveries},
+		},
+		{
+			name:  "should return 200 and deliveries with provided limit",
+			query: "limit=5",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().GetDeliveriesForWebhookWithID(setup.testCtx, testWebhook.ID.Hex(), int64(5)).
+					Return(testDeliveries, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+			wantRes:     &getWebhookDeliveriesRes{Deliveries: testDeliveries},
+		},
+		{
+			name:        "should return 400 when limit is not a number",
+			query:       "limit=five",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when limit is too large",
+			query:       "limit=1001",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when webhook service returns ErrInvalidID",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().GetDeliveriesForWebhookWithID(setup.testCtx, gomock.Any(), gomock.Any()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when webhook service returns unknown error",
+			prep: func(setup *webhookTestSetup) {
+				setup.mockWService.EXPECT().GetDeliveriesForWebhookWithID(setup.testCtx, gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebhookTest(t)
+			defer setup.ctrl.Finish()
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/webhooks/"+testWebhook.ID.Hex()+"/deliveries?"+tt.query, nil)
+			setup.testCtx.Params = gin.Params{{Key: "id", Value: testWebhook.ID.Hex()}}
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.GetWebhookDeliveries(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantRes != nil {
+				var actualRes getWebhookDeliveriesRes
+				err := testutils.UnmarshallResponse(setup.w.Body, &actualRes)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.wantRes, actualRes)
+			}
+		})
+	}
+}
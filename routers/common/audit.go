@@ -0,0 +1,28 @@
+package common
+
+import (
+	"github.com/gin-gonic/gin"
+	authV2 "github.com/unicsmcr/hs_auth/authorization/v2"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/services"
+	"go.uber.org/zap"
+)
+
+// LogAuditEvent appends the given event to the audit log, recording the request's IP address and user agent.
+// The event's actor is set to the holder of the request's auth token if the event does not specify one.
+// Failing to log the event should not fail the request, so errors are only logged.
+func LogAuditEvent(ctx *gin.Context, logger *zap.Logger, auditService services.AuditService, event entities.AuditEvent) {
+	if event.Actor.IsZero() {
+		if actor, exists := authV2.GetActor(ctx); exists {
+			event.Actor = actor.ID
+			event.ActorType = string(actor.TokenType)
+		}
+	}
+	event.IP = ctx.ClientIP()
+	event.UserAgent = ctx.Request.UserAgent()
+
+	err := auditService.LogEvent(ctx, event)
+	if err != nil {
+		logger.Error("could not log audit event", zap.String("action", string(event.Action)), zap.Error(err))
+	}
+}
@@ -22,3 +22,34 @@ func MakePasswordResetURIs(user entities.User) common.UniformResourceIdentifiers
 
 	return []common.UniformResourceIdentifier{apiV2Uri, frontendUri}
 }
+
+// MakeTwoFactorLoginURIs returns the URIs a user whose password has been checked needs to enter their two-factor code
+func MakeTwoFactorLoginURIs(user entities.User) common.UniformResourceIdentifiers {
+	frontendUri, _ := common.NewURIFromString(fmt.Sprintf("%s:LoginTwoFactor?postForm_userId=%s", FrontendResourcePath, user.ID.Hex()))
+
+	return []common.UniformResourceIdentifier{frontendUri}
+}
+
+// MakeTwoFactorEnrollmentURIs returns the URIs a user whose password has been checked needs to set up
+// two-factor authentication when it is required for their role
+func MakeTwoFactorEnrollmentURIs(user entities.User) common.UniformResourceIdentifiers {
+	frontendUri, _ := common.NewURIFromString(fmt.Sprintf("%s:EnableTwoFactor?postForm_userId=%s", FrontendResourcePath, user.ID.Hex()))
+
+	return []common.UniformResourceIdentifier{frontendUri}
+}
+
+// MakeLoginLinkURIs returns the URIs a user needs to log in with the link sent to their email
+func MakeLoginLinkURIs(user entities.User) common.UniformResourceIdentifiers {
+	frontendUri, _ := common.NewURIFromString(fmt.Sprintf("%s:LoginWithLink?query_userId=%s", FrontendResourcePath, user.ID.Hex()))
+
+	return []common.UniformResourceIdentifier{frontendUri}
+}
+
+// MakeEmailChangeConfirmationURIs returns the URIs a user needs to confirm the change of their email to their pending email.
+// The URIs are bound to the nonce of the pending change, so they cannot confirm a later change
+func MakeEmailChangeConfirmationURIs(user entities.User) common.UniformResourceIdentifiers {
+	apiV2Uri, _ := common.NewURIFromString(fmt.Sprintf("%s:ConfirmEmailChange?path_id=%s&postForm_nonce=%s", ApiV2ResourcePath, user.ID.Hex(), user.PendingEmailNonce))
+	frontendUri, _ := common.NewURIFromString(fmt.Sprintf("%s:ConfirmEmailChange?query_userId=%s&query_nonce=%s", FrontendResourcePath, user.ID.Hex(), user.PendingEmailNonce))
+
+	return []common.UniformResourceIdentifier{apiV2Uri, frontendUri}
+}
@@ -2,11 +2,14 @@ package common
 
 import (
 	"fmt"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/unicsmcr/hs_auth/entities"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 )
 
 var testUserId = primitive.NewObjectID()
@@ -44,3 +47,72 @@ func TestMakePasswordResetURIs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, fmt.Sprintf("\"hs:hs_auth:frontend:ResetPassword?postForm_userId=%s\"", testUserId.Hex()), unescapedFrontendUri)
 }
+
+func TestMakeTwoFactorLoginURIs(t *testing.T) {
+	uris := MakeTwoFactorLoginURIs(entities.User{ID: testUserId})
+
+	assert.Len(t, uris, 1)
+
+	frontendUri, err := uris[0].MarshalJSON()
+	assert.NoError(t, err)
+	unescapedFrontendUri, err := url.QueryUnescape(string(frontendUri))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("\"hs:hs_auth:frontend:LoginTwoFactor?postForm_userId=%s\"", testUserId.Hex()), unescapedFrontendUri)
+}
+
+func TestMakeTwoFactorEnrollmentURIs(t *testing.T) {
+	uris := MakeTwoFactorEnrollmentURIs(entities.User{ID: testUserId})
+
+	assert.Len(t, uris, 1)
+
+	frontendUri, err := uris[0].MarshalJSON()
+	assert.NoError(t, err)
+	unescapedFrontendUri, err := url.QueryUnescape(string(frontendUri))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("\"hs:hs_auth:frontend:EnableTwoFactor?postForm_userId=%s\"", testUserId.Hex()), unescapedFrontendUri)
+}
+
+func TestMakeLoginLinkURIs(t *testing.T) {
+	uris := MakeLoginLinkURIs(entities.User{ID: testUserId})
+
+	assert.Len(t, uris, 1)
+
+	frontendUri, err := uris[0].MarshalJSON()
+	assert.NoError(t, err)
+	unescapedFrontendUri, err := url.QueryUnescape(string(frontendUri))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("\"hs:hs_auth:frontend:LoginWithLink?query_userId=%s\"", testUserId.Hex()), unescapedFrontendUri)
+}
+
+func TestMakeEmailChangeConfirmationURIs(t *testing.T) {
+	uris := MakeEmailChangeConfirmationURIs(entities.User{ID: testUserId, PendingEmailNonce: "abc123"})
+
+	assert.Len(t, uris, 2)
+
+	assert.Equal(t, "hs:hs_auth:api:v2:ConfirmEmailChange", uris[0].GetPath())
+	assert.Equal(t, map[string]string{"path_id": testUserId.Hex(), "postForm_nonce": "abc123"}, uris[0].GetArguments())
+	assert.Equal(t, "hs:hs_auth:frontend:ConfirmEmailChange", uris[1].GetPath())
+	assert.Equal(t, map[string]string{"query_userId": testUserId.Hex(), "query_nonce": "abc123"}, uris[1].GetArguments())
+}
+
+func TestSetRetryAfterHeader(t *testing.T) {
+	tests := []struct {
+		wait     time.Duration
+		expected string
+	}{
+		{wait: 0, expected: "0"},
+		{wait: 2 * time.Second, expected: "2"},
+		{wait: 1500 * time.Millisecond, expected: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wait.String(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			testCtx, _ := gin.CreateTestContext(w)
+
+			SetRetryAfterHeader(testCtx, tt.wait)
+
+			assert.Equal(t, tt.expected, w.Header().Get("Retry-After"))
+		})
+	}
+}
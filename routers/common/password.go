@@ -0,0 +1,46 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/services/password"
+)
+
+var characterClassDescriptions = map[string]string{
+	password.ClassLowercase: "a lowercase letter",
+	password.ClassUppercase: "an uppercase letter",
+	password.ClassLetter:    "a letter",
+	password.ClassDigit:     "a digit",
+	password.ClassSymbol:    "a symbol",
+}
+
+// PasswordPolicyErrorMessage returns a message telling the user why their password was rejected by the password policy
+func PasswordPolicyErrorMessage(cfg config.PasswordPolicyConfig, err error) string {
+	switch errors.Cause(err) {
+	case services.ErrPasswordTooShort:
+		return fmt.Sprintf("password must be at least %d characters long", cfg.MinLength)
+	case services.ErrPasswordMissingCharacterClass:
+		descriptions := make([]string, len(cfg.RequiredCharacterClasses))
+		for i, class := range cfg.RequiredCharacterClasses {
+			descriptions[i] = characterClassDescriptions[class]
+		}
+		switch len(descriptions) {
+		case 0:
+			return "password is not allowed"
+		case 1:
+			return fmt.Sprintf("password must contain %s", descriptions[0])
+		}
+		return fmt.Sprintf("password must contain %s and %s", strings.Join(descriptions[:len(descriptions)-1], ", "),
+			descriptions[len(descriptions)-1])
+	case services.ErrPasswordContainsPersonalInfo:
+		return "password must not contain your name or email"
+	case services.ErrPasswordBreached:
+		return "password has appeared in a data breach, please choose a different one"
+	default:
+		return "password is not allowed"
+	}
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/services/password"
+)
+
+func TestPasswordPolicyErrorMessage(t *testing.T) {
+	cfg := config.PasswordPolicyConfig{
+		MinLength:                8,
+		RequiredCharacterClasses: []string{password.ClassLetter, password.ClassDigit, password.ClassSymbol},
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.PasswordPolicyConfig
+		err  error
+		want string
+	}{
+		{
+			name: "ErrPasswordTooShort",
+			cfg:  cfg,
+			err:  services.ErrPasswordTooShort,
+			want: "password must be at least 8 characters long",
+		},
+		{
+			name: "ErrPasswordMissingCharacterClass with multiple classes",
+			cfg:  cfg,
+			err:  pkgerrors.Wrap(services.ErrPasswordMissingCharacterClass, "no digit"),
+			want: "password must contain a letter, a digit and a symbol",
+		},
+		{
+			name: "ErrPasswordMissingCharacterClass with one class",
+			cfg:  config.PasswordPolicyConfig{RequiredCharacterClasses: []string{password.ClassUppercase}},
+			err:  services.ErrPasswordMissingCharacterClass,
+			want: "password must contain an uppercase letter",
+		},
+		{
+			name: "ErrPasswordContainsPersonalInfo",
+			cfg:  cfg,
+			err:  services.ErrPasswordContainsPersonalInfo,
+			want: "password must not contain your name or email",
+		},
+		{
+			name: "ErrPasswordBreached",
+			cfg:  cfg,
+			err:  services.ErrPasswordBreached,
+			want: "password has appeared in a data breach, please choose a different one",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PasswordPolicyErrorMessage(tt.cfg, tt.err))
+		})
+	}
+}
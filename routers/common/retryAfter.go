@@ -0,0 +1,19 @@
+package common
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const retryAfterHeader = "Retry-After"
+
+// SetRetryAfterHeader tells the client how long to wait before retrying the request, rounded up to whole seconds
+func SetRetryAfterHeader(ctx *gin.Context, wait time.Duration) {
+	seconds := int64(wait / time.Second)
+	if wait%time.Second != 0 {
+		seconds++
+	}
+	ctx.Header(retryAfterHeader, strconv.FormatInt(seconds, 10))
+}
@@ -1,8 +1,12 @@
 package frontend
 
 import (
+	"time"
+
 	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/config/role"
 	"github.com/unicsmcr/hs_auth/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type pageDataModel struct {
@@ -22,13 +26,89 @@ type teamPanelDataModel struct {
 	Team                 *entities.Team
 	Teammates            []entities.User
 	TeamMembersSoftLimit uint
+	// TeamMembersLimit is the most members the team can have, 0 when there is no limit
+	TeamMembersLimit int
+	// IsCreator is true when the user viewing the panel created the team
+	IsCreator bool
+	// JoinRequests are the pending requests to join the team, only set for the team's creator
+	JoinRequests []teamJoinRequest
+	// RequestedTeams are the teams the user has asked to join, only set when the user is not in a team
+	RequestedTeams []entities.Team
+	// Tracks are the judging tracks the team can enter, any track can be entered when empty
+	Tracks []string
+}
+
+type matchmakingPanelDataModel struct {
+	// InTeam is true when the user viewing the panel is in a team, so the user cannot have a profile
+	InTeam bool
+	// IsCreator is true when the user viewing the panel created their team, so the user manages its advert
+	IsCreator bool
+	// TeamFull is true when the user's team has reached its member limit, so it cannot be advertised
+	TeamFull       bool
+	Profile        *entities.MatchmakingProfile
+	TeamMatches    []entities.TeamMatch
+	Advert         *entities.TeamAdvert
+	ProfileMatches []entities.ProfileMatch
+}
+
+type teamJoinRequest struct {
+	ID        primitive.ObjectID
+	User      entities.User
+	CreatedAt time.Time
 }
 
 type personalInformationPanelDataModel struct {
-	Name  string
-	Email string
+	Name         string
+	Email        string
+	PendingEmail string
+}
+
+type twoFactorPanelDataModel struct {
+	Enabled                bool
+	Required               bool
+	RecoveryCodesRemaining int
+}
+
+type passkeyPanelDataModel struct {
+	Passkeys []entities.WebAuthnCredential
+}
+
+type linkedAccountsPanelDataModel struct {
+	Accounts []linkedAccount
+}
+
+// linkedAccount is an identity provider and the user's account with it, if one is linked
+type linkedAccount struct {
+	Provider    string
+	DisplayName string
+	Identity    *entities.ExternalIdentity
 }
 
 type usersListPanelDataModel struct {
 	Users []entities.User
 }
+
+type usersDashboardPanelDataModel struct {
+	Users  []usersDashboardUser
+	Teams  []entities.Team
+	Roles  []role.UserRole
+	Filter usersDashboardFilter
+}
+
+// usersDashboardUser is a user as shown on the users dashboard
+type usersDashboardUser struct {
+	entities.User
+	TeamName string
+	Verified bool
+	// Permissions are the user's special permissions, one per line
+	Permissions string
+}
+
+// usersDashboardFilter holds the search and filters applied to the users dashboard.
+// Empty values match all users
+type usersDashboardFilter struct {
+	Query    string
+	Role     string
+	Team     string
+	Verified string
+}
@@ -4,7 +4,12 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
 	"github.com/unicsmcr/hs_auth/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"strings"
 )
 
 const defaultComponentsGroup = "Default"
@@ -20,15 +25,40 @@ var (
 		dataProvider: personalInformationPanelDataProvider,
 	}
 
+	twoFactorPanel = frontendComponent{
+		name:         fmt.Sprintf("%s:TwoFactorPanel", defaultComponentsGroup),
+		dataProvider: twoFactorPanelDataProvider,
+	}
+
+	passkeyPanel = frontendComponent{
+		name:         fmt.Sprintf("%s:PasskeyPanel", defaultComponentsGroup),
+		dataProvider: passkeyPanelDataProvider,
+	}
+
+	linkedAccountsPanel = frontendComponent{
+		name:         fmt.Sprintf("%s:LinkedAccountsPanel", defaultComponentsGroup),
+		dataProvider: linkedAccountsPanelDataProvider,
+	}
+
 	teamPanel = frontendComponent{
 		name:         "TeamPanel",
 		dataProvider: teamPanelDataProvider,
 	}
 
+	matchmakingPanel = frontendComponent{
+		name:         "MatchmakingPanel",
+		dataProvider: matchmakingPanelDataProvider,
+	}
+
 	usersListPanel = frontendComponent{
 		name:         "UsersListPanel",
 		dataProvider: usersListPanelDataProvider,
 	}
+
+	usersDashboardPanel = frontendComponent{
+		name:         "UsersDashboardPanel",
+		dataProvider: usersDashboardPanelDataProvider,
+	}
 )
 
 func navbarDataProvider(ctx *gin.Context, _ *frontendRouter) (interface{}, error) {
@@ -54,8 +84,73 @@ func personalInformationPanelDataProvider(ctx *gin.Context, r *frontendRouter) (
 	}
 
 	return personalInformationPanelDataModel{
-		Name:  user.Name,
-		Email: user.Email,
+		Name:         user.Name,
+		Email:        user.Email,
+		PendingEmail: user.PendingEmail,
+	}, nil
+}
+
+func twoFactorPanelDataProvider(ctx *gin.Context, r *frontendRouter) (interface{}, error) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get user id from token")
+	}
+
+	user, err := r.userService.GetUserWithID(ctx, userId.Hex())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not fetch user %s", userId.Hex()))
+	}
+
+	return twoFactorPanelDataModel{
+		Enabled:                user.TwoFactorEnabled,
+		Required:               r.twoFactorService.IsRequiredForRole(user.Role),
+		RecoveryCodesRemaining: len(user.TwoFactorRecoveryCodes),
+	}, nil
+}
+
+func passkeyPanelDataProvider(ctx *gin.Context, r *frontendRouter) (interface{}, error) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get user id from token")
+	}
+
+	passkeys, err := r.webAuthnService.GetCredentialsForUser(ctx, userId.Hex())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not fetch passkeys for user %s", userId.Hex()))
+	}
+
+	return passkeyPanelDataModel{
+		Passkeys: passkeys,
+	}, nil
+}
+
+func linkedAccountsPanelDataProvider(ctx *gin.Context, r *frontendRouter) (interface{}, error) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get user id from token")
+	}
+
+	identities, err := r.externalIdentityService.GetIdentitiesForUser(ctx, userId.Hex())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not fetch linked accounts for user %s", userId.Hex()))
+	}
+
+	accounts := []linkedAccount{}
+	for _, provider := range r.externalIdentityService.GetProviders() {
+		account := linkedAccount{
+			Provider:    provider.Name(),
+			DisplayName: provider.DisplayName(),
+		}
+		for i := range identities {
+			if identities[i].Provider == provider.Name() {
+				account.Identity = &identities[i]
+			}
+		}
+		accounts = append(accounts, account)
+	}
+
+	return linkedAccountsPanelDataModel{
+		Accounts: accounts,
 	}, nil
 }
 
@@ -75,13 +170,134 @@ func teamPanelDataProvider(ctx *gin.Context, r *frontendRouter) (interface{}, er
 		return nil, errors.Wrap(err, fmt.Sprintf("could not fetch teammates for user %s", userId.Hex()))
 	}
 
+	isCreator := team != nil && team.Creator == userId
+
+	var membersLimit int
+	if team != nil {
+		membersLimit = r.cfg.Teams.MaxMembers
+		if team.MaxMembers > 0 {
+			membersLimit = team.MaxMembers
+		}
+	}
+
+	var joinRequests []teamJoinRequest
+	if isCreator {
+		requests, err := r.joinRequestService.GetJoinRequestsForTeamWithID(ctx, team.ID.Hex())
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("could not fetch join requests for team %s", team.ID.Hex()))
+		}
+
+		for _, request := range requests {
+			user, err := r.userService.GetUserWithID(ctx, request.User.Hex())
+			if err == services.ErrNotFound {
+				continue
+			} else if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("could not fetch user %s", request.User.Hex()))
+			}
+
+			joinRequests = append(joinRequests, teamJoinRequest{
+				ID:        request.ID,
+				User:      *user,
+				CreatedAt: request.CreatedAt,
+			})
+		}
+	}
+
+	var requestedTeams []entities.Team
+	if team == nil {
+		requests, err := r.joinRequestService.GetJoinRequestsForUserWithID(ctx, userId.Hex())
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("could not fetch join requests for user %s", userId.Hex()))
+		}
+
+		for _, request := range requests {
+			requestedTeam, err := r.teamService.GetTeamWithID(ctx, request.Team.Hex())
+			if err == services.ErrNotFound {
+				continue
+			} else if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("could not fetch team %s", request.Team.Hex()))
+			}
+
+			requestedTeams = append(requestedTeams, *requestedTeam)
+		}
+	}
+
 	return teamPanelDataModel{
 		Team:                 team,
 		Teammates:            teammates,
 		TeamMembersSoftLimit: r.cfg.TeamMembersSoftLimit,
+		TeamMembersLimit:     membersLimit,
+		IsCreator:            isCreator,
+		JoinRequests:         joinRequests,
+		RequestedTeams:       requestedTeams,
+		Tracks:               r.cfg.Teams.Tracks,
 	}, nil
 }
 
+func matchmakingPanelDataProvider(ctx *gin.Context, r *frontendRouter) (interface{}, error) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get user id from token")
+	}
+
+	team, err := r.teamService.GetTeamForUserWithID(ctx, userId.Hex())
+	if err != nil && err != services.ErrNotFound {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not fetch team for user %s", userId.Hex()))
+	}
+
+	if team == nil {
+		profile, err := r.matchmakingService.GetProfileForUserWithID(ctx, userId.Hex())
+		if err == services.ErrNotFound {
+			return matchmakingPanelDataModel{}, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("could not fetch matchmaking profile for user %s", userId.Hex()))
+		}
+
+		matches, err := r.matchmakingService.GetTeamMatchesForUserWithID(ctx, userId.Hex())
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("could not fetch team matches for user %s", userId.Hex()))
+		}
+
+		return matchmakingPanelDataModel{
+			Profile:     profile,
+			TeamMatches: matches,
+		}, nil
+	}
+
+	if team.Creator != userId {
+		return matchmakingPanelDataModel{
+			InTeam: true,
+		}, nil
+	}
+
+	membersLimit := r.cfg.Teams.MaxMembers
+	if team.MaxMembers > 0 {
+		membersLimit = team.MaxMembers
+	}
+
+	model := matchmakingPanelDataModel{
+		InTeam:    true,
+		IsCreator: true,
+		TeamFull:  membersLimit > 0 && team.MemberCount >= membersLimit,
+	}
+
+	advert, err := r.matchmakingService.GetAdvertForTeamWithID(ctx, team.ID.Hex())
+	if err == services.ErrNotFound {
+		return model, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not fetch advert for team %s", team.ID.Hex()))
+	}
+
+	matches, err := r.matchmakingService.GetProfileMatchesForTeamWithID(ctx, team.ID.Hex())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not fetch profile matches for team %s", team.ID.Hex()))
+	}
+
+	model.Advert = advert
+	model.ProfileMatches = matches
+	return model, nil
+}
+
 func usersListPanelDataProvider(ctx *gin.Context, r *frontendRouter) (interface{}, error) {
 	users, err := r.userService.GetUsers(ctx)
 	if err != nil {
@@ -93,6 +309,92 @@ func usersListPanelDataProvider(ctx *gin.Context, r *frontendRouter) (interface{
 	}, nil
 }
 
+func usersDashboardPanelDataProvider(ctx *gin.Context, r *frontendRouter) (interface{}, error) {
+	users, err := r.userService.GetUsers(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch users")
+	}
+
+	teams, err := r.teamService.GetTeams(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch teams")
+	}
+
+	teamNames := map[primitive.ObjectID]string{}
+	for _, team := range teams {
+		teamNames[team.ID] = team.Name
+	}
+
+	filter := getUsersDashboardFilter(ctx)
+	matchingUsers := []usersDashboardUser{}
+	for _, user := range users {
+		if !filter.matches(user) {
+			continue
+		}
+
+		permissions := make([]string, 0, len(user.SpecialPermissions))
+		for _, uri := range user.SpecialPermissions {
+			marshalledURI, _ := uri.MarshalJSON()
+			// MarshalJSON en-quotes the marshalled URI, so we unquote it here
+			unquotedURI, _ := strconv.Unquote(string(marshalledURI))
+			permissions = append(permissions, unquotedURI)
+		}
+
+		matchingUsers = append(matchingUsers, usersDashboardUser{
+			User:        user,
+			TeamName:    teamNames[user.Team],
+			Verified:    user.Role != role.Unverified,
+			Permissions: strings.Join(permissions, "\n"),
+		})
+	}
+
+	return usersDashboardPanelDataModel{
+		Users:  matchingUsers,
+		Teams:  teams,
+		Roles:  role.KnownRoles(),
+		Filter: filter,
+	}, nil
+}
+
+// getUsersDashboardFilter reads the users dashboard's filters from the request's query or form parameters
+func getUsersDashboardFilter(ctx *gin.Context) usersDashboardFilter {
+	return usersDashboardFilter{
+		Query:    strings.TrimSpace(ctx.Request.FormValue("q")),
+		Role:     ctx.Request.FormValue("filter_role"),
+		Team:     ctx.Request.FormValue("filter_team"),
+		Verified: ctx.Request.FormValue("filter_verified"),
+	}
+}
+
+// matches checks whether the user passes all of the filters.
+// Team can be a team id or "none" for users without a team, Verified can be "true" or "false"
+func (f usersDashboardFilter) matches(user entities.User) bool {
+	if len(f.Query) > 0 {
+		query := strings.ToLower(f.Query)
+		if !strings.Contains(strings.ToLower(user.Name), query) && !strings.Contains(strings.ToLower(user.Email), query) {
+			return false
+		}
+	}
+
+	if len(f.Role) > 0 && string(user.Role) != f.Role {
+		return false
+	}
+
+	if f.Team == "none" {
+		if user.Team != primitive.NilObjectID {
+			return false
+		}
+	} else if len(f.Team) > 0 && user.Team.Hex() != f.Team {
+		return false
+	}
+
+	if len(f.Verified) > 0 && strconv.FormatBool(user.Role != role.Unverified) != f.Verified {
+		return false
+	}
+
+	return true
+}
+
 type frontendComponent struct {
 	name         string
 	dataProvider frontendComponentDataProvider
@@ -3,7 +3,11 @@ package frontend
 import (
 	"errors"
 	"github.com/stretchr/testify/assert"
+	authCommon "github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/config/role"
 	"github.com/unicsmcr/hs_auth/entities"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	"github.com/unicsmcr/hs_auth/services"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
 	"net/http/httptest"
@@ -108,7 +112,251 @@ func Test_personalInformationPanelDataProvider(t *testing.T) {
 	}
 }
 
+func Test_twoFactorPanelDataProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		prep    func(*testSetup)
+		wantErr bool
+		wantRes twoFactorPanelDataModel
+	}{
+		{
+			name: "should return error when authorizer returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error when user service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return correct model",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{
+						Role:                   role.Organiser,
+						TwoFactorEnabled:       true,
+						TwoFactorRecoveryCodes: []string{"code1", "code2"},
+					}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Organiser).Return(true).Times(1)
+			},
+			wantRes: twoFactorPanelDataModel{
+				Enabled:                true,
+				Required:               true,
+				RecoveryCodesRemaining: 2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			attachAuthCookie(setup.testCtx)
+
+			dataModel, err := twoFactorPanelDataProvider(setup.testCtx, &setup.router)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if !reflect.DeepEqual(tt.wantRes, twoFactorPanelDataModel{}) {
+				assert.IsType(t, twoFactorPanelDataModel{}, dataModel)
+				assert.Equal(t, tt.wantRes, dataModel.(twoFactorPanelDataModel))
+			}
+		})
+	}
+}
+
+func Test_passkeyPanelDataProvider(t *testing.T) {
+	testPasskeys := []entities.WebAuthnCredential{
+		{
+			ID:     primitive.NewObjectID(),
+			UserID: testUserId,
+			Name:   "My key",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		prep    func(*testSetup)
+		wantErr bool
+		wantRes passkeyPanelDataModel
+	}{
+		{
+			name: "should return error when authorizer returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error when webauthn service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().GetCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return correct model",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().GetCredentialsForUser(setup.testCtx, testUserId.Hex()).
+					Return(testPasskeys, nil).Times(1)
+			},
+			wantRes: passkeyPanelDataModel{
+				Passkeys: testPasskeys,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			attachAuthCookie(setup.testCtx)
+
+			dataModel, err := passkeyPanelDataProvider(setup.testCtx, &setup.router)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if !reflect.DeepEqual(tt.wantRes, passkeyPanelDataModel{}) {
+				assert.IsType(t, passkeyPanelDataModel{}, dataModel)
+				assert.Equal(t, tt.wantRes, dataModel.(passkeyPanelDataModel))
+			}
+		})
+	}
+}
+
+func Test_linkedAccountsPanelDataProvider(t *testing.T) {
+	testIdentities := []entities.ExternalIdentity{
+		{
+			ID:         primitive.NewObjectID(),
+			UserID:     testUserId,
+			Provider:   "github",
+			ExternalID: "42",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		prep    func(*testSetup)
+		wantErr bool
+		wantRes linkedAccountsPanelDataModel
+	}{
+		{
+			name: "should return error when authorizer returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error when external identity service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockEIService.EXPECT().GetIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return correct model",
+			prep: func(setup *testSetup) {
+				mockGithub := mock_services.NewMockOAuthProvider(setup.ctrl)
+				mockGithub.EXPECT().Name().Return("github").AnyTimes()
+				mockGithub.EXPECT().DisplayName().Return("GitHub").AnyTimes()
+				mockDiscord := mock_services.NewMockOAuthProvider(setup.ctrl)
+				mockDiscord.EXPECT().Name().Return("discord").AnyTimes()
+				mockDiscord.EXPECT().DisplayName().Return("Discord").AnyTimes()
+
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockEIService.EXPECT().GetIdentitiesForUser(setup.testCtx, testUserId.Hex()).
+					Return(testIdentities, nil).Times(1)
+				setup.mockEIService.EXPECT().GetProviders().
+					Return([]services.OAuthProvider{mockGithub, mockDiscord}).Times(1)
+			},
+			wantRes: linkedAccountsPanelDataModel{
+				Accounts: []linkedAccount{
+					{
+						Provider:    "github",
+						DisplayName: "GitHub",
+						Identity:    &testIdentities[0],
+					},
+					{
+						Provider:    "discord",
+						DisplayName: "Discord",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			attachAuthCookie(setup.testCtx)
+
+			dataModel, err := linkedAccountsPanelDataProvider(setup.testCtx, &setup.router)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if !reflect.DeepEqual(tt.wantRes, linkedAccountsPanelDataModel{}) {
+				assert.IsType(t, linkedAccountsPanelDataModel{}, dataModel)
+				assert.Equal(t, tt.wantRes, dataModel.(linkedAccountsPanelDataModel))
+			}
+		})
+	}
+}
+
 func Test_teamPanelDataProvider(t *testing.T) {
+	testOtherUserId := primitive.NewObjectID()
+	testRequestId := primitive.NewObjectID()
+
 	tests := []struct {
 		name    string
 		prep    func(*testSetup)
@@ -155,11 +403,126 @@ func Test_teamPanelDataProvider(t *testing.T) {
 				setup.mockUService.EXPECT().GetTeammatesForUserWithID(setup.testCtx, testUserId.Hex()).
 					Return([]entities.User{{Name: "Bob the Tester"}}, nil).Times(1)
 				setup.cfg.TeamMembersSoftLimit = 4
+				setup.cfg.Teams.MaxMembers = 6
 			},
 			wantRes: teamPanelDataModel{
 				Team:                 &entities.Team{Name: "Team of Bobs"},
 				Teammates:            []entities.User{{Name: "Bob the Tester"}},
 				TeamMembersSoftLimit: 4,
+				TeamMembersLimit:     6,
+			},
+		},
+		{
+			name: "should return team's own member limit when it is set",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{Name: "Team of Bobs", MaxMembers: 8}, nil).Times(1)
+				setup.mockUService.EXPECT().GetTeammatesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return([]entities.User{{Name: "Bob the Tester"}}, nil).Times(1)
+				setup.cfg.Teams.MaxMembers = 6
+			},
+			wantRes: teamPanelDataModel{
+				Team:             &entities.Team{Name: "Team of Bobs", MaxMembers: 8},
+				Teammates:        []entities.User{{Name: "Bob the Tester"}},
+				TeamMembersLimit: 8,
+			},
+		},
+		{
+			name: "should return expected model when user is team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Name: "Team of Bobs", Creator: testUserId, JoinCode: "ABCD2345"}, nil).Times(1)
+				setup.mockUService.EXPECT().GetTeammatesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return([]entities.User{{Name: "Bob the Tester"}}, nil).Times(1)
+				setup.mockJRService.EXPECT().GetJoinRequestsForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return([]entities.TeamJoinRequest{}, nil).Times(1)
+				setup.cfg.TeamMembersSoftLimit = 4
+			},
+			wantRes: teamPanelDataModel{
+				Team:                 &entities.Team{ID: testTeamId, Name: "Team of Bobs", Creator: testUserId, JoinCode: "ABCD2345"},
+				Teammates:            []entities.User{{Name: "Bob the Tester"}},
+				TeamMembersSoftLimit: 4,
+				IsCreator:            true,
+			},
+		},
+		{
+			name: "should return error when join request service returns error for team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockUService.EXPECT().GetTeammatesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return([]entities.User{}, nil).Times(1)
+				setup.mockJRService.EXPECT().GetJoinRequestsForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return team's join requests when user is team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockUService.EXPECT().GetTeammatesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return([]entities.User{}, nil).Times(1)
+				setup.mockJRService.EXPECT().GetJoinRequestsForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return([]entities.TeamJoinRequest{
+						{ID: testRequestId, User: testOtherUserId},
+						{ID: primitive.NewObjectID(), User: primitive.NilObjectID},
+					}, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testOtherUserId.Hex()).
+					Return(&entities.User{ID: testOtherUserId, Name: "Rob the Tester"}, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, primitive.NilObjectID.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantRes: teamPanelDataModel{
+				Team:      &entities.Team{ID: testTeamId, Creator: testUserId},
+				Teammates: []entities.User{},
+				IsCreator: true,
+				JoinRequests: []teamJoinRequest{
+					{ID: testRequestId, User: entities.User{ID: testOtherUserId, Name: "Rob the Tester"}},
+				},
+			},
+		},
+		{
+			name: "should return error when join request service returns error for user without team",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockUService.EXPECT().GetTeammatesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrUserNotInTeam).Times(1)
+				setup.mockJRService.EXPECT().GetJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return requested teams when user is not in a team",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockUService.EXPECT().GetTeammatesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrUserNotInTeam).Times(1)
+				setup.mockJRService.EXPECT().GetJoinRequestsForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return([]entities.TeamJoinRequest{{ID: testRequestId, Team: testTeamId}}, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Name: "Team of Bobs"}, nil).Times(1)
+				setup.cfg.TeamMembersSoftLimit = 4
+			},
+			wantRes: teamPanelDataModel{
+				TeamMembersSoftLimit: 4,
+				RequestedTeams:       []entities.Team{{ID: testTeamId, Name: "Team of Bobs"}},
 			},
 		},
 	}
@@ -190,6 +553,141 @@ func Test_teamPanelDataProvider(t *testing.T) {
 		})
 	}
 }
+func Test_matchmakingPanelDataProvider(t *testing.T) {
+	testProfile := &entities.MatchmakingProfile{User: testUserId, Skills: []string{"go"}}
+	testAdvert := &entities.TeamAdvert{Team: testTeamId, Skills: []string{"go"}}
+
+	tests := []struct {
+		name    string
+		prep    func(*testSetup)
+		wantErr bool
+		wantRes matchmakingPanelDataModel
+	}{
+		{
+			name: "should return error when authorizer returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error when team service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error when matchmaking service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return profile and team matches when user is not in a team",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockMMService.EXPECT().GetProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(testProfile, nil).Times(1)
+				setup.mockMMService.EXPECT().GetTeamMatchesForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return([]entities.TeamMatch{{Advert: *testAdvert, TeamName: "Team of Bobs"}}, nil).Times(1)
+			},
+			wantRes: matchmakingPanelDataModel{
+				Profile:     testProfile,
+				TeamMatches: []entities.TeamMatch{{Advert: *testAdvert, TeamName: "Team of Bobs"}},
+			},
+		},
+		{
+			name: "should return InTeam when user is in a team they did not create",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantRes: matchmakingPanelDataModel{
+				InTeam: true,
+			},
+		},
+		{
+			name: "should return TeamFull when creator's team has reached its member limit",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId, MemberCount: 4}, nil).Times(1)
+				setup.mockMMService.EXPECT().GetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.cfg.Teams.MaxMembers = 4
+			},
+			wantRes: matchmakingPanelDataModel{
+				InTeam:    true,
+				IsCreator: true,
+				TeamFull:  true,
+			},
+		},
+		{
+			name: "should return advert and profile matches when user created their team",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId, MemberCount: 1}, nil).Times(1)
+				setup.mockMMService.EXPECT().GetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(testAdvert, nil).Times(1)
+				setup.mockMMService.EXPECT().GetProfileMatchesForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return([]entities.ProfileMatch{{Profile: *testProfile, UserName: "Bob the Tester"}}, nil).Times(1)
+				setup.cfg.Teams.MaxMembers = 4
+			},
+			wantRes: matchmakingPanelDataModel{
+				InTeam:         true,
+				IsCreator:      true,
+				Advert:         testAdvert,
+				ProfileMatches: []entities.ProfileMatch{{Profile: *testProfile, UserName: "Bob the Tester"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			attachAuthCookie(setup.testCtx)
+
+			dataModel, err := matchmakingPanelDataProvider(setup.testCtx, &setup.router)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if !reflect.DeepEqual(tt.wantRes, matchmakingPanelDataModel{}) {
+				assert.IsType(t, matchmakingPanelDataModel{}, dataModel)
+				assert.Equal(t, tt.wantRes, dataModel.(matchmakingPanelDataModel))
+			}
+		})
+	}
+}
+
 func Test_usersListPanelDataProvider(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -244,10 +742,162 @@ func Test_usersListPanelDataProvider(t *testing.T) {
 	}
 }
 
+func Test_usersDashboardPanelDataProvider(t *testing.T) {
+	testTeam := entities.Team{ID: primitive.NewObjectID(), Name: "Testers"}
+	uri, err := authCommon.NewURIFromString("hs:hs_auth:api:v2:GetUsers")
+	assert.NoError(t, err)
+	verifiedUser := entities.User{
+		ID:                 primitive.NewObjectID(),
+		Name:               "Bob the Tester",
+		Email:              "bob@tester.com",
+		Role:               role.Applicant,
+		Team:               testTeam.ID,
+		SpecialPermissions: authCommon.UniformResourceIdentifiers{uri},
+	}
+	unverifiedUser := entities.User{
+		ID:    primitive.NewObjectID(),
+		Name:  "Rob the Tester",
+		Email: "rob@tester.com",
+		Role:  role.Unverified,
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		prep    func(*testSetup)
+		wantErr bool
+		wantRes usersDashboardPanelDataModel
+	}{
+		{
+			name: "should return error when user service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUsers(setup.testCtx).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error when team service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUsers(setup.testCtx).
+					Return([]entities.User{verifiedUser, unverifiedUser}, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeams(setup.testCtx).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return all users when no filters are set",
+			wantRes: usersDashboardPanelDataModel{
+				Users: []usersDashboardUser{
+					{User: verifiedUser, TeamName: "Testers", Verified: true, Permissions: "hs:hs_auth:api:v2:GetUsers"},
+					{User: unverifiedUser, Verified: false, Permissions: ""},
+				},
+				Teams: []entities.Team{testTeam},
+				Roles: role.KnownRoles(),
+			},
+		},
+		{
+			name:  "should search users by name and email",
+			query: "q=ROB@",
+			wantRes: usersDashboardPanelDataModel{
+				Users: []usersDashboardUser{
+					{User: unverifiedUser, Verified: false, Permissions: ""},
+				},
+				Teams:  []entities.Team{testTeam},
+				Roles:  role.KnownRoles(),
+				Filter: usersDashboardFilter{Query: "ROB@"},
+			},
+		},
+		{
+			name:  "should filter users by role",
+			query: "filter_role=applicant",
+			wantRes: usersDashboardPanelDataModel{
+				Users: []usersDashboardUser{
+					{User: verifiedUser, TeamName: "Testers", Verified: true, Permissions: "hs:hs_auth:api:v2:GetUsers"},
+				},
+				Teams:  []entities.Team{testTeam},
+				Roles:  role.KnownRoles(),
+				Filter: usersDashboardFilter{Role: "applicant"},
+			},
+		},
+		{
+			name:  "should filter users without a team",
+			query: "filter_team=none",
+			wantRes: usersDashboardPanelDataModel{
+				Users: []usersDashboardUser{
+					{User: unverifiedUser, Verified: false, Permissions: ""},
+				},
+				Teams:  []entities.Team{testTeam},
+				Roles:  role.KnownRoles(),
+				Filter: usersDashboardFilter{Team: "none"},
+			},
+		},
+		{
+			name:  "should filter users by team",
+			query: "filter_team=" + testTeam.ID.Hex(),
+			wantRes: usersDashboardPanelDataModel{
+				Users: []usersDashboardUser{
+					{User: verifiedUser, TeamName: "Testers", Verified: true, Permissions: "hs:hs_auth:api:v2:GetUsers"},
+				},
+				Teams:  []entities.Team{testTeam},
+				Roles:  role.KnownRoles(),
+				Filter: usersDashboardFilter{Team: testTeam.ID.Hex()},
+			},
+		},
+		{
+			name:  "should filter users by verification state",
+			query: "filter_verified=false",
+			wantRes: usersDashboardPanelDataModel{
+				Users: []usersDashboardUser{
+					{User: unverifiedUser, Verified: false, Permissions: ""},
+				},
+				Teams:  []entities.Team{testTeam},
+				Roles:  role.KnownRoles(),
+				Filter: usersDashboardFilter{Verified: "false"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			} else {
+				setup.mockUService.EXPECT().GetUsers(setup.testCtx).
+					Return([]entities.User{verifiedUser, unverifiedUser}, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeams(setup.testCtx).
+					Return([]entities.Team{testTeam}, nil).Times(1)
+			}
+
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/users?"+tt.query, nil)
+			attachAuthCookie(setup.testCtx)
+
+			dataModel, err := usersDashboardPanelDataProvider(setup.testCtx, &setup.router)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.IsType(t, usersDashboardPanelDataModel{}, dataModel)
+				assert.Equal(t, tt.wantRes, dataModel.(usersDashboardPanelDataModel))
+			}
+		})
+	}
+}
+
 func Test_components_have_correct_names(t *testing.T) {
 	// REMINDER: if you have to update any values here, you will most likely have to update the user permissions as well
 	assert.Equal(t, "Default:Navbar", navbar.name)
 	assert.Equal(t, "Default:PersonalInformationPanel", personalInformationPanel.name)
+	assert.Equal(t, "Default:TwoFactorPanel", twoFactorPanel.name)
+	assert.Equal(t, "Default:PasskeyPanel", passkeyPanel.name)
+	assert.Equal(t, "Default:LinkedAccountsPanel", linkedAccountsPanel.name)
 	assert.Equal(t, "TeamPanel", teamPanel.name)
 	assert.Equal(t, "UsersListPanel", usersListPanel.name)
+	assert.Equal(t, "UsersDashboardPanel", usersDashboardPanel.name)
+	assert.Equal(t, "MatchmakingPanel", matchmakingPanel.name)
 }
@@ -13,11 +13,19 @@ var (
 		frontendComponents{
 			teamPanel,
 			personalInformationPanel,
+			twoFactorPanel,
+			passkeyPanel,
+			linkedAccountsPanel,
 			usersListPanel,
 			navbar,
 		})
 
-	loginPage, _ = newFrontendPage("LoginPage", "login.gohtml", nil)
+	loginPage, _         = newFrontendPage("LoginPage", "login.gohtml", nil)
+	loginLinkSentPage, _ = newFrontendPage("LoginLinkSentPage", "loginLinkSent.gohtml", nil)
+
+	twoFactorLoginPage, _         = newFrontendPage("TwoFactorLoginPage", "twoFactorLogin.gohtml", nil)
+	twoFactorSetupPage, _         = newFrontendPage("TwoFactorSetupPage", "twoFactorSetup.gohtml", nil)
+	twoFactorRecoveryCodesPage, _ = newFrontendPage("TwoFactorRecoveryCodesPage", "twoFactorRecoveryCodes.gohtml", nil)
 
 	registerPage, _    = newFrontendPage("RegisterPage", "register.gohtml", nil)
 	registerEndPage, _ = newFrontendPage("RegisterEndPage", "registerEnd.gohtml", nil)
@@ -33,8 +41,50 @@ var (
 	emailUnverifiedPage, _   = newFrontendPage("EmailUnverifiedPage", "emailNotVerified.gohtml", frontendComponents{
 		navbar,
 	})
+	emailChangedPage, _ = newFrontendPage("EmailChangedPage", "emailChanged.gohtml", nil)
+
+	matchmakingPage, _ = newFrontendPage("MatchmakingPage", "matchmaking.gohtml", frontendComponents{
+		matchmakingPanel,
+		navbar,
+	})
+
+	usersDashboardPage, _ = newFrontendPage("UsersDashboardPage", "usersDashboard.gohtml", frontendComponents{
+		usersDashboardPanel,
+		navbar,
+	})
+
+	frontendPages = []frontendPage{
+		profilePage,
+		loginPage,
+		loginLinkSentPage,
+		twoFactorLoginPage,
+		twoFactorSetupPage,
+		twoFactorRecoveryCodesPage,
+		registerPage,
+		registerEndPage,
+		forgotPasswordPage,
+		forgotPasswordEndPage,
+		resetPasswordPage,
+		resetPasswordEndPage,
+		verifyEmailPage,
+		verifyEmailResendPage,
+		emailUnverifiedPage,
+		emailChangedPage,
+		matchmakingPage,
+		usersDashboardPage,
+	}
 )
 
+// ComponentURIs returns the URIs of the components on all of the frontend's pages
+func ComponentURIs() authCommon.UniformResourceIdentifiers {
+	var uris authCommon.UniformResourceIdentifiers
+	for _, page := range frontendPages {
+		uris = append(uris, page.componentURIs...)
+	}
+
+	return uris
+}
+
 func newFrontendPage(pageName, templatePath string, components frontendComponents) (frontendPage, error) {
 	var componentURIs = make(authCommon.UniformResourceIdentifiers, 0, len(components))
 	for _, component := range components {
@@ -56,16 +56,28 @@ func Test_getComponentsWithURIs_returns_correct_components(t *testing.T) {
 }
 
 func Test_pages_contain_correct_components(t *testing.T) {
-	assert.Len(t, profilePage.components, 4)
+	assert.Len(t, profilePage.components, 7)
 	assert.True(t, containsComponent(profilePage, teamPanel))
 	assert.True(t, containsComponent(profilePage, personalInformationPanel))
+	assert.True(t, containsComponent(profilePage, twoFactorPanel))
+	assert.True(t, containsComponent(profilePage, passkeyPanel))
+	assert.True(t, containsComponent(profilePage, linkedAccountsPanel))
 	assert.True(t, containsComponent(profilePage, usersListPanel))
 	assert.True(t, containsComponent(profilePage, navbar))
+
+	assert.Len(t, usersDashboardPage.components, 2)
+	assert.True(t, containsComponent(usersDashboardPage, usersDashboardPanel))
+	assert.True(t, containsComponent(usersDashboardPage, navbar))
+
+	assert.Len(t, matchmakingPage.components, 2)
+	assert.True(t, containsComponent(matchmakingPage, matchmakingPanel))
+	assert.True(t, containsComponent(matchmakingPage, navbar))
 }
 
 func Test_pages_use_correct_templates(t *testing.T) {
 	assert.Equal(t, "profile.gohtml", profilePage.templateName)
 	assert.Equal(t, "login.gohtml", loginPage.templateName)
+	assert.Equal(t, "loginLinkSent.gohtml", loginLinkSentPage.templateName)
 	assert.Equal(t, "register.gohtml", registerPage.templateName)
 	assert.Equal(t, "registerEnd.gohtml", registerEndPage.templateName)
 	assert.Equal(t, "forgotPassword.gohtml", forgotPasswordPage.templateName)
@@ -75,12 +87,16 @@ func Test_pages_use_correct_templates(t *testing.T) {
 	assert.Equal(t, "verifyEmail.gohtml", verifyEmailPage.templateName)
 	assert.Equal(t, "emailVerifyResend.gohtml", verifyEmailResendPage.templateName)
 	assert.Equal(t, "emailNotVerified.gohtml", emailUnverifiedPage.templateName)
+	assert.Equal(t, "emailChanged.gohtml", emailChangedPage.templateName)
+	assert.Equal(t, "usersDashboard.gohtml", usersDashboardPage.templateName)
+	assert.Equal(t, "matchmaking.gohtml", matchmakingPage.templateName)
 }
 
 func Test_pages_have_correct_names(t *testing.T) {
 	// REMINDER: if you have to update any values here, you will most likely have to update the user permissions as well
 	assert.Equal(t, "ProfilePage", profilePage.name)
 	assert.Equal(t, "LoginPage", loginPage.name)
+	assert.Equal(t, "LoginLinkSentPage", loginLinkSentPage.name)
 	assert.Equal(t, "RegisterPage", registerPage.name)
 	assert.Equal(t, "RegisterEndPage", registerEndPage.name)
 	assert.Equal(t, "ForgotPasswordPage", forgotPasswordPage.name)
@@ -90,6 +106,9 @@ func Test_pages_have_correct_names(t *testing.T) {
 	assert.Equal(t, "VerifyEmailPage", verifyEmailPage.name)
 	assert.Equal(t, "VerifyEmailResendPage", verifyEmailResendPage.name)
 	assert.Equal(t, "EmailUnverifiedPage", emailUnverifiedPage.name)
+	assert.Equal(t, "EmailChangedPage", emailChangedPage.name)
+	assert.Equal(t, "UsersDashboardPage", usersDashboardPage.name)
+	assert.Equal(t, "MatchmakingPage", matchmakingPage.name)
 }
 
 func containsComponent(page frontendPage, component frontendComponent) bool {
@@ -100,3 +119,15 @@ func containsComponent(page frontendPage, component frontendComponent) bool {
 	}
 	return false
 }
+
+func Test_ComponentURIs__should_return_component_uris_of_all_pages(t *testing.T) {
+	uris := ComponentURIs()
+
+	for _, page := range frontendPages {
+		for _, uri := range page.componentURIs {
+			assert.Contains(t, uris, uri)
+		}
+	}
+	assert.Len(t, uris, len(profilePage.componentURIs)+len(emailUnverifiedPage.componentURIs)+
+		len(usersDashboardPage.componentURIs)+len(matchmakingPage.componentURIs))
+}
@@ -33,22 +33,65 @@ type Router interface {
 	EmailUnverifiedPage(*gin.Context)
 	CreateTeam(*gin.Context)
 	JoinTeam(*gin.Context)
+	RegenerateTeamJoinCode(*gin.Context)
+	SetTeamPrivacy(*gin.Context)
+	ApproveTeamJoinRequest(*gin.Context)
+	RejectTeamJoinRequest(*gin.Context)
+	RenameTeam(*gin.Context)
+	UpdateTeamDetails(*gin.Context)
+	RemoveTeamMember(*gin.Context)
+	SetTeamCreator(*gin.Context)
+	DisbandTeam(*gin.Context)
+	MatchmakingPage(*gin.Context)
+	SetMatchmakingProfile(*gin.Context)
+	DeleteMatchmakingProfile(*gin.Context)
+	AcceptTeamMatch(*gin.Context)
+	SetTeamAdvert(*gin.Context)
+	DeleteTeamAdvert(*gin.Context)
 	LeaveTeam(*gin.Context)
 	UpdateUser(*gin.Context)
 	ProfilePage(*gin.Context)
 	RedirectToEntryPage(*gin.Context)
+	LoginTwoFactor(*gin.Context)
+	TwoFactorSetupPage(*gin.Context)
+	EnableTwoFactor(*gin.Context)
+	DisableTwoFactor(*gin.Context)
+	BeginPasskeyLogin(*gin.Context)
+	LoginWithPasskey(*gin.Context)
+	BeginPasskeyRegistration(*gin.Context)
+	RegisterPasskey(*gin.Context)
+	DeletePasskey(*gin.Context)
+	SendLoginLink(*gin.Context)
+	LoginWithLink(*gin.Context)
+	LoginWithOAuth(*gin.Context)
+	LinkOAuthAccount(*gin.Context)
+	OAuthCallback(*gin.Context)
+	UnlinkOAuthAccount(*gin.Context)
+	UsersDashboardPage(*gin.Context)
+	SetUserRole(*gin.Context)
+	SetUserSpecialPermissions(*gin.Context)
+	SendUserPasswordReset(*gin.Context)
+	ResendUserEmailVerification(*gin.Context)
 }
 
 type frontendRouter struct {
 	models.BaseRouter
-	logger         *zap.Logger
-	cfg            *config.AppConfig
-	env            *environment.Env
-	userService    services.UserService
-	teamService    services.TeamService
-	emailServiceV2 services.EmailServiceV2
-	authorizer     authV2.Authorizer
-	timeProvider   utils.TimeProvider
+	logger                  *zap.Logger
+	cfg                     *config.AppConfig
+	env                     *environment.Env
+	userService             services.UserService
+	teamService             services.TeamService
+	emailServiceV2          services.EmailServiceV2
+	auditService            services.AuditService
+	loginAttemptService     services.LoginAttemptService
+	twoFactorService        services.TwoFactorService
+	webAuthnService         services.WebAuthnService
+	externalIdentityService services.ExternalIdentityService
+	invitationService       services.InvitationService
+	joinRequestService      services.TeamJoinRequestService
+	matchmakingService      services.MatchmakingService
+	authorizer              authV2.Authorizer
+	timeProvider            utils.TimeProvider
 }
 
 func (r *frontendRouter) GetResourcePath() string {
@@ -73,21 +116,33 @@ func (r *frontendRouter) HandleUnauthorized(ctx *gin.Context) {
 
 func NewRouter(logger *zap.Logger, cfg *config.AppConfig, env *environment.Env, userService services.UserService,
 	teamService services.TeamService, authorizer authV2.Authorizer,
-	timeProvider utils.TimeProvider, emailServiceV2 services.EmailServiceV2) Router {
+	timeProvider utils.TimeProvider, emailServiceV2 services.EmailServiceV2, auditService services.AuditService,
+	loginAttemptService services.LoginAttemptService, twoFactorService services.TwoFactorService,
+	webAuthnService services.WebAuthnService, externalIdentityService services.ExternalIdentityService,
+	invitationService services.InvitationService, joinRequestService services.TeamJoinRequestService,
+	matchmakingService services.MatchmakingService) Router {
 	return &frontendRouter{
-		logger:         logger,
-		cfg:            cfg,
-		env:            env,
-		userService:    userService,
-		teamService:    teamService,
-		authorizer:     authorizer,
-		timeProvider:   timeProvider,
-		emailServiceV2: emailServiceV2,
+		logger:                  logger,
+		cfg:                     cfg,
+		env:                     env,
+		userService:             userService,
+		teamService:             teamService,
+		authorizer:              authorizer,
+		timeProvider:            timeProvider,
+		emailServiceV2:          emailServiceV2,
+		auditService:            auditService,
+		loginAttemptService:     loginAttemptService,
+		twoFactorService:        twoFactorService,
+		webAuthnService:         webAuthnService,
+		externalIdentityService: externalIdentityService,
+		invitationService:       invitationService,
+		joinRequestService:      joinRequestService,
+		matchmakingService:      matchmakingService,
 	}
 }
 
 func (r *frontendRouter) RegisterRoutes(routerGroup *gin.RouterGroup) {
-	emailVerificationRouter := emailVerificationRouter{
+	emailLinkRouter := emailLinkRouter{
 		frontendRouter: *r,
 	}
 
@@ -95,6 +150,13 @@ func (r *frontendRouter) RegisterRoutes(routerGroup *gin.RouterGroup) {
 	routerGroup.GET("/profile", r.authorizer.WithAuthMiddleware(r, r.ProfilePage))
 	routerGroup.GET("login", r.LoginPage)
 	routerGroup.POST("login", r.Login)
+	routerGroup.POST("login/2fa", r.authorizer.WithAuthMiddleware(r, r.LoginTwoFactor))
+	routerGroup.POST("login/passkey", r.LoginWithPasskey)
+	routerGroup.POST("login/link", r.SendLoginLink)
+	routerGroup.GET("login/link", r.authorizer.WithAuthMiddleware(&emailLinkRouter, r.LoginWithLink))
+	routerGroup.POST("webauthn/login/begin", r.BeginPasskeyLogin)
+	routerGroup.GET("login/oauth/:provider", r.LoginWithOAuth)
+	routerGroup.GET("login/oauth/:provider/callback", r.OAuthCallback)
 	routerGroup.GET("logout", r.Logout)
 	routerGroup.GET("register", r.RegisterPage)
 	routerGroup.POST("register", r.Register)
@@ -102,13 +164,43 @@ func (r *frontendRouter) RegisterRoutes(routerGroup *gin.RouterGroup) {
 	routerGroup.POST("forgotpwd", r.ForgotPassword)
 	routerGroup.GET("resetpwd", r.ResetPasswordPage)
 	routerGroup.POST("resetpwd", r.authorizer.WithAuthMiddleware(r, r.ResetPassword))
-	routerGroup.GET("verifyemail", r.authorizer.WithAuthMiddleware(&emailVerificationRouter, r.VerifyEmail))
+	routerGroup.GET("verifyemail", r.authorizer.WithAuthMiddleware(&emailLinkRouter, r.VerifyEmail))
 	routerGroup.GET("verifyemail/resend", r.authorizer.WithAuthMiddleware(r, r.VerifyEmailResend))
 	routerGroup.GET("emailunverified", r.authorizer.WithAuthMiddleware(r, r.EmailUnverifiedPage))
 	routerGroup.POST("team/create", r.authorizer.WithAuthMiddleware(r, r.CreateTeam))
 	routerGroup.POST("team/join", r.authorizer.WithAuthMiddleware(r, r.JoinTeam))
+	routerGroup.POST("team/code", r.authorizer.WithAuthMiddleware(r, r.RegenerateTeamJoinCode))
+	routerGroup.POST("team/privacy", r.authorizer.WithAuthMiddleware(r, r.SetTeamPrivacy))
+	routerGroup.POST("team/requests/:id/approve", r.authorizer.WithAuthMiddleware(r, r.ApproveTeamJoinRequest))
+	routerGroup.POST("team/requests/:id/reject", r.authorizer.WithAuthMiddleware(r, r.RejectTeamJoinRequest))
+	routerGroup.POST("team/rename", r.authorizer.WithAuthMiddleware(r, r.RenameTeam))
+	routerGroup.POST("team/details", r.authorizer.WithAuthMiddleware(r, r.UpdateTeamDetails))
+	routerGroup.POST("team/members/:id/remove", r.authorizer.WithAuthMiddleware(r, r.RemoveTeamMember))
+	routerGroup.POST("team/members/:id/creator", r.authorizer.WithAuthMiddleware(r, r.SetTeamCreator))
+	routerGroup.POST("team/disband", r.authorizer.WithAuthMiddleware(r, r.DisbandTeam))
 	routerGroup.POST("team/leave", r.authorizer.WithAuthMiddleware(r, r.LeaveTeam))
+	routerGroup.GET("matchmaking", r.authorizer.WithAuthMiddleware(r, r.MatchmakingPage))
+	routerGroup.POST("matchmaking/profile", r.authorizer.WithAuthMiddleware(r, r.SetMatchmakingProfile))
+	routerGroup.POST("matchmaking/profile/delete", r.authorizer.WithAuthMiddleware(r, r.DeleteMatchmakingProfile))
+	routerGroup.POST("matchmaking/matches/:id/accept", r.authorizer.WithAuthMiddleware(r, r.AcceptTeamMatch))
+	routerGroup.POST("matchmaking/advert", r.authorizer.WithAuthMiddleware(r, r.SetTeamAdvert))
+	routerGroup.POST("matchmaking/advert/delete", r.authorizer.WithAuthMiddleware(r, r.DeleteTeamAdvert))
 	routerGroup.POST("user/update/:id", r.authorizer.WithAuthMiddleware(r, r.UpdateUser))
+	routerGroup.POST("email/change", r.authorizer.WithAuthMiddleware(r, r.RequestEmailChange))
+	routerGroup.GET("email/confirm", r.authorizer.WithAuthMiddleware(&emailLinkRouter, r.ConfirmEmailChange))
+	routerGroup.GET("2fa/setup", r.authorizer.WithAuthMiddleware(r, r.TwoFactorSetupPage))
+	routerGroup.POST("2fa/enable", r.authorizer.WithAuthMiddleware(r, r.EnableTwoFactor))
+	routerGroup.POST("2fa/disable", r.authorizer.WithAuthMiddleware(r, r.DisableTwoFactor))
+	routerGroup.POST("webauthn/register/begin", r.authorizer.WithAuthMiddleware(r, r.BeginPasskeyRegistration))
+	routerGroup.POST("webauthn/register/finish", r.authorizer.WithAuthMiddleware(r, r.RegisterPasskey))
+	routerGroup.POST("webauthn/delete", r.authorizer.WithAuthMiddleware(r, r.DeletePasskey))
+	routerGroup.GET("oauth/:provider/link", r.authorizer.WithAuthMiddleware(r, r.LinkOAuthAccount))
+	routerGroup.POST("oauth/unlink", r.authorizer.WithAuthMiddleware(r, r.UnlinkOAuthAccount))
+	routerGroup.GET("users", r.authorizer.WithAuthMiddleware(r, r.UsersDashboardPage))
+	routerGroup.POST("users/:id/role", r.authorizer.WithAuthMiddleware(r, r.SetUserRole))
+	routerGroup.POST("users/:id/permissions", r.authorizer.WithAuthMiddleware(r, r.SetUserSpecialPermissions))
+	routerGroup.POST("users/:id/passwordreset", r.authorizer.WithAuthMiddleware(r, r.SendUserPasswordReset))
+	routerGroup.POST("users/:id/verifyemail/resend", r.authorizer.WithAuthMiddleware(r, r.ResendUserEmailVerification))
 }
 
 func (r *frontendRouter) renderPage(ctx *gin.Context, page frontendPage, statusCode int, pageData interface{}, alertMessage string) {
@@ -151,13 +243,13 @@ func (r *frontendRouter) renderPage(ctx *gin.Context, page frontendPage, statusC
 	})
 }
 
-// RouterResource implementation for email verification.
+// RouterResource implementation for the links sent to users' emails.
 // Modifies frontendRouter's auth token extraction function to deal with the way
-// the token is provided to the VerifyEmail operation
-type emailVerificationRouter struct {
+// the token is provided to the VerifyEmail and LoginWithLink operations
+type emailLinkRouter struct {
 	frontendRouter
 }
 
-func (r *emailVerificationRouter) GetAuthToken(ctx *gin.Context) string {
+func (r *emailLinkRouter) GetAuthToken(ctx *gin.Context) string {
 	return ctx.Query("token")
 }
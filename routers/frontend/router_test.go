@@ -5,6 +5,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	authV2 "github.com/unicsmcr/hs_auth/authorization/v2"
 	authCommon "github.com/unicsmcr/hs_auth/authorization/v2/common"
 	"github.com/unicsmcr/hs_auth/config"
 	"github.com/unicsmcr/hs_auth/environment"
@@ -32,21 +33,29 @@ func Test_RegisterRoutes__should_register_required_routes(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockUserService := mock_services.NewMockUserService(ctrl)
 	mockTeamService := mock_services.NewMockTeamService(ctrl)
+	mockWAService := mock_services.NewMockWebAuthnService(ctrl)
+	mockEIService := mock_services.NewMockExternalIdentityService(ctrl)
 	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
 
 	mockUserService.EXPECT().GetUserWithID(gomock.Any(), gomock.Any()).Return(nil, services.ErrInvalidID).AnyTimes()
 	mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).AnyTimes()
 	mockAuthorizer.EXPECT().GetAuthorizedResources(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockAuthorizer.EXPECT().GetTokenTypeFromToken(gomock.Any()).Return(authV2.TokenType(""), authCommon.ErrInvalidToken).AnyTimes()
+	mockAuthorizer.EXPECT().InvalidateServiceToken(gomock.Any(), gomock.Any()).Return(authCommon.ErrInvalidToken).AnyTimes()
+	mockWAService.EXPECT().BeginLogin(gomock.Any(), "").Return(nil, errors.New("service err")).AnyTimes()
+	mockEIService.EXPECT().GetAuthCodeURL(gomock.Any(), gomock.Any()).Return("https://provider.com/authorize", nil).AnyTimes()
 
 	router := &frontendRouter{
-		logger:      zap.NewNop(),
-		cfg:         &config.AppConfig{Name: "test"},
-		env:         env,
-		userService: mockUserService,
-		teamService: mockTeamService,
-		authorizer:  mockAuthorizer,
+		logger:                  zap.NewNop(),
+		cfg:                     &config.AppConfig{Name: "test"},
+		env:                     env,
+		userService:             mockUserService,
+		teamService:             mockTeamService,
+		webAuthnService:         mockWAService,
+		externalIdentityService: mockEIService,
+		authorizer:              mockAuthorizer,
 	}
-	emailVerificationRouter := &emailVerificationRouter{*router}
+	emailLinkRouter := &emailLinkRouter{*router}
 
 	tests := []struct {
 		route  string
@@ -108,10 +117,158 @@ func Test_RegisterRoutes__should_register_required_routes(t *testing.T) {
 			route:  "/team/join",
 			method: http.MethodPost,
 		},
+		{
+			route:  "/team/code",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/team/privacy",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/team/requests/test/approve",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/team/requests/test/reject",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/team/rename",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/team/details",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/team/members/test/remove",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/team/members/test/creator",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/team/disband",
+			method: http.MethodPost,
+		},
 		{
 			route:  "/team/leave",
 			method: http.MethodPost,
 		},
+		{
+			route:  "/matchmaking",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/matchmaking/profile",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/matchmaking/profile/delete",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/matchmaking/matches/test/accept",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/matchmaking/advert",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/matchmaking/advert/delete",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/login/2fa",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/2fa/setup",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/2fa/enable",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/2fa/disable",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/login/passkey",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/login/link",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/login/link",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/webauthn/login/begin",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/webauthn/register/begin",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/webauthn/register/finish",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/webauthn/delete",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/login/oauth/github",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/login/oauth/github/callback",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/oauth/github/link",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/oauth/unlink",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/email/change",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/email/confirm",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/users",
+			method: http.MethodGet,
+		},
+		{
+			route:  "/users/test/role",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/users/test/permissions",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/users/test/passwordreset",
+			method: http.MethodPost,
+		},
+		{
+			route:  "/users/test/verifyemail/resend",
+			method: http.MethodPost,
+		},
 	}
 
 	for _, tt := range tests {
@@ -122,13 +279,45 @@ func Test_RegisterRoutes__should_register_required_routes(t *testing.T) {
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.ResetPassword)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.ProfilePage)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.Logout)
-			mockAuthMiddlewareCall(emailVerificationRouter, mockAuthorizer, router.VerifyEmail)
+			mockAuthMiddlewareCall(emailLinkRouter, mockAuthorizer, router.LoginWithLink)
+			mockAuthMiddlewareCall(emailLinkRouter, mockAuthorizer, router.VerifyEmail)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.VerifyEmailResend)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.EmailUnverifiedPage)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.CreateTeam)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.JoinTeam)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RegenerateTeamJoinCode)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetTeamPrivacy)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ApproveTeamJoinRequest)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RejectTeamJoinRequest)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RenameTeam)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.UpdateTeamDetails)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RemoveTeamMember)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetTeamCreator)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DisbandTeam)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.MatchmakingPage)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetMatchmakingProfile)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteMatchmakingProfile)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.AcceptTeamMatch)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetTeamAdvert)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeleteTeamAdvert)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.LeaveTeam)
 			mockAuthMiddlewareCall(router, mockAuthorizer, router.UpdateUser)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RequestEmailChange)
+			mockAuthMiddlewareCall(emailLinkRouter, mockAuthorizer, router.ConfirmEmailChange)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.LoginTwoFactor)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.TwoFactorSetupPage)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.EnableTwoFactor)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DisableTwoFactor)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.BeginPasskeyRegistration)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.RegisterPasskey)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.DeletePasskey)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.LinkOAuthAccount)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.UnlinkOAuthAccount)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.UsersDashboardPage)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetUserRole)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SetUserSpecialPermissions)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.SendUserPasswordReset)
+			mockAuthMiddlewareCall(router, mockAuthorizer, router.ResendUserEmailVerification)
 
 			router.RegisterRoutes(&testServer.RouterGroup)
 
@@ -198,18 +387,18 @@ func mockAuthMiddlewareCall(router Router, mockAuthorizer *mock_v2.MockAuthorize
 		})
 }
 
-func TestEmailVerificationRouter_GetAuthToken(t *testing.T) {
+func TestEmailLinkRouter_GetAuthToken(t *testing.T) {
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 	ctx.Request = httptest.NewRequest(http.MethodGet, "/test?token=authToken", nil)
 
-	router := emailVerificationRouter{}
+	router := emailLinkRouter{}
 
 	assert.Equal(t, "authToken", router.GetAuthToken(ctx))
 }
 
 func TestNewRouter__returns_non_nil(t *testing.T) {
-	assert.NotNil(t, NewRouter(nil, nil, nil, nil, nil, nil, nil, nil))
+	assert.NotNil(t, NewRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
 }
 
 func Test_renderPage__omits_component_with_failing_data_provider(t *testing.T) {
@@ -4,17 +4,34 @@ import (
 	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	authV2 "github.com/unicsmcr/hs_auth/authorization/v2"
 	authCommon "github.com/unicsmcr/hs_auth/authorization/v2/common"
 	"github.com/unicsmcr/hs_auth/config/role"
 	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/routers/api/models"
 	"github.com/unicsmcr/hs_auth/routers/common"
 	"github.com/unicsmcr/hs_auth/services"
 	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-const returnToCookie = "ReturnTo"
+const (
+	returnToCookie   = "ReturnTo"
+	oauthStateCookie = "OAuthState"
+	// lifetime of the OAuth state cookie in seconds, i.e. how long the user has to consent with the provider
+	oauthStateLifetime = 600
+	oauthStateBytes    = 32
+)
+
+// purposes of the OAuth flow, stored with the state so that the callback knows what to do with the account
+const (
+	oauthPurposeLogin = "login"
+	oauthPurposeLink  = "link"
+)
 
 func (r *frontendRouter) RedirectToEntryPage(ctx *gin.Context) {
 	if len(r.GetAuthToken(ctx)) != 0 {
@@ -50,11 +67,23 @@ func (r *frontendRouter) Login(ctx *gin.Context) {
 		return
 	}
 
+	if !r.checkLoginAllowed(ctx, req.Email, loginPage, nil) {
+		return
+	}
+
 	user, err := r.userService.GetUserWithEmailAndPwd(ctx, req.Email, req.Password)
 	if err != nil {
 		switch errors.Cause(err) {
 		case services.ErrNotFound:
 			r.logger.Debug("user not found", zap.String("email", req.Email), zap.Error(err))
+			common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+				Action: entities.AuditActionLoginFailed,
+				Target: req.Email,
+			})
+			err = r.loginAttemptService.RecordFailedLogin(ctx, req.Email, ctx.ClientIP())
+			if err != nil {
+				r.logger.Error("could not record failed login", zap.Error(err))
+			}
 			r.renderPage(ctx, loginPage, http.StatusNotFound, nil, "User not found")
 		default:
 			r.logger.Error("could not fetch user", zap.Error(err))
@@ -63,19 +92,80 @@ func (r *frontendRouter) Login(ctx *gin.Context) {
 		return
 	}
 
+	r.completeLogin(ctx, *user)
+}
+
+// completeLogin takes a user who has proven who they are with their password, a login link or a linked account
+// through two-factor authentication, if needed, and logs them in
+func (r *frontendRouter) completeLogin(ctx *gin.Context, user entities.User) {
+	if user.TwoFactorEnabled {
+		r.startTwoFactorLogin(ctx, user)
+		return
+	}
+
+	if r.twoFactorService.IsRequiredForRole(user.Role) {
+		r.startRequiredTwoFactorEnrollment(ctx, user)
+		return
+	}
+
+	if !r.logIn(ctx, user, loginPage, nil) {
+		return
+	}
+
+	ctx.Redirect(http.StatusMovedPermanently, r.getPageAfterLogin(ctx, user))
+}
+
+// checkLoginAllowed renders the given page with an error and returns false if logins
+// for the given email are currently rejected after too many failures
+func (r *frontendRouter) checkLoginAllowed(ctx *gin.Context, email string, page frontendPage, pageData interface{}) bool {
+	retryAfter, err := r.loginAttemptService.CheckLoginAllowed(ctx, email, ctx.ClientIP())
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrLoginThrottled, services.ErrLoginLocked:
+			r.logger.Debug("login attempt rejected", zap.String("email", email), zap.Error(err))
+			common.SetRetryAfterHeader(ctx, retryAfter)
+			r.renderPage(ctx, page, http.StatusTooManyRequests, pageData, "Too many failed login attempts, please try again later")
+		default:
+			r.logger.Error("could not check if login is allowed", zap.Error(err))
+			r.renderPage(ctx, page, http.StatusInternalServerError, pageData, "Something went wrong")
+		}
+		return false
+	}
+
+	return true
+}
+
+// logIn sets the auth cookie to a new token for the given user, who has passed all of the login steps.
+// Renders the given page with an error and returns false if the token cannot be created.
+func (r *frontendRouter) logIn(ctx *gin.Context, user entities.User, page frontendPage, pageData interface{}) bool {
 	token, err := r.authorizer.CreateUserToken(user.ID, r.cfg.Auth.UserTokenLifetime+r.timeProvider.Now().Unix())
 	if err != nil {
 		r.logger.Error("could not create JWT", zap.Error(err))
-		r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
-		return
+		r.renderPage(ctx, page, http.StatusInternalServerError, pageData, "Something went wrong")
+		return false
+	}
+
+	err = r.loginAttemptService.RecordSuccessfulLogin(ctx, user.Email)
+	if err != nil {
+		r.logger.Error("could not clear failed logins", zap.Error(err))
 	}
 
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action:    entities.AuditActionLogin,
+		Actor:     user.ID,
+		ActorType: string(authV2.User),
+		Target:    user.ID.Hex(),
+	})
+
 	ctx.SetCookie(authCookieName, token, int(r.cfg.Auth.UserTokenLifetime), "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
+	return true
+}
 
+// getPageAfterLogin returns where the given user should be sent after logging in and clears the return cookie
+func (r *frontendRouter) getPageAfterLogin(ctx *gin.Context, user entities.User) string {
 	if user.Role == role.Unverified {
-		r.logger.Debug("user's email not verified", zap.String("user id", user.ID.Hex()), zap.String("email", req.Email))
-		ctx.Redirect(http.StatusMovedPermanently, "/emailunverified")
-		return
+		r.logger.Debug("user's email not verified", zap.String("user id", user.ID.Hex()), zap.String("email", user.Email))
+		return "/emailunverified"
 	}
 
 	returnTo, err := ctx.Cookie(returnToCookie)
@@ -83,254 +173,1460 @@ func (r *frontendRouter) Login(ctx *gin.Context) {
 		returnTo = "/"
 	}
 	ctx.SetCookie(returnToCookie, returnTo, -1, "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
-	ctx.Redirect(http.StatusMovedPermanently, returnTo)
+	return returnTo
 }
 
-func (r *frontendRouter) RegisterPage(ctx *gin.Context) {
-	r.renderPage(ctx, registerPage, http.StatusOK, nil, "")
+type twoFactorPageData struct {
+	UserId         string
+	Secret         string
+	URI            string
+	PasskeyOptions *utils.WebAuthnRequestOptions
 }
 
-func (r *frontendRouter) Register(ctx *gin.Context) {
+type passkeyOptionsRes struct {
+	PublicKey interface{} `json:"publicKey"`
+}
+
+type twoFactorRecoveryCodesPageData struct {
+	RecoveryCodes []string
+	ContinueTo    string
+}
+
+// startTwoFactorLogin asks the user, whose password has been checked, for their two-factor code.
+// Until then, the auth cookie holds a service token that only allows the user to enter the code.
+func (r *frontendRouter) startTwoFactorLogin(ctx *gin.Context, user entities.User) {
+	if !r.setTwoFactorChallengeToken(ctx, user, common.MakeTwoFactorLoginURIs(user)) {
+		return
+	}
+
+	pageData := twoFactorPageData{UserId: user.ID.Hex()}
+	options, err := r.webAuthnService.BeginLogin(ctx, user.ID.Hex())
+	if err != nil {
+		// the user can still log in with their two-factor code
+		r.logger.Error("could not begin passkey login", zap.String("user id", user.ID.Hex()), zap.Error(err))
+	} else if len(options.AllowCredentials) > 0 {
+		pageData.PasskeyOptions = options
+	}
+
+	r.renderPage(ctx, twoFactorLoginPage, http.StatusOK, pageData, "")
+}
+
+// startRequiredTwoFactorEnrollment makes the user, whose password has been checked, set up two-factor
+// authentication before they can log in. Until then, the auth cookie holds a service token
+// that only allows the user to confirm the enrollment.
+func (r *frontendRouter) startRequiredTwoFactorEnrollment(ctx *gin.Context, user entities.User) {
+	secret, uri, err := r.twoFactorService.BeginEnrollment(ctx, user.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not begin two-factor enrollment", zap.String("user id", user.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	if !r.setTwoFactorChallengeToken(ctx, user, common.MakeTwoFactorEnrollmentURIs(user)) {
+		return
+	}
+
+	r.renderPage(ctx, twoFactorSetupPage, http.StatusOK, twoFactorPageData{
+		UserId: user.ID.Hex(),
+		Secret: secret,
+		URI:    uri,
+	}, "Your role requires two-factor authentication, please set it up to continue")
+}
+
+func (r *frontendRouter) setTwoFactorChallengeToken(ctx *gin.Context, user entities.User, uris authCommon.UniformResourceIdentifiers) bool {
+	lifetime := r.cfg.Auth.TwoFactorChallengeLifetime
+	token, err := r.authorizer.CreateServiceToken(ctx, user.ID, uris, r.timeProvider.Now().Unix()+lifetime)
+	if err != nil {
+		r.logger.Error("could not create two-factor challenge token", zap.Error(err))
+		r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return false
+	}
+
+	ctx.SetCookie(authCookieName, token, int(lifetime), "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
+	return true
+}
+
+func (r *frontendRouter) LoginTwoFactor(ctx *gin.Context) {
 	var req struct {
-		Name            string `form:"name"`
-		Email           string `form:"email"`
-		Password        string `form:"password"`
-		PasswordConfirm string `form:"passwordConfirm"`
+		UserId     string `form:"userId"`
+		Code       string `form:"code"`
+		Credential string `form:"credential"`
 	}
 	ctx.Bind(&req)
 
-	if len(req.Name) == 0 || len(req.Email) == 0 || len(req.Password) == 0 {
-		r.logger.Debug("one of name, email, password, passwordConfirm not specified", zap.String("name", req.Name), zap.String("email", req.Email), zap.Int("password length", len(req.Password)), zap.Int("passwordConfirm length", len(req.PasswordConfirm)))
-		r.renderPage(ctx, registerPage, http.StatusBadRequest, nil, "All fields are required")
+	pageData := twoFactorPageData{UserId: req.UserId}
+	if len(req.Code) == 0 && len(req.Credential) == 0 {
+		r.logger.Debug("two-factor code not specified")
+		r.renderPage(ctx, twoFactorLoginPage, http.StatusBadRequest, pageData, "Please enter your two-factor code")
 		return
 	}
 
-	// TODO: implement automatic validation at the entity level (https://github.com/unicsmcr/hs_auth/issues/123)
-	if len(req.Password) < 6 || len(req.Password) > 160 {
-		r.logger.Debug("invalid password length", zap.Int("length", len(req.Password)))
-		r.renderPage(ctx, registerPage, http.StatusBadRequest, nil, "Password must contain between 6 and 160 characters")
+	user, err := r.userService.GetUserWithID(ctx, req.UserId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.String("userId", req.UserId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("userId", req.UserId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusNotFound, nil, "User not found")
+		default:
+			r.logger.Error("could not fetch user", zap.String("userId", req.UserId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
 		return
 	}
 
-	if req.Password != req.PasswordConfirm {
-		r.logger.Debug("password and passwordConfirm do not match")
-		r.renderPage(ctx, registerPage, http.StatusBadRequest, nil, "Passwords do not match")
+	if !r.checkLoginAllowed(ctx, user.Email, twoFactorLoginPage, pageData) {
 		return
 	}
 
-	user, err := r.userService.CreateUser(ctx, req.Name, req.Email, req.Password, r.cfg.Auth.DefaultRole)
+	err = r.verifySecondFactor(ctx, req.UserId, req.Code, req.Credential)
 	if err != nil {
-		switch err {
-		case services.ErrEmailTaken:
-			r.logger.Debug("email taken")
-			r.renderPage(ctx, registerPage, http.StatusBadRequest, nil, "Email taken")
-			return
+		switch errors.Cause(err) {
+		case services.ErrInvalidTwoFactorCode, services.ErrInvalidWebAuthnCredential:
+			r.logger.Debug("invalid two-factor code or passkey", zap.String("userId", req.UserId), zap.Error(err))
+			common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+				Action: entities.AuditActionTwoFactorFailed,
+				Target: req.UserId,
+			})
+			err = r.loginAttemptService.RecordFailedLogin(ctx, user.Email, ctx.ClientIP())
+			if err != nil {
+				r.logger.Error("could not record failed login", zap.Error(err))
+			}
+			r.renderPage(ctx, twoFactorLoginPage, http.StatusUnauthorized, pageData, "Invalid two-factor code or passkey")
 		default:
-			r.logger.Error("could not create user", zap.Error(err))
-			r.renderPage(ctx, registerPage, http.StatusInternalServerError, nil, "Something went wrong")
-			return
+			r.logger.Error("could not verify two-factor code", zap.String("userId", req.UserId), zap.Error(err))
+			r.renderPage(ctx, twoFactorLoginPage, http.StatusInternalServerError, pageData, "Something went wrong")
 		}
+		return
 	}
 
-	type registerEndPageData struct {
-		Email string
+	err = r.authorizer.InvalidateServiceToken(ctx, r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Warn("could not invalidate service token", zap.Error(err))
 	}
-	r.renderPage(ctx, registerEndPage, http.StatusOK, registerEndPageData{
-		Email: user.Email,
-	}, "")
 
-	err = r.emailServiceV2.SendEmailVerificationEmail(ctx, *user, common.MakeEmailVerificationURIs(*user))
+	if !r.logIn(ctx, *user, loginPage, nil) {
+		return
+	}
+
+	ctx.Redirect(http.StatusMovedPermanently, r.getPageAfterLogin(ctx, *user))
+}
+
+// verifySecondFactor checks the two-factor code or, if one was sent instead, the passkey
+// of the user whose password has been checked
+func (r *frontendRouter) verifySecondFactor(ctx *gin.Context, userId, code, rawCredential string) error {
+	if len(rawCredential) == 0 {
+		return r.twoFactorService.VerifyCode(ctx, userId, code)
+	}
+
+	credential, err := utils.ParseWebAuthnCredentialResponse(rawCredential)
 	if err != nil {
-		r.logger.Error("could not send email verification email", zap.Error(err))
+		return errors.Wrap(services.ErrInvalidWebAuthnCredential, err.Error())
 	}
+
+	_, err = r.webAuthnService.FinishLogin(ctx, userId, *credential)
+	return err
 }
 
-func (r *frontendRouter) ForgotPasswordPage(ctx *gin.Context) {
-	r.renderPage(ctx, forgotPasswordPage, http.StatusOK, nil, "")
+func (r *frontendRouter) TwoFactorSetupPage(ctx *gin.Context) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		switch errors.Cause(err) {
+		case authCommon.ErrInvalidToken, authCommon.ErrInvalidTokenType:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		default:
+			r.logger.Error("could not extract user id from token", zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	secret, uri, err := r.twoFactorService.BeginEnrollment(ctx, userId.Hex())
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrTwoFactorEnabled:
+			r.logger.Debug("two-factor authentication already enabled", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Two-factor authentication is already enabled")
+		default:
+			r.logger.Error("could not begin two-factor enrollment", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, twoFactorSetupPage, http.StatusOK, twoFactorPageData{
+		UserId: userId.Hex(),
+		Secret: secret,
+		URI:    uri,
+	}, "")
 }
 
-func (r *frontendRouter) ForgotPassword(ctx *gin.Context) {
-	email := ctx.PostForm("email")
+func (r *frontendRouter) EnableTwoFactor(ctx *gin.Context) {
+	tokenType, err := r.authorizer.GetTokenTypeFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Debug("invalid token", zap.Error(err))
+		r.HandleUnauthorized(ctx)
+		return
+	}
 
-	if len(email) == 0 {
-		r.logger.Debug("email not specified")
-		r.renderPage(ctx, forgotPasswordPage, http.StatusBadRequest, nil, "Please enter your email")
+	// users who have to set up two-factor authentication to log in only have a service token,
+	// which is limited to the id of the user in the form
+	duringLogin := tokenType == authV2.Service
+	userId := ctx.PostForm("userId")
+	if !duringLogin {
+		tokenUserId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+		if err != nil {
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+			return
+		}
+		userId = tokenUserId.Hex()
+	}
+
+	recoveryCodes, err := r.twoFactorService.ConfirmEnrollment(ctx, userId, ctx.PostForm("code"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidTwoFactorCode:
+			r.logger.Debug("invalid two-factor code", zap.String("userId", userId))
+			r.renderTwoFactorSetupPage(ctx, userId, http.StatusBadRequest, "Invalid two-factor code")
+		case services.ErrTwoFactorEnabled:
+			r.logger.Debug("two-factor authentication already enabled", zap.String("userId", userId))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Two-factor authentication is already enabled")
+		case services.ErrTwoFactorNotEnrolling:
+			r.logger.Debug("two-factor enrollment not started", zap.String("userId", userId))
+			r.renderTwoFactorSetupPage(ctx, userId, http.StatusBadRequest, "Please scan the new code to set up two-factor authentication")
+		case services.ErrInvalidID, services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusNotFound, nil, "User not found")
+		default:
+			r.logger.Error("could not confirm two-factor enrollment", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
 		return
 	}
 
-	type res struct {
-		Email string
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionTwoFactorEnabled,
+		Target: userId,
+	})
+
+	pageData := twoFactorRecoveryCodesPageData{
+		RecoveryCodes: recoveryCodes,
+		ContinueTo:    "/profile",
 	}
-	user, err := r.userService.GetUserWithEmail(ctx, email)
+	if duringLogin {
+		user, err := r.userService.GetUserWithID(ctx, userId)
+		if err != nil {
+			r.logger.Error("could not fetch user", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+			return
+		}
+
+		err = r.authorizer.InvalidateServiceToken(ctx, r.GetAuthToken(ctx))
+		if err != nil {
+			r.logger.Warn("could not invalidate service token", zap.Error(err))
+		}
+
+		pageData.ContinueTo = r.getPageAfterLogin(ctx, *user)
+		if !r.logIn(ctx, *user, twoFactorRecoveryCodesPage, pageData) {
+			return
+		}
+	}
+
+	r.renderPage(ctx, twoFactorRecoveryCodesPage, http.StatusOK, pageData, "")
+}
+
+// renderTwoFactorSetupPage renders the setup page for the pending two-factor enrollment of the given user
+func (r *frontendRouter) renderTwoFactorSetupPage(ctx *gin.Context, userId string, statusCode int, alertMessage string) {
+	secret, uri, err := r.twoFactorService.BeginEnrollment(ctx, userId)
+	if err != nil {
+		r.logger.Error("could not begin two-factor enrollment", zap.String("userId", userId), zap.Error(err))
+		r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, twoFactorSetupPage, statusCode, twoFactorPageData{
+		UserId: userId,
+		Secret: secret,
+		URI:    uri,
+	}, alertMessage)
+}
+
+func (r *frontendRouter) DisableTwoFactor(ctx *gin.Context) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		switch errors.Cause(err) {
+		case authCommon.ErrInvalidToken, authCommon.ErrInvalidTokenType:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		default:
+			r.logger.Error("could not extract user id from token", zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	user, err := r.userService.GetUserWithID(ctx, userId.Hex())
 	if err != nil {
 		switch errors.Cause(err) {
 		case services.ErrNotFound:
-			r.logger.Debug("user with email doesn't exist", zap.String("email", email))
-			r.renderPage(ctx, forgotPasswordEndPage, http.StatusOK, res{Email: email}, "")
+			r.logger.Debug("user not found", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "User not found")
 		default:
-			r.logger.Error("could not fetch user", zap.String("email", email), zap.Error(err))
-			r.renderPage(ctx, forgotPasswordPage, http.StatusInternalServerError, nil, "Something went wrong")
+			r.logger.Error("could not fetch user", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
 		}
 		return
 	}
 
-	err = r.emailServiceV2.SendPasswordResetEmail(ctx, *user, common.MakePasswordResetURIs(*user))
+	if r.twoFactorService.IsRequiredForRole(user.Role) {
+		r.logger.Debug("two-factor authentication is required for user's role", zap.String("userId", userId.Hex()))
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Two-factor authentication is required for your role")
+		return
+	}
+
+	err = r.twoFactorService.VerifyCode(ctx, userId.Hex(), ctx.PostForm("code"))
 	if err != nil {
-		r.logger.Error("could not send password reset email", zap.Error(err))
-		r.renderPage(ctx, forgotPasswordPage, http.StatusInternalServerError, nil, "Something went wrong")
+		switch errors.Cause(err) {
+		case services.ErrInvalidTwoFactorCode:
+			r.logger.Debug("invalid two-factor code", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, profilePage, http.StatusUnauthorized, nil, "Invalid two-factor code")
+		case services.ErrTwoFactorNotEnabled:
+			r.logger.Debug("two-factor authentication not enabled", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Two-factor authentication is not enabled")
+		default:
+			r.logger.Error("could not verify two-factor code", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
 		return
 	}
 
-	r.renderPage(ctx, forgotPasswordEndPage, http.StatusOK, res{Email: email}, "")
-}
+	err = r.twoFactorService.Disable(ctx, userId.Hex())
+	if err != nil {
+		r.logger.Error("could not disable two-factor authentication", zap.String("userId", userId.Hex()), zap.Error(err))
+		r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
 
-func (r *frontendRouter) ResetPasswordPage(ctx *gin.Context) {
-	ctx.SetCookie(authCookieName, ctx.Query("token"), int(r.cfg.Auth.UserTokenLifetime), "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionTwoFactorDisabled,
+		Target: userId.Hex(),
+	})
 
-	type res struct {
-		UserId string
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) BeginPasskeyLogin(ctx *gin.Context) {
+	options, err := r.webAuthnService.BeginLogin(ctx, "")
+	if err != nil {
+		r.logger.Error("could not begin passkey login", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "Something went wrong")
+		return
 	}
-	r.renderPage(ctx, resetPasswordPage, http.StatusOK, res{UserId: ctx.Query("userId")}, "")
+
+	ctx.JSON(http.StatusOK, passkeyOptionsRes{
+		PublicKey: options,
+	})
 }
 
-func (r *frontendRouter) ResetPassword(ctx *gin.Context) {
-	var req struct {
-		UserId          string `form:"userId"`
-		Password        string `form:"password"`
-		PasswordConfirm string `form:"passwordConfirm"`
+func (r *frontendRouter) LoginWithPasskey(ctx *gin.Context) {
+	credential, err := utils.ParseWebAuthnCredentialResponse(ctx.PostForm("credential"))
+	if err != nil {
+		r.logger.Debug("could not parse passkey credential", zap.Error(err))
+		r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Passkey could not be verified")
+		return
 	}
-	ctx.Bind(&req)
 
-	type res struct {
-		UserId string
+	user, err := r.webAuthnService.FinishLogin(ctx, "", *credential)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidWebAuthnCredential:
+			r.logger.Debug("invalid passkey", zap.Error(err))
+			common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+				Action: entities.AuditActionLoginFailed,
+				Target: utils.EncodeWebAuthnBytes(credential.RawID),
+			})
+			r.renderPage(ctx, loginPage, http.StatusUnauthorized, nil, "Passkey could not be verified")
+		default:
+			r.logger.Error("could not verify passkey", zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
 	}
-	if len(req.Password) == 0 {
-		r.logger.Debug("password not specified", zap.Int("password len", len(req.Password)))
-		r.renderPage(ctx, resetPasswordPage, http.StatusBadRequest, res{UserId: req.UserId}, "All fields are required")
+
+	// a locked account stays locked, whichever way the user logs in
+	if !r.checkLoginAllowed(ctx, user.Email, loginPage, nil) {
 		return
 	}
 
-	if req.Password != req.PasswordConfirm {
-		r.logger.Debug("password and passwordConfirm do not match")
-		r.renderPage(ctx, resetPasswordPage, http.StatusBadRequest, res{UserId: req.UserId}, "Passwords do not match")
+	if !r.logIn(ctx, *user, loginPage, nil) {
+		return
+	}
+
+	ctx.Redirect(http.StatusMovedPermanently, r.getPageAfterLogin(ctx, *user))
+}
+
+func (r *frontendRouter) BeginPasskeyRegistration(ctx *gin.Context) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Debug("invalid token", zap.Error(err))
+		models.SendAPIError(ctx, http.StatusUnauthorized, "You are not authorized to use this operation")
+		return
+	}
+
+	options, err := r.webAuthnService.BeginRegistration(ctx, userId.Hex())
+	if err != nil {
+		r.logger.Error("could not begin passkey registration", zap.String("userId", userId.Hex()), zap.Error(err))
+		models.SendAPIError(ctx, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, passkeyOptionsRes{
+		PublicKey: options,
+	})
+}
+
+func (r *frontendRouter) RegisterPasskey(ctx *gin.Context) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Debug("invalid token", zap.Error(err))
+		r.HandleUnauthorized(ctx)
+		return
+	}
+
+	credential, err := utils.ParseWebAuthnCredentialResponse(ctx.PostForm("credential"))
+	if err != nil {
+		r.logger.Debug("could not parse passkey credential", zap.Error(err))
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Passkey could not be verified")
+		return
+	}
+
+	storedCredential, err := r.webAuthnService.FinishRegistration(ctx, userId.Hex(), ctx.PostForm("name"), *credential)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidWebAuthnCredential:
+			r.logger.Debug("invalid passkey", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Passkey could not be verified")
+		case services.ErrWebAuthnCredentialExists:
+			r.logger.Debug("passkey already registered", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "This passkey is already registered")
+		default:
+			r.logger.Error("could not register passkey", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionPasskeyRegistered,
+		Target: userId.Hex(),
+		After: entities.AuditValues{
+			string(entities.WebAuthnCredentialID):   storedCredential.ID.Hex(),
+			string(entities.WebAuthnCredentialName): storedCredential.Name,
+		},
+	})
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) DeletePasskey(ctx *gin.Context) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Debug("invalid token", zap.Error(err))
+		r.HandleUnauthorized(ctx)
+		return
+	}
+
+	credentialId := ctx.PostForm("credentialId")
+	err = r.webAuthnService.DeleteCredentialForUser(ctx, userId.Hex(), credentialId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID, services.ErrNotFound:
+			r.logger.Debug("passkey not found", zap.String("credentialId", credentialId), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "Passkey not found")
+		default:
+			r.logger.Error("could not delete passkey", zap.String("credentialId", credentialId), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionPasskeyDeleted,
+		Target: userId.Hex(),
+		Before: entities.AuditValues{
+			string(entities.WebAuthnCredentialID): credentialId,
+		},
+	})
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) SendLoginLink(ctx *gin.Context) {
+	email := ctx.PostForm("email")
+
+	if len(email) == 0 {
+		r.logger.Debug("email not specified")
+		r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Please enter your email")
+		return
+	}
+
+	// the cooldown applies to emails without an account too, so that it does not reveal who has one
+	retryAfter, err := r.loginAttemptService.RecordLoginLinkRequest(ctx, email)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrLoginLinkSent:
+			r.logger.Debug("login link requested again too soon", zap.String("email", email))
+			common.SetRetryAfterHeader(ctx, retryAfter)
+			r.renderPage(ctx, loginPage, http.StatusTooManyRequests, nil, "A login link was sent to this email recently, please try again later")
+		default:
+			r.logger.Error("could not record login link request", zap.String("email", email), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	type res struct {
+		Email string
+	}
+	user, err := r.userService.GetUserWithEmail(ctx, email)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNotFound:
+			// the page does not change, so that the form cannot be used to find out who has an account
+			r.logger.Debug("user with email doesn't exist", zap.String("email", email))
+			r.renderPage(ctx, loginLinkSentPage, http.StatusOK, res{Email: email}, "")
+		default:
+			r.logger.Error("could not fetch user", zap.String("email", email), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	err = r.emailServiceV2.SendLoginLinkEmail(ctx, *user, common.MakeLoginLinkURIs(*user))
+	if err != nil {
+		r.logger.Error("could not send login link email", zap.Error(err))
+		r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, loginLinkSentPage, http.StatusOK, res{Email: email}, "")
+}
+
+func (r *frontendRouter) LoginWithLink(ctx *gin.Context) {
+	userId := ctx.Query("userId")
+
+	// the token is deleted before the user gets logged in, so that each link can only be used once
+	err := r.authorizer.InvalidateServiceToken(ctx, ctx.Query("token"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNotFound:
+			r.logger.Debug("login link already used", zap.String("userId", userId))
+			r.renderPage(ctx, loginPage, http.StatusUnauthorized, nil, "This login link has already been used")
+		default:
+			r.logger.Error("could not invalidate login link token", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	user, err := r.userService.GetUserWithID(ctx, userId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusNotFound, nil, "User not found")
+		default:
+			r.logger.Error("could not fetch user", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	if !r.checkLoginAllowed(ctx, user.Email, loginPage, nil) {
+		return
+	}
+
+	r.completeLogin(ctx, *user)
+}
+
+func (r *frontendRouter) LoginWithOAuth(ctx *gin.Context) {
+	r.redirectToOAuthProvider(ctx, oauthPurposeLogin, loginPage)
+}
+
+func (r *frontendRouter) LinkOAuthAccount(ctx *gin.Context) {
+	r.redirectToOAuthProvider(ctx, oauthPurposeLink, profilePage)
+}
+
+// redirectToOAuthProvider sends the user to the consent page of the provider in the URL.
+// The state sent to the provider is stored in a cookie together with the purpose of the flow
+// and checked in OAuthCallback, so that the callback cannot be triggered by other sites.
+func (r *frontendRouter) redirectToOAuthProvider(ctx *gin.Context, purpose string, page frontendPage) {
+	provider := ctx.Param("provider")
+
+	state, err := utils.GenerateRandomHexString(oauthStateBytes)
+	if err != nil {
+		r.logger.Error("could not generate OAuth state", zap.Error(err))
+		r.renderPage(ctx, page, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	authCodeURL, err := r.externalIdentityService.GetAuthCodeURL(provider, state)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrUnknownOAuthProvider:
+			r.logger.Debug("unknown OAuth provider", zap.String("provider", provider))
+			r.renderPage(ctx, page, http.StatusNotFound, nil, "Unknown login provider")
+		default:
+			r.logger.Error("could not get OAuth consent page url", zap.String("provider", provider), zap.Error(err))
+			r.renderPage(ctx, page, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	ctx.SetCookie(oauthStateCookie, purpose+":"+state, oauthStateLifetime, "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
+	ctx.Redirect(http.StatusFound, authCodeURL)
+}
+
+func (r *frontendRouter) OAuthCallback(ctx *gin.Context) {
+	provider := ctx.Param("provider")
+
+	storedState, err := ctx.Cookie(oauthStateCookie)
+	if err != nil {
+		storedState = ""
+	}
+	ctx.SetCookie(oauthStateCookie, "", -1, "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
+
+	purpose := oauthPurposeLogin
+	page := loginPage
+	if strings.HasPrefix(storedState, oauthPurposeLink+":") {
+		purpose = oauthPurposeLink
+		page = profilePage
+	}
+
+	if len(ctx.Query("state")) == 0 || storedState != purpose+":"+ctx.Query("state") {
+		r.logger.Debug("OAuth state does not match", zap.String("provider", provider))
+		r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Your login session has expired, please try again")
+		return
+	}
+
+	if len(ctx.Query("error")) > 0 {
+		r.logger.Debug("OAuth consent not given", zap.String("provider", provider), zap.String("error", ctx.Query("error")))
+		r.renderPage(ctx, page, http.StatusUnauthorized, nil, "Access was not granted by the login provider")
+		return
+	}
+
+	externalUser, err := r.externalIdentityService.Authenticate(ctx, provider, ctx.Query("code"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrUnknownOAuthProvider:
+			r.logger.Debug("unknown OAuth provider", zap.String("provider", provider))
+			r.renderPage(ctx, page, http.StatusNotFound, nil, "Unknown login provider")
+		case services.ErrInvalidOAuthCode:
+			r.logger.Debug("invalid OAuth code", zap.String("provider", provider), zap.Error(err))
+			r.renderPage(ctx, page, http.StatusUnauthorized, nil, "Your account with the login provider could not be verified")
+		default:
+			r.logger.Error("could not authenticate with OAuth provider", zap.String("provider", provider), zap.Error(err))
+			r.renderPage(ctx, page, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	if purpose == oauthPurposeLink {
+		r.linkOAuthAccount(ctx, provider, *externalUser)
+		return
+	}
+
+	user, err := r.externalIdentityService.GetUserWithIdentity(ctx, provider, externalUser.ID)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNotFound:
+			r.logger.Debug("external identity not linked", zap.String("provider", provider), zap.String("externalId", externalUser.ID))
+			common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+				Action: entities.AuditActionLoginFailed,
+				Target: provider + ":" + externalUser.ID,
+			})
+			r.renderPage(ctx, loginPage, http.StatusNotFound, nil,
+				"No user is linked to this account, please log in and link it on your profile first")
+		default:
+			r.logger.Error("could not fetch user with external identity", zap.String("provider", provider), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	if !r.checkLoginAllowed(ctx, user.Email, loginPage, nil) {
+		return
+	}
+
+	r.completeLogin(ctx, *user)
+}
+
+// linkOAuthAccount links the given account with the provider to the logged in user
+func (r *frontendRouter) linkOAuthAccount(ctx *gin.Context, provider string, externalUser services.ExternalUser) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Debug("invalid token", zap.Error(err))
+		r.HandleUnauthorized(ctx)
+		return
+	}
+
+	identity, err := r.externalIdentityService.LinkIdentity(ctx, userId.Hex(), provider, externalUser)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrExternalIdentityLinked:
+			r.logger.Debug("external identity already linked", zap.String("userId", userId.Hex()), zap.String("provider", provider))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "This account or another account with the provider is already linked")
+		default:
+			r.logger.Error("could not link external identity", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionIdentityLinked,
+		Target: userId.Hex(),
+		After: entities.AuditValues{
+			string(entities.ExternalIdentityProvider):   identity.Provider,
+			string(entities.ExternalIdentityExternalID): identity.ExternalID,
+		},
+	})
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) UnlinkOAuthAccount(ctx *gin.Context) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Debug("invalid token", zap.Error(err))
+		r.HandleUnauthorized(ctx)
+		return
+	}
+
+	identityId := ctx.PostForm("identityId")
+	err = r.externalIdentityService.UnlinkIdentityForUser(ctx, userId.Hex(), identityId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID, services.ErrNotFound:
+			r.logger.Debug("linked account not found", zap.String("identityId", identityId), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "Linked account not found")
+		default:
+			r.logger.Error("could not unlink account", zap.String("identityId", identityId), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionIdentityUnlinked,
+		Target: userId.Hex(),
+		Before: entities.AuditValues{
+			string(entities.ExternalIdentityID): identityId,
+		},
+	})
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+// registerPageData holds the code of the invitation the user registers with, if any
+type registerPageData struct {
+	Invite string
+}
+
+func (r *frontendRouter) RegisterPage(ctx *gin.Context) {
+	invite := ctx.Query("invite")
+	if len(invite) == 0 {
+		r.renderPage(ctx, registerPage, http.StatusOK, nil, "")
+		return
+	}
+
+	_, err := r.invitationService.GetInvitationWithCode(ctx, invite)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNotFound:
+			r.logger.Debug("invitation not found")
+			r.renderPage(ctx, registerPage, http.StatusNotFound, nil, "This invitation is invalid, has expired or has been used up")
+		default:
+			r.logger.Error("could not fetch invitation", zap.Error(err))
+			r.renderPage(ctx, registerPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, registerPage, http.StatusOK, registerPageData{Invite: invite}, "")
+}
+
+func (r *frontendRouter) Register(ctx *gin.Context) {
+	var req struct {
+		Name            string `form:"name"`
+		Email           string `form:"email"`
+		Password        string `form:"password"`
+		PasswordConfirm string `form:"passwordConfirm"`
+		Invite          string `form:"invite"`
+	}
+	ctx.Bind(&req)
+	// the invitation is kept when the page is rendered again after a failed registration
+	pageData := registerPageData{Invite: req.Invite}
+
+	if len(req.Name) == 0 || len(req.Email) == 0 || len(req.Password) == 0 {
+		r.logger.Debug("one of name, email, password, passwordConfirm not specified", zap.String("name", req.Name), zap.String("email", req.Email), zap.Int("password length", len(req.Password)), zap.Int("passwordConfirm length", len(req.PasswordConfirm)))
+		r.renderPage(ctx, registerPage, http.StatusBadRequest, pageData, "All fields are required")
+		return
+	}
+
+	// TODO: implement automatic validation at the entity level (https://github.com/unicsmcr/hs_auth/issues/123)
+	if len(req.Password) < 6 || len(req.Password) > 160 {
+		r.logger.Debug("invalid password length", zap.Int("length", len(req.Password)))
+		r.renderPage(ctx, registerPage, http.StatusBadRequest, pageData, "Password must contain between 6 and 160 characters")
+		return
+	}
+
+	if req.Password != req.PasswordConfirm {
+		r.logger.Debug("password and passwordConfirm do not match")
+		r.renderPage(ctx, registerPage, http.StatusBadRequest, pageData, "Passwords do not match")
+		return
+	}
+
+	userRole := r.cfg.Auth.DefaultRole
+	var invitation *entities.Invitation
+	if len(req.Invite) > 0 {
+		var err error
+		invitation, err = r.invitationService.UseInvitationWithCode(ctx, req.Invite)
+		if err != nil {
+			switch errors.Cause(err) {
+			case services.ErrNotFound:
+				r.logger.Debug("invitation not found")
+				r.renderPage(ctx, registerPage, http.StatusBadRequest, nil, "This invitation is invalid, has expired or has been used up")
+			default:
+				r.logger.Error("could not use invitation", zap.Error(err))
+				r.renderPage(ctx, registerPage, http.StatusInternalServerError, pageData, "Something went wrong")
+			}
+			return
+		}
+		userRole = invitation.Role
+	}
+
+	user, err := r.userService.CreateUser(ctx, req.Name, req.Email, req.Password, userRole)
+	if err != nil {
+		if invitation != nil {
+			releaseErr := r.invitationService.ReleaseInvitationUse(ctx, invitation.ID.Hex())
+			if releaseErr != nil {
+				r.logger.Warn("could not release invitation use", zap.String("invitation id", invitation.ID.Hex()), zap.Error(releaseErr))
+			}
+		}
+
+		switch errors.Cause(err) {
+		case services.ErrEmailTaken:
+			r.logger.Debug("email taken")
+			r.renderPage(ctx, registerPage, http.StatusBadRequest, pageData, "Email taken")
+			return
+		case services.ErrPasswordTooShort, services.ErrPasswordMissingCharacterClass,
+			services.ErrPasswordContainsPersonalInfo, services.ErrPasswordBreached:
+			r.logger.Debug("password rejected by password policy", zap.Error(err))
+			r.renderPage(ctx, registerPage, http.StatusBadRequest, pageData, r.passwordPolicyAlert(err))
+			return
+		default:
+			r.logger.Error("could not create user", zap.Error(err))
+			r.renderPage(ctx, registerPage, http.StatusInternalServerError, pageData, "Something went wrong")
+			return
+		}
+	}
+
+	if invitation != nil && invitation.Team != primitive.NilObjectID {
+		err = r.teamService.AddUserWithIDToTeamWithID(ctx, user.ID.Hex(), invitation.Team.Hex())
+		if err != nil {
+			r.logger.Warn("could not add invited user to team", zap.String("team id", invitation.Team.Hex()), zap.Error(err))
+		}
+	}
+
+	type registerEndPageData struct {
+		Email   string
+		Invited bool
+	}
+	r.renderPage(ctx, registerEndPage, http.StatusOK, registerEndPageData{
+		Email:   user.Email,
+		Invited: invitation != nil,
+	}, "")
+
+	// the user got their role from the invitation, so their email does not have to be verified to get one
+	if invitation != nil {
+		return
+	}
+
+	err = r.emailServiceV2.SendEmailVerificationEmail(ctx, *user, common.MakeEmailVerificationURIs(*user))
+	if err != nil {
+		r.logger.Error("could not send email verification email", zap.Error(err))
+	}
+}
+
+func (r *frontendRouter) ForgotPasswordPage(ctx *gin.Context) {
+	r.renderPage(ctx, forgotPasswordPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) ForgotPassword(ctx *gin.Context) {
+	email := ctx.PostForm("email")
+
+	if len(email) == 0 {
+		r.logger.Debug("email not specified")
+		r.renderPage(ctx, forgotPasswordPage, http.StatusBadRequest, nil, "Please enter your email")
+		return
+	}
+
+	type res struct {
+		Email string
+	}
+	user, err := r.userService.GetUserWithEmail(ctx, email)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNotFound:
+			r.logger.Debug("user with email doesn't exist", zap.String("email", email))
+			r.renderPage(ctx, forgotPasswordEndPage, http.StatusOK, res{Email: email}, "")
+		default:
+			r.logger.Error("could not fetch user", zap.String("email", email), zap.Error(err))
+			r.renderPage(ctx, forgotPasswordPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	err = r.emailServiceV2.SendPasswordResetEmail(ctx, *user, common.MakePasswordResetURIs(*user))
+	if err != nil {
+		r.logger.Error("could not send password reset email", zap.Error(err))
+		r.renderPage(ctx, forgotPasswordPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, forgotPasswordEndPage, http.StatusOK, res{Email: email}, "")
+}
+
+func (r *frontendRouter) ResetPasswordPage(ctx *gin.Context) {
+	ctx.SetCookie(authCookieName, ctx.Query("token"), int(r.cfg.Auth.UserTokenLifetime), "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
+
+	type res struct {
+		UserId string
+	}
+	r.renderPage(ctx, resetPasswordPage, http.StatusOK, res{UserId: ctx.Query("userId")}, "")
+}
+
+func (r *frontendRouter) ResetPassword(ctx *gin.Context) {
+	var req struct {
+		UserId          string `form:"userId"`
+		Password        string `form:"password"`
+		PasswordConfirm string `form:"passwordConfirm"`
+	}
+	ctx.Bind(&req)
+
+	type res struct {
+		UserId string
+	}
+	if len(req.Password) == 0 {
+		r.logger.Debug("password not specified", zap.Int("password len", len(req.Password)))
+		r.renderPage(ctx, resetPasswordPage, http.StatusBadRequest, res{UserId: req.UserId}, "All fields are required")
+		return
+	}
+
+	if req.Password != req.PasswordConfirm {
+		r.logger.Debug("password and passwordConfirm do not match")
+		r.renderPage(ctx, resetPasswordPage, http.StatusBadRequest, res{UserId: req.UserId}, "Passwords do not match")
+		return
+	}
+
+	err := r.userService.SetPasswordForUserWithID(ctx, req.UserId, req.Password)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrPasswordTooShort, services.ErrPasswordMissingCharacterClass,
+			services.ErrPasswordContainsPersonalInfo, services.ErrPasswordBreached:
+			r.logger.Debug("password rejected by password policy", zap.Error(err))
+			r.renderPage(ctx, resetPasswordPage, http.StatusBadRequest, res{UserId: req.UserId}, r.passwordPolicyAlert(err))
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.String("user id", req.UserId), zap.Error(err))
+			r.renderPage(ctx, resetPasswordPage, http.StatusBadRequest, res{UserId: req.UserId}, "Invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.Error(err))
+			r.renderPage(ctx, resetPasswordPage, http.StatusNotFound, res{UserId: req.UserId}, "User not found")
+		default:
+			r.logger.Error("could not update user with id", zap.Error(err))
+			r.renderPage(ctx, resetPasswordPage, http.StatusInternalServerError, res{UserId: req.UserId}, "Something went wrong")
+		}
+		return
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionPasswordSet,
+		Target: req.UserId,
+	})
+
+	err = r.authorizer.InvalidateServiceToken(ctx, r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Warn("could not invalidate service token", zap.Error(err))
+	}
+
+	ctx.SetCookie(authCookieName, "", 0, "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
+	r.renderPage(ctx, resetPasswordEndPage, http.StatusOK, nil, "")
+}
+
+// passwordPolicyAlert returns the alert shown to users whose password was rejected by the password policy
+func (r *frontendRouter) passwordPolicyAlert(err error) string {
+	message := common.PasswordPolicyErrorMessage(r.cfg.Auth.PasswordPolicy, err)
+	return strings.ToUpper(message[:1]) + message[1:]
+}
+
+func (r *frontendRouter) VerifyEmail(ctx *gin.Context) {
+	userId := ctx.Query("userId")
+	user, err := r.userService.GetUserWithID(ctx, userId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusNotFound, nil, "User not found")
+		default:
+			r.logger.Debug("could not fetch user", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	if user.Role != role.Unverified {
+		r.logger.Debug("user's email is already verified", zap.String("userId", userId))
+		r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Your email is already verified")
+		return
+	}
+
+	err = r.userService.UpdateUserWithID(ctx, userId, services.UserUpdateParams{
+		entities.UserRole:          r.cfg.Auth.DefaultEmailVerifiedRole,
+		entities.UserEmailVerified: true,
+	})
+	if err != nil {
+		r.logger.Debug("could not update user", zap.String("userId", userId), zap.Error(err))
+		r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, verifyEmailPage, http.StatusOK, nil, "")
+
+	err = r.authorizer.InvalidateServiceToken(ctx, ctx.Query("token"))
+	if err != nil {
+		r.logger.Warn("could not invalidate service token", zap.Error(err))
+	}
+}
+
+func (r *frontendRouter) RequestEmailChange(ctx *gin.Context) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		r.logger.Debug("invalid token", zap.Error(err))
+		r.HandleUnauthorized(ctx)
+		return
+	}
+
+	email := ctx.PostForm("email")
+	if len(email) == 0 {
+		r.logger.Debug("email not specified")
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Please enter your new email")
+		return
+	}
+
+	user, err := r.userService.RequestEmailChangeForUserWithID(ctx, userId.Hex(), email)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrEmailTaken:
+			r.logger.Debug("email taken", zap.String("email", email))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Email taken")
+		default:
+			r.logger.Error("could not request email change", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	err = r.emailServiceV2.SendEmailChangeConfirmationEmail(ctx, *user, user.PendingEmail, common.MakeEmailChangeConfirmationURIs(*user))
+	if err != nil {
+		r.logger.Error("could not send email change confirmation email", zap.Error(err))
+		r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	// the change cannot be made without the confirmation, so failing to notify the current address is not fatal
+	err = r.emailServiceV2.SendEmailChangeNoticeEmail(ctx, *user, user.PendingEmail)
+	if err != nil {
+		r.logger.Warn("could not send email change notice email", zap.Error(err))
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionEmailChangeRequested,
+		Target: user.ID.Hex(),
+		After: entities.AuditValues{
+			string(entities.UserPendingEmail): user.PendingEmail,
+		},
+	})
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) ConfirmEmailChange(ctx *gin.Context) {
+	userId := ctx.Query("userId")
+	previousUser, err := r.userService.GetUserWithID(ctx, userId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusNotFound, nil, "User not found")
+		default:
+			r.logger.Error("could not fetch user", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	user, err := r.userService.ConfirmEmailChangeForUserWithID(ctx, userId, ctx.Query("nonce"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNoPendingEmailChange:
+			r.logger.Debug("nonce does not match pending email change", zap.String("userId", userId))
+			r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "This link is no longer valid")
+		case services.ErrEmailTaken:
+			r.logger.Debug("email taken", zap.String("userId", userId))
+			r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Email taken")
+		default:
+			r.logger.Error("could not confirm email change", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionEmailChanged,
+		Target: user.ID.Hex(),
+		Before: entities.AuditValues{
+			string(entities.UserEmail): previousUser.Email,
+			string(entities.UserRole):  previousUser.Role,
+		},
+		After: entities.AuditValues{
+			string(entities.UserEmail): user.Email,
+			string(entities.UserRole):  user.Role,
+		},
+	})
+
+	type res struct {
+		Email string
+	}
+	r.renderPage(ctx, emailChangedPage, http.StatusOK, res{Email: user.Email}, "")
+
+	err = r.authorizer.InvalidateServiceToken(ctx, ctx.Query("token"))
+	if err != nil {
+		r.logger.Warn("could not invalidate service token", zap.Error(err))
+	}
+}
+
+func (r *frontendRouter) Logout(ctx *gin.Context) {
+	ctx.SetCookie(authCookieName, "", 0, "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
+	r.renderPage(ctx, loginPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) CreateTeam(ctx *gin.Context) {
+	name := ctx.PostForm("name")
+	if len(name) == 0 {
+		r.logger.Debug("team name not specified", zap.String("name", name))
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Please specify team name")
+		return
+	}
+
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		switch errors.Cause(err) {
+		case authCommon.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		default:
+			r.logger.Error("could not extract user id from token", zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	_, err = r.teamService.CreateTeamForUserWithID(ctx, name, userId.Hex())
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNameTaken:
+			r.logger.Debug("team name taken", zap.String("teamName", name), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Team with the given name already exists")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user is already in team", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "You are already in a team")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "User not found")
+		default:
+			r.logger.Error("could not create team for user", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) JoinTeam(ctx *gin.Context) {
+	joinCode := ctx.PostForm("code")
+	if len(joinCode) == 0 {
+		r.logger.Debug("join code not provided")
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Please specify the join code of the team to join")
+		return
+	}
+
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		switch errors.Cause(err) {
+		case authCommon.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		default:
+			r.logger.Error("could not extract user id from token", zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	err = r.teamService.AddUserWithIDToTeamWithJoinCode(ctx, userId.Hex(), joinCode)
+	if errors.Cause(err) == services.ErrTeamClosed {
+		_, err = r.joinRequestService.CreateJoinRequestWithJoinCode(ctx, userId.Hex(), joinCode)
+		if err == nil {
+			r.renderPage(ctx, profilePage, http.StatusOK, nil, "Your request to join the team has been sent to its creator")
+			return
+		}
+	}
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrNotFound:
+			r.logger.Debug("team not found", zap.String("joinCode", joinCode), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "Join code is invalid or has expired")
+		case services.ErrJoinCodeThrottled:
+			r.logger.Debug("too many wrong join codes", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusTooManyRequests, nil, "Too many wrong join codes entered, please try again later")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user is already in team", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "You are already in a team")
+		case services.ErrTeamFull:
+			r.logger.Debug("team is full", zap.String("joinCode", joinCode))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "The team is full")
+		case services.ErrJoinRequestExists:
+			r.logger.Debug("user has already requested to join team", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "You have already requested to join this team")
+		default:
+			r.logger.Debug("could not add user to team", zap.String("userId", userId.Hex()), zap.String("joinCode", joinCode), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) RegenerateTeamJoinCode(ctx *gin.Context) {
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can generate a new join code")
+	if !ok {
+		return
+	}
+
+	_, err := r.teamService.RegenerateJoinCodeForTeamWithID(ctx, team.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not regenerate team's join code", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) SetTeamPrivacy(ctx *gin.Context) {
+	privacy := ctx.PostForm("privacy")
+
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can change who can join it")
+	if !ok {
+		return
+	}
+
+	_, err := r.teamService.SetPrivacyForTeamWithID(ctx, team.ID.Hex(), entities.TeamPrivacySetting(privacy))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidTeamPrivacy:
+			r.logger.Debug("invalid team privacy", zap.String("privacy", privacy))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Please choose whether the team is open or closed")
+		default:
+			r.logger.Error("could not set team's privacy", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) ApproveTeamJoinRequest(ctx *gin.Context) {
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can approve requests to join it")
+	if !ok {
+		return
+	}
+
+	err := r.joinRequestService.ApproveJoinRequestWithID(ctx, team.ID.Hex(), ctx.Param("id"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID, services.ErrNotFound:
+			r.logger.Debug("join request not found", zap.String("requestId", ctx.Param("id")), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "The request has already been approved or rejected")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user who made join request is already in team", zap.String("requestId", ctx.Param("id")))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "The user has already joined another team")
+		case services.ErrTeamFull:
+			r.logger.Debug("team is full", zap.String("teamId", team.ID.Hex()))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "The team is full, a member has to leave before the request can be approved")
+		default:
+			r.logger.Error("could not approve join request", zap.String("requestId", ctx.Param("id")), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) RejectTeamJoinRequest(ctx *gin.Context) {
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can reject requests to join it")
+	if !ok {
+		return
+	}
+
+	err := r.joinRequestService.RejectJoinRequestWithID(ctx, team.ID.Hex(), ctx.Param("id"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID, services.ErrNotFound:
+			r.logger.Debug("join request not found", zap.String("requestId", ctx.Param("id")), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "The request has already been approved or rejected")
+		default:
+			r.logger.Error("could not reject join request", zap.String("requestId", ctx.Param("id")), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) RenameTeam(ctx *gin.Context) {
+	name := ctx.PostForm("name")
+	if len(name) == 0 {
+		r.logger.Debug("team name not provided")
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Please specify the team's new name")
 		return
 	}
 
-	hashedPassword, err := utils.GetHashForPassword(req.Password)
-	if err != nil {
-		r.logger.Error("could not make hash for password", zap.Error(err))
-		r.renderPage(ctx, resetPasswordPage, http.StatusInternalServerError, res{UserId: req.UserId}, "Something went wrong")
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can rename it")
+	if !ok {
 		return
 	}
 
-	err = r.userService.UpdateUserWithID(ctx, req.UserId, services.UserUpdateParams{
-		entities.UserPassword: hashedPassword,
-	})
+	_, err := r.teamService.RenameTeamWithID(ctx, team.ID.Hex(), name)
 	if err != nil {
-		switch err {
-		case services.ErrInvalidID:
-			r.logger.Debug("invalid user id", zap.String("user id", req.UserId), zap.Error(err))
-			r.renderPage(ctx, resetPasswordPage, http.StatusBadRequest, res{UserId: req.UserId}, "Invalid user id")
-		case services.ErrNotFound:
-			r.logger.Debug("user not found", zap.Error(err))
-			r.renderPage(ctx, resetPasswordPage, http.StatusNotFound, res{UserId: req.UserId}, "User not found")
+		switch errors.Cause(err) {
+		case services.ErrNameTaken:
+			r.logger.Debug("team name taken", zap.String("name", name))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Team name is already taken")
 		default:
-			r.logger.Error("could not update user with id", zap.Error(err))
-			r.renderPage(ctx, resetPasswordPage, http.StatusInternalServerError, res{UserId: req.UserId}, "Something went wrong")
+			r.logger.Error("could not rename team", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
 		}
 		return
 	}
 
-	err = r.authorizer.InvalidateServiceToken(ctx, r.GetAuthToken(ctx))
-	if err != nil {
-		r.logger.Warn("could not invalidate service token", zap.Error(err))
-	}
-
-	ctx.SetCookie(authCookieName, "", 0, "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
-	r.renderPage(ctx, resetPasswordEndPage, http.StatusOK, nil, "")
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
 }
 
-func (r *frontendRouter) VerifyEmail(ctx *gin.Context) {
-	userId := ctx.Query("userId")
-	user, err := r.userService.GetUserWithID(ctx, userId)
-	if err != nil {
-		switch errors.Cause(err) {
-		case services.ErrInvalidID:
-			r.logger.Debug("invalid user id", zap.String("userId", userId), zap.Error(err))
-			r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Invalid user id")
-		case services.ErrNotFound:
-			r.logger.Debug("user not found", zap.String("userId", userId), zap.Error(err))
-			r.renderPage(ctx, loginPage, http.StatusNotFound, nil, "User not found")
-		default:
-			r.logger.Debug("could not fetch user", zap.String("userId", userId), zap.Error(err))
-			r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+func (r *frontendRouter) UpdateTeamDetails(ctx *gin.Context) {
+	updatedFields := map[entities.TeamField]string{}
+	for _, field := range []entities.TeamField{entities.TeamDescription, entities.TeamRepositoryURL,
+		entities.TeamDemoURL, entities.TeamTrack, entities.TeamTableNumber, entities.TeamTags} {
+		if value, exists := ctx.GetPostForm(string(field)); exists {
+			updatedFields[field] = value
 		}
-		return
 	}
-
-	if user.Role != role.Unverified {
-		r.logger.Debug("user's email is already verified", zap.String("userId", userId))
-		r.renderPage(ctx, loginPage, http.StatusBadRequest, nil, "Your email is already verified")
+	if len(updatedFields) == 0 {
+		r.logger.Debug("no team details to update provided")
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Please specify the team's details")
 		return
 	}
 
-	err = r.userService.UpdateUserWithID(ctx, userId, services.UserUpdateParams{
-		entities.UserRole: r.cfg.Auth.DefaultEmailVerifiedRole,
-	})
+	params, err := services.BuildTeamUpdateParams(r.cfg, updatedFields)
 	if err != nil {
-		r.logger.Debug("could not update user", zap.String("userId", userId), zap.Error(err))
-		r.renderPage(ctx, loginPage, http.StatusInternalServerError, nil, "Something went wrong")
+		r.logger.Debug("could not build params to update", zap.Error(err))
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Invalid team details")
 		return
 	}
 
-	r.renderPage(ctx, verifyEmailPage, http.StatusOK, nil, "")
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can update its details")
+	if !ok {
+		return
+	}
 
-	err = r.authorizer.InvalidateServiceToken(ctx, ctx.Query("token"))
+	_, err = r.teamService.UpdateTeamWithID(ctx, team.ID.Hex(), params)
 	if err != nil {
-		r.logger.Warn("could not invalidate service token", zap.Error(err))
+		r.logger.Error("could not update team details", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
 	}
-}
 
-func (r *frontendRouter) Logout(ctx *gin.Context) {
-	ctx.SetCookie(authCookieName, "", 0, "", r.cfg.DomainName, r.cfg.UseSecureCookies, true)
-	r.renderPage(ctx, loginPage, http.StatusOK, nil, "")
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
 }
 
-func (r *frontendRouter) CreateTeam(ctx *gin.Context) {
-	name := ctx.PostForm("name")
-	if len(name) == 0 {
-		r.logger.Debug("team name not specified", zap.String("name", name))
-		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Please specify team name")
+func (r *frontendRouter) RemoveTeamMember(ctx *gin.Context) {
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can remove its members")
+	if !ok {
 		return
 	}
 
-	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	err := r.teamService.RemoveUserWithIDFromTeamWithID(ctx, team.ID.Hex(), ctx.Param("id"))
 	if err != nil {
 		switch errors.Cause(err) {
-		case authCommon.ErrInvalidToken:
-			r.logger.Debug("invalid token", zap.Error(err))
-			r.HandleUnauthorized(ctx)
+		case services.ErrInvalidID, services.ErrNotFound, services.ErrUserNotInTeam:
+			r.logger.Debug("user is not a member of team", zap.String("userId", ctx.Param("id")), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "The user is not a member of the team")
+		case services.ErrUserIsTeamCreator:
+			r.logger.Debug("team's creator cannot be removed", zap.String("userId", ctx.Param("id")))
+			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "You cannot remove yourself, leave the team instead")
 		default:
-			r.logger.Error("could not extract user id from token", zap.Error(err))
+			r.logger.Error("could not remove member from team", zap.String("userId", ctx.Param("id")), zap.Error(err))
 			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
 		}
 		return
 	}
 
-	_, err = r.teamService.CreateTeamForUserWithID(ctx, name, userId.Hex())
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) SetTeamCreator(ctx *gin.Context) {
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can hand it over")
+	if !ok {
+		return
+	}
+
+	_, err := r.teamService.SetCreatorForTeamWithID(ctx, team.ID.Hex(), ctx.Param("id"))
 	if err != nil {
 		switch errors.Cause(err) {
-		case services.ErrNameTaken:
-			r.logger.Debug("team name taken", zap.String("teamName", name), zap.Error(err))
-			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Team with the given name already exists")
-		case services.ErrUserInTeam:
-			r.logger.Debug("user is already in team", zap.String("userId", userId.Hex()), zap.Error(err))
-			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "You are already in a team")
-		case services.ErrNotFound:
-			r.logger.Debug("user not found", zap.String("userId", userId.Hex()), zap.Error(err))
-			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "User not found")
+		case services.ErrInvalidID, services.ErrNotFound, services.ErrUserNotInTeam:
+			r.logger.Debug("user is not a member of team", zap.String("userId", ctx.Param("id")), zap.Error(err))
+			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "The user is not a member of the team")
 		default:
-			r.logger.Error("could not create team for user", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.logger.Error("could not set team's creator", zap.String("userId", ctx.Param("id")), zap.Error(err))
 			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
 		}
 		return
@@ -339,14 +1635,25 @@ func (r *frontendRouter) CreateTeam(ctx *gin.Context) {
 	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
 }
 
-func (r *frontendRouter) JoinTeam(ctx *gin.Context) {
-	teamId := ctx.PostForm("id")
-	if len(teamId) == 0 {
-		r.logger.Debug("team id not provided")
-		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Please specify the ID of the team to join")
+func (r *frontendRouter) DisbandTeam(ctx *gin.Context) {
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, profilePage, "Only the team's creator can disband it")
+	if !ok {
 		return
 	}
 
+	err := r.teamService.DisbandTeamWithID(ctx, team.ID.Hex())
+	if err != nil {
+		r.logger.Error("could not disband team", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+// getTeamCreatedByCurrentUser returns the team of the user making the request if they are its creator.
+// Renders the given page with the appropriate alert and returns false otherwise
+func (r *frontendRouter) getTeamCreatedByCurrentUser(ctx *gin.Context, page frontendPage, forbiddenMessage string) (*entities.Team, bool) {
 	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
 	if err != nil {
 		switch errors.Cause(err) {
@@ -355,31 +1662,31 @@ func (r *frontendRouter) JoinTeam(ctx *gin.Context) {
 			r.HandleUnauthorized(ctx)
 		default:
 			r.logger.Error("could not extract user id from token", zap.Error(err))
-			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+			r.renderPage(ctx, page, http.StatusInternalServerError, nil, "Something went wrong")
 		}
-		return
+		return nil, false
 	}
 
-	err = r.teamService.AddUserWithIDToTeamWithID(ctx, userId.Hex(), teamId)
+	team, err := r.teamService.GetTeamForUserWithID(ctx, userId.Hex())
 	if err != nil {
 		switch errors.Cause(err) {
-		case services.ErrInvalidID:
-			r.logger.Debug("invalid team id", zap.String("teamId", teamId), zap.Error(err))
-			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "Invalid team ID provided")
 		case services.ErrNotFound:
-			r.logger.Debug("team not found", zap.String("teamId", teamId), zap.Error(err))
-			r.renderPage(ctx, profilePage, http.StatusNotFound, nil, "Team with given ID does not exist")
-		case services.ErrUserInTeam:
-			r.logger.Debug("user is already in team", zap.String("userId", userId.Hex()), zap.Error(err))
-			r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "You are already in a team")
+			r.logger.Debug("team not found", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, page, http.StatusNotFound, nil, "You are not in a team")
 		default:
-			r.logger.Debug("could not add user to team", zap.String("userId", userId.Hex()), zap.String("teamId", teamId), zap.Error(err))
-			r.renderPage(ctx, profilePage, http.StatusInternalServerError, nil, "Something went wrong")
+			r.logger.Error("could not fetch team for user", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, page, http.StatusInternalServerError, nil, "Something went wrong")
 		}
-		return
+		return nil, false
 	}
 
-	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+	if team.Creator != userId {
+		r.logger.Debug("user is not the team's creator", zap.String("userId", userId.Hex()), zap.String("teamId", team.ID.Hex()))
+		r.renderPage(ctx, page, http.StatusForbidden, nil, forbiddenMessage)
+		return nil, false
+	}
+
+	return team, true
 }
 
 func (r *frontendRouter) LeaveTeam(ctx *gin.Context) {
@@ -451,6 +1758,12 @@ func (r *frontendRouter) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
+	if _, exists := builtParams[entities.UserEmail]; exists {
+		r.logger.Debug("user's email cannot be updated without confirmation")
+		r.renderPage(ctx, profilePage, http.StatusBadRequest, nil, "User's email can only be changed by confirming the new email")
+		return
+	}
+
 	err = r.userService.UpdateUserWithID(ctx, userID, builtParams)
 	if err != nil {
 		switch err {
@@ -466,6 +1779,16 @@ func (r *frontendRouter) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
+	updatedValues := entities.AuditValues{}
+	for field, value := range updatedFields {
+		updatedValues[string(field)] = value
+	}
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionUserUpdated,
+		Target: userID,
+		After:  updatedValues,
+	})
+
 	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
 }
 
@@ -509,3 +1832,285 @@ func (r *frontendRouter) VerifyEmailResend(ctx *gin.Context) {
 
 	r.renderPage(ctx, verifyEmailResendPage, http.StatusOK, nil, "")
 }
+
+func (r *frontendRouter) MatchmakingPage(ctx *gin.Context) {
+	r.renderPage(ctx, matchmakingPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) SetMatchmakingProfile(ctx *gin.Context) {
+	userId, ok := r.getCurrentUserId(ctx, matchmakingPage)
+	if !ok {
+		return
+	}
+
+	_, err := r.matchmakingService.SetProfileForUserWithID(ctx, userId.Hex(), strings.Split(ctx.PostForm("skills"), ","),
+		strings.Split(ctx.PostForm("interests"), ","), ctx.PostForm("pitch"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidMatchmakingProfile:
+			r.logger.Debug("invalid matchmaking profile", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, matchmakingPage, http.StatusBadRequest, nil, "Please list at least one skill and keep your profile short")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user is already in team", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, matchmakingPage, http.StatusBadRequest, nil, "You are already in a team")
+		default:
+			r.logger.Error("could not set matchmaking profile", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, matchmakingPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, matchmakingPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) DeleteMatchmakingProfile(ctx *gin.Context) {
+	userId, ok := r.getCurrentUserId(ctx, matchmakingPage)
+	if !ok {
+		return
+	}
+
+	err := r.matchmakingService.DeleteProfileForUserWithID(ctx, userId.Hex())
+	if err != nil && errors.Cause(err) != services.ErrNotFound {
+		r.logger.Error("could not delete matchmaking profile", zap.String("userId", userId.Hex()), zap.Error(err))
+		r.renderPage(ctx, matchmakingPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, matchmakingPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) AcceptTeamMatch(ctx *gin.Context) {
+	userId, ok := r.getCurrentUserId(ctx, matchmakingPage)
+	if !ok {
+		return
+	}
+
+	request, err := r.matchmakingService.AcceptTeamMatchForUserWithID(ctx, userId.Hex(), ctx.Param("id"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID, services.ErrNotFound:
+			r.logger.Debug("advertised team not found", zap.String("teamId", ctx.Param("id")), zap.Error(err))
+			r.renderPage(ctx, matchmakingPage, http.StatusNotFound, nil, "The team is not looking for members anymore")
+		case services.ErrUserInTeam:
+			r.logger.Debug("user is already in team", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, matchmakingPage, http.StatusBadRequest, nil, "You are already in a team")
+		case services.ErrTeamFull:
+			r.logger.Debug("team is full", zap.String("teamId", ctx.Param("id")))
+			r.renderPage(ctx, matchmakingPage, http.StatusBadRequest, nil, "The team is full")
+		case services.ErrJoinRequestExists:
+			r.logger.Debug("user has already requested to join team", zap.String("userId", userId.Hex()))
+			r.renderPage(ctx, matchmakingPage, http.StatusBadRequest, nil, "You have already requested to join this team")
+		default:
+			r.logger.Error("could not accept team match", zap.String("userId", userId.Hex()), zap.Error(err))
+			r.renderPage(ctx, matchmakingPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	if request != nil {
+		r.renderPage(ctx, matchmakingPage, http.StatusOK, nil, "Your request to join the team has been sent to its creator")
+		return
+	}
+
+	r.renderPage(ctx, profilePage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) SetTeamAdvert(ctx *gin.Context) {
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, matchmakingPage, "Only the team's creator can advertise it")
+	if !ok {
+		return
+	}
+
+	_, err := r.matchmakingService.SetAdvertForTeamWithID(ctx, team.ID.Hex(), strings.Split(ctx.PostForm("skills"), ","), ctx.PostForm("pitch"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidTeamAdvert:
+			r.logger.Debug("invalid team advert", zap.String("teamId", team.ID.Hex()))
+			r.renderPage(ctx, matchmakingPage, http.StatusBadRequest, nil, "Please list at least one skill and keep your advert short")
+		case services.ErrTeamFull:
+			r.logger.Debug("team is full", zap.String("teamId", team.ID.Hex()))
+			r.renderPage(ctx, matchmakingPage, http.StatusBadRequest, nil, "Your team is full")
+		default:
+			r.logger.Error("could not set team advert", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+			r.renderPage(ctx, matchmakingPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return
+	}
+
+	r.renderPage(ctx, matchmakingPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) DeleteTeamAdvert(ctx *gin.Context) {
+	team, ok := r.getTeamCreatedByCurrentUser(ctx, matchmakingPage, "Only the team's creator can advertise it")
+	if !ok {
+		return
+	}
+
+	err := r.matchmakingService.DeleteAdvertForTeamWithID(ctx, team.ID.Hex())
+	if err != nil && errors.Cause(err) != services.ErrNotFound {
+		r.logger.Error("could not delete team advert", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, matchmakingPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, matchmakingPage, http.StatusOK, nil, "")
+}
+
+// getCurrentUserId returns the id of the user making the request.
+// Renders the given page with the appropriate alert and returns false when it cannot be extracted
+func (r *frontendRouter) getCurrentUserId(ctx *gin.Context, page frontendPage) (primitive.ObjectID, bool) {
+	userId, err := r.authorizer.GetUserIdFromToken(r.GetAuthToken(ctx))
+	if err != nil {
+		switch errors.Cause(err) {
+		case authCommon.ErrInvalidToken:
+			r.logger.Debug("invalid token", zap.Error(err))
+			r.HandleUnauthorized(ctx)
+		default:
+			r.logger.Error("could not extract user id from token", zap.Error(err))
+			r.renderPage(ctx, page, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return primitive.ObjectID{}, false
+	}
+
+	return userId, true
+}
+
+func (r *frontendRouter) UsersDashboardPage(ctx *gin.Context) {
+	r.renderPage(ctx, usersDashboardPage, http.StatusOK, nil, "")
+}
+
+// getUserForDashboardAction fetches the user the users dashboard action is performed on.
+// The users dashboard is rendered with the appropriate alert and false returned when the user cannot be fetched
+func (r *frontendRouter) getUserForDashboardAction(ctx *gin.Context) (*entities.User, bool) {
+	userId := ctx.Param("id")
+	user, err := r.userService.GetUserWithID(ctx, userId)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidID:
+			r.logger.Debug("invalid user id", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, usersDashboardPage, http.StatusBadRequest, nil, "Invalid user id")
+		case services.ErrNotFound:
+			r.logger.Debug("user not found", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, usersDashboardPage, http.StatusNotFound, nil, "User not found")
+		default:
+			r.logger.Error("could not fetch user", zap.String("userId", userId), zap.Error(err))
+			r.renderPage(ctx, usersDashboardPage, http.StatusInternalServerError, nil, "Something went wrong")
+		}
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (r *frontendRouter) SetUserRole(ctx *gin.Context) {
+	var userRole role.UserRole
+	err := json.Unmarshal([]byte(strconv.Quote(ctx.PostForm("role"))), &userRole)
+	if err != nil {
+		r.logger.Debug("invalid role", zap.Error(err))
+		r.renderPage(ctx, usersDashboardPage, http.StatusBadRequest, nil, "Role does not exist")
+		return
+	}
+
+	user, ok := r.getUserForDashboardAction(ctx)
+	if !ok {
+		return
+	}
+
+	err = r.userService.UpdateUserWithID(ctx, user.ID.Hex(), services.UserUpdateParams{
+		entities.UserRole: userRole,
+	})
+	if err != nil {
+		r.logger.Error("could not set user's role", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, usersDashboardPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionRoleSet,
+		Target: user.ID.Hex(),
+		Before: entities.AuditValues{string(entities.UserRole): user.Role},
+		After:  entities.AuditValues{string(entities.UserRole): userRole},
+	})
+
+	r.renderPage(ctx, usersDashboardPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) SetUserSpecialPermissions(ctx *gin.Context) {
+	// the permissions are submitted one per line, an empty list removes all of the user's special permissions
+	permissions := authCommon.UniformResourceIdentifiers{}
+	for _, line := range strings.Split(ctx.PostForm("permissions"), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		uri, err := authCommon.NewURIFromString(line)
+		if err != nil {
+			r.logger.Debug("invalid special permission", zap.String("permission", line), zap.Error(err))
+			r.renderPage(ctx, usersDashboardPage, http.StatusBadRequest, nil, "Invalid permission: "+line)
+			return
+		}
+		permissions = append(permissions, uri)
+	}
+
+	user, ok := r.getUserForDashboardAction(ctx)
+	if !ok {
+		return
+	}
+
+	err := r.userService.UpdateUserWithID(ctx, user.ID.Hex(), services.UserUpdateParams{
+		entities.UserSpecialPermissions: permissions,
+	})
+	if err != nil {
+		r.logger.Error("could not set user's special permissions", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, usersDashboardPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	common.LogAuditEvent(ctx, r.logger, r.auditService, entities.AuditEvent{
+		Action: entities.AuditActionSpecialPermissionsSet,
+		Target: user.ID.Hex(),
+		Before: entities.AuditValues{string(entities.UserSpecialPermissions): user.SpecialPermissions},
+		After:  entities.AuditValues{string(entities.UserSpecialPermissions): permissions},
+	})
+
+	r.renderPage(ctx, usersDashboardPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) SendUserPasswordReset(ctx *gin.Context) {
+	user, ok := r.getUserForDashboardAction(ctx)
+	if !ok {
+		return
+	}
+
+	err := r.emailServiceV2.SendPasswordResetEmail(ctx, *user, common.MakePasswordResetURIs(*user))
+	if err != nil {
+		r.logger.Error("could not send password reset email", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, usersDashboardPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, usersDashboardPage, http.StatusOK, nil, "")
+}
+
+func (r *frontendRouter) ResendUserEmailVerification(ctx *gin.Context) {
+	user, ok := r.getUserForDashboardAction(ctx)
+	if !ok {
+		return
+	}
+
+	if user.Role != role.Unverified {
+		r.logger.Debug("user's email is already verified", zap.String("userId", user.ID.Hex()))
+		r.renderPage(ctx, usersDashboardPage, http.StatusBadRequest, nil, "The user's email is already verified")
+		return
+	}
+
+	err := r.emailServiceV2.SendEmailVerificationEmail(ctx, *user, common.MakeEmailVerificationURIs(*user))
+	if err != nil {
+		r.logger.Error("could not send email verification email", zap.String("userId", user.ID.Hex()), zap.Error(err))
+		r.renderPage(ctx, usersDashboardPage, http.StatusInternalServerError, nil, "Something went wrong")
+		return
+	}
+
+	r.renderPage(ctx, usersDashboardPage, http.StatusOK, nil, "")
+}
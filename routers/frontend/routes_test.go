@@ -3,12 +3,14 @@ package frontend
 import (
 	"errors"
 	"fmt"
+	authV2 "github.com/unicsmcr/hs_auth/authorization/v2"
 	authCommon "github.com/unicsmcr/hs_auth/authorization/v2/common"
 	"github.com/unicsmcr/hs_auth/config/role"
 	_ "github.com/unicsmcr/hs_auth/config/role"
 	mock_v2 "github.com/unicsmcr/hs_auth/mocks/authorization/v2"
 	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
 	rcommon "github.com/unicsmcr/hs_auth/routers/common"
+	"github.com/unicsmcr/hs_auth/utils"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -29,13 +31,30 @@ import (
 )
 
 var testUserId = primitive.NewObjectID()
+var testTeamId = primitive.NewObjectID()
 var emailVerificationURIs = rcommon.MakeEmailVerificationURIs(entities.User{ID: testUserId})
 var passwordResetURIs = rcommon.MakePasswordResetURIs(entities.User{ID: testUserId})
+var loginLinkURIs = rcommon.MakeLoginLinkURIs(entities.User{ID: testUserId})
+var twoFactorLoginURIs = []authCommon.UniformResourceIdentifier(rcommon.MakeTwoFactorLoginURIs(entities.User{ID: testUserId}))
+var twoFactorEnrollmentURIs = []authCommon.UniformResourceIdentifier(rcommon.MakeTwoFactorEnrollmentURIs(entities.User{ID: testUserId}))
+var testPasskeyAuthenticator = testutils.NewWebAuthnAuthenticator()
+var testPasskeyAssertion = testPasskeyAuthenticator.GetAssertion("localhost", "http://localhost:8000",
+	[]byte("challenge"), testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified, nil)
+var testPasskeyAttestation = testPasskeyAuthenticator.MakeCredential("localhost", "http://localhost:8000",
+	[]byte("challenge"), testutils.WebAuthnFlagUserPresent)
 
 type testSetup struct {
 	mockUService     *mock_services.MockUserService
 	mockEServiceV2   *mock_services.MockEmailServiceV2
 	mockTService     *mock_services.MockTeamService
+	mockAService     *mock_services.MockAuditService
+	mockLService     *mock_services.MockLoginAttemptService
+	mockTFService    *mock_services.MockTwoFactorService
+	mockWAService    *mock_services.MockWebAuthnService
+	mockEIService    *mock_services.MockExternalIdentityService
+	mockIService     *mock_services.MockInvitationService
+	mockJRService    *mock_services.MockTeamJoinRequestService
+	mockMMService    *mock_services.MockMatchmakingService
 	mockAuthorizer   *mock_v2.MockAuthorizer
 	mockTimeProvider *mock_utils.MockTimeProvider
 	env              *environment.Env
@@ -54,6 +73,14 @@ func setupTest(t *testing.T, envVars map[string]string) *testSetup {
 	mockUService := mock_services.NewMockUserService(ctrl)
 	mockEServiceV2 := mock_services.NewMockEmailServiceV2(ctrl)
 	mockTService := mock_services.NewMockTeamService(ctrl)
+	mockAService := mock_services.NewMockAuditService(ctrl)
+	mockLService := mock_services.NewMockLoginAttemptService(ctrl)
+	mockTFService := mock_services.NewMockTwoFactorService(ctrl)
+	mockWAService := mock_services.NewMockWebAuthnService(ctrl)
+	mockEIService := mock_services.NewMockExternalIdentityService(ctrl)
+	mockIService := mock_services.NewMockInvitationService(ctrl)
+	mockJRService := mock_services.NewMockTeamJoinRequestService(ctrl)
+	mockMMService := mock_services.NewMockMatchmakingService(ctrl)
 	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
 	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
 
@@ -63,19 +90,28 @@ func setupTest(t *testing.T, envVars map[string]string) *testSetup {
 
 	cfg := &config.AppConfig{
 		Auth: config.AuthConfig{
-			UserTokenLifetime: 1000,
+			UserTokenLifetime:          1000,
+			TwoFactorChallengeLifetime: 100,
 		},
 	}
 
 	router := frontendRouter{
-		logger:         zap.NewNop(),
-		cfg:            cfg,
-		env:            env,
-		userService:    mockUService,
-		teamService:    mockTService,
-		emailServiceV2: mockEServiceV2,
-		authorizer:     mockAuthorizer,
-		timeProvider:   mockTimeProvider,
+		logger:                  zap.NewNop(),
+		cfg:                     cfg,
+		env:                     env,
+		userService:             mockUService,
+		teamService:             mockTService,
+		emailServiceV2:          mockEServiceV2,
+		auditService:            mockAService,
+		loginAttemptService:     mockLService,
+		twoFactorService:        mockTFService,
+		webAuthnService:         mockWAService,
+		externalIdentityService: mockEIService,
+		invitationService:       mockIService,
+		joinRequestService:      mockJRService,
+		matchmakingService:      mockMMService,
+		authorizer:              mockAuthorizer,
+		timeProvider:            mockTimeProvider,
 	}
 
 	testUser := entities.User{
@@ -93,6 +129,14 @@ func setupTest(t *testing.T, envVars map[string]string) *testSetup {
 		mockUService:     mockUService,
 		mockEServiceV2:   mockEServiceV2,
 		mockTService:     mockTService,
+		mockAService:     mockAService,
+		mockLService:     mockLService,
+		mockTFService:    mockTFService,
+		mockWAService:    mockWAService,
+		mockEIService:    mockEIService,
+		mockIService:     mockIService,
+		mockJRService:    mockJRService,
+		mockMMService:    mockMMService,
 		mockAuthorizer:   mockAuthorizer,
 		mockTimeProvider: mockTimeProvider,
 		env:              env,
@@ -143,14 +187,51 @@ func Test_Login(t *testing.T) {
 			email:       "test@email.com",
 			wantResCode: http.StatusBadRequest,
 		},
+		{
+			name:     "should return 429 when login is throttled",
+			email:    "test@email.com",
+			password: "testpassword",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(2*time.Second, services.ErrLoginThrottled).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name:     "should return 429 when login is locked",
+			email:    "test@email.com",
+			password: "testpassword",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Minute, services.ErrLoginLocked).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name:     "should return 500 when CheckLoginAllowed returns unknown error",
+			email:    "test@email.com",
+			password: "testpassword",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
 		{
 			name:     "should return 404 when GetUserWithEmailAndPwd returns ErrNotFound",
 			email:    "test@email.com",
 			password: "testpassword",
 			prep: func(setup *testSetup) {
 				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
 					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				setup.mockLService.EXPECT().RecordFailedLogin(gomock.Any(), "test@email.com", gomock.Any()).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusNotFound,
 		},
@@ -160,6 +241,8 @@ func Test_Login(t *testing.T) {
 			password: "testpassword",
 			prep: func(setup *testSetup) {
 				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
 					Return(nil, errors.New("service err")).Times(1)
 			},
@@ -171,24 +254,101 @@ func Test_Login(t *testing.T) {
 			password: "testpassword",
 			prep: func(setup *testSetup) {
 				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
-					Return(&entities.User{ID: testUserId}, nil).Times(1)
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(gomock.Any()).Return(false).Times(1)
 				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
 				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
 					Return("", errors.New("authorizer err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
+		{
+			name:     "should return 200 and ask for two-factor code when two-factor authentication is enabled",
+			email:    "test@email.com",
+			password: "testpassword",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", TwoFactorEnabled: true}, nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateServiceToken(gomock.Any(), testUserId, twoFactorLoginURIs, int64(1100)).
+					Return("challengeToken", nil).Times(1)
+				setup.mockWAService.EXPECT().BeginLogin(gomock.Any(), testUserId.Hex()).
+					Return(&utils.WebAuthnRequestOptions{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:     "should return 500 when creating two-factor challenge token fails",
+			email:    "test@email.com",
+			password: "testpassword",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", TwoFactorEnabled: true}, nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateServiceToken(gomock.Any(), testUserId, twoFactorLoginURIs, int64(1100)).
+					Return("", errors.New("authorizer err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "should return 200 and ask to set up two-factor authentication when it is required",
+			email:    "test@email.com",
+			password: "testpassword",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", Role: role.Organiser}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Organiser).Return(true).Times(1)
+				setup.mockTFService.EXPECT().BeginEnrollment(gomock.Any(), testUserId.Hex()).
+					Return("secret", "otpauth://totp/test", nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateServiceToken(gomock.Any(), testUserId, twoFactorEnrollmentURIs, int64(1100)).
+					Return("challengeToken", nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:     "should return 500 when beginning required two-factor enrollment fails",
+			email:    "test@email.com",
+			password: "testpassword",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", Role: role.Organiser}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Organiser).Return(true).Times(1)
+				setup.mockTFService.EXPECT().BeginEnrollment(gomock.Any(), testUserId.Hex()).
+					Return("", "", errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
 		{
 			name:     "should return 200 when user's email is not verified",
 			email:    "test@email.com",
 			password: "testpassword",
 			prep: func(setup *testSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
-					Return(&entities.User{ID: testUserId, Role: role.Unverified}, nil).Times(1)
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", Role: role.Unverified}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Unverified).Return(false).Times(1)
 				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
 				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
 					Return("authToken", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -197,11 +357,3108 @@ func Test_Login(t *testing.T) {
 			email:    "test@email.com",
 			password: "testpassword",
 			prep: func(setup *testSetup) {
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
 				setup.mockUService.EXPECT().GetUserWithEmailAndPwd(gomock.Any(), "test@email.com", "testpassword").
-					Return(&entities.User{ID: testUserId, Role: role.Unverified}, nil).Times(1)
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", Role: role.Unverified}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Unverified).Return(false).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
+					Return("authToken", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"email":    tt.email,
+				"password": tt.password,
+			})
+			setup.router.Login(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_LoginTwoFactor(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		code        string
+		credential  string
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when neither code nor passkey is specified",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when user service returns ErrInvalidID",
+			code: "123456",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when user service returns ErrNotFound",
+			code: "123456",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when user service returns unknown error",
+			code: "123456",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 429 when login is locked",
+			code: "123456",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Minute, services.ErrLoginLocked).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "should return 401 and record failed login when code is invalid",
+			code: "123456",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(setup.testCtx, testUserId.Hex(), "123456").
+					Return(services.ErrInvalidTwoFactorCode).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				setup.mockLService.EXPECT().RecordFailedLogin(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 500 when two-factor service returns unknown error",
+			code: "123456",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(setup.testCtx, testUserId.Hex(), "123456").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 500 when authorizer fails to create token",
+			code: "123456",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
+					Return(nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
+					Return("", errors.New("authorizer err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			code: "123456",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
+					Return(nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
+					Return("authToken", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:       "should return 401 and record failed login when passkey is invalid",
+			credential: testPasskeyAssertion,
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockWAService.EXPECT().FinishLogin(setup.testCtx, testUserId.Hex(), gomock.Any()).
+					Return(nil, services.ErrInvalidWebAuthnCredential).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				setup.mockLService.EXPECT().RecordFailedLogin(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "should return 401 when passkey cannot be parsed",
+			credential: "not a credential",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				setup.mockLService.EXPECT().RecordFailedLogin(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "should return 200 when passkey is used instead of code",
+			credential: testPasskeyAssertion,
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockWAService.EXPECT().FinishLogin(setup.testCtx, testUserId.Hex(), gomock.Any()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
+					Return(nil).Times(1)
 				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
 				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
 					Return("authToken", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"userId":     testUserId.Hex(),
+				"code":       tt.code,
+				"credential": tt.credential,
+			})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.LoginTwoFactor(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_TwoFactorSetupPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when authorizer returns ErrInvalidTokenType",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidTokenType).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 500 when authorizer returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 400 when two-factor service returns ErrTwoFactorEnabled",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTFService.EXPECT().BeginEnrollment(setup.testCtx, testUserId.Hex()).
+					Return("", "", services.ErrTwoFactorEnabled).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when two-factor service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTFService.EXPECT().BeginEnrollment(setup.testCtx, testUserId.Hex()).
+					Return("", "", errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTFService.EXPECT().BeginEnrollment(setup.testCtx, testUserId.Hex()).
+					Return("secret", "otpauth://totp/test", nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, map[string]string{})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.TwoFactorSetupPage(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_EnableTwoFactor(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when authorizer returns ErrInvalidToken",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.TokenType(""), authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 401 when authorizer cannot extract user id from user token",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.User, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 400 when two-factor service returns ErrInvalidTwoFactorCode",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.User, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTFService.EXPECT().ConfirmEnrollment(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil, services.ErrInvalidTwoFactorCode).Times(1)
+				setup.mockTFService.EXPECT().BeginEnrollment(setup.testCtx, testUserId.Hex()).
+					Return("secret", "otpauth://totp/test", nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when two-factor service returns ErrTwoFactorEnabled",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.User, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTFService.EXPECT().ConfirmEnrollment(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil, services.ErrTwoFactorEnabled).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when two-factor service returns ErrTwoFactorNotEnrolling",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.User, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTFService.EXPECT().ConfirmEnrollment(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil, services.ErrTwoFactorNotEnrolling).Times(1)
+				setup.mockTFService.EXPECT().BeginEnrollment(setup.testCtx, testUserId.Hex()).
+					Return("secret", "otpauth://totp/test", nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when two-factor service returns ErrNotFound",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.Service, nil).Times(1)
+				setup.mockTFService.EXPECT().ConfirmEnrollment(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when two-factor service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.User, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTFService.EXPECT().ConfirmEnrollment(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 500 when user service returns error during login",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.Service, nil).Times(1)
+				setup.mockTFService.EXPECT().ConfirmEnrollment(setup.testCtx, testUserId.Hex(), "123456").
+					Return([]string{"recovery-code"}, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and log user in when enrollment is required to log in",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.Service, nil).Times(1)
+				setup.mockTFService.EXPECT().ConfirmEnrollment(setup.testCtx, testUserId.Hex(), "123456").
+					Return([]string{"recovery-code"}, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", Role: role.Organiser}, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
+					Return(nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
+					Return("authToken", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetTokenTypeFromToken(testAuthToken).
+					Return(authV2.User, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTFService.EXPECT().ConfirmEnrollment(setup.testCtx, testUserId.Hex(), "123456").
+					Return([]string{"recovery-code"}, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"userId": testUserId.Hex(),
+				"code":   "123456",
+			})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.EnableTwoFactor(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_DisableTwoFactor(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when authorizer returns ErrInvalidToken",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 500 when authorizer returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 404 when user service returns ErrNotFound",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when user service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 400 when two-factor authentication is required for user's role",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Role: role.Organiser}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Organiser).Return(true).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 401 when two-factor service returns ErrInvalidTwoFactorCode",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Role: role.Applicant}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Applicant).Return(false).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(setup.testCtx, testUserId.Hex(), "123456").
+					Return(services.ErrInvalidTwoFactorCode).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 400 when two-factor service returns ErrTwoFactorNotEnabled",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Role: role.Applicant}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Applicant).Return(false).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(setup.testCtx, testUserId.Hex(), "123456").
+					Return(services.ErrTwoFactorNotEnabled).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when two-factor service fails to disable two-factor authentication",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Role: role.Applicant}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Applicant).Return(false).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil).Times(1)
+				setup.mockTFService.EXPECT().Disable(setup.testCtx, testUserId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Role: role.Applicant}, nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Applicant).Return(false).Times(1)
+				setup.mockTFService.EXPECT().VerifyCode(setup.testCtx, testUserId.Hex(), "123456").
+					Return(nil).Times(1)
+				setup.mockTFService.EXPECT().Disable(setup.testCtx, testUserId.Hex()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"code": "123456",
+			})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.DisableTwoFactor(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_BeginPasskeyLogin(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 500 when webauthn service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockWAService.EXPECT().BeginLogin(setup.testCtx, "").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockWAService.EXPECT().BeginLogin(setup.testCtx, "").
+					Return(&utils.WebAuthnRequestOptions{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.testCtx.Request = httptest.NewRequest(http.MethodPost, "/webauthn/login/begin", nil)
+			setup.router.BeginPasskeyLogin(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_LoginWithPasskey(t *testing.T) {
+	tests := []struct {
+		name        string
+		credential  string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when credential cannot be parsed",
+			credential:  "not a credential",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 401 when webauthn service returns ErrInvalidWebAuthnCredential",
+			credential: testPasskeyAssertion,
+			prep: func(setup *testSetup) {
+				setup.mockWAService.EXPECT().FinishLogin(setup.testCtx, "", gomock.Any()).
+					Return(nil, services.ErrInvalidWebAuthnCredential).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "should return 500 when webauthn service returns unknown error",
+			credential: testPasskeyAssertion,
+			prep: func(setup *testSetup) {
+				setup.mockWAService.EXPECT().FinishLogin(setup.testCtx, "", gomock.Any()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "should return 429 when login is locked",
+			credential: testPasskeyAssertion,
+			prep: func(setup *testSetup) {
+				setup.mockWAService.EXPECT().FinishLogin(setup.testCtx, "", gomock.Any()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Minute, services.ErrLoginLocked).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name:       "should return 200",
+			credential: testPasskeyAssertion,
+			prep: func(setup *testSetup) {
+				setup.mockWAService.EXPECT().FinishLogin(setup.testCtx, "", gomock.Any()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
+					Return("authToken", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.wantResCode != http.StatusOK {
+				mockRenderPageCall(setup)
+			}
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"credential": tt.credential,
+			})
+			setup.router.LoginWithPasskey(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_BeginPasskeyRegistration(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when authorizer returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 500 when webauthn service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().BeginRegistration(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().BeginRegistration(setup.testCtx, testUserId.Hex()).
+					Return(&utils.WebAuthnCreationOptions{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.testCtx.Request = httptest.NewRequest(http.MethodPost, "/webauthn/register/begin", nil)
+			attachAuthCookie(setup.testCtx)
+			setup.router.BeginPasskeyRegistration(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_RegisterPasskey(t *testing.T) {
+	tests := []struct {
+		name        string
+		credential  string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name:       "should return 401 when authorizer returns error",
+			credential: testPasskeyAttestation,
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "should return 400 when credential cannot be parsed",
+			credential: "not a credential",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 400 when webauthn service returns ErrInvalidWebAuthnCredential",
+			credential: testPasskeyAttestation,
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishRegistration(setup.testCtx, testUserId.Hex(), "My key", gomock.Any()).
+					Return(nil, services.ErrInvalidWebAuthnCredential).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 400 when webauthn service returns ErrWebAuthnCredentialExists",
+			credential: testPasskeyAttestation,
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishRegistration(setup.testCtx, testUserId.Hex(), "My key", gomock.Any()).
+					Return(nil, services.ErrWebAuthnCredentialExists).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:       "should return 500 when webauthn service returns unknown error",
+			credential: testPasskeyAttestation,
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishRegistration(setup.testCtx, testUserId.Hex(), "My key", gomock.Any()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "should return 200",
+			credential: testPasskeyAttestation,
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().FinishRegistration(setup.testCtx, testUserId.Hex(), "My key", gomock.Any()).
+					Return(&entities.WebAuthnCredential{ID: primitive.NewObjectID(), Name: "My key"}, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"name":       "My key",
+				"credential": tt.credential,
+			})
+			attachAuthCookie(setup.testCtx)
+			setup.router.RegisterPasskey(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_DeletePasskey(t *testing.T) {
+	testCredentialId := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when authorizer returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 404 when webauthn service returns ErrNotFound",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialForUser(setup.testCtx, testUserId.Hex(), testCredentialId).
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when webauthn service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialForUser(setup.testCtx, testUserId.Hex(), testCredentialId).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockWAService.EXPECT().DeleteCredentialForUser(setup.testCtx, testUserId.Hex(), testCredentialId).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"credentialId": testCredentialId,
+			})
+			attachAuthCookie(setup.testCtx)
+			setup.router.DeletePasskey(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_Register(t *testing.T) {
+	tests := []struct {
+		name            string
+		prep            func(*testSetup)
+		userName        string
+		email           string
+		password        string
+		passwordConfirm string
+		invite          string
+		wantResCode     int
+	}{
+		{
+			name:            "should return 400 when email not specified",
+			password:        "testtest",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			wantResCode:     http.StatusBadRequest,
+		},
+		{
+			name:            "should return 400 when name not specified",
+			password:        "testtest",
+			passwordConfirm: "testtest",
+			email:           "bob@test.com",
+			wantResCode:     http.StatusBadRequest,
+		},
+		{
+			name:            "should return 400 when password not specified",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			email:           "bob@test.com",
+			wantResCode:     http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when password is too short",
+			userName:    "bob",
+			password:    "test",
+			email:       "bob@test.com",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 400 when password is too long",
+			userName: "bob",
+			password: "testtesttesttesttesttesttesttesttesttest" +
+				"testtesttesttesttesttesttesttesttesttesttesttesttesttesttesttesttesttests",
+			email:       "bob@test.com",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:            "should return 400 when password does not match passwordConfirm",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			password:        "testtest2",
+			email:           "bob@test.com",
+			wantResCode:     http.StatusBadRequest,
+		},
+		{
+			name:            "should return 400 when CreateUser returns ErrEmailTaken",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			email:           "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", setup.cfg.Auth.DefaultRole).
+					Return(nil, services.ErrEmailTaken).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:            "should return 400 when CreateUser rejects password",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			email:           "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", setup.cfg.Auth.DefaultRole).
+					Return(nil, services.ErrPasswordBreached).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:            "should return 500 when CreateUser returns unknown error",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			email:           "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", gomock.Any()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:            "should return 200 even if SendEmailVerificationEmail returns error",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			email:           "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", gomock.Any()).
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendEmailVerificationEmail(setup.testCtx, entities.User{ID: testUserId}, emailVerificationURIs).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:            "should return 400 when invitation is not usable",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			email:           "bob@test.com",
+			invite:          "invite",
+			prep: func(setup *testSetup) {
+				setup.mockIService.EXPECT().UseInvitationWithCode(setup.testCtx, "invite").
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:            "should release invitation use when CreateUser returns error",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			email:           "bob@test.com",
+			invite:          "invite",
+			prep: func(setup *testSetup) {
+				setup.mockIService.EXPECT().UseInvitationWithCode(setup.testCtx, "invite").
+					Return(&entities.Invitation{ID: testTeamId, Role: role.Volunteer}, nil).Times(1)
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", role.Volunteer).
+					Return(nil, services.ErrEmailTaken).Times(1)
+				setup.mockIService.EXPECT().ReleaseInvitationUse(setup.testCtx, testTeamId.Hex()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:            "should return 200 and skip email verification when registering with invitation",
+			userName:        "bob",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			email:           "bob@test.com",
+			invite:          "invite",
+			prep: func(setup *testSetup) {
+				setup.mockIService.EXPECT().UseInvitationWithCode(setup.testCtx, "invite").
+					Return(&entities.Invitation{Role: role.Volunteer, Team: testTeamId}, nil).Times(1)
+				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", role.Volunteer).
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"name":            tt.userName,
+				"email":           tt.email,
+				"password":        tt.password,
+				"passwordConfirm": tt.passwordConfirm,
+				"invite":          tt.invite,
+			})
+			setup.router.Register(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_RegisterPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		invite      string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name:        "should return 200 when no invitation is given",
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:   "should return 404 when invitation is not usable",
+			invite: "invite",
+			prep: func(setup *testSetup) {
+				setup.mockIService.EXPECT().GetInvitationWithCode(setup.testCtx, "invite").
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 500 when invitation service returns unknown error",
+			invite: "invite",
+			prep: func(setup *testSetup) {
+				setup.mockIService.EXPECT().GetInvitationWithCode(setup.testCtx, "invite").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 200 when invitation is usable",
+			invite: "invite",
+			prep: func(setup *testSetup) {
+				setup.mockIService.EXPECT().GetInvitationWithCode(setup.testCtx, "invite").
+					Return(&entities.Invitation{Role: role.Volunteer}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/register?invite="+tt.invite, nil)
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.router.RegisterPage(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_ForgotPassword(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		email       string
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when email not specified",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 200 when GetUserWithEmail returns ErrNotFound",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:  "should return 500 when GetUserWithEmail returns unknown error",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 500 when SendPasswordResetEmail returns unknown error",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendPasswordResetEmail(setup.testCtx, entities.User{ID: testUserId}, passwordResetURIs).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 200",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendPasswordResetEmail(setup.testCtx, entities.User{ID: testUserId}, passwordResetURIs).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"email": tt.email,
+			})
+			setup.router.ForgotPassword(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_SendLoginLink(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		email       string
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when email not specified",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 429 when login link was requested recently",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockLService.EXPECT().RecordLoginLinkRequest(setup.testCtx, "bob@test.com").
+					Return(30*time.Second, services.ErrLoginLinkSent).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name:  "should return 500 when RecordLoginLinkRequest returns unknown error",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockLService.EXPECT().RecordLoginLinkRequest(setup.testCtx, "bob@test.com").
+					Return(time.Duration(0), errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 200 when GetUserWithEmail returns ErrNotFound",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockLService.EXPECT().RecordLoginLinkRequest(setup.testCtx, "bob@test.com").
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:  "should return 500 when GetUserWithEmail returns unknown error",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockLService.EXPECT().RecordLoginLinkRequest(setup.testCtx, "bob@test.com").
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 500 when SendLoginLinkEmail returns unknown error",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockLService.EXPECT().RecordLoginLinkRequest(setup.testCtx, "bob@test.com").
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendLoginLinkEmail(setup.testCtx, entities.User{ID: testUserId}, loginLinkURIs).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 200",
+			email: "bob@test.com",
+			prep: func(setup *testSetup) {
+				setup.mockLService.EXPECT().RecordLoginLinkRequest(setup.testCtx, "bob@test.com").
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendLoginLinkEmail(setup.testCtx, entities.User{ID: testUserId}, loginLinkURIs).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"email": tt.email,
+			})
+			setup.router.SendLoginLink(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_LoginWithLink(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when link has already been used",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, "linkToken").
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 500 when authorizer fails to invalidate token",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, "linkToken").
+					Return(errors.New("authorizer err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 404 when user service returns ErrNotFound",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, "linkToken").
+					Return(nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when user service returns unknown error",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, "linkToken").
+					Return(nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 429 when login is locked",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, "linkToken").
+					Return(nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Minute, services.ErrLoginLocked).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "should return 200 and ask for two-factor code when two-factor authentication is enabled",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, "linkToken").
+					Return(nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", TwoFactorEnabled: true}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateServiceToken(gomock.Any(), testUserId, twoFactorLoginURIs, int64(1100)).
+					Return("challengeToken", nil).Times(1)
+				setup.mockWAService.EXPECT().BeginLogin(gomock.Any(), testUserId.Hex()).
+					Return(&utils.WebAuthnRequestOptions{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name: "should log user in and redirect",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, "linkToken").
+					Return(nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", Role: role.Applicant}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Applicant).Return(false).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
+					Return("authToken", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusMovedPermanently,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/test?token=linkToken&userId=%s", testUserId.Hex()), nil)
+			setup.testCtx.Request = req
+
+			setup.router.LoginWithLink(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_LoginWithOAuth(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+		wantCookie  bool
+	}{
+		{
+			name: "should return 404 when provider is unknown",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().GetAuthCodeURL("github", gomock.Any()).
+					Return("", services.ErrUnknownOAuthProvider).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when external identity service returns unknown error",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().GetAuthCodeURL("github", gomock.Any()).
+					Return("", errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should set state cookie and redirect to provider",
+			prep: func(setup *testSetup) {
+				setup.mockEIService.EXPECT().GetAuthCodeURL("github", gomock.Any()).
+					Return("https://github.com/login/oauth/authorize", nil).Times(1)
+			},
+			wantResCode: http.StatusFound,
+			wantCookie:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+			setup.testCtx.Params = gin.Params{{Key: "provider", Value: "github"}}
+
+			setup.router.LoginWithOAuth(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+			if tt.wantCookie {
+				assert.Equal(t, "https://github.com/login/oauth/authorize", setup.w.Header().Get("Location"))
+				assert.Contains(t, setup.w.Header().Get("Set-Cookie"), oauthStateCookie+"="+oauthPurposeLogin)
+			}
+		})
+	}
+}
+
+func Test_LinkOAuthAccount__should_set_state_cookie_and_redirect_to_provider(t *testing.T) {
+	setup := setupTest(t, nil)
+	defer setup.ctrl.Finish()
+
+	setup.mockEIService.EXPECT().GetAuthCodeURL("github", gomock.Any()).
+		Return("https://github.com/login/oauth/authorize", nil).Times(1)
+
+	attachAuthCookie(setup.testCtx)
+	setup.testCtx.Params = gin.Params{{Key: "provider", Value: "github"}}
+
+	setup.router.LinkOAuthAccount(setup.testCtx)
+
+	assert.Equal(t, http.StatusFound, setup.w.Code)
+	assert.Contains(t, setup.w.Header().Get("Set-Cookie"), oauthStateCookie+"="+oauthPurposeLink)
+}
+
+func Test_OAuthCallback(t *testing.T) {
+	testExternalUser := services.ExternalUser{ID: "42", Email: "john@github.com", Name: "john"}
+
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		stateCookie string
+		query       string
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when state cookie is not set",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+			},
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when state does not match",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+			},
+			stateCookie: "login:otherstate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 401 when user did not give consent",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+			},
+			stateCookie: "login:teststate",
+			query:       "state=teststate&error=access_denied",
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 401 when code is rejected",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().Authenticate(setup.testCtx, "github", "testcode").
+					Return(nil, services.ErrInvalidOAuthCode).Times(1)
+			},
+			stateCookie: "login:teststate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 500 when external identity service returns unknown error",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().Authenticate(setup.testCtx, "github", "testcode").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			stateCookie: "login:teststate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 404 when account is not linked to a user",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().Authenticate(setup.testCtx, "github", "testcode").
+					Return(&testExternalUser, nil).Times(1)
+				setup.mockEIService.EXPECT().GetUserWithIdentity(setup.testCtx, "github", "42").
+					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			stateCookie: "login:teststate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 429 when login is locked",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().Authenticate(setup.testCtx, "github", "testcode").
+					Return(&testExternalUser, nil).Times(1)
+				setup.mockEIService.EXPECT().GetUserWithIdentity(setup.testCtx, "github", "42").
+					Return(&entities.User{ID: testUserId, Email: "test@email.com"}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Minute, services.ErrLoginLocked).Times(1)
+			},
+			stateCookie: "login:teststate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "should log user in and redirect",
+			prep: func(setup *testSetup) {
+				setup.mockEIService.EXPECT().Authenticate(setup.testCtx, "github", "testcode").
+					Return(&testExternalUser, nil).Times(1)
+				setup.mockEIService.EXPECT().GetUserWithIdentity(setup.testCtx, "github", "42").
+					Return(&entities.User{ID: testUserId, Email: "test@email.com", Role: role.Applicant}, nil).Times(1)
+				setup.mockLService.EXPECT().CheckLoginAllowed(gomock.Any(), "test@email.com", gomock.Any()).
+					Return(time.Duration(0), nil).Times(1)
+				setup.mockTFService.EXPECT().IsRequiredForRole(role.Applicant).Return(false).Times(1)
+				setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+				setup.mockAuthorizer.EXPECT().CreateUserToken(testUserId, setup.cfg.Auth.UserTokenLifetime+1000).
+					Return("authToken", nil).Times(1)
+				setup.mockLService.EXPECT().RecordSuccessfulLogin(gomock.Any(), "test@email.com").Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			stateCookie: "login:teststate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusMovedPermanently,
+		},
+		{
+			name: "should return 401 when linking without being logged in",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().Authenticate(setup.testCtx, "github", "testcode").
+					Return(&testExternalUser, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			stateCookie: "link:teststate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 400 when account is already linked",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().Authenticate(setup.testCtx, "github", "testcode").
+					Return(&testExternalUser, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).
+					Return(testUserId, nil).Times(1)
+				setup.mockEIService.EXPECT().LinkIdentity(setup.testCtx, testUserId.Hex(), "github", testExternalUser).
+					Return(nil, services.ErrExternalIdentityLinked).Times(1)
+			},
+			stateCookie: "link:teststate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should link account and return 200",
+			prep: func(setup *testSetup) {
+				mockRenderPageCall(setup)
+				setup.mockEIService.EXPECT().Authenticate(setup.testCtx, "github", "testcode").
+					Return(&testExternalUser, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(gomock.Any()).
+					Return(testUserId, nil).Times(1)
+				setup.mockEIService.EXPECT().LinkIdentity(setup.testCtx, testUserId.Hex(), "github", testExternalUser).
+					Return(&entities.ExternalIdentity{Provider: "github", ExternalID: "42"}, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			stateCookie: "link:teststate",
+			query:       "state=teststate&code=testcode",
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.testCtx.Request = httptest.NewRequest(http.MethodGet, "/test?"+tt.query, nil)
+			if len(tt.stateCookie) > 0 {
+				setup.testCtx.Request.AddCookie(&http.Cookie{
+					Name:  oauthStateCookie,
+					Value: tt.stateCookie,
+				})
+			}
+			attachAuthCookie(setup.testCtx)
+			setup.testCtx.Params = gin.Params{{Key: "provider", Value: "github"}}
+
+			setup.router.OAuthCallback(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_UnlinkOAuthAccount(t *testing.T) {
+	testIdentityId := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when authorizer returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 404 when external identity service returns ErrNotFound",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentityForUser(setup.testCtx, testUserId.Hex(), testIdentityId).
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when external identity service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentityForUser(setup.testCtx, testUserId.Hex(), testIdentityId).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockEIService.EXPECT().UnlinkIdentityForUser(setup.testCtx, testUserId.Hex(), testIdentityId).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"identityId": testIdentityId,
+			})
+			attachAuthCookie(setup.testCtx)
+			setup.router.UnlinkOAuthAccount(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_ResetPasswordPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		jwt         string
+		wantResCode int
+	}{
+		{
+			name:        "should return 200",
+			jwt:         testAuthToken,
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			setup.testCtx.Request, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/test?token=%s", tt.jwt), nil)
+			setup.router.ResetPasswordPage(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name            string
+		prep            func(*testSetup)
+		password        string
+		passwordConfirm string
+		jwt             string
+		userId          string
+		wantResCode     int
+	}{
+		{
+			name:            "should return 400 when password not specified",
+			passwordConfirm: "testtest",
+			wantResCode:     http.StatusBadRequest,
+		},
+		{
+			name:            "should return 400 when password does not match passwordConfirm",
+			passwordConfirm: "testtest",
+			password:        "testtest2",
+			wantResCode:     http.StatusBadRequest,
+		},
+		{
+			name:            "should return 400 when user service returns ErrInvalidID",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			jwt:             testAuthToken,
+			userId:          testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "testtest").
+					Return(services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:            "should return 400 when password is rejected by the password policy",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			jwt:             testAuthToken,
+			userId:          testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "testtest").
+					Return(services.ErrPasswordMissingCharacterClass).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:            "should return 404 when user service returns ErrNotFound",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			jwt:             testAuthToken,
+			userId:          testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "testtest").
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:            "should return 500 when user service returns unknown error",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			jwt:             testAuthToken,
+			userId:          testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "testtest").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:            "should return 500 when user service returns unknown error",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			jwt:             testAuthToken,
+			userId:          testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "testtest").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:            "should return 200 when authorizer fails to invalidate token",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			jwt:             testAuthToken,
+			userId:          testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "testtest").
+					Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, gomock.Any()).
+					Return(authCommon.ErrInvalidTokenType).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:            "should return 200",
+			passwordConfirm: "testtest",
+			password:        "testtest",
+			jwt:             testAuthToken,
+			userId:          testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().SetPasswordForUserWithID(setup.testCtx, testUserId.Hex(), "testtest").
+					Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, gomock.Any()).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"password":        tt.password,
+				"passwordConfirm": tt.passwordConfirm,
+				"userId":          tt.userId,
+			})
+			setup.router.ResetPassword(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		jwt         string
+		userId      string
+		wantResCode int
+	}{
+		{
+			name:   "should return 400 when user service returns ErrInvalidID",
+			userId: testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 404 when user service returns ErrNotFound",
+			userId: testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:   "should return 500 when user service returns unknown error",
+			userId: testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 400 when user's email is already verified",
+			userId: testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{Role: role.Applicant}, nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 500 when updating user fails",
+			userId: testUserId.Hex(),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Role: role.Unverified}, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "should return 200 when invalidating service token fails",
+			userId: testUserId.Hex(),
+			jwt:    testAuthToken,
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Role: role.Unverified}, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+					Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
+					Return(errors.New("authorizer err")).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:   "should return 200",
+			userId: testUserId.Hex(),
+			jwt:    testAuthToken,
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Role: role.Unverified}, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+					Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/test?token=%s&userId=%s", tt.jwt, tt.userId), nil)
+			setup.testCtx.Request = req
+
+			setup.router.VerifyEmail(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_RequestEmailChange(t *testing.T) {
+	newEmail := "new@email.com"
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		email       string
+		wantResCode int
+	}{
+		{
+			name:  "should return 401 when authorizer returns ErrInvalidToken",
+			email: newEmail,
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 400 when email is not provided",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 400 when email is taken",
+			email: newEmail,
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(nil, services.ErrEmailTaken).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return 500 when user service returns unknown error",
+			email: newEmail,
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 500 when confirmation email cannot be sent",
+			email: newEmail,
+			prep: func(setup *testSetup) {
+				user := entities.User{ID: testUserId, PendingEmail: newEmail}
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(&user, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendEmailChangeConfirmationEmail(setup.testCtx, user, newEmail,
+					rcommon.MakeEmailChangeConfirmationURIs(user)).Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "should return 200 when notice email cannot be sent",
+			email: newEmail,
+			prep: func(setup *testSetup) {
+				user := entities.User{ID: testUserId, PendingEmail: newEmail}
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(&user, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendEmailChangeConfirmationEmail(setup.testCtx, user, newEmail,
+					rcommon.MakeEmailChangeConfirmationURIs(user)).Return(nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendEmailChangeNoticeEmail(setup.testCtx, user, newEmail).
+					Return(errors.New("service err")).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:  "should return 200",
+			email: newEmail,
+			prep: func(setup *testSetup) {
+				user := entities.User{ID: testUserId, PendingEmail: newEmail}
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockUService.EXPECT().RequestEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), newEmail).
+					Return(&user, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendEmailChangeConfirmationEmail(setup.testCtx, user, newEmail,
+					rcommon.MakeEmailChangeConfirmationURIs(user)).Return(nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendEmailChangeNoticeEmail(setup.testCtx, user, newEmail).
+					Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"email": tt.email,
+			})
+			setup.testCtx.Request.AddCookie(&http.Cookie{
+				Name:  authCookieName,
+				Value: testAuthToken,
+			})
+
+			setup.router.RequestEmailChange(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_ConfirmEmailChange(t *testing.T) {
+	newEmail := "new@email.com"
+	nonce := "abc123"
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when user service returns ErrInvalidID",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when user service returns ErrNotFound",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 400 when nonce does not match pending email change",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockUService.EXPECT().ConfirmEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), nonce).
+					Return(nil, services.ErrNoPendingEmailChange).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when email has been taken since the request",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockUService.EXPECT().ConfirmEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), nonce).
+					Return(nil, services.ErrEmailTaken).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when user service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId}, nil).Times(1)
+				setup.mockUService.EXPECT().ConfirmEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), nonce).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and invalidate service token",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.User{ID: testUserId, Email: "old@email.com"}, nil).Times(1)
+				setup.mockUService.EXPECT().ConfirmEmailChangeForUserWithID(setup.testCtx, testUserId.Hex(), nonce).
+					Return(&entities.User{ID: testUserId, Email: newEmail}, nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/test?token=%s&userId=%s&nonce=%s",
+				testAuthToken, testUserId.Hex(), nonce), nil)
+			setup.testCtx.Request = req
+
+			setup.router.ConfirmEmailChange(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_Logout__should_clear_the_auth_cookie(t *testing.T) {
+	setup := setupTest(t, nil)
+	defer setup.ctrl.Finish()
+
+	mockRenderPageCall(setup)
+
+	testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, nil)
+	setup.router.Logout(setup.testCtx)
+
+	assert.True(t, strings.Contains(setup.w.HeaderMap["Set-Cookie"][0], authCookieName+"="))
+}
+
+func Test_CreateTeam(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		teamName    string
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when team name is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 401 when authorizer returns ErrInvalidToken",
+			teamName: "testteam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "should return 500 when authorizer returns unknown error",
+			teamName: "testteam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "should return 400 when team service returns ErrNameTaken",
+			teamName: "testteam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
+					Return(nil, services.ErrNameTaken).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 400 when team service returns ErrUserInTeam",
+			teamName: "testteam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
+					Return(nil, services.ErrUserInTeam).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 404 when team service returns ErrNotFound",
+			teamName: "testteam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:     "should return 500 when team service returns unknown error",
+			teamName: "testteam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "should return 200",
+			teamName: "testteam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
+					Return(&entities.Team{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"name": tt.teamName,
+			})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.CreateTeam(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_JoinTeam(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		joinCode    string
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when join code is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 401 when authorizer returns ErrInvalidToken",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "should return 500 when authorizer returns unknown error",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "should return 404 when team service returns ErrNotFound",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:     "should return 429 when team service returns ErrJoinCodeThrottled",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrJoinCodeThrottled).Times(1)
+			},
+			wantResCode: http.StatusTooManyRequests,
+		},
+		{
+			name:     "should return 400 when team service returns ErrUserInTeam",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrUserInTeam).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 400 when team service returns ErrTeamFull",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrTeamFull).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 500 when team service returns unknown error",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "should return 400 when team is closed and user has already requested to join it",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrTeamClosed).Times(1)
+				setup.mockJRService.EXPECT().CreateJoinRequestWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(nil, services.ErrJoinRequestExists).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 500 when team is closed and join request service returns unknown error",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrTeamClosed).Times(1)
+				setup.mockJRService.EXPECT().CreateJoinRequestWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "should return 200 when team is closed and join request is created",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(services.ErrTeamClosed).Times(1)
+				setup.mockJRService.EXPECT().CreateJoinRequestWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(&entities.TeamJoinRequest{}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name:     "should return 200",
+			joinCode: "ABCD2345",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().AddUserWithIDToTeamWithJoinCode(setup.testCtx, testUserId.Hex(), "ABCD2345").
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"code": tt.joinCode,
+			})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.JoinTeam(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_RegenerateTeamJoinCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when authorizer returns ErrInvalidToken",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 404 when user is not in a team",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 403 when user is not the team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name: "should return 500 when team service returns error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RegenerateJoinCodeForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RegenerateJoinCodeForTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId, JoinCode: "ABCD2345"}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.RegenerateTeamJoinCode(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_SetTeamPrivacy(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		privacy     string
+		wantResCode int
+	}{
+		{
+			name: "should return 401 when authorizer returns ErrInvalidToken",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 403 when user is not the team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:    "should return 400 when team service returns ErrInvalidTeamPrivacy",
+			privacy: "secret",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().SetPrivacyForTeamWithID(setup.testCtx, testTeamId.Hex(), entities.TeamPrivacySetting("secret")).
+					Return(nil, services.ErrInvalidTeamPrivacy).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should return 500 when team service returns unknown error",
+			privacy: "closed",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().SetPrivacyForTeamWithID(setup.testCtx, testTeamId.Hex(), entities.TeamClosed).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "should return 200",
+			privacy: "closed",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().SetPrivacyForTeamWithID(setup.testCtx, testTeamId.Hex(), entities.TeamClosed).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId, Privacy: entities.TeamClosed}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"privacy": tt.privacy,
+			})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.SetTeamPrivacy(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_ApproveTeamJoinRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 403 when user is not the team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name: "should return 404 when join request service returns ErrNotFound",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 400 when join request service returns ErrUserInTeam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrUserInTeam).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when join request service returns ErrTeamFull",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrTeamFull).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when join request service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockJRService.EXPECT().ApproveJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			setup.testCtx.Params = gin.Params{{Key: "id", Value: "test_request"}}
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.ApproveTeamJoinRequest(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_RejectTeamJoinRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 403 when user is not the team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name: "should return 404 when join request service returns ErrNotFound",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockJRService.EXPECT().RejectJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when join request service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockJRService.EXPECT().RejectJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockJRService.EXPECT().RejectJoinRequestWithID(setup.testCtx, testTeamId.Hex(), "test_request").
+					Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			setup.testCtx.Params = gin.Params{{Key: "id", Value: "test_request"}}
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.RejectTeamJoinRequest(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_RenameTeam(t *testing.T) {
+	tests := []struct {
+		name        string
+		teamName    string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when name is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 403 when user is not the team's creator",
+			teamName: "Bobs the Renamed",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:     "should return 400 when team service returns ErrNameTaken",
+			teamName: "Bobs the Renamed",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RenameTeamWithID(setup.testCtx, testTeamId.Hex(), "Bobs the Renamed").
+					Return(nil, services.ErrNameTaken).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:     "should return 500 when team service returns unknown error",
+			teamName: "Bobs the Renamed",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RenameTeamWithID(setup.testCtx, testTeamId.Hex(), "Bobs the Renamed").
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "should return 200",
+			teamName: "Bobs the Renamed",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RenameTeamWithID(setup.testCtx, testTeamId.Hex(), "Bobs the Renamed").
+					Return(&entities.Team{ID: testTeamId, Name: "Bobs the Renamed", Creator: testUserId}, nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"name": tt.teamName,
+			})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.RenameTeam(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_UpdateTeamDetails(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      map[string]string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when no details are provided",
+			params:      map[string]string{},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when details are invalid",
+			params:      map[string]string{"demo_url": "not a url"},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return 403 when user is not the team's creator",
+			params: map[string]string{"description": "We build robots"},
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name:   "should return 500 when team service returns unknown error",
+			params: map[string]string{"description": "We build robots"},
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().UpdateTeamWithID(setup.testCtx, testTeamId.Hex(), gomock.Any()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			params: map[string]string{
+				"description":  "We build robots",
+				"track":        "hardware",
+				"table_number": "12",
+				"tags":         "Robots, hardware",
+			},
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().UpdateTeamWithID(setup.testCtx, testTeamId.Hex(), services.TeamUpdateParams{
+					entities.TeamDescription: "We build robots",
+					entities.TeamTrack:       "hardware",
+					entities.TeamTableNumber: 12,
+					entities.TeamTags:        []string{"robots", "hardware"},
+				}).Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -214,110 +3471,83 @@ func Test_Login(t *testing.T) {
 			})
 			defer setup.ctrl.Finish()
 
+			mockRenderPageCall(setup)
+
 			if tt.prep != nil {
 				tt.prep(setup)
 			}
 
-			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
-				"email":    tt.email,
-				"password": tt.password,
-			})
-			setup.router.Login(setup.testCtx)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, tt.params)
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.UpdateTeamDetails(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
 	}
 }
 
-func Test_Register(t *testing.T) {
+func Test_RemoveTeamMember(t *testing.T) {
 	tests := []struct {
-		name            string
-		prep            func(*testSetup)
-		userName        string
-		email           string
-		password        string
-		passwordConfirm string
-		wantResCode     int
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
 	}{
 		{
-			name:            "should return 400 when email not specified",
-			password:        "testtest",
-			userName:        "bob",
-			passwordConfirm: "testtest",
-			wantResCode:     http.StatusBadRequest,
-		},
-		{
-			name:            "should return 400 when name not specified",
-			password:        "testtest",
-			passwordConfirm: "testtest",
-			email:           "bob@test.com",
-			wantResCode:     http.StatusBadRequest,
-		},
-		{
-			name:            "should return 400 when password not specified",
-			userName:        "bob",
-			passwordConfirm: "testtest",
-			email:           "bob@test.com",
-			wantResCode:     http.StatusBadRequest,
-		},
-		{
-			name:        "should return 400 when password is too short",
-			userName:    "bob",
-			password:    "test",
-			email:       "bob@test.com",
-			wantResCode: http.StatusBadRequest,
-		},
-		{
-			name:     "should return 400 when password is too long",
-			userName: "bob",
-			password: "testtesttesttesttesttesttesttesttesttest" +
-				"testtesttesttesttesttesttesttesttesttesttesttesttesttesttesttesttesttests",
-			email:       "bob@test.com",
-			wantResCode: http.StatusBadRequest,
+			name: "should return 403 when user is not the team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
 		},
 		{
-			name:            "should return 400 when password does not match passwordConfirm",
-			userName:        "bob",
-			passwordConfirm: "testtest",
-			password:        "testtest2",
-			email:           "bob@test.com",
-			wantResCode:     http.StatusBadRequest,
+			name: "should return 404 when team service returns ErrUserNotInTeam",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), "test_member").
+					Return(services.ErrUserNotInTeam).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
 		},
 		{
-			name:            "should return 400 when CreateUser returns ErrEmailTaken",
-			userName:        "bob",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			email:           "bob@test.com",
+			name: "should return 400 when team service returns ErrUserIsTeamCreator",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", setup.cfg.Auth.DefaultRole).
-					Return(nil, services.ErrEmailTaken).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), "test_member").
+					Return(services.ErrUserIsTeamCreator).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:            "should return 500 when CreateUser returns unknown error",
-			userName:        "bob",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			email:           "bob@test.com",
+			name: "should return 500 when team service returns unknown error",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", gomock.Any()).
-					Return(nil, errors.New("service err")).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), "test_member").
+					Return(errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:            "should return 200 even if SendEmailVerificationEmail returns error",
-			userName:        "bob",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			email:           "bob@test.com",
+			name: "should return 200",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().CreateUser(gomock.Any(), "bob", "bob@test.com", "testtest", gomock.Any()).
-					Return(&entities.User{ID: testUserId}, nil).Times(1)
-				setup.mockEServiceV2.EXPECT().SendEmailVerificationEmail(setup.testCtx, entities.User{ID: testUserId}, emailVerificationURIs).
-					Return(errors.New("service err")).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTeamWithID(setup.testCtx, testTeamId.Hex(), "test_member").
+					Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -336,67 +3566,66 @@ func Test_Register(t *testing.T) {
 				tt.prep(setup)
 			}
 
-			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
-				"name":            tt.userName,
-				"email":           tt.email,
-				"password":        tt.password,
-				"passwordConfirm": tt.passwordConfirm,
-			})
-			setup.router.Register(setup.testCtx)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			setup.testCtx.Params = gin.Params{{Key: "id", Value: "test_member"}}
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.RemoveTeamMember(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
 	}
 }
 
-func Test_ForgotPassword(t *testing.T) {
+func Test_SetTeamCreator(t *testing.T) {
 	tests := []struct {
 		name        string
 		prep        func(*testSetup)
-		email       string
 		wantResCode int
 	}{
 		{
-			name:        "should return 400 when email not specified",
-			wantResCode: http.StatusBadRequest,
-		},
-		{
-			name:  "should return 200 when GetUserWithEmail returns ErrNotFound",
-			email: "bob@test.com",
+			name: "should return 403 when user is not the team's creator",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
-					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
 			},
-			wantResCode: http.StatusOK,
+			wantResCode: http.StatusForbidden,
 		},
 		{
-			name:  "should return 500 when GetUserWithEmail returns unknown error",
-			email: "bob@test.com",
+			name: "should return 404 when team service returns ErrUserNotInTeam",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
-					Return(nil, errors.New("service err")).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().SetCreatorForTeamWithID(setup.testCtx, testTeamId.Hex(), "test_member").
+					Return(nil, services.ErrUserNotInTeam).Times(1)
 			},
-			wantResCode: http.StatusInternalServerError,
+			wantResCode: http.StatusNotFound,
 		},
 		{
-			name:  "should return 500 when SendPasswordResetEmail returns unknown error",
-			email: "bob@test.com",
+			name: "should return 500 when team service returns unknown error",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
-					Return(&entities.User{ID: testUserId}, nil).Times(1)
-				setup.mockEServiceV2.EXPECT().SendPasswordResetEmail(setup.testCtx, entities.User{ID: testUserId}, passwordResetURIs).
-					Return(errors.New("service err")).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().SetCreatorForTeamWithID(setup.testCtx, testTeamId.Hex(), "test_member").
+					Return(nil, errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:  "should return 200",
-			email: "bob@test.com",
+			name: "should return 200",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithEmail(setup.testCtx, "bob@test.com").
-					Return(&entities.User{ID: testUserId}, nil).Times(1)
-				setup.mockEServiceV2.EXPECT().SendPasswordResetEmail(setup.testCtx, entities.User{ID: testUserId}, passwordResetURIs).
-					Return(nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().SetCreatorForTeamWithID(setup.testCtx, testTeamId.Hex(), "test_member").
+					Return(&entities.Team{ID: testTeamId}, nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -415,26 +3644,55 @@ func Test_ForgotPassword(t *testing.T) {
 				tt.prep(setup)
 			}
 
-			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
-				"email": tt.email,
-			})
-			setup.router.ForgotPassword(setup.testCtx)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			setup.testCtx.Params = gin.Params{{Key: "id", Value: "test_member"}}
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.SetTeamCreator(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
 	}
 }
 
-func Test_ResetPasswordPage(t *testing.T) {
+func Test_DisbandTeam(t *testing.T) {
 	tests := []struct {
 		name        string
 		prep        func(*testSetup)
-		jwt         string
 		wantResCode int
 	}{
 		{
-			name:        "should return 200",
-			jwt:         testAuthToken,
+			name: "should return 403 when user is not the team's creator",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
+			},
+			wantResCode: http.StatusForbidden,
+		},
+		{
+			name: "should return 500 when team service returns unknown error",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().DisbandTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockTService.EXPECT().DisbandTeamWithID(setup.testCtx, testTeamId.Hex()).
+					Return(nil).Times(1)
+			},
 			wantResCode: http.StatusOK,
 		},
 	}
@@ -452,107 +3710,81 @@ func Test_ResetPasswordPage(t *testing.T) {
 				tt.prep(setup)
 			}
 
-			setup.testCtx.Request, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/test?token=%s", tt.jwt), nil)
-			setup.router.ResetPasswordPage(setup.testCtx)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.DisbandTeam(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
 	}
 }
 
-func Test_ResetPassword(t *testing.T) {
+func Test_LeaveTeam(t *testing.T) {
 	tests := []struct {
-		name            string
-		prep            func(*testSetup)
-		password        string
-		passwordConfirm string
-		jwt             string
-		userId          string
-		wantResCode     int
+		name        string
+		prep        func(*testSetup)
+		jwt         string
+		wantResCode int
 	}{
 		{
-			name:            "should return 400 when password not specified",
-			passwordConfirm: "testtest",
-			wantResCode:     http.StatusBadRequest,
-		},
-		{
-			name:            "should return 400 when password does not match passwordConfirm",
-			passwordConfirm: "testtest",
-			password:        "testtest2",
-			wantResCode:     http.StatusBadRequest,
+			name: "should return 401 when authorizer returns ErrInvalidToken",
+			jwt:  "test",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
 		},
 		{
-			name:            "should return 400 when user service returns ErrInvalidID",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			jwt:             testAuthToken,
-			userId:          testUserId.Hex(),
+			name: "should return 500 when authorizer returns unknown error",
+			jwt:  "test",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(services.ErrInvalidID).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
 			},
-			wantResCode: http.StatusBadRequest,
+			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:            "should return 404 when user service returns ErrNotFound",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			jwt:             testAuthToken,
-			userId:          testUserId.Hex(),
+			name: "should return 404 when team service returns ErrNotFound",
+			jwt:  "test",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
 					Return(services.ErrNotFound).Times(1)
 			},
 			wantResCode: http.StatusNotFound,
 		},
 		{
-			name:            "should return 500 when user service returns unknown error",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			jwt:             testAuthToken,
-			userId:          testUserId.Hex(),
+			name: "should return 400 when team service returns ErrUserNotInTeam",
+			jwt:  "test",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(errors.New("service err")).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+					Return(services.ErrUserNotInTeam).Times(1)
 			},
-			wantResCode: http.StatusInternalServerError,
+			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:            "should return 500 when user service returns unknown error",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			jwt:             testAuthToken,
-			userId:          testUserId.Hex(),
+			name: "should return 500 when team service returns unknown error",
+			jwt:  "test",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
 					Return(errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:            "should return 200 when authorizer fails to invalidate token",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			jwt:             testAuthToken,
-			userId:          testUserId.Hex(),
-			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(nil).Times(1)
-				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, gomock.Any()).
-					Return(authCommon.ErrInvalidTokenType).Times(1)
-			},
-			wantResCode: http.StatusOK,
-		},
-		{
-			name:            "should return 200",
-			passwordConfirm: "testtest",
-			password:        "testtest",
-			jwt:             testAuthToken,
-			userId:          testUserId.Hex(),
+			name: "should return 200",
+			jwt:  "test",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(nil).Times(1)
-				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, gomock.Any()).
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
 					Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
@@ -572,97 +3804,131 @@ func Test_ResetPassword(t *testing.T) {
 				tt.prep(setup)
 			}
 
-			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
-				"password":        tt.password,
-				"passwordConfirm": tt.passwordConfirm,
-				"userId":          tt.userId,
-			})
-			setup.router.ResetPassword(setup.testCtx)
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.LeaveTeam(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
 	}
 }
 
-func Test_VerifyEmail(t *testing.T) {
+func Test_SetMatchmakingProfile(t *testing.T) {
 	tests := []struct {
 		name        string
 		prep        func(*testSetup)
-		jwt         string
-		userId      string
 		wantResCode int
 	}{
 		{
-			name:   "should return 400 when user service returns ErrInvalidID",
-			userId: testUserId.Hex(),
+			name: "should return 401 when authorizer returns ErrInvalidToken",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
-					Return(nil, services.ErrInvalidID).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+			},
+			wantResCode: http.StatusUnauthorized,
+		},
+		{
+			name: "should return 400 when matchmaking service returns ErrInvalidMatchmakingProfile",
+			prep: func(setup *testSetup) {
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), []string{"go", " design"},
+					[]string{"fintech"}, "I like building things").Return(nil, services.ErrInvalidMatchmakingProfile).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:   "should return 404 when user service returns ErrNotFound",
-			userId: testUserId.Hex(),
+			name: "should return 400 when matchmaking service returns ErrUserInTeam",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
-					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, services.ErrUserInTeam).Times(1)
 			},
-			wantResCode: http.StatusNotFound,
+			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:   "should return 500 when user service returns unknown error",
-			userId: testUserId.Hex(),
+			name: "should return 500 when matchmaking service returns unknown error",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(nil, errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:   "should return 400 when user's email is already verified",
-			userId: testUserId.Hex(),
+			name: "should return 200",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
-					Return(&entities.User{Role: role.Applicant}, nil).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().SetProfileForUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&entities.MatchmakingProfile{User: testUserId}, nil).Times(1)
 			},
-			wantResCode: http.StatusBadRequest,
+			wantResCode: http.StatusOK,
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, map[string]string{
+				environment.JWTSecret: "test",
+			})
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"skills":    "go, design",
+				"interests": "fintech",
+				"pitch":     "I like building things",
+			})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.SetMatchmakingProfile(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_DeleteMatchmakingProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
 		{
-			name:   "should return 500 when updating user fails",
-			userId: testUserId.Hex(),
+			name: "should return 500 when matchmaking service returns unknown error",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
-					Return(&entities.User{ID: testUserId, Role: role.Unverified}, nil).Times(1)
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
 					Return(errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:   "should return 200 when invalidating service token fails",
-			userId: testUserId.Hex(),
-			jwt:    testAuthToken,
+			name: "should return 200 when user has no profile",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
-					Return(&entities.User{ID: testUserId, Role: role.Unverified}, nil).Times(1)
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(nil).Times(1)
-				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
-					Return(errors.New("authorizer err")).Times(1)
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(services.ErrNotFound).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
 		{
-			name:   "should return 200",
-			userId: testUserId.Hex(),
-			jwt:    testAuthToken,
+			name: "should return 200",
 			prep: func(setup *testSetup) {
-				setup.mockUService.EXPECT().GetUserWithID(setup.testCtx, testUserId.Hex()).
-					Return(&entities.User{ID: testUserId, Role: role.Unverified}, nil).Times(1)
-				setup.mockUService.EXPECT().UpdateUserWithID(setup.testCtx, testUserId.Hex(), gomock.Any()).
-					Return(nil).Times(1)
-				setup.mockAuthorizer.EXPECT().InvalidateServiceToken(setup.testCtx, testAuthToken).
+				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
+					Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteProfileForUserWithID(setup.testCtx, testUserId.Hex()).
 					Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
@@ -682,109 +3948,87 @@ func Test_VerifyEmail(t *testing.T) {
 				tt.prep(setup)
 			}
 
-			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/test?token=%s&userId=%s", tt.jwt, tt.userId), nil)
-			setup.testCtx.Request = req
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			attachAuthCookie(setup.testCtx)
 
-			setup.router.VerifyEmail(setup.testCtx)
+			setup.router.DeleteMatchmakingProfile(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
 	}
 }
 
-func Test_Logout__should_clear_the_auth_cookie(t *testing.T) {
-	setup := setupTest(t, nil)
-	defer setup.ctrl.Finish()
-
-	mockRenderPageCall(setup)
-
-	testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodGet, nil)
-	setup.router.Logout(setup.testCtx)
-
-	assert.True(t, strings.Contains(setup.w.HeaderMap["Set-Cookie"][0], authCookieName+"="))
-}
-
-func Test_CreateTeam(t *testing.T) {
+func Test_AcceptTeamMatch(t *testing.T) {
 	tests := []struct {
 		name        string
 		prep        func(*testSetup)
-		teamName    string
 		wantResCode int
 	}{
 		{
-			name:        "should return 400 when team name is not provided",
-			wantResCode: http.StatusBadRequest,
-		},
-		{
-			name:     "should return 401 when authorizer returns ErrInvalidToken",
-			teamName: "testteam",
+			name: "should return 500 when authorizer returns unknown error",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
-					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
+					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
 			},
-			wantResCode: http.StatusUnauthorized,
+			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:     "should return 500 when authorizer returns unknown error",
-			teamName: "testteam",
+			name: "should return 404 when matchmaking service returns ErrNotFound",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
-					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
+					Return(testUserId, nil).Times(1)
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
 			},
-			wantResCode: http.StatusInternalServerError,
+			wantResCode: http.StatusNotFound,
 		},
 		{
-			name:     "should return 400 when team service returns ErrNameTaken",
-			teamName: "testteam",
+			name: "should return 400 when matchmaking service returns ErrTeamFull",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
-					Return(nil, services.ErrNameTaken).Times(1)
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, services.ErrTeamFull).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:     "should return 400 when team service returns ErrUserInTeam",
-			teamName: "testteam",
+			name: "should return 400 when matchmaking service returns ErrJoinRequestExists",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
-					Return(nil, services.ErrUserInTeam).Times(1)
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, services.ErrJoinRequestExists).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:     "should return 404 when team service returns ErrNotFound",
-			teamName: "testteam",
+			name: "should return 500 when matchmaking service returns unknown error",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
-					Return(nil, services.ErrNotFound).Times(1)
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
 			},
-			wantResCode: http.StatusNotFound,
+			wantResCode: http.StatusInternalServerError,
 		},
 		{
-			name:     "should return 500 when team service returns unknown error",
-			teamName: "testteam",
+			name: "should return 200 when join request is sent",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
-					Return(nil, errors.New("service err")).Times(1)
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(&entities.TeamJoinRequest{User: testUserId, Team: testTeamId}, nil).Times(1)
 			},
-			wantResCode: http.StatusInternalServerError,
+			wantResCode: http.StatusOK,
 		},
 		{
-			name:     "should return 200",
-			teamName: "testteam",
+			name: "should return 200 when user joins team",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().CreateTeamForUserWithID(setup.testCtx, "testteam", testUserId.Hex()).
-					Return(&entities.Team{}, nil).Times(1)
+				setup.mockMMService.EXPECT().AcceptTeamMatchForUserWithID(setup.testCtx, testUserId.Hex(), testTeamId.Hex()).
+					Return(nil, nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -803,99 +4047,66 @@ func Test_CreateTeam(t *testing.T) {
 				tt.prep(setup)
 			}
 
-			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
-				"name": tt.teamName,
-			})
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": testTeamId.Hex()})
 			attachAuthCookie(setup.testCtx)
 
-			setup.router.CreateTeam(setup.testCtx)
+			setup.router.AcceptTeamMatch(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
 	}
 }
 
-func Test_JoinTeam(t *testing.T) {
+func Test_SetTeamAdvert(t *testing.T) {
 	tests := []struct {
 		name        string
 		prep        func(*testSetup)
-		teamID      string
 		wantResCode int
 	}{
 		{
-			name:        "should return 400 when team id is not provided",
-			wantResCode: http.StatusBadRequest,
-		},
-		{
-			name:   "should return 401 when authorizer returns ErrInvalidToken",
-			teamID: "testteam",
-			prep: func(setup *testSetup) {
-				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
-					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
-			},
-			wantResCode: http.StatusUnauthorized,
-		},
-		{
-			name:   "should return 500 when authorizer returns unknown error",
-			teamID: "testteam",
-			prep: func(setup *testSetup) {
-				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
-					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
-			},
-			wantResCode: http.StatusInternalServerError,
-		},
-		{
-			name:   "should return 400 when team service returns ErrInvalidID",
-			teamID: "testteam",
-			prep: func(setup *testSetup) {
-				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
-					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), "testteam").
-					Return(services.ErrInvalidID).Times(1)
-			},
-			wantResCode: http.StatusBadRequest,
-		},
-		{
-			name:   "should return 404 when team service returns ErrNotFound",
-			teamID: "testteam",
+			name: "should return 403 when user is not the team's creator",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), "testteam").
-					Return(services.ErrNotFound).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
 			},
-			wantResCode: http.StatusNotFound,
+			wantResCode: http.StatusForbidden,
 		},
 		{
-			name:   "should return 400 when team service returns ErrUserInTeam",
-			teamID: "testteam",
+			name: "should return 400 when matchmaking service returns ErrInvalidTeamAdvert",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), "testteam").
-					Return(services.ErrUserInTeam).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockMMService.EXPECT().SetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex(), []string{"go"}, "We need a backend developer").
+					Return(nil, services.ErrInvalidTeamAdvert).Times(1)
 			},
 			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:   "should return 500 when team service returns unknown error",
-			teamID: "testteam",
+			name: "should return 400 when matchmaking service returns ErrTeamFull",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), "testteam").
-					Return(errors.New("service err")).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockMMService.EXPECT().SetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex(), gomock.Any(), gomock.Any()).
+					Return(nil, services.ErrTeamFull).Times(1)
 			},
-			wantResCode: http.StatusInternalServerError,
+			wantResCode: http.StatusBadRequest,
 		},
 		{
-			name:   "should return 200",
-			teamID: "testteam",
+			name: "should return 200",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(setup.testCtx, testUserId.Hex(), "testteam").
-					Return(nil).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockMMService.EXPECT().SetAdvertForTeamWithID(setup.testCtx, testTeamId.Hex(), gomock.Any(), gomock.Any()).
+					Return(&entities.TeamAdvert{Team: testTeamId}, nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -915,82 +4126,54 @@ func Test_JoinTeam(t *testing.T) {
 			}
 
 			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
-				"id": tt.teamID,
+				"skills": "go",
+				"pitch":  "We need a backend developer",
 			})
 			attachAuthCookie(setup.testCtx)
 
-			setup.router.JoinTeam(setup.testCtx)
+			setup.router.SetTeamAdvert(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
 	}
 }
 
-func Test_LeaveTeam(t *testing.T) {
+func Test_DeleteTeamAdvert(t *testing.T) {
 	tests := []struct {
 		name        string
 		prep        func(*testSetup)
-		jwt         string
 		wantResCode int
 	}{
 		{
-			name: "should return 401 when authorizer returns ErrInvalidToken",
-			jwt:  "test",
-			prep: func(setup *testSetup) {
-				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
-					Return(primitive.ObjectID{}, authCommon.ErrInvalidToken).Times(1)
-			},
-			wantResCode: http.StatusUnauthorized,
-		},
-		{
-			name: "should return 500 when authorizer returns unknown error",
-			jwt:  "test",
-			prep: func(setup *testSetup) {
-				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
-					Return(primitive.ObjectID{}, errors.New("authorizer err")).Times(1)
-			},
-			wantResCode: http.StatusInternalServerError,
-		},
-		{
-			name: "should return 404 when team service returns ErrNotFound",
-			jwt:  "test",
-			prep: func(setup *testSetup) {
-				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
-					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
-					Return(services.ErrNotFound).Times(1)
-			},
-			wantResCode: http.StatusNotFound,
-		},
-		{
-			name: "should return 400 when team service returns ErrUserNotInTeam",
-			jwt:  "test",
+			name: "should return 403 when user is not the team's creator",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
-					Return(services.ErrUserNotInTeam).Times(1)
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: primitive.NewObjectID()}, nil).Times(1)
 			},
-			wantResCode: http.StatusBadRequest,
+			wantResCode: http.StatusForbidden,
 		},
 		{
-			name: "should return 500 when team service returns unknown error",
-			jwt:  "test",
+			name: "should return 500 when matchmaking service returns unknown error",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteAdvertForTeamWithID(setup.testCtx, testTeamId.Hex()).
 					Return(errors.New("service err")).Times(1)
 			},
 			wantResCode: http.StatusInternalServerError,
 		},
 		{
 			name: "should return 200",
-			jwt:  "test",
 			prep: func(setup *testSetup) {
 				setup.mockAuthorizer.EXPECT().GetUserIdFromToken(testAuthToken).
 					Return(testUserId, nil).Times(1)
-				setup.mockTService.EXPECT().RemoveUserWithIDFromTheirTeam(setup.testCtx, testUserId.Hex()).
+				setup.mockTService.EXPECT().GetTeamForUserWithID(setup.testCtx, testUserId.Hex()).
+					Return(&entities.Team{ID: testTeamId, Creator: testUserId}, nil).Times(1)
+				setup.mockMMService.EXPECT().DeleteAdvertForTeamWithID(setup.testCtx, testTeamId.Hex()).
 					Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
@@ -1010,10 +4193,10 @@ func Test_LeaveTeam(t *testing.T) {
 				tt.prep(setup)
 			}
 
-			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{})
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
 			attachAuthCookie(setup.testCtx)
 
-			setup.router.LeaveTeam(setup.testCtx)
+			setup.router.DeleteTeamAdvert(setup.testCtx)
 
 			assert.Equal(t, tt.wantResCode, setup.w.Code)
 		})
@@ -1056,6 +4239,12 @@ func Test_UpdateUser(t *testing.T) {
 			userID:         "test id",
 			paramsToUpdate: "{\"_id\":\"not a number\"}",
 		},
+		{
+			name:           "should return 400 when paramsToUpdate include email",
+			wantResCode:    http.StatusBadRequest,
+			userID:         "test id",
+			paramsToUpdate: "{\"email\":\"rob@tester.com\"}",
+		},
 		{
 			name:           "should return 400 when user service returns ErrInvalidID",
 			userID:         "test id",
@@ -1095,6 +4284,7 @@ func Test_UpdateUser(t *testing.T) {
 					entities.UserName: "Rob the Tester",
 				}).
 					Return(nil)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 			},
 			wantResCode: http.StatusOK,
 		},
@@ -1242,6 +4432,18 @@ func TestFrontendRouter_ProfilePage(t *testing.T) {
 	assert.Equal(t, http.StatusOK, setup.w.Code)
 }
 
+func TestFrontendRouter_MatchmakingPage(t *testing.T) {
+	setup := setupTest(t, nil)
+	defer setup.ctrl.Finish()
+
+	attachAuthCookie(setup.testCtx)
+	mockRenderPageCall(setup)
+
+	setup.router.MatchmakingPage(setup.testCtx)
+
+	assert.Equal(t, http.StatusOK, setup.w.Code)
+}
+
 func TestFrontendRouter_RedirectToEntryPage__should_redirect_to_login_when_auth_token_is_unavailable(t *testing.T) {
 	setup := setupTest(t, nil)
 	defer setup.ctrl.Finish()
@@ -1281,3 +4483,314 @@ func attachAuthCookie(ctx *gin.Context) {
 		MaxAge: 1000,
 	})
 }
+
+func Test_SetUserRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		role        string
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when role is not provided",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 400 when role does not exist",
+			role:        "superuser",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 400 when user id is invalid",
+			role: string(role.Attendee),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when user does not exist",
+			role: string(role.Attendee),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when fetching user fails",
+			role: string(role.Attendee),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(nil, errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 500 when updating user fails",
+			role: string(role.Attendee),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(gomock.Any(), setup.testUser.ID.Hex(), services.UserUpdateParams{
+					entities.UserRole: role.Attendee,
+				}).Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 and log audit event when role is set",
+			role: string(role.Attendee),
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(gomock.Any(), setup.testUser.ID.Hex(), services.UserUpdateParams{
+					entities.UserRole: role.Attendee,
+				}).Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"role": tt.role,
+			})
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": setup.testUser.ID.Hex()})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.SetUserRole(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_SetUserSpecialPermissions(t *testing.T) {
+	uri1, err := authCommon.NewURIFromString("hs:hs_auth:api:v2:GetUsers")
+	assert.NoError(t, err)
+	uri2, err := authCommon.NewURIFromString("hs:hs_auth:api:v2:GetTeams")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		permissions string
+		wantResCode int
+	}{
+		{
+			name:        "should return 400 when a permission is invalid",
+			permissions: "hs:hs_auth:api:v2:GetUsers\nhs:hs_auth?a=b?c=d",
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return 404 when user does not exist",
+			permissions: "hs:hs_auth:api:v2:GetUsers",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name:        "should return 500 when updating user fails",
+			permissions: "hs:hs_auth:api:v2:GetUsers",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(gomock.Any(), setup.testUser.ID.Hex(), gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name:        "should return 200 and set permissions listed one per line",
+			permissions: " hs:hs_auth:api:v2:GetUsers\r\n\r\nhs:hs_auth:api:v2:GetTeams\n",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(gomock.Any(), setup.testUser.ID.Hex(), services.UserUpdateParams{
+					entities.UserSpecialPermissions: authCommon.UniformResourceIdentifiers{uri1, uri2},
+				}).Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+		{
+			name: "should return 200 and remove all permissions when none are provided",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockUService.EXPECT().UpdateUserWithID(gomock.Any(), setup.testUser.ID.Hex(), services.UserUpdateParams{
+					entities.UserSpecialPermissions: authCommon.UniformResourceIdentifiers{},
+				}).Return(nil).Times(1)
+				setup.mockAService.EXPECT().LogEvent(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+
+			if tt.prep != nil {
+				tt.prep(setup)
+			}
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, map[string]string{
+				"permissions": tt.permissions,
+			})
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": setup.testUser.ID.Hex()})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.SetUserSpecialPermissions(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_SendUserPasswordReset(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 400 when user id is invalid",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(nil, services.ErrInvalidID).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 404 when user does not exist",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 500 when sending email fails",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendPasswordResetEmail(gomock.Any(), *setup.testUser, gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 when email is sent",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendPasswordResetEmail(gomock.Any(), *setup.testUser,
+					rcommon.MakePasswordResetURIs(*setup.testUser)).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+			tt.prep(setup)
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": setup.testUser.ID.Hex()})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.SendUserPasswordReset(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
+
+func Test_ResendUserEmailVerification(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(*testSetup)
+		wantResCode int
+	}{
+		{
+			name: "should return 404 when user does not exist",
+			prep: func(setup *testSetup) {
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(nil, services.ErrNotFound).Times(1)
+			},
+			wantResCode: http.StatusNotFound,
+		},
+		{
+			name: "should return 400 when user's email is already verified",
+			prep: func(setup *testSetup) {
+				setup.testUser.Role = role.Applicant
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+			},
+			wantResCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return 500 when sending email fails",
+			prep: func(setup *testSetup) {
+				setup.testUser.Role = role.Unverified
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendEmailVerificationEmail(gomock.Any(), *setup.testUser, gomock.Any()).
+					Return(errors.New("service err")).Times(1)
+			},
+			wantResCode: http.StatusInternalServerError,
+		},
+		{
+			name: "should return 200 when email is sent",
+			prep: func(setup *testSetup) {
+				setup.testUser.Role = role.Unverified
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.testUser.ID.Hex()).
+					Return(setup.testUser, nil).Times(1)
+				setup.mockEServiceV2.EXPECT().SendEmailVerificationEmail(gomock.Any(), *setup.testUser,
+					rcommon.MakeEmailVerificationURIs(*setup.testUser)).Return(nil).Times(1)
+			},
+			wantResCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTest(t, nil)
+			defer setup.ctrl.Finish()
+
+			mockRenderPageCall(setup)
+			tt.prep(setup)
+
+			testutils.AddRequestWithFormParamsToCtx(setup.testCtx, http.MethodPost, nil)
+			testutils.AddUrlParamsToCtx(setup.testCtx, map[string]string{"id": setup.testUser.ID.Hex()})
+			attachAuthCookie(setup.testCtx)
+
+			setup.router.ResendUserEmailVerification(setup.testCtx)
+
+			assert.Equal(t, tt.wantResCode, setup.w.Code)
+		})
+	}
+}
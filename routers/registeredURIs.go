@@ -0,0 +1,53 @@
+package routers
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	authV2 "github.com/unicsmcr/hs_auth/authorization/v2"
+	"github.com/unicsmcr/hs_auth/authorization/v2/common"
+	"github.com/unicsmcr/hs_auth/config"
+	v2 "github.com/unicsmcr/hs_auth/routers/api/v2"
+	"github.com/unicsmcr/hs_auth/routers/frontend"
+	"go.uber.org/zap"
+)
+
+// uriCollector records the URI of every operation wrapped with the auth middleware.
+// It is only meant to be used while registering routes, calling any other Authorizer method will panic
+type uriCollector struct {
+	authV2.Authorizer
+	uris map[string]common.UniformResourceIdentifier
+}
+
+func (c *uriCollector) WithAuthMiddleware(router common.RouterResource, handler gin.HandlerFunc) gin.HandlerFunc {
+	uri := common.NewURIFromHandler(router, handler)
+	c.uris[uri.GetPath()] = uri
+	return handler
+}
+
+// RegisteredURIs returns the URIs of all operations and page components protected by the app's routers,
+// sorted by their path
+func RegisteredURIs(cfg *config.AppConfig) common.UniformResourceIdentifiers {
+	logger := zap.NewNop()
+	collector := &uriCollector{
+		uris: map[string]common.UniformResourceIdentifier{},
+	}
+
+	apiV2Router := v2.NewAPIV2Router(logger, cfg, collector, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	frontendRouter := frontend.NewRouter(logger, cfg, nil, nil, nil, collector, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	NewMainRouter(logger, apiV2Router, frontendRouter).RegisterRoutes(gin.New().Group("/"))
+
+	for _, uri := range frontend.ComponentURIs() {
+		collector.uris[uri.GetPath()] = uri
+	}
+
+	uris := make(common.UniformResourceIdentifiers, 0, len(collector.uris))
+	for _, uri := range collector.uris {
+		uris = append(uris, uri)
+	}
+	sort.Slice(uris, func(i, j int) bool {
+		return uris[i].GetPath() < uris[j].GetPath()
+	})
+
+	return uris
+}
@@ -1,9 +1,11 @@
 package routers
 
 import (
+	"github.com/unicsmcr/hs_auth/config"
 	mock_v2 "github.com/unicsmcr/hs_auth/mocks/routers/api/v2"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -46,3 +48,18 @@ func Test_RegisterRoutes__should_register_required_routes(t *testing.T) {
 		})
 	}
 }
+
+func Test_RegisteredURIs__should_return_uris_of_protected_operations_and_components(t *testing.T) {
+	uris := RegisteredURIs(&config.AppConfig{})
+
+	var paths []string
+	for _, uri := range uris {
+		paths = append(paths, uri.GetPath())
+	}
+
+	assert.Contains(t, paths, "hs:hs_auth:api:v2:GetUser")
+	assert.Contains(t, paths, "hs:hs_auth:frontend:ProfilePage")
+	assert.Contains(t, paths, "hs:hs_auth:frontend:ProfilePageComponents:TeamPanel")
+	assert.NotContains(t, paths, "hs:hs_auth:api:v2:Login")
+	assert.True(t, sort.StringsAreSorted(paths))
+}
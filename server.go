@@ -3,18 +3,26 @@ package main
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/unicsmcr/hs_auth/environment"
+	"github.com/unicsmcr/hs_auth/migrations"
 	"github.com/unicsmcr/hs_auth/routers"
+	"github.com/unicsmcr/hs_auth/services"
 )
 
 type Server struct {
 	*gin.Engine
-	Port string
+	Port           string
+	SchemaVersion  migrations.SchemaVersion
+	WebhookService services.WebhookService
 }
 
-func NewServer(mainRouter routers.MainRouter, env *environment.Env) Server {
+// NewServer creates the app's server. Taking the schema version guarantees that all
+// pending migrations have been applied before any requests are served.
+func NewServer(mainRouter routers.MainRouter, env *environment.Env, schemaVersion migrations.SchemaVersion, webhookService services.WebhookService) Server {
 	server := Server{
-		Engine: gin.Default(),
-		Port:   env.Get(environment.Port),
+		Engine:         gin.Default(),
+		Port:           env.Get(environment.Port),
+		SchemaVersion:  schemaVersion,
+		WebhookService: webhookService,
 	}
 
 	server.Static("static", "static")
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicsmcr/hs_auth/entities"
+)
+
+// AuditEventFilter restricts which events are returned by AuditService.GetEvents.
+// Fields left as their zero value do not restrict the events.
+type AuditEventFilter struct {
+	Action  entities.AuditAction
+	ActorID string
+	Target  string
+	From    time.Time
+	To      time.Time
+	Limit   int64
+}
+
+// AuditService is the service for interactions with the append-only audit log.
+// Events are only ever changed to remove the personal data of deleted users.
+type AuditService interface {
+	// LogEvent appends the given event to the audit log. The event's ID and timestamp are set by the service.
+	LogEvent(ctx context.Context, event entities.AuditEvent) error
+	// GetEvents returns the events matching the given filter, newest first
+	GetEvents(ctx context.Context, filter AuditEventFilter) ([]entities.AuditEvent, error)
+	// AnonymiseEventsForUser removes the personal data of the user with the given id from the audit log.
+	// Events targeting any of the given emails, compared case-insensitively, are retargeted to the user's id,
+	// the IP addresses and user agents of the events performed by or on the user are removed and so are
+	// the changed values of the events performed on the user
+	AnonymiseEventsForUser(ctx context.Context, userID string, emails []string) error
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/unicsmcr/hs_auth/authorization/v2/common"
 	"github.com/unicsmcr/hs_auth/entities"
+	"time"
 )
 
 // EmailServiceV2 is used to send out emails
@@ -13,4 +14,21 @@ type EmailServiceV2 interface {
 	SendEmailVerificationEmail(ctx context.Context, user entities.User, emailVerificationResources common.UniformResourceIdentifiers) error
 
 	SendPasswordResetEmail(ctx context.Context, user entities.User, passwordResetResources common.UniformResourceIdentifiers) error
+
+	SendAccountLockedEmail(ctx context.Context, user entities.User, lockedUntil time.Time) error
+
+	SendLoginLinkEmail(ctx context.Context, user entities.User, loginLinkResources common.UniformResourceIdentifiers) error
+
+	SendEmailChangeConfirmationEmail(ctx context.Context, user entities.User, newEmail string, emailChangeResources common.UniformResourceIdentifiers) error
+
+	SendEmailChangeNoticeEmail(ctx context.Context, user entities.User, newEmail string) error
+
+	// SendInvitationEmail invites a user whose account was created for them to set their password
+	SendInvitationEmail(ctx context.Context, user entities.User, passwordResetResources common.UniformResourceIdentifiers) error
+
+	// SendTeamJoinRequestEmail notifies the creator of a closed team that requester has asked to join it
+	SendTeamJoinRequestEmail(ctx context.Context, creator entities.User, requester entities.User, team entities.Team) error
+
+	// SendTeamJoinRequestDecisionEmail tells the user whether their request to join the team was approved
+	SendTeamJoinRequestDecisionEmail(ctx context.Context, user entities.User, team entities.Team, approved bool) error
 }
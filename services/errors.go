@@ -13,13 +13,60 @@ var (
 	// when Sendgrid rejects an email request
 	ErrSendgridRejectedRequest = errors.New("email request rejected by Sendgrid")
 
+	// Pagination errors
+	ErrInvalidCursor    = errors.New("invalid pagination cursor")
+	ErrInvalidSortField = errors.New("objects cannot be sorted by the given field")
+
 	// User service errors
 	ErrEmailTaken              = errors.New("email is already taken")
 	ErrNameTaken               = errors.New("name is already taken")
 	ErrInvalidToken            = errors.New("invalid auth token")
 	ErrInvalidUserUpdateParams = errors.New("invalid user update params")
+	ErrNoPendingEmailChange    = errors.New("user has no pending change to the given email")
+
+	// Password policy errors
+	ErrPasswordTooShort              = errors.New("password is too short")
+	ErrPasswordMissingCharacterClass = errors.New("password does not contain all required character classes")
+	ErrPasswordContainsPersonalInfo  = errors.New("password contains the user's name or email")
+	ErrPasswordBreached              = errors.New("password has appeared in a data breach")
 
 	// Team service errors
-	ErrUserInTeam    = errors.New("user is already in a team")
-	ErrUserNotInTeam = errors.New("user is not in a team")
+	ErrUserInTeam              = errors.New("user is already in a team")
+	ErrUserNotInTeam           = errors.New("user is not in a team")
+	ErrJoinCodeThrottled       = errors.New("too many wrong team join codes entered")
+	ErrTeamClosed              = errors.New("team can only be joined by requesting to join")
+	ErrInvalidTeamPrivacy      = errors.New("invalid team privacy setting")
+	ErrTeamFull                = errors.New("team has reached its member limit")
+	ErrInvalidMemberLimit      = errors.New("invalid team member limit")
+	ErrJoinRequestExists       = errors.New("user has already requested to join the team")
+	ErrUserIsTeamCreator       = errors.New("user is the team's creator")
+	ErrInvalidTeamUpdateParams = errors.New("invalid team update params")
+
+	// Matchmaking service errors
+	ErrInvalidMatchmakingProfile = errors.New("invalid matchmaking profile")
+	ErrInvalidTeamAdvert         = errors.New("invalid team advert")
+
+	// Login attempt service errors
+	ErrLoginThrottled = errors.New("login delayed after failed attempts")
+	ErrLoginLocked    = errors.New("login locked after too many failed attempts")
+	ErrLoginLinkSent  = errors.New("login link already sent to email recently")
+
+	// Two-factor service errors
+	ErrTwoFactorEnabled      = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled   = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolling = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode  = errors.New("invalid two-factor code")
+
+	// WebAuthn service errors
+	ErrInvalidWebAuthnCredential = errors.New("invalid WebAuthn credential")
+	ErrWebAuthnCredentialExists  = errors.New("WebAuthn credential is already registered")
+
+	// External identity service errors
+	ErrUnknownOAuthProvider   = errors.New("unknown OAuth provider")
+	ErrInvalidOAuthCode       = errors.New("invalid OAuth authorization code")
+	ErrExternalIdentityLinked = errors.New("external identity is already linked")
+
+	// Webhook service errors
+	ErrInvalidWebhookURL   = errors.New("invalid webhook url")
+	ErrInvalidWebhookEvent = errors.New("invalid webhook event")
 )
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+
+	"github.com/unicsmcr/hs_auth/entities"
+)
+
+// ExternalUser is the account of a user with an external identity provider
+type ExternalUser struct {
+	ID    string
+	Email string
+	Name  string
+}
+
+// OAuthProvider is an external identity provider users can log in with using OAuth2
+type OAuthProvider interface {
+	// Name returns the name identifying the provider
+	Name() string
+	// DisplayName returns the name of the provider shown to users
+	DisplayName() string
+	// AuthCodeURL returns the URL of the provider's consent page the user should be redirected to.
+	// The provider redirects the user back with the given state.
+	AuthCodeURL(state string) string
+	// Authenticate exchanges the authorization code returned by the provider for the user's account
+	// with the provider. Returns ErrInvalidOAuthCode if the code was rejected by the provider.
+	Authenticate(ctx context.Context, code string) (*ExternalUser, error)
+}
+
+// ExternalIdentityService is the service for linking accounts with external identity providers
+// to users and logging in with them
+type ExternalIdentityService interface {
+	// GetProviders returns the configured identity providers
+	GetProviders() []OAuthProvider
+	// GetAuthCodeURL returns the URL of the consent page of the provider with the given name.
+	// Returns ErrUnknownOAuthProvider if no such provider is configured.
+	GetAuthCodeURL(provider, state string) (string, error)
+	// Authenticate returns the user's account with the provider with the given name using the authorization code
+	// returned by the provider. Returns ErrUnknownOAuthProvider if no such provider is configured.
+	Authenticate(ctx context.Context, provider, code string) (*ExternalUser, error)
+	// LinkIdentity links the given account with the provider to the user with the given id.
+	// Returns ErrExternalIdentityLinked if the account or another account with the provider is already linked.
+	LinkIdentity(ctx context.Context, userID, provider string, externalUser ExternalUser) (*entities.ExternalIdentity, error)
+	// GetUserWithIdentity returns the user the account with the given id with the provider is linked to
+	GetUserWithIdentity(ctx context.Context, provider, externalID string) (*entities.User, error)
+	// GetIdentitiesForUser returns the accounts linked to the user with the given id
+	GetIdentitiesForUser(ctx context.Context, userID string) ([]entities.ExternalIdentity, error)
+	// UnlinkIdentityForUser unlinks the account with the given id, if it is linked to the user with the given id
+	UnlinkIdentityForUser(ctx context.Context, userID, id string) error
+	// UnlinkIdentitiesForUser unlinks all accounts linked to the user with the given id
+	UnlinkIdentitiesForUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+)
+
+// InvitationService is the service for managing the invitations users can register with to get a given role
+type InvitationService interface {
+	// CreateInvitation creates an invitation for the given role which can be used maxUses times until expiresAt.
+	// teamID is optional, users registering with the invitation are added to the team with the given id.
+	// createdBy is the id of the user creating the invitation
+	CreateInvitation(ctx context.Context, role role.UserRole, teamID string, maxUses int, expiresAt time.Time,
+		createdBy string) (*entities.Invitation, error)
+
+	// GetOutstandingInvitations returns the invitations which have not expired and have uses left
+	GetOutstandingInvitations(ctx context.Context) ([]entities.Invitation, error)
+	// GetInvitationWithCode returns the invitation with the given code.
+	// Returns ErrNotFound if the invitation has expired or has no uses left
+	GetInvitationWithCode(ctx context.Context, code string) (*entities.Invitation, error)
+
+	// UseInvitationWithCode records a use of the invitation with the given code and returns the invitation.
+	// Returns ErrNotFound if the invitation has expired or has no uses left
+	UseInvitationWithCode(ctx context.Context, code string) (*entities.Invitation, error)
+	// ReleaseInvitationUse takes back a use of the invitation with the given id,
+	// for when registering with the invitation failed after it was used
+	ReleaseInvitationUse(ctx context.Context, id string) error
+
+	// DeleteInvitationWithID revokes the invitation with the given id
+	DeleteInvitationWithID(ctx context.Context, id string) error
+}
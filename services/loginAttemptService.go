@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttemptService is the service for protecting logins against brute-force attacks.
+// Failed logins are counted both per account and per IP address.
+type LoginAttemptService interface {
+	// CheckLoginAllowed returns ErrLoginLocked or ErrLoginThrottled when a login for the given email
+	// from the given IP address should not be attempted, along with how long to wait before retrying
+	CheckLoginAllowed(ctx context.Context, email, ip string) (time.Duration, error)
+	// RecordFailedLogin counts a failed login for the given email and IP address, locking them
+	// when they have too many failures. The account's owner gets emailed when their account is locked.
+	RecordFailedLogin(ctx context.Context, email, ip string) error
+	// RecordSuccessfulLogin clears the failed logins for the given email
+	RecordSuccessfulLogin(ctx context.Context, email string) error
+	// UnlockAccount clears the failed logins and lockout for the given email
+	UnlockAccount(ctx context.Context, email string) error
+	// RecordLoginLinkRequest records that a login link is about to be sent to the given email.
+	// Returns ErrLoginLinkSent, along with how long to wait before retrying, when a link was requested
+	// for the email within the configured cooldown
+	RecordLoginLinkRequest(ctx context.Context, email string) (time.Duration, error)
+	// DeleteLoginAttemptsForEmail removes the failed logins, lockout and login link requests stored for the given email
+	DeleteLoginAttemptsForEmail(ctx context.Context, email string) error
+}
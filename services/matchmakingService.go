@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"github.com/unicsmcr/hs_auth/entities"
+)
+
+// MatchmakingService is the service for matching users looking for a team with teams looking for more members
+type MatchmakingService interface {
+	// GetProfileForUserWithID returns the matchmaking profile of the user with the given id.
+	// Returns ErrNotFound if the user has no profile
+	GetProfileForUserWithID(ctx context.Context, userID string) (*entities.MatchmakingProfile, error)
+	// SetProfileForUserWithID creates or replaces the matchmaking profile of the user with the given id.
+	// Returns ErrUserInTeam if the user is already in a team and ErrInvalidMatchmakingProfile if no skills
+	// are given or the profile is too long
+	SetProfileForUserWithID(ctx context.Context, userID string, skills []string, interests []string, pitch string) (*entities.MatchmakingProfile, error)
+	// DeleteProfileForUserWithID removes the user's profile from the board.
+	// Returns ErrNotFound if the user has no profile
+	DeleteProfileForUserWithID(ctx context.Context, userID string) error
+
+	// GetAdvertForTeamWithID returns the advert of the team with the given id.
+	// Returns ErrNotFound if the team has no advert
+	GetAdvertForTeamWithID(ctx context.Context, teamID string) (*entities.TeamAdvert, error)
+	// SetAdvertForTeamWithID creates or replaces the advert of the team with the given id.
+	// Returns ErrTeamFull if the team has reached its member limit and ErrInvalidTeamAdvert if no skills
+	// are given or the advert is too long
+	SetAdvertForTeamWithID(ctx context.Context, teamID string, skills []string, pitch string) (*entities.TeamAdvert, error)
+	// DeleteAdvertForTeamWithID removes the team's advert from the board.
+	// Returns ErrNotFound if the team has no advert
+	DeleteAdvertForTeamWithID(ctx context.Context, teamID string) error
+
+	// GetTeamMatchesForUserWithID returns the advertised teams with open slots, best matches for the
+	// user's profile first. Returns ErrNotFound if the user has no profile and ErrUserInTeam if the user
+	// is already in a team
+	GetTeamMatchesForUserWithID(ctx context.Context, userID string) ([]entities.TeamMatch, error)
+	// GetProfileMatchesForTeamWithID returns the profiles of users without a team, best matches for the
+	// team's advert first. Returns ErrNotFound if the team has no advert
+	GetProfileMatchesForTeamWithID(ctx context.Context, teamID string) ([]entities.ProfileMatch, error)
+
+	// AcceptTeamMatchForUserWithID adds the user with the given id to the advertised team with the given id
+	// and removes the user's profile. When the team is closed, a request to join it is made instead and returned.
+	// Returns ErrNotFound if the team has no advert, and the same errors as TeamService.AddUserWithIDToTeamWithID
+	// and TeamJoinRequestService.CreateJoinRequestForTeamWithID
+	AcceptTeamMatchForUserWithID(ctx context.Context, userID string, teamID string) (*entities.TeamJoinRequest, error)
+}
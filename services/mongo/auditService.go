@@ -0,0 +1,151 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type mongoAuditService struct {
+	logger               *zap.Logger
+	timeProvider         utils.TimeProvider
+	auditEventRepository *repositories.AuditEventRepository
+}
+
+// NewMongoAuditService creates a new AuditService that uses MongoDB as the storage technology
+func NewMongoAuditService(logger *zap.Logger, timeProvider utils.TimeProvider, auditEventRepository *repositories.AuditEventRepository) services.AuditService {
+	return &mongoAuditService{
+		logger:               logger,
+		timeProvider:         timeProvider,
+		auditEventRepository: auditEventRepository,
+	}
+}
+
+func (s *mongoAuditService) LogEvent(ctx context.Context, event entities.AuditEvent) error {
+	event.ID = primitive.NewObjectID()
+	event.Timestamp = s.timeProvider.Now()
+
+	_, err := s.auditEventRepository.InsertOne(ctx, event)
+	if err != nil {
+		return errors.Wrap(err, "could not insert audit event")
+	}
+
+	return nil
+}
+
+func (s *mongoAuditService) GetEvents(ctx context.Context, filter services.AuditEventFilter) ([]entities.AuditEvent, error) {
+	query := bson.M{}
+	if len(filter.Action) > 0 {
+		query[string(entities.AuditEventAction)] = filter.Action
+	}
+	if len(filter.ActorID) > 0 {
+		actorID, err := primitive.ObjectIDFromHex(filter.ActorID)
+		if err != nil {
+			return nil, services.ErrInvalidID
+		}
+		query[string(entities.AuditEventActor)] = actorID
+	}
+	if len(filter.Target) > 0 {
+		query[string(entities.AuditEventTarget)] = filter.Target
+	}
+
+	timestampQuery := bson.M{}
+	if !filter.From.IsZero() {
+		timestampQuery["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timestampQuery["$lte"] = filter.To
+	}
+	if len(timestampQuery) > 0 {
+		query[string(entities.AuditEventTimestamp)] = timestampQuery
+	}
+
+	findOptions := options.Find().SetSort(bson.M{string(entities.AuditEventTimestamp): -1})
+	if filter.Limit > 0 {
+		findOptions.SetLimit(filter.Limit)
+	}
+
+	cur, err := s.auditEventRepository.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for audit events")
+	}
+	defer cur.Close(ctx)
+
+	events, err := decodeAuditEventsResult(ctx, cur)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode result")
+	}
+
+	return events, nil
+}
+
+func (s *mongoAuditService) AnonymiseEventsForUser(ctx context.Context, userID string, emails []string) error {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	// failed logins are recorded against the email that was entered, in whichever case it was entered
+	if len(emails) > 0 {
+		_, err = s.auditEventRepository.UpdateMany(ctx, bson.M{
+			string(entities.AuditEventTarget): bson.M{"$in": emails},
+		}, bson.M{
+			"$set": bson.M{string(entities.AuditEventTarget): userID},
+		}, options.Update().SetCollation(&options.Collation{Locale: "en", Strength: 2}))
+		if err != nil {
+			return errors.Wrap(err, "could not retarget events targeting user's emails")
+		}
+	}
+
+	_, err = s.auditEventRepository.UpdateMany(ctx, bson.M{
+		"$or": []bson.M{
+			{string(entities.AuditEventActor): mongoID},
+			{string(entities.AuditEventTarget): userID},
+		},
+	}, bson.M{
+		"$unset": bson.M{
+			string(entities.AuditEventIP):        "",
+			string(entities.AuditEventUserAgent): "",
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not remove IP addresses and user agents of user's events")
+	}
+
+	_, err = s.auditEventRepository.UpdateMany(ctx, bson.M{
+		string(entities.AuditEventTarget): userID,
+	}, bson.M{
+		"$unset": bson.M{
+			string(entities.AuditEventBefore): "",
+			string(entities.AuditEventAfter):  "",
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not remove changed values of events targeting user")
+	}
+
+	return nil
+}
+
+func decodeAuditEventsResult(ctx context.Context, cur *mongo.Cursor) ([]entities.AuditEvent, error) {
+	events := []entities.AuditEvent{}
+	for cur.Next(ctx) {
+		var event entities.AuditEvent
+		err := cur.Decode(&event)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode audit event")
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,221 @@
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type auditTestSetup struct {
+	ctrl             *gomock.Controller
+	aService         *mongoAuditService
+	aRepo            *repositories.AuditEventRepository
+	mockTimeProvider *mock_utils.MockTimeProvider
+	cleanup          func()
+}
+
+func setupAuditTest(t *testing.T) *auditTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	aRepo, err := repositories.NewAuditEventRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+
+	aService := &mongoAuditService{
+		logger:               zap.NewNop(),
+		timeProvider:         mockTimeProvider,
+		auditEventRepository: aRepo,
+	}
+
+	return &auditTestSetup{
+		ctrl:             ctrl,
+		aService:         aService,
+		aRepo:            aRepo,
+		mockTimeProvider: mockTimeProvider,
+		cleanup: func() {
+			ctrl.Finish()
+			aRepo.Drop(context.Background())
+		},
+	}
+}
+
+func Test_NewMongoAuditService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoAuditService(nil, nil, nil))
+}
+
+func Test_LogEvent__should_insert_event_with_id_and_timestamp(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(100, 0)).Times(1)
+	actor := primitive.NewObjectID()
+
+	err := setup.aService.LogEvent(context.Background(), entities.AuditEvent{
+		Action: entities.AuditActionRoleSet,
+		Actor:  actor,
+		Target: "target",
+		Before: entities.AuditValues{"role": "applicant"},
+		After:  entities.AuditValues{"role": "attendee"},
+	})
+	assert.NoError(t, err)
+
+	var event entities.AuditEvent
+	err = setup.aRepo.FindOne(context.Background(), bson.M{}).Decode(&event)
+	assert.NoError(t, err)
+
+	assert.False(t, event.ID.IsZero())
+	assert.Equal(t, int64(100), event.Timestamp.Unix())
+	assert.Equal(t, entities.AuditActionRoleSet, event.Action)
+	assert.Equal(t, actor, event.Actor)
+	assert.Equal(t, "attendee", event.After["role"])
+}
+
+func Test_GetEvents__should_return_matching_events_newest_first(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+
+	actor := primitive.NewObjectID()
+	testEvents := []interface{}{
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(100, 0), Action: entities.AuditActionLogin, Actor: actor},
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(200, 0), Action: entities.AuditActionLogin, Actor: actor},
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(300, 0), Action: entities.AuditActionLogin, Actor: primitive.NewObjectID()},
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(400, 0), Action: entities.AuditActionLoginFailed, Target: "bob@email.com"},
+	}
+	_, err := setup.aRepo.InsertMany(context.Background(), testEvents)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name               string
+		filter             services.AuditEventFilter
+		expectedTimestamps []int64
+	}{
+		{
+			name:               "no filter",
+			expectedTimestamps: []int64{400, 300, 200, 100},
+		},
+		{
+			name:               "action",
+			filter:             services.AuditEventFilter{Action: entities.AuditActionLogin},
+			expectedTimestamps: []int64{300, 200, 100},
+		},
+		{
+			name:               "actor",
+			filter:             services.AuditEventFilter{ActorID: actor.Hex()},
+			expectedTimestamps: []int64{200, 100},
+		},
+		{
+			name:               "target",
+			filter:             services.AuditEventFilter{Target: "bob@email.com"},
+			expectedTimestamps: []int64{400},
+		},
+		{
+			name:               "time range",
+			filter:             services.AuditEventFilter{From: time.Unix(200, 0), To: time.Unix(300, 0)},
+			expectedTimestamps: []int64{300, 200},
+		},
+		{
+			name:               "limit",
+			filter:             services.AuditEventFilter{Limit: 1},
+			expectedTimestamps: []int64{400},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := setup.aService.GetEvents(context.Background(), tt.filter)
+			assert.NoError(t, err)
+
+			timestamps := make([]int64, len(events))
+			for i, event := range events {
+				timestamps[i] = event.Timestamp.Unix()
+			}
+			assert.Equal(t, tt.expectedTimestamps, timestamps)
+		})
+	}
+}
+
+func Test_GetEvents__should_return_ErrInvalidID_when_actor_id_is_invalid(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.aService.GetEvents(context.Background(), services.AuditEventFilter{ActorID: "invalid"})
+	assert.Equal(t, services.ErrInvalidID, err)
+}
+
+func Test_AnonymiseEventsForUser__should_remove_users_personal_data(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+
+	user := primitive.NewObjectID()
+	organiser := primitive.NewObjectID()
+	changes := entities.AuditValues{"email": "old@email.com"}
+	testEvents := []interface{}{
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(100, 0), Action: entities.AuditActionLoginFailed,
+			Target: "Bob@email.com", IP: "1.2.3.4", UserAgent: "browser"},
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(200, 0), Action: entities.AuditActionEmailChanged,
+			Actor: user, Target: user.Hex(), Before: changes, After: changes, IP: "1.2.3.4", UserAgent: "browser"},
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(300, 0), Action: entities.AuditActionRoleSet,
+			Actor: user, Target: organiser.Hex(), Before: changes, After: changes, IP: "1.2.3.4", UserAgent: "browser"},
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(400, 0), Action: entities.AuditActionRoleSet,
+			Actor: organiser, Target: organiser.Hex(), Before: changes, After: changes, IP: "5.6.7.8", UserAgent: "browser"},
+		entities.AuditEvent{ID: primitive.NewObjectID(), Timestamp: time.Unix(500, 0), Action: entities.AuditActionLoginFailed,
+			Target: "alice@email.com", IP: "5.6.7.8", UserAgent: "browser"},
+	}
+	_, err := setup.aRepo.InsertMany(context.Background(), testEvents)
+	assert.NoError(t, err)
+
+	err = setup.aService.AnonymiseEventsForUser(context.Background(), user.Hex(), []string{"bob@email.com", "old@email.com"})
+	assert.NoError(t, err)
+
+	events, err := setup.aService.GetEvents(context.Background(), services.AuditEventFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 5)
+
+	// the other users' events are unchanged
+	assert.Equal(t, "alice@email.com", events[0].Target)
+	assert.Equal(t, "5.6.7.8", events[0].IP)
+	assert.Equal(t, "5.6.7.8", events[1].IP)
+	assert.Equal(t, changes, events[1].After)
+
+	// the values changed by the user on others are kept
+	assert.Equal(t, organiser.Hex(), events[2].Target)
+	assert.Equal(t, changes, events[2].After)
+	assert.Empty(t, events[2].IP)
+	assert.Empty(t, events[2].UserAgent)
+
+	assert.Equal(t, user.Hex(), events[3].Target)
+	assert.Nil(t, events[3].Before)
+	assert.Nil(t, events[3].After)
+	assert.Empty(t, events[3].IP)
+	assert.Empty(t, events[3].UserAgent)
+
+	assert.Equal(t, entities.AuditActionLoginFailed, events[4].Action)
+	assert.Equal(t, user.Hex(), events[4].Target)
+	assert.Empty(t, events[4].IP)
+	assert.Empty(t, events[4].UserAgent)
+}
+
+func Test_AnonymiseEventsForUser__should_return_ErrInvalidID_when_user_id_is_invalid(t *testing.T) {
+	setup := setupAuditTest(t)
+	defer setup.cleanup()
+
+	err := setup.aService.AnonymiseEventsForUser(context.Background(), "invalid", nil)
+	assert.Equal(t, services.ErrInvalidID, err)
+}
@@ -0,0 +1,198 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type mongoExternalIdentityService struct {
+	logger                     *zap.Logger
+	timeProvider               utils.TimeProvider
+	externalIdentityRepository *repositories.ExternalIdentityRepository
+	userService                services.UserService
+	providers                  []services.OAuthProvider
+}
+
+// NewMongoExternalIdentityService creates a new ExternalIdentityService that stores the linked accounts in MongoDB
+func NewMongoExternalIdentityService(logger *zap.Logger, timeProvider utils.TimeProvider,
+	externalIdentityRepository *repositories.ExternalIdentityRepository, userService services.UserService,
+	providers []services.OAuthProvider) services.ExternalIdentityService {
+	return &mongoExternalIdentityService{
+		logger:                     logger,
+		timeProvider:               timeProvider,
+		externalIdentityRepository: externalIdentityRepository,
+		userService:                userService,
+		providers:                  providers,
+	}
+}
+
+func (s *mongoExternalIdentityService) GetProviders() []services.OAuthProvider {
+	return s.providers
+}
+
+func (s *mongoExternalIdentityService) GetAuthCodeURL(provider, state string) (string, error) {
+	oauthProvider, err := s.getProvider(provider)
+	if err != nil {
+		return "", err
+	}
+
+	return oauthProvider.AuthCodeURL(state), nil
+}
+
+func (s *mongoExternalIdentityService) Authenticate(ctx context.Context, provider, code string) (*services.ExternalUser, error) {
+	oauthProvider, err := s.getProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return oauthProvider.Authenticate(ctx, code)
+}
+
+func (s *mongoExternalIdentityService) LinkIdentity(ctx context.Context, userID, provider string, externalUser services.ExternalUser) (*entities.ExternalIdentity, error) {
+	_, err := s.getProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.externalIdentityRepository.CountDocuments(ctx, bson.M{
+		"$or": []bson.M{
+			{
+				string(entities.ExternalIdentityProvider):   provider,
+				string(entities.ExternalIdentityExternalID): externalUser.ID,
+			},
+			{
+				string(entities.ExternalIdentityUserID):   user.ID,
+				string(entities.ExternalIdentityProvider): provider,
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for external identity")
+	} else if count > 0 {
+		return nil, services.ErrExternalIdentityLinked
+	}
+
+	identity := &entities.ExternalIdentity{
+		ID:         primitive.NewObjectID(),
+		UserID:     user.ID,
+		Provider:   provider,
+		ExternalID: externalUser.ID,
+		Email:      externalUser.Email,
+		Name:       externalUser.Name,
+		LinkedAt:   s.timeProvider.Now(),
+	}
+
+	_, err = s.externalIdentityRepository.InsertOne(ctx, *identity)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not store external identity")
+	}
+
+	return identity, nil
+}
+
+func (s *mongoExternalIdentityService) GetUserWithIdentity(ctx context.Context, provider, externalID string) (*entities.User, error) {
+	var identity entities.ExternalIdentity
+	err := s.externalIdentityRepository.FindOne(ctx, bson.M{
+		string(entities.ExternalIdentityProvider):   provider,
+		string(entities.ExternalIdentityExternalID): externalID,
+	}).Decode(&identity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, services.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "could not query for external identity")
+	}
+
+	return s.userService.GetUserWithID(ctx, identity.UserID.Hex())
+}
+
+func (s *mongoExternalIdentityService) GetIdentitiesForUser(ctx context.Context, userID string) ([]entities.ExternalIdentity, error) {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	cur, err := s.externalIdentityRepository.Find(ctx, bson.M{
+		string(entities.ExternalIdentityUserID): mongoID,
+	}, options.Find().SetSort(bson.M{string(entities.ExternalIdentityLinkedAt): 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for external identities")
+	}
+	defer cur.Close(ctx)
+
+	identities := []entities.ExternalIdentity{}
+	for cur.Next(ctx) {
+		var identity entities.ExternalIdentity
+		err = cur.Decode(&identity)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode external identity")
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, nil
+}
+
+func (s *mongoExternalIdentityService) UnlinkIdentityForUser(ctx context.Context, userID, id string) error {
+	mongoUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+	mongoID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.externalIdentityRepository.DeleteOne(ctx, bson.M{
+		string(entities.ExternalIdentityID):     mongoID,
+		string(entities.ExternalIdentityUserID): mongoUserID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete external identity")
+	} else if res.DeletedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *mongoExternalIdentityService) UnlinkIdentitiesForUser(ctx context.Context, userID string) error {
+	mongoUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	_, err = s.externalIdentityRepository.DeleteMany(ctx, bson.M{
+		string(entities.ExternalIdentityUserID): mongoUserID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete external identities")
+	}
+
+	return nil
+}
+
+func (s *mongoExternalIdentityService) getProvider(name string) (services.OAuthProvider, error) {
+	for _, provider := range s.providers {
+		if provider.Name() == name {
+			return provider, nil
+		}
+	}
+
+	return nil, services.ErrUnknownOAuthProvider
+}
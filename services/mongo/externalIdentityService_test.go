@@ -0,0 +1,255 @@
+//go:build integration
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/environment"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/services/oauth"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var testLinkTime = time.Unix(1234567890, 0).UTC()
+
+type externalIdentityTestSetup struct {
+	eiService    *mongoExternalIdentityService
+	fakeProvider *testutils.OAuthProvider
+	testUser     entities.User
+	cleanup      func()
+}
+
+func setupExternalIdentityTest(t *testing.T) *externalIdentityTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	uRepo, err := repositories.NewUserRepository(db)
+	if err != nil {
+		panic(err)
+	}
+	eiRepo, err := repositories.NewExternalIdentityRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTimeProvider.EXPECT().Now().Return(testLinkTime).AnyTimes()
+
+	fakeProvider := testutils.NewOAuthProvider()
+	restoreVars := testutils.SetEnvVars(map[string]string{environment.OAuthClientSecret("test"): fakeProvider.ClientSecret})
+	defer restoreVars()
+
+	cfg := &config.AppConfig{
+		AppURL: "localhost:8000",
+		OAuthProviders: []config.OAuthProviderConfig{
+			{
+				Name:        "test",
+				ClientID:    fakeProvider.ClientID,
+				AuthURL:     fakeProvider.AuthURL(),
+				TokenURL:    fakeProvider.TokenURL(),
+				UserInfoURL: fakeProvider.UserInfoURL(),
+				IDField:     "id",
+				EmailField:  "email",
+				NameField:   "name",
+			},
+		},
+	}
+
+	eiService := &mongoExternalIdentityService{
+		logger:                     zap.NewNop(),
+		timeProvider:               mockTimeProvider,
+		externalIdentityRepository: eiRepo,
+		userService: &mongoUserService{
+			logger:         zap.NewNop(),
+			cfg:            cfg,
+			userRepository: uRepo,
+		},
+		providers: oauth.NewOAuthProviders(zap.NewNop(), cfg, environment.NewEnv(zap.NewNop()), utils.NewHTTPClient()),
+	}
+
+	user := entities.User{
+		ID:    primitive.NewObjectID(),
+		Name:  "Bob the Tester",
+		Email: "bob@email.com",
+	}
+	_, err = uRepo.InsertOne(context.Background(), user)
+	if err != nil {
+		panic(err)
+	}
+
+	return &externalIdentityTestSetup{
+		eiService:    eiService,
+		fakeProvider: fakeProvider,
+		testUser:     user,
+		cleanup: func() {
+			ctrl.Finish()
+			fakeProvider.Close()
+			uRepo.Drop(context.Background())
+			eiRepo.Drop(context.Background())
+		},
+	}
+}
+
+func Test_NewMongoExternalIdentityService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoExternalIdentityService(nil, nil, nil, nil, nil))
+}
+
+func Test_GetAuthCodeURL__should_return_ErrUnknownOAuthProvider_when_provider_is_not_configured(t *testing.T) {
+	setup := setupExternalIdentityTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.eiService.GetAuthCodeURL("unknown", "teststate")
+	assert.Equal(t, services.ErrUnknownOAuthProvider, err)
+}
+
+func Test_ExternalIdentityService_Authenticate__should_return_external_user(t *testing.T) {
+	setup := setupExternalIdentityTest(t)
+	defer setup.cleanup()
+	setup.fakeProvider.AddCode("testcode", map[string]interface{}{"id": 42, "email": "bob@github.com", "name": "bob"})
+
+	externalUser, err := setup.eiService.Authenticate(context.Background(), "test", "testcode")
+	assert.NoError(t, err)
+
+	assert.Equal(t, services.ExternalUser{ID: "42", Email: "bob@github.com", Name: "bob"}, *externalUser)
+}
+
+func Test_LinkIdentity__should_link_identity_and_log_user_in_with_it(t *testing.T) {
+	setup := setupExternalIdentityTest(t)
+	defer setup.cleanup()
+
+	identity, err := setup.eiService.LinkIdentity(context.Background(), setup.testUser.ID.Hex(), "test",
+		services.ExternalUser{ID: "42", Email: "bob@github.com", Name: "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, setup.testUser.ID, identity.UserID)
+	assert.Equal(t, testLinkTime, identity.LinkedAt)
+
+	user, err := setup.eiService.GetUserWithIdentity(context.Background(), "test", "42")
+	assert.NoError(t, err)
+	assert.Equal(t, setup.testUser.ID, user.ID)
+
+	identities, err := setup.eiService.GetIdentitiesForUser(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.ExternalIdentity{*identity}, identities)
+}
+
+func Test_LinkIdentity__should_return_error(t *testing.T) {
+	otherUser := entities.User{
+		ID:    primitive.NewObjectID(),
+		Name:  "Rob the Tester",
+		Email: "rob@email.com",
+	}
+
+	tests := []struct {
+		name         string
+		userID       string
+		provider     string
+		externalUser services.ExternalUser
+		wantErr      error
+	}{
+		{
+			name:     "ErrUnknownOAuthProvider when provider is not configured",
+			userID:   otherUser.ID.Hex(),
+			provider: "unknown",
+			wantErr:  services.ErrUnknownOAuthProvider,
+		},
+		{
+			name:     "ErrNotFound when user does not exist",
+			userID:   primitive.NewObjectID().Hex(),
+			provider: "test",
+			wantErr:  services.ErrNotFound,
+		},
+		{
+			name:         "ErrExternalIdentityLinked when account is linked to another user",
+			userID:       otherUser.ID.Hex(),
+			provider:     "test",
+			externalUser: services.ExternalUser{ID: "42"},
+			wantErr:      services.ErrExternalIdentityLinked,
+		},
+		{
+			name:         "ErrExternalIdentityLinked when user already linked an account with the provider",
+			provider:     "test",
+			externalUser: services.ExternalUser{ID: "43"},
+			wantErr:      services.ErrExternalIdentityLinked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupExternalIdentityTest(t)
+			defer setup.cleanup()
+			_, err := setup.eiService.userService.(*mongoUserService).userRepository.InsertOne(context.Background(), otherUser)
+			assert.NoError(t, err)
+			_, err = setup.eiService.LinkIdentity(context.Background(), setup.testUser.ID.Hex(), "test", services.ExternalUser{ID: "42"})
+			assert.NoError(t, err)
+
+			userID := tt.userID
+			if len(userID) == 0 {
+				userID = setup.testUser.ID.Hex()
+			}
+
+			_, err = setup.eiService.LinkIdentity(context.Background(), userID, tt.provider, tt.externalUser)
+			assert.Equal(t, tt.wantErr, errors.Cause(err))
+		})
+	}
+}
+
+func Test_GetUserWithIdentity__should_return_ErrNotFound_when_identity_is_not_linked(t *testing.T) {
+	setup := setupExternalIdentityTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.eiService.GetUserWithIdentity(context.Background(), "test", "42")
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_UnlinkIdentityForUser__should_unlink_identity(t *testing.T) {
+	setup := setupExternalIdentityTest(t)
+	defer setup.cleanup()
+	identity, err := setup.eiService.LinkIdentity(context.Background(), setup.testUser.ID.Hex(), "test", services.ExternalUser{ID: "42"})
+	assert.NoError(t, err)
+
+	err = setup.eiService.UnlinkIdentityForUser(context.Background(), setup.testUser.ID.Hex(), identity.ID.Hex())
+	assert.NoError(t, err)
+
+	_, err = setup.eiService.GetUserWithIdentity(context.Background(), "test", "42")
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_UnlinkIdentitiesForUser__should_unlink_all_identities_of_user(t *testing.T) {
+	setup := setupExternalIdentityTest(t)
+	defer setup.cleanup()
+	_, err := setup.eiService.LinkIdentity(context.Background(), setup.testUser.ID.Hex(), "test", services.ExternalUser{ID: "42"})
+	assert.NoError(t, err)
+
+	err = setup.eiService.UnlinkIdentitiesForUser(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+
+	identities, err := setup.eiService.GetIdentitiesForUser(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Empty(t, identities)
+
+	assert.Equal(t, services.ErrInvalidID, setup.eiService.UnlinkIdentitiesForUser(context.Background(), "invalid"))
+}
+
+func Test_UnlinkIdentityForUser__should_return_ErrNotFound_when_identity_belongs_to_another_user(t *testing.T) {
+	setup := setupExternalIdentityTest(t)
+	defer setup.cleanup()
+	identity, err := setup.eiService.LinkIdentity(context.Background(), setup.testUser.ID.Hex(), "test", services.ExternalUser{ID: "42"})
+	assert.NoError(t, err)
+
+	err = setup.eiService.UnlinkIdentityForUser(context.Background(), primitive.NewObjectID().Hex(), identity.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+}
@@ -0,0 +1,181 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const invitationCodeBytes = 16
+
+type mongoInvitationService struct {
+	logger               *zap.Logger
+	timeProvider         utils.TimeProvider
+	invitationRepository *repositories.InvitationRepository
+}
+
+// NewMongoInvitationService creates a new InvitationService that uses MongoDB as the storage technology
+func NewMongoInvitationService(logger *zap.Logger, timeProvider utils.TimeProvider,
+	invitationRepository *repositories.InvitationRepository) services.InvitationService {
+	return &mongoInvitationService{
+		logger:               logger,
+		timeProvider:         timeProvider,
+		invitationRepository: invitationRepository,
+	}
+}
+
+func (s *mongoInvitationService) CreateInvitation(ctx context.Context, role role.UserRole, teamID string, maxUses int,
+	expiresAt time.Time, createdBy string) (*entities.Invitation, error) {
+	creatorID, err := primitive.ObjectIDFromHex(createdBy)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	var teamMongoID primitive.ObjectID
+	if len(teamID) > 0 {
+		teamMongoID, err = primitive.ObjectIDFromHex(teamID)
+		if err != nil {
+			return nil, services.ErrInvalidID
+		}
+	}
+
+	code, err := utils.GenerateRandomHexString(invitationCodeBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate invitation code")
+	}
+
+	invitation := &entities.Invitation{
+		ID:        primitive.NewObjectID(),
+		Code:      code,
+		Role:      role,
+		Team:      teamMongoID,
+		MaxUses:   maxUses,
+		CreatedBy: creatorID,
+		CreatedAt: s.timeProvider.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	_, err = s.invitationRepository.InsertOne(ctx, *invitation)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create new invitation")
+	}
+
+	return invitation, nil
+}
+
+func (s *mongoInvitationService) GetOutstandingInvitations(ctx context.Context) ([]entities.Invitation, error) {
+	cur, err := s.invitationRepository.Find(ctx, s.usableInvitationsQuery(bson.M{}),
+		options.Find().SetSort(bson.M{string(entities.InvitationCreatedAt): -1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for invitations")
+	}
+	defer cur.Close(ctx)
+
+	invitations := []entities.Invitation{}
+	for cur.Next(ctx) {
+		var invitation entities.Invitation
+		err := cur.Decode(&invitation)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode invitation")
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+func (s *mongoInvitationService) GetInvitationWithCode(ctx context.Context, code string) (*entities.Invitation, error) {
+	var invitation entities.Invitation
+	err := s.invitationRepository.FindOne(ctx, s.usableInvitationsQuery(bson.M{
+		string(entities.InvitationCode): code,
+	})).Decode(&invitation)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for invitation with code")
+	}
+
+	return &invitation, nil
+}
+
+func (s *mongoInvitationService) UseInvitationWithCode(ctx context.Context, code string) (*entities.Invitation, error) {
+	// the uses are checked and incremented in one operation, so that concurrent
+	// registrations cannot use the invitation more than MaxUses times
+	var invitation entities.Invitation
+	err := s.invitationRepository.FindOneAndUpdate(ctx, s.usableInvitationsQuery(bson.M{
+		string(entities.InvitationCode): code,
+	}), bson.M{
+		"$inc": bson.M{
+			string(entities.InvitationUses): 1,
+		},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&invitation)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not use invitation with code")
+	}
+
+	return &invitation, nil
+}
+
+func (s *mongoInvitationService) ReleaseInvitationUse(ctx context.Context, id string) error {
+	mongoID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.invitationRepository.UpdateOne(ctx, bson.M{
+		string(entities.InvitationID):   mongoID,
+		string(entities.InvitationUses): bson.M{"$gt": 0},
+	}, bson.M{
+		"$inc": bson.M{
+			string(entities.InvitationUses): -1,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not release invitation use")
+	} else if res.MatchedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *mongoInvitationService) DeleteInvitationWithID(ctx context.Context, id string) error {
+	mongoID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.invitationRepository.DeleteOne(ctx, bson.M{
+		string(entities.InvitationID): mongoID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete invitation with ID")
+	} else if res.DeletedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	return nil
+}
+
+// usableInvitationsQuery restricts the query to invitations which have not expired and have uses left.
+// Expired invitations are removed by MongoDB's TTL monitor, but it only runs once a minute
+func (s *mongoInvitationService) usableInvitationsQuery(query bson.M) bson.M {
+	query[string(entities.InvitationExpiresAt)] = bson.M{"$gt": s.timeProvider.Now()}
+	query["$expr"] = bson.M{
+		"$lt": bson.A{"$" + string(entities.InvitationUses), "$" + string(entities.InvitationMaxUses)},
+	}
+	return query
+}
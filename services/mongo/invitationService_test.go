@@ -0,0 +1,233 @@
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var testInvitationTime = time.Unix(1000, 0)
+
+type invitationTestSetup struct {
+	ctrl     *gomock.Controller
+	iService *mongoInvitationService
+	iRepo    *repositories.InvitationRepository
+	cleanup  func()
+}
+
+func setupInvitationTest(t *testing.T) *invitationTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	iRepo, err := repositories.NewInvitationRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTimeProvider.EXPECT().Now().Return(testInvitationTime).AnyTimes()
+
+	return &invitationTestSetup{
+		ctrl: ctrl,
+		iService: &mongoInvitationService{
+			logger:               zap.NewNop(),
+			timeProvider:         mockTimeProvider,
+			invitationRepository: iRepo,
+		},
+		iRepo: iRepo,
+		cleanup: func() {
+			ctrl.Finish()
+			iRepo.Drop(context.Background())
+		},
+	}
+}
+
+func (setup *invitationTestSetup) insertInvitation(t *testing.T, code string, uses, maxUses int, expiresAt time.Time) entities.Invitation {
+	invitation := entities.Invitation{
+		ID:        primitive.NewObjectID(),
+		Code:      code,
+		Role:      role.Volunteer,
+		MaxUses:   maxUses,
+		Uses:      uses,
+		ExpiresAt: expiresAt,
+	}
+	_, err := setup.iRepo.InsertOne(context.Background(), invitation)
+	assert.NoError(t, err)
+	return invitation
+}
+
+func Test_NewMongoInvitationService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoInvitationService(nil, nil, nil))
+}
+
+func Test_CreateInvitation__should_return_ErrInvalidID(t *testing.T) {
+	tests := []struct {
+		name      string
+		teamID    string
+		createdBy string
+	}{
+		{
+			name:      "when creator id is invalid",
+			createdBy: "bob",
+		},
+		{
+			name:      "when team id is invalid",
+			teamID:    "bobs",
+			createdBy: primitive.NewObjectID().Hex(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupInvitationTest(t)
+			defer setup.cleanup()
+
+			_, err := setup.iService.CreateInvitation(context.Background(), role.Volunteer, tt.teamID, 1,
+				testInvitationTime.Add(time.Hour), tt.createdBy)
+			assert.Equal(t, services.ErrInvalidID, err)
+		})
+	}
+}
+
+func Test_CreateInvitation__should_store_invitation(t *testing.T) {
+	setup := setupInvitationTest(t)
+	defer setup.cleanup()
+	teamID := primitive.NewObjectID()
+	creatorID := primitive.NewObjectID()
+
+	invitation, err := setup.iService.CreateInvitation(context.Background(), role.Volunteer, teamID.Hex(), 3,
+		testInvitationTime.Add(time.Hour), creatorID.Hex())
+	assert.NoError(t, err)
+
+	assert.Len(t, invitation.Code, 2*invitationCodeBytes)
+	assert.Equal(t, role.Volunteer, invitation.Role)
+	assert.Equal(t, teamID, invitation.Team)
+	assert.Equal(t, 3, invitation.MaxUses)
+	assert.Equal(t, creatorID, invitation.CreatedBy)
+
+	storedInvitation, err := setup.iService.GetInvitationWithCode(context.Background(), invitation.Code)
+	assert.NoError(t, err)
+	assert.Equal(t, invitation.ID, storedInvitation.ID)
+}
+
+func Test_GetOutstandingInvitations__should_return_usable_invitations(t *testing.T) {
+	setup := setupInvitationTest(t)
+	defer setup.cleanup()
+	usableInvitation := setup.insertInvitation(t, "usable", 1, 2, testInvitationTime.Add(time.Hour))
+	setup.insertInvitation(t, "usedUp", 2, 2, testInvitationTime.Add(time.Hour))
+	setup.insertInvitation(t, "expired", 0, 2, testInvitationTime.Add(-time.Hour))
+
+	invitations, err := setup.iService.GetOutstandingInvitations(context.Background())
+	assert.NoError(t, err)
+
+	assert.Len(t, invitations, 1)
+	assert.Equal(t, usableInvitation.ID, invitations[0].ID)
+}
+
+func Test_GetInvitationWithCode__should_return_ErrNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{name: "when invitation does not exist", code: "unknown"},
+		{name: "when invitation has no uses left", code: "usedUp"},
+		{name: "when invitation has expired", code: "expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupInvitationTest(t)
+			defer setup.cleanup()
+			setup.insertInvitation(t, "usedUp", 2, 2, testInvitationTime.Add(time.Hour))
+			setup.insertInvitation(t, "expired", 0, 2, testInvitationTime.Add(-time.Hour))
+
+			_, err := setup.iService.GetInvitationWithCode(context.Background(), tt.code)
+			assert.Equal(t, services.ErrNotFound, err)
+		})
+	}
+}
+
+func Test_UseInvitationWithCode__should_use_invitation_until_it_has_no_uses_left(t *testing.T) {
+	setup := setupInvitationTest(t)
+	defer setup.cleanup()
+	setup.insertInvitation(t, "code", 0, 2, testInvitationTime.Add(time.Hour))
+
+	invitation, err := setup.iService.UseInvitationWithCode(context.Background(), "code")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, invitation.Uses)
+
+	invitation, err = setup.iService.UseInvitationWithCode(context.Background(), "code")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, invitation.Uses)
+
+	_, err = setup.iService.UseInvitationWithCode(context.Background(), "code")
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_ReleaseInvitationUse__should_make_invitation_usable_again(t *testing.T) {
+	setup := setupInvitationTest(t)
+	defer setup.cleanup()
+	invitation := setup.insertInvitation(t, "code", 1, 1, testInvitationTime.Add(time.Hour))
+
+	err := setup.iService.ReleaseInvitationUse(context.Background(), invitation.ID.Hex())
+	assert.NoError(t, err)
+
+	usedInvitation, err := setup.iService.UseInvitationWithCode(context.Background(), "code")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, usedInvitation.Uses)
+}
+
+func Test_ReleaseInvitationUse__should_return_ErrNotFound_when_invitation_is_unused(t *testing.T) {
+	setup := setupInvitationTest(t)
+	defer setup.cleanup()
+	invitation := setup.insertInvitation(t, "code", 0, 1, testInvitationTime.Add(time.Hour))
+
+	err := setup.iService.ReleaseInvitationUse(context.Background(), invitation.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_DeleteInvitationWithID__should_return_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{name: "when id is invalid", id: "bob", wantErr: services.ErrInvalidID},
+		{name: "when invitation does not exist", id: primitive.NewObjectID().Hex(), wantErr: services.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupInvitationTest(t)
+			defer setup.cleanup()
+
+			err := setup.iService.DeleteInvitationWithID(context.Background(), tt.id)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func Test_DeleteInvitationWithID__should_revoke_invitation(t *testing.T) {
+	setup := setupInvitationTest(t)
+	defer setup.cleanup()
+	invitation := setup.insertInvitation(t, "code", 0, 1, testInvitationTime.Add(time.Hour))
+
+	err := setup.iService.DeleteInvitationWithID(context.Background(), invitation.ID.Hex())
+	assert.NoError(t, err)
+
+	_, err = setup.iService.GetInvitationWithCode(context.Background(), "code")
+	assert.Equal(t, services.ErrNotFound, err)
+}
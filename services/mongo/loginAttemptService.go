@@ -0,0 +1,317 @@
+package mongo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	accountLoginAttemptsKeyPrefix = "account:"
+	ipLoginAttemptsKeyPrefix      = "ip:"
+	// login link requests are counted in the same collection as failed logins
+	loginLinkAttemptsKeyPrefix = "login_link:"
+)
+
+type mongoLoginAttemptService struct {
+	logger                  *zap.Logger
+	cfg                     *config.AppConfig
+	timeProvider            utils.TimeProvider
+	loginAttemptsRepository *repositories.LoginAttemptsRepository
+	userService             services.UserService
+	emailService            services.EmailServiceV2
+}
+
+// NewMongoLoginAttemptService creates a new LoginAttemptService that uses MongoDB as the storage technology,
+// so that failed logins are counted across all instances of hs_auth
+func NewMongoLoginAttemptService(logger *zap.Logger, cfg *config.AppConfig, timeProvider utils.TimeProvider,
+	loginAttemptsRepository *repositories.LoginAttemptsRepository, userService services.UserService,
+	emailService services.EmailServiceV2) services.LoginAttemptService {
+	return &mongoLoginAttemptService{
+		logger:                  logger,
+		cfg:                     cfg,
+		timeProvider:            timeProvider,
+		loginAttemptsRepository: loginAttemptsRepository,
+		userService:             userService,
+		emailService:            emailService,
+	}
+}
+
+func (s *mongoLoginAttemptService) CheckLoginAllowed(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := s.timeProvider.Now()
+
+	accountAttempts, err := s.getLoginAttempts(ctx, accountLoginAttemptsKey(email), now)
+	if err != nil {
+		return 0, err
+	}
+	ipAttempts, err := s.getLoginAttempts(ctx, ipLoginAttemptsKey(ip), now)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, attempts := range []*entities.LoginAttempts{accountAttempts, ipAttempts} {
+		if attempts != nil && attempts.LockedUntil.After(now) {
+			return attempts.LockedUntil.Sub(now), services.ErrLoginLocked
+		}
+	}
+
+	// only accounts are delayed, as many users can share the IP address of e.g. a university network
+	if accountAttempts != nil {
+		retryAt := accountAttempts.LastFailureAt.Add(s.loginDelay(accountAttempts.Failures))
+		if retryAt.After(now) {
+			return retryAt.Sub(now), services.ErrLoginThrottled
+		}
+	}
+
+	return 0, nil
+}
+
+func (s *mongoLoginAttemptService) RecordFailedLogin(ctx context.Context, email, ip string) error {
+	now := s.timeProvider.Now()
+
+	accountAttempts, err := s.incrementFailures(ctx, accountLoginAttemptsKey(email), now)
+	if err != nil {
+		return errors.Wrap(err, "could not record failed login for account")
+	}
+
+	maxAccountFailures := s.cfg.LoginProtection.MaxAccountFailures
+	if maxAccountFailures > 0 && accountAttempts.Failures >= maxAccountFailures {
+		locked, lockedUntil, err := s.lock(ctx, accountAttempts.Key, now)
+		if err != nil {
+			return errors.Wrap(err, "could not lock account")
+		}
+		if locked {
+			s.logger.Info("account locked after failed logins", zap.String("email", email), zap.Int("failures", accountAttempts.Failures))
+			s.sendAccountLockedEmail(ctx, email, lockedUntil)
+		}
+	}
+
+	ipAttempts, err := s.incrementFailures(ctx, ipLoginAttemptsKey(ip), now)
+	if err != nil {
+		return errors.Wrap(err, "could not record failed login for ip address")
+	}
+
+	maxIPFailures := s.cfg.LoginProtection.MaxIPFailures
+	if maxIPFailures > 0 && ipAttempts.Failures >= maxIPFailures {
+		locked, _, err := s.lock(ctx, ipAttempts.Key, now)
+		if err != nil {
+			return errors.Wrap(err, "could not lock ip address")
+		}
+		if locked {
+			s.logger.Info("ip address locked after failed logins", zap.String("ip", ip), zap.Int("failures", ipAttempts.Failures))
+		}
+	}
+
+	return nil
+}
+
+func (s *mongoLoginAttemptService) RecordSuccessfulLogin(ctx context.Context, email string) error {
+	_, err := s.loginAttemptsRepository.DeleteOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey): accountLoginAttemptsKey(email),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not clear failed logins for account")
+	}
+
+	return nil
+}
+
+func (s *mongoLoginAttemptService) UnlockAccount(ctx context.Context, email string) error {
+	_, err := s.loginAttemptsRepository.DeleteOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey): accountLoginAttemptsKey(email),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not unlock account")
+	}
+
+	return nil
+}
+
+func (s *mongoLoginAttemptService) RecordLoginLinkRequest(ctx context.Context, email string) (time.Duration, error) {
+	cooldown := time.Duration(s.cfg.LoginProtection.LoginLinkCooldown) * time.Second
+	if cooldown <= 0 {
+		return 0, nil
+	}
+
+	now := s.timeProvider.Now()
+	key := loginLinkAttemptsKeyPrefix + strings.ToLower(email)
+
+	// expired requests may not have been removed by the TTL monitor yet
+	_, err := s.loginAttemptsRepository.DeleteOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey):       key,
+		string(entities.LoginAttemptsExpiresAt): bson.M{"$lte": now},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "could not clear expired login link request")
+	}
+
+	// the request is only stored if there is no recent one, so concurrent requests cannot both be allowed
+	res := s.loginAttemptsRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.LoginAttemptsKey): key,
+	}, bson.M{
+		"$setOnInsert": bson.M{
+			string(entities.LoginAttemptsExpiresAt): now.Add(cooldown),
+		},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before))
+
+	var previousRequest entities.LoginAttempts
+	err = res.Decode(&previousRequest)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.Wrap(err, "could not record login link request")
+	}
+
+	return previousRequest.ExpiresAt.Sub(now), services.ErrLoginLinkSent
+}
+
+func (s *mongoLoginAttemptService) DeleteLoginAttemptsForEmail(ctx context.Context, email string) error {
+	_, err := s.loginAttemptsRepository.DeleteMany(ctx, bson.M{
+		string(entities.LoginAttemptsKey): bson.M{
+			"$in": []string{accountLoginAttemptsKey(email), loginLinkAttemptsKeyPrefix + strings.ToLower(email)},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete login attempts for email")
+	}
+
+	return nil
+}
+
+// getLoginAttempts returns the failed logins stored under the given key, or nil if there are none
+func (s *mongoLoginAttemptService) getLoginAttempts(ctx context.Context, key string, now time.Time) (*entities.LoginAttempts, error) {
+	res := s.loginAttemptsRepository.FindOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey):       key,
+		string(entities.LoginAttemptsExpiresAt): bson.M{"$gt": now},
+	})
+
+	err := res.Err()
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for failed logins")
+	}
+
+	var attempts entities.LoginAttempts
+	err = res.Decode(&attempts)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode failed logins")
+	}
+
+	return &attempts, nil
+}
+
+// incrementFailures counts a failed login under the given key and returns the updated failed logins
+func (s *mongoLoginAttemptService) incrementFailures(ctx context.Context, key string, now time.Time) (*entities.LoginAttempts, error) {
+	// expired failures may not have been removed by the TTL monitor yet, so they have to be cleared
+	// before counting the new failure
+	_, err := s.loginAttemptsRepository.DeleteOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey):       key,
+		string(entities.LoginAttemptsExpiresAt): bson.M{"$lte": now},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not clear expired failed logins")
+	}
+
+	res := s.loginAttemptsRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.LoginAttemptsKey): key,
+	}, bson.M{
+		"$inc": bson.M{
+			string(entities.LoginAttemptsFailures): 1,
+		},
+		"$set": bson.M{
+			string(entities.LoginAttemptsLastFailureAt): now,
+		},
+		"$max": bson.M{
+			string(entities.LoginAttemptsExpiresAt): now.Add(time.Duration(s.cfg.LoginProtection.FailureWindow) * time.Second),
+		},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After))
+
+	var attempts entities.LoginAttempts
+	err = res.Decode(&attempts)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not increment failed logins")
+	}
+
+	return &attempts, nil
+}
+
+// lock locks the given key, unless it is already locked. The failed logins are forgotten once the lock expires.
+// Returns whether the key got locked by this call, so that the account's owner only gets notified once.
+func (s *mongoLoginAttemptService) lock(ctx context.Context, key string, now time.Time) (bool, time.Time, error) {
+	lockedUntil := now.Add(time.Duration(s.cfg.LoginProtection.LockoutDuration) * time.Second)
+
+	res, err := s.loginAttemptsRepository.UpdateOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey): key,
+		"$or": []bson.M{
+			{string(entities.LoginAttemptsLockedUntil): bson.M{"$exists": false}},
+			{string(entities.LoginAttemptsLockedUntil): bson.M{"$lte": now}},
+		},
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.LoginAttemptsLockedUntil): lockedUntil,
+			string(entities.LoginAttemptsExpiresAt):   lockedUntil,
+		},
+	})
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	return res.ModifiedCount == 1, lockedUntil, nil
+}
+
+// loginDelay returns how long to wait after the last of the given number of failed logins,
+// doubling with every failure after the configured number of failures
+func (s *mongoLoginAttemptService) loginDelay(failures int) time.Duration {
+	cfg := s.cfg.LoginProtection
+	if cfg.DelayAfterFailures <= 0 || failures < cfg.DelayAfterFailures {
+		return 0
+	}
+
+	delay := time.Duration(cfg.BaseDelay) * time.Second
+	maxDelay := time.Duration(cfg.MaxDelay) * time.Second
+	for i := cfg.DelayAfterFailures; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+// sendAccountLockedEmail notifies the owner of the account with the given email that the account got locked.
+// Failing to send the email should not fail the login, so errors are only logged.
+func (s *mongoLoginAttemptService) sendAccountLockedEmail(ctx context.Context, email string, lockedUntil time.Time) {
+	user, err := s.userService.GetUserWithEmail(ctx, email)
+	if err != nil {
+		if errors.Cause(err) != services.ErrNotFound {
+			s.logger.Error("could not fetch user for account locked email", zap.String("email", email), zap.Error(err))
+		}
+		return
+	}
+
+	err = s.emailService.SendAccountLockedEmail(ctx, *user, lockedUntil)
+	if err != nil {
+		s.logger.Error("could not send account locked email", zap.String("email", email), zap.Error(err))
+	}
+}
+
+func accountLoginAttemptsKey(email string) string {
+	return accountLoginAttemptsKeyPrefix + strings.ToLower(email)
+}
+
+func ipLoginAttemptsKey(ip string) string {
+	return ipLoginAttemptsKeyPrefix + ip
+}
@@ -0,0 +1,373 @@
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+const (
+	testLoginEmail = "bob@email.com"
+	testLoginIP    = "10.0.0.1"
+)
+
+type loginAttemptTestSetup struct {
+	ctrl             *gomock.Controller
+	lService         *mongoLoginAttemptService
+	laRepo           *repositories.LoginAttemptsRepository
+	mockUService     *mock_services.MockUserService
+	mockEService     *mock_services.MockEmailServiceV2
+	mockTimeProvider *mock_utils.MockTimeProvider
+	cleanup          func()
+}
+
+func setupLoginAttemptTest(t *testing.T) *loginAttemptTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	laRepo, err := repositories.NewLoginAttemptsRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockUService := mock_services.NewMockUserService(ctrl)
+	mockEService := mock_services.NewMockEmailServiceV2(ctrl)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+
+	lService := &mongoLoginAttemptService{
+		logger: zap.NewNop(),
+		cfg: &config.AppConfig{
+			LoginProtection: config.LoginProtectionConfig{
+				DelayAfterFailures: 2,
+				BaseDelay:          1,
+				MaxDelay:           4,
+				MaxAccountFailures: 5,
+				MaxIPFailures:      8,
+				LockoutDuration:    100,
+				FailureWindow:      1000,
+				LoginLinkCooldown:  60,
+			},
+		},
+		timeProvider:            mockTimeProvider,
+		loginAttemptsRepository: laRepo,
+		userService:             mockUService,
+		emailService:            mockEService,
+	}
+
+	return &loginAttemptTestSetup{
+		ctrl:             ctrl,
+		lService:         lService,
+		laRepo:           laRepo,
+		mockUService:     mockUService,
+		mockEService:     mockEService,
+		mockTimeProvider: mockTimeProvider,
+		cleanup: func() {
+			ctrl.Finish()
+			laRepo.Drop(context.Background())
+		},
+	}
+}
+
+func (setup *loginAttemptTestSetup) insertLoginAttempts(t *testing.T, attempts ...entities.LoginAttempts) {
+	for _, attempt := range attempts {
+		_, err := setup.laRepo.InsertOne(context.Background(), attempt)
+		assert.NoError(t, err)
+	}
+}
+
+func (setup *loginAttemptTestSetup) getLoginAttempts(t *testing.T, key string) *entities.LoginAttempts {
+	var attempts entities.LoginAttempts
+	err := setup.laRepo.FindOne(context.Background(), bson.M{
+		string(entities.LoginAttemptsKey): key,
+	}).Decode(&attempts)
+	if err != nil {
+		return nil
+	}
+	return &attempts
+}
+
+func Test_NewMongoLoginAttemptService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoLoginAttemptService(nil, nil, nil, nil, nil, nil))
+}
+
+func Test_CheckLoginAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts []entities.LoginAttempts
+		wantWait time.Duration
+		wantErr  error
+	}{
+		{
+			name: "should allow login without failed logins",
+		},
+		{
+			name: "should allow login when failures are below the delay threshold",
+			attempts: []entities.LoginAttempts{
+				{Key: "account:" + testLoginEmail, Failures: 1, LastFailureAt: time.Unix(1000, 0), ExpiresAt: time.Unix(2000, 0)},
+			},
+		},
+		{
+			name: "should return ErrLoginThrottled when account's last failure was too recent",
+			attempts: []entities.LoginAttempts{
+				{Key: "account:" + testLoginEmail, Failures: 3, LastFailureAt: time.Unix(999, 0), ExpiresAt: time.Unix(2000, 0)},
+			},
+			wantWait: time.Second,
+			wantErr:  services.ErrLoginThrottled,
+		},
+		{
+			name: "should allow login when account's delay has passed",
+			attempts: []entities.LoginAttempts{
+				{Key: "account:" + testLoginEmail, Failures: 3, LastFailureAt: time.Unix(990, 0), ExpiresAt: time.Unix(2000, 0)},
+			},
+		},
+		{
+			name: "should return ErrLoginLocked when account is locked",
+			attempts: []entities.LoginAttempts{
+				{Key: "account:" + testLoginEmail, Failures: 5, LastFailureAt: time.Unix(990, 0), LockedUntil: time.Unix(1050, 0), ExpiresAt: time.Unix(1050, 0)},
+			},
+			wantWait: 50 * time.Second,
+			wantErr:  services.ErrLoginLocked,
+		},
+		{
+			name: "should return ErrLoginLocked when ip address is locked",
+			attempts: []entities.LoginAttempts{
+				{Key: "ip:" + testLoginIP, Failures: 8, LastFailureAt: time.Unix(990, 0), LockedUntil: time.Unix(1020, 0), ExpiresAt: time.Unix(1020, 0)},
+			},
+			wantWait: 20 * time.Second,
+			wantErr:  services.ErrLoginLocked,
+		},
+		{
+			name: "should ignore expired failed logins",
+			attempts: []entities.LoginAttempts{
+				{Key: "account:" + testLoginEmail, Failures: 5, LastFailureAt: time.Unix(900, 0), LockedUntil: time.Unix(950, 0), ExpiresAt: time.Unix(950, 0)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupLoginAttemptTest(t)
+			defer setup.cleanup()
+			setup.insertLoginAttempts(t, tt.attempts...)
+			setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+
+			wait, err := setup.lService.CheckLoginAllowed(context.Background(), testLoginEmail, testLoginIP)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantWait, wait)
+		})
+	}
+}
+
+func Test_RecordFailedLogin__should_count_failures_per_account_and_ip(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(2)
+
+	err := setup.lService.RecordFailedLogin(context.Background(), "Bob@email.com", testLoginIP)
+	assert.NoError(t, err)
+	err = setup.lService.RecordFailedLogin(context.Background(), testLoginEmail, testLoginIP)
+	assert.NoError(t, err)
+
+	accountAttempts := setup.getLoginAttempts(t, "account:"+testLoginEmail)
+	assert.NotNil(t, accountAttempts)
+	assert.Equal(t, 2, accountAttempts.Failures)
+	assert.Equal(t, int64(2000), accountAttempts.ExpiresAt.Unix())
+
+	ipAttempts := setup.getLoginAttempts(t, "ip:"+testLoginIP)
+	assert.NotNil(t, ipAttempts)
+	assert.Equal(t, 2, ipAttempts.Failures)
+}
+
+func Test_RecordFailedLogin__should_reset_expired_failures(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.insertLoginAttempts(t, entities.LoginAttempts{
+		Key: "account:" + testLoginEmail, Failures: 4, LastFailureAt: time.Unix(100, 0), ExpiresAt: time.Unix(500, 0),
+	})
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+
+	err := setup.lService.RecordFailedLogin(context.Background(), testLoginEmail, testLoginIP)
+	assert.NoError(t, err)
+
+	accountAttempts := setup.getLoginAttempts(t, "account:"+testLoginEmail)
+	assert.NotNil(t, accountAttempts)
+	assert.Equal(t, 1, accountAttempts.Failures)
+}
+
+func Test_RecordFailedLogin__should_lock_account_and_send_email_once(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.insertLoginAttempts(t, entities.LoginAttempts{
+		Key: "account:" + testLoginEmail, Failures: 4, LastFailureAt: time.Unix(900, 0), ExpiresAt: time.Unix(1900, 0),
+	})
+	testUser := entities.User{Name: "Bob", Email: testLoginEmail}
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(2)
+	setup.mockUService.EXPECT().GetUserWithEmail(gomock.Any(), testLoginEmail).Return(&testUser, nil).Times(1)
+	setup.mockEService.EXPECT().SendAccountLockedEmail(gomock.Any(), testUser, time.Unix(1100, 0)).Return(nil).Times(1)
+
+	err := setup.lService.RecordFailedLogin(context.Background(), testLoginEmail, testLoginIP)
+	assert.NoError(t, err)
+	err = setup.lService.RecordFailedLogin(context.Background(), testLoginEmail, testLoginIP)
+	assert.NoError(t, err)
+
+	accountAttempts := setup.getLoginAttempts(t, "account:"+testLoginEmail)
+	assert.NotNil(t, accountAttempts)
+	assert.Equal(t, int64(1100), accountAttempts.LockedUntil.Unix())
+}
+
+func Test_RecordFailedLogin__should_lock_account_without_email_when_user_does_not_exist(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.insertLoginAttempts(t, entities.LoginAttempts{
+		Key: "account:" + testLoginEmail, Failures: 4, LastFailureAt: time.Unix(900, 0), ExpiresAt: time.Unix(1900, 0),
+	})
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+	setup.mockUService.EXPECT().GetUserWithEmail(gomock.Any(), testLoginEmail).Return(nil, services.ErrNotFound).Times(1)
+
+	err := setup.lService.RecordFailedLogin(context.Background(), testLoginEmail, testLoginIP)
+	assert.NoError(t, err)
+
+	accountAttempts := setup.getLoginAttempts(t, "account:"+testLoginEmail)
+	assert.NotNil(t, accountAttempts)
+	assert.Equal(t, int64(1100), accountAttempts.LockedUntil.Unix())
+}
+
+func Test_RecordFailedLogin__should_lock_ip_address(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.insertLoginAttempts(t, entities.LoginAttempts{
+		Key: "ip:" + testLoginIP, Failures: 7, LastFailureAt: time.Unix(900, 0), ExpiresAt: time.Unix(1900, 0),
+	})
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+
+	err := setup.lService.RecordFailedLogin(context.Background(), testLoginEmail, testLoginIP)
+	assert.NoError(t, err)
+
+	ipAttempts := setup.getLoginAttempts(t, "ip:"+testLoginIP)
+	assert.NotNil(t, ipAttempts)
+	assert.Equal(t, int64(1100), ipAttempts.LockedUntil.Unix())
+}
+
+func Test_RecordSuccessfulLogin__should_clear_account_failures_only(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.insertLoginAttempts(t,
+		entities.LoginAttempts{Key: "account:" + testLoginEmail, Failures: 3, ExpiresAt: time.Unix(1900, 0)},
+		entities.LoginAttempts{Key: "ip:" + testLoginIP, Failures: 3, ExpiresAt: time.Unix(1900, 0)},
+	)
+
+	err := setup.lService.RecordSuccessfulLogin(context.Background(), "Bob@email.com")
+	assert.NoError(t, err)
+
+	assert.Nil(t, setup.getLoginAttempts(t, "account:"+testLoginEmail))
+	assert.NotNil(t, setup.getLoginAttempts(t, "ip:"+testLoginIP))
+}
+
+func Test_UnlockAccount__should_clear_account_lockout(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.insertLoginAttempts(t, entities.LoginAttempts{
+		Key: "account:" + testLoginEmail, Failures: 5, LockedUntil: time.Unix(1100, 0), ExpiresAt: time.Unix(1100, 0),
+	})
+
+	err := setup.lService.UnlockAccount(context.Background(), testLoginEmail)
+	assert.NoError(t, err)
+
+	assert.Nil(t, setup.getLoginAttempts(t, "account:"+testLoginEmail))
+}
+
+func Test_DeleteLoginAttemptsForEmail__should_clear_account_and_login_link_attempts_only(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.insertLoginAttempts(t,
+		entities.LoginAttempts{Key: "account:" + testLoginEmail, Failures: 3, ExpiresAt: time.Unix(1900, 0)},
+		entities.LoginAttempts{Key: "login_link:" + testLoginEmail, ExpiresAt: time.Unix(1900, 0)},
+		entities.LoginAttempts{Key: "account:other@email.com", Failures: 3, ExpiresAt: time.Unix(1900, 0)},
+		entities.LoginAttempts{Key: "ip:" + testLoginIP, Failures: 3, ExpiresAt: time.Unix(1900, 0)},
+	)
+
+	err := setup.lService.DeleteLoginAttemptsForEmail(context.Background(), "Bob@email.com")
+	assert.NoError(t, err)
+
+	assert.Nil(t, setup.getLoginAttempts(t, "account:"+testLoginEmail))
+	assert.Nil(t, setup.getLoginAttempts(t, "login_link:"+testLoginEmail))
+	assert.NotNil(t, setup.getLoginAttempts(t, "account:other@email.com"))
+	assert.NotNil(t, setup.getLoginAttempts(t, "ip:"+testLoginIP))
+}
+
+func Test_RecordLoginLinkRequest__should_allow_one_request_per_cooldown(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(2)
+	wait, err := setup.lService.RecordLoginLinkRequest(context.Background(), "Bob@email.com")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), wait)
+
+	wait, err = setup.lService.RecordLoginLinkRequest(context.Background(), testLoginEmail)
+	assert.Equal(t, services.ErrLoginLinkSent, err)
+	assert.Equal(t, 60*time.Second, wait)
+
+	// login link requests are not counted as failed logins
+	assert.Nil(t, setup.getLoginAttempts(t, "account:"+testLoginEmail))
+}
+
+func Test_RecordLoginLinkRequest__should_allow_request_after_cooldown(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.insertLoginAttempts(t, entities.LoginAttempts{Key: "login_link:" + testLoginEmail, ExpiresAt: time.Unix(1000, 0)})
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1000, 0)).Times(1)
+	_, err := setup.lService.RecordLoginLinkRequest(context.Background(), testLoginEmail)
+	assert.NoError(t, err)
+
+	attempts := setup.getLoginAttempts(t, "login_link:"+testLoginEmail)
+	assert.NotNil(t, attempts)
+	assert.Equal(t, time.Unix(1060, 0).UTC(), attempts.ExpiresAt.UTC())
+}
+
+func Test_RecordLoginLinkRequest__should_allow_every_request_when_cooldown_is_disabled(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+	setup.lService.cfg.LoginProtection.LoginLinkCooldown = 0
+
+	for i := 0; i < 2; i++ {
+		_, err := setup.lService.RecordLoginLinkRequest(context.Background(), testLoginEmail)
+		assert.NoError(t, err)
+	}
+}
+
+func Test_loginDelay(t *testing.T) {
+	setup := setupLoginAttemptTest(t)
+	defer setup.cleanup()
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 2, want: time.Second},
+		{failures: 3, want: 2 * time.Second},
+		{failures: 4, want: 4 * time.Second},
+		{failures: 10, want: 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, setup.lService.loginDelay(tt.failures))
+	}
+}
@@ -0,0 +1,432 @@
+package mongo
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	maxMatchmakingTags        = 20
+	maxMatchmakingTagLength   = 50
+	maxMatchmakingPitchLength = 500
+)
+
+type mongoMatchmakingService struct {
+	logger                       *zap.Logger
+	cfg                          *config.AppConfig
+	timeProvider                 utils.TimeProvider
+	matchmakingProfileRepository *repositories.MatchmakingProfileRepository
+	teamAdvertRepository         *repositories.TeamAdvertRepository
+	userService                  services.UserService
+	teamService                  services.TeamService
+	teamJoinRequestService       services.TeamJoinRequestService
+}
+
+// NewMongoMatchmakingService creates a new MatchmakingService that uses MongoDB as the storage technology
+func NewMongoMatchmakingService(logger *zap.Logger, cfg *config.AppConfig, timeProvider utils.TimeProvider,
+	matchmakingProfileRepository *repositories.MatchmakingProfileRepository, teamAdvertRepository *repositories.TeamAdvertRepository,
+	userService services.UserService, teamService services.TeamService, teamJoinRequestService services.TeamJoinRequestService) services.MatchmakingService {
+	return &mongoMatchmakingService{
+		logger:                       logger,
+		cfg:                          cfg,
+		timeProvider:                 timeProvider,
+		matchmakingProfileRepository: matchmakingProfileRepository,
+		teamAdvertRepository:         teamAdvertRepository,
+		userService:                  userService,
+		teamService:                  teamService,
+		teamJoinRequestService:       teamJoinRequestService,
+	}
+}
+
+func (s *mongoMatchmakingService) GetProfileForUserWithID(ctx context.Context, userID string) (*entities.MatchmakingProfile, error) {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	var profile entities.MatchmakingProfile
+	err = s.matchmakingProfileRepository.FindOne(ctx, bson.M{
+		string(entities.MatchmakingProfileUser): mongoID,
+	}).Decode(&profile)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for matchmaking profile")
+	}
+
+	return &profile, nil
+}
+
+func (s *mongoMatchmakingService) SetProfileForUserWithID(ctx context.Context, userID string, skills []string, interests []string, pitch string) (*entities.MatchmakingProfile, error) {
+	skills, interests = normaliseMatchmakingTags(skills), normaliseMatchmakingTags(interests)
+	if len(skills) == 0 || !validMatchmakingTags(skills) || !validMatchmakingTags(interests) || !validMatchmakingPitch(pitch) {
+		return nil, services.ErrInvalidMatchmakingProfile
+	}
+
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Team != primitive.NilObjectID {
+		return nil, services.ErrUserInTeam
+	}
+
+	var profile entities.MatchmakingProfile
+	err = s.matchmakingProfileRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.MatchmakingProfileUser): user.ID,
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.MatchmakingProfileSkills):    skills,
+			string(entities.MatchmakingProfileInterests): interests,
+			string(entities.MatchmakingProfilePitch):     pitch,
+			string(entities.MatchmakingProfileUpdatedAt): s.timeProvider.Now(),
+		},
+		"$setOnInsert": bson.M{
+			string(entities.MatchmakingProfileID): primitive.NewObjectID(),
+		},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&profile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not save matchmaking profile")
+	}
+
+	return &profile, nil
+}
+
+func (s *mongoMatchmakingService) DeleteProfileForUserWithID(ctx context.Context, userID string) error {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.matchmakingProfileRepository.DeleteOne(ctx, bson.M{
+		string(entities.MatchmakingProfileUser): mongoID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete matchmaking profile")
+	} else if res.DeletedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *mongoMatchmakingService) GetAdvertForTeamWithID(ctx context.Context, teamID string) (*entities.TeamAdvert, error) {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	var advert entities.TeamAdvert
+	err = s.teamAdvertRepository.FindOne(ctx, bson.M{
+		string(entities.TeamAdvertTeam): mongoID,
+	}).Decode(&advert)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for team advert")
+	}
+
+	return &advert, nil
+}
+
+func (s *mongoMatchmakingService) SetAdvertForTeamWithID(ctx context.Context, teamID string, skills []string, pitch string) (*entities.TeamAdvert, error) {
+	skills = normaliseMatchmakingTags(skills)
+	if len(skills) == 0 || !validMatchmakingTags(skills) || !validMatchmakingPitch(pitch) {
+		return nil, services.ErrInvalidTeamAdvert
+	}
+
+	team, err := s.teamService.GetTeamWithID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, open := s.openSlots(*team); !open {
+		return nil, services.ErrTeamFull
+	}
+
+	var advert entities.TeamAdvert
+	err = s.teamAdvertRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.TeamAdvertTeam): team.ID,
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.TeamAdvertSkills):    skills,
+			string(entities.TeamAdvertPitch):     pitch,
+			string(entities.TeamAdvertUpdatedAt): s.timeProvider.Now(),
+		},
+		"$setOnInsert": bson.M{
+			string(entities.TeamAdvertID): primitive.NewObjectID(),
+		},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&advert)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not save team advert")
+	}
+
+	return &advert, nil
+}
+
+func (s *mongoMatchmakingService) DeleteAdvertForTeamWithID(ctx context.Context, teamID string) error {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.teamAdvertRepository.DeleteOne(ctx, bson.M{
+		string(entities.TeamAdvertTeam): mongoID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete team advert")
+	} else if res.DeletedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *mongoMatchmakingService) GetTeamMatchesForUserWithID(ctx context.Context, userID string) ([]entities.TeamMatch, error) {
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Team != primitive.NilObjectID {
+		return nil, services.ErrUserInTeam
+	}
+
+	profile, err := s.GetProfileForUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	adverts, err := s.findAdverts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []entities.TeamMatch{}
+	for _, advert := range adverts {
+		team, err := s.teamService.GetTeamWithID(ctx, advert.Team.Hex())
+		if errors.Cause(err) == services.ErrNotFound {
+			// the team has been deleted since it was advertised
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		openSlots, open := s.openSlots(*team)
+		if !open {
+			continue
+		}
+
+		sharedSkills := sharedMatchmakingTags(advert.Skills, profile.Skills)
+		matches = append(matches, entities.TeamMatch{
+			Advert:       advert,
+			TeamName:     team.Name,
+			OpenSlots:    openSlots,
+			SharedSkills: sharedSkills,
+			Score:        len(sharedSkills),
+		})
+	}
+
+	// adverts are sorted by most recently updated, which is kept for matches with the same score
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches, nil
+}
+
+func (s *mongoMatchmakingService) GetProfileMatchesForTeamWithID(ctx context.Context, teamID string) ([]entities.ProfileMatch, error) {
+	advert, err := s.GetAdvertForTeamWithID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	// profiles of users who have joined a team since creating them are left out
+	userNames := map[primitive.ObjectID]string{}
+	err = s.userService.StreamUsers(ctx, services.UserFilter{TeamID: services.NoTeam}, func(user entities.User) error {
+		userNames[user.ID] = user.Name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := s.findProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []entities.ProfileMatch{}
+	for _, profile := range profiles {
+		userName, ok := userNames[profile.User]
+		if !ok {
+			continue
+		}
+
+		sharedSkills := sharedMatchmakingTags(advert.Skills, profile.Skills)
+		matches = append(matches, entities.ProfileMatch{
+			Profile:      profile,
+			UserName:     userName,
+			SharedSkills: sharedSkills,
+			Score:        len(sharedSkills),
+		})
+	}
+
+	// profiles are sorted by most recently updated, which is kept for matches with the same score
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches, nil
+}
+
+func (s *mongoMatchmakingService) AcceptTeamMatchForUserWithID(ctx context.Context, userID string, teamID string) (*entities.TeamJoinRequest, error) {
+	_, err := s.GetAdvertForTeamWithID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := s.teamService.GetTeamWithID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if team.Privacy == entities.TeamClosed {
+		return s.teamJoinRequestService.CreateJoinRequestForTeamWithID(ctx, userID, teamID)
+	}
+
+	err = s.teamService.AddUserWithIDToTeamWithID(ctx, userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the user is not looking for a team anymore
+	err = s.DeleteProfileForUserWithID(ctx, userID)
+	if err != nil && errors.Cause(err) != services.ErrNotFound {
+		s.logger.Error("could not delete matchmaking profile after user joined team", zap.String("userId", userID), zap.Error(err))
+	}
+
+	return nil, nil
+}
+
+// openSlots returns how many more members the team can have, which is 0 when the team has no member limit,
+// and whether the team can have more members
+func (s *mongoMatchmakingService) openSlots(team entities.Team) (int, bool) {
+	limit := s.cfg.Teams.MaxMembers
+	if team.MaxMembers > 0 {
+		limit = team.MaxMembers
+	}
+
+	if limit == 0 {
+		return 0, true
+	}
+
+	return limit - team.MemberCount, team.MemberCount < limit
+}
+
+func (s *mongoMatchmakingService) findProfiles(ctx context.Context) ([]entities.MatchmakingProfile, error) {
+	cur, err := s.matchmakingProfileRepository.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.M{string(entities.MatchmakingProfileUpdatedAt): -1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for matchmaking profiles")
+	}
+	defer cur.Close(ctx)
+
+	var profiles []entities.MatchmakingProfile
+	for cur.Next(ctx) {
+		var profile entities.MatchmakingProfile
+		err := cur.Decode(&profile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode matchmaking profile")
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+func (s *mongoMatchmakingService) findAdverts(ctx context.Context) ([]entities.TeamAdvert, error) {
+	cur, err := s.teamAdvertRepository.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.M{string(entities.TeamAdvertUpdatedAt): -1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for team adverts")
+	}
+	defer cur.Close(ctx)
+
+	var adverts []entities.TeamAdvert
+	for cur.Next(ctx) {
+		var advert entities.TeamAdvert
+		err := cur.Decode(&advert)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode team advert")
+		}
+		adverts = append(adverts, advert)
+	}
+
+	return adverts, nil
+}
+
+// normaliseMatchmakingTags lower cases the skills or interests and removes blank and repeated ones,
+// so that they can be compared between profiles and adverts
+func normaliseMatchmakingTags(tags []string) []string {
+	normalised := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) == 0 || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalised = append(normalised, tag)
+	}
+
+	return normalised
+}
+
+func validMatchmakingTags(tags []string) bool {
+	if len(tags) > maxMatchmakingTags {
+		return false
+	}
+
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > maxMatchmakingTagLength {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validMatchmakingPitch(pitch string) bool {
+	return utf8.RuneCountInString(pitch) <= maxMatchmakingPitchLength
+}
+
+// sharedMatchmakingTags returns the wanted tags which are in tags, in the order they are wanted in
+func sharedMatchmakingTags(wanted []string, tags []string) []string {
+	shared := []string{}
+	for _, wantedTag := range wanted {
+		for _, tag := range tags {
+			if tag == wantedTag {
+				shared = append(shared, tag)
+				break
+			}
+		}
+	}
+
+	return shared
+}
@@ -0,0 +1,361 @@
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var testMatchmakingTime = time.Unix(3000, 0)
+
+type matchmakingTestSetup struct {
+	ctrl          *gomock.Controller
+	mmService     *mongoMatchmakingService
+	mpRepo        *repositories.MatchmakingProfileRepository
+	taRepo        *repositories.TeamAdvertRepository
+	mockUService  *mock_services.MockUserService
+	mockTService  *mock_services.MockTeamService
+	mockJRService *mock_services.MockTeamJoinRequestService
+	user          entities.User
+	team          entities.Team
+	cleanup       func()
+}
+
+func setupMatchmakingTest(t *testing.T) *matchmakingTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	mpRepo, err := repositories.NewMatchmakingProfileRepository(db)
+	if err != nil {
+		panic(err)
+	}
+	taRepo, err := repositories.NewTeamAdvertRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockUService := mock_services.NewMockUserService(ctrl)
+	mockTService := mock_services.NewMockTeamService(ctrl)
+	mockJRService := mock_services.NewMockTeamJoinRequestService(ctrl)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTimeProvider.EXPECT().Now().Return(testMatchmakingTime).AnyTimes()
+
+	cfg := &config.AppConfig{
+		Teams: config.TeamConfig{
+			MaxMembers: 4,
+		},
+	}
+
+	return &matchmakingTestSetup{
+		ctrl: ctrl,
+		mmService: &mongoMatchmakingService{
+			logger:                       zap.NewNop(),
+			cfg:                          cfg,
+			timeProvider:                 mockTimeProvider,
+			matchmakingProfileRepository: mpRepo,
+			teamAdvertRepository:         taRepo,
+			userService:                  mockUService,
+			teamService:                  mockTService,
+			teamJoinRequestService:       mockJRService,
+		},
+		mpRepo:        mpRepo,
+		taRepo:        taRepo,
+		mockUService:  mockUService,
+		mockTService:  mockTService,
+		mockJRService: mockJRService,
+		user:          entities.User{ID: primitive.NewObjectID(), Name: "Bob"},
+		team: entities.Team{
+			ID:          primitive.NewObjectID(),
+			Name:        "Team of Robs",
+			Creator:     primitive.NewObjectID(),
+			MemberCount: 1,
+		},
+		cleanup: func() {
+			ctrl.Finish()
+			mpRepo.Drop(context.Background())
+			taRepo.Drop(context.Background())
+		},
+	}
+}
+
+func (setup *matchmakingTestSetup) insertProfile(t *testing.T, userID primitive.ObjectID, skills ...string) {
+	_, err := setup.mpRepo.InsertOne(context.Background(), entities.MatchmakingProfile{
+		ID:        primitive.NewObjectID(),
+		User:      userID,
+		Skills:    skills,
+		UpdatedAt: testMatchmakingTime,
+	})
+	assert.NoError(t, err)
+}
+
+func (setup *matchmakingTestSetup) insertAdvert(t *testing.T, teamID primitive.ObjectID, updatedAt time.Time, skills ...string) {
+	_, err := setup.taRepo.InsertOne(context.Background(), entities.TeamAdvert{
+		ID:        primitive.NewObjectID(),
+		Team:      teamID,
+		Skills:    skills,
+		UpdatedAt: updatedAt,
+	})
+	assert.NoError(t, err)
+}
+
+func Test_NewMongoMatchmakingService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoMatchmakingService(nil, nil, nil, nil, nil, nil, nil, nil))
+}
+
+func Test_SetProfileForUserWithID__should_create_and_update_normalised_profile(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.user.ID.Hex()).Return(&setup.user, nil).Times(2)
+
+	profile, err := setup.mmService.SetProfileForUserWithID(ctx, setup.user.ID.Hex(), []string{" Go", "design", "go", ""}, nil, "hi")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go", "design"}, profile.Skills)
+	assert.Equal(t, []string{}, profile.Interests)
+
+	updatedProfile, err := setup.mmService.SetProfileForUserWithID(ctx, setup.user.ID.Hex(), []string{"hardware"}, []string{"Games"}, "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, profile.ID, updatedProfile.ID)
+
+	storedProfile, err := setup.mmService.GetProfileForUserWithID(ctx, setup.user.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hardware"}, storedProfile.Skills)
+	assert.Equal(t, []string{"games"}, storedProfile.Interests)
+	assert.Equal(t, "hello", storedProfile.Pitch)
+	assert.Equal(t, testMatchmakingTime, storedProfile.UpdatedAt.UTC())
+}
+
+func Test_SetProfileForUserWithID__should_return_error(t *testing.T) {
+	tests := []struct {
+		name      string
+		skills    []string
+		interests []string
+		pitch     string
+		prepare   func(setup *matchmakingTestSetup)
+		wantErr   error
+	}{
+		{
+			name:    "when no skills are given",
+			skills:  []string{" ", ""},
+			wantErr: services.ErrInvalidMatchmakingProfile,
+		},
+		{
+			name:    "when a skill is too long",
+			skills:  []string{strings.Repeat("a", maxMatchmakingTagLength+1)},
+			wantErr: services.ErrInvalidMatchmakingProfile,
+		},
+		{
+			name:    "when pitch is too long",
+			skills:  []string{"go"},
+			pitch:   strings.Repeat("a", maxMatchmakingPitchLength+1),
+			wantErr: services.ErrInvalidMatchmakingProfile,
+		},
+		{
+			name:   "when user is in a team",
+			skills: []string{"go"},
+			prepare: func(setup *matchmakingTestSetup) {
+				setup.user.Team = primitive.NewObjectID()
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.user.ID.Hex()).Return(&setup.user, nil).Times(1)
+			},
+			wantErr: services.ErrUserInTeam,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupMatchmakingTest(t)
+			defer setup.cleanup()
+			if tt.prepare != nil {
+				tt.prepare(setup)
+			}
+
+			profile, err := setup.mmService.SetProfileForUserWithID(context.Background(), setup.user.ID.Hex(), tt.skills, tt.interests, tt.pitch)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Nil(t, profile)
+		})
+	}
+}
+
+func Test_DeleteProfileForUserWithID(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.insertProfile(t, setup.user.ID, "go")
+
+	assert.NoError(t, setup.mmService.DeleteProfileForUserWithID(ctx, setup.user.ID.Hex()))
+	assert.Equal(t, services.ErrNotFound, setup.mmService.DeleteProfileForUserWithID(ctx, setup.user.ID.Hex()))
+	assert.Equal(t, services.ErrInvalidID, setup.mmService.DeleteProfileForUserWithID(ctx, "invalid"))
+}
+
+func Test_SetAdvertForTeamWithID__should_return_ErrTeamFull_when_team_has_no_open_slots(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.team.MemberCount = 4
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+
+	advert, err := setup.mmService.SetAdvertForTeamWithID(ctx, setup.team.ID.Hex(), []string{"go"}, "")
+	assert.Equal(t, services.ErrTeamFull, err)
+	assert.Nil(t, advert)
+}
+
+func Test_SetAdvertForTeamWithID__should_save_advert(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+
+	_, err := setup.mmService.SetAdvertForTeamWithID(ctx, setup.team.ID.Hex(), []string{"Go", "Design"}, "we build things")
+	assert.NoError(t, err)
+
+	advert, err := setup.mmService.GetAdvertForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, setup.team.ID, advert.Team)
+	assert.Equal(t, []string{"go", "design"}, advert.Skills)
+	assert.Equal(t, "we build things", advert.Pitch)
+
+	assert.NoError(t, setup.mmService.DeleteAdvertForTeamWithID(ctx, setup.team.ID.Hex()))
+	_, err = setup.mmService.GetAdvertForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_GetTeamMatchesForUserWithID__should_return_open_teams_ordered_by_score(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	fullTeam := entities.Team{ID: primitive.NewObjectID(), Name: "Full team", MemberCount: 4}
+	deletedTeamID := primitive.NewObjectID()
+	betterTeam := entities.Team{ID: primitive.NewObjectID(), Name: "Better team", MemberCount: 3}
+
+	setup.insertProfile(t, setup.user.ID, "go", "design")
+	setup.insertAdvert(t, setup.team.ID, testMatchmakingTime.Add(time.Minute), "hardware")
+	setup.insertAdvert(t, fullTeam.ID, testMatchmakingTime, "go")
+	setup.insertAdvert(t, deletedTeamID, testMatchmakingTime, "go")
+	setup.insertAdvert(t, betterTeam.ID, testMatchmakingTime, "design", "go")
+
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.user.ID.Hex()).Return(&setup.user, nil).Times(1)
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, fullTeam.ID.Hex()).Return(&fullTeam, nil).Times(1)
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, deletedTeamID.Hex()).Return(nil, services.ErrNotFound).Times(1)
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, betterTeam.ID.Hex()).Return(&betterTeam, nil).Times(1)
+
+	matches, err := setup.mmService.GetTeamMatchesForUserWithID(ctx, setup.user.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, matches, 2)
+	assert.Equal(t, "Better team", matches[0].TeamName)
+	assert.Equal(t, 1, matches[0].OpenSlots)
+	assert.Equal(t, []string{"design", "go"}, matches[0].SharedSkills)
+	assert.Equal(t, 2, matches[0].Score)
+	assert.Equal(t, "Team of Robs", matches[1].TeamName)
+	assert.Equal(t, 0, matches[1].Score)
+}
+
+func Test_GetProfileMatchesForTeamWithID__should_leave_out_users_in_teams(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	userInTeam := entities.User{ID: primitive.NewObjectID(), Name: "Rob"}
+
+	setup.insertAdvert(t, setup.team.ID, testMatchmakingTime, "go")
+	setup.insertProfile(t, setup.user.ID, "go", "design")
+	setup.insertProfile(t, userInTeam.ID, "go")
+
+	setup.mockUService.EXPECT().StreamUsers(ctx, services.UserFilter{TeamID: services.NoTeam}, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, filter services.UserFilter, callback func(entities.User) error) error {
+			return callback(setup.user)
+		}).Times(1)
+
+	matches, err := setup.mmService.GetProfileMatchesForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "Bob", matches[0].UserName)
+	assert.Equal(t, []string{"go"}, matches[0].SharedSkills)
+}
+
+func Test_AcceptTeamMatchForUserWithID__should_return_ErrNotFound_when_team_is_not_advertised(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	request, err := setup.mmService.AcceptTeamMatchForUserWithID(context.Background(), setup.user.ID.Hex(), setup.team.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+	assert.Nil(t, request)
+}
+
+func Test_AcceptTeamMatchForUserWithID__should_create_join_request_for_closed_team(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.team.Privacy = entities.TeamClosed
+	setup.insertAdvert(t, setup.team.ID, testMatchmakingTime, "go")
+	setup.insertProfile(t, setup.user.ID, "go")
+	wantRequest := &entities.TeamJoinRequest{ID: primitive.NewObjectID(), Team: setup.team.ID, User: setup.user.ID}
+
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+	setup.mockJRService.EXPECT().CreateJoinRequestForTeamWithID(ctx, setup.user.ID.Hex(), setup.team.ID.Hex()).
+		Return(wantRequest, nil).Times(1)
+
+	request, err := setup.mmService.AcceptTeamMatchForUserWithID(ctx, setup.user.ID.Hex(), setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, wantRequest, request)
+
+	// the user keeps looking for a team until their request is approved
+	_, err = setup.mmService.GetProfileForUserWithID(ctx, setup.user.ID.Hex())
+	assert.NoError(t, err)
+}
+
+func Test_AcceptTeamMatchForUserWithID__should_add_user_to_open_team_and_delete_their_profile(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.insertAdvert(t, setup.team.ID, testMatchmakingTime, "go")
+	setup.insertProfile(t, setup.user.ID, "go")
+
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+	setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(ctx, setup.user.ID.Hex(), setup.team.ID.Hex()).Return(nil).Times(1)
+
+	request, err := setup.mmService.AcceptTeamMatchForUserWithID(ctx, setup.user.ID.Hex(), setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Nil(t, request)
+
+	_, err = setup.mmService.GetProfileForUserWithID(ctx, setup.user.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_AcceptTeamMatchForUserWithID__should_return_error_when_team_is_full(t *testing.T) {
+	setup := setupMatchmakingTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.insertAdvert(t, setup.team.ID, testMatchmakingTime, "go")
+
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+	setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(ctx, setup.user.ID.Hex(), setup.team.ID.Hex()).
+		Return(services.ErrTeamFull).Times(1)
+
+	request, err := setup.mmService.AcceptTeamMatchForUserWithID(ctx, setup.user.ID.Hex(), setup.team.ID.Hex())
+	assert.Equal(t, services.ErrTeamFull, err)
+	assert.Nil(t, request)
+}
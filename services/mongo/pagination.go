@@ -0,0 +1,112 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/unicsmcr/hs_auth/services"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idField = "_id"
+
+// pageCursor points to the last object of a page by the value of the field the page is sorted by
+// and the object's id, which breaks ties between objects with the same value
+type pageCursor struct {
+	Value string             `json:"v,omitempty"`
+	ID    primitive.ObjectID `json:"id"`
+}
+
+func encodePageCursor(cursor pageCursor) string {
+	marshalledCursor, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(marshalledCursor)
+}
+
+func decodePageCursor(encodedCursor string) (pageCursor, error) {
+	marshalledCursor, err := base64.RawURLEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return pageCursor{}, services.ErrInvalidCursor
+	}
+
+	var cursor pageCursor
+	err = json.Unmarshal(marshalledCursor, &cursor)
+	if err != nil || cursor.ID == primitive.NilObjectID {
+		return pageCursor{}, services.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// buildPageQuery restricts the query to the objects on the given page and returns the options to sort and limit them with.
+// sortableFields are the string fields the objects can be sorted by in addition to their id.
+// One object more than the page's limit is requested so that makePageInfo can tell whether more objects follow the page
+func buildPageQuery(query bson.M, page services.Pagination, sortableFields ...string) (bson.M, *options.FindOptions, error) {
+	sortBy := page.SortBy
+	if len(sortBy) == 0 {
+		sortBy = idField
+	}
+	if sortBy != idField && !containsString(sortableFields, sortBy) {
+		return nil, nil, services.ErrInvalidSortField
+	}
+
+	direction, comparison := 1, "$gt"
+	if page.Descending {
+		direction, comparison = -1, "$lt"
+	}
+
+	findOptions := options.Find()
+	if sortBy == idField {
+		findOptions.SetSort(bson.D{{Key: idField, Value: direction}})
+	} else {
+		findOptions.SetSort(bson.D{{Key: sortBy, Value: direction}, {Key: idField, Value: direction}})
+	}
+	if page.Limit > 0 {
+		findOptions.SetLimit(page.Limit + 1)
+	}
+
+	if len(page.After) == 0 {
+		return query, findOptions, nil
+	}
+
+	cursor, err := decodePageCursor(page.After)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cursorQuery bson.M
+	if sortBy == idField {
+		cursorQuery = bson.M{idField: bson.M{comparison: cursor.ID}}
+	} else {
+		cursorQuery = bson.M{"$or": bson.A{
+			bson.M{sortBy: bson.M{comparison: cursor.Value}},
+			bson.M{sortBy: cursor.Value, idField: bson.M{comparison: cursor.ID}},
+		}}
+	}
+
+	return bson.M{"$and": bson.A{query, cursorQuery}}, findOptions, nil
+}
+
+// makePageInfo returns the number of fetched objects which are on the page and the page's PageInfo.
+// cursorForObject returns the cursor pointing to the fetched object with the given index
+func makePageInfo(fetchedObjects int, page services.Pagination, cursorForObject func(int) pageCursor) (int, *services.PageInfo) {
+	if page.Limit <= 0 || int64(fetchedObjects) <= page.Limit {
+		return fetchedObjects, &services.PageInfo{}
+	}
+
+	pageSize := int(page.Limit)
+	return pageSize, &services.PageInfo{
+		NextCursor: encodePageCursor(cursorForObject(pageSize - 1)),
+		HasMore:    true,
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/services"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_decodePageCursor__should_decode_encoded_cursor(t *testing.T) {
+	cursor := pageCursor{Value: "Bob the Tester", ID: primitive.NewObjectID()}
+
+	decodedCursor, err := decodePageCursor(encodePageCursor(cursor))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decodedCursor)
+}
+
+func Test_decodePageCursor__should_return_ErrInvalidCursor_for_invalid_cursors(t *testing.T) {
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24", encodePageCursor(pageCursor{Value: "no id"})} {
+		_, err := decodePageCursor(cursor)
+		assert.Equal(t, services.ErrInvalidCursor, err)
+	}
+}
+
+func Test_buildPageQuery(t *testing.T) {
+	cursorID := primitive.NewObjectID()
+	idCursor := encodePageCursor(pageCursor{ID: cursorID})
+	nameCursor := encodePageCursor(pageCursor{Value: "Bob", ID: cursorID})
+
+	tests := []struct {
+		name      string
+		page      services.Pagination
+		wantQuery bson.M
+		wantSort  bson.D
+		wantLimit *int64
+		wantErr   error
+	}{
+		{
+			name:      "should sort by id by default",
+			page:      services.Pagination{},
+			wantQuery: bson.M{"role": "applicant"},
+			wantSort:  bson.D{{Key: "_id", Value: 1}},
+		},
+		{
+			name:    "should return ErrInvalidSortField when field is not sortable",
+			page:    services.Pagination{SortBy: "password"},
+			wantErr: services.ErrInvalidSortField,
+		},
+		{
+			name:    "should return ErrInvalidCursor when cursor is invalid",
+			page:    services.Pagination{After: "invalid"},
+			wantErr: services.ErrInvalidCursor,
+		},
+		{
+			name:      "should request one more object than the limit",
+			page:      services.Pagination{Limit: 10},
+			wantQuery: bson.M{"role": "applicant"},
+			wantSort:  bson.D{{Key: "_id", Value: 1}},
+			wantLimit: func() *int64 { limit := int64(11); return &limit }(),
+		},
+		{
+			name: "should continue after id cursor",
+			page: services.Pagination{After: idCursor, Descending: true},
+			wantQuery: bson.M{"$and": bson.A{
+				bson.M{"role": "applicant"},
+				bson.M{"_id": bson.M{"$lt": cursorID}},
+			}},
+			wantSort: bson.D{{Key: "_id", Value: -1}},
+		},
+		{
+			name: "should continue after cursor of sorted field with id breaking ties",
+			page: services.Pagination{After: nameCursor, SortBy: "name"},
+			wantQuery: bson.M{"$and": bson.A{
+				bson.M{"role": "applicant"},
+				bson.M{"$or": bson.A{
+					bson.M{"name": bson.M{"$gt": "Bob"}},
+					bson.M{"name": "Bob", "_id": bson.M{"$gt": cursorID}},
+				}},
+			}},
+			wantSort: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, findOptions, err := buildPageQuery(bson.M{"role": "applicant"}, tt.page, "name")
+
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantQuery, query)
+			assert.Equal(t, tt.wantSort, findOptions.Sort)
+			assert.Equal(t, tt.wantLimit, findOptions.Limit)
+		})
+	}
+}
+
+func Test_makePageInfo(t *testing.T) {
+	cursorID := primitive.NewObjectID()
+	cursorForObject := func(i int) pageCursor {
+		assert.Equal(t, 1, i)
+		return pageCursor{ID: cursorID}
+	}
+
+	pageSize, pageInfo := makePageInfo(2, services.Pagination{Limit: 2}, cursorForObject)
+	assert.Equal(t, 2, pageSize)
+	assert.Equal(t, &services.PageInfo{}, pageInfo)
+
+	pageSize, pageInfo = makePageInfo(3, services.Pagination{Limit: 2}, cursorForObject)
+	assert.Equal(t, 2, pageSize)
+	assert.Equal(t, &services.PageInfo{NextCursor: encodePageCursor(pageCursor{ID: cursorID}), HasMore: true}, pageInfo)
+
+	pageSize, pageInfo = makePageInfo(3, services.Pagination{}, cursorForObject)
+	assert.Equal(t, 3, pageSize)
+	assert.Equal(t, &services.PageInfo{}, pageInfo)
+}
@@ -0,0 +1,286 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type mongoTeamJoinRequestService struct {
+	logger                    *zap.Logger
+	timeProvider              utils.TimeProvider
+	teamJoinRequestRepository *repositories.TeamJoinRequestRepository
+	teamService               services.TeamService
+	userService               services.UserService
+	emailService              services.EmailServiceV2
+}
+
+// NewMongoTeamJoinRequestService creates a new TeamJoinRequestService that uses MongoDB as the storage technology
+func NewMongoTeamJoinRequestService(logger *zap.Logger, timeProvider utils.TimeProvider,
+	teamJoinRequestRepository *repositories.TeamJoinRequestRepository, teamService services.TeamService,
+	userService services.UserService, emailService services.EmailServiceV2) services.TeamJoinRequestService {
+	return &mongoTeamJoinRequestService{
+		logger:                    logger,
+		timeProvider:              timeProvider,
+		teamJoinRequestRepository: teamJoinRequestRepository,
+		teamService:               teamService,
+		userService:               userService,
+		emailService:              emailService,
+	}
+}
+
+func (s *mongoTeamJoinRequestService) CreateJoinRequestWithJoinCode(ctx context.Context, userID string, joinCode string) (*entities.TeamJoinRequest, error) {
+	team, err := s.teamService.GetTeamWithJoinCode(ctx, userID, joinCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJoinRequest(ctx, userID, team)
+}
+
+func (s *mongoTeamJoinRequestService) CreateJoinRequestForTeamWithID(ctx context.Context, userID string, teamID string) (*entities.TeamJoinRequest, error) {
+	team, err := s.teamService.GetTeamWithID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJoinRequest(ctx, userID, team)
+}
+
+// createJoinRequest creates a request of the user with the given id to join the team and notifies the team's creator
+func (s *mongoTeamJoinRequestService) createJoinRequest(ctx context.Context, userID string, team *entities.Team) (*entities.TeamJoinRequest, error) {
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Team != primitive.NilObjectID {
+		return nil, services.ErrUserInTeam
+	}
+
+	// check if the user has not requested to join the team already
+	err = s.teamJoinRequestRepository.FindOne(ctx, bson.M{
+		string(entities.TeamJoinRequestTeam): team.ID,
+		string(entities.TeamJoinRequestUser): user.ID,
+	}).Err()
+	if err == nil {
+		return nil, services.ErrJoinRequestExists
+	} else if err != mongo.ErrNoDocuments {
+		return nil, errors.Wrap(err, "could not query for join request")
+	}
+
+	request := &entities.TeamJoinRequest{
+		ID:        primitive.NewObjectID(),
+		Team:      team.ID,
+		User:      user.ID,
+		CreatedAt: s.timeProvider.Now(),
+	}
+
+	_, err = s.teamJoinRequestRepository.InsertOne(ctx, *request)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create new join request")
+	}
+
+	creator, err := s.userService.GetUserWithID(ctx, team.Creator.Hex())
+	if err != nil {
+		s.logger.Error("could not fetch team's creator for join request email", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+		return request, nil
+	}
+
+	err = s.emailService.SendTeamJoinRequestEmail(ctx, *creator, *user, *team)
+	if err != nil {
+		s.logger.Error("could not send join request email", zap.String("teamId", team.ID.Hex()), zap.Error(err))
+	}
+
+	return request, nil
+}
+
+func (s *mongoTeamJoinRequestService) GetJoinRequestsForTeamWithID(ctx context.Context, teamID string) ([]entities.TeamJoinRequest, error) {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	return s.findJoinRequests(ctx, bson.M{
+		string(entities.TeamJoinRequestTeam): mongoID,
+	})
+}
+
+func (s *mongoTeamJoinRequestService) GetJoinRequestsForUserWithID(ctx context.Context, userID string) ([]entities.TeamJoinRequest, error) {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	return s.findJoinRequests(ctx, bson.M{
+		string(entities.TeamJoinRequestUser): mongoID,
+	})
+}
+
+func (s *mongoTeamJoinRequestService) ApproveJoinRequestWithID(ctx context.Context, teamID string, requestID string) error {
+	request, err := s.getJoinRequestForTeam(ctx, teamID, requestID)
+	if err != nil {
+		return err
+	}
+
+	err = s.teamService.AddUserWithIDToTeamWithID(ctx, request.User.Hex(), teamID)
+	if errors.Cause(err) == services.ErrUserInTeam {
+		// the user has joined another team since making the request, so it cannot be approved anymore
+		_, deleteErr := s.teamJoinRequestRepository.DeleteOne(ctx, bson.M{
+			string(entities.TeamJoinRequestID): request.ID,
+		})
+		if deleteErr != nil {
+			s.logger.Error("could not delete outdated join request", zap.String("requestId", requestID), zap.Error(deleteErr))
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	// the user cannot join any other team now
+	_, err = s.teamJoinRequestRepository.DeleteMany(ctx, bson.M{
+		string(entities.TeamJoinRequestUser): request.User,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete user's join requests")
+	}
+
+	s.sendDecisionEmail(ctx, *request, true)
+
+	return nil
+}
+
+func (s *mongoTeamJoinRequestService) RejectJoinRequestWithID(ctx context.Context, teamID string, requestID string) error {
+	request, err := s.getJoinRequestForTeam(ctx, teamID, requestID)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.teamJoinRequestRepository.DeleteOne(ctx, bson.M{
+		string(entities.TeamJoinRequestID): request.ID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete join request")
+	} else if res.DeletedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	s.sendDecisionEmail(ctx, *request, false)
+
+	return nil
+}
+
+func (s *mongoTeamJoinRequestService) DeleteJoinRequestsForUserWithID(ctx context.Context, userID string) error {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	_, err = s.teamJoinRequestRepository.DeleteMany(ctx, bson.M{
+		string(entities.TeamJoinRequestUser): mongoID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete user's join requests")
+	}
+
+	return nil
+}
+
+func (s *mongoTeamJoinRequestService) DeleteJoinRequestsForTeamWithID(ctx context.Context, teamID string) error {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	return deleteJoinRequestsForTeam(ctx, s.teamJoinRequestRepository, mongoID)
+}
+
+// deleteJoinRequestsForTeam removes all requests to join the team with the given id.
+// It is shared with the team service, which cannot depend on the join request service
+func deleteJoinRequestsForTeam(ctx context.Context, teamJoinRequestRepository *repositories.TeamJoinRequestRepository, teamID primitive.ObjectID) error {
+	_, err := teamJoinRequestRepository.DeleteMany(ctx, bson.M{
+		string(entities.TeamJoinRequestTeam): teamID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete team's join requests")
+	}
+
+	return nil
+}
+
+// getJoinRequestForTeam returns the request with the given id if it was made to join the team with the given id
+func (s *mongoTeamJoinRequestService) getJoinRequestForTeam(ctx context.Context, teamID string, requestID string) (*entities.TeamJoinRequest, error) {
+	teamMongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	requestMongoID, err := primitive.ObjectIDFromHex(requestID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	var request entities.TeamJoinRequest
+	err = s.teamJoinRequestRepository.FindOne(ctx, bson.M{
+		string(entities.TeamJoinRequestID):   requestMongoID,
+		string(entities.TeamJoinRequestTeam): teamMongoID,
+	}).Decode(&request)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for join request with ID")
+	}
+
+	return &request, nil
+}
+
+func (s *mongoTeamJoinRequestService) findJoinRequests(ctx context.Context, query bson.M) ([]entities.TeamJoinRequest, error) {
+	cur, err := s.teamJoinRequestRepository.Find(ctx, query,
+		options.Find().SetSort(bson.M{string(entities.TeamJoinRequestCreatedAt): 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for join requests")
+	}
+	defer cur.Close(ctx)
+
+	requests := []entities.TeamJoinRequest{}
+	for cur.Next(ctx) {
+		var request entities.TeamJoinRequest
+		err := cur.Decode(&request)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode join request")
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+// sendDecisionEmail tells the user who made the request whether it was approved.
+// The decision has already been made, so failing to send the email is only logged
+func (s *mongoTeamJoinRequestService) sendDecisionEmail(ctx context.Context, request entities.TeamJoinRequest, approved bool) {
+	user, err := s.userService.GetUserWithID(ctx, request.User.Hex())
+	if err != nil {
+		s.logger.Error("could not fetch user for join request decision email", zap.String("userId", request.User.Hex()), zap.Error(err))
+		return
+	}
+
+	team, err := s.teamService.GetTeamWithID(ctx, request.Team.Hex())
+	if err != nil {
+		s.logger.Error("could not fetch team for join request decision email", zap.String("teamId", request.Team.Hex()), zap.Error(err))
+		return
+	}
+
+	err = s.emailService.SendTeamJoinRequestDecisionEmail(ctx, *user, *team, approved)
+	if err != nil {
+		s.logger.Error("could not send join request decision email", zap.String("userId", request.User.Hex()), zap.Error(err))
+	}
+}
@@ -0,0 +1,395 @@
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var testJoinRequestTime = time.Unix(2000, 0)
+
+type teamJoinRequestTestSetup struct {
+	ctrl         *gomock.Controller
+	tjrService   *mongoTeamJoinRequestService
+	tjrRepo      *repositories.TeamJoinRequestRepository
+	mockTService *mock_services.MockTeamService
+	mockUService *mock_services.MockUserService
+	mockEService *mock_services.MockEmailServiceV2
+	team         entities.Team
+	creator      entities.User
+	requester    entities.User
+	cleanup      func()
+}
+
+func setupTeamJoinRequestTest(t *testing.T) *teamJoinRequestTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	tjrRepo, err := repositories.NewTeamJoinRequestRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockTService := mock_services.NewMockTeamService(ctrl)
+	mockUService := mock_services.NewMockUserService(ctrl)
+	mockEService := mock_services.NewMockEmailServiceV2(ctrl)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTimeProvider.EXPECT().Now().Return(testJoinRequestTime).AnyTimes()
+
+	creator := entities.User{ID: primitive.NewObjectID(), Name: "Bob"}
+	team := entities.Team{
+		ID:       primitive.NewObjectID(),
+		Name:     "Team of Bobs",
+		Creator:  creator.ID,
+		JoinCode: "ABCD2345",
+		Privacy:  entities.TeamClosed,
+	}
+	creator.Team = team.ID
+
+	return &teamJoinRequestTestSetup{
+		ctrl: ctrl,
+		tjrService: &mongoTeamJoinRequestService{
+			logger:                    zap.NewNop(),
+			timeProvider:              mockTimeProvider,
+			teamJoinRequestRepository: tjrRepo,
+			teamService:               mockTService,
+			userService:               mockUService,
+			emailService:              mockEService,
+		},
+		tjrRepo:      tjrRepo,
+		mockTService: mockTService,
+		mockUService: mockUService,
+		mockEService: mockEService,
+		team:         team,
+		creator:      creator,
+		requester:    entities.User{ID: primitive.NewObjectID(), Name: "Rob"},
+		cleanup: func() {
+			ctrl.Finish()
+			tjrRepo.Drop(context.Background())
+		},
+	}
+}
+
+func (setup *teamJoinRequestTestSetup) insertJoinRequest(t *testing.T, teamID, userID primitive.ObjectID) entities.TeamJoinRequest {
+	request := entities.TeamJoinRequest{
+		ID:        primitive.NewObjectID(),
+		Team:      teamID,
+		User:      userID,
+		CreatedAt: testJoinRequestTime,
+	}
+	_, err := setup.tjrRepo.InsertOne(context.Background(), request)
+	assert.NoError(t, err)
+	return request
+}
+
+func Test_NewMongoTeamJoinRequestService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoTeamJoinRequestService(nil, nil, nil, nil, nil, nil))
+}
+
+func Test_CreateJoinRequestWithJoinCode__should_create_request_and_notify_creator(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.mockTService.EXPECT().GetTeamWithJoinCode(ctx, setup.requester.ID.Hex(), "ABCD2345").Return(&setup.team, nil).Times(1)
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.requester.ID.Hex()).Return(&setup.requester, nil).Times(1)
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.creator.ID.Hex()).Return(&setup.creator, nil).Times(1)
+	setup.mockEService.EXPECT().SendTeamJoinRequestEmail(ctx, setup.creator, setup.requester, setup.team).Return(nil).Times(1)
+
+	request, err := setup.tjrService.CreateJoinRequestWithJoinCode(ctx, setup.requester.ID.Hex(), "ABCD2345")
+	assert.NoError(t, err)
+	assert.Equal(t, setup.team.ID, request.Team)
+	assert.Equal(t, setup.requester.ID, request.User)
+
+	var storedRequest entities.TeamJoinRequest
+	err = setup.tjrRepo.FindOne(ctx, bson.M{
+		string(entities.TeamJoinRequestID): request.ID,
+	}).Decode(&storedRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, testJoinRequestTime, storedRequest.CreatedAt.UTC())
+}
+
+func Test_CreateJoinRequestWithJoinCode__should_not_return_error_when_email_fails(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.mockTService.EXPECT().GetTeamWithJoinCode(ctx, setup.requester.ID.Hex(), "ABCD2345").Return(&setup.team, nil).Times(1)
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.requester.ID.Hex()).Return(&setup.requester, nil).Times(1)
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.creator.ID.Hex()).Return(&setup.creator, nil).Times(1)
+	setup.mockEService.EXPECT().SendTeamJoinRequestEmail(ctx, setup.creator, setup.requester, setup.team).
+		Return(errors.New("email err")).Times(1)
+
+	request, err := setup.tjrService.CreateJoinRequestWithJoinCode(ctx, setup.requester.ID.Hex(), "ABCD2345")
+	assert.NoError(t, err)
+	assert.NotNil(t, request)
+}
+
+func Test_CreateJoinRequestWithJoinCode__should_return_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(setup *teamJoinRequestTestSetup)
+		wantErr error
+	}{
+		{
+			name: "when join code lookup fails",
+			prepare: func(setup *teamJoinRequestTestSetup) {
+				setup.mockTService.EXPECT().GetTeamWithJoinCode(gomock.Any(), setup.requester.ID.Hex(), "ABCD2345").
+					Return(nil, services.ErrJoinCodeThrottled).Times(1)
+			},
+			wantErr: services.ErrJoinCodeThrottled,
+		},
+		{
+			name: "when user is already in a team",
+			prepare: func(setup *teamJoinRequestTestSetup) {
+				setup.requester.Team = primitive.NewObjectID()
+				setup.mockTService.EXPECT().GetTeamWithJoinCode(gomock.Any(), setup.requester.ID.Hex(), "ABCD2345").
+					Return(&setup.team, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.requester.ID.Hex()).Return(&setup.requester, nil).Times(1)
+			},
+			wantErr: services.ErrUserInTeam,
+		},
+		{
+			name: "when user has already requested to join the team",
+			prepare: func(setup *teamJoinRequestTestSetup) {
+				setup.mockTService.EXPECT().GetTeamWithJoinCode(gomock.Any(), setup.requester.ID.Hex(), "ABCD2345").
+					Return(&setup.team, nil).Times(1)
+				setup.mockUService.EXPECT().GetUserWithID(gomock.Any(), setup.requester.ID.Hex()).Return(&setup.requester, nil).Times(1)
+				_, err := setup.tjrRepo.InsertOne(context.Background(), entities.TeamJoinRequest{
+					ID:   primitive.NewObjectID(),
+					Team: setup.team.ID,
+					User: setup.requester.ID,
+				})
+				if err != nil {
+					panic(err)
+				}
+			},
+			wantErr: services.ErrJoinRequestExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamJoinRequestTest(t)
+			defer setup.cleanup()
+			tt.prepare(setup)
+
+			request, err := setup.tjrService.CreateJoinRequestWithJoinCode(context.Background(), setup.requester.ID.Hex(), "ABCD2345")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Nil(t, request)
+		})
+	}
+}
+
+func Test_CreateJoinRequestForTeamWithID__should_create_request_and_notify_creator(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.requester.ID.Hex()).Return(&setup.requester, nil).Times(1)
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.creator.ID.Hex()).Return(&setup.creator, nil).Times(1)
+	setup.mockEService.EXPECT().SendTeamJoinRequestEmail(ctx, setup.creator, setup.requester, setup.team).Return(nil).Times(1)
+
+	request, err := setup.tjrService.CreateJoinRequestForTeamWithID(ctx, setup.requester.ID.Hex(), setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, setup.team.ID, request.Team)
+	assert.Equal(t, setup.requester.ID, request.User)
+}
+
+func Test_CreateJoinRequestForTeamWithID__should_return_error_when_team_lookup_fails(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(nil, services.ErrNotFound).Times(1)
+
+	request, err := setup.tjrService.CreateJoinRequestForTeamWithID(ctx, setup.requester.ID.Hex(), setup.team.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+	assert.Nil(t, request)
+}
+
+func Test_GetJoinRequestsForTeamWithID__should_return_team_requests(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	request := setup.insertJoinRequest(t, setup.team.ID, setup.requester.ID)
+	setup.insertJoinRequest(t, primitive.NewObjectID(), setup.requester.ID)
+
+	requests, err := setup.tjrService.GetJoinRequestsForTeamWithID(context.Background(), setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, request.ID, requests[0].ID)
+}
+
+func Test_GetJoinRequestsForUserWithID__should_return_user_requests(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	setup.insertJoinRequest(t, setup.team.ID, setup.requester.ID)
+	setup.insertJoinRequest(t, primitive.NewObjectID(), setup.requester.ID)
+	setup.insertJoinRequest(t, setup.team.ID, primitive.NewObjectID())
+
+	requests, err := setup.tjrService.GetJoinRequestsForUserWithID(context.Background(), setup.requester.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 2)
+}
+
+func Test_ApproveJoinRequestWithID__should_add_user_to_team_and_remove_their_requests(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	request := setup.insertJoinRequest(t, setup.team.ID, setup.requester.ID)
+	setup.insertJoinRequest(t, primitive.NewObjectID(), setup.requester.ID)
+	otherRequest := setup.insertJoinRequest(t, setup.team.ID, primitive.NewObjectID())
+
+	setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(ctx, setup.requester.ID.Hex(), setup.team.ID.Hex()).Return(nil).Times(1)
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.requester.ID.Hex()).Return(&setup.requester, nil).Times(1)
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+	setup.mockEService.EXPECT().SendTeamJoinRequestDecisionEmail(ctx, setup.requester, setup.team, true).Return(nil).Times(1)
+
+	err := setup.tjrService.ApproveJoinRequestWithID(ctx, setup.team.ID.Hex(), request.ID.Hex())
+	assert.NoError(t, err)
+
+	requests, err := setup.tjrService.GetJoinRequestsForUserWithID(ctx, setup.requester.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 0)
+
+	requests, err = setup.tjrService.GetJoinRequestsForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, otherRequest.ID, requests[0].ID)
+}
+
+func Test_ApproveJoinRequestWithID__should_remove_request_when_user_is_already_in_a_team(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	request := setup.insertJoinRequest(t, setup.team.ID, setup.requester.ID)
+
+	setup.mockTService.EXPECT().AddUserWithIDToTeamWithID(ctx, setup.requester.ID.Hex(), setup.team.ID.Hex()).
+		Return(services.ErrUserInTeam).Times(1)
+
+	err := setup.tjrService.ApproveJoinRequestWithID(ctx, setup.team.ID.Hex(), request.ID.Hex())
+	assert.Equal(t, services.ErrUserInTeam, err)
+
+	requests, err := setup.tjrService.GetJoinRequestsForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 0)
+}
+
+func Test_RejectJoinRequestWithID__should_remove_request_and_notify_user(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	request := setup.insertJoinRequest(t, setup.team.ID, setup.requester.ID)
+
+	setup.mockUService.EXPECT().GetUserWithID(ctx, setup.requester.ID.Hex()).Return(&setup.requester, nil).Times(1)
+	setup.mockTService.EXPECT().GetTeamWithID(ctx, setup.team.ID.Hex()).Return(&setup.team, nil).Times(1)
+	setup.mockEService.EXPECT().SendTeamJoinRequestDecisionEmail(ctx, setup.requester, setup.team, false).Return(nil).Times(1)
+
+	err := setup.tjrService.RejectJoinRequestWithID(ctx, setup.team.ID.Hex(), request.ID.Hex())
+	assert.NoError(t, err)
+
+	requests, err := setup.tjrService.GetJoinRequestsForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 0)
+}
+
+func Test_DeleteJoinRequestsForUserWithID__should_only_remove_user_requests(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.insertJoinRequest(t, setup.team.ID, setup.requester.ID)
+	setup.insertJoinRequest(t, primitive.NewObjectID(), setup.requester.ID)
+	otherRequest := setup.insertJoinRequest(t, setup.team.ID, primitive.NewObjectID())
+
+	err := setup.tjrService.DeleteJoinRequestsForUserWithID(ctx, setup.requester.ID.Hex())
+	assert.NoError(t, err)
+
+	requests, err := setup.tjrService.GetJoinRequestsForUserWithID(ctx, setup.requester.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 0)
+
+	requests, err = setup.tjrService.GetJoinRequestsForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, otherRequest.ID, requests[0].ID)
+}
+
+func Test_DeleteJoinRequestsForTeamWithID__should_only_remove_team_requests(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	ctx := context.Background()
+	setup.insertJoinRequest(t, setup.team.ID, setup.requester.ID)
+	setup.insertJoinRequest(t, setup.team.ID, primitive.NewObjectID())
+	otherRequest := setup.insertJoinRequest(t, primitive.NewObjectID(), setup.requester.ID)
+
+	err := setup.tjrService.DeleteJoinRequestsForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.NoError(t, err)
+
+	requests, err := setup.tjrService.GetJoinRequestsForTeamWithID(ctx, setup.team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 0)
+
+	requests, err = setup.tjrService.GetJoinRequestsForUserWithID(ctx, setup.requester.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, otherRequest.ID, requests[0].ID)
+}
+
+func Test_JoinRequestDecisions__should_return_ErrNotFound_when_request_is_for_another_team(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	request := setup.insertJoinRequest(t, primitive.NewObjectID(), setup.requester.ID)
+
+	err := setup.tjrService.ApproveJoinRequestWithID(context.Background(), setup.team.ID.Hex(), request.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+
+	err = setup.tjrService.RejectJoinRequestWithID(context.Background(), setup.team.ID.Hex(), request.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_JoinRequestDecisions__should_return_ErrInvalidID(t *testing.T) {
+	setup := setupTeamJoinRequestTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tjrService.GetJoinRequestsForTeamWithID(context.Background(), "invalid ID")
+	assert.Equal(t, services.ErrInvalidID, err)
+
+	_, err = setup.tjrService.GetJoinRequestsForUserWithID(context.Background(), "invalid ID")
+	assert.Equal(t, services.ErrInvalidID, err)
+
+	err = setup.tjrService.ApproveJoinRequestWithID(context.Background(), setup.team.ID.Hex(), "invalid ID")
+	assert.Equal(t, services.ErrInvalidID, err)
+
+	err = setup.tjrService.RejectJoinRequestWithID(context.Background(), "invalid ID", primitive.NewObjectID().Hex())
+	assert.Equal(t, services.ErrInvalidID, err)
+
+	err = setup.tjrService.DeleteJoinRequestsForUserWithID(context.Background(), "invalid ID")
+	assert.Equal(t, services.ErrInvalidID, err)
+
+	err = setup.tjrService.DeleteJoinRequestsForTeamWithID(context.Background(), "invalid ID")
+	assert.Equal(t, services.ErrInvalidID, err)
+}
@@ -2,36 +2,86 @@ package mongo
 
 import (
 	"context"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
 	"github.com/unicsmcr/hs_auth/entities"
 	"github.com/unicsmcr/hs_auth/environment"
 	"github.com/unicsmcr/hs_auth/repositories"
 	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
+const (
+	teamJoinCodeLength = 8
+	// join codes are random, so a few attempts are enough to find one that is not in use
+	maxJoinCodeGenerationAttempts = 5
+	// wrong join codes are counted in the same collection as failed logins
+	teamJoinCodeAttemptsKeyPrefix = "team_join:"
+)
+
 type mongoTeamService struct {
-	logger         *zap.Logger
-	env            *environment.Env
-	teamRepository *repositories.TeamRepository
-	userService    services.UserService
+	logger                    *zap.Logger
+	env                       *environment.Env
+	cfg                       *config.AppConfig
+	timeProvider              utils.TimeProvider
+	teamRepository            *repositories.TeamRepository
+	loginAttemptsRepository   *repositories.LoginAttemptsRepository
+	teamJoinRequestRepository *repositories.TeamJoinRequestRepository
+	teamAdvertRepository      *repositories.TeamAdvertRepository
+	userService               services.UserService
+	webhookService            services.WebhookService
+	transactions              transactionRunner
+}
+
+// webhookTeamEventData is the data sent to webhooks with team events
+type webhookTeamEventData struct {
+	Team   entities.Team      `json:"team"`
+	UserID primitive.ObjectID `json:"user_id,omitempty"`
 }
 
 // NewMongoTeamService creates a new TeamService that uses MongoDB as the storage technology
-func NewMongoTeamService(logger *zap.Logger, env *environment.Env, teamRepository *repositories.TeamRepository, userService services.UserService) services.TeamService {
+func NewMongoTeamService(logger *zap.Logger, env *environment.Env, cfg *config.AppConfig, timeProvider utils.TimeProvider,
+	teamRepository *repositories.TeamRepository, loginAttemptsRepository *repositories.LoginAttemptsRepository,
+	teamJoinRequestRepository *repositories.TeamJoinRequestRepository, teamAdvertRepository *repositories.TeamAdvertRepository,
+	userService services.UserService, webhookService services.WebhookService) services.TeamService {
 	return &mongoTeamService{
-		logger:         logger,
-		env:            env,
-		teamRepository: teamRepository,
-		userService:    userService,
+		logger:                    logger,
+		env:                       env,
+		cfg:                       cfg,
+		timeProvider:              timeProvider,
+		teamRepository:            teamRepository,
+		loginAttemptsRepository:   loginAttemptsRepository,
+		teamJoinRequestRepository: teamJoinRequestRepository,
+		teamAdvertRepository:      teamAdvertRepository,
+		userService:               userService,
+		webhookService:            webhookService,
 	}
 }
 
 func (s *mongoTeamService) CreateTeam(ctx context.Context, name, creatorID string) (*entities.Team, error) {
+	team, err := s.createTeam(ctx, name, creatorID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventTeamCreated, webhookTeamEventData{
+		Team: *team,
+	})
+
+	return team, nil
+}
+
+// createTeam creates a team which already has memberCount members, without emitting a webhook event
+func (s *mongoTeamService) createTeam(ctx context.Context, name, creatorID string, memberCount int) (*entities.Team, error) {
 	creatorMongoID, err := primitive.ObjectIDFromHex(creatorID)
 	if err != nil {
 		return nil, services.ErrInvalidID
@@ -49,10 +99,18 @@ func (s *mongoTeamService) CreateTeam(ctx context.Context, name, creatorID strin
 		return nil, errors.Wrap(err, "could not query for team with name")
 	}
 
+	joinCode, joinCodeExpiresAt, err := s.generateJoinCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	team := &entities.Team{
-		ID:      primitive.NewObjectID(),
-		Name:    name,
-		Creator: creatorMongoID,
+		ID:                primitive.NewObjectID(),
+		Name:              name,
+		Creator:           creatorMongoID,
+		JoinCode:          joinCode,
+		JoinCodeExpiresAt: joinCodeExpiresAt,
+		MemberCount:       memberCount,
 	}
 
 	_, err = s.teamRepository.InsertOne(ctx, *team)
@@ -64,30 +122,51 @@ func (s *mongoTeamService) CreateTeam(ctx context.Context, name, creatorID strin
 }
 
 func (s *mongoTeamService) CreateTeamForUserWithID(ctx context.Context, name, userID string) (*entities.Team, error) {
-	user, err := s.userService.GetUserWithID(ctx, userID)
-	if err != nil {
-		return nil, err
-	}
+	var (
+		user *entities.User
+		team *entities.Team
+	)
+	// the team is created and the user is added to it together, so the team cannot be left without its creator
+	err := s.transactions.run(ctx, s.logger, s.teamRepository.Database(), func(ctx context.Context) error {
+		var err error
+		user, err = s.userService.GetUserWithID(ctx, userID)
+		if err != nil {
+			return err
+		}
 
-	if user.Team != primitive.NilObjectID {
-		return nil, services.ErrUserInTeam
-	}
+		if user.Team != primitive.NilObjectID {
+			return services.ErrUserInTeam
+		}
+
+		// the creator is the team's first member
+		team, err = s.createTeam(ctx, name, userID, 1)
+		if err != nil {
+			return err
+		}
+
+		err = s.userService.JoinTeamForUserWithID(ctx, userID, team.ID.Hex())
+		if err != nil {
+			// without a transaction the new team has to be deleted again
+			deleteErr := s.DeleteTeamWithID(ctx, team.ID.Hex())
+			if deleteErr != nil {
+				s.logger.Error("could not delete team after adding user to new team failed", zap.Error(deleteErr))
+			}
+			return errors.Wrap(err, "could not add user to new team")
+		}
 
-	team, err := s.CreateTeam(ctx, name, userID)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.userService.UpdateUserWithID(ctx, userID, services.UserUpdateParams{
-		entities.UserTeam: team.ID,
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventTeamCreated, webhookTeamEventData{
+		Team: *team,
+	})
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventTeamMemberJoined, webhookTeamEventData{
+		Team:   *team,
+		UserID: user.ID,
 	})
-	if err != nil {
-		err := s.DeleteTeamWithID(ctx, team.ID.Hex())
-		if err != nil {
-			s.logger.Error("could not delete team after adding user to new team failed", zap.Error(err))
-		}
-		return nil, errors.Wrap(err, "could not add user to new team")
-	}
 
 	return team, nil
 }
@@ -107,6 +186,76 @@ func (s *mongoTeamService) GetTeams(ctx context.Context) ([]entities.Team, error
 	return teams, nil
 }
 
+func (s *mongoTeamService) GetTeamsPage(ctx context.Context, filter services.TeamFilter, page services.Pagination) ([]entities.Team, *services.PageInfo, error) {
+	query, findOptions, err := buildPageQuery(buildTeamFilterQuery(filter), page, string(entities.TeamName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cur, err := s.teamRepository.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not query for teams")
+	}
+	defer cur.Close(ctx)
+
+	teams, err := decodeTeamsResult(ctx, cur)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not decode result")
+	}
+
+	pageSize, pageInfo := makePageInfo(len(teams), page, func(i int) pageCursor {
+		cursor := pageCursor{ID: teams[i].ID}
+		if entities.TeamField(page.SortBy) == entities.TeamName {
+			cursor.Value = teams[i].Name
+		}
+		return cursor
+	})
+
+	return teams[:pageSize], pageInfo, nil
+}
+
+func (s *mongoTeamService) StreamTeams(ctx context.Context, filter services.TeamFilter, callback func(entities.Team) error) error {
+	cur, err := s.teamRepository.Find(ctx, buildTeamFilterQuery(filter),
+		options.Find().SetSort(bson.D{{Key: string(entities.TeamID), Value: 1}}))
+	if err != nil {
+		return errors.Wrap(err, "could not query for teams")
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var team entities.Team
+		err = cur.Decode(&team)
+		if err != nil {
+			return errors.Wrap(err, "could not decode team")
+		}
+
+		err = callback(team)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(cur.Err(), "could not iterate over teams")
+}
+
+// buildTeamFilterQuery builds the query matching the teams allowed by the filter
+func buildTeamFilterQuery(filter services.TeamFilter) bson.M {
+	query := bson.M{}
+	if len(filter.NamePrefix) > 0 {
+		query[string(entities.TeamName)] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.NamePrefix)}
+	}
+	if len(filter.Track) > 0 {
+		query[string(entities.TeamTrack)] = filter.Track
+	}
+	if len(filter.Tag) > 0 {
+		query[string(entities.TeamTags)] = strings.ToLower(filter.Tag)
+	}
+	if len(filter.Search) > 0 {
+		query["$text"] = bson.M{"$search": filter.Search}
+	}
+	return query
+}
+
 func (s *mongoTeamService) GetTeamWithID(ctx context.Context, id string) (*entities.Team, error) {
 	mongoID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -175,7 +324,138 @@ func (s *mongoTeamService) DeleteTeamWithID(ctx context.Context, id string) erro
 		return services.ErrNotFound
 	}
 
-	return err
+	// the team's join requests and advert are of no use once it is gone
+	err = deleteJoinRequestsForTeam(ctx, s.teamJoinRequestRepository, mongoID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.teamAdvertRepository.DeleteMany(ctx, bson.M{
+		string(entities.TeamAdvertTeam): mongoID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete team advert")
+	}
+
+	return nil
+}
+
+func (s *mongoTeamService) DisbandTeamWithID(ctx context.Context, teamID string) error {
+	var (
+		team    *entities.Team
+		members []entities.User
+	)
+	err := s.transactions.run(ctx, s.logger, s.teamRepository.Database(), func(ctx context.Context) error {
+		var err error
+		team, err = s.GetTeamWithID(ctx, teamID)
+		if err != nil {
+			return err
+		}
+
+		members, err = s.userService.GetUsersWithTeam(ctx, teamID)
+		if err != nil {
+			return err
+		}
+
+		err = s.userService.UpdateUsersWithTeam(ctx, teamID, services.UserUpdateParams{
+			entities.UserTeam: primitive.NilObjectID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.DeleteTeamWithID(ctx, teamID)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventTeamMemberLeft, webhookTeamEventData{
+			Team:   *team,
+			UserID: member.ID,
+		})
+	}
+
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventTeamDeleted, webhookTeamEventData{
+		Team: *team,
+	})
+
+	return nil
+}
+
+func (s *mongoTeamService) RenameTeamWithID(ctx context.Context, teamID string, name string) (*entities.Team, error) {
+	return s.UpdateTeamWithID(ctx, teamID, services.TeamUpdateParams{
+		entities.TeamName: name,
+	})
+}
+
+func (s *mongoTeamService) UpdateTeamWithID(ctx context.Context, teamID string, params services.TeamUpdateParams) (*entities.Team, error) {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	if name, ok := params[entities.TeamName]; ok {
+		// check if name is not taken by another team
+		err = s.teamRepository.FindOne(ctx, bson.M{
+			string(entities.TeamName): name,
+			string(entities.TeamID):   bson.M{"$ne": mongoID},
+		}).Err()
+		if err == nil {
+			return nil, services.ErrNameTaken
+		} else if err != mongo.ErrNoDocuments {
+			return nil, errors.Wrap(err, "could not query for team with name")
+		}
+	}
+
+	set := bson.M{}
+	for field, value := range params {
+		set[string(field)] = value
+	}
+
+	return s.updateTeam(ctx, mongoID, set)
+}
+
+func (s *mongoTeamService) SetCreatorForTeamWithID(ctx context.Context, teamID string, userID string) (*entities.Team, error) {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Team != mongoID {
+		return nil, services.ErrUserNotInTeam
+	}
+
+	return s.updateTeam(ctx, mongoID, bson.M{
+		string(entities.TeamCreator): user.ID,
+	})
+}
+
+// updateTeam sets the given fields of the team with the given id, notifies webhooks and returns the updated team
+func (s *mongoTeamService) updateTeam(ctx context.Context, teamID primitive.ObjectID, set bson.M) (*entities.Team, error) {
+	var team entities.Team
+	err := s.teamRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.TeamID): teamID,
+	}, bson.M{
+		"$set": set,
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&team)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not update team")
+	}
+
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventTeamUpdated, webhookTeamEventData{
+		Team: team,
+	})
+
+	return &team, nil
 }
 
 func (s *mongoTeamService) AddUserWithIDToTeamWithID(ctx context.Context, userID string, teamID string) error {
@@ -184,61 +464,412 @@ func (s *mongoTeamService) AddUserWithIDToTeamWithID(ctx context.Context, userID
 		return err
 	}
 
-	// TODO: missing error handling
 	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	if user.Team != primitive.NilObjectID {
 		return services.ErrUserInTeam
 	}
 
-	return s.userService.UpdateUserWithID(ctx, userID, services.UserUpdateParams{
-		entities.UserTeam: team.ID,
+	// the member is counted and the user joins the team together, so the team's member count stays correct
+	err = s.transactions.run(ctx, s.logger, s.teamRepository.Database(), func(ctx context.Context) error {
+		err := s.reserveTeamSlot(ctx, team.ID)
+		if err != nil {
+			return err
+		}
+
+		err = s.userService.JoinTeamForUserWithID(ctx, userID, team.ID.Hex())
+		if err != nil {
+			// without a transaction the slot has to be released again
+			releaseErr := s.releaseTeamSlot(ctx, team.ID)
+			if releaseErr != nil {
+				s.logger.Error("could not release team slot after adding user to team failed", zap.String("teamId", team.ID.Hex()), zap.Error(releaseErr))
+			}
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventTeamMemberJoined, webhookTeamEventData{
+		Team:   *team,
+		UserID: user.ID,
 	})
+
+	return nil
 }
 
-func (s *mongoTeamService) RemoveUserWithIDFromTheirTeam(ctx context.Context, userID string) error {
-	user, err := s.userService.GetUserWithID(ctx, userID)
+func (s *mongoTeamService) GetTeamWithJoinCode(ctx context.Context, userID string, joinCode string) (*entities.Team, error) {
+	_, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return err
+		return nil, services.ErrInvalidID
 	}
 
-	if user.Team == primitive.NilObjectID {
-		return services.ErrUserNotInTeam
+	now := s.timeProvider.Now()
+	attemptsKey := teamJoinCodeAttemptsKeyPrefix + userID
+
+	throttled, err := s.isJoinCodeThrottled(ctx, attemptsKey, now)
+	if err != nil {
+		return nil, err
+	} else if throttled {
+		return nil, services.ErrJoinCodeThrottled
 	}
 
-	team, err := s.GetTeamWithID(ctx, user.Team.Hex())
+	res := s.teamRepository.FindOne(ctx, bson.M{
+		string(entities.TeamJoinCode): normaliseJoinCode(joinCode),
+		"$or": []bson.M{
+			{string(entities.TeamJoinCodeExpiresAt): bson.M{"$exists": false}},
+			{string(entities.TeamJoinCodeExpiresAt): bson.M{"$gt": now}},
+		},
+	})
+
+	team, err := decodeTeamResult(res)
+	if errors.Cause(err) == mongo.ErrNoDocuments {
+		err = s.recordJoinCodeFailure(ctx, attemptsKey, now)
+		if err != nil {
+			return nil, err
+		}
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for team with join code")
+	}
+
+	return team, nil
+}
+
+func (s *mongoTeamService) AddUserWithIDToTeamWithJoinCode(ctx context.Context, userID string, joinCode string) error {
+	team, err := s.GetTeamWithJoinCode(ctx, userID, joinCode)
 	if err != nil {
 		return err
 	}
 
-	err = s.userService.UpdateUserWithID(ctx, userID, services.UserUpdateParams{
-		entities.UserTeam: primitive.NilObjectID,
+	if team.Privacy == entities.TeamClosed {
+		return services.ErrTeamClosed
+	}
+
+	return s.AddUserWithIDToTeamWithID(ctx, userID, team.ID.Hex())
+}
+
+func (s *mongoTeamService) SetPrivacyForTeamWithID(ctx context.Context, teamID string, privacy entities.TeamPrivacySetting) (*entities.Team, error) {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	if privacy != entities.TeamOpen && privacy != entities.TeamClosed {
+		return nil, services.ErrInvalidTeamPrivacy
+	}
+
+	var team entities.Team
+	err = s.teamRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.TeamID): mongoID,
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.TeamPrivacy): privacy,
+		},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&team)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not update team's privacy")
+	}
+
+	return &team, nil
+}
+
+func (s *mongoTeamService) SetMemberLimitForTeamWithID(ctx context.Context, teamID string, maxMembers int) (*entities.Team, error) {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	if maxMembers < 0 {
+		return nil, services.ErrInvalidMemberLimit
+	}
+
+	update := bson.M{
+		"$set": bson.M{string(entities.TeamMaxMembers): maxMembers},
+	}
+	if maxMembers == 0 {
+		update = bson.M{
+			"$unset": bson.M{string(entities.TeamMaxMembers): ""},
+		}
+	}
+
+	var team entities.Team
+	err = s.teamRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.TeamID): mongoID,
+	}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&team)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not update team's member limit")
+	}
+
+	return &team, nil
+}
+
+func (s *mongoTeamService) RegenerateJoinCodeForTeamWithID(ctx context.Context, teamID string) (*entities.Team, error) {
+	mongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	joinCode, joinCodeExpiresAt, err := s.generateJoinCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{
+		string(entities.TeamJoinCode): joinCode,
+	}
+	update := bson.M{"$set": set}
+	if joinCodeExpiresAt.IsZero() {
+		update["$unset"] = bson.M{string(entities.TeamJoinCodeExpiresAt): ""}
+	} else {
+		set[string(entities.TeamJoinCodeExpiresAt)] = joinCodeExpiresAt
+	}
+
+	var team entities.Team
+	err = s.teamRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.TeamID): mongoID,
+	}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&team)
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not update team's join code")
+	}
+
+	return &team, nil
+}
+
+func (s *mongoTeamService) RemoveUserWithIDFromTheirTeam(ctx context.Context, userID string) error {
+	var (
+		user *entities.User
+		team *entities.Team
+	)
+	// the user leaves and the team gets a new creator or is deleted together,
+	// so concurrent leaves cannot leave the team without a creator or members
+	err := s.transactions.run(ctx, s.logger, s.teamRepository.Database(), func(ctx context.Context) error {
+		var err error
+		user, err = s.userService.GetUserWithID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if user.Team == primitive.NilObjectID {
+			return services.ErrUserNotInTeam
+		}
+
+		team, err = s.GetTeamWithID(ctx, user.Team.Hex())
+		if err != nil {
+			return err
+		}
+
+		err = s.userService.UpdateUserWithID(ctx, userID, services.UserUpdateParams{
+			entities.UserTeam: primitive.NilObjectID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = s.releaseTeamSlot(ctx, team.ID)
+		if err != nil {
+			return err
+		}
+
+		if team.Creator != user.ID {
+			return nil
+		}
+
+		// Removed team's creator from the team, need to assign new creator
+		teamMembers, err := s.userService.GetUsersWithTeam(ctx, team.ID.Hex())
+		if err != nil {
+			return err
+		}
+
+		if len(teamMembers) == 0 { // team is empty, should be deleted
+			return s.DeleteTeamWithID(ctx, team.ID.Hex())
+		}
+
+		_, err = s.teamRepository.UpdateOne(ctx, bson.M{
+			string(entities.TeamID): team.ID,
+		}, bson.M{
+			"$set": map[entities.TeamField]interface{}{
+				entities.TeamCreator: teamMembers[0].ID,
+			},
+		})
+		return err
 	})
 	if err != nil {
 		return err
 	}
 
-	if team.Creator != user.ID {
-		return nil
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventTeamMemberLeft, webhookTeamEventData{
+		Team:   *team,
+		UserID: user.ID,
+	})
+
+	return nil
+}
+
+func (s *mongoTeamService) RemoveUserWithIDFromTeamWithID(ctx context.Context, teamID string, userID string) error {
+	team, err := s.GetTeamWithID(ctx, teamID)
+	if err != nil {
+		return err
 	}
 
-	// Removed team's creator from the team, need to assign new creator
-	teamMembers, err := s.userService.GetUsersWithTeam(ctx, team.ID.Hex())
+	user, err := s.userService.GetUserWithID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	if len(teamMembers) == 0 { // team is empty, should be deleted
-		return s.DeleteTeamWithID(ctx, team.ID.Hex())
+	if user.Team != team.ID {
+		return services.ErrUserNotInTeam
+	} else if user.ID == team.Creator {
+		return services.ErrUserIsTeamCreator
 	}
 
-	_, err = s.teamRepository.UpdateOne(ctx, bson.M{
-		string(entities.TeamID): team.ID,
-	}, bson.M{
-		"$set": map[entities.TeamField]interface{}{
-			entities.TeamCreator: teamMembers[0].ID,
+	return s.RemoveUserWithIDFromTheirTeam(ctx, userID)
+}
+
+// reserveTeamSlot counts a new member of the team, unless the team has reached its member limit.
+// The limit is checked in the same update that counts the member, so concurrent joins cannot exceed it
+func (s *mongoTeamService) reserveTeamSlot(ctx context.Context, teamID primitive.ObjectID) error {
+	memberCount := bson.M{"$ifNull": bson.A{"$" + string(entities.TeamMemberCount), 0}}
+	maxMembers := bson.M{"$ifNull": bson.A{"$" + string(entities.TeamMaxMembers), 0}}
+	limit := bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{maxMembers, 0}}, maxMembers, s.cfg.Teams.MaxMembers}}
+
+	res, err := s.teamRepository.UpdateOne(ctx, bson.M{
+		string(entities.TeamID): teamID,
+		"$expr": bson.M{
+			"$or": bson.A{
+				bson.M{"$lte": bson.A{limit, 0}},
+				bson.M{"$lt": bson.A{memberCount, limit}},
+			},
 		},
+	}, bson.M{
+		"$inc": bson.M{string(entities.TeamMemberCount): 1},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not count new team member")
+	} else if res.MatchedCount == 0 {
+		return services.ErrTeamFull
+	}
+
+	return nil
+}
+
+// releaseTeamSlot stops counting one of the team's members
+func (s *mongoTeamService) releaseTeamSlot(ctx context.Context, teamID primitive.ObjectID) error {
+	_, err := s.teamRepository.UpdateOne(ctx, bson.M{
+		string(entities.TeamID):          teamID,
+		string(entities.TeamMemberCount): bson.M{"$gt": 0},
+	}, bson.M{
+		"$inc": bson.M{string(entities.TeamMemberCount): -1},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not stop counting team member")
+	}
+
+	return nil
+}
+
+// generateJoinCode returns a join code that is not used by any team and the time it should expire at,
+// which is the zero time if join codes do not expire
+func (s *mongoTeamService) generateJoinCode(ctx context.Context) (string, time.Time, error) {
+	var expiresAt time.Time
+	if s.cfg.Teams.JoinCodeLifetime > 0 {
+		expiresAt = s.timeProvider.Now().Add(time.Duration(s.cfg.Teams.JoinCodeLifetime) * time.Second)
+	}
+
+	for i := 0; i < maxJoinCodeGenerationAttempts; i++ {
+		joinCode, err := utils.GenerateRandomCode(teamJoinCodeLength)
+		if err != nil {
+			return "", time.Time{}, errors.Wrap(err, "could not generate join code")
+		}
+
+		// expired codes stay on their teams until they are regenerated, so they cannot be reused either
+		err = s.teamRepository.FindOne(ctx, bson.M{
+			string(entities.TeamJoinCode): joinCode,
+		}).Err()
+		if err == mongo.ErrNoDocuments {
+			return joinCode, expiresAt, nil
+		} else if err != nil {
+			return "", time.Time{}, errors.Wrap(err, "could not query for team with join code")
+		}
+	}
+
+	return "", time.Time{}, errors.New("could not generate unused join code")
+}
+
+// isJoinCodeThrottled checks whether the wrong join codes stored under the given key
+// have reached the configured limit
+func (s *mongoTeamService) isJoinCodeThrottled(ctx context.Context, key string, now time.Time) (bool, error) {
+	if s.cfg.Teams.MaxJoinCodeFailures <= 0 {
+		return false, nil
+	}
+
+	var attempts entities.LoginAttempts
+	err := s.loginAttemptsRepository.FindOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey):       key,
+		string(entities.LoginAttemptsExpiresAt): bson.M{"$gt": now},
+	}).Decode(&attempts)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "could not query for wrong join codes")
+	}
+
+	return attempts.Failures >= s.cfg.Teams.MaxJoinCodeFailures, nil
+}
+
+// recordJoinCodeFailure counts a wrong join code under the given key.
+// The wrong codes are forgotten once the failure window since the first of them has passed.
+func (s *mongoTeamService) recordJoinCodeFailure(ctx context.Context, key string, now time.Time) error {
+	// expired failures may not have been removed by the TTL monitor yet
+	_, err := s.loginAttemptsRepository.DeleteOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey):       key,
+		string(entities.LoginAttemptsExpiresAt): bson.M{"$lte": now},
 	})
-	return err
+	if err != nil {
+		return errors.Wrap(err, "could not clear expired wrong join codes")
+	}
+
+	_, err = s.loginAttemptsRepository.UpdateOne(ctx, bson.M{
+		string(entities.LoginAttemptsKey): key,
+	}, bson.M{
+		"$inc": bson.M{
+			string(entities.LoginAttemptsFailures): 1,
+		},
+		"$set": bson.M{
+			string(entities.LoginAttemptsLastFailureAt): now,
+		},
+		"$setOnInsert": bson.M{
+			string(entities.LoginAttemptsExpiresAt): now.Add(time.Duration(s.cfg.Teams.JoinCodeFailureWindow) * time.Second),
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return errors.Wrap(err, "could not record wrong join code")
+	}
+
+	return nil
+}
+
+// normaliseJoinCode converts a join code entered by a user to the format it is stored in,
+// so that codes can be entered in lowercase and with spaces or dashes
+func normaliseJoinCode(joinCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(joinCode)))
 }
 
 func decodeTeamResult(res *mongo.SingleResult) (*entities.Team, error) {
@@ -8,12 +8,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
 	"github.com/unicsmcr/hs_auth/entities"
 	"github.com/unicsmcr/hs_auth/environment"
 	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
 	"github.com/unicsmcr/hs_auth/repositories"
 	"github.com/unicsmcr/hs_auth/services"
 	"github.com/unicsmcr/hs_auth/testutils"
@@ -28,13 +31,18 @@ var (
 		Name:    "Team of Bobs",
 		Creator: primitive.NewObjectID(),
 	}
+	testTeamTime = time.Date(2020, 10, 10, 10, 0, 0, 0, time.UTC)
 )
 
 type teamTestSetup struct {
 	ctrl         *gomock.Controller
 	tService     *mongoTeamService
 	tRepo        *repositories.TeamRepository
+	laRepo       *repositories.LoginAttemptsRepository
+	tjrRepo      *repositories.TeamJoinRequestRepository
+	taRepo       *repositories.TeamAdvertRepository
 	mockUService *mock_services.MockUserService
+	mockWService *mock_services.MockWebhookService
 	cleanup      func()
 	testCtx      *gin.Context
 }
@@ -44,12 +52,31 @@ func setupTeamTest(t *testing.T) *teamTestSetup {
 
 	ctrl := gomock.NewController(t)
 	mockUService := mock_services.NewMockUserService(ctrl)
+	mockWService := mock_services.NewMockWebhookService(ctrl)
 
 	tRepo, err := repositories.NewTeamRepository(db)
 	if err != nil {
 		panic(err)
 	}
 
+	laRepo, err := repositories.NewLoginAttemptsRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	tjrRepo, err := repositories.NewTeamJoinRequestRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	taRepo, err := repositories.NewTeamAdvertRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTimeProvider.EXPECT().Now().Return(testTeamTime).AnyTimes()
+
 	resetEnv := testutils.SetEnvVars(map[string]string{
 		environment.JWTSecret: testJWTSecret,
 	})
@@ -57,10 +84,21 @@ func setupTeamTest(t *testing.T) *teamTestSetup {
 	resetEnv()
 
 	tService := &mongoTeamService{
-		logger:         zap.NewNop(),
-		env:            env,
-		teamRepository: tRepo,
-		userService:    mockUService,
+		logger: zap.NewNop(),
+		env:    env,
+		cfg: &config.AppConfig{
+			Teams: config.TeamConfig{
+				MaxJoinCodeFailures:   2,
+				JoinCodeFailureWindow: 100,
+			},
+		},
+		timeProvider:              mockTimeProvider,
+		teamRepository:            tRepo,
+		loginAttemptsRepository:   laRepo,
+		teamJoinRequestRepository: tjrRepo,
+		teamAdvertRepository:      taRepo,
+		userService:               mockUService,
+		webhookService:            mockWService,
 	}
 
 	w := httptest.NewRecorder()
@@ -70,16 +108,57 @@ func setupTeamTest(t *testing.T) *teamTestSetup {
 		ctrl:         ctrl,
 		tService:     tService,
 		tRepo:        tRepo,
+		laRepo:       laRepo,
+		tjrRepo:      tjrRepo,
+		taRepo:       taRepo,
 		mockUService: mockUService,
+		mockWService: mockWService,
 		cleanup: func() {
 			tRepo.Drop(context.Background())
+			laRepo.Drop(context.Background())
+			tjrRepo.Drop(context.Background())
+			taRepo.Drop(context.Background())
 		},
 		testCtx: testCtx,
 	}
 }
 
+// insertJoinRequestAndAdvert stores a join request and an advert for the team with the given id
+func (setup *teamTestSetup) insertJoinRequestAndAdvert(t *testing.T, teamID primitive.ObjectID) {
+	_, err := setup.tjrRepo.InsertOne(context.Background(), entities.TeamJoinRequest{
+		ID:        primitive.NewObjectID(),
+		Team:      teamID,
+		User:      primitive.NewObjectID(),
+		CreatedAt: testTeamTime,
+	})
+	assert.NoError(t, err)
+
+	_, err = setup.taRepo.InsertOne(context.Background(), entities.TeamAdvert{
+		ID:        primitive.NewObjectID(),
+		Team:      teamID,
+		Pitch:     "we need a designer",
+		UpdatedAt: testTeamTime,
+	})
+	assert.NoError(t, err)
+}
+
+// assertJoinRequestsAndAdverts checks how many join requests and adverts are stored for the team with the given id
+func (setup *teamTestSetup) assertJoinRequestsAndAdverts(t *testing.T, teamID primitive.ObjectID, expected int64) {
+	count, err := setup.tjrRepo.CountDocuments(context.Background(), bson.M{
+		string(entities.TeamJoinRequestTeam): teamID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, count)
+
+	count, err = setup.taRepo.CountDocuments(context.Background(), bson.M{
+		string(entities.TeamAdvertTeam): teamID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, count)
+}
+
 func Test_NewMongoTeamService__should_return_non_nil_object(t *testing.T) {
-	assert.NotNil(t, NewMongoTeamService(nil, nil, nil, nil))
+	assert.NotNil(t, NewMongoTeamService(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
 }
 
 func Test_Team_ErrInvalidID_should_be_returned_when_provided_id_is_invalid(t *testing.T) {
@@ -118,6 +197,59 @@ func Test_Team_ErrInvalidID_should_be_returned_when_provided_id_is_invalid(t *te
 				return err
 			},
 		},
+		{
+			name: "AddUserWithIDToTeamWithJoinCode",
+			testFunction: func(id string) error {
+				return setup.tService.AddUserWithIDToTeamWithJoinCode(context.Background(), id, "ABCD2345")
+			},
+		},
+		{
+			name: "RegenerateJoinCodeForTeamWithID",
+			testFunction: func(id string) error {
+				_, err := setup.tService.RegenerateJoinCodeForTeamWithID(context.Background(), id)
+				return err
+			},
+		},
+		{
+			name: "SetPrivacyForTeamWithID",
+			testFunction: func(id string) error {
+				_, err := setup.tService.SetPrivacyForTeamWithID(context.Background(), id, entities.TeamClosed)
+				return err
+			},
+		},
+		{
+			name: "SetMemberLimitForTeamWithID",
+			testFunction: func(id string) error {
+				_, err := setup.tService.SetMemberLimitForTeamWithID(context.Background(), id, 8)
+				return err
+			},
+		},
+		{
+			name: "DisbandTeamWithID",
+			testFunction: func(id string) error {
+				return setup.tService.DisbandTeamWithID(context.Background(), id)
+			},
+		},
+		{
+			name: "RenameTeamWithID",
+			testFunction: func(id string) error {
+				_, err := setup.tService.RenameTeamWithID(context.Background(), id, "Team of Bobs")
+				return err
+			},
+		},
+		{
+			name: "SetCreatorForTeamWithID",
+			testFunction: func(id string) error {
+				_, err := setup.tService.SetCreatorForTeamWithID(context.Background(), id, testUser.ID.Hex())
+				return err
+			},
+		},
+		{
+			name: "RemoveUserWithIDFromTeamWithID",
+			testFunction: func(id string) error {
+				return setup.tService.RemoveUserWithIDFromTeamWithID(context.Background(), id, testUser.ID.Hex())
+			},
+		},
 		{
 			name: "RemoveUserWithIDFromTheirTeam",
 			testFunction: func(id string) error {
@@ -152,18 +284,77 @@ func Test_CreateTeam__should_create_correct_team(t *testing.T) {
 	setup := setupTeamTest(t)
 	defer setup.cleanup()
 
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamCreated, gomock.Any()).
+		Return(nil).Times(1)
+
 	team, err := setup.tService.CreateTeam(context.Background(), testTeam.Name, testTeam.Creator.Hex())
 	assert.NoError(t, err)
 
+	assert.Len(t, team.JoinCode, teamJoinCodeLength)
+	assert.True(t, team.JoinCodeExpiresAt.IsZero())
+
 	res := setup.tRepo.FindOne(context.Background(), bson.M{
-		string(entities.TeamID):      team.ID,
-		string(entities.TeamName):    testTeam.Name,
-		string(entities.TeamCreator): testTeam.Creator,
+		string(entities.TeamID):       team.ID,
+		string(entities.TeamName):     testTeam.Name,
+		string(entities.TeamCreator):  testTeam.Creator,
+		string(entities.TeamJoinCode): team.JoinCode,
 	})
 
 	assert.NoError(t, res.Err())
 }
 
+func Test_CreateTeamForUserWithID__should_return_ErrUserInTeam_when_user_is_in_a_team(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), testUser.ID.Hex()).
+		Return(&entities.User{ID: testUser.ID, Team: testTeam.ID}, nil).Times(1)
+
+	team, err := setup.tService.CreateTeamForUserWithID(context.Background(), testTeam.Name, testUser.ID.Hex())
+	assert.Equal(t, services.ErrUserInTeam, err)
+	assert.Nil(t, team)
+}
+
+func Test_CreateTeamForUserWithID__should_create_team_with_user_as_its_creator(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), testUser.ID.Hex()).
+		Return(&entities.User{ID: testUser.ID}, nil).Times(1)
+	setup.mockUService.EXPECT().JoinTeamForUserWithID(context.Background(), testUser.ID.Hex(), gomock.Any()).
+		Return(nil).Times(1)
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamCreated, gomock.Any()).
+		Return(nil).Times(1)
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberJoined, gomock.Any()).
+		Return(nil).Times(1)
+
+	team, err := setup.tService.CreateTeamForUserWithID(context.Background(), testTeam.Name, testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, testUser.ID, team.Creator)
+	assert.Equal(t, 1, team.MemberCount)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), team.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, team, storedTeam)
+}
+
+func Test_CreateTeamForUserWithID__should_not_leave_team_behind_when_user_cannot_be_added_to_it(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), testUser.ID.Hex()).
+		Return(&entities.User{ID: testUser.ID}, nil).Times(1)
+	setup.mockUService.EXPECT().JoinTeamForUserWithID(context.Background(), testUser.ID.Hex(), gomock.Any()).
+		Return(errors.New("service err")).Times(1)
+
+	team, err := setup.tService.CreateTeamForUserWithID(context.Background(), testTeam.Name, testUser.ID.Hex())
+	assert.Error(t, err)
+	assert.Nil(t, team)
+
+	_, err = setup.tService.GetTeamWithName(context.Background(), testTeam.Name)
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
 func Test_GetTeams__should_return_expected_teams(t *testing.T) {
 	setup := setupTeamTest(t)
 	defer setup.cleanup()
@@ -183,6 +374,98 @@ func Test_GetTeams__should_return_expected_teams(t *testing.T) {
 	assert.Equal(t, testTeams, teams)
 }
 
+func Test_GetTeamsPage__should_return_teams_page_by_page(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeam2 := testTeam
+	testTeam2.ID = primitive.NewObjectID()
+	testTeam2.Name = "Team of Robs"
+	testTeam3 := testTeam
+	testTeam3.ID = primitive.NewObjectID()
+	testTeam3.Name = "Team of Amys"
+
+	_, err := setup.tRepo.InsertMany(context.Background(), []interface{}{testTeam, testTeam2, testTeam3})
+	assert.NoError(t, err)
+
+	page := services.Pagination{Limit: 2, SortBy: "name", Descending: true}
+	filter := services.TeamFilter{NamePrefix: "Team of"}
+	teams, pageInfo, err := setup.tService.GetTeamsPage(context.Background(), filter, page)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Team{testTeam2, testTeam}, teams)
+	assert.True(t, pageInfo.HasMore)
+
+	page.After = pageInfo.NextCursor
+	teams, pageInfo, err = setup.tService.GetTeamsPage(context.Background(), filter, page)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Team{testTeam3}, teams)
+	assert.Equal(t, &services.PageInfo{}, pageInfo)
+}
+
+func Test_GetTeamsPage__should_return_ErrInvalidCursor_when_cursor_is_invalid(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, _, err := setup.tService.GetTeamsPage(context.Background(), services.TeamFilter{}, services.Pagination{After: "invalid"})
+	assert.Equal(t, services.ErrInvalidCursor, err)
+}
+
+func Test_StreamTeams__should_call_callback_with_matching_teams(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeam2 := testTeam
+	testTeam2.ID = primitive.NewObjectID()
+	testTeam2.Name = "Team of Robs"
+	testTeam3 := testTeam
+	testTeam3.ID = primitive.NewObjectID()
+	testTeam3.Name = "Amys"
+
+	_, err := setup.tRepo.InsertMany(context.Background(), []interface{}{testTeam, testTeam2, testTeam3})
+	assert.NoError(t, err)
+
+	var teams []entities.Team
+	err = setup.tService.StreamTeams(context.Background(), services.TeamFilter{NamePrefix: "Team of"}, func(team entities.Team) error {
+		teams = append(teams, team)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Team{testTeam, testTeam2}, teams)
+}
+
+func Test_GetTeamsPage__should_filter_teams_by_track_tag_and_search(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeam2 := testTeam
+	testTeam2.ID = primitive.NewObjectID()
+	testTeam2.Name = "Team of Robs"
+	testTeam2.Description = "We build robots"
+	testTeam2.Track = "hardware"
+	testTeam2.Tags = []string{"robots"}
+	testTeam3 := testTeam
+	testTeam3.ID = primitive.NewObjectID()
+	testTeam3.Name = "Team of Amys"
+	testTeam3.Track = "hardware"
+	testTeam3.Tags = []string{"web"}
+
+	_, err := setup.tRepo.InsertMany(context.Background(), []interface{}{testTeam, testTeam2, testTeam3})
+	assert.NoError(t, err)
+
+	page := services.Pagination{Limit: 10, SortBy: "name"}
+	teams, _, err := setup.tService.GetTeamsPage(context.Background(), services.TeamFilter{Track: "hardware"}, page)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Team{testTeam3, testTeam2}, teams)
+
+	teams, _, err = setup.tService.GetTeamsPage(context.Background(), services.TeamFilter{Tag: "Robots"}, page)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Team{testTeam2}, teams)
+
+	teams, _, err = setup.tService.GetTeamsPage(context.Background(), services.TeamFilter{Search: "robots"}, page)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Team{testTeam2}, teams)
+}
+
 func Test_GetTeamWithID__should_return_ErrNotFound_when_team_with_id_doesnt_exist(t *testing.T) {
 	setup := setupTeamTest(t)
 	defer setup.cleanup()
@@ -331,6 +614,25 @@ func Test_DeleteTeamWithID__should_delete_expected_team(t *testing.T) {
 	assert.Equal(t, []entities.Team{testTeam2}, teams)
 }
 
+func Test_DeleteTeamWithID__should_delete_teams_join_requests_and_advert(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeam2 := testTeam
+	testTeam2.ID = primitive.NewObjectID()
+
+	_, err := setup.tRepo.InsertMany(context.Background(), []interface{}{testTeam, testTeam2})
+	assert.NoError(t, err)
+	setup.insertJoinRequestAndAdvert(t, testTeam.ID)
+	setup.insertJoinRequestAndAdvert(t, testTeam2.ID)
+
+	err = setup.tService.DeleteTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+
+	setup.assertJoinRequestsAndAdverts(t, testTeam.ID, 0)
+	setup.assertJoinRequestsAndAdverts(t, testTeam2.ID, 1)
+}
+
 func Test_AddUserWithIDToTeamWithID__should_add_correct_user_to_correct_team(t *testing.T) {
 	setup := setupTeamTest(t)
 	defer setup.cleanup()
@@ -344,12 +646,143 @@ func Test_AddUserWithIDToTeamWithID__should_add_correct_user_to_correct_team(t *
 	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid").
 		Return(&testUser2, nil).Times(1)
 
-	setup.mockUService.EXPECT().UpdateUserWithID(context.Background(), "testid", services.UserUpdateParams{
-		entities.UserTeam: testTeam.ID,
-	})
+	setup.mockUService.EXPECT().JoinTeamForUserWithID(context.Background(), "testid", testTeam.ID.Hex())
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberJoined, webhookTeamEventData{
+		Team:   testTeam,
+		UserID: testUser2.ID,
+	}).Return(nil).Times(1)
+
+	err = setup.tService.AddUserWithIDToTeamWithID(context.Background(), "testid", testTeam.ID.Hex())
+	assert.NoError(t, err)
+}
+
+func Test_AddUserWithIDToTeamWithID__should_count_new_team_member(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+	setup.tService.cfg.Teams.MaxMembers = 2
+
+	testTeamWithMembers := testTeam
+	testTeamWithMembers.MemberCount = 1
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeamWithMembers)
+	assert.NoError(t, err)
+
+	testUser2 := testUser
+	testUser2.Team = primitive.NilObjectID
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid").Return(&testUser2, nil).Times(1)
+	setup.mockUService.EXPECT().JoinTeamForUserWithID(context.Background(), "testid", testTeam.ID.Hex()).Return(nil).Times(1)
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberJoined, gomock.Any()).
+		Return(nil).Times(1)
+
+	err = setup.tService.AddUserWithIDToTeamWithID(context.Background(), "testid", testTeam.ID.Hex())
+	assert.NoError(t, err)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, storedTeam.MemberCount)
+}
+
+func Test_AddUserWithIDToTeamWithID__should_return_ErrTeamFull_when_team_has_reached_member_limit(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+	setup.tService.cfg.Teams.MaxMembers = 2
+
+	testFullTeam := testTeam
+	testFullTeam.MemberCount = 2
+	_, err := setup.tRepo.InsertOne(context.Background(), testFullTeam)
+	assert.NoError(t, err)
+
+	testUser2 := testUser
+	testUser2.Team = primitive.NilObjectID
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid").Return(&testUser2, nil).Times(1)
+
+	err = setup.tService.AddUserWithIDToTeamWithID(context.Background(), "testid", testTeam.ID.Hex())
+	assert.Equal(t, services.ErrTeamFull, err)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, storedTeam.MemberCount)
+}
+
+func Test_AddUserWithIDToTeamWithID__should_use_team_member_limit_over_configured_limit(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+	setup.tService.cfg.Teams.MaxMembers = 2
+
+	testLargeTeam := testTeam
+	testLargeTeam.MemberCount = 2
+	testLargeTeam.MaxMembers = 3
+	_, err := setup.tRepo.InsertOne(context.Background(), testLargeTeam)
+	assert.NoError(t, err)
+
+	testUser2 := testUser
+	testUser2.Team = primitive.NilObjectID
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid").Return(&testUser2, nil).Times(1)
+	setup.mockUService.EXPECT().JoinTeamForUserWithID(context.Background(), "testid", testTeam.ID.Hex()).Return(nil).Times(1)
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberJoined, gomock.Any()).
+		Return(nil).Times(1)
 
 	err = setup.tService.AddUserWithIDToTeamWithID(context.Background(), "testid", testTeam.ID.Hex())
 	assert.NoError(t, err)
+
+	// the team is now full
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid2").Return(&testUser2, nil).Times(1)
+	err = setup.tService.AddUserWithIDToTeamWithID(context.Background(), "testid2", testTeam.ID.Hex())
+	assert.Equal(t, services.ErrTeamFull, err)
+}
+
+func Test_AddUserWithIDToTeamWithID__should_not_count_member_when_user_cannot_be_updated(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+
+	testUser2 := testUser
+	testUser2.Team = primitive.NilObjectID
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid").Return(&testUser2, nil).Times(1)
+	setup.mockUService.EXPECT().JoinTeamForUserWithID(context.Background(), "testid", testTeam.ID.Hex()).Return(errors.New("service err")).Times(1)
+
+	err = setup.tService.AddUserWithIDToTeamWithID(context.Background(), "testid", testTeam.ID.Hex())
+	assert.Error(t, err)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, storedTeam.MemberCount)
+}
+
+func Test_AddUserWithIDToTeamWithID__should_not_count_member_when_user_joined_another_team(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+
+	testUser2 := testUser
+	testUser2.Team = primitive.NilObjectID
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid").Return(&testUser2, nil).Times(1)
+	// the user joins another team after they were fetched
+	setup.mockUService.EXPECT().JoinTeamForUserWithID(context.Background(), "testid", testTeam.ID.Hex()).
+		Return(services.ErrUserInTeam).Times(1)
+
+	err = setup.tService.AddUserWithIDToTeamWithID(context.Background(), "testid", testTeam.ID.Hex())
+	assert.Equal(t, services.ErrUserInTeam, err)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, storedTeam.MemberCount)
+}
+
+func Test_AddUserWithIDToTeamWithID__should_return_error_when_user_service_returns_error(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid").Return(nil, services.ErrNotFound).Times(1)
+
+	err = setup.tService.AddUserWithIDToTeamWithID(context.Background(), "testid", testTeam.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
 }
 
 func Test_AddUserWithIDToTeamWithID__should_return_err_when_user_is_already_in_a_team(t *testing.T) {
@@ -366,12 +799,435 @@ func Test_AddUserWithIDToTeamWithID__should_return_err_when_user_is_already_in_a
 	assert.Error(t, err)
 }
 
+func Test_AddUserWithIDToTeamWithJoinCode__should_add_user_to_team_with_join_code(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeamWithCode := testTeam
+	testTeamWithCode.JoinCode = "ABCD2345"
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeamWithCode)
+	assert.NoError(t, err)
+
+	testUser2 := testUser
+	testUser2.Team = primitive.NilObjectID
+	userID := testUser2.ID.Hex()
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), userID).Return(&testUser2, nil).Times(1)
+	setup.mockUService.EXPECT().JoinTeamForUserWithID(context.Background(), userID, testTeam.ID.Hex()).Return(nil).Times(1)
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberJoined, gomock.Any()).
+		Return(nil).Times(1)
+
+	// codes can be entered in lowercase and with dashes
+	err = setup.tService.AddUserWithIDToTeamWithJoinCode(context.Background(), userID, "abcd-2345")
+	assert.NoError(t, err)
+}
+
+func Test_AddUserWithIDToTeamWithJoinCode__should_return_ErrNotFound_when_join_code_has_expired(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeamWithCode := testTeam
+	testTeamWithCode.JoinCode = "ABCD2345"
+	testTeamWithCode.JoinCodeExpiresAt = testTeamTime.Add(-time.Minute)
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeamWithCode)
+	assert.NoError(t, err)
+
+	err = setup.tService.AddUserWithIDToTeamWithJoinCode(context.Background(), testUser.ID.Hex(), "ABCD2345")
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_AddUserWithIDToTeamWithJoinCode__should_return_ErrJoinCodeThrottled_after_too_many_wrong_codes(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeamWithCode := testTeam
+	testTeamWithCode.JoinCode = "ABCD2345"
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeamWithCode)
+	assert.NoError(t, err)
+
+	userID := testUser.ID.Hex()
+	for i := 0; i < setup.tService.cfg.Teams.MaxJoinCodeFailures; i++ {
+		err = setup.tService.AddUserWithIDToTeamWithJoinCode(context.Background(), userID, "WRONG234")
+		assert.Equal(t, services.ErrNotFound, err)
+	}
+
+	// the right code is refused too, until the failure window has passed
+	err = setup.tService.AddUserWithIDToTeamWithJoinCode(context.Background(), userID, "ABCD2345")
+	assert.Equal(t, services.ErrJoinCodeThrottled, err)
+
+	var attempts entities.LoginAttempts
+	err = setup.laRepo.FindOne(context.Background(), bson.M{
+		string(entities.LoginAttemptsKey): teamJoinCodeAttemptsKeyPrefix + userID,
+	}).Decode(&attempts)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts.Failures)
+	assert.Equal(t, testTeamTime.Add(100*time.Second), attempts.ExpiresAt.UTC())
+}
+
+func Test_AddUserWithIDToTeamWithJoinCode__should_return_ErrTeamClosed_when_team_is_closed(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeamWithCode := testTeam
+	testTeamWithCode.JoinCode = "ABCD2345"
+	testTeamWithCode.Privacy = entities.TeamClosed
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeamWithCode)
+	assert.NoError(t, err)
+
+	err = setup.tService.AddUserWithIDToTeamWithJoinCode(context.Background(), testUser.ID.Hex(), "ABCD2345")
+	assert.Equal(t, services.ErrTeamClosed, err)
+}
+
+func Test_SetPrivacyForTeamWithID__should_return_ErrInvalidTeamPrivacy_when_privacy_is_unknown(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	team, err := setup.tService.SetPrivacyForTeamWithID(context.Background(), testTeam.ID.Hex(), "secret")
+	assert.Equal(t, services.ErrInvalidTeamPrivacy, err)
+	assert.Nil(t, team)
+}
+
+func Test_SetPrivacyForTeamWithID__should_return_ErrNotFound_when_team_with_id_doesnt_exist(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	team, err := setup.tService.SetPrivacyForTeamWithID(context.Background(), testTeam.ID.Hex(), entities.TeamClosed)
+	assert.Equal(t, services.ErrNotFound, err)
+	assert.Nil(t, team)
+}
+
+func Test_SetPrivacyForTeamWithID__should_update_team_privacy(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+
+	team, err := setup.tService.SetPrivacyForTeamWithID(context.Background(), testTeam.ID.Hex(), entities.TeamClosed)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.TeamClosed, team.Privacy)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, entities.TeamClosed, storedTeam.Privacy)
+}
+
+func Test_SetMemberLimitForTeamWithID__should_return_ErrInvalidMemberLimit_when_limit_is_negative(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	team, err := setup.tService.SetMemberLimitForTeamWithID(context.Background(), testTeam.ID.Hex(), -1)
+	assert.Equal(t, services.ErrInvalidMemberLimit, err)
+	assert.Nil(t, team)
+}
+
+func Test_SetMemberLimitForTeamWithID__should_return_ErrNotFound_when_team_with_id_doesnt_exist(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	team, err := setup.tService.SetMemberLimitForTeamWithID(context.Background(), testTeam.ID.Hex(), 8)
+	assert.Equal(t, services.ErrNotFound, err)
+	assert.Nil(t, team)
+}
+
+func Test_SetMemberLimitForTeamWithID__should_set_and_remove_team_member_limit(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+
+	team, err := setup.tService.SetMemberLimitForTeamWithID(context.Background(), testTeam.ID.Hex(), 8)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, team.MaxMembers)
+
+	team, err = setup.tService.SetMemberLimitForTeamWithID(context.Background(), testTeam.ID.Hex(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, team.MaxMembers)
+
+	count, err := setup.tRepo.CountDocuments(context.Background(), bson.M{
+		string(entities.TeamMaxMembers): bson.M{"$exists": true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func Test_RegenerateJoinCodeForTeamWithID__should_return_ErrNotFound_when_team_with_id_doesnt_exist(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	team, err := setup.tService.RegenerateJoinCodeForTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+	assert.Nil(t, team)
+}
+
+func Test_RegenerateJoinCodeForTeamWithID__should_replace_join_code(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+	setup.tService.cfg.Teams.JoinCodeLifetime = 3600
+
+	testTeamWithCode := testTeam
+	testTeamWithCode.JoinCode = "ABCD2345"
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeamWithCode)
+	assert.NoError(t, err)
+
+	team, err := setup.tService.RegenerateJoinCodeForTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.NotEqual(t, "ABCD2345", team.JoinCode)
+	assert.Len(t, team.JoinCode, teamJoinCodeLength)
+	assert.Equal(t, testTeamTime.Add(time.Hour), team.JoinCodeExpiresAt.UTC())
+
+	count, err := setup.tRepo.CountDocuments(context.Background(), bson.M{
+		string(entities.TeamJoinCode): "ABCD2345",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func Test_normaliseJoinCode(t *testing.T) {
+	assert.Equal(t, "ABCD2345", normaliseJoinCode(" abcd-2345 "))
+	assert.Equal(t, "ABCD2345", normaliseJoinCode("ABCD 2345"))
+}
+
+func Test_RenameTeamWithID__should_return_ErrNameTaken_when_name_is_taken_by_another_team(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeam2 := testTeam
+	testTeam2.ID = primitive.NewObjectID()
+	testTeam2.Name = "Team of Alices"
+
+	_, err := setup.tRepo.InsertMany(context.Background(), []interface{}{testTeam, testTeam2})
+	assert.NoError(t, err)
+
+	team, err := setup.tService.RenameTeamWithID(context.Background(), testTeam2.ID.Hex(), testTeam.Name)
+	assert.Equal(t, services.ErrNameTaken, err)
+	assert.Nil(t, team)
+}
+
+func Test_RenameTeamWithID__should_return_ErrNotFound_when_team_with_id_doesnt_exist(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	team, err := setup.tService.RenameTeamWithID(context.Background(), testTeam.ID.Hex(), "Team of Alices")
+	assert.Equal(t, services.ErrNotFound, err)
+	assert.Nil(t, team)
+}
+
+func Test_RenameTeamWithID__should_rename_team(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+
+	renamedTeam := testTeam
+	renamedTeam.Name = "Team of Alices"
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamUpdated, webhookTeamEventData{
+		Team: renamedTeam,
+	}).Return(nil).Times(1)
+
+	team, err := setup.tService.RenameTeamWithID(context.Background(), testTeam.ID.Hex(), "Team of Alices")
+	assert.NoError(t, err)
+	assert.Equal(t, renamedTeam, *team)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, "Team of Alices", storedTeam.Name)
+}
+
+func Test_UpdateTeamWithID__should_return_ErrNotFound_when_team_with_id_doesnt_exist(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	team, err := setup.tService.UpdateTeamWithID(context.Background(), testTeam.ID.Hex(), services.TeamUpdateParams{
+		entities.TeamDescription: "We build robots",
+	})
+	assert.Equal(t, services.ErrNotFound, err)
+	assert.Nil(t, team)
+}
+
+func Test_UpdateTeamWithID__should_update_team_details(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+
+	updatedTeam := testTeam
+	updatedTeam.Description = "We build robots"
+	updatedTeam.RepositoryURL = "https://github.com/bobs/robots"
+	updatedTeam.Track = "hardware"
+	updatedTeam.TableNumber = 12
+	updatedTeam.Tags = []string{"robots", "hardware"}
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamUpdated, webhookTeamEventData{
+		Team: updatedTeam,
+	}).Return(nil).Times(1)
+
+	team, err := setup.tService.UpdateTeamWithID(context.Background(), testTeam.ID.Hex(), services.TeamUpdateParams{
+		entities.TeamDescription:   updatedTeam.Description,
+		entities.TeamRepositoryURL: updatedTeam.RepositoryURL,
+		entities.TeamTrack:         updatedTeam.Track,
+		entities.TeamTableNumber:   updatedTeam.TableNumber,
+		entities.TeamTags:          updatedTeam.Tags,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, updatedTeam, *team)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, updatedTeam, *storedTeam)
+}
+
+func Test_SetCreatorForTeamWithID__should_return_ErrUserNotInTeam_when_user_is_not_a_team_member(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), testUser.ID.Hex()).
+		Return(&entities.User{ID: testUser.ID, Team: primitive.NewObjectID()}, nil).Times(1)
+
+	team, err := setup.tService.SetCreatorForTeamWithID(context.Background(), testTeam.ID.Hex(), testUser.ID.Hex())
+	assert.Equal(t, services.ErrUserNotInTeam, err)
+	assert.Nil(t, team)
+}
+
+func Test_SetCreatorForTeamWithID__should_set_team_creator(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+
+	updatedTeam := testTeam
+	updatedTeam.Creator = testUser.ID
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), testUser.ID.Hex()).
+		Return(&entities.User{ID: testUser.ID, Team: testTeam.ID}, nil).Times(1)
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamUpdated, webhookTeamEventData{
+		Team: updatedTeam,
+	}).Return(nil).Times(1)
+
+	team, err := setup.tService.SetCreatorForTeamWithID(context.Background(), testTeam.ID.Hex(), testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, testUser.ID, team.Creator)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, testUser.ID, storedTeam.Creator)
+}
+
+func Test_RemoveUserWithIDFromTeamWithID__should_return_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    entities.User
+		wantErr error
+	}{
+		{
+			name:    "ErrUserNotInTeam when user is not a team member",
+			user:    entities.User{ID: testUser.ID, Team: primitive.NewObjectID()},
+			wantErr: services.ErrUserNotInTeam,
+		},
+		{
+			name:    "ErrUserIsTeamCreator when user is the team's creator",
+			user:    entities.User{ID: testTeam.Creator, Team: testTeam.ID},
+			wantErr: services.ErrUserIsTeamCreator,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTeamTest(t)
+			defer setup.cleanup()
+
+			_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+			assert.NoError(t, err)
+
+			setup.mockUService.EXPECT().GetUserWithID(context.Background(), tt.user.ID.Hex()).
+				Return(&tt.user, nil).Times(1)
+
+			err = setup.tService.RemoveUserWithIDFromTeamWithID(context.Background(), testTeam.ID.Hex(), tt.user.ID.Hex())
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func Test_RemoveUserWithIDFromTeamWithID__should_remove_user_from_team(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	testTeam2 := testTeam
+	testTeam2.MemberCount = 2
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam2)
+	assert.NoError(t, err)
+
+	member := entities.User{ID: testUser.ID, Team: testTeam2.ID}
+	setup.mockUService.EXPECT().GetUserWithID(context.Background(), member.ID.Hex()).
+		Return(&member, nil).Times(2)
+	setup.mockUService.EXPECT().UpdateUserWithID(context.Background(), member.ID.Hex(), services.UserUpdateParams{
+		entities.UserTeam: primitive.NilObjectID,
+	}).Return(nil).Times(1)
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberLeft, webhookTeamEventData{
+		Team:   testTeam2,
+		UserID: member.ID,
+	}).Return(nil).Times(1)
+	setup.mockUService.EXPECT().GetUsersWithTeam(context.Background(), testTeam2.ID.Hex()).
+		Return([]entities.User{{ID: testTeam2.Creator}}, nil).Times(1)
+
+	err = setup.tService.RemoveUserWithIDFromTeamWithID(context.Background(), testTeam2.ID.Hex(), member.ID.Hex())
+	assert.NoError(t, err)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam2.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, storedTeam.MemberCount)
+	assert.Equal(t, testTeam2.Creator, storedTeam.Creator)
+}
+
+func Test_DisbandTeamWithID__should_return_ErrNotFound_when_team_with_id_doesnt_exist(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	err := setup.tService.DisbandTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_DisbandTeamWithID__should_remove_members_and_delete_team(t *testing.T) {
+	setup := setupTeamTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.tRepo.InsertOne(context.Background(), testTeam)
+	assert.NoError(t, err)
+	setup.insertJoinRequestAndAdvert(t, testTeam.ID)
+
+	members := []entities.User{{ID: testTeam.Creator, Team: testTeam.ID}, {ID: testUser.ID, Team: testTeam.ID}}
+	setup.mockUService.EXPECT().GetUsersWithTeam(context.Background(), testTeam.ID.Hex()).
+		Return(members, nil).Times(1)
+	setup.mockUService.EXPECT().UpdateUsersWithTeam(context.Background(), testTeam.ID.Hex(), services.UserUpdateParams{
+		entities.UserTeam: primitive.NilObjectID,
+	}).Return(nil).Times(1)
+	for _, member := range members {
+		setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberLeft, webhookTeamEventData{
+			Team:   testTeam,
+			UserID: member.ID,
+		}).Return(nil).Times(1)
+	}
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamDeleted, webhookTeamEventData{
+		Team: testTeam,
+	}).Return(nil).Times(1)
+
+	err = setup.tService.DisbandTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.NoError(t, err)
+
+	_, err = setup.tService.GetTeamWithID(context.Background(), testTeam.ID.Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+	setup.assertJoinRequestsAndAdverts(t, testTeam.ID, 0)
+}
+
 func Test_RemoveUserWithIDFromTheirTeam__should_remove_correct_user_from_team(t *testing.T) {
 	setup := setupTeamTest(t)
 	defer setup.cleanup()
 
 	testTeam2 := testTeam
 	testTeam2.ID = primitive.NewObjectID()
+	testTeam2.MemberCount = 2
 
 	testUser2 := testUser
 	testUser2.Team = testTeam2.ID
@@ -384,11 +1240,19 @@ func Test_RemoveUserWithIDFromTheirTeam__should_remove_correct_user_from_team(t
 	setup.mockUService.EXPECT().UpdateUserWithID(context.Background(), "testid", services.UserUpdateParams{
 		entities.UserTeam: primitive.NilObjectID,
 	})
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberLeft, webhookTeamEventData{
+		Team:   testTeam2,
+		UserID: testUser2.ID,
+	}).Return(nil).Times(1)
 	setup.mockUService.EXPECT().GetUsersWithTeam(context.Background(), testTeam2.ID.Hex()).
 		Return([]entities.User{{}}, nil).Times(1)
 
 	err = setup.tService.RemoveUserWithIDFromTheirTeam(context.Background(), "testid")
 	assert.NoError(t, err)
+
+	storedTeam, err := setup.tService.GetTeamWithID(context.Background(), testTeam2.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, storedTeam.MemberCount)
 }
 
 func Test_RemoveUserWithIDFromTheirTeam__should_remove_correct_user_from_team_and_delete_empty_team(t *testing.T) {
@@ -404,12 +1268,17 @@ func Test_RemoveUserWithIDFromTheirTeam__should_remove_correct_user_from_team_an
 
 	_, err := setup.tRepo.InsertMany(context.Background(), []interface{}{testTeam2})
 	assert.NoError(t, err)
+	setup.insertJoinRequestAndAdvert(t, testTeam2.ID)
 
 	setup.mockUService.EXPECT().GetUserWithID(context.Background(), "testid").
 		Return(&testUser2, nil).Times(1)
 	setup.mockUService.EXPECT().UpdateUserWithID(context.Background(), "testid", services.UserUpdateParams{
 		entities.UserTeam: primitive.NilObjectID,
 	})
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberLeft, webhookTeamEventData{
+		Team:   testTeam2,
+		UserID: testUser2.ID,
+	}).Return(nil).Times(1)
 	setup.mockUService.EXPECT().GetUsersWithTeam(context.Background(), testTeam2.ID.Hex()).Return(nil, nil).Times(1)
 
 	err = setup.tService.RemoveUserWithIDFromTheirTeam(context.Background(), "testid")
@@ -422,6 +1291,7 @@ func Test_RemoveUserWithIDFromTheirTeam__should_remove_correct_user_from_team_an
 	assert.NoError(t, err)
 
 	assert.Nil(t, teams)
+	setup.assertJoinRequestsAndAdverts(t, testTeam2.ID, 0)
 }
 
 func Test_RemoveUserWithIDFromTheirTeam__should_remove_correct_user_from_team_and_assign_new_creator_for_team(t *testing.T) {
@@ -443,6 +1313,10 @@ func Test_RemoveUserWithIDFromTheirTeam__should_remove_correct_user_from_team_an
 	setup.mockUService.EXPECT().UpdateUserWithID(context.Background(), "testid", services.UserUpdateParams{
 		entities.UserTeam: primitive.NilObjectID,
 	})
+	setup.mockWService.EXPECT().EmitEvent(context.Background(), entities.WebhookEventTeamMemberLeft, webhookTeamEventData{
+		Team:   testTeam2,
+		UserID: testUser2.ID,
+	}).Return(nil).Times(1)
 	setup.mockUService.EXPECT().GetUsersWithTeam(context.Background(), testTeam2.ID.Hex()).
 		Return([]entities.User{{ID: testUser.ID}}, nil).Times(1)
 
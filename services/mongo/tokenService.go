@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 
+	"github.com/pkg/errors"
 	"github.com/unicsmcr/hs_auth/entities"
 	"github.com/unicsmcr/hs_auth/environment"
 	"github.com/unicsmcr/hs_auth/repositories"
@@ -74,3 +75,46 @@ func (s *mongoTokenService) DeleteServiceToken(ctx context.Context, id string) e
 
 	return nil
 }
+
+func (s *mongoTokenService) GetServiceTokensWithCreator(ctx context.Context, creatorID string) ([]entities.ServiceToken, error) {
+	creatorMongoID, err := primitive.ObjectIDFromHex(creatorID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	cur, err := s.tokenRepository.Find(ctx, bson.M{
+		string(entities.ServiceTokenCreator): creatorMongoID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for service tokens")
+	}
+	defer cur.Close(ctx)
+
+	tokens := []entities.ServiceToken{}
+	for cur.Next(ctx) {
+		var token entities.ServiceToken
+		err = cur.Decode(&token)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode service token")
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+func (s *mongoTokenService) DeleteServiceTokensWithCreator(ctx context.Context, creatorID string) error {
+	creatorMongoID, err := primitive.ObjectIDFromHex(creatorID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	_, err = s.tokenRepository.DeleteMany(ctx, bson.M{
+		string(entities.ServiceTokenCreator): creatorMongoID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete service tokens")
+	}
+
+	return nil
+}
@@ -86,6 +86,19 @@ func Test_Token_ErrInvalidID_should_be_returned_when_provided_id_is_invalid(t *t
 				return err
 			},
 		},
+		{
+			name: "GetServiceTokensWithCreator",
+			testFunction: func(id string) error {
+				_, err := setup.tService.GetServiceTokensWithCreator(context.Background(), id)
+				return err
+			},
+		},
+		{
+			name: "DeleteServiceTokensWithCreator",
+			testFunction: func(id string) error {
+				return setup.tService.DeleteServiceTokensWithCreator(context.Background(), id)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -139,3 +152,44 @@ func Test_DeleteServiceToken__should_return_nil_error_when_deleted_token(t *test
 
 	assert.Error(t, services.ErrNotFound, err)
 }
+
+func Test_GetServiceTokensWithCreator__should_return_expected_tokens(t *testing.T) {
+	setup := setupTokenTest(t)
+	defer setup.cleanup()
+
+	testToken2 := testToken
+	testToken2.ID = primitive.NewObjectID()
+	testToken3 := testToken
+	testToken3.ID = primitive.NewObjectID()
+	testToken3.Creator = primitive.NewObjectID()
+	_, err := setup.tRepo.InsertMany(context.Background(), []interface{}{testToken, testToken2, testToken3})
+	assert.NoError(t, err)
+
+	tokens, err := setup.tService.GetServiceTokensWithCreator(context.Background(), testToken.Creator.Hex())
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []entities.ServiceToken{testToken, testToken2}, tokens)
+}
+
+func Test_DeleteServiceTokensWithCreator__should_delete_expected_tokens(t *testing.T) {
+	setup := setupTokenTest(t)
+	defer setup.cleanup()
+
+	testToken2 := testToken
+	testToken2.ID = primitive.NewObjectID()
+	testToken3 := testToken
+	testToken3.ID = primitive.NewObjectID()
+	testToken3.Creator = primitive.NewObjectID()
+	_, err := setup.tRepo.InsertMany(context.Background(), []interface{}{testToken, testToken2, testToken3})
+	assert.NoError(t, err)
+
+	err = setup.tService.DeleteServiceTokensWithCreator(context.Background(), testToken.Creator.Hex())
+	assert.NoError(t, err)
+
+	tokens, err := setup.tService.GetServiceTokensWithCreator(context.Background(), testToken.Creator.Hex())
+	assert.NoError(t, err)
+	assert.Empty(t, tokens)
+	tokens, err = setup.tService.GetServiceTokensWithCreator(context.Background(), testToken3.Creator.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.ServiceToken{testToken3}, tokens)
+}
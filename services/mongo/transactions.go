@@ -0,0 +1,107 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+	"go.uber.org/zap"
+)
+
+// transactions are retried a few times when they conflict with concurrent writes or the primary changes
+const maxTransactionAttempts = 5
+
+// transactionRunner runs functions in multi-document transactions. MongoDB only supports transactions on
+// replica sets and sharded clusters, so on standalone servers the functions run without a transaction.
+// The zero value is ready to use
+type transactionRunner struct {
+	mutex     sync.Mutex
+	checked   bool
+	supported bool
+}
+
+// run calls fn in a transaction on db's client, retrying it when the transaction fails with a transient error.
+// fn may be called more than once, so it should not have side effects outside of the database,
+// e.g. webhook events should be emitted once run returns
+func (t *transactionRunner) run(ctx context.Context, logger *zap.Logger, db *mongo.Database, fn func(ctx context.Context) error) error {
+	supported, err := t.transactionsSupported(ctx, logger, db)
+	if err != nil {
+		return err
+	} else if !supported {
+		return fn(ctx)
+	}
+
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return errors.Wrap(err, "could not start session")
+	}
+	defer session.EndSession(ctx)
+
+	return mongo.WithSession(ctx, session, func(sessCtx mongo.SessionContext) error {
+		for attempt := 1; ; attempt++ {
+			err := runTransaction(sessCtx, fn)
+			if err == nil || attempt == maxTransactionAttempts || !hasErrorLabel(err, driver.TransientTransactionError) {
+				return err
+			}
+			logger.Debug("retrying transaction after transient error", zap.Int("attempt", attempt), zap.Error(err))
+		}
+	})
+}
+
+// runTransaction makes a single attempt at running fn in a transaction
+func runTransaction(sessCtx mongo.SessionContext, fn func(ctx context.Context) error) error {
+	err := sessCtx.StartTransaction()
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+
+	err = fn(sessCtx)
+	if err != nil {
+		// the server aborts transactions which are not committed eventually, so a failed abort is not reported
+		_ = sessCtx.AbortTransaction(sessCtx)
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = sessCtx.CommitTransaction(sessCtx)
+		// the commit is safe to retry when it is not known whether it succeeded
+		if err == nil || attempt == maxTransactionAttempts || !hasErrorLabel(err, driver.UnknownTransactionCommitResult) {
+			return err
+		}
+	}
+}
+
+// transactionsSupported checks whether db is served by a replica set or a sharded cluster
+func (t *transactionRunner) transactionsSupported(ctx context.Context, logger *zap.Logger, db *mongo.Database) (bool, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.checked {
+		return t.supported, nil
+	}
+
+	var reply struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := db.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&reply)
+	if err != nil {
+		return false, errors.Wrap(err, "could not check whether database supports transactions")
+	}
+
+	t.checked = true
+	t.supported = len(reply.SetName) > 0 || reply.Msg == "isdbgrid"
+	if !t.supported {
+		logger.Warn("database is a standalone server, changes spanning several documents will not run in transactions")
+	}
+
+	return t.supported, nil
+}
+
+func hasErrorLabel(err error, label string) bool {
+	cmdErr, ok := errors.Cause(err).(mongo.CommandError)
+	return ok && cmdErr.HasErrorLabel(label)
+}
@@ -0,0 +1,39 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+	"go.uber.org/zap"
+)
+
+type testContextKey struct{}
+
+func Test_transactionRunner_run__should_call_function_without_transaction_when_transactions_are_not_supported(t *testing.T) {
+	runner := &transactionRunner{checked: true}
+	ctx := context.WithValue(context.Background(), testContextKey{}, "value")
+	testErr := errors.New("function err")
+
+	calls := 0
+	err := runner.run(ctx, zap.NewNop(), nil, func(fnCtx context.Context) error {
+		calls++
+		assert.Equal(t, ctx, fnCtx)
+		return testErr
+	})
+
+	assert.Equal(t, testErr, err)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_hasErrorLabel(t *testing.T) {
+	transientErr := mongo.CommandError{Labels: []string{driver.TransientTransactionError}}
+
+	assert.True(t, hasErrorLabel(transientErr, driver.TransientTransactionError))
+	assert.True(t, hasErrorLabel(errors.Wrap(transientErr, "could not update team"), driver.TransientTransactionError))
+	assert.False(t, hasErrorLabel(transientErr, driver.UnknownTransactionCommitResult))
+	assert.False(t, hasErrorLabel(errors.New("service err"), driver.TransientTransactionError))
+}
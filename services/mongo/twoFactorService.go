@@ -0,0 +1,266 @@
+package mongo
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	noOfRecoveryCodes     = 10
+	recoveryCodeBytes     = 10
+	recoveryCodeSeparator = "-"
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type mongoTwoFactorService struct {
+	logger         *zap.Logger
+	cfg            *config.AppConfig
+	timeProvider   utils.TimeProvider
+	userRepository *repositories.UserRepository
+	userService    services.UserService
+}
+
+// NewMongoTwoFactorService creates a new TwoFactorService that stores the users' two-factor secrets in MongoDB
+func NewMongoTwoFactorService(logger *zap.Logger, cfg *config.AppConfig, timeProvider utils.TimeProvider,
+	userRepository *repositories.UserRepository, userService services.UserService) services.TwoFactorService {
+	return &mongoTwoFactorService{
+		logger:         logger,
+		cfg:            cfg,
+		timeProvider:   timeProvider,
+		userRepository: userRepository,
+		userService:    userService,
+	}
+}
+
+func (s *mongoTwoFactorService) BeginEnrollment(ctx context.Context, userID string) (string, string, error) {
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user.TwoFactorEnabled {
+		return "", "", services.ErrTwoFactorEnabled
+	}
+
+	// the user may have already added the pending secret to their authenticator app
+	if len(user.TwoFactorSecret) > 0 {
+		return user.TwoFactorSecret, utils.MakeTOTPURI(s.cfg.Name, user.Email, user.TwoFactorSecret), nil
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", errors.Wrap(err, "could not generate two-factor secret")
+	}
+
+	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+		string(entities.UserID):               user.ID,
+		string(entities.UserTwoFactorEnabled): bson.M{"$ne": true},
+		string(entities.UserTwoFactorSecret):  bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.UserTwoFactorSecret): secret,
+		},
+	})
+	if err != nil {
+		return "", "", errors.Wrap(err, "could not store two-factor secret")
+	}
+
+	if res.MatchedCount == 0 {
+		// another enrollment was started or completed in the meantime
+		return s.BeginEnrollment(ctx, userID)
+	}
+
+	return secret, utils.MakeTOTPURI(s.cfg.Name, user.Email, secret), nil
+}
+
+func (s *mongoTwoFactorService) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, services.ErrTwoFactorEnabled
+	}
+
+	if len(user.TwoFactorSecret) == 0 {
+		return nil, services.ErrTwoFactorNotEnrolling
+	}
+
+	step, ok := utils.ValidateTOTPCode(user.TwoFactorSecret, code, s.timeProvider.Now())
+	if !ok {
+		return nil, services.ErrInvalidTwoFactorCode
+	}
+
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate recovery codes")
+	}
+
+	// the secret is part of the filter, so that a secret replaced in the meantime is not enabled
+	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+		string(entities.UserID):               user.ID,
+		string(entities.UserTwoFactorSecret):  user.TwoFactorSecret,
+		string(entities.UserTwoFactorEnabled): bson.M{"$ne": true},
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.UserTwoFactorEnabled):       true,
+			string(entities.UserTwoFactorRecoveryCodes): hashedRecoveryCodes,
+			string(entities.UserTwoFactorLastUsedStep):  step,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not enable two-factor authentication")
+	}
+
+	if res.MatchedCount == 0 {
+		return nil, services.ErrTwoFactorNotEnrolling
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s *mongoTwoFactorService) VerifyCode(ctx context.Context, userID, code string) error {
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactorEnabled {
+		return services.ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := utils.ValidateTOTPCode(user.TwoFactorSecret, code, s.timeProvider.Now()); ok {
+		return s.useTOTPStep(ctx, user.ID, step)
+	}
+
+	return s.useRecoveryCode(ctx, user.ID, code)
+}
+
+func (s *mongoTwoFactorService) Disable(ctx context.Context, userID string) error {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+		string(entities.UserID): mongoID,
+	}, bson.M{
+		"$unset": bson.M{
+			string(entities.UserTwoFactorEnabled):       "",
+			string(entities.UserTwoFactorSecret):        "",
+			string(entities.UserTwoFactorRecoveryCodes): "",
+			string(entities.UserTwoFactorLastUsedStep):  "",
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not disable two-factor authentication")
+	}
+
+	if res.MatchedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *mongoTwoFactorService) IsRequiredForRole(userRole role.UserRole) bool {
+	for _, requiredRole := range s.cfg.Auth.TwoFactorRequiredRoles {
+		if requiredRole == userRole {
+			return true
+		}
+	}
+
+	return false
+}
+
+// useTOTPStep records the time step of an accepted TOTP code. Fails with ErrInvalidTwoFactorCode
+// if a code from the same or a later time step has already been used.
+func (s *mongoTwoFactorService) useTOTPStep(ctx context.Context, userID primitive.ObjectID, step int64) error {
+	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+		string(entities.UserID):                    userID,
+		string(entities.UserTwoFactorLastUsedStep): bson.M{"$lt": step},
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.UserTwoFactorLastUsedStep): step,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not record used two-factor code")
+	}
+
+	if res.ModifiedCount == 0 {
+		return services.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// useRecoveryCode removes the given recovery code from the user's unused recovery codes.
+// Fails with ErrInvalidTwoFactorCode if the code is not one of them.
+func (s *mongoTwoFactorService) useRecoveryCode(ctx context.Context, userID primitive.ObjectID, code string) error {
+	hashedCode := hashRecoveryCode(code)
+
+	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+		string(entities.UserID):                     userID,
+		string(entities.UserTwoFactorRecoveryCodes): hashedCode,
+	}, bson.M{
+		"$pull": bson.M{
+			string(entities.UserTwoFactorRecoveryCodes): hashedCode,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not use recovery code")
+	}
+
+	if res.ModifiedCount == 0 {
+		return services.ErrInvalidTwoFactorCode
+	}
+
+	s.logger.Info("recovery code used", zap.String("user id", userID.Hex()))
+	return nil
+}
+
+// generateRecoveryCodes returns new recovery codes along with their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, noOfRecoveryCodes)
+	hashedCodes := make([]string, noOfRecoveryCodes)
+	for i := range codes {
+		bytes := make([]byte, recoveryCodeBytes)
+		_, err := rand.Read(bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(bytes))
+		codes[i] = encoded[:len(encoded)/2] + recoveryCodeSeparator + encoded[len(encoded)/2:]
+		hashedCodes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashedCodes, nil
+}
+
+// hashRecoveryCode hashes the given recovery code, ignoring its case and separator.
+// Recovery codes are random, so unlike passwords they do not need a slow hash.
+func hashRecoveryCode(code string) string {
+	normalised := strings.ToLower(strings.Replace(code, recoveryCodeSeparator, "", -1))
+	hash := sha256.Sum256([]byte(normalised))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,285 @@
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/config/role"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var testTwoFactorTime = time.Unix(1234567890, 0)
+
+type twoFactorTestSetup struct {
+	ctrl             *gomock.Controller
+	tfService        *mongoTwoFactorService
+	uRepo            *repositories.UserRepository
+	mockTimeProvider *mock_utils.MockTimeProvider
+	testUser         entities.User
+	cleanup          func()
+}
+
+func setupTwoFactorTest(t *testing.T) *twoFactorTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	uRepo, err := repositories.NewUserRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTimeProvider.EXPECT().Now().Return(testTwoFactorTime).AnyTimes()
+
+	cfg := &config.AppConfig{
+		Name: "HS Auth",
+		Auth: config.AuthConfig{
+			TwoFactorRequiredRoles: []role.UserRole{role.Organiser},
+		},
+	}
+
+	tfService := &mongoTwoFactorService{
+		logger:         zap.NewNop(),
+		cfg:            cfg,
+		timeProvider:   mockTimeProvider,
+		userRepository: uRepo,
+		userService: &mongoUserService{
+			logger:         zap.NewNop(),
+			cfg:            cfg,
+			userRepository: uRepo,
+		},
+	}
+
+	user := entities.User{
+		ID:    primitive.NewObjectID(),
+		Name:  "Bob the Tester",
+		Email: "bob@email.com",
+	}
+	_, err = uRepo.InsertOne(context.Background(), user)
+	if err != nil {
+		panic(err)
+	}
+
+	return &twoFactorTestSetup{
+		ctrl:             ctrl,
+		tfService:        tfService,
+		uRepo:            uRepo,
+		mockTimeProvider: mockTimeProvider,
+		testUser:         user,
+		cleanup: func() {
+			ctrl.Finish()
+			uRepo.Drop(context.Background())
+		},
+	}
+}
+
+func (setup *twoFactorTestSetup) getUser(t *testing.T) entities.User {
+	var user entities.User
+	err := setup.uRepo.FindOne(context.Background(), bson.M{
+		string(entities.UserID): setup.testUser.ID,
+	}).Decode(&user)
+	assert.NoError(t, err)
+	return user
+}
+
+// enroll enables two-factor authentication for the test user and returns the secret and recovery codes
+func (setup *twoFactorTestSetup) enroll(t *testing.T) (string, []string) {
+	secret, _, err := setup.tfService.BeginEnrollment(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+
+	code, err := utils.GetTOTPCode(secret, utils.GetTOTPStep(testTwoFactorTime)-1)
+	assert.NoError(t, err)
+
+	recoveryCodes, err := setup.tfService.ConfirmEnrollment(context.Background(), setup.testUser.ID.Hex(), code)
+	assert.NoError(t, err)
+
+	return secret, recoveryCodes
+}
+
+func Test_NewMongoTwoFactorService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoTwoFactorService(nil, nil, nil, nil, nil))
+}
+
+func Test_BeginEnrollment__should_store_pending_secret(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+
+	secret, uri, err := setup.tfService.BeginEnrollment(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, utils.MakeTOTPURI("HS Auth", setup.testUser.Email, secret), uri)
+
+	user := setup.getUser(t)
+	assert.Equal(t, secret, user.TwoFactorSecret)
+	assert.False(t, user.TwoFactorEnabled)
+}
+
+func Test_BeginEnrollment__should_return_pending_secret_when_enrollment_was_started(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+
+	secret, _, err := setup.tfService.BeginEnrollment(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+
+	secret2, _, err := setup.tfService.BeginEnrollment(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, secret, secret2)
+}
+
+func Test_BeginEnrollment__should_return_ErrTwoFactorEnabled_when_already_enabled(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+	setup.enroll(t)
+
+	_, _, err := setup.tfService.BeginEnrollment(context.Background(), setup.testUser.ID.Hex())
+	assert.Equal(t, services.ErrTwoFactorEnabled, err)
+}
+
+func Test_ConfirmEnrollment__should_enable_two_factor_and_store_hashed_recovery_codes(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+
+	_, recoveryCodes := setup.enroll(t)
+	assert.Len(t, recoveryCodes, noOfRecoveryCodes)
+
+	user := setup.getUser(t)
+	assert.True(t, user.TwoFactorEnabled)
+	assert.Len(t, user.TwoFactorRecoveryCodes, noOfRecoveryCodes)
+	for i, code := range recoveryCodes {
+		assert.NotEqual(t, code, user.TwoFactorRecoveryCodes[i])
+		assert.Equal(t, hashRecoveryCode(code), user.TwoFactorRecoveryCodes[i])
+	}
+}
+
+func Test_ConfirmEnrollment__should_return_error(t *testing.T) {
+	tests := []struct {
+		name        string
+		prep        func(t *testing.T, setup *twoFactorTestSetup) string
+		expectedErr error
+	}{
+		{
+			name: "ErrTwoFactorNotEnrolling when enrollment was not started",
+			prep: func(t *testing.T, setup *twoFactorTestSetup) string {
+				return "123456"
+			},
+			expectedErr: services.ErrTwoFactorNotEnrolling,
+		},
+		{
+			name: "ErrInvalidTwoFactorCode when code is invalid",
+			prep: func(t *testing.T, setup *twoFactorTestSetup) string {
+				secret, _, err := setup.tfService.BeginEnrollment(context.Background(), setup.testUser.ID.Hex())
+				assert.NoError(t, err)
+				code, err := utils.GetTOTPCode(secret, utils.GetTOTPStep(testTwoFactorTime)-5)
+				assert.NoError(t, err)
+				return code
+			},
+			expectedErr: services.ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "ErrTwoFactorEnabled when already enabled",
+			prep: func(t *testing.T, setup *twoFactorTestSetup) string {
+				setup.enroll(t)
+				return "123456"
+			},
+			expectedErr: services.ErrTwoFactorEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTwoFactorTest(t)
+			defer setup.cleanup()
+
+			code := tt.prep(t, setup)
+
+			_, err := setup.tfService.ConfirmEnrollment(context.Background(), setup.testUser.ID.Hex(), code)
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func Test_VerifyCode__should_accept_totp_code_only_once(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+	secret, _ := setup.enroll(t)
+
+	code, err := utils.GetTOTPCode(secret, utils.GetTOTPStep(testTwoFactorTime))
+	assert.NoError(t, err)
+
+	assert.NoError(t, setup.tfService.VerifyCode(context.Background(), setup.testUser.ID.Hex(), code))
+	assert.Equal(t, services.ErrInvalidTwoFactorCode, setup.tfService.VerifyCode(context.Background(), setup.testUser.ID.Hex(), code))
+}
+
+func Test_VerifyCode__should_reject_code_used_during_enrollment(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+	secret, _ := setup.enroll(t)
+
+	code, err := utils.GetTOTPCode(secret, utils.GetTOTPStep(testTwoFactorTime)-1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, services.ErrInvalidTwoFactorCode, setup.tfService.VerifyCode(context.Background(), setup.testUser.ID.Hex(), code))
+}
+
+func Test_VerifyCode__should_accept_recovery_code_only_once(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+	_, recoveryCodes := setup.enroll(t)
+
+	assert.NoError(t, setup.tfService.VerifyCode(context.Background(), setup.testUser.ID.Hex(), recoveryCodes[0]))
+	assert.Equal(t, services.ErrInvalidTwoFactorCode, setup.tfService.VerifyCode(context.Background(), setup.testUser.ID.Hex(), recoveryCodes[0]))
+
+	user := setup.getUser(t)
+	assert.Len(t, user.TwoFactorRecoveryCodes, noOfRecoveryCodes-1)
+}
+
+func Test_VerifyCode__should_return_ErrTwoFactorNotEnabled_when_not_enabled(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+
+	err := setup.tfService.VerifyCode(context.Background(), setup.testUser.ID.Hex(), "123456")
+	assert.Equal(t, services.ErrTwoFactorNotEnabled, err)
+}
+
+func Test_Disable__should_remove_two_factor_fields(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+	setup.enroll(t)
+
+	err := setup.tfService.Disable(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+
+	user := setup.getUser(t)
+	assert.False(t, user.TwoFactorEnabled)
+	assert.Empty(t, user.TwoFactorSecret)
+	assert.Empty(t, user.TwoFactorRecoveryCodes)
+}
+
+func Test_Disable__should_return_error(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+
+	assert.Equal(t, services.ErrInvalidID, setup.tfService.Disable(context.Background(), "invalid"))
+	assert.Equal(t, services.ErrNotFound, setup.tfService.Disable(context.Background(), primitive.NewObjectID().Hex()))
+}
+
+func Test_IsRequiredForRole__should_return_whether_role_is_configured(t *testing.T) {
+	setup := setupTwoFactorTest(t)
+	defer setup.cleanup()
+
+	assert.True(t, setup.tfService.IsRequiredForRole(role.Organiser))
+	assert.False(t, setup.tfService.IsRequiredForRole(role.Applicant))
+}
@@ -2,8 +2,10 @@ package mongo
 
 import (
 	"context"
+	"crypto/subtle"
 	"github.com/unicsmcr/hs_auth/config/role"
 	"github.com/unicsmcr/hs_auth/utils"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -15,35 +17,55 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
+// the nonce bound to the links confirming a pending email change
+const pendingEmailNonceBytes = 16
+
 type mongoUserService struct {
 	logger         *zap.Logger
 	env            *environment.Env
 	cfg            *config.AppConfig
 	userRepository *repositories.UserRepository
+	webhookService services.WebhookService
+	passwordPolicy services.PasswordPolicy
+}
+
+// webhookUserEventData is the data sent to webhooks with user events
+type webhookUserEventData struct {
+	User         entities.User `json:"user"`
+	PreviousRole role.UserRole `json:"previous_role,omitempty"`
 }
 
 // NewMongoUserService creates a new UserService that uses MongoDB as the storage technology
-func NewMongoUserService(logger *zap.Logger, env *environment.Env, cfg *config.AppConfig, userRepository *repositories.UserRepository) services.UserService {
+func NewMongoUserService(logger *zap.Logger, env *environment.Env, cfg *config.AppConfig, userRepository *repositories.UserRepository,
+	webhookService services.WebhookService, passwordPolicy services.PasswordPolicy) services.UserService {
 	return &mongoUserService{
 		logger:         logger,
 		env:            env,
 		cfg:            cfg,
 		userRepository: userRepository,
+		webhookService: webhookService,
+		passwordPolicy: passwordPolicy,
 	}
 }
 
 func (s *mongoUserService) CreateUser(ctx context.Context, name, email, password string, role role.UserRole) (*entities.User, error) {
 	formattedEmail := strings.ToLower(email)
 
+	err := s.passwordPolicy.Validate(password, entities.User{Name: name, Email: formattedEmail})
+	if err != nil {
+		return nil, err
+	}
+
 	// check if email is not taken
 	res := s.userRepository.FindOne(ctx, bson.M{
 		string(entities.UserEmail): formattedEmail,
 	})
 
-	err := res.Err()
+	err = res.Err()
 	if err == nil {
 		return nil, services.ErrEmailTaken
 	} else if err != mongo.ErrNoDocuments {
@@ -69,6 +91,10 @@ func (s *mongoUserService) CreateUser(ctx context.Context, name, email, password
 		return nil, errors.Wrap(err, "could not create new user")
 	}
 
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventUserRegistered, webhookUserEventData{
+		User: *user,
+	})
+
 	return user, nil
 }
 
@@ -87,6 +113,100 @@ func (s *mongoUserService) GetUsers(ctx context.Context) ([]entities.User, error
 	return users, nil
 }
 
+func (s *mongoUserService) GetUsersPage(ctx context.Context, filter services.UserFilter, page services.Pagination) ([]entities.User, *services.PageInfo, error) {
+	query, err := buildUserFilterQuery(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query, findOptions, err := buildPageQuery(query, page, string(entities.UserName), string(entities.UserEmail))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cur, err := s.userRepository.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not query for users")
+	}
+	defer cur.Close(ctx)
+
+	users, err := decodeUsersResult(ctx, cur)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not decode result")
+	}
+
+	pageSize, pageInfo := makePageInfo(len(users), page, func(i int) pageCursor {
+		cursor := pageCursor{ID: users[i].ID}
+		switch entities.UserField(page.SortBy) {
+		case entities.UserName:
+			cursor.Value = users[i].Name
+		case entities.UserEmail:
+			cursor.Value = users[i].Email
+		}
+		return cursor
+	})
+
+	return users[:pageSize], pageInfo, nil
+}
+
+func (s *mongoUserService) StreamUsers(ctx context.Context, filter services.UserFilter, callback func(entities.User) error) error {
+	query, err := buildUserFilterQuery(filter)
+	if err != nil {
+		return err
+	}
+
+	cur, err := s.userRepository.Find(ctx, query, options.Find().SetSort(bson.D{{Key: string(entities.UserID), Value: 1}}))
+	if err != nil {
+		return errors.Wrap(err, "could not query for users")
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var user entities.User
+		err = cur.Decode(&user)
+		if err != nil {
+			return errors.Wrap(err, "could not decode user")
+		}
+
+		err = callback(user)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(cur.Err(), "could not iterate over users")
+}
+
+// buildUserFilterQuery builds the query matching the users allowed by the filter
+func buildUserFilterQuery(filter services.UserFilter) (bson.M, error) {
+	query := bson.M{}
+	if len(filter.Role) > 0 {
+		query[string(entities.UserRole)] = filter.Role
+	}
+	if filter.TeamID == services.NoTeam {
+		// users who have never been in a team have no team field
+		query[string(entities.UserTeam)] = bson.M{"$in": bson.A{nil, primitive.NilObjectID}}
+	} else if len(filter.TeamID) > 0 {
+		teamID, err := primitive.ObjectIDFromHex(filter.TeamID)
+		if err != nil {
+			return nil, services.ErrInvalidID
+		}
+		query[string(entities.UserTeam)] = teamID
+	}
+	if filter.EmailVerified != nil {
+		if *filter.EmailVerified {
+			query[string(entities.UserEmailVerified)] = true
+		} else {
+			query[string(entities.UserEmailVerified)] = bson.M{"$ne": true}
+		}
+	}
+	if len(filter.NamePrefix) > 0 {
+		query[string(entities.UserName)] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.NamePrefix)}
+	}
+
+	return query, nil
+}
+
 func (s *mongoUserService) GetUsersWithTeam(ctx context.Context, teamID string) ([]entities.User, error) {
 	mongoID, err := primitive.ObjectIDFromHex(teamID)
 	if err != nil {
@@ -155,9 +275,44 @@ func (s *mongoUserService) GetUserWithEmailAndPwd(ctx context.Context, email, pw
 		return nil, services.ErrNotFound
 	}
 
+	if utils.PasswordHashNeedsUpdate(user.Password) {
+		err = s.rehashPassword(ctx, user, pwd)
+		if err != nil {
+			// the user has already been authenticated, so failing to upgrade their hash should not fail the login
+			s.logger.Warn("could not rehash user's password", zap.String("user id", user.ID.Hex()), zap.Error(err))
+		}
+	}
+
 	return user, nil
 }
 
+// rehashPassword replaces the user's password hash with one made by the default password hasher.
+// The hash is only replaced if it has not been changed since the user was fetched
+func (s *mongoUserService) rehashPassword(ctx context.Context, user *entities.User, pwd string) error {
+	pwdHash, err := utils.GetHashForPassword(pwd)
+	if err != nil {
+		return errors.Wrap(err, "could not hash password")
+	}
+
+	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+		string(entities.UserID):       user.ID,
+		string(entities.UserPassword): user.Password,
+	}, bson.M{
+		"$set": services.UserUpdateParams{
+			entities.UserPassword: pwdHash,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not update user's password")
+	}
+
+	if res.ModifiedCount > 0 {
+		user.Password = pwdHash
+	}
+
+	return nil
+}
+
 func (s *mongoUserService) GetTeamMembersForUserWithID(ctx context.Context, userID string) ([]entities.User, error) {
 	user, err := s.GetUserWithID(ctx, userID)
 	if err != nil {
@@ -217,37 +372,107 @@ func (s *mongoUserService) UpdateUserWithID(ctx context.Context, userID string,
 		return services.ErrInvalidID
 	}
 
-	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+	return s.updateUser(ctx, bson.M{
 		string(entities.UserID): mongoID,
+	}, params)
+}
+
+func (s *mongoUserService) JoinTeamForUserWithID(ctx context.Context, userID string, teamID string) error {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	teamMongoID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+		string(entities.UserID):   mongoID,
+		string(entities.UserTeam): bson.M{"$in": bson.A{nil, primitive.NilObjectID}},
 	}, bson.M{
-		"$set": params,
+		"$set": bson.M{string(entities.UserTeam): teamMongoID},
 	})
 	if err != nil {
-		return errors.Wrap(err, "could not update user with ID")
+		return errors.Wrap(err, "could not add user to team")
 	}
 
 	if res.MatchedCount == 0 {
-		return services.ErrNotFound
+		// either the user does not exist or they are in a team
+		_, err = s.GetUserWithID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return services.ErrUserInTeam
 	}
 
 	return nil
 }
 
 func (s *mongoUserService) UpdateUserWithEmail(ctx context.Context, email string, params services.UserUpdateParams) error {
-	res, err := s.userRepository.UpdateOne(ctx, bson.M{
+	return s.updateUser(ctx, bson.M{
 		string(entities.UserEmail): email,
-	}, bson.M{
+	}, params)
+}
+
+// updateUser applies params to the user matching the filter.
+// If the update changes the user's role, the change is sent to the subscribed webhooks.
+func (s *mongoUserService) updateUser(ctx context.Context, filter bson.M, params services.UserUpdateParams) error {
+	if _, updatesRole := params[entities.UserRole]; !updatesRole {
+		res, err := s.userRepository.UpdateOne(ctx, filter, bson.M{
+			"$set": params,
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not update user")
+		}
+
+		if res.MatchedCount == 0 {
+			return services.ErrNotFound
+		}
+
+		return nil
+	}
+
+	// the user as it was before the update is needed to tell whether the role has changed
+	res := s.userRepository.FindOneAndUpdate(ctx, filter, bson.M{
 		"$set": params,
 	})
+	previousUser, err := decodeUserResult(res)
+	if errors.Cause(err) == mongo.ErrNoDocuments {
+		return services.ErrNotFound
+	} else if err != nil {
+		return errors.Wrap(err, "could not update user")
+	}
+
+	s.emitRoleChangeEvents(ctx, *previousUser)
+
+	return nil
+}
+
+// emitRoleChangeEvents sends user.role_changed to the subscribed webhooks if the user's role is no
+// longer the same as previousUser's. A user whose role changes from unverified has verified their email.
+func (s *mongoUserService) emitRoleChangeEvents(ctx context.Context, previousUser entities.User) {
+	user, err := s.GetUserWithID(ctx, previousUser.ID.Hex())
 	if err != nil {
-		return errors.Wrap(err, "could not update user with email")
+		s.logger.Error("could not fetch updated user for webhook event", zap.String("user", previousUser.ID.Hex()), zap.Error(err))
+		return
 	}
 
-	if res.MatchedCount == 0 {
-		return services.ErrNotFound
+	if user.Role == previousUser.Role {
+		return
 	}
 
-	return nil
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventUserRoleChanged, webhookUserEventData{
+		User:         *user,
+		PreviousRole: previousUser.Role,
+	})
+
+	if previousUser.Role == role.Unverified {
+		emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventUserEmailVerified, webhookUserEventData{
+			User: *user,
+		})
+	}
 }
 
 func (s *mongoUserService) DeleteUserWithID(ctx context.Context, userID string) error {
@@ -256,57 +481,84 @@ func (s *mongoUserService) DeleteUserWithID(ctx context.Context, userID string)
 		return services.ErrInvalidID
 	}
 
-	res, err := s.userRepository.DeleteOne(ctx, bson.M{
+	res := s.userRepository.FindOneAndDelete(ctx, bson.M{
 		string(entities.UserID): mongoID,
 	})
-	if err != nil {
-		return errors.Wrap(err, "could not delete user with ID")
-	}
 
-	if res.DeletedCount == 0 {
+	user, err := decodeUserResult(res)
+	if errors.Cause(err) == mongo.ErrNoDocuments {
 		return services.ErrNotFound
+	} else if err != nil {
+		return errors.Wrap(err, "could not delete user with ID")
 	}
 
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventUserDeleted, webhookUserEventData{
+		User: *user,
+	})
+
 	return nil
 }
 
 func (s *mongoUserService) DeleteUserWithEmail(ctx context.Context, email string) error {
-	res, err := s.userRepository.DeleteOne(ctx, bson.M{
+	res := s.userRepository.FindOneAndDelete(ctx, bson.M{
 		string(entities.UserEmail): email,
 	})
-	if err != nil {
-		return errors.Wrap(err, "could not delete user with email")
-	}
 
-	if res.DeletedCount == 0 {
+	user, err := decodeUserResult(res)
+	if errors.Cause(err) == mongo.ErrNoDocuments {
 		return services.ErrNotFound
+	} else if err != nil {
+		return errors.Wrap(err, "could not delete user with email")
 	}
 
+	emitWebhookEvent(ctx, s.logger, s.webhookService, entities.WebhookEventUserDeleted, webhookUserEventData{
+		User: *user,
+	})
+
 	return nil
 }
 
 func (s *mongoUserService) ResetPasswordForUserWithIDAndEmail(ctx context.Context, userID string, email string, newPwd string) error {
-	mongoID, err := primitive.ObjectIDFromHex(userID)
+	user, err := s.GetUserWithID(ctx, userID)
 	if err != nil {
-		return services.ErrInvalidID
+		return err
+	} else if user.Email != email {
+		return services.ErrNotFound
 	}
 
-	// hash password
-	pwdHash, err := utils.GetHashForPassword(newPwd)
+	return s.setPassword(ctx, *user, newPwd)
+}
+
+func (s *mongoUserService) SetPasswordForUserWithID(ctx context.Context, userID string, password string) error {
+	user, err := s.GetUserWithID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.setPassword(ctx, *user, password)
+}
+
+// setPassword checks the given password against the password policy and sets it as the user's password
+func (s *mongoUserService) setPassword(ctx context.Context, user entities.User, password string) error {
+	err := s.passwordPolicy.Validate(password, user)
+	if err != nil {
+		return err
+	}
+
+	pwdHash, err := utils.GetHashForPassword(password)
 	if err != nil {
 		return errors.Wrap(err, "could not hash password")
 	}
 
 	res, err := s.userRepository.UpdateOne(ctx, bson.M{
-		string(entities.UserID):    mongoID,
-		string(entities.UserEmail): email,
+		string(entities.UserID): user.ID,
 	}, bson.M{
 		"$set": services.UserUpdateParams{
 			entities.UserPassword: pwdHash,
 		},
 	})
 	if err != nil {
-		return errors.Wrap(err, "could not update user with ID and email")
+		return errors.Wrap(err, "could not update user's password")
 	}
 
 	if res.MatchedCount == 0 {
@@ -316,6 +568,110 @@ func (s *mongoUserService) ResetPasswordForUserWithIDAndEmail(ctx context.Contex
 	return nil
 }
 
+func (s *mongoUserService) RequestEmailChangeForUserWithID(ctx context.Context, userID string, newEmail string) (*entities.User, error) {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	formattedEmail := strings.ToLower(newEmail)
+	err = s.checkEmailNotTaken(ctx, formattedEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := utils.GenerateRandomHexString(pendingEmailNonceBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate pending email nonce")
+	}
+
+	res := s.userRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.UserID): mongoID,
+	}, bson.M{
+		"$set": services.UserUpdateParams{
+			entities.UserPendingEmail:      formattedEmail,
+			entities.UserPendingEmailNonce: nonce,
+		},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	user, err := decodeUserResult(res)
+	if errors.Cause(err) == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not set user's pending email")
+	}
+
+	return user, nil
+}
+
+func (s *mongoUserService) ConfirmEmailChangeForUserWithID(ctx context.Context, userID string, nonce string) (*entities.User, error) {
+	user, err := s.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(user.PendingEmail) == 0 || len(user.PendingEmailNonce) == 0 ||
+		subtle.ConstantTimeCompare([]byte(user.PendingEmailNonce), []byte(nonce)) != 1 {
+		return nil, services.ErrNoPendingEmailChange
+	}
+	formattedEmail := user.PendingEmail
+
+	// the address could have been registered or confirmed by another user since the change was requested
+	err = s.checkEmailNotTaken(ctx, formattedEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	// the new address has been confirmed through the link sent to it, so a user who has
+	// not verified their previous address gets the same role as after verifying it
+	updatedFields := services.UserUpdateParams{
+		entities.UserEmail:         formattedEmail,
+		entities.UserEmailVerified: true,
+	}
+	if user.Role == role.Unverified {
+		updatedFields[entities.UserRole] = s.cfg.Auth.DefaultEmailVerifiedRole
+	}
+
+	res := s.userRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.UserID):                user.ID,
+		string(entities.UserPendingEmailNonce): user.PendingEmailNonce,
+	}, bson.M{
+		"$set": updatedFields,
+		"$unset": bson.M{
+			string(entities.UserPendingEmail):      "",
+			string(entities.UserPendingEmailNonce): "",
+		},
+	})
+
+	previousUser, err := decodeUserResult(res)
+	if errors.Cause(err) == mongo.ErrNoDocuments {
+		// the pending email was changed after the user was fetched
+		return nil, services.ErrNoPendingEmailChange
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not update user's email")
+	}
+
+	s.emitRoleChangeEvents(ctx, *previousUser)
+
+	return s.GetUserWithID(ctx, userID)
+}
+
+// checkEmailNotTaken returns ErrEmailTaken if the email is used by a user
+func (s *mongoUserService) checkEmailNotTaken(ctx context.Context, email string) error {
+	count, err := s.userRepository.CountDocuments(ctx, bson.M{
+		string(entities.UserEmail): email,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not query for user with email")
+	}
+
+	if count > 0 {
+		return services.ErrEmailTaken
+	}
+
+	return nil
+}
+
 func decodeUserResult(res *mongo.SingleResult) (*entities.User, error) {
 	err := res.Err()
 	if err != nil {
@@ -4,18 +4,24 @@ package mongo
 
 import (
 	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/unicsmcr/hs_auth/config"
 	"github.com/unicsmcr/hs_auth/config/role"
 	"github.com/unicsmcr/hs_auth/entities"
 	"github.com/unicsmcr/hs_auth/environment"
+	mock_services "github.com/unicsmcr/hs_auth/mocks/services"
 	"github.com/unicsmcr/hs_auth/repositories"
 	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/services/password"
 	"github.com/unicsmcr/hs_auth/testutils"
 	"github.com/unicsmcr/hs_auth/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -46,11 +52,21 @@ func setupUserTest(t *testing.T) (uService *mongoUserService, uRepo *repositorie
 	env := environment.NewEnv(zap.NewNop())
 	resetEnv()
 
+	passwordPolicy, err := password.NewPolicy(&config.AppConfig{})
+	if err != nil {
+		panic(err)
+	}
+
+	mockWService := mock_services.NewMockWebhookService(gomock.NewController(t))
+	mockWService.EXPECT().EmitEvent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
 	uService = &mongoUserService{
 		logger:         zap.NewNop(),
 		cfg:            &config.AppConfig{},
 		env:            env,
 		userRepository: userRepository,
+		webhookService: mockWService,
+		passwordPolicy: passwordPolicy,
 	}
 
 	return uService, userRepository, func() {
@@ -59,7 +75,7 @@ func setupUserTest(t *testing.T) (uService *mongoUserService, uRepo *repositorie
 }
 
 func Test_NewMongoUserService__should_return_non_nil_object(t *testing.T) {
-	assert.NotNil(t, NewMongoUserService(nil, nil, nil, nil))
+	assert.NotNil(t, NewMongoUserService(nil, nil, nil, nil, nil, nil))
 }
 
 func Test_User_ErrInvalidID_should_be_returned_when_provided_id_is_invalid(t *testing.T) {
@@ -77,6 +93,13 @@ func Test_User_ErrInvalidID_should_be_returned_when_provided_id_is_invalid(t *te
 				return err
 			},
 		},
+		{
+			name: "GetUsersPage",
+			testFunction: func(id string) error {
+				_, _, err := uService.GetUsersPage(context.Background(), services.UserFilter{TeamID: id}, services.Pagination{})
+				return err
+			},
+		},
 		{
 			name: "GetUserWithID",
 			testFunction: func(id string) error {
@@ -108,6 +131,26 @@ func Test_User_ErrInvalidID_should_be_returned_when_provided_id_is_invalid(t *te
 				return uService.ResetPasswordForUserWithIDAndEmail(context.Background(), id, "", "")
 			},
 		},
+		{
+			name: "RequestEmailChangeForUserWithID",
+			testFunction: func(id string) error {
+				_, err := uService.RequestEmailChangeForUserWithID(context.Background(), id, "new@email.com")
+				return err
+			},
+		},
+		{
+			name: "ConfirmEmailChangeForUserWithID",
+			testFunction: func(id string) error {
+				_, err := uService.ConfirmEmailChangeForUserWithID(context.Background(), id, "abc123")
+				return err
+			},
+		},
+		{
+			name: "JoinTeamForUserWithID",
+			testFunction: func(id string) error {
+				return uService.JoinTeamForUserWithID(context.Background(), id, primitive.NewObjectID().Hex())
+			},
+		},
 		{
 			name: "GetTeammatesForUserWithID",
 			testFunction: func(id string) error {
@@ -161,6 +204,27 @@ func Test_CreateUser__should_create_correct_user(t *testing.T) {
 	assert.NoError(t, res.Err())
 }
 
+func Test_CreateUser__should_emit_user_registered_event(t *testing.T) {
+	uService, _, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWService := mock_services.NewMockWebhookService(ctrl)
+	uService.webhookService = mockWService
+
+	var eventData webhookUserEventData
+	mockWService.EXPECT().EmitEvent(gomock.Any(), entities.WebhookEventUserRegistered, gomock.Any()).
+		Do(func(_ context.Context, _ entities.WebhookEvent, data interface{}) {
+			eventData = data.(webhookUserEventData)
+		}).Return(nil).Times(1)
+
+	user, err := uService.CreateUser(context.Background(), testUser.Name, testUser.Email, testUser.Password, role.Applicant)
+	assert.NoError(t, err)
+
+	assert.Equal(t, *user, eventData.User)
+}
+
 func Test_GetUsers__should_return_expected_users(t *testing.T) {
 	uService, uRepo, cleanup := setupUserTest(t)
 	defer cleanup()
@@ -180,6 +244,157 @@ func Test_GetUsers__should_return_expected_users(t *testing.T) {
 	assert.Equal(t, testUsers, users)
 }
 
+func Test_GetUsersPage__should_return_users_page_by_page(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	var testUsers []entities.User
+	for i, name := range []string{"Dan", "Bob", "Cat", "Bob", "Amy"} {
+		user := testUser
+		user.ID = primitive.NewObjectID()
+		user.Name = name
+		user.Email = fmt.Sprintf("test%d@email.com", i)
+		testUsers = append(testUsers, user)
+		_, err := uRepo.InsertOne(context.Background(), user)
+		assert.NoError(t, err)
+	}
+
+	page := services.Pagination{Limit: 2, SortBy: "name"}
+	users, pageInfo, err := uService.GetUsersPage(context.Background(), services.UserFilter{}, page)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.User{testUsers[4], testUsers[1]}, users)
+	assert.True(t, pageInfo.HasMore)
+
+	page.After = pageInfo.NextCursor
+	users, pageInfo, err = uService.GetUsersPage(context.Background(), services.UserFilter{}, page)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.User{testUsers[3], testUsers[2]}, users)
+	assert.True(t, pageInfo.HasMore)
+
+	page.After = pageInfo.NextCursor
+	users, pageInfo, err = uService.GetUsersPage(context.Background(), services.UserFilter{}, page)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.User{testUsers[0]}, users)
+	assert.Equal(t, &services.PageInfo{}, pageInfo)
+}
+
+func Test_GetUsersPage__should_return_users_matching_filter(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	verifiedUser := testUser
+	verifiedUser.Role = role.Applicant
+	verifiedUser.EmailVerified = true
+	unverifiedUser := testUser
+	unverifiedUser.ID = primitive.NewObjectID()
+	unverifiedUser.Name = "Rob the Tester"
+	unverifiedUser.Email = "test2@email.com"
+	unverifiedUser.Role = role.Unverified
+	unverifiedUser.Team = primitive.NilObjectID
+
+	_, err := uRepo.InsertMany(context.Background(), []interface{}{verifiedUser, unverifiedUser})
+	assert.NoError(t, err)
+
+	verified, unverified := true, false
+	tests := []struct {
+		name      string
+		filter    services.UserFilter
+		wantUsers []entities.User
+	}{
+		{
+			name:      "role",
+			filter:    services.UserFilter{Role: role.Applicant},
+			wantUsers: []entities.User{verifiedUser},
+		},
+		{
+			name:      "team",
+			filter:    services.UserFilter{TeamID: testUser.Team.Hex()},
+			wantUsers: []entities.User{verifiedUser},
+		},
+		{
+			name:      "no team",
+			filter:    services.UserFilter{TeamID: services.NoTeam},
+			wantUsers: []entities.User{unverifiedUser},
+		},
+		{
+			name:      "verified email",
+			filter:    services.UserFilter{EmailVerified: &verified},
+			wantUsers: []entities.User{verifiedUser},
+		},
+		{
+			name:      "unverified email",
+			filter:    services.UserFilter{EmailVerified: &unverified},
+			wantUsers: []entities.User{unverifiedUser},
+		},
+		{
+			name:      "name prefix",
+			filter:    services.UserFilter{NamePrefix: "Rob"},
+			wantUsers: []entities.User{unverifiedUser},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, _, err := uService.GetUsersPage(context.Background(), tt.filter, services.Pagination{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantUsers, users)
+		})
+	}
+}
+
+func Test_GetUsersPage__should_return_ErrInvalidSortField_when_sort_field_is_not_sortable(t *testing.T) {
+	uService, _, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	_, _, err := uService.GetUsersPage(context.Background(), services.UserFilter{}, services.Pagination{SortBy: "password"})
+	assert.Equal(t, services.ErrInvalidSortField, err)
+}
+
+func Test_StreamUsers__should_call_callback_with_matching_users(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	testUser2 := testUser
+	testUser2.ID = primitive.NewObjectID()
+	testUser2.Email = "test2@email.com"
+	testUser3 := testUser
+	testUser3.ID = primitive.NewObjectID()
+	testUser3.Email = "test3@email.com"
+	testUser3.Team = primitive.NilObjectID
+
+	_, err := uRepo.InsertMany(context.Background(), []interface{}{testUser, testUser2, testUser3})
+	assert.NoError(t, err)
+
+	var users []entities.User
+	err = uService.StreamUsers(context.Background(), services.UserFilter{TeamID: testUser.Team.Hex()}, func(user entities.User) error {
+		users = append(users, user)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.User{testUser, testUser2}, users)
+}
+
+func Test_StreamUsers__should_stop_when_callback_returns_error(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	testUser2 := testUser
+	testUser2.ID = primitive.NewObjectID()
+	testUser2.Email = "test2@email.com"
+
+	_, err := uRepo.InsertMany(context.Background(), []interface{}{testUser, testUser2})
+	assert.NoError(t, err)
+
+	callbackErr := errors.New("callback err")
+	calls := 0
+	err = uService.StreamUsers(context.Background(), services.UserFilter{}, func(user entities.User) error {
+		calls++
+		return callbackErr
+	})
+	assert.Equal(t, callbackErr, err)
+	assert.Equal(t, 1, calls)
+}
+
 func Test_GetUsersWithTeam__should_return_expected_users(t *testing.T) {
 	uService, uRepo, cleanup := setupUserTest(t)
 	defer cleanup()
@@ -315,6 +530,30 @@ func Test_GetUserWithEmailAndPwd__should_return_expected_user(t *testing.T) {
 	assert.Equal(t, testUser, *user)
 }
 
+func Test_GetUserWithEmailAndPwd__should_rehash_outdated_password_hash(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	assert.NoError(t, err)
+	legacyUser := testUser
+	legacyUser.Password = string(bcryptHash)
+
+	_, err = uRepo.InsertOne(context.Background(), legacyUser)
+	assert.NoError(t, err)
+
+	user, err := uService.GetUserWithEmailAndPwd(context.Background(), legacyUser.Email, "password123")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(user.Password, "$argon2id$"))
+
+	var storedUser entities.User
+	err = uRepo.FindOne(context.Background(), bson.M{string(entities.UserID): legacyUser.ID}).Decode(&storedUser)
+	assert.NoError(t, err)
+	assert.Equal(t, user.Password, storedUser.Password)
+	assert.NoError(t, utils.CompareHashAndPassword(storedUser.Password, "password123"))
+	assert.False(t, utils.PasswordHashNeedsUpdate(storedUser.Password))
+}
+
 func Test_UpdateUsersWithTeam__should_update_expected_users(t *testing.T) {
 	uService, uRepo, cleanup := setupUserTest(t)
 	defer cleanup()
@@ -387,6 +626,112 @@ func Test_UpdateUserWithID__should_update_expected_user(t *testing.T) {
 	assert.Equal(t, []entities.User{testUser, testUser2}, users)
 }
 
+func Test_UpdateUserWithID__should_emit_role_changed_and_email_verified_events_when_unverified_user_gets_new_role(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWService := mock_services.NewMockWebhookService(ctrl)
+	uService.webhookService = mockWService
+
+	unverifiedUser := testUser
+	unverifiedUser.Role = role.Unverified
+	_, err := uRepo.InsertOne(context.Background(), unverifiedUser)
+	assert.NoError(t, err)
+
+	verifiedUser := unverifiedUser
+	verifiedUser.Role = role.Applicant
+	mockWService.EXPECT().EmitEvent(gomock.Any(), entities.WebhookEventUserRoleChanged, webhookUserEventData{
+		User:         verifiedUser,
+		PreviousRole: role.Unverified,
+	}).Return(nil).Times(1)
+	mockWService.EXPECT().EmitEvent(gomock.Any(), entities.WebhookEventUserEmailVerified, webhookUserEventData{
+		User: verifiedUser,
+	}).Return(nil).Times(1)
+
+	err = uService.UpdateUserWithID(context.Background(), testUser.ID.Hex(), services.UserUpdateParams{
+		entities.UserRole: role.Applicant,
+	})
+	assert.NoError(t, err)
+}
+
+func Test_UpdateUserWithID__should_not_emit_events_when_role_is_unchanged(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	uService.webhookService = mock_services.NewMockWebhookService(ctrl)
+
+	applicant := testUser
+	applicant.Role = role.Applicant
+	_, err := uRepo.InsertOne(context.Background(), applicant)
+	assert.NoError(t, err)
+
+	err = uService.UpdateUserWithID(context.Background(), testUser.ID.Hex(), services.UserUpdateParams{
+		entities.UserRole: role.Applicant,
+	})
+	assert.NoError(t, err)
+}
+
+func Test_UpdateUserWithID__should_return_ErrNotFound_when_updating_role_of_user_that_doesnt_exist(t *testing.T) {
+	uService, _, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	err := uService.UpdateUserWithID(context.Background(), testUser.ID.Hex(), services.UserUpdateParams{
+		entities.UserRole: role.Applicant,
+	})
+
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_JoinTeamForUserWithID__should_return_ErrNotFound_when_user_with_id_doesnt_exist(t *testing.T) {
+	uService, _, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	err := uService.JoinTeamForUserWithID(context.Background(), testUser.ID.Hex(), primitive.NewObjectID().Hex())
+
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_JoinTeamForUserWithID__should_return_ErrUserInTeam_when_user_is_in_a_team(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	_, err := uRepo.InsertOne(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	err = uService.JoinTeamForUserWithID(context.Background(), testUser.ID.Hex(), primitive.NewObjectID().Hex())
+	assert.Equal(t, services.ErrUserInTeam, err)
+
+	user, err := uService.GetUserWithID(context.Background(), testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, testUser.Team, user.Team)
+}
+
+func Test_JoinTeamForUserWithID__should_add_user_without_team_to_team(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	userWithoutTeam := testUser
+	userWithoutTeam.Team = primitive.NilObjectID
+	_, err := uRepo.InsertOne(context.Background(), userWithoutTeam)
+	assert.NoError(t, err)
+
+	teamID := primitive.NewObjectID()
+	err = uService.JoinTeamForUserWithID(context.Background(), testUser.ID.Hex(), teamID.Hex())
+	assert.NoError(t, err)
+
+	user, err := uService.GetUserWithID(context.Background(), testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, teamID, user.Team)
+
+	// the user cannot join a second team
+	err = uService.JoinTeamForUserWithID(context.Background(), testUser.ID.Hex(), primitive.NewObjectID().Hex())
+	assert.Equal(t, services.ErrUserInTeam, err)
+}
+
 func Test_UpdateUserWithEmail__should_return_ErrNotFound_when_user_with_id_doesnt_exist(t *testing.T) {
 	uService, _, cleanup := setupUserTest(t)
 	defer cleanup()
@@ -457,6 +802,26 @@ func Test_DeleteUserWithID__should_delete_expected_user(t *testing.T) {
 	assert.Equal(t, []entities.User{testUser2}, users)
 }
 
+func Test_DeleteUserWithID__should_emit_user_deleted_event(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWService := mock_services.NewMockWebhookService(ctrl)
+	uService.webhookService = mockWService
+
+	_, err := uRepo.InsertOne(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	mockWService.EXPECT().EmitEvent(gomock.Any(), entities.WebhookEventUserDeleted, webhookUserEventData{
+		User: testUser,
+	}).Return(nil).Times(1)
+
+	err = uService.DeleteUserWithID(context.Background(), testUser.ID.Hex())
+	assert.NoError(t, err)
+}
+
 func Test_DeleteUserWithEmail__should_return_ErrNotFound_when_user_with_email_doesnt_exist(t *testing.T) {
 	uService, _, cleanup := setupUserTest(t)
 	defer cleanup()
@@ -592,3 +957,193 @@ func Test_GetTeamMembersForUserWithID__should_return_error_when_user_is_not_in_a
 	assert.Equal(t, services.ErrUserNotInTeam, err)
 	assert.Nil(t, members)
 }
+
+func Test_SetPasswordForUserWithID__should_update_expected_user(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	_, err := uRepo.InsertOne(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	err = uService.SetPasswordForUserWithID(context.Background(), testUser.ID.Hex(), "password321")
+	assert.NoError(t, err)
+
+	user, err := uService.GetUserWithID(context.Background(), testUser.ID.Hex())
+	assert.NoError(t, err)
+
+	err = utils.CompareHashAndPassword(user.Password, "password321")
+	assert.NoError(t, err)
+}
+
+func Test_SetPasswordForUserWithID__should_return_ErrNotFound_when_user_doesnt_exist(t *testing.T) {
+	uService, _, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	err := uService.SetPasswordForUserWithID(context.Background(), testUser.ID.Hex(), "password321")
+
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_password_policy__should_be_enforced(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	passwordPolicy, err := password.NewPolicy(&config.AppConfig{Auth: config.AuthConfig{
+		PasswordPolicy: config.PasswordPolicyConfig{MinLength: 8, DisallowPersonalInfo: true},
+	}})
+	assert.NoError(t, err)
+	uService.passwordPolicy = passwordPolicy
+
+	_, err = uRepo.InsertOne(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	_, err = uService.CreateUser(context.Background(), "Rob", "rob@email.com", "short", role.Applicant)
+	assert.Equal(t, services.ErrPasswordTooShort, errors.Cause(err))
+
+	err = uService.SetPasswordForUserWithID(context.Background(), testUser.ID.Hex(), "Tester2020")
+	assert.Equal(t, services.ErrPasswordContainsPersonalInfo, errors.Cause(err))
+
+	err = uService.ResetPasswordForUserWithIDAndEmail(context.Background(), testUser.ID.Hex(), testUser.Email, "short")
+	assert.Equal(t, services.ErrPasswordTooShort, errors.Cause(err))
+}
+
+func Test_RequestEmailChangeForUserWithID__should_return_ErrEmailTaken_when_email_is_taken(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	testUser2 := testUser
+	testUser2.ID = primitive.NewObjectID()
+	testUser2.Email = "test2@email.com"
+	_, err := uRepo.InsertMany(context.Background(), []interface{}{testUser, testUser2})
+	assert.NoError(t, err)
+
+	user, err := uService.RequestEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "Test2@email.com")
+
+	assert.Equal(t, services.ErrEmailTaken, err)
+	assert.Nil(t, user)
+}
+
+func Test_RequestEmailChangeForUserWithID__should_return_ErrNotFound_when_user_doesnt_exist(t *testing.T) {
+	uService, _, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	user, err := uService.RequestEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "new@email.com")
+
+	assert.Equal(t, services.ErrNotFound, err)
+	assert.Nil(t, user)
+}
+
+func Test_RequestEmailChangeForUserWithID__should_only_set_pending_email_and_nonce(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	_, err := uRepo.InsertOne(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	user, err := uService.RequestEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "New@email.com")
+	assert.NoError(t, err)
+	assert.Len(t, user.PendingEmailNonce, 2*pendingEmailNonceBytes)
+
+	expectedUser := testUser
+	expectedUser.PendingEmail = "new@email.com"
+	expectedUser.PendingEmailNonce = user.PendingEmailNonce
+	assert.Equal(t, expectedUser, *user)
+}
+
+func Test_RequestEmailChangeForUserWithID__should_replace_nonce_of_earlier_request(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	_, err := uRepo.InsertOne(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	firstRequest, err := uService.RequestEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "first@email.com")
+	assert.NoError(t, err)
+	_, err = uService.RequestEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "second@email.com")
+	assert.NoError(t, err)
+
+	user, err := uService.ConfirmEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), firstRequest.PendingEmailNonce)
+
+	assert.Equal(t, services.ErrNoPendingEmailChange, err)
+	assert.Nil(t, user)
+}
+
+func Test_ConfirmEmailChangeForUserWithID__should_return_ErrNoPendingEmailChange_when_nonce_doesnt_match(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	pendingUser := testUser
+	pendingUser.PendingEmail = "new@email.com"
+	pendingUser.PendingEmailNonce = "abc123"
+	_, err := uRepo.InsertOne(context.Background(), pendingUser)
+	assert.NoError(t, err)
+
+	user, err := uService.ConfirmEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "def456")
+
+	assert.Equal(t, services.ErrNoPendingEmailChange, err)
+	assert.Nil(t, user)
+}
+
+func Test_ConfirmEmailChangeForUserWithID__should_return_ErrEmailTaken_when_email_was_taken_after_request(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+
+	pendingUser := testUser
+	pendingUser.PendingEmail = "new@email.com"
+	pendingUser.PendingEmailNonce = "abc123"
+	testUser2 := testUser
+	testUser2.ID = primitive.NewObjectID()
+	testUser2.Email = "new@email.com"
+	_, err := uRepo.InsertMany(context.Background(), []interface{}{pendingUser, testUser2})
+	assert.NoError(t, err)
+
+	user, err := uService.ConfirmEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "abc123")
+
+	assert.Equal(t, services.ErrEmailTaken, err)
+	assert.Nil(t, user)
+}
+
+func Test_ConfirmEmailChangeForUserWithID__should_swap_email_and_verify_unverified_user(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+	uService.cfg.Auth.DefaultEmailVerifiedRole = role.Applicant
+
+	pendingUser := testUser
+	pendingUser.Role = role.Unverified
+	pendingUser.PendingEmail = "new@email.com"
+	pendingUser.PendingEmailNonce = "abc123"
+	_, err := uRepo.InsertOne(context.Background(), pendingUser)
+	assert.NoError(t, err)
+
+	user, err := uService.ConfirmEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "abc123")
+	assert.NoError(t, err)
+
+	expectedUser := testUser
+	expectedUser.Email = "new@email.com"
+	expectedUser.EmailVerified = true
+	expectedUser.Role = role.Applicant
+	assert.Equal(t, expectedUser, *user)
+}
+
+func Test_ConfirmEmailChangeForUserWithID__should_keep_role_of_verified_user(t *testing.T) {
+	uService, uRepo, cleanup := setupUserTest(t)
+	defer cleanup()
+	uService.cfg.Auth.DefaultEmailVerifiedRole = role.Applicant
+
+	pendingUser := testUser
+	pendingUser.Role = role.Attendee
+	pendingUser.EmailVerified = true
+	pendingUser.PendingEmail = "new@email.com"
+	pendingUser.PendingEmailNonce = "abc123"
+	_, err := uRepo.InsertOne(context.Background(), pendingUser)
+	assert.NoError(t, err)
+
+	user, err := uService.ConfirmEmailChangeForUserWithID(context.Background(), testUser.ID.Hex(), "abc123")
+	assert.NoError(t, err)
+
+	expectedUser := pendingUser
+	expectedUser.Email = "new@email.com"
+	expectedUser.PendingEmail = ""
+	expectedUser.PendingEmailNonce = ""
+	assert.Equal(t, expectedUser, *user)
+}
@@ -0,0 +1,433 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	webAuthnChallengeBytes        = 32
+	webAuthnCredentialType        = "public-key"
+	webAuthnUserVerification      = "preferred"
+	defaultWebAuthnCredentialName = "Passkey"
+	maxWebAuthnCredentialName     = 64
+)
+
+type mongoWebAuthnService struct {
+	logger                       *zap.Logger
+	cfg                          *config.AppConfig
+	timeProvider                 utils.TimeProvider
+	webAuthnCredentialRepository *repositories.WebAuthnCredentialRepository
+	webAuthnChallengeRepository  *repositories.WebAuthnChallengeRepository
+	userService                  services.UserService
+}
+
+// NewMongoWebAuthnService creates a new WebAuthnService that stores the users' passkeys in MongoDB
+func NewMongoWebAuthnService(logger *zap.Logger, cfg *config.AppConfig, timeProvider utils.TimeProvider,
+	webAuthnCredentialRepository *repositories.WebAuthnCredentialRepository,
+	webAuthnChallengeRepository *repositories.WebAuthnChallengeRepository, userService services.UserService) services.WebAuthnService {
+	return &mongoWebAuthnService{
+		logger:                       logger,
+		cfg:                          cfg,
+		timeProvider:                 timeProvider,
+		webAuthnCredentialRepository: webAuthnCredentialRepository,
+		webAuthnChallengeRepository:  webAuthnChallengeRepository,
+		userService:                  userService,
+	}
+}
+
+func (s *mongoWebAuthnService) BeginRegistration(ctx context.Context, userID string) (*utils.WebAuthnCreationOptions, error) {
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.GetCredentialsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.createChallenge(ctx, entities.WebAuthnCeremonyRegistration, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialParams := make([]utils.WebAuthnCredentialParameters, len(utils.WebAuthnAlgorithms))
+	for i, alg := range utils.WebAuthnAlgorithms {
+		credentialParams[i] = utils.WebAuthnCredentialParameters{Type: webAuthnCredentialType, Alg: alg}
+	}
+
+	return &utils.WebAuthnCreationOptions{
+		Challenge: challenge,
+		RP: utils.WebAuthnRelyingParty{
+			ID:   s.cfg.WebAuthn.RPID,
+			Name: s.cfg.Name,
+		},
+		User: utils.WebAuthnUser{
+			ID:          user.ID[:],
+			Name:        user.Email,
+			DisplayName: user.Name,
+		},
+		PubKeyCredParams: credentialParams,
+		Timeout:          s.cfg.WebAuthn.ChallengeLifetime * 1000,
+		// stops the user from registering the same authenticator twice
+		ExcludeCredentials: makeCredentialDescriptors(credentials),
+		AuthenticatorSelection: utils.WebAuthnAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: webAuthnUserVerification,
+		},
+		Attestation: "none",
+	}, nil
+}
+
+func (s *mongoWebAuthnService) FinishRegistration(ctx context.Context, userID, name string, credential utils.WebAuthnCredentialResponse) (*entities.WebAuthnCredential, error) {
+	user, err := s.userService.GetUserWithID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientData, err := s.verifyClientData(credential, utils.WebAuthnTypeCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.useChallenge(ctx, entities.WebAuthnCeremonyRegistration, clientData.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID != user.ID {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "challenge was issued to another user")
+	}
+
+	authData, err := utils.ParseWebAuthnAttestationObject(credential.Response.AttestationObject)
+	if err != nil {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, err.Error())
+	}
+	err = s.verifyAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialID := utils.EncodeWebAuthnBytes(authData.CredentialID)
+	count, err := s.webAuthnCredentialRepository.CountDocuments(ctx, bson.M{
+		string(entities.WebAuthnCredentialCredentialID): credentialID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for credential")
+	} else if count > 0 {
+		return nil, services.ErrWebAuthnCredentialExists
+	}
+
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		name = defaultWebAuthnCredentialName
+	} else if len(name) > maxWebAuthnCredentialName {
+		name = name[:maxWebAuthnCredentialName]
+	}
+
+	storedCredential := &entities.WebAuthnCredential{
+		ID:           primitive.NewObjectID(),
+		UserID:       user.ID,
+		CredentialID: credentialID,
+		PublicKey:    authData.PublicKey,
+		SignCount:    int64(authData.SignCount),
+		Name:         name,
+		CreatedAt:    s.timeProvider.Now(),
+	}
+
+	_, err = s.webAuthnCredentialRepository.InsertOne(ctx, *storedCredential)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not store credential")
+	}
+
+	return storedCredential, nil
+}
+
+func (s *mongoWebAuthnService) BeginLogin(ctx context.Context, userID string) (*utils.WebAuthnRequestOptions, error) {
+	var (
+		mongoID     primitive.ObjectID
+		credentials []entities.WebAuthnCredential
+		err         error
+	)
+	if len(userID) > 0 {
+		mongoID, err = primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return nil, services.ErrInvalidID
+		}
+
+		credentials, err = s.GetCredentialsForUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	challenge, err := s.createChallenge(ctx, entities.WebAuthnCeremonyLogin, mongoID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &utils.WebAuthnRequestOptions{
+		Challenge:        challenge,
+		Timeout:          s.cfg.WebAuthn.ChallengeLifetime * 1000,
+		RPID:             s.cfg.WebAuthn.RPID,
+		AllowCredentials: makeCredentialDescriptors(credentials),
+		UserVerification: webAuthnUserVerification,
+	}, nil
+}
+
+func (s *mongoWebAuthnService) FinishLogin(ctx context.Context, userID string, credential utils.WebAuthnCredentialResponse) (*entities.User, error) {
+	clientData, err := s.verifyClientData(credential, utils.WebAuthnTypeGet)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.useChallenge(ctx, entities.WebAuthnCeremonyLogin, clientData.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	var storedCredential entities.WebAuthnCredential
+	err = s.webAuthnCredentialRepository.FindOne(ctx, bson.M{
+		string(entities.WebAuthnCredentialCredentialID): utils.EncodeWebAuthnBytes(credential.RawID),
+	}).Decode(&storedCredential)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "credential not registered")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for credential")
+	}
+
+	if !challenge.UserID.IsZero() && challenge.UserID != storedCredential.UserID {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "challenge was issued to another user")
+	}
+	if len(userID) > 0 && userID != storedCredential.UserID.Hex() {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "credential belongs to another user")
+	}
+	if len(credential.Response.UserHandle) > 0 && !bytes.Equal(credential.Response.UserHandle, storedCredential.UserID[:]) {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "user handle does not match credential")
+	}
+
+	authData, err := utils.ParseWebAuthnAuthenticatorData(credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, err.Error())
+	}
+	err = s.verifyAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	// without a password, the passkey is only enough to log in if the authenticator verified the user
+	if len(userID) == 0 && !authData.UserVerified() {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "user not verified by authenticator")
+	}
+
+	err = utils.VerifyWebAuthnSignature(storedCredential.PublicKey, credential.Response.AuthenticatorData,
+		credential.Response.ClientDataJSON, credential.Response.Signature)
+	if err != nil {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, err.Error())
+	}
+
+	err = s.useCredential(ctx, storedCredential, int64(authData.SignCount))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userService.GetUserWithID(ctx, storedCredential.UserID.Hex())
+}
+
+func (s *mongoWebAuthnService) GetCredentialsForUser(ctx context.Context, userID string) ([]entities.WebAuthnCredential, error) {
+	mongoID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	cur, err := s.webAuthnCredentialRepository.Find(ctx, bson.M{
+		string(entities.WebAuthnCredentialUserID): mongoID,
+	}, options.Find().SetSort(bson.M{string(entities.WebAuthnCredentialCreatedAt): 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for credentials")
+	}
+	defer cur.Close(ctx)
+
+	credentials := []entities.WebAuthnCredential{}
+	for cur.Next(ctx) {
+		var credential entities.WebAuthnCredential
+		err = cur.Decode(&credential)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode credential")
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, nil
+}
+
+func (s *mongoWebAuthnService) DeleteCredentialForUser(ctx context.Context, userID, id string) error {
+	mongoUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+	mongoID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.webAuthnCredentialRepository.DeleteOne(ctx, bson.M{
+		string(entities.WebAuthnCredentialID):     mongoID,
+		string(entities.WebAuthnCredentialUserID): mongoUserID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete credential")
+	} else if res.DeletedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteCredentialsForUser deletes all passkeys of the user with the given id
+func (s *mongoWebAuthnService) DeleteCredentialsForUser(ctx context.Context, userID string) error {
+	mongoUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	_, err = s.webAuthnCredentialRepository.DeleteMany(ctx, bson.M{
+		string(entities.WebAuthnCredentialUserID): mongoUserID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete credentials")
+	}
+
+	return nil
+}
+
+// createChallenge stores a new random challenge for a ceremony of the given type and returns it
+func (s *mongoWebAuthnService) createChallenge(ctx context.Context, ceremony entities.WebAuthnCeremony, userID primitive.ObjectID) ([]byte, error) {
+	challenge := make([]byte, webAuthnChallengeBytes)
+	_, err := rand.Read(challenge)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate challenge")
+	}
+
+	_, err = s.webAuthnChallengeRepository.InsertOne(ctx, entities.WebAuthnChallenge{
+		ID:        primitive.NewObjectID(),
+		Challenge: utils.EncodeWebAuthnBytes(challenge),
+		Ceremony:  ceremony,
+		UserID:    userID,
+		ExpiresAt: s.timeProvider.Now().Add(time.Duration(s.cfg.WebAuthn.ChallengeLifetime) * time.Second),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not store challenge")
+	}
+
+	return challenge, nil
+}
+
+// useChallenge removes the given challenge, so that it cannot be used again. Fails with
+// ErrInvalidWebAuthnCredential if the challenge was not issued for a ceremony of the given type or has expired.
+func (s *mongoWebAuthnService) useChallenge(ctx context.Context, ceremony entities.WebAuthnCeremony, challenge string) (*entities.WebAuthnChallenge, error) {
+	var storedChallenge entities.WebAuthnChallenge
+	err := s.webAuthnChallengeRepository.FindOneAndDelete(ctx, bson.M{
+		string(entities.WebAuthnChallengeChallenge): challenge,
+		string(entities.WebAuthnChallengeCeremony):  ceremony,
+		string(entities.WebAuthnChallengeExpiresAt): bson.M{"$gt": s.timeProvider.Now()},
+	}).Decode(&storedChallenge)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "unknown or expired challenge")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for challenge")
+	}
+
+	return &storedChallenge, nil
+}
+
+// useCredential records the signature counter of an accepted assertion. Fails with ErrInvalidWebAuthnCredential
+// if the counter has not increased since the credential was last used, as the authenticator may have been cloned.
+// Some authenticators do not implement the counter and always report 0.
+func (s *mongoWebAuthnService) useCredential(ctx context.Context, credential entities.WebAuthnCredential, signCount int64) error {
+	if (signCount != 0 || credential.SignCount != 0) && signCount <= credential.SignCount {
+		s.logger.Warn("signature counter of WebAuthn credential did not increase", zap.String("credential id", credential.ID.Hex()))
+		return errors.Wrap(services.ErrInvalidWebAuthnCredential, "signature counter did not increase")
+	}
+
+	// the previous counter is part of the filter, so that the same assertion cannot be used concurrently
+	res, err := s.webAuthnCredentialRepository.UpdateOne(ctx, bson.M{
+		string(entities.WebAuthnCredentialID):        credential.ID,
+		string(entities.WebAuthnCredentialSignCount): credential.SignCount,
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.WebAuthnCredentialSignCount):  signCount,
+			string(entities.WebAuthnCredentialLastUsedAt): s.timeProvider.Now(),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not update credential")
+	} else if res.MatchedCount == 0 {
+		return errors.Wrap(services.ErrInvalidWebAuthnCredential, "credential was used concurrently")
+	}
+
+	return nil
+}
+
+// verifyClientData checks that the credential was created by the browser for a ceremony of the given type
+// on one of hs_auth's pages
+func (s *mongoWebAuthnService) verifyClientData(credential utils.WebAuthnCredentialResponse, ceremonyType string) (*utils.WebAuthnClientData, error) {
+	clientData, err := utils.ParseWebAuthnClientData(credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, err.Error())
+	}
+
+	if clientData.Type != ceremonyType {
+		return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "unexpected ceremony type")
+	}
+
+	for _, origin := range s.cfg.WebAuthn.RPOrigins {
+		if clientData.Origin == origin {
+			return clientData, nil
+		}
+	}
+
+	return nil, errors.Wrap(services.ErrInvalidWebAuthnCredential, "unexpected origin")
+}
+
+func (s *mongoWebAuthnService) verifyAuthenticatorData(authData *utils.WebAuthnAuthenticatorData) error {
+	if !authData.MatchesRPID(s.cfg.WebAuthn.RPID) {
+		return errors.Wrap(services.ErrInvalidWebAuthnCredential, "unexpected relying party id")
+	}
+
+	if !authData.UserPresent() {
+		return errors.Wrap(services.ErrInvalidWebAuthnCredential, "user not present")
+	}
+
+	return nil
+}
+
+func makeCredentialDescriptors(credentials []entities.WebAuthnCredential) []utils.WebAuthnCredentialDescriptor {
+	descriptors := []utils.WebAuthnCredentialDescriptor{}
+	for _, credential := range credentials {
+		credentialID, err := utils.DecodeWebAuthnBytes(credential.CredentialID)
+		if err != nil {
+			continue
+		}
+		descriptors = append(descriptors, utils.WebAuthnCredentialDescriptor{
+			Type: webAuthnCredentialType,
+			ID:   credentialID,
+		})
+	}
+
+	return descriptors
+}
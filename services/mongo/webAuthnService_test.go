@@ -0,0 +1,359 @@
+//go:build integration
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost:8000"
+)
+
+var testWebAuthnTime = time.Unix(1234567890, 0)
+
+type webAuthnTestSetup struct {
+	ctrl          *gomock.Controller
+	waService     *mongoWebAuthnService
+	authenticator *testutils.WebAuthnAuthenticator
+	testUser      entities.User
+	cleanup       func()
+}
+
+func setupWebAuthnTest(t *testing.T) *webAuthnTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	uRepo, err := repositories.NewUserRepository(db)
+	if err != nil {
+		panic(err)
+	}
+	credentialRepo, err := repositories.NewWebAuthnCredentialRepository(db)
+	if err != nil {
+		panic(err)
+	}
+	challengeRepo, err := repositories.NewWebAuthnChallengeRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTimeProvider.EXPECT().Now().Return(testWebAuthnTime).AnyTimes()
+
+	cfg := &config.AppConfig{
+		Name: "HS Auth",
+		WebAuthn: config.WebAuthnConfig{
+			RPID:              testRPID,
+			RPOrigins:         []string{testOrigin},
+			ChallengeLifetime: 300,
+		},
+	}
+
+	waService := &mongoWebAuthnService{
+		logger:                       zap.NewNop(),
+		cfg:                          cfg,
+		timeProvider:                 mockTimeProvider,
+		webAuthnCredentialRepository: credentialRepo,
+		webAuthnChallengeRepository:  challengeRepo,
+		userService: &mongoUserService{
+			logger:         zap.NewNop(),
+			cfg:            cfg,
+			userRepository: uRepo,
+		},
+	}
+
+	user := entities.User{
+		ID:    primitive.NewObjectID(),
+		Name:  "Bob the Tester",
+		Email: "bob@email.com",
+	}
+	_, err = uRepo.InsertOne(context.Background(), user)
+	if err != nil {
+		panic(err)
+	}
+
+	return &webAuthnTestSetup{
+		ctrl:          ctrl,
+		waService:     waService,
+		authenticator: testutils.NewWebAuthnAuthenticator(),
+		testUser:      user,
+		cleanup: func() {
+			ctrl.Finish()
+			uRepo.Drop(context.Background())
+			credentialRepo.Drop(context.Background())
+			challengeRepo.Drop(context.Background())
+		},
+	}
+}
+
+// register registers the setup's authenticator as a passkey of the test user
+func (setup *webAuthnTestSetup) register(t *testing.T) *entities.WebAuthnCredential {
+	options, err := setup.waService.BeginRegistration(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+
+	credential, err := utils.ParseWebAuthnCredentialResponse(setup.authenticator.MakeCredential(testRPID, testOrigin,
+		options.Challenge, testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified))
+	assert.NoError(t, err)
+
+	storedCredential, err := setup.waService.FinishRegistration(context.Background(), setup.testUser.ID.Hex(), "My key", *credential)
+	assert.NoError(t, err)
+
+	return storedCredential
+}
+
+// assert makes an assertion with the setup's authenticator for a login challenge issued for the given user
+func (setup *webAuthnTestSetup) assert(t *testing.T, userID string, flags byte) utils.WebAuthnCredentialResponse {
+	options, err := setup.waService.BeginLogin(context.Background(), userID)
+	assert.NoError(t, err)
+
+	credential, err := utils.ParseWebAuthnCredentialResponse(setup.authenticator.GetAssertion(testRPID, testOrigin,
+		options.Challenge, flags, setup.testUser.ID[:]))
+	assert.NoError(t, err)
+
+	return *credential
+}
+
+func Test_NewMongoWebAuthnService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoWebAuthnService(nil, nil, nil, nil, nil, nil))
+}
+
+func Test_BeginRegistration__should_exclude_registered_credentials(t *testing.T) {
+	setup := setupWebAuthnTest(t)
+	defer setup.cleanup()
+	setup.register(t)
+
+	options, err := setup.waService.BeginRegistration(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+
+	assert.Equal(t, testRPID, options.RP.ID)
+	assert.Equal(t, []byte(setup.testUser.ID[:]), options.User.ID)
+	assert.Len(t, options.ExcludeCredentials, 1)
+	assert.Equal(t, setup.authenticator.CredentialID, []byte(options.ExcludeCredentials[0].ID))
+}
+
+func Test_FinishRegistration__should_store_credential(t *testing.T) {
+	setup := setupWebAuthnTest(t)
+	defer setup.cleanup()
+
+	storedCredential := setup.register(t)
+	assert.Equal(t, "My key", storedCredential.Name)
+	assert.Equal(t, setup.authenticator.EncodedCredentialID(), storedCredential.CredentialID)
+
+	credentials, err := setup.waService.GetCredentialsForUser(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, credentials, 1)
+	assert.Equal(t, storedCredential.ID, credentials[0].ID)
+	assert.NotEmpty(t, credentials[0].PublicKey)
+}
+
+func Test_FinishRegistration__should_return_error(t *testing.T) {
+	tests := []struct {
+		name        string
+		makeCred    func(t *testing.T, setup *webAuthnTestSetup, challenge []byte) string
+		expectedErr error
+	}{
+		{
+			name: "ErrInvalidWebAuthnCredential when origin is unexpected",
+			makeCred: func(t *testing.T, setup *webAuthnTestSetup, challenge []byte) string {
+				return setup.authenticator.MakeCredential(testRPID, "https://evil.com", challenge, testutils.WebAuthnFlagUserPresent)
+			},
+			expectedErr: services.ErrInvalidWebAuthnCredential,
+		},
+		{
+			name: "ErrInvalidWebAuthnCredential when rp id is unexpected",
+			makeCred: func(t *testing.T, setup *webAuthnTestSetup, challenge []byte) string {
+				return setup.authenticator.MakeCredential("evil.com", testOrigin, challenge, testutils.WebAuthnFlagUserPresent)
+			},
+			expectedErr: services.ErrInvalidWebAuthnCredential,
+		},
+		{
+			name: "ErrInvalidWebAuthnCredential when challenge is unknown",
+			makeCred: func(t *testing.T, setup *webAuthnTestSetup, challenge []byte) string {
+				return setup.authenticator.MakeCredential(testRPID, testOrigin, []byte("unknown"), testutils.WebAuthnFlagUserPresent)
+			},
+			expectedErr: services.ErrInvalidWebAuthnCredential,
+		},
+		{
+			name: "ErrInvalidWebAuthnCredential when user is not present",
+			makeCred: func(t *testing.T, setup *webAuthnTestSetup, challenge []byte) string {
+				return setup.authenticator.MakeCredential(testRPID, testOrigin, challenge, 0)
+			},
+			expectedErr: services.ErrInvalidWebAuthnCredential,
+		},
+		{
+			name: "ErrWebAuthnCredentialExists when credential is already registered",
+			makeCred: func(t *testing.T, setup *webAuthnTestSetup, challenge []byte) string {
+				setup.register(t)
+				return setup.authenticator.MakeCredential(testRPID, testOrigin, challenge, testutils.WebAuthnFlagUserPresent)
+			},
+			expectedErr: services.ErrWebAuthnCredentialExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebAuthnTest(t)
+			defer setup.cleanup()
+
+			options, err := setup.waService.BeginRegistration(context.Background(), setup.testUser.ID.Hex())
+			assert.NoError(t, err)
+
+			credential, err := utils.ParseWebAuthnCredentialResponse(tt.makeCred(t, setup, options.Challenge))
+			assert.NoError(t, err)
+
+			_, err = setup.waService.FinishRegistration(context.Background(), setup.testUser.ID.Hex(), "", *credential)
+			assert.Equal(t, tt.expectedErr, errors.Cause(err))
+		})
+	}
+}
+
+func Test_FinishLogin__should_return_user_and_update_sign_count(t *testing.T) {
+	setup := setupWebAuthnTest(t)
+	defer setup.cleanup()
+	setup.register(t)
+
+	credential := setup.assert(t, "", testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified)
+
+	user, err := setup.waService.FinishLogin(context.Background(), "", credential)
+	assert.NoError(t, err)
+	assert.Equal(t, setup.testUser.ID, user.ID)
+
+	credentials, err := setup.waService.GetCredentialsForUser(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(setup.authenticator.SignCount), credentials[0].SignCount)
+	assert.Equal(t, testWebAuthnTime.Unix(), credentials[0].LastUsedAt.Unix())
+}
+
+func Test_FinishLogin__should_accept_unverified_user_as_second_factor(t *testing.T) {
+	setup := setupWebAuthnTest(t)
+	defer setup.cleanup()
+	setup.register(t)
+
+	credential := setup.assert(t, setup.testUser.ID.Hex(), testutils.WebAuthnFlagUserPresent)
+
+	user, err := setup.waService.FinishLogin(context.Background(), setup.testUser.ID.Hex(), credential)
+	assert.NoError(t, err)
+	assert.Equal(t, setup.testUser.ID, user.ID)
+}
+
+func Test_FinishLogin__should_return_error(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID func(setup *webAuthnTestSetup) string
+		prep   func(t *testing.T, setup *webAuthnTestSetup) utils.WebAuthnCredentialResponse
+	}{
+		{
+			name: "when user is not verified without a password",
+			prep: func(t *testing.T, setup *webAuthnTestSetup) utils.WebAuthnCredentialResponse {
+				return setup.assert(t, "", testutils.WebAuthnFlagUserPresent)
+			},
+		},
+		{
+			name: "when challenge is used twice",
+			prep: func(t *testing.T, setup *webAuthnTestSetup) utils.WebAuthnCredentialResponse {
+				credential := setup.assert(t, "", testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified)
+				_, err := setup.waService.FinishLogin(context.Background(), "", credential)
+				assert.NoError(t, err)
+				return credential
+			},
+		},
+		{
+			name: "when sign count did not increase",
+			prep: func(t *testing.T, setup *webAuthnTestSetup) utils.WebAuthnCredentialResponse {
+				credential := setup.assert(t, "", testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified)
+				_, err := setup.waService.FinishLogin(context.Background(), "", credential)
+				assert.NoError(t, err)
+				setup.authenticator.SignCount--
+				return setup.assert(t, "", testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified)
+			},
+		},
+		{
+			name: "when credential belongs to another user",
+			userID: func(setup *webAuthnTestSetup) string {
+				return primitive.NewObjectID().Hex()
+			},
+			prep: func(t *testing.T, setup *webAuthnTestSetup) utils.WebAuthnCredentialResponse {
+				return setup.assert(t, "", testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified)
+			},
+		},
+		{
+			name: "when credential is not registered",
+			prep: func(t *testing.T, setup *webAuthnTestSetup) utils.WebAuthnCredentialResponse {
+				setup.authenticator = testutils.NewWebAuthnAuthenticator()
+				return setup.assert(t, "", testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebAuthnTest(t)
+			defer setup.cleanup()
+			setup.register(t)
+
+			credential := tt.prep(t, setup)
+			userID := ""
+			if tt.userID != nil {
+				userID = tt.userID(setup)
+			}
+
+			_, err := setup.waService.FinishLogin(context.Background(), userID, credential)
+			assert.Equal(t, services.ErrInvalidWebAuthnCredential, errors.Cause(err))
+		})
+	}
+}
+
+func Test_DeleteCredentialForUser__should_delete_credential(t *testing.T) {
+	setup := setupWebAuthnTest(t)
+	defer setup.cleanup()
+	storedCredential := setup.register(t)
+
+	err := setup.waService.DeleteCredentialForUser(context.Background(), setup.testUser.ID.Hex(), storedCredential.ID.Hex())
+	assert.NoError(t, err)
+
+	credentials, err := setup.waService.GetCredentialsForUser(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Empty(t, credentials)
+}
+
+func Test_DeleteCredentialsForUser__should_delete_all_credentials_of_user(t *testing.T) {
+	setup := setupWebAuthnTest(t)
+	defer setup.cleanup()
+	setup.register(t)
+
+	err := setup.waService.DeleteCredentialsForUser(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+
+	credentials, err := setup.waService.GetCredentialsForUser(context.Background(), setup.testUser.ID.Hex())
+	assert.NoError(t, err)
+	assert.Empty(t, credentials)
+
+	assert.Equal(t, services.ErrInvalidID, setup.waService.DeleteCredentialsForUser(context.Background(), "invalid"))
+}
+
+func Test_DeleteCredentialForUser__should_return_error(t *testing.T) {
+	setup := setupWebAuthnTest(t)
+	defer setup.cleanup()
+	storedCredential := setup.register(t)
+
+	assert.Equal(t, services.ErrInvalidID, setup.waService.DeleteCredentialForUser(context.Background(), "invalid", storedCredential.ID.Hex()))
+	assert.Equal(t, services.ErrInvalidID, setup.waService.DeleteCredentialForUser(context.Background(), setup.testUser.ID.Hex(), "invalid"))
+	assert.Equal(t, services.ErrNotFound, setup.waService.DeleteCredentialForUser(context.Background(), primitive.NewObjectID().Hex(), storedCredential.ID.Hex()))
+}
@@ -0,0 +1,458 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	// Headers sent with every webhook delivery
+	webhookEventHeader     = "X-HS-Auth-Event"
+	webhookDeliveryHeader  = "X-HS-Auth-Delivery"
+	webhookSignatureHeader = "X-HS-Auth-Signature"
+
+	webhookSecretBytes          = 32
+	deliveryBatchSize           = 50
+	defaultDeliveryInterval     = 10 * time.Second
+	maxWebhookResponseBodyBytes = 1 << 16
+)
+
+// webhookPayload is the body of every webhook delivery
+type webhookPayload struct {
+	ID        string                `json:"id"`
+	Event     entities.WebhookEvent `json:"event"`
+	Timestamp int64                 `json:"timestamp"`
+	Data      interface{}           `json:"data"`
+}
+
+type mongoWebhookService struct {
+	logger                    *zap.Logger
+	cfg                       *config.AppConfig
+	timeProvider              utils.TimeProvider
+	httpClient                utils.HTTPClient
+	webhookRepository         *repositories.WebhookRepository
+	webhookDeliveryRepository *repositories.WebhookDeliveryRepository
+}
+
+// NewMongoWebhookService creates a new WebhookService that uses MongoDB as the storage technology
+// for webhook subscriptions and as the queue for their deliveries
+func NewMongoWebhookService(logger *zap.Logger, cfg *config.AppConfig, timeProvider utils.TimeProvider, httpClient utils.HTTPClient,
+	webhookRepository *repositories.WebhookRepository, webhookDeliveryRepository *repositories.WebhookDeliveryRepository) services.WebhookService {
+	return &mongoWebhookService{
+		logger:                    logger,
+		cfg:                       cfg,
+		timeProvider:              timeProvider,
+		httpClient:                httpClient,
+		webhookRepository:         webhookRepository,
+		webhookDeliveryRepository: webhookDeliveryRepository,
+	}
+}
+
+func (s *mongoWebhookService) CreateWebhook(ctx context.Context, webhookURL string, events []entities.WebhookEvent) (*entities.Webhook, error) {
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || len(parsedURL.Host) == 0 {
+		return nil, services.ErrInvalidWebhookURL
+	}
+
+	if len(events) == 0 {
+		return nil, services.ErrInvalidWebhookEvent
+	}
+	uniqueEvents := make([]entities.WebhookEvent, 0, len(events))
+	seenEvents := map[entities.WebhookEvent]bool{}
+	for _, event := range events {
+		if !entities.IsKnownWebhookEvent(event) {
+			return nil, errors.Wrap(services.ErrInvalidWebhookEvent, string(event))
+		}
+		if !seenEvents[event] {
+			seenEvents[event] = true
+			uniqueEvents = append(uniqueEvents, event)
+		}
+	}
+
+	secret, err := utils.GenerateRandomHexString(webhookSecretBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate webhook secret")
+	}
+
+	webhook := &entities.Webhook{
+		ID:        primitive.NewObjectID(),
+		URL:       webhookURL,
+		Secret:    secret,
+		Events:    uniqueEvents,
+		CreatedAt: s.timeProvider.Now(),
+	}
+
+	_, err = s.webhookRepository.InsertOne(ctx, *webhook)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create new webhook")
+	}
+
+	return webhook, nil
+}
+
+func (s *mongoWebhookService) GetWebhooks(ctx context.Context) ([]entities.Webhook, error) {
+	cur, err := s.webhookRepository.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for webhooks")
+	}
+	defer cur.Close(ctx)
+
+	webhooks, err := decodeWebhooksResult(ctx, cur)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode result")
+	}
+
+	return webhooks, nil
+}
+
+func (s *mongoWebhookService) GetWebhookWithID(ctx context.Context, id string) (*entities.Webhook, error) {
+	mongoID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	res := s.webhookRepository.FindOne(ctx, bson.M{
+		string(entities.WebhookID): mongoID,
+	})
+
+	err = res.Err()
+	if err == mongo.ErrNoDocuments {
+		return nil, services.ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not query for webhook with ID")
+	}
+
+	var webhook entities.Webhook
+	err = res.Decode(&webhook)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode webhook")
+	}
+
+	return &webhook, nil
+}
+
+func (s *mongoWebhookService) DeleteWebhookWithID(ctx context.Context, id string) error {
+	mongoID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return services.ErrInvalidID
+	}
+
+	res, err := s.webhookRepository.DeleteOne(ctx, bson.M{
+		string(entities.WebhookID): mongoID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not delete webhook with ID")
+	} else if res.DeletedCount == 0 {
+		return services.ErrNotFound
+	}
+
+	// the webhook's deliveries are kept as part of the delivery log, but should not be retried
+	_, err = s.webhookDeliveryRepository.UpdateMany(ctx, bson.M{
+		string(entities.WebhookDeliveryWebhookID): mongoID,
+		string(entities.WebhookDeliveryStatus):    entities.WebhookDeliveryPending,
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.WebhookDeliveryStatus):    entities.WebhookDeliveryFailed,
+			string(entities.WebhookDeliveryLastError): "webhook was deleted",
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not cancel pending deliveries of webhook")
+	}
+
+	return nil
+}
+
+func (s *mongoWebhookService) GetDeliveriesForWebhookWithID(ctx context.Context, id string, limit int64) ([]entities.WebhookDelivery, error) {
+	mongoID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, services.ErrInvalidID
+	}
+
+	findOptions := options.Find().SetSort(bson.M{string(entities.WebhookDeliveryCreatedAt): -1})
+	if limit > 0 {
+		findOptions.SetLimit(limit)
+	}
+
+	cur, err := s.webhookDeliveryRepository.Find(ctx, bson.M{
+		string(entities.WebhookDeliveryWebhookID): mongoID,
+	}, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query for webhook deliveries")
+	}
+	defer cur.Close(ctx)
+
+	deliveries := []entities.WebhookDelivery{}
+	for cur.Next(ctx) {
+		var delivery entities.WebhookDelivery
+		err := cur.Decode(&delivery)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode webhook delivery")
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (s *mongoWebhookService) EmitEvent(ctx context.Context, event entities.WebhookEvent, data interface{}) error {
+	cur, err := s.webhookRepository.Find(ctx, bson.M{
+		string(entities.WebhookEvents): event,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not query for webhooks subscribed to event")
+	}
+	defer cur.Close(ctx)
+
+	webhooks, err := decodeWebhooksResult(ctx, cur)
+	if err != nil {
+		return errors.Wrap(err, "could not decode result")
+	}
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := s.timeProvider.Now()
+	payload, err := json.Marshal(webhookPayload{
+		ID:        primitive.NewObjectID().Hex(),
+		Event:     event,
+		Timestamp: now.Unix(),
+		Data:      data,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not marshal webhook payload")
+	}
+
+	deliveries := make([]interface{}, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, entities.WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        entities.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	_, err = s.webhookDeliveryRepository.InsertMany(ctx, deliveries)
+	if err != nil {
+		return errors.Wrap(err, "could not queue webhook deliveries")
+	}
+
+	return nil
+}
+
+func (s *mongoWebhookService) ProcessPendingDeliveries(ctx context.Context) (int, error) {
+	attempted := 0
+	for attempted < deliveryBatchSize {
+		delivery, err := s.claimNextPendingDelivery(ctx)
+		if errors.Cause(err) == mongo.ErrNoDocuments {
+			break
+		} else if err != nil {
+			return attempted, errors.Wrap(err, "could not claim pending delivery")
+		}
+
+		attempted++
+		err = s.attemptDelivery(ctx, *delivery)
+		if err != nil {
+			return attempted, err
+		}
+	}
+
+	return attempted, nil
+}
+
+func (s *mongoWebhookService) RunDeliveryWorker(ctx context.Context) {
+	interval := time.Duration(s.cfg.Webhooks.DeliveryInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultDeliveryInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := s.ProcessPendingDeliveries(ctx)
+			if err != nil {
+				s.logger.Error("could not process pending webhook deliveries", zap.Error(err))
+			}
+		}
+	}
+}
+
+// claimNextPendingDelivery pushes back the next attempt of the oldest due delivery so that it will
+// not be picked up again while it is being sent. If the worker dies before the delivery's outcome is
+// recorded, the delivery will be retried once the lease runs out.
+func (s *mongoWebhookService) claimNextPendingDelivery(ctx context.Context) (*entities.WebhookDelivery, error) {
+	now := s.timeProvider.Now()
+	lease := 2 * s.deliveryTimeout()
+	if lease <= 0 {
+		lease = 2 * defaultDeliveryInterval
+	}
+
+	res := s.webhookDeliveryRepository.FindOneAndUpdate(ctx, bson.M{
+		string(entities.WebhookDeliveryStatus):        entities.WebhookDeliveryPending,
+		string(entities.WebhookDeliveryNextAttemptAt): bson.M{"$lte": now},
+	}, bson.M{
+		"$set": bson.M{
+			string(entities.WebhookDeliveryNextAttemptAt): now.Add(lease),
+		},
+	}, options.FindOneAndUpdate().SetSort(bson.M{string(entities.WebhookDeliveryNextAttemptAt): 1}))
+
+	err := res.Err()
+	if err != nil {
+		return nil, errors.Wrap(err, "query returned error")
+	}
+
+	var delivery entities.WebhookDelivery
+	err = res.Decode(&delivery)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode webhook delivery")
+	}
+
+	return &delivery, nil
+}
+
+// attemptDelivery sends the delivery to its webhook and records the outcome.
+// Returns an error only if the outcome could not be recorded.
+func (s *mongoWebhookService) attemptDelivery(ctx context.Context, delivery entities.WebhookDelivery) error {
+	var statusCode int
+	retry := true
+	webhook, err := s.GetWebhookWithID(ctx, delivery.WebhookID.Hex())
+	if err == services.ErrNotFound {
+		err = errors.New("webhook was deleted")
+		retry = false
+	} else if err != nil {
+		return errors.Wrap(err, "could not fetch webhook of delivery")
+	} else {
+		statusCode, err = s.send(ctx, *webhook, delivery)
+	}
+
+	now := s.timeProvider.Now()
+	attempts := delivery.Attempts + 1
+	update := bson.M{
+		string(entities.WebhookDeliveryAttempts):       attempts,
+		string(entities.WebhookDeliveryLastStatusCode): statusCode,
+	}
+
+	if err == nil {
+		update[string(entities.WebhookDeliveryStatus)] = entities.WebhookDeliveryDelivered
+		update[string(entities.WebhookDeliveredAt)] = now
+		update[string(entities.WebhookDeliveryLastError)] = ""
+	} else {
+		s.logger.Debug("webhook delivery failed", zap.String("delivery", delivery.ID.Hex()),
+			zap.Int("attempt", attempts), zap.Error(err))
+		update[string(entities.WebhookDeliveryLastError)] = err.Error()
+		if !retry || attempts >= s.cfg.Webhooks.MaxDeliveryAttempts {
+			update[string(entities.WebhookDeliveryStatus)] = entities.WebhookDeliveryFailed
+		} else {
+			update[string(entities.WebhookDeliveryNextAttemptAt)] = now.Add(s.retryBackoff(attempts))
+		}
+	}
+
+	_, err = s.webhookDeliveryRepository.UpdateOne(ctx, bson.M{
+		string(entities.WebhookDeliveryID): delivery.ID,
+	}, bson.M{
+		"$set": update,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not record outcome of webhook delivery")
+	}
+
+	return nil
+}
+
+// send posts the delivery's payload to the webhook's URL, signed with the webhook's secret.
+// Any response other than 2xx is treated as a failed delivery.
+func (s *mongoWebhookService) send(ctx context.Context, webhook entities.Webhook, delivery entities.WebhookDelivery) (int, error) {
+	if timeout := s.deliveryTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "could not create request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, string(delivery.Event))
+	req.Header.Set(webhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(webhookSignatureHeader, "sha256="+utils.GetHMACSHA256(webhook.Secret, []byte(delivery.Payload)))
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not send request")
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxWebhookResponseBodyBytes))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+func (s *mongoWebhookService) deliveryTimeout() time.Duration {
+	return time.Duration(s.cfg.Webhooks.DeliveryTimeout) * time.Second
+}
+
+// retryBackoff returns the delay before the next attempt of a delivery, doubling with every failed attempt
+func (s *mongoWebhookService) retryBackoff(attempts int) time.Duration {
+	backoff := time.Duration(s.cfg.Webhooks.RetryBackoff) * time.Second
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+	}
+	return backoff
+}
+
+// emitWebhookEvent queues the event for delivery to the subscribed webhooks. Failing to queue the event
+// should not fail the operation that caused it, so errors are only logged.
+func emitWebhookEvent(ctx context.Context, logger *zap.Logger, webhookService services.WebhookService, event entities.WebhookEvent, data interface{}) {
+	err := webhookService.EmitEvent(ctx, event, data)
+	if err != nil {
+		logger.Error("could not emit webhook event", zap.String("event", string(event)), zap.Error(err))
+	}
+}
+
+func decodeWebhooksResult(ctx context.Context, cur *mongo.Cursor) ([]entities.Webhook, error) {
+	webhooks := []entities.Webhook{}
+	for cur.Next(ctx) {
+		var webhook entities.Webhook
+		err := cur.Decode(&webhook)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode webhook")
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
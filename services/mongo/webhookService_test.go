@@ -0,0 +1,375 @@
+// +build integration
+
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	mock_utils "github.com/unicsmcr/hs_auth/mocks/utils"
+	"github.com/unicsmcr/hs_auth/repositories"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var testWebhookTime = time.Unix(1000, 0)
+
+type webhookTestSetup struct {
+	ctrl             *gomock.Controller
+	wService         *mongoWebhookService
+	wRepo            *repositories.WebhookRepository
+	dRepo            *repositories.WebhookDeliveryRepository
+	mockTimeProvider *mock_utils.MockTimeProvider
+	cleanup          func()
+}
+
+func setupWebhookTest(t *testing.T) *webhookTestSetup {
+	db := testutils.ConnectToIntegrationTestDB(t)
+
+	wRepo, err := repositories.NewWebhookRepository(db)
+	if err != nil {
+		panic(err)
+	}
+	dRepo, err := repositories.NewWebhookDeliveryRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	mockTimeProvider := mock_utils.NewMockTimeProvider(ctrl)
+	mockTimeProvider.EXPECT().Now().Return(testWebhookTime).AnyTimes()
+
+	wService := &mongoWebhookService{
+		logger: zap.NewNop(),
+		cfg: &config.AppConfig{
+			Webhooks: config.WebhookConfig{
+				DeliveryTimeout:     5,
+				MaxDeliveryAttempts: 3,
+				RetryBackoff:        10,
+			},
+		},
+		timeProvider:              mockTimeProvider,
+		httpClient:                utils.NewHTTPClient(),
+		webhookRepository:         wRepo,
+		webhookDeliveryRepository: dRepo,
+	}
+
+	return &webhookTestSetup{
+		ctrl:             ctrl,
+		wService:         wService,
+		wRepo:            wRepo,
+		dRepo:            dRepo,
+		mockTimeProvider: mockTimeProvider,
+		cleanup: func() {
+			ctrl.Finish()
+			wRepo.Drop(context.Background())
+			dRepo.Drop(context.Background())
+		},
+	}
+}
+
+func (setup *webhookTestSetup) insertWebhook(t *testing.T, url string, events ...entities.WebhookEvent) entities.Webhook {
+	webhook := entities.Webhook{
+		ID:     primitive.NewObjectID(),
+		URL:    url,
+		Secret: "supersecret",
+		Events: events,
+	}
+	_, err := setup.wRepo.InsertOne(context.Background(), webhook)
+	assert.NoError(t, err)
+	return webhook
+}
+
+func (setup *webhookTestSetup) getDeliveries(t *testing.T) []entities.WebhookDelivery {
+	cur, err := setup.dRepo.Find(context.Background(), bson.M{})
+	assert.NoError(t, err)
+	defer cur.Close(context.Background())
+
+	var deliveries []entities.WebhookDelivery
+	for cur.Next(context.Background()) {
+		var delivery entities.WebhookDelivery
+		assert.NoError(t, cur.Decode(&delivery))
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+func Test_NewMongoWebhookService__should_return_non_nil_object(t *testing.T) {
+	assert.NotNil(t, NewMongoWebhookService(nil, nil, nil, nil, nil, nil))
+}
+
+func Test_CreateWebhook__should_return_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		events  []entities.WebhookEvent
+		wantErr error
+	}{
+		{
+			name:    "when url is not absolute",
+			url:     "/webhooks",
+			events:  []entities.WebhookEvent{entities.WebhookEventUserRegistered},
+			wantErr: services.ErrInvalidWebhookURL,
+		},
+		{
+			name:    "when url is not http",
+			url:     "ftp://hs_apply.unicsmcr.com",
+			events:  []entities.WebhookEvent{entities.WebhookEventUserRegistered},
+			wantErr: services.ErrInvalidWebhookURL,
+		},
+		{
+			name:    "when no events are given",
+			url:     "https://hs_apply.unicsmcr.com",
+			wantErr: services.ErrInvalidWebhookEvent,
+		},
+		{
+			name:    "when event is unknown",
+			url:     "https://hs_apply.unicsmcr.com",
+			events:  []entities.WebhookEvent{"user.deleted"},
+			wantErr: services.ErrInvalidWebhookEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupWebhookTest(t)
+			defer setup.cleanup()
+
+			webhook, err := setup.wService.CreateWebhook(context.Background(), tt.url, tt.events)
+
+			assert.Nil(t, webhook)
+			assert.Equal(t, tt.wantErr, errors.Cause(err))
+		})
+	}
+}
+
+func Test_CreateWebhook__should_create_webhook_with_secret_and_unique_events(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	webhook, err := setup.wService.CreateWebhook(context.Background(), "https://hs_apply.unicsmcr.com", []entities.WebhookEvent{
+		entities.WebhookEventUserRegistered, entities.WebhookEventTeamCreated, entities.WebhookEventUserRegistered,
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, webhook.Secret, 2*webhookSecretBytes)
+	assert.Equal(t, []entities.WebhookEvent{entities.WebhookEventUserRegistered, entities.WebhookEventTeamCreated}, webhook.Events)
+
+	storedWebhook, err := setup.wService.GetWebhookWithID(context.Background(), webhook.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, webhook.Secret, storedWebhook.Secret)
+	assert.Equal(t, webhook.Events, storedWebhook.Events)
+}
+
+func Test_GetWebhookWithID__should_return_error(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.wService.GetWebhookWithID(context.Background(), "invalid")
+	assert.Equal(t, services.ErrInvalidID, err)
+
+	_, err = setup.wService.GetWebhookWithID(context.Background(), primitive.NewObjectID().Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_DeleteWebhookWithID__should_return_ErrNotFound_when_webhook_doesnt_exist(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	err := setup.wService.DeleteWebhookWithID(context.Background(), primitive.NewObjectID().Hex())
+	assert.Equal(t, services.ErrNotFound, err)
+}
+
+func Test_DeleteWebhookWithID__should_delete_webhook_and_cancel_its_pending_deliveries(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	webhook := setup.insertWebhook(t, "https://hs_apply.unicsmcr.com", entities.WebhookEventUserRegistered)
+	assert.NoError(t, setup.wService.EmitEvent(context.Background(), entities.WebhookEventUserRegistered, nil))
+
+	err := setup.wService.DeleteWebhookWithID(context.Background(), webhook.ID.Hex())
+	assert.NoError(t, err)
+
+	webhooks, err := setup.wService.GetWebhooks(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, webhooks)
+
+	deliveries := setup.getDeliveries(t)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, entities.WebhookDeliveryFailed, deliveries[0].Status)
+}
+
+func Test_EmitEvent__should_queue_delivery_for_every_subscribed_webhook(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	subscribedWebhook := setup.insertWebhook(t, "https://hs_apply.unicsmcr.com", entities.WebhookEventUserRegistered, entities.WebhookEventTeamCreated)
+	setup.insertWebhook(t, "https://hs_discord.unicsmcr.com", entities.WebhookEventTeamCreated)
+
+	err := setup.wService.EmitEvent(context.Background(), entities.WebhookEventUserRegistered, map[string]string{"name": "Bob"})
+	assert.NoError(t, err)
+
+	deliveries := setup.getDeliveries(t)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, subscribedWebhook.ID, deliveries[0].WebhookID)
+	assert.Equal(t, entities.WebhookDeliveryPending, deliveries[0].Status)
+	assert.Equal(t, entities.WebhookEventUserRegistered, deliveries[0].Event)
+
+	var payload struct {
+		Event     entities.WebhookEvent `json:"event"`
+		Timestamp int64                 `json:"timestamp"`
+		Data      map[string]string     `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+	assert.Equal(t, entities.WebhookEventUserRegistered, payload.Event)
+	assert.Equal(t, testWebhookTime.Unix(), payload.Timestamp)
+	assert.Equal(t, map[string]string{"name": "Bob"}, payload.Data)
+}
+
+func Test_ProcessPendingDeliveries__should_send_signed_delivery(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	var receivedReq *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedReq = r
+		receivedBody, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	setup.insertWebhook(t, server.URL, entities.WebhookEventTeamCreated)
+	assert.NoError(t, setup.wService.EmitEvent(context.Background(), entities.WebhookEventTeamCreated, nil))
+
+	attempted, err := setup.wService.ProcessPendingDeliveries(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+
+	deliveries := setup.getDeliveries(t)
+	assert.Equal(t, entities.WebhookDeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusOK, deliveries[0].LastStatusCode)
+
+	assert.Equal(t, deliveries[0].Payload, string(receivedBody))
+	assert.Equal(t, string(entities.WebhookEventTeamCreated), receivedReq.Header.Get(webhookEventHeader))
+	assert.Equal(t, deliveries[0].ID.Hex(), receivedReq.Header.Get(webhookDeliveryHeader))
+	assert.Equal(t, "sha256="+utils.GetHMACSHA256("supersecret", receivedBody), receivedReq.Header.Get(webhookSignatureHeader))
+}
+
+func Test_ProcessPendingDeliveries__should_reschedule_delivery_when_webhook_responds_with_error(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	setup.insertWebhook(t, server.URL, entities.WebhookEventTeamCreated)
+	assert.NoError(t, setup.wService.EmitEvent(context.Background(), entities.WebhookEventTeamCreated, nil))
+
+	_, err := setup.wService.ProcessPendingDeliveries(context.Background())
+	assert.NoError(t, err)
+
+	deliveries := setup.getDeliveries(t)
+	assert.Equal(t, entities.WebhookDeliveryPending, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].LastStatusCode)
+	assert.Equal(t, testWebhookTime.Add(10*time.Second).Unix(), deliveries[0].NextAttemptAt.Unix())
+
+	// the delivery is not due yet, so it should not be attempted again
+	attempted, err := setup.wService.ProcessPendingDeliveries(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, attempted)
+}
+
+func Test_ProcessPendingDeliveries__should_fail_delivery_after_max_attempts(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := setup.insertWebhook(t, server.URL, entities.WebhookEventTeamCreated)
+	_, err := setup.dRepo.InsertOne(context.Background(), entities.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     webhook.ID,
+		Event:         entities.WebhookEventTeamCreated,
+		Payload:       "{}",
+		Status:        entities.WebhookDeliveryPending,
+		Attempts:      2,
+		NextAttemptAt: testWebhookTime,
+	})
+	assert.NoError(t, err)
+
+	_, err = setup.wService.ProcessPendingDeliveries(context.Background())
+	assert.NoError(t, err)
+
+	deliveries := setup.getDeliveries(t)
+	assert.Equal(t, entities.WebhookDeliveryFailed, deliveries[0].Status)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+}
+
+func Test_ProcessPendingDeliveries__should_fail_delivery_when_webhook_was_deleted(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	_, err := setup.dRepo.InsertOne(context.Background(), entities.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     primitive.NewObjectID(),
+		Event:         entities.WebhookEventTeamCreated,
+		Payload:       "{}",
+		Status:        entities.WebhookDeliveryPending,
+		NextAttemptAt: testWebhookTime,
+	})
+	assert.NoError(t, err)
+
+	_, err = setup.wService.ProcessPendingDeliveries(context.Background())
+	assert.NoError(t, err)
+
+	deliveries := setup.getDeliveries(t)
+	assert.Equal(t, entities.WebhookDeliveryFailed, deliveries[0].Status)
+	assert.Equal(t, "webhook was deleted", deliveries[0].LastError)
+}
+
+func Test_GetDeliveriesForWebhookWithID__should_return_newest_deliveries_first(t *testing.T) {
+	setup := setupWebhookTest(t)
+	defer setup.cleanup()
+
+	webhookID := primitive.NewObjectID()
+	olderDelivery := entities.WebhookDelivery{ID: primitive.NewObjectID(), WebhookID: webhookID, CreatedAt: time.Unix(100, 0)}
+	newerDelivery := entities.WebhookDelivery{ID: primitive.NewObjectID(), WebhookID: webhookID, CreatedAt: time.Unix(200, 0)}
+	otherDelivery := entities.WebhookDelivery{ID: primitive.NewObjectID(), WebhookID: primitive.NewObjectID(), CreatedAt: time.Unix(300, 0)}
+	_, err := setup.dRepo.InsertMany(context.Background(), []interface{}{olderDelivery, newerDelivery, otherDelivery})
+	assert.NoError(t, err)
+
+	deliveries, err := setup.wService.GetDeliveriesForWebhookWithID(context.Background(), webhookID.Hex(), 0)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, newerDelivery.ID, deliveries[0].ID)
+	assert.Equal(t, olderDelivery.ID, deliveries[1].ID)
+
+	deliveries, err = setup.wService.GetDeliveriesForWebhookWithID(context.Background(), webhookID.Hex(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	_, err = setup.wService.GetDeliveriesForWebhookWithID(context.Background(), "invalid", 0)
+	assert.Equal(t, services.ErrInvalidID, err)
+}
@@ -0,0 +1,183 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/environment"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.uber.org/zap"
+)
+
+type genericProvider struct {
+	logger       *zap.Logger
+	cfg          config.OAuthProviderConfig
+	clientSecret string
+	redirectURL  string
+	httpClient   utils.HTTPClient
+}
+
+// NewOAuthProviders creates the OAuth providers configured in the app config.
+// The providers follow the OAuth2 authorization code flow and fetch the user's account
+// from the provider's user info endpoint.
+func NewOAuthProviders(logger *zap.Logger, cfg *config.AppConfig, env *environment.Env, httpClient utils.HTTPClient) []services.OAuthProvider {
+	scheme := "http"
+	if cfg.UseSecureCookies {
+		scheme = "https"
+	}
+
+	providers := make([]services.OAuthProvider, len(cfg.OAuthProviders))
+	for i, providerCfg := range cfg.OAuthProviders {
+		providers[i] = &genericProvider{
+			logger:       logger,
+			cfg:          providerCfg,
+			clientSecret: env.Get(environment.OAuthClientSecret(providerCfg.Name)),
+			redirectURL:  fmt.Sprintf("%s://%s/login/oauth/%s/callback", scheme, cfg.AppURL, providerCfg.Name),
+			httpClient:   httpClient,
+		}
+	}
+
+	return providers
+}
+
+func (p *genericProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *genericProvider) DisplayName() string {
+	if len(p.cfg.DisplayName) == 0 {
+		return p.cfg.Name
+	}
+	return p.cfg.DisplayName
+}
+
+func (p *genericProvider) AuthCodeURL(state string) string {
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.cfg.ClientID},
+		"redirect_uri":  {p.redirectURL},
+		"scope":         {strings.Join(p.cfg.Scopes, " ")},
+		"state":         {state},
+	}
+
+	separator := "?"
+	if strings.Contains(p.cfg.AuthURL, "?") {
+		separator = "&"
+	}
+
+	return p.cfg.AuthURL + separator + params.Encode()
+}
+
+func (p *genericProvider) Authenticate(ctx context.Context, code string) (*services.ExternalUser, error) {
+	accessToken, err := p.exchangeCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo, err := p.getUserInfo(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	externalUser := &services.ExternalUser{
+		ID:    stringField(userInfo, p.cfg.IDField),
+		Email: stringField(userInfo, p.cfg.EmailField),
+		Name:  stringField(userInfo, p.cfg.NameField),
+	}
+	if len(externalUser.ID) == 0 {
+		return nil, errors.Errorf("user info returned by %s has no field %s", p.cfg.Name, p.cfg.IDField)
+	}
+
+	return externalUser, nil
+}
+
+func (p *genericProvider) exchangeCode(ctx context.Context, code string) (string, error) {
+	params := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.clientSecret},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.cfg.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return "", errors.Wrap(err, "could not create token request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	statusCode, err := p.doJSONRequest(req, &tokenResponse)
+	if err != nil {
+		return "", errors.Wrap(err, "could not exchange authorization code")
+	}
+	// some providers (e.g. GitHub) report rejected codes with status code 200
+	if statusCode == http.StatusBadRequest || statusCode == http.StatusUnauthorized ||
+		len(tokenResponse.Error) > 0 || len(tokenResponse.AccessToken) == 0 {
+		p.logger.Debug("authorization code rejected", zap.String("provider", p.cfg.Name),
+			zap.Int("status code", statusCode), zap.String("error", tokenResponse.Error))
+		return "", services.ErrInvalidOAuthCode
+	} else if statusCode != http.StatusOK {
+		return "", errors.Errorf("token endpoint of %s returned status code %d", p.cfg.Name, statusCode)
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+func (p *genericProvider) getUserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequest(http.MethodGet, p.cfg.UserInfoURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create user info request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var userInfo map[string]interface{}
+	statusCode, err := p.doJSONRequest(req, &userInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch user info")
+	} else if statusCode != http.StatusOK {
+		return nil, errors.Errorf("user info endpoint of %s returned status code %d", p.cfg.Name, statusCode)
+	}
+
+	return userInfo, nil
+}
+
+func (p *genericProvider) doJSONRequest(req *http.Request, out interface{}) (int, error) {
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	decoder := json.NewDecoder(res.Body)
+	// stops numeric ids from being formatted as floats
+	decoder.UseNumber()
+	err = decoder.Decode(out)
+	if err != nil && res.StatusCode == http.StatusOK {
+		return res.StatusCode, errors.Wrap(err, "could not decode response")
+	}
+
+	return res.StatusCode, nil
+}
+
+func stringField(object map[string]interface{}, field string) string {
+	value, ok := object[field]
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
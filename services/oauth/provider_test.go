@@ -0,0 +1,107 @@
+package oauth
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/environment"
+	"github.com/unicsmcr/hs_auth/services"
+	"github.com/unicsmcr/hs_auth/testutils"
+	"github.com/unicsmcr/hs_auth/utils"
+	"go.uber.org/zap"
+)
+
+func setupTest(t *testing.T, fakeProvider *testutils.OAuthProvider) services.OAuthProvider {
+	restoreVars := testutils.SetEnvVars(map[string]string{environment.OAuthClientSecret("test"): fakeProvider.ClientSecret})
+	defer restoreVars()
+
+	cfg := &config.AppConfig{
+		AppURL: "localhost:8000",
+		OAuthProviders: []config.OAuthProviderConfig{
+			{
+				Name:        "test",
+				DisplayName: "Test",
+				ClientID:    fakeProvider.ClientID,
+				AuthURL:     fakeProvider.AuthURL(),
+				TokenURL:    fakeProvider.TokenURL(),
+				UserInfoURL: fakeProvider.UserInfoURL(),
+				Scopes:      []string{"identify", "email"},
+				IDField:     "id",
+				EmailField:  "email",
+				NameField:   "username",
+			},
+		},
+	}
+
+	providers := NewOAuthProviders(zap.NewNop(), cfg, environment.NewEnv(zap.NewNop()), utils.NewHTTPClient())
+	assert.Len(t, providers, 1)
+
+	return providers[0]
+}
+
+func Test_AuthCodeURL__should_return_correct_url(t *testing.T) {
+	fakeProvider := testutils.NewOAuthProvider()
+	defer fakeProvider.Close()
+	provider := setupTest(t, fakeProvider)
+
+	authCodeURL, err := url.Parse(provider.AuthCodeURL("teststate"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, fakeProvider.AuthURL(), authCodeURL.Scheme+"://"+authCodeURL.Host+authCodeURL.Path)
+	assert.Equal(t, url.Values{
+		"response_type": {"code"},
+		"client_id":     {fakeProvider.ClientID},
+		"redirect_uri":  {"http://localhost:8000/login/oauth/test/callback"},
+		"scope":         {"identify email"},
+		"state":         {"teststate"},
+	}, authCodeURL.Query())
+}
+
+func Test_Authenticate__should_return_external_user(t *testing.T) {
+	fakeProvider := testutils.NewOAuthProvider()
+	defer fakeProvider.Close()
+	provider := setupTest(t, fakeProvider)
+
+	fakeProvider.AddCode("testcode", map[string]interface{}{
+		"id":       12345678901,
+		"email":    "john@doe.com",
+		"username": "johndoe",
+	})
+
+	externalUser, err := provider.Authenticate(context.Background(), "testcode")
+	assert.NoError(t, err)
+
+	assert.Equal(t, services.ExternalUser{
+		ID:    "12345678901",
+		Email: "john@doe.com",
+		Name:  "johndoe",
+	}, *externalUser)
+}
+
+func Test_Authenticate__should_return_ErrInvalidOAuthCode_when_code_is_rejected(t *testing.T) {
+	fakeProvider := testutils.NewOAuthProvider()
+	defer fakeProvider.Close()
+	provider := setupTest(t, fakeProvider)
+
+	fakeProvider.AddCode("testcode", map[string]interface{}{"id": "1"})
+	_, err := provider.Authenticate(context.Background(), "testcode")
+	assert.NoError(t, err)
+
+	_, err = provider.Authenticate(context.Background(), "testcode")
+	assert.Equal(t, services.ErrInvalidOAuthCode, errors.Cause(err))
+}
+
+func Test_Authenticate__should_return_err_when_user_info_has_no_id(t *testing.T) {
+	fakeProvider := testutils.NewOAuthProvider()
+	defer fakeProvider.Close()
+	provider := setupTest(t, fakeProvider)
+
+	fakeProvider.AddCode("testcode", map[string]interface{}{"email": "john@doe.com"})
+
+	_, err := provider.Authenticate(context.Background(), "testcode")
+	assert.Error(t, err)
+}
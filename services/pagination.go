@@ -0,0 +1,20 @@
+package services
+
+// Pagination selects a page of a list of objects sorted by SortBy.
+// The page starts after the object the After cursor points to, or at the start of the list if After is empty.
+type Pagination struct {
+	// Limit is the maximum number of objects on the page, 0 returns all remaining objects
+	Limit int64
+	// After is the cursor returned in PageInfo.NextCursor with the previous page
+	After string
+	// SortBy is the name of the field to sort the objects by, defaults to the objects' id (i.e. creation order)
+	SortBy     string
+	Descending bool
+}
+
+// PageInfo describes where the returned page ends
+type PageInfo struct {
+	// NextCursor is the cursor to fetch the next page with, empty when there are no more objects
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
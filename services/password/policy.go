@@ -0,0 +1,136 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/services"
+)
+
+// names of the character classes that can be required in the config
+const (
+	ClassLowercase = "lowercase"
+	ClassUppercase = "uppercase"
+	ClassLetter    = "letter"
+	ClassDigit     = "digit"
+	ClassSymbol    = "symbol"
+)
+
+// minimum length of the parts of a user's name or email that cannot appear in their password,
+// so that e.g. a user called "Al" can still have "always" in their password
+const minPersonalInfoLength = 3
+
+var characterClasses = map[string]func(rune) bool{
+	ClassLowercase: unicode.IsLower,
+	ClassUppercase: unicode.IsUpper,
+	ClassLetter:    unicode.IsLetter,
+	ClassDigit:     unicode.IsDigit,
+	ClassSymbol: func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+	},
+}
+
+type policy struct {
+	cfg config.PasswordPolicyConfig
+	// upper case hex encoded SHA-1 hashes of breached passwords
+	breachedHashes map[string]struct{}
+}
+
+// NewPolicy creates a PasswordPolicy enforcing the password policy in the app config.
+// The hashes of breached passwords are loaded from the configured file once, so that
+// passwords can be checked without sending them anywhere.
+func NewPolicy(cfg *config.AppConfig) (services.PasswordPolicy, error) {
+	p := &policy{
+		cfg:            cfg.Auth.PasswordPolicy,
+		breachedHashes: map[string]struct{}{},
+	}
+
+	for _, class := range p.cfg.RequiredCharacterClasses {
+		if _, ok := characterClasses[class]; !ok {
+			return nil, fmt.Errorf("unknown password character class %s", class)
+		}
+	}
+
+	if len(p.cfg.BreachedPasswordsFile) > 0 {
+		err := p.loadBreachedHashes(p.cfg.BreachedPasswordsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *policy) Validate(password string, user entities.User) error {
+	if len([]rune(password)) < p.cfg.MinLength {
+		return errors.Wrapf(services.ErrPasswordTooShort, "password must be at least %d characters long", p.cfg.MinLength)
+	}
+
+	for _, class := range p.cfg.RequiredCharacterClasses {
+		if strings.IndexFunc(password, characterClasses[class]) < 0 {
+			return errors.Wrapf(services.ErrPasswordMissingCharacterClass, "password has no %s character", class)
+		}
+	}
+
+	if p.cfg.DisallowPersonalInfo && containsPersonalInfo(password, user) {
+		return services.ErrPasswordContainsPersonalInfo
+	}
+
+	if _, breached := p.breachedHashes[hashPassword(password)]; breached {
+		return services.ErrPasswordBreached
+	}
+
+	return nil
+}
+
+func (p *policy) loadBreachedHashes(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "could not open breached passwords file")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// the Pwned Passwords list stores the number of breaches after the hash
+		hash := strings.SplitN(line, ":", 2)[0]
+		p.breachedHashes[strings.ToUpper(hash)] = struct{}{}
+	}
+
+	return errors.Wrap(scanner.Err(), "could not read breached passwords file")
+}
+
+func containsPersonalInfo(password string, user entities.User) bool {
+	password = strings.ToLower(password)
+
+	personalInfo := strings.Fields(strings.ToLower(user.Name))
+	email := strings.ToLower(user.Email)
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		personalInfo = append(personalInfo, email[:at])
+	}
+
+	for _, info := range personalInfo {
+		if len([]rune(info)) >= minPersonalInfoLength && strings.Contains(password, info) {
+			return true
+		}
+	}
+
+	return len(email) > 0 && strings.Contains(password, email)
+}
+
+func hashPassword(password string) string {
+	hash := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,155 @@
+package password
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/unicsmcr/hs_auth/config"
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/services"
+)
+
+var testUser = entities.User{
+	Name:  "John Doe",
+	Email: "jdoe@email.com",
+}
+
+func setupTest(t *testing.T, cfg config.PasswordPolicyConfig) services.PasswordPolicy {
+	policy, err := NewPolicy(&config.AppConfig{Auth: config.AuthConfig{PasswordPolicy: cfg}})
+	assert.NoError(t, err)
+
+	return policy
+}
+
+func Test_NewPolicy__should_return_error_when_character_class_is_unknown(t *testing.T) {
+	_, err := NewPolicy(&config.AppConfig{Auth: config.AuthConfig{PasswordPolicy: config.PasswordPolicyConfig{
+		RequiredCharacterClasses: []string{"emoji"},
+	}}})
+
+	assert.Error(t, err)
+}
+
+func Test_NewPolicy__should_return_error_when_breached_passwords_file_does_not_exist(t *testing.T) {
+	_, err := NewPolicy(&config.AppConfig{Auth: config.AuthConfig{PasswordPolicy: config.PasswordPolicyConfig{
+		BreachedPasswordsFile: "does_not_exist.txt",
+	}}})
+
+	assert.Error(t, err)
+}
+
+func Test_NewPolicy__should_load_shipped_breached_passwords_file(t *testing.T) {
+	policy := setupTest(t, config.PasswordPolicyConfig{BreachedPasswordsFile: "../../config/breached_passwords.txt"})
+
+	assert.Equal(t, services.ErrPasswordBreached, errors.Cause(policy.Validate("password123", testUser)))
+}
+
+func Test_Validate(t *testing.T) {
+	breachedFile, err := ioutil.TempFile("", "breached_passwords")
+	assert.NoError(t, err)
+	defer os.Remove(breachedFile.Name())
+	// SHA-1 hashes of "qwerty123" in the Pwned Passwords format and "letmein1" in lower case
+	_, err = breachedFile.WriteString("# comment\n5CEC175B165E3D5E62C9E13CE848EF6FEAC81BFF:10\n" +
+		"d04c1675b232c6ece69ed95e189e95d589f217b0\n")
+	assert.NoError(t, err)
+	assert.NoError(t, breachedFile.Close())
+
+	tests := []struct {
+		name     string
+		cfg      config.PasswordPolicyConfig
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "should accept any password with empty policy",
+			password: "a",
+		},
+		{
+			name:     "should return ErrPasswordTooShort when password is shorter than min length",
+			cfg:      config.PasswordPolicyConfig{MinLength: 8},
+			password: "short1",
+			wantErr:  services.ErrPasswordTooShort,
+		},
+		{
+			name:     "should count characters instead of bytes",
+			cfg:      config.PasswordPolicyConfig{MinLength: 4},
+			password: "ééé",
+			wantErr:  services.ErrPasswordTooShort,
+		},
+		{
+			name:     "should return ErrPasswordMissingCharacterClass when password has no digit",
+			cfg:      config.PasswordPolicyConfig{RequiredCharacterClasses: []string{ClassLetter, ClassDigit}},
+			password: "onlyletters",
+			wantErr:  services.ErrPasswordMissingCharacterClass,
+		},
+		{
+			name:     "should return ErrPasswordMissingCharacterClass when password has no uppercase letter",
+			cfg:      config.PasswordPolicyConfig{RequiredCharacterClasses: []string{ClassUppercase}},
+			password: "lowercase1",
+			wantErr:  services.ErrPasswordMissingCharacterClass,
+		},
+		{
+			name:     "should return ErrPasswordMissingCharacterClass when password has no symbol",
+			cfg:      config.PasswordPolicyConfig{RequiredCharacterClasses: []string{ClassSymbol}},
+			password: "Letters and 123",
+			wantErr:  services.ErrPasswordMissingCharacterClass,
+		},
+		{
+			name: "should accept password with all required character classes",
+			cfg: config.PasswordPolicyConfig{RequiredCharacterClasses: []string{ClassLowercase, ClassUppercase,
+				ClassLetter, ClassDigit, ClassSymbol}},
+			password: "aB3$",
+		},
+		{
+			name:     "should return ErrPasswordContainsPersonalInfo when password contains user's name",
+			cfg:      config.PasswordPolicyConfig{DisallowPersonalInfo: true},
+			password: "iamJOHN2020",
+			wantErr:  services.ErrPasswordContainsPersonalInfo,
+		},
+		{
+			name:     "should return ErrPasswordContainsPersonalInfo when password contains user's email",
+			cfg:      config.PasswordPolicyConfig{DisallowPersonalInfo: true},
+			password: "jdoe@email.com",
+			wantErr:  services.ErrPasswordContainsPersonalInfo,
+		},
+		{
+			name:     "should return ErrPasswordContainsPersonalInfo when password contains user's email username",
+			cfg:      config.PasswordPolicyConfig{DisallowPersonalInfo: true},
+			password: "secret-jdoe",
+			wantErr:  services.ErrPasswordContainsPersonalInfo,
+		},
+		{
+			name:     "should ignore personal info when it is not disallowed",
+			password: "john doe",
+		},
+		{
+			name:     "should return ErrPasswordBreached when password hash is in Pwned Passwords format",
+			cfg:      config.PasswordPolicyConfig{BreachedPasswordsFile: breachedFile.Name()},
+			password: "qwerty123",
+			wantErr:  services.ErrPasswordBreached,
+		},
+		{
+			name:     "should return ErrPasswordBreached when password hash is in lower case",
+			cfg:      config.PasswordPolicyConfig{BreachedPasswordsFile: breachedFile.Name()},
+			password: "letmein1",
+			wantErr:  services.ErrPasswordBreached,
+		},
+		{
+			name:     "should accept password not in breached passwords file",
+			cfg:      config.PasswordPolicyConfig{BreachedPasswordsFile: breachedFile.Name()},
+			password: "correct horse battery staple",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := setupTest(t, tt.cfg)
+
+			err := policy.Validate(tt.password, testUser)
+
+			assert.Equal(t, tt.wantErr, errors.Cause(err))
+		})
+	}
+}
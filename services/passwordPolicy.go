@@ -0,0 +1,13 @@
+package services
+
+import (
+	"github.com/unicsmcr/hs_auth/entities"
+)
+
+// PasswordPolicy checks the passwords users want to set against the password policy in the app config
+type PasswordPolicy interface {
+	// Validate returns nil if the given password can be set for the given user. Otherwise, returns
+	// ErrPasswordTooShort, ErrPasswordMissingCharacterClass, ErrPasswordContainsPersonalInfo or ErrPasswordBreached.
+	// Only the name and email of the user are used, so the user does not have to exist yet.
+	Validate(password string, user entities.User) error
+}
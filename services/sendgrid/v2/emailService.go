@@ -16,17 +16,30 @@ import (
 	"github.com/unicsmcr/hs_auth/utils"
 	"html/template"
 	"net/http"
+	"time"
 )
 
 var (
-	passwordResetEmailTemplatePath = "templates/emails/passwordReset_email.gohtml"
-	emailVerifyEmailTemplatePath   = "templates/emails/emailVerify_email.gohtml"
+	passwordResetEmailTemplatePath     = "templates/emails/passwordReset_email.gohtml"
+	emailVerifyEmailTemplatePath       = "templates/emails/emailVerify_email.gohtml"
+	accountLockedEmailTemplatePath     = "templates/emails/accountLocked_email.gohtml"
+	loginLinkEmailTemplatePath         = "templates/emails/loginLink_email.gohtml"
+	emailChangeEmailTemplatePath       = "templates/emails/emailChange_email.gohtml"
+	emailChangeNoticeEmailTemplatePath = "templates/emails/emailChangeNotice_email.gohtml"
+	invitationEmailTemplatePath        = "templates/emails/invitation_email.gohtml"
+	teamJoinRequestEmailTemplatePath   = "templates/emails/teamJoinRequest_email.gohtml"
+	teamJoinDecisionEmailTemplatePath  = "templates/emails/teamJoinRequestDecision_email.gohtml"
 )
 
 type emailTemplateDataModel struct {
-	EventName  string
-	Link       string
-	SenderName string
+	EventName     string
+	Link          string
+	SenderName    string
+	LockedUntil   string
+	NewEmail      string
+	TeamName      string
+	RequesterName string
+	Approved      bool
 }
 
 type sendgridEmailService struct {
@@ -37,8 +50,15 @@ type sendgridEmailService struct {
 	authorizer   authV2.Authorizer
 	timeProvider utils.TimeProvider
 
-	passwordResetEmailTemplate *template.Template
-	emailVerifyEmailTemplate   *template.Template
+	passwordResetEmailTemplate     *template.Template
+	emailVerifyEmailTemplate       *template.Template
+	accountLockedEmailTemplate     *template.Template
+	loginLinkEmailTemplate         *template.Template
+	emailChangeEmailTemplate       *template.Template
+	emailChangeNoticeEmailTemplate *template.Template
+	invitationEmailTemplate        *template.Template
+	teamJoinRequestEmailTemplate   *template.Template
+	teamJoinDecisionEmailTemplate  *template.Template
 }
 
 func NewSendgridEmailServiceV2(cfg *config.AppConfig, env *environment.Env,
@@ -54,15 +74,57 @@ func NewSendgridEmailServiceV2(cfg *config.AppConfig, env *environment.Env,
 		return nil, errors.Wrap(err, "could not load email verify template")
 	}
 
+	accountLockedEmailTemplate, err := utils.LoadTemplate("account locked", accountLockedEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load account locked template")
+	}
+
+	loginLinkEmailTemplate, err := utils.LoadTemplate("login link", loginLinkEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load login link template")
+	}
+
+	emailChangeEmailTemplate, err := utils.LoadTemplate("email change", emailChangeEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load email change template")
+	}
+
+	emailChangeNoticeEmailTemplate, err := utils.LoadTemplate("email change notice", emailChangeNoticeEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load email change notice template")
+	}
+
+	invitationEmailTemplate, err := utils.LoadTemplate("invitation", invitationEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load invitation template")
+	}
+
+	teamJoinRequestEmailTemplate, err := utils.LoadTemplate("team join request", teamJoinRequestEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load team join request template")
+	}
+
+	teamJoinDecisionEmailTemplate, err := utils.LoadTemplate("team join decision", teamJoinDecisionEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load team join decision template")
+	}
+
 	return &sendgridEmailService{
-		Client:                     client,
-		cfg:                        cfg,
-		env:                        env,
-		userService:                userService,
-		passwordResetEmailTemplate: passwordResetEmailTemplate,
-		emailVerifyEmailTemplate:   emailVerifyEmailTemplate,
-		authorizer:                 authorizer,
-		timeProvider:               timeProvider,
+		Client:                         client,
+		cfg:                            cfg,
+		env:                            env,
+		userService:                    userService,
+		passwordResetEmailTemplate:     passwordResetEmailTemplate,
+		emailVerifyEmailTemplate:       emailVerifyEmailTemplate,
+		accountLockedEmailTemplate:     accountLockedEmailTemplate,
+		loginLinkEmailTemplate:         loginLinkEmailTemplate,
+		emailChangeEmailTemplate:       emailChangeEmailTemplate,
+		emailChangeNoticeEmailTemplate: emailChangeNoticeEmailTemplate,
+		invitationEmailTemplate:        invitationEmailTemplate,
+		teamJoinRequestEmailTemplate:   teamJoinRequestEmailTemplate,
+		teamJoinDecisionEmailTemplate:  teamJoinDecisionEmailTemplate,
+		authorizer:                     authorizer,
+		timeProvider:                   timeProvider,
 	}, nil
 }
 
@@ -142,3 +204,200 @@ func (s *sendgridEmailService) SendPasswordResetEmail(ctx context.Context, user
 		user.Name,
 		user.Email)
 }
+
+func (s *sendgridEmailService) SendAccountLockedEmail(ctx context.Context, user entities.User, lockedUntil time.Time) error {
+	resetURL := fmt.Sprintf("http://%s/forgotpwd", s.cfg.AppURL)
+
+	var contentBuff bytes.Buffer
+	err := s.accountLockedEmailTemplate.Execute(&contentBuff, emailTemplateDataModel{
+		Link:        resetURL,
+		SenderName:  s.cfg.Email.NoreplyEmailName,
+		LockedUntil: lockedUntil.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.AccountLockedEmailSubj,
+		contentBuff.String(),
+		// TODO: plaintext should not be the same as HTML (https://github.com/unicsmcr/hs_auth/issues/120)
+		contentBuff.String(),
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
+
+func (s *sendgridEmailService) SendLoginLinkEmail(ctx context.Context, user entities.User, loginLinkResources common.UniformResourceIdentifiers) error {
+	emailToken, err := s.authorizer.CreateServiceToken(ctx, user.ID,
+		loginLinkResources, s.timeProvider.Now().Unix()+s.cfg.Auth.LoginLinkLifetime)
+	if err != nil {
+		return errors.Wrap(err, "could not create auth token for email")
+	}
+
+	loginURL := fmt.Sprintf("http://%s/login/link?token=%s&userId=%s", s.cfg.AppURL, emailToken, user.ID.Hex())
+
+	var contentBuff bytes.Buffer
+	err = s.loginLinkEmailTemplate.Execute(&contentBuff, emailTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       loginURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.LoginLinkEmailSubj,
+		contentBuff.String(),
+		// TODO: plaintext should not be the same as HTML (https://github.com/unicsmcr/hs_auth/issues/120)
+		contentBuff.String(),
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
+
+func (s *sendgridEmailService) SendEmailChangeConfirmationEmail(ctx context.Context, user entities.User, newEmail string, emailChangeResources common.UniformResourceIdentifiers) error {
+	emailToken, err := s.authorizer.CreateServiceToken(ctx, user.ID,
+		emailChangeResources, s.timeProvider.Now().Unix()+s.cfg.Email.TokenLifetime)
+	if err != nil {
+		return errors.Wrap(err, "could not create auth token for email")
+	}
+
+	confirmationURL := fmt.Sprintf("http://%s/email/confirm?token=%s&userId=%s&nonce=%s", s.cfg.AppURL, emailToken,
+		user.ID.Hex(), user.PendingEmailNonce)
+
+	var contentBuff bytes.Buffer
+	err = s.emailChangeEmailTemplate.Execute(&contentBuff, emailTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       confirmationURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.EmailChangeEmailSubj,
+		contentBuff.String(),
+		// TODO: plaintext should not be the same as HTML (https://github.com/unicsmcr/hs_auth/issues/120)
+		contentBuff.String(),
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		newEmail)
+}
+
+func (s *sendgridEmailService) SendEmailChangeNoticeEmail(ctx context.Context, user entities.User, newEmail string) error {
+	resetURL := fmt.Sprintf("http://%s/forgotpwd", s.cfg.AppURL)
+
+	var contentBuff bytes.Buffer
+	err := s.emailChangeNoticeEmailTemplate.Execute(&contentBuff, emailTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       resetURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+		NewEmail:   newEmail,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.EmailChangeNoticeEmailSubj,
+		contentBuff.String(),
+		// TODO: plaintext should not be the same as HTML (https://github.com/unicsmcr/hs_auth/issues/120)
+		contentBuff.String(),
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
+
+func (s *sendgridEmailService) SendInvitationEmail(ctx context.Context, user entities.User, passwordResetResources common.UniformResourceIdentifiers) error {
+	emailToken, err := s.authorizer.CreateServiceToken(ctx, user.ID,
+		passwordResetResources, s.timeProvider.Now().Unix()+s.cfg.Email.TokenLifetime)
+	if err != nil {
+		return errors.Wrap(err, "could not create auth token for email")
+	}
+
+	setPasswordURL := fmt.Sprintf("http://%s/resetpwd?token=%s&userId=%s", s.cfg.AppURL, emailToken, user.ID.Hex())
+
+	var contentBuff bytes.Buffer
+	err = s.invitationEmailTemplate.Execute(&contentBuff, emailTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       setPasswordURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.InvitationEmailSubj,
+		contentBuff.String(),
+		// TODO: plaintext should not be the same as HTML (https://github.com/unicsmcr/hs_auth/issues/120)
+		contentBuff.String(),
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
+
+func (s *sendgridEmailService) SendTeamJoinRequestEmail(ctx context.Context, creator entities.User, requester entities.User, team entities.Team) error {
+	profileURL := fmt.Sprintf("http://%s/profile", s.cfg.AppURL)
+
+	var contentBuff bytes.Buffer
+	err := s.teamJoinRequestEmailTemplate.Execute(&contentBuff, emailTemplateDataModel{
+		EventName:     s.cfg.Name,
+		Link:          profileURL,
+		SenderName:    s.cfg.Email.NoreplyEmailName,
+		TeamName:      team.Name,
+		RequesterName: requester.Name,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.TeamJoinRequestEmailSubj,
+		contentBuff.String(),
+		// TODO: plaintext should not be the same as HTML (https://github.com/unicsmcr/hs_auth/issues/120)
+		contentBuff.String(),
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		creator.Name,
+		creator.Email)
+}
+
+func (s *sendgridEmailService) SendTeamJoinRequestDecisionEmail(ctx context.Context, user entities.User, team entities.Team, approved bool) error {
+	profileURL := fmt.Sprintf("http://%s/profile", s.cfg.AppURL)
+
+	var contentBuff bytes.Buffer
+	err := s.teamJoinDecisionEmailTemplate.Execute(&contentBuff, emailTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       profileURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+		TeamName:   team.Name,
+		Approved:   approved,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	subject := s.cfg.Email.TeamJoinRejectedEmailSubj
+	if approved {
+		subject = s.cfg.Email.TeamJoinApprovedEmailSubj
+	}
+
+	return s.SendEmail(
+		subject,
+		contentBuff.String(),
+		// TODO: plaintext should not be the same as HTML (https://github.com/unicsmcr/hs_auth/issues/120)
+		contentBuff.String(),
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
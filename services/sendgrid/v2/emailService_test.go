@@ -53,9 +53,20 @@ var testCfg = config.AppConfig{
 		EmailVerificationEmailSubj: "test subject",
 		TokenLifetime:              1000,
 	},
+	Auth: config.AuthConfig{
+		LoginLinkLifetime: 100,
+	},
 }
 
 func setupEmailTest(t *testing.T) *emailTestSetup {
+	accountLockedEmailTemplatePath = _testEmailTemplate
+	loginLinkEmailTemplatePath = _testEmailTemplate
+	emailChangeEmailTemplatePath = _testEmailTemplate
+	emailChangeNoticeEmailTemplatePath = _testEmailTemplate
+	invitationEmailTemplatePath = _testEmailTemplate
+	teamJoinRequestEmailTemplatePath = _testEmailTemplate
+	teamJoinDecisionEmailTemplatePath = _testEmailTemplate
+
 	ctrl := gomock.NewController(t)
 	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
 	mockUService := mock_services.NewMockUserService(ctrl)
@@ -110,6 +121,13 @@ func Test_NewSendgridEmailService__should_return_error_when_template_path_is_inc
 	// password reset
 	passwordResetEmailTemplatePath = "invalid path"
 	emailVerifyEmailTemplatePath = _testEmailTemplate
+	accountLockedEmailTemplatePath = _testEmailTemplate
+	loginLinkEmailTemplatePath = _testEmailTemplate
+	emailChangeEmailTemplatePath = _testEmailTemplate
+	emailChangeNoticeEmailTemplatePath = _testEmailTemplate
+	invitationEmailTemplatePath = _testEmailTemplate
+	teamJoinRequestEmailTemplatePath = _testEmailTemplate
+	teamJoinDecisionEmailTemplatePath = _testEmailTemplate
 
 	service, err := NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
 	assert.Error(t, err)
@@ -122,11 +140,74 @@ func Test_NewSendgridEmailService__should_return_error_when_template_path_is_inc
 	service, err = NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
 	assert.Error(t, err)
 	assert.Nil(t, service)
+
+	// account locked
+	accountLockedEmailTemplatePath = "invalid path"
+	emailVerifyEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// login link
+	loginLinkEmailTemplatePath = "invalid path"
+	accountLockedEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// email change
+	emailChangeEmailTemplatePath = "invalid path"
+	loginLinkEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// email change notice
+	emailChangeNoticeEmailTemplatePath = "invalid path"
+	emailChangeEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// invitation
+	invitationEmailTemplatePath = "invalid path"
+	emailChangeNoticeEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// team join request
+	teamJoinRequestEmailTemplatePath = "invalid path"
+	invitationEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// team join decision
+	teamJoinDecisionEmailTemplatePath = "invalid path"
+	teamJoinRequestEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSendgridEmailServiceV2(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
 }
 
 func Test_SendEmail__should_send_correct_message_to_sendgrid(t *testing.T) {
 	passwordResetEmailTemplatePath = "../testEmailTemplate.txt"
 	emailVerifyEmailTemplatePath = "../testEmailTemplate.txt"
+	accountLockedEmailTemplatePath = "../testEmailTemplate.txt"
+	loginLinkEmailTemplatePath = "../testEmailTemplate.txt"
+	emailChangeEmailTemplatePath = "../testEmailTemplate.txt"
+	emailChangeNoticeEmailTemplatePath = "../testEmailTemplate.txt"
+	invitationEmailTemplatePath = "../testEmailTemplate.txt"
+	teamJoinRequestEmailTemplatePath = "../testEmailTemplate.txt"
+	teamJoinDecisionEmailTemplatePath = "../testEmailTemplate.txt"
 
 	client, server := getTestClient(t, `{"from":{"name":"Bob the Tester","email":"bob@test.com"},"subject":"test email","personalizations":[{"to":[{"name":"Rob the Tester","email":"rob@test.com"}]}],"content":[{"type":"text/plain","value":"test email body"},{"type":"text/html","value":"test email body"}]}`,
 		response{
@@ -146,6 +227,8 @@ func Test_SendEmail__should_send_correct_message_to_sendgrid(t *testing.T) {
 func Test_SendEmail__should_return_error_when_sendgrid_rejects_request(t *testing.T) {
 	passwordResetEmailTemplatePath = _testEmailTemplate
 	emailVerifyEmailTemplatePath = _testEmailTemplate
+	accountLockedEmailTemplatePath = _testEmailTemplate
+	loginLinkEmailTemplatePath = _testEmailTemplate
 
 	client, server := getTestClient(t, "",
 		response{
@@ -233,3 +316,152 @@ func Test_SendPasswordResetEmail__should_return_error_when_authorizer_returns_er
 	}, []common.UniformResourceIdentifier{testURI})
 	assert.Error(t, err)
 }
+
+func Test_SendAccountLockedEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	passwordResetEmailTemplatePath = _testEmailTemplate
+	emailVerifyEmailTemplatePath = _testEmailTemplate
+
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+
+	err := setup.emailService.SendAccountLockedEmail(setup.testCtx, entities.User{
+		ID: testUserId,
+	}, time.Unix(1000, 0))
+	assert.NoError(t, err)
+}
+
+func Test_SendLoginLinkEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+	testURI, _ := common.NewURIFromString("test")
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(101)).
+		Return("", nil).Times(1)
+
+	err := setup.emailService.SendLoginLinkEmail(setup.testCtx, entities.User{
+		ID: testUserId,
+	}, []common.UniformResourceIdentifier{testURI})
+	assert.NoError(t, err)
+}
+
+func Test_SendLoginLinkEmail__should_return_error_when_authorizer_returns_error(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+	testURI, _ := common.NewURIFromString("test")
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(101)).
+		Return("", errors.New("authorizer err")).Times(1)
+
+	err := setup.emailService.SendLoginLinkEmail(setup.testCtx, entities.User{
+		ID: testUserId,
+	}, []common.UniformResourceIdentifier{testURI})
+	assert.Error(t, err)
+}
+
+func Test_SendEmailChangeConfirmationEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(1001)).
+		Return("", nil).Times(1)
+
+	err := setup.emailService.SendEmailChangeConfirmationEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, "new@test.com", []common.UniformResourceIdentifier{testURI})
+	assert.NoError(t, err)
+}
+
+func Test_SendEmailChangeConfirmationEmail__should_return_error_when_authorizer_returns_error(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(1001)).
+		Return("", errors.New("authorizer err")).Times(1)
+
+	err := setup.emailService.SendEmailChangeConfirmationEmail(setup.testCtx, entities.User{
+		ID: testUserId,
+	}, "new@test.com", []common.UniformResourceIdentifier{testURI})
+	assert.Error(t, err)
+}
+
+func Test_SendEmailChangeNoticeEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+
+	err := setup.emailService.SendEmailChangeNoticeEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, "new@test.com")
+	assert.NoError(t, err)
+}
+
+func Test_SendInvitationEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(1001)).
+		Return("", nil).Times(1)
+
+	err := setup.emailService.SendInvitationEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, []common.UniformResourceIdentifier{testURI})
+	assert.NoError(t, err)
+}
+
+func Test_SendInvitationEmail__should_return_error_when_authorizer_returns_error(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(1001)).
+		Return("", errors.New("authorizer err")).Times(1)
+
+	err := setup.emailService.SendInvitationEmail(setup.testCtx, entities.User{
+		ID: testUserId,
+	}, []common.UniformResourceIdentifier{testURI})
+	assert.Error(t, err)
+}
+
+func Test_SendTeamJoinRequestEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+
+	err := setup.emailService.SendTeamJoinRequestEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, entities.User{
+		Name:  "Bob the Tester",
+		Email: "bob@test.com",
+	}, entities.Team{Name: "testers"})
+	assert.NoError(t, err)
+}
+
+func Test_SendTeamJoinRequestDecisionEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	defer setup.emailServer.Close()
+
+	err := setup.emailService.SendTeamJoinRequestDecisionEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, entities.Team{Name: "testers"}, false)
+	assert.NoError(t, err)
+}
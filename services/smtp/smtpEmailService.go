@@ -14,12 +14,20 @@ import (
 	"github.com/unicsmcr/hs_auth/utils"
 	"html/template"
 	"net/smtp"
+	"time"
 )
 
 var (
-	passwordResetEmailTemplatePath = "templates/emails/passwordReset_email.gohtml"
-	emailVerifyEmailTemplatePath   = "templates/emails/emailVerify_email.gohtml"
-	htmlEmailTemplateStr           = `From: %s <%s>
+	passwordResetEmailTemplatePath     = "templates/emails/passwordReset_email.gohtml"
+	emailVerifyEmailTemplatePath       = "templates/emails/emailVerify_email.gohtml"
+	accountLockedEmailTemplatePath     = "templates/emails/accountLocked_email.gohtml"
+	loginLinkEmailTemplatePath         = "templates/emails/loginLink_email.gohtml"
+	emailChangeEmailTemplatePath       = "templates/emails/emailChange_email.gohtml"
+	emailChangeNoticeEmailTemplatePath = "templates/emails/emailChangeNotice_email.gohtml"
+	invitationEmailTemplatePath        = "templates/emails/invitation_email.gohtml"
+	teamJoinRequestEmailTemplatePath   = "templates/emails/teamJoinRequest_email.gohtml"
+	teamJoinDecisionEmailTemplatePath  = "templates/emails/teamJoinRequestDecision_email.gohtml"
+	htmlEmailTemplateStr               = `From: %s <%s>
 To: %s <%s>
 Subject: %s
 Mime-Version: 1.0;
@@ -31,9 +39,14 @@ Content-Transfer-Encoding: 8bit;
 )
 
 type emailBodyTemplateDataModel struct {
-	EventName  string
-	Link       string
-	SenderName string
+	EventName     string
+	Link          string
+	SenderName    string
+	LockedUntil   string
+	NewEmail      string
+	TeamName      string
+	RequesterName string
+	Approved      bool
 }
 
 type smtpEmailService struct {
@@ -44,9 +57,16 @@ type smtpEmailService struct {
 	authorizer   authV2.Authorizer
 	timeProvider utils.TimeProvider
 
-	smtpAuth                       smtp.Auth
-	passwordResetEmailBodyTemplate *template.Template
-	emailVerifyEmailBodyTemplate   *template.Template
+	smtpAuth                           smtp.Auth
+	passwordResetEmailBodyTemplate     *template.Template
+	emailVerifyEmailBodyTemplate       *template.Template
+	accountLockedEmailBodyTemplate     *template.Template
+	loginLinkEmailBodyTemplate         *template.Template
+	emailChangeEmailBodyTemplate       *template.Template
+	emailChangeNoticeEmailBodyTemplate *template.Template
+	invitationEmailBodyTemplate        *template.Template
+	teamJoinRequestEmailBodyTemplate   *template.Template
+	teamJoinDecisionEmailBodyTemplate  *template.Template
 }
 
 func NewSMPTEmailService(cfg *config.AppConfig, env *environment.Env, client utils.SMTPClient,
@@ -62,15 +82,57 @@ func NewSMPTEmailService(cfg *config.AppConfig, env *environment.Env, client uti
 		return nil, errors.Wrap(err, "could not load email verify template")
 	}
 
+	accountLockedEmailTemplate, err := utils.LoadTemplate("account locked", accountLockedEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load account locked template")
+	}
+
+	loginLinkEmailTemplate, err := utils.LoadTemplate("login link", loginLinkEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load login link template")
+	}
+
+	emailChangeEmailTemplate, err := utils.LoadTemplate("email change", emailChangeEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load email change template")
+	}
+
+	emailChangeNoticeEmailTemplate, err := utils.LoadTemplate("email change notice", emailChangeNoticeEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load email change notice template")
+	}
+
+	invitationEmailTemplate, err := utils.LoadTemplate("invitation", invitationEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load invitation template")
+	}
+
+	teamJoinRequestEmailTemplate, err := utils.LoadTemplate("team join request", teamJoinRequestEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load team join request template")
+	}
+
+	teamJoinDecisionEmailTemplate, err := utils.LoadTemplate("team join decision", teamJoinDecisionEmailTemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load team join decision template")
+	}
+
 	return &smtpEmailService{
-		cfg:                            cfg,
-		env:                            env,
-		client:                         client,
-		userService:                    userService,
-		passwordResetEmailBodyTemplate: passwordResetEmailTemplate,
-		emailVerifyEmailBodyTemplate:   emailVerifyEmailTemplate,
-		authorizer:                     authorizer,
-		timeProvider:                   timeProvider,
+		cfg:                                cfg,
+		env:                                env,
+		client:                             client,
+		userService:                        userService,
+		passwordResetEmailBodyTemplate:     passwordResetEmailTemplate,
+		emailVerifyEmailBodyTemplate:       emailVerifyEmailTemplate,
+		accountLockedEmailBodyTemplate:     accountLockedEmailTemplate,
+		loginLinkEmailBodyTemplate:         loginLinkEmailTemplate,
+		emailChangeEmailBodyTemplate:       emailChangeEmailTemplate,
+		emailChangeNoticeEmailBodyTemplate: emailChangeNoticeEmailTemplate,
+		invitationEmailBodyTemplate:        invitationEmailTemplate,
+		teamJoinRequestEmailBodyTemplate:   teamJoinRequestEmailTemplate,
+		teamJoinDecisionEmailBodyTemplate:  teamJoinDecisionEmailTemplate,
+		authorizer:                         authorizer,
+		timeProvider:                       timeProvider,
 		smtpAuth: smtp.PlainAuth("", env.Get(environment.SMTPUsername),
 			env.Get(environment.SMTPPassword), env.Get(environment.SMTPHost)),
 	}, nil
@@ -145,3 +207,193 @@ func (s *smtpEmailService) SendPasswordResetEmail(ctx context.Context, user enti
 		user.Name,
 		user.Email)
 }
+
+func (s *smtpEmailService) SendAccountLockedEmail(ctx context.Context, user entities.User, lockedUntil time.Time) error {
+	resetURL := fmt.Sprintf("http://%s/forgotpwd", s.cfg.AppURL)
+
+	var contentBuff bytes.Buffer
+	err := s.accountLockedEmailBodyTemplate.Execute(&contentBuff, emailBodyTemplateDataModel{
+		Link:        resetURL,
+		SenderName:  s.cfg.Email.NoreplyEmailName,
+		LockedUntil: lockedUntil.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.AccountLockedEmailSubj,
+		contentBuff.String(),
+		"",
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
+
+func (s *smtpEmailService) SendLoginLinkEmail(ctx context.Context, user entities.User, loginLinkResources common.UniformResourceIdentifiers) error {
+	emailToken, err := s.authorizer.CreateServiceToken(ctx, user.ID,
+		loginLinkResources, s.timeProvider.Now().Unix()+s.cfg.Auth.LoginLinkLifetime)
+	if err != nil {
+		return errors.Wrap(err, "could not create auth token for email")
+	}
+
+	loginURL := fmt.Sprintf("http://%s/login/link?token=%s&userId=%s", s.cfg.AppURL, emailToken, user.ID.Hex())
+
+	var contentBuff bytes.Buffer
+	err = s.loginLinkEmailBodyTemplate.Execute(&contentBuff, emailBodyTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       loginURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.LoginLinkEmailSubj,
+		contentBuff.String(),
+		"",
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
+
+func (s *smtpEmailService) SendEmailChangeConfirmationEmail(ctx context.Context, user entities.User, newEmail string, emailChangeResources common.UniformResourceIdentifiers) error {
+	emailToken, err := s.authorizer.CreateServiceToken(ctx, user.ID,
+		emailChangeResources, s.timeProvider.Now().Unix()+s.cfg.Email.TokenLifetime)
+	if err != nil {
+		return errors.Wrap(err, "could not create auth token for email")
+	}
+
+	confirmationURL := fmt.Sprintf("http://%s/email/confirm?token=%s&userId=%s&nonce=%s", s.cfg.AppURL, emailToken,
+		user.ID.Hex(), user.PendingEmailNonce)
+
+	var contentBuff bytes.Buffer
+	err = s.emailChangeEmailBodyTemplate.Execute(&contentBuff, emailBodyTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       confirmationURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.EmailChangeEmailSubj,
+		contentBuff.String(),
+		"",
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		newEmail)
+}
+
+func (s *smtpEmailService) SendEmailChangeNoticeEmail(ctx context.Context, user entities.User, newEmail string) error {
+	resetURL := fmt.Sprintf("http://%s/forgotpwd", s.cfg.AppURL)
+
+	var contentBuff bytes.Buffer
+	err := s.emailChangeNoticeEmailBodyTemplate.Execute(&contentBuff, emailBodyTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       resetURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+		NewEmail:   newEmail,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.EmailChangeNoticeEmailSubj,
+		contentBuff.String(),
+		"",
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
+
+func (s *smtpEmailService) SendInvitationEmail(ctx context.Context, user entities.User, passwordResetResources common.UniformResourceIdentifiers) error {
+	emailToken, err := s.authorizer.CreateServiceToken(ctx, user.ID,
+		passwordResetResources, s.timeProvider.Now().Unix()+s.cfg.Email.TokenLifetime)
+	if err != nil {
+		return errors.Wrap(err, "could not create auth token for email")
+	}
+
+	setPasswordURL := fmt.Sprintf("http://%s/resetpwd?token=%s&userId=%s", s.cfg.AppURL, emailToken, user.ID.Hex())
+
+	var contentBuff bytes.Buffer
+	err = s.invitationEmailBodyTemplate.Execute(&contentBuff, emailBodyTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       setPasswordURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.InvitationEmailSubj,
+		contentBuff.String(),
+		"",
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
+
+func (s *smtpEmailService) SendTeamJoinRequestEmail(ctx context.Context, creator entities.User, requester entities.User, team entities.Team) error {
+	profileURL := fmt.Sprintf("http://%s/profile", s.cfg.AppURL)
+
+	var contentBuff bytes.Buffer
+	err := s.teamJoinRequestEmailBodyTemplate.Execute(&contentBuff, emailBodyTemplateDataModel{
+		EventName:     s.cfg.Name,
+		Link:          profileURL,
+		SenderName:    s.cfg.Email.NoreplyEmailName,
+		TeamName:      team.Name,
+		RequesterName: requester.Name,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	return s.SendEmail(
+		s.cfg.Email.TeamJoinRequestEmailSubj,
+		contentBuff.String(),
+		"",
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		creator.Name,
+		creator.Email)
+}
+
+func (s *smtpEmailService) SendTeamJoinRequestDecisionEmail(ctx context.Context, user entities.User, team entities.Team, approved bool) error {
+	profileURL := fmt.Sprintf("http://%s/profile", s.cfg.AppURL)
+
+	var contentBuff bytes.Buffer
+	err := s.teamJoinDecisionEmailBodyTemplate.Execute(&contentBuff, emailBodyTemplateDataModel{
+		EventName:  s.cfg.Name,
+		Link:       profileURL,
+		SenderName: s.cfg.Email.NoreplyEmailName,
+		TeamName:   team.Name,
+		Approved:   approved,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not construct email")
+	}
+
+	subject := s.cfg.Email.TeamJoinRejectedEmailSubj
+	if approved {
+		subject = s.cfg.Email.TeamJoinApprovedEmailSubj
+	}
+
+	return s.SendEmail(
+		subject,
+		contentBuff.String(),
+		"",
+		s.cfg.Email.NoreplyEmailName,
+		s.cfg.Email.NoreplyEmailAddr,
+		user.Name,
+		user.Email)
+}
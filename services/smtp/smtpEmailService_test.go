@@ -58,11 +58,21 @@ var testCfg = config.AppConfig{
 		EmailVerificationEmailSubj: "test subject",
 		TokenLifetime:              1000,
 	},
+	Auth: config.AuthConfig{
+		LoginLinkLifetime: 100,
+	},
 }
 
 func setupEmailTest(t *testing.T) *emailTestSetup {
 	emailVerifyEmailTemplatePath = _testEmailTemplate
 	passwordResetEmailTemplatePath = _testEmailTemplate
+	accountLockedEmailTemplatePath = _testEmailTemplate
+	loginLinkEmailTemplatePath = _testEmailTemplate
+	emailChangeEmailTemplatePath = _testEmailTemplate
+	emailChangeNoticeEmailTemplatePath = _testEmailTemplate
+	invitationEmailTemplatePath = _testEmailTemplate
+	teamJoinRequestEmailTemplatePath = _testEmailTemplate
+	teamJoinDecisionEmailTemplatePath = _testEmailTemplate
 
 	ctrl := gomock.NewController(t)
 	mockAuthorizer := mock_v2.NewMockAuthorizer(ctrl)
@@ -100,6 +110,13 @@ func Test_NewSMTPEmailService__should_return_error_when_template_path_is_incorre
 	// password reset
 	passwordResetEmailTemplatePath = "invalid path"
 	emailVerifyEmailTemplatePath = _testEmailTemplate
+	accountLockedEmailTemplatePath = _testEmailTemplate
+	loginLinkEmailTemplatePath = _testEmailTemplate
+	emailChangeEmailTemplatePath = _testEmailTemplate
+	emailChangeNoticeEmailTemplatePath = _testEmailTemplate
+	invitationEmailTemplatePath = _testEmailTemplate
+	teamJoinRequestEmailTemplatePath = _testEmailTemplate
+	teamJoinDecisionEmailTemplatePath = _testEmailTemplate
 
 	service, err := NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
 	assert.Error(t, err)
@@ -112,6 +129,62 @@ func Test_NewSMTPEmailService__should_return_error_when_template_path_is_incorre
 	service, err = NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
 	assert.Error(t, err)
 	assert.Nil(t, service)
+
+	// account locked
+	accountLockedEmailTemplatePath = "invalid path"
+	emailVerifyEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// login link
+	loginLinkEmailTemplatePath = "invalid path"
+	accountLockedEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// email change
+	emailChangeEmailTemplatePath = "invalid path"
+	loginLinkEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// email change notice
+	emailChangeNoticeEmailTemplatePath = "invalid path"
+	emailChangeEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// invitation
+	invitationEmailTemplatePath = "invalid path"
+	emailChangeNoticeEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// team join request
+	teamJoinRequestEmailTemplatePath = "invalid path"
+	invitationEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+
+	// team join decision
+	teamJoinDecisionEmailTemplatePath = "invalid path"
+	teamJoinRequestEmailTemplatePath = _testEmailTemplate
+
+	service, err = NewSMPTEmailService(nil, nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
 }
 
 func Test_SendEmail__should_send_correct_message_to_smtp(t *testing.T) {
@@ -207,3 +280,176 @@ func Test_SendPasswordResetEmail__should_return_error_when_authorizer_returns_er
 
 	assert.Error(t, err)
 }
+
+func Test_SendAccountLockedEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+
+	setup.mockSMTPClient.EXPECT().SendEmail(fmt.Sprintf("%s:%s", testServer, testPort), testAuth,
+		testCfg.Email.NoreplyEmailAddr, []string{"rob@test.com"}, gomock.Any()).Return(nil).Times(1)
+
+	err := setup.emailService.SendAccountLockedEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, time.Unix(1000, 0))
+	assert.NoError(t, err)
+}
+
+func Test_SendAccountLockedEmail__should_return_error_when_sending_email_fails(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+
+	setup.mockSMTPClient.EXPECT().SendEmail(fmt.Sprintf("%s:%s", testServer, testPort), testAuth,
+		testCfg.Email.NoreplyEmailAddr, []string{"rob@test.com"}, gomock.Any()).Return(errors.New("smtp err")).Times(1)
+
+	err := setup.emailService.SendAccountLockedEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, time.Unix(1000, 0))
+	assert.Error(t, err)
+}
+
+func Test_SendLoginLinkEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockSMTPClient.EXPECT().SendEmail(fmt.Sprintf("%s:%s", testServer, testPort), testAuth,
+		testCfg.Email.NoreplyEmailAddr, []string{"rob@test.com"}, gomock.Any()).Return(nil).Times(1)
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(101)).
+		Return("", nil).Times(1)
+
+	err := setup.emailService.SendLoginLinkEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, []common.UniformResourceIdentifier{testURI})
+	assert.NoError(t, err)
+}
+
+func Test_SendLoginLinkEmail__should_return_error_when_authorizer_returns_error(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(101)).
+		Return("", errors.New("authorizer err")).Times(1)
+
+	err := setup.emailService.SendLoginLinkEmail(setup.testCtx, entities.User{
+		ID: testUserId,
+	}, []common.UniformResourceIdentifier{testURI})
+
+	assert.Error(t, err)
+}
+
+func Test_SendEmailChangeConfirmationEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockSMTPClient.EXPECT().SendEmail(fmt.Sprintf("%s:%s", testServer, testPort), testAuth,
+		testCfg.Email.NoreplyEmailAddr, []string{"new@test.com"}, gomock.Any()).Return(nil).Times(1)
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(1001)).
+		Return("", nil).Times(1)
+
+	err := setup.emailService.SendEmailChangeConfirmationEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, "new@test.com", []common.UniformResourceIdentifier{testURI})
+	assert.NoError(t, err)
+}
+
+func Test_SendEmailChangeConfirmationEmail__should_return_error_when_authorizer_returns_error(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(1001)).
+		Return("", errors.New("authorizer err")).Times(1)
+
+	err := setup.emailService.SendEmailChangeConfirmationEmail(setup.testCtx, entities.User{
+		ID: testUserId,
+	}, "new@test.com", []common.UniformResourceIdentifier{testURI})
+	assert.Error(t, err)
+}
+
+func Test_SendEmailChangeNoticeEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+
+	setup.mockSMTPClient.EXPECT().SendEmail(fmt.Sprintf("%s:%s", testServer, testPort), testAuth,
+		testCfg.Email.NoreplyEmailAddr, []string{"rob@test.com"}, gomock.Any()).Return(nil).Times(1)
+
+	err := setup.emailService.SendEmailChangeNoticeEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, "new@test.com")
+	assert.NoError(t, err)
+}
+
+func Test_SendInvitationEmail__should_not_return_error_when_sending_email_is_successful(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockSMTPClient.EXPECT().SendEmail(fmt.Sprintf("%s:%s", testServer, testPort), testAuth,
+		testCfg.Email.NoreplyEmailAddr, []string{"rob@test.com"}, gomock.Any()).Return(nil).Times(1)
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(1001)).
+		Return("", nil).Times(1)
+
+	err := setup.emailService.SendInvitationEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, []common.UniformResourceIdentifier{testURI})
+	assert.NoError(t, err)
+}
+
+func Test_SendInvitationEmail__should_return_error_when_authorizer_returns_error(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+	testURI, _ := common.NewURIFromString("test")
+
+	setup.mockTimeProvider.EXPECT().Now().Return(time.Unix(1, 0)).Times(1)
+	setup.mockAuthorizer.EXPECT().CreateServiceToken(setup.testCtx, testUserId, []common.UniformResourceIdentifier{testURI}, int64(1001)).
+		Return("", errors.New("authorizer err")).Times(1)
+
+	err := setup.emailService.SendInvitationEmail(setup.testCtx, entities.User{
+		ID: testUserId,
+	}, []common.UniformResourceIdentifier{testURI})
+	assert.Error(t, err)
+}
+
+func Test_SendTeamJoinRequestEmail__should_send_email_to_team_creator(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+
+	setup.mockSMTPClient.EXPECT().SendEmail(fmt.Sprintf("%s:%s", testServer, testPort), testAuth,
+		testCfg.Email.NoreplyEmailAddr, []string{"rob@test.com"}, gomock.Any()).Return(nil).Times(1)
+
+	err := setup.emailService.SendTeamJoinRequestEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, entities.User{
+		Name:  "Bob the Tester",
+		Email: "bob@test.com",
+	}, entities.Team{Name: "testers"})
+	assert.NoError(t, err)
+}
+
+func Test_SendTeamJoinRequestDecisionEmail__should_return_error_when_sending_email_fails(t *testing.T) {
+	setup := setupEmailTest(t)
+	defer setup.ctrl.Finish()
+
+	setup.mockSMTPClient.EXPECT().SendEmail(fmt.Sprintf("%s:%s", testServer, testPort), testAuth,
+		testCfg.Email.NoreplyEmailAddr, []string{"rob@test.com"}, gomock.Any()).Return(errors.New("smtp err")).Times(1)
+
+	err := setup.emailService.SendTeamJoinRequestDecisionEmail(setup.testCtx, entities.User{
+		ID:    testUserId,
+		Email: "rob@test.com",
+	}, entities.Team{Name: "testers"}, true)
+	assert.Error(t, err)
+}
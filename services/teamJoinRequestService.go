@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+
+	"github.com/unicsmcr/hs_auth/entities"
+)
+
+// TeamJoinRequestService is the service for managing the requests of users to join closed teams
+type TeamJoinRequestService interface {
+	// CreateJoinRequestWithJoinCode creates a request of the user with the given id to join the team
+	// with the given join code and notifies the team's creator about it.
+	// Returns the same errors as TeamService.GetTeamWithJoinCode, ErrUserInTeam if the user is already
+	// in a team and ErrJoinRequestExists if the user has already requested to join the team
+	CreateJoinRequestWithJoinCode(ctx context.Context, userID string, joinCode string) (*entities.TeamJoinRequest, error)
+	// CreateJoinRequestForTeamWithID creates a request of the user with the given id to join the team
+	// with the given id and notifies the team's creator about it.
+	// Returns ErrUserInTeam if the user is already in a team and ErrJoinRequestExists if the user has
+	// already requested to join the team
+	CreateJoinRequestForTeamWithID(ctx context.Context, userID string, teamID string) (*entities.TeamJoinRequest, error)
+
+	// GetJoinRequestsForTeamWithID returns the pending requests to join the team with the given id, oldest first
+	GetJoinRequestsForTeamWithID(ctx context.Context, teamID string) ([]entities.TeamJoinRequest, error)
+	// GetJoinRequestsForUserWithID returns the pending requests of the user with the given id
+	GetJoinRequestsForUserWithID(ctx context.Context, userID string) ([]entities.TeamJoinRequest, error)
+
+	// ApproveJoinRequestWithID adds the user who made the request to the team, removes the user's other
+	// requests and notifies the user. Returns ErrNotFound if the team has no request with the given id
+	// and ErrTeamFull, keeping the request, if the team has reached its member limit
+	ApproveJoinRequestWithID(ctx context.Context, teamID string, requestID string) error
+	// RejectJoinRequestWithID removes the request and notifies the user who made it.
+	// Returns ErrNotFound if the team has no request with the given id
+	RejectJoinRequestWithID(ctx context.Context, teamID string, requestID string) error
+	// DeleteJoinRequestsForUserWithID removes all requests of the user with the given id without notifying anyone
+	DeleteJoinRequestsForUserWithID(ctx context.Context, userID string) error
+	// DeleteJoinRequestsForTeamWithID removes all requests to join the team with the given id without notifying anyone
+	DeleteJoinRequestsForTeamWithID(ctx context.Context, teamID string) error
+}
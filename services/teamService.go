@@ -2,24 +2,189 @@ package services
 
 import (
 	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/pkg/errors"
+	"github.com/unicsmcr/hs_auth/config"
 	"github.com/unicsmcr/hs_auth/entities"
 )
 
+const (
+	maxTeamDescriptionLength = 1000
+	maxTeamURLLength         = 2048
+	maxTeamTags              = 10
+	maxTeamTagLength         = 30
+)
+
+// BuildTeamUpdateParams validates the given values of the team's fields and converts them to the fields' types.
+// Empty values clear the team's optional fields. Tags are given as a comma separated list.
+// Returns ErrInvalidTeamUpdateParams if a value is invalid or the field cannot be updated
+func BuildTeamUpdateParams(cfg *config.AppConfig, stringParams map[entities.TeamField]string) (TeamUpdateParams, error) {
+	builtParams := TeamUpdateParams{}
+	for field, value := range stringParams {
+		switch field {
+		case entities.TeamName:
+			if len(strings.TrimSpace(value)) == 0 {
+				return TeamUpdateParams{}, errors.Wrap(ErrInvalidTeamUpdateParams, "team name must not be empty")
+			}
+			builtParams[field] = value
+		case entities.TeamDescription:
+			if utf8.RuneCountInString(value) > maxTeamDescriptionLength {
+				return TeamUpdateParams{}, errors.Wrap(ErrInvalidTeamUpdateParams, "description is too long")
+			}
+			builtParams[field] = value
+		case entities.TeamRepositoryURL, entities.TeamDemoURL:
+			if len(value) > 0 && !validTeamURL(value) {
+				return TeamUpdateParams{}, errors.Wrap(ErrInvalidTeamUpdateParams, string(field)+" must be a http or https URL")
+			}
+			builtParams[field] = value
+		case entities.TeamTrack:
+			if len(value) > 0 && len(cfg.Teams.Tracks) > 0 && !containsTrack(cfg.Teams.Tracks, value) {
+				return TeamUpdateParams{}, errors.Wrap(ErrInvalidTeamUpdateParams, "unknown judging track")
+			}
+			builtParams[field] = value
+		case entities.TeamTableNumber:
+			tableNumber := 0
+			if len(value) > 0 {
+				var err error
+				tableNumber, err = strconv.Atoi(value)
+				if err != nil || tableNumber < 0 {
+					return TeamUpdateParams{}, errors.Wrap(ErrInvalidTeamUpdateParams, "table number must be a non-negative integer")
+				}
+			}
+			builtParams[field] = tableNumber
+		case entities.TeamTags:
+			tags := normaliseTeamTags(strings.Split(value, ","))
+			if len(tags) > maxTeamTags {
+				return TeamUpdateParams{}, errors.Wrap(ErrInvalidTeamUpdateParams, "too many tags")
+			}
+			for _, tag := range tags {
+				if utf8.RuneCountInString(tag) > maxTeamTagLength {
+					return TeamUpdateParams{}, errors.Wrap(ErrInvalidTeamUpdateParams, "tag is too long")
+				}
+			}
+			builtParams[field] = tags
+		default:
+			return TeamUpdateParams{}, errors.Wrap(ErrInvalidTeamUpdateParams, string(field)+" cannot be updated")
+		}
+	}
+	return builtParams, nil
+}
+
+// TeamUpdateParams are the new values of the team's fields, as returned by BuildTeamUpdateParams
+type TeamUpdateParams map[entities.TeamField]interface{}
+
+func validTeamURL(value string) bool {
+	if len(value) > maxTeamURLLength {
+		return false
+	}
+
+	parsedURL, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	return (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") && len(parsedURL.Host) > 0
+}
+
+func containsTrack(tracks []string, track string) bool {
+	for _, configuredTrack := range tracks {
+		if configuredTrack == track {
+			return true
+		}
+	}
+	return false
+}
+
+// normaliseTeamTags lower cases the tags and removes blank and repeated ones, so that teams can be searched by them
+func normaliseTeamTags(tags []string) []string {
+	normalised := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) == 0 || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalised = append(normalised, tag)
+	}
+
+	return normalised
+}
+
+// TeamFilter restricts which teams are returned by TeamService.GetTeamsPage and TeamService.StreamTeams.
+// Fields left as their zero value do not restrict the teams.
+type TeamFilter struct {
+	// NamePrefix is the case-sensitive start of the teams' names
+	NamePrefix string
+	// Track is the judging track the teams have entered
+	Track string
+	// Tag is one of the teams' tags
+	Tag string
+	// Search are words contained in the teams' names, descriptions or tags
+	Search string
+}
+
 type TeamService interface {
 	CreateTeam(ctx context.Context, name, creatorID string) (*entities.Team, error)
 	CreateTeamForUserWithID(ctx context.Context, name, userID string) (*entities.Team, error)
 
 	GetTeams(context.Context) ([]entities.Team, error)
+	// GetTeamsPage returns the page of teams matching the filter.
+	// Teams can be sorted by _id or name.
+	GetTeamsPage(ctx context.Context, filter TeamFilter, page Pagination) ([]entities.Team, *PageInfo, error)
+	// StreamTeams calls callback with each team matching the filter, in order of their ids, without loading
+	// all of them into memory. Stops and returns the error if callback returns one.
+	StreamTeams(ctx context.Context, filter TeamFilter, callback func(entities.Team) error) error
 
 	GetTeamWithID(ctx context.Context, id string) (*entities.Team, error)
 	GetTeamWithName(ctx context.Context, name string) (*entities.Team, error)
 	GetTeamForUserWithID(ctx context.Context, userID string) (*entities.Team, error)
 	GetTeamForUserWithEmail(ctx context.Context, email string) (*entities.Team, error)
+	// GetTeamWithJoinCode returns the team with the given join code for the user with the given id.
+	// Returns ErrNotFound if no team has the code or the code has expired, and ErrJoinCodeThrottled
+	// if the user has entered too many wrong codes recently
+	GetTeamWithJoinCode(ctx context.Context, userID string, joinCode string) (*entities.Team, error)
 
 	DeleteTeamWithID(ctx context.Context, id string) error
+	// DisbandTeamWithID removes all members from the team and deletes it
+	DisbandTeamWithID(ctx context.Context, teamID string) error
+
+	// RenameTeamWithID changes the team's name and returns the updated team.
+	// Returns ErrNameTaken if another team has the name already
+	RenameTeamWithID(ctx context.Context, teamID string, name string) (*entities.Team, error)
+	// UpdateTeamWithID sets the given fields of the team and returns the updated team.
+	// Returns ErrNameTaken if the team is renamed and another team has the name already
+	UpdateTeamWithID(ctx context.Context, teamID string, params TeamUpdateParams) (*entities.Team, error)
+	// SetCreatorForTeamWithID hands the team over to another of its members and returns the updated team.
+	// Returns ErrUserNotInTeam if the user is not a member of the team
+	SetCreatorForTeamWithID(ctx context.Context, teamID string, userID string) (*entities.Team, error)
 
+	// AddUserWithIDToTeamWithID adds the user to the team.
+	// Returns ErrUserInTeam if the user is already in a team and ErrTeamFull if the team has reached its member limit
 	AddUserWithIDToTeamWithID(ctx context.Context, userID string, teamID string) error
+	// AddUserWithIDToTeamWithJoinCode adds the user to the team with the given join code.
+	// Returns the same errors as GetTeamWithJoinCode and ErrTeamClosed if the team
+	// can only be joined by requesting to join
+	AddUserWithIDToTeamWithJoinCode(ctx context.Context, userID string, joinCode string) error
+
+	// SetPrivacyForTeamWithID changes how users can join the team and returns the updated team
+	SetPrivacyForTeamWithID(ctx context.Context, teamID string, privacy entities.TeamPrivacySetting) (*entities.Team, error)
+
+	// SetMemberLimitForTeamWithID overrides the configured member limit for the team and returns the updated team.
+	// A limit of 0 removes the override. Lowering the limit does not remove any of the team's members
+	SetMemberLimitForTeamWithID(ctx context.Context, teamID string, maxMembers int) (*entities.Team, error)
+
+	// RegenerateJoinCodeForTeamWithID replaces the team's join code with a new one, so that the old code
+	// stops working, and returns the updated team
+	RegenerateJoinCodeForTeamWithID(ctx context.Context, teamID string) (*entities.Team, error)
 
 	RemoveUserWithIDFromTheirTeam(ctx context.Context, userID string) error
+	// RemoveUserWithIDFromTeamWithID removes a member from the team. Returns ErrUserNotInTeam if the user
+	// is not a member of the team and ErrUserIsTeamCreator if the user is the team's creator, who has to
+	// hand the team over or leave it instead
+	RemoveUserWithIDFromTeamWithID(ctx context.Context, teamID string, userID string) error
 }
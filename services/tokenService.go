@@ -10,4 +10,8 @@ type TokenService interface {
 	GenerateServiceTokenID() primitive.ObjectID
 	CreateServiceToken(ctx context.Context, tokenId, creatorId, jwt string) (*entities.ServiceToken, error)
 	DeleteServiceToken(ctx context.Context, id string) error
+	// GetServiceTokensWithCreator returns the service tokens created by or for the user with the given id
+	GetServiceTokensWithCreator(ctx context.Context, creatorID string) ([]entities.ServiceToken, error)
+	// DeleteServiceTokensWithCreator deletes the service tokens created by or for the user with the given id
+	DeleteServiceTokensWithCreator(ctx context.Context, creatorID string) error
 }
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+
+	"github.com/unicsmcr/hs_auth/config/role"
+)
+
+// TwoFactorService is the service for TOTP based two-factor authentication of users
+type TwoFactorService interface {
+	// BeginEnrollment generates a new TOTP secret for the user with the given id and returns it along with
+	// the otpauth URI to be shown as a QR code. The secret is only used for logins once the enrollment is confirmed.
+	// Returns the pending secret if the enrollment has already been started.
+	// Returns ErrTwoFactorEnabled if the user already has two-factor authentication enabled.
+	BeginEnrollment(ctx context.Context, userID string) (secret string, uri string, err error)
+	// ConfirmEnrollment enables two-factor authentication for the user with the given id if code is valid
+	// for the secret generated by BeginEnrollment. Returns the recovery codes, which are only stored hashed.
+	ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	// VerifyCode checks the given TOTP or recovery code of the user with the given id.
+	// Every code can only be used once. Returns ErrInvalidTwoFactorCode if the code is not valid.
+	VerifyCode(ctx context.Context, userID, code string) error
+	// Disable turns off two-factor authentication for the user with the given id
+	Disable(ctx context.Context, userID string) error
+	// IsRequiredForRole returns whether users with the given role have to use two-factor authentication
+	IsRequiredForRole(userRole role.UserRole) bool
+}
@@ -42,11 +42,31 @@ func BuildUserUpdateParams(cfg *config.AppConfig, stringParams map[entities.User
 
 type UserUpdateParams map[entities.UserField]interface{}
 
+// NoTeam is the UserFilter.TeamID matching users who are not in a team
+const NoTeam = "none"
+
+// UserFilter restricts which users are returned by UserService.GetUsersPage and UserService.StreamUsers.
+// Fields left as their zero value do not restrict the users.
+type UserFilter struct {
+	Role role.UserRole
+	// TeamID is the id of the users' team or NoTeam
+	TeamID        string
+	EmailVerified *bool
+	// NamePrefix is the case-sensitive start of the users' names
+	NamePrefix string
+}
+
 // UserService is the service for interactions with a remote users repository
 type UserService interface {
 	CreateUser(ctx context.Context, name, email, password string, role role.UserRole) (*entities.User, error)
 
 	GetUsers(ctx context.Context) ([]entities.User, error)
+	// GetUsersPage returns the page of users matching the filter.
+	// Users can be sorted by _id, name or email.
+	GetUsersPage(ctx context.Context, filter UserFilter, page Pagination) ([]entities.User, *PageInfo, error)
+	// StreamUsers calls callback with each user matching the filter, in order of their ids, without loading
+	// all of them into memory. Stops and returns the error if callback returns one.
+	StreamUsers(ctx context.Context, filter UserFilter, callback func(entities.User) error) error
 	GetUsersWithTeam(ctx context.Context, teamID string) ([]entities.User, error)
 
 	GetUserWithID(ctx context.Context, userID string) (*entities.User, error)
@@ -60,10 +80,25 @@ type UserService interface {
 	UpdateUsersWithTeam(ctx context.Context, teamID string, params UserUpdateParams) error
 
 	UpdateUserWithID(ctx context.Context, userID string, params UserUpdateParams) error
+	// JoinTeamForUserWithID makes the user with the given id a member of the team with the given id.
+	// The user is only updated while they are not in a team, so concurrent joins cannot both succeed.
+	// Returns ErrUserInTeam if the user is already in a team
+	JoinTeamForUserWithID(ctx context.Context, userID string, teamID string) error
 	UpdateUserWithEmail(ctx context.Context, email string, params UserUpdateParams) error
 
 	DeleteUserWithID(ctx context.Context, userID string) error
 	DeleteUserWithEmail(ctx context.Context, email string) error
 
 	ResetPasswordForUserWithIDAndEmail(ctx context.Context, userID string, email string, newPwd string) error
+	// SetPasswordForUserWithID sets the password of the user with the given id, if the password follows the password policy.
+	// Returns one of the password policy errors (e.g. ErrPasswordTooShort) otherwise.
+	SetPasswordForUserWithID(ctx context.Context, userID string, password string) error
+
+	// RequestEmailChangeForUserWithID stores newEmail as the pending email of the user with the given id,
+	// together with a new PendingEmailNonce, so the links confirming earlier changes stop working.
+	// Returns ErrEmailTaken if newEmail is used by any user, including the user themselves.
+	RequestEmailChangeForUserWithID(ctx context.Context, userID string, newEmail string) (*entities.User, error)
+	// ConfirmEmailChangeForUserWithID makes the pending email of the user with the given id their email and marks it as verified.
+	// Returns ErrNoPendingEmailChange if nonce is not the PendingEmailNonce of the user's pending email.
+	ConfirmEmailChangeForUserWithID(ctx context.Context, userID string, nonce string) (*entities.User, error)
 }
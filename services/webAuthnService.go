@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+
+	"github.com/unicsmcr/hs_auth/entities"
+	"github.com/unicsmcr/hs_auth/utils"
+)
+
+// WebAuthnService is the service for registering passkeys and logging in with them
+type WebAuthnService interface {
+	// BeginRegistration returns the options for registering a new passkey for the user with the given id
+	BeginRegistration(ctx context.Context, userID string) (*utils.WebAuthnCreationOptions, error)
+	// FinishRegistration verifies the credential created with the options returned by BeginRegistration
+	// and stores it under the given name. Returns ErrInvalidWebAuthnCredential if the credential cannot be verified.
+	FinishRegistration(ctx context.Context, userID, name string, credential utils.WebAuthnCredentialResponse) (*entities.WebAuthnCredential, error)
+	// BeginLogin returns the options for logging in with a passkey. When userID is empty,
+	// any passkey stored on the user's authenticator can be used.
+	BeginLogin(ctx context.Context, userID string) (*utils.WebAuthnRequestOptions, error)
+	// FinishLogin verifies the assertion made with the options returned by BeginLogin and returns the passkey's owner.
+	// When the passkey is used as a second factor, userID is the id of the user whose password has been checked
+	// and the passkey has to belong to them. Otherwise, userID is empty and the authenticator has to verify the user.
+	// Returns ErrInvalidWebAuthnCredential if the assertion cannot be verified.
+	FinishLogin(ctx context.Context, userID string, credential utils.WebAuthnCredentialResponse) (*entities.User, error)
+	// GetCredentialsForUser returns the passkeys of the user with the given id
+	GetCredentialsForUser(ctx context.Context, userID string) ([]entities.WebAuthnCredential, error)
+	// DeleteCredentialForUser deletes the passkey with the given id, if it belongs to the user with the given id
+	DeleteCredentialForUser(ctx context.Context, userID, id string) error
+	// DeleteCredentialsForUser deletes all passkeys of the user with the given id
+	DeleteCredentialsForUser(ctx context.Context, userID string) error
+}
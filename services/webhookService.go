@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+
+	"github.com/unicsmcr/hs_auth/entities"
+)
+
+// WebhookService is the service for managing webhook subscriptions and delivering events to them
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, url string, events []entities.WebhookEvent) (*entities.Webhook, error)
+
+	GetWebhooks(ctx context.Context) ([]entities.Webhook, error)
+	GetWebhookWithID(ctx context.Context, id string) (*entities.Webhook, error)
+
+	DeleteWebhookWithID(ctx context.Context, id string) error
+
+	// GetDeliveriesForWebhookWithID returns at most limit of the webhook's deliveries, newest first
+	GetDeliveriesForWebhookWithID(ctx context.Context, id string, limit int64) ([]entities.WebhookDelivery, error)
+
+	// EmitEvent queues a delivery of the event for every webhook subscribed to it.
+	// data is sent to the webhooks as the payload's data field.
+	EmitEvent(ctx context.Context, event entities.WebhookEvent, data interface{}) error
+
+	// ProcessPendingDeliveries sends the queued deliveries that are due and reschedules
+	// the ones that could not be delivered. Returns the number of deliveries that were attempted.
+	ProcessPendingDeliveries(ctx context.Context) (int, error)
+	// RunDeliveryWorker calls ProcessPendingDeliveries periodically until ctx is cancelled
+	RunDeliveryWorker(ctx context.Context)
+}
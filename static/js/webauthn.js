@@ -0,0 +1,125 @@
+// Passkey (WebAuthn) ceremonies. The server sends binary values in the options
+// and expects them in the credential as unpadded base64url strings.
+
+function base64URLToBuffer(value) {
+  let base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+  while (base64.length % 4 !== 0) {
+    base64 += "=";
+  }
+
+  let binary = atob(base64);
+  let bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+}
+
+function bufferToBase64URL(buffer) {
+  let bytes = new Uint8Array(buffer);
+  let binary = "";
+  for (let i = 0; i < bytes.length; i++) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+function decodeCredentialDescriptors(descriptors) {
+  return (descriptors || []).map(function(descriptor) {
+    return {
+      type: descriptor.type,
+      id: base64URLToBuffer(descriptor.id)
+    };
+  });
+}
+
+function decodeCreationOptions(options) {
+  options.challenge = base64URLToBuffer(options.challenge);
+  options.user.id = base64URLToBuffer(options.user.id);
+  options.excludeCredentials = decodeCredentialDescriptors(options.excludeCredentials);
+  return options;
+}
+
+function decodeRequestOptions(options) {
+  options.challenge = base64URLToBuffer(options.challenge);
+  options.allowCredentials = decodeCredentialDescriptors(options.allowCredentials);
+  return options;
+}
+
+function encodeCredential(credential) {
+  let response = {
+    clientDataJSON: bufferToBase64URL(credential.response.clientDataJSON)
+  };
+  if (credential.response.attestationObject) {
+    response.attestationObject = bufferToBase64URL(credential.response.attestationObject);
+  }
+  if (credential.response.authenticatorData) {
+    response.authenticatorData = bufferToBase64URL(credential.response.authenticatorData);
+    response.signature = bufferToBase64URL(credential.response.signature);
+    if (credential.response.userHandle) {
+      response.userHandle = bufferToBase64URL(credential.response.userHandle);
+    }
+  }
+
+  return JSON.stringify({
+    id: credential.id,
+    rawId: bufferToBase64URL(credential.rawId),
+    type: credential.type,
+    response: response
+  });
+}
+
+function passkeysSupported() {
+  if (!window.PublicKeyCredential) {
+    showError("Your browser does not support passkeys");
+    return false;
+  }
+  return true;
+}
+
+// submitCredential puts the credential into the form's credential field and submits the form
+function submitCredential(form, credential) {
+  form.elements["credential"].value = encodeCredential(credential);
+  form.submit();
+}
+
+function registerPasskey(form) {
+  if (!passkeysSupported()) {
+    return;
+  }
+
+  $.post("/webauthn/register/begin")
+    .then(function(response) {
+      return navigator.credentials.create({
+        publicKey: decodeCreationOptions(response.publicKey)
+      });
+    })
+    .then(function(credential) {
+      submitCredential(form, credential);
+    })
+    .catch(function(error) {
+      showError((error.responseJSON && error.responseJSON.message) || "Could not register passkey");
+    });
+}
+
+// usePasskey logs in with a passkey using the given request options,
+// or options fetched from the server when none are given
+function usePasskey(form, options) {
+  if (!passkeysSupported()) {
+    return;
+  }
+
+  let getOptions = options ? $.Deferred().resolve({publicKey: options}) : $.post("/webauthn/login/begin");
+  getOptions
+    .then(function(response) {
+      return navigator.credentials.get({
+        publicKey: decodeRequestOptions(response.publicKey)
+      });
+    })
+    .then(function(credential) {
+      submitCredential(form, credential);
+    })
+    .catch(function(error) {
+      showError((error.responseJSON && error.responseJSON.message) || "Could not log in with passkey");
+    });
+}
@@ -0,0 +1,106 @@
+package testutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// OAuthProvider is a local OAuth2 identity provider, used to test logging in with external identities.
+// It accepts the authorization codes added with AddCode and returns the user info associated with them.
+type OAuthProvider struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu           sync.Mutex
+	codes        map[string]string
+	accessTokens map[string]map[string]interface{}
+}
+
+// NewOAuthProvider starts a new OAuthProvider. The provider should be closed when no longer needed.
+func NewOAuthProvider() *OAuthProvider {
+	p := &OAuthProvider{
+		ClientID:     "test_client_id",
+		ClientSecret: "test_client_secret",
+		codes:        map[string]string{},
+		accessTokens: map[string]map[string]interface{}{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", p.handleToken)
+	mux.HandleFunc("/user", p.handleUserInfo)
+	p.Server = httptest.NewServer(mux)
+
+	return p
+}
+
+// AuthURL returns the URL of the provider's consent page
+func (p *OAuthProvider) AuthURL() string {
+	return p.URL + "/authorize"
+}
+
+// TokenURL returns the URL of the provider's token endpoint
+func (p *OAuthProvider) TokenURL() string {
+	return p.URL + "/token"
+}
+
+// UserInfoURL returns the URL of the provider's user info endpoint
+func (p *OAuthProvider) UserInfoURL() string {
+	return p.URL + "/user"
+}
+
+// AddCode makes the provider accept the given single-use authorization code
+// and return the given user info for the access token the code is exchanged for
+func (p *OAuthProvider) AddCode(code string, userInfo map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	accessToken := "access_token_" + code
+	p.codes[code] = accessToken
+	p.accessTokens[accessToken] = userInfo
+}
+
+func (p *OAuthProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.FormValue("grant_type") != "authorization_code" ||
+		r.FormValue("client_id") != p.ClientID || r.FormValue("client_secret") != p.ClientSecret {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid_request"})
+		return
+	}
+
+	p.mu.Lock()
+	accessToken, ok := p.codes[r.FormValue("code")]
+	delete(p.codes, r.FormValue("code"))
+	p.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid_grant"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "bearer",
+	})
+}
+
+func (p *OAuthProvider) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	userInfo, ok := p.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	p.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"message": "bad credentials"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userInfo)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
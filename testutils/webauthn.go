@@ -0,0 +1,163 @@
+package testutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Flags set by WebAuthnAuthenticator in the authenticator data
+const (
+	WebAuthnFlagUserPresent  byte = 0x01
+	WebAuthnFlagUserVerified byte = 0x04
+)
+
+// WebAuthnAuthenticator is a software authenticator with an ES256 key, used to test WebAuthn ceremonies
+type WebAuthnAuthenticator struct {
+	CredentialID []byte
+	SignCount    uint32
+	key          *ecdsa.PrivateKey
+}
+
+// NewWebAuthnAuthenticator creates an authenticator with a new key pair
+func NewWebAuthnAuthenticator() *WebAuthnAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	if err != nil {
+		panic(err)
+	}
+
+	return &WebAuthnAuthenticator{
+		CredentialID: credentialID,
+		key:          key,
+	}
+}
+
+// EncodedCredentialID returns the base64url encoded id of the authenticator's credential
+func (a *WebAuthnAuthenticator) EncodedCredentialID() string {
+	return base64.RawURLEncoding.EncodeToString(a.CredentialID)
+}
+
+// MakeCredential returns the JSON serialised PublicKeyCredential a browser would send after
+// navigator.credentials.create() with the given challenge
+func (a *WebAuthnAuthenticator) MakeCredential(rpID, origin string, challenge []byte, flags byte) string {
+	coseKey := map[int]interface{}{
+		1:  2,
+		3:  -7,
+		-1: 1,
+		-2: padTo32Bytes(a.key.X.Bytes()),
+		-3: padTo32Bytes(a.key.Y.Bytes()),
+	}
+	var encodedKey []byte
+	codec.NewEncoderBytes(&encodedKey, new(codec.CborHandle)).MustEncode(coseKey)
+
+	authData := a.makeAuthenticatorData(rpID, flags|0x40)
+	authData = append(authData, make([]byte, 16)...)
+	credentialIDLength := make([]byte, 2)
+	binary.BigEndian.PutUint16(credentialIDLength, uint16(len(a.CredentialID)))
+	authData = append(authData, credentialIDLength...)
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, encodedKey...)
+
+	var attestationObject []byte
+	codec.NewEncoderBytes(&attestationObject, new(codec.CborHandle)).MustEncode(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+
+	return a.marshalCredential(map[string]string{
+		"clientDataJSON":    encode(makeClientData("webauthn.create", origin, challenge)),
+		"attestationObject": encode(attestationObject),
+	})
+}
+
+// GetAssertion returns the JSON serialised PublicKeyCredential a browser would send after
+// navigator.credentials.get() with the given challenge. Increments the authenticator's sign count.
+func (a *WebAuthnAuthenticator) GetAssertion(rpID, origin string, challenge []byte, flags byte, userHandle []byte) string {
+	a.SignCount++
+	authData := a.makeAuthenticatorData(rpID, flags)
+	clientData := makeClientData("webauthn.get", origin, challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	signedDataHash := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	r, sig, err := ecdsa.Sign(rand.Reader, a.key, signedDataHash[:])
+	if err != nil {
+		panic(err)
+	}
+	signature, err := asn1.Marshal(struct {
+		R, S *big.Int
+	}{r, sig})
+	if err != nil {
+		panic(err)
+	}
+
+	response := map[string]string{
+		"clientDataJSON":    encode(clientData),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+	}
+	if len(userHandle) > 0 {
+		response["userHandle"] = encode(userHandle)
+	}
+
+	return a.marshalCredential(response)
+}
+
+func (a *WebAuthnAuthenticator) makeAuthenticatorData(rpID string, flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	signCount := make([]byte, 4)
+	binary.BigEndian.PutUint32(signCount, a.SignCount)
+
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, flags)
+	return append(authData, signCount...)
+}
+
+func (a *WebAuthnAuthenticator) marshalCredential(response map[string]string) string {
+	credential, err := json.Marshal(map[string]interface{}{
+		"id":       a.EncodedCredentialID(),
+		"rawId":    a.EncodedCredentialID(),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return string(credential)
+}
+
+func makeClientData(ceremonyType, origin string, challenge []byte) []byte {
+	clientData, err := json.Marshal(map[string]string{
+		"type":      ceremonyType,
+		"challenge": encode(challenge),
+		"origin":    origin,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return clientData
+}
+
+func padTo32Bytes(b []byte) []byte {
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
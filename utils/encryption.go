@@ -1,21 +1,72 @@
 package utils
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 )
 
-// GetHashForPassword generates a hash for the given password with the given salt
+// codeAlphabet leaves out characters that are easily confused with each other, e.g. 0 and O or 1 and I
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GetHashForPassword generates a salted hash for the given password using DefaultPasswordHasher
 func GetHashForPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hash), nil
+	return DefaultPasswordHasher.Hash(password)
 }
 
-// CompareHashAndPassword compares the hash to the password.
+// CompareHashAndPassword compares the hash to the password using the hasher the hash was made with.
 // If they both represent the same string, returns nill.
 // Returns an error otherwise
 func CompareHashAndPassword(hash, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	hasher, err := getHasherForHash(hash)
+	if err != nil {
+		return err
+	}
+	return hasher.Verify(hash, password)
+}
+
+// GenerateRandomHexString returns a hex encoded string of noOfBytes cryptographically secure random bytes
+func GenerateRandomHexString(noOfBytes int) (string, error) {
+	bytes := make([]byte, noOfBytes)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// GenerateRandomCode returns a cryptographically secure random code of the given length,
+// made of uppercase letters and digits that are easy to read out and type in
+func GenerateRandomCode(length int) (string, error) {
+	bytes := make([]byte, length)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	for i := range bytes {
+		// len(codeAlphabet) divides 256, so every character is equally likely
+		bytes[i] = codeAlphabet[int(bytes[i])%len(codeAlphabet)]
+	}
+	return string(bytes), nil
+}
+
+// GenerateRandomPassword returns a password made of noOfBytes cryptographically secure random bytes.
+// The password also contains a lowercase and an uppercase letter, a digit and a symbol,
+// so that it has all the character classes a password policy can require
+func GenerateRandomPassword(noOfBytes int) (string, error) {
+	bytes := make([]byte, noOfBytes)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes) + "aA0!", nil
+}
+
+// GetHMACSHA256 returns the hex encoded HMAC-SHA256 of the message, keyed with the given secret
+func GetHMACSHA256(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,24 +12,81 @@ func Test_GetHashForPassword__should_return_expected_hash(t *testing.T) {
 	hash, err := GetHashForPassword("test password")
 	assert.NoError(t, err)
 
-	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte("test password"))
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$"))
+	err = DefaultPasswordHasher.Verify(hash, "test password")
 	assert.NoError(t, err)
 }
 
 func Test_CompareHashAndPassword__should_return_nil_for_valid_hash_and_password_combination(t *testing.T) {
 	password := "test password"
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := GetHashForPassword(password)
 	assert.NoError(t, err)
 
-	assert.NoError(t, CompareHashAndPassword(string(hash), password))
+	assert.NoError(t, CompareHashAndPassword(hash, password))
 }
 
 func Test_CompareHashAndPassword__should_return_nil_for_invalid_hash_and_password_combination(t *testing.T) {
 	password := "test password"
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := GetHashForPassword(password)
 	assert.NoError(t, err)
 
 	password += "invalid"
 
-	assert.Error(t, CompareHashAndPassword(string(hash), password))
+	assert.Error(t, CompareHashAndPassword(hash, password))
+}
+
+func Test_CompareHashAndPassword__should_verify_bcrypt_hashes(t *testing.T) {
+	password := "test password"
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	assert.NoError(t, err)
+
+	assert.NoError(t, CompareHashAndPassword(string(hash), password))
+	assert.Equal(t, ErrPasswordMismatch, CompareHashAndPassword(string(hash), password+"invalid"))
+}
+
+func Test_CompareHashAndPassword__should_return_ErrUnknownPasswordHash_for_unknown_hash_format(t *testing.T) {
+	assert.Equal(t, ErrUnknownPasswordHash, CompareHashAndPassword("$md5$abc", "test password"))
+}
+
+func Test_GenerateRandomHexString__should_return_string_of_expected_length(t *testing.T) {
+	str, err := GenerateRandomHexString(16)
+	assert.NoError(t, err)
+	assert.Len(t, str, 32)
+
+	otherStr, err := GenerateRandomHexString(16)
+	assert.NoError(t, err)
+	assert.NotEqual(t, str, otherStr)
+}
+
+func Test_GenerateRandomCode__should_return_different_codes_of_expected_length(t *testing.T) {
+	code, err := GenerateRandomCode(8)
+	assert.NoError(t, err)
+	otherCode, err := GenerateRandomCode(8)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, code, otherCode)
+	assert.Len(t, code, 8)
+	for _, char := range code {
+		assert.Contains(t, codeAlphabet, string(char))
+	}
+}
+
+func Test_GetHMACSHA256__should_return_expected_signature(t *testing.T) {
+	// test vector from RFC 4231
+	assert.Equal(t, "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		GetHMACSHA256("Jefe", []byte("what do ya want for nothing?")))
+}
+
+func Test_GenerateRandomPassword__should_return_different_passwords_with_all_character_classes(t *testing.T) {
+	password, err := GenerateRandomPassword(24)
+	assert.NoError(t, err)
+	otherPassword, err := GenerateRandomPassword(24)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, password, otherPassword)
+	assert.Len(t, password, 36)
+	assert.True(t, strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz"))
+	assert.True(t, strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+	assert.True(t, strings.ContainsAny(password, "0123456789"))
+	assert.True(t, strings.ContainsAny(password, "!"))
 }
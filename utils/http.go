@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"net/http"
+	"time"
+)
+
+// HTTPClient provides access to http.Client's Do function through an interface
+// in order to make mocking of outgoing requests possible
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+func NewHTTPClient() HTTPClient {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+	}
+}
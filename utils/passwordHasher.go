@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownPasswordHash is returned when no password hasher recognises the format of a password hash
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// ErrPasswordMismatch is returned when a password does not match the hash it is compared to
+var ErrPasswordMismatch = errors.New("password does not match hash")
+
+// PasswordHasher hashes passwords with one algorithm and verifies hashes made with it
+type PasswordHasher interface {
+	// Hash returns the hash of the password in PHC string format
+	Hash(password string) (string, error)
+	// Recognises checks whether the hash was made with the hasher's algorithm
+	Recognises(hash string) bool
+	// Verify returns nil if the password matches the hash and an error otherwise
+	Verify(hash, password string) error
+	// NeedsRehash checks whether the hash was made with parameters other than the hasher's current ones
+	NeedsRehash(hash string) bool
+}
+
+// DefaultPasswordHasher is the hasher used for all new password hashes
+var DefaultPasswordHasher PasswordHasher = Argon2idHasher{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHashers are the hashers which can verify stored password hashes, in order of preference
+var PasswordHashers = []PasswordHasher{
+	DefaultPasswordHasher,
+	BcryptHasher{Cost: bcrypt.DefaultCost},
+}
+
+// PasswordHashNeedsUpdate checks whether the hash should be replaced with a hash made by DefaultPasswordHasher,
+// either because it was made with a different algorithm or with outdated parameters
+func PasswordHashNeedsUpdate(hash string) bool {
+	return !DefaultPasswordHasher.Recognises(hash) || DefaultPasswordHasher.NeedsRehash(hash)
+}
+
+func getHasherForHash(hash string) (PasswordHasher, error) {
+	for _, hasher := range PasswordHashers {
+		if hasher.Recognises(hash) {
+			return hasher, nil
+		}
+	}
+	return nil, ErrUnknownPasswordHash
+}
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idHasher is a PasswordHasher using argon2id
+type Argon2idHasher struct {
+	// Memory is the amount of memory used by the algorithm, in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// argon2idHash holds the parts of an argon2id PHC string
+type argon2idHash struct {
+	params Argon2idHasher
+	salt   []byte
+	key    []byte
+}
+
+var phcEncoding = base64.RawStdEncoding
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", errors.Wrap(err, "could not generate salt")
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, h.Memory, h.Iterations,
+		h.Parallelism, phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key)), nil
+}
+
+func (h Argon2idHasher) Recognises(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (h Argon2idHasher) Verify(hash, password string) error {
+	parsed, err := parseArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey([]byte(password), parsed.salt, parsed.params.Iterations, parsed.params.Memory,
+		parsed.params.Parallelism, parsed.params.KeyLength)
+	if subtle.ConstantTimeCompare(key, parsed.key) != 1 {
+		return ErrPasswordMismatch
+	}
+
+	return nil
+}
+
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	parsed, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return parsed.params != h
+}
+
+// parseArgon2idHash parses hashes of the form $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+func parseArgon2idHash(hash string) (*argon2idHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse argon2id version")
+	}
+	if version != argon2.Version {
+		return nil, errors.Errorf("unsupported argon2id version %d", version)
+	}
+
+	var parsed argon2idHash
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &parsed.params.Memory, &parsed.params.Iterations,
+		&parsed.params.Parallelism)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse argon2id parameters")
+	}
+
+	parsed.salt, err = phcEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode argon2id salt")
+	}
+	parsed.key, err = phcEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode argon2id key")
+	}
+	parsed.params.SaltLength = uint32(len(parsed.salt))
+	parsed.params.KeyLength = uint32(len(parsed.key))
+
+	return &parsed, nil
+}
+
+// BcryptHasher is a PasswordHasher using bcrypt.
+// bcrypt hashes are stored in bcrypt's own modular crypt format, which predates PHC strings
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h BcryptHasher) Recognises(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h BcryptHasher) Verify(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != h.Cost
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idHasher = Argon2idHasher{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func Test_Argon2idHasher_Hash__should_return_hash_in_PHC_format(t *testing.T) {
+	hash, err := testArgon2idHasher.Hash("test password")
+	assert.NoError(t, err)
+
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, hash)
+}
+
+func Test_Argon2idHasher_Hash__should_use_random_salt(t *testing.T) {
+	hash, err := testArgon2idHasher.Hash("test password")
+	assert.NoError(t, err)
+	otherHash, err := testArgon2idHasher.Hash("test password")
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, hash, otherHash)
+}
+
+func Test_Argon2idHasher_Verify(t *testing.T) {
+	hash, err := testArgon2idHasher.Hash("test password")
+	assert.NoError(t, err)
+	otherHasher := Argon2idHasher{Memory: 2048, Iterations: 2, Parallelism: 2, SaltLength: 8, KeyLength: 16}
+	otherHash, err := otherHasher.Hash("test password")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "should return nil for matching password",
+			hash:     hash,
+			password: "test password",
+		},
+		{
+			name:     "should return ErrPasswordMismatch for wrong password",
+			hash:     hash,
+			password: "wrong password",
+			wantErr:  ErrPasswordMismatch,
+		},
+		{
+			name:     "should verify hashes made with other parameters",
+			hash:     otherHash,
+			password: "test password",
+		},
+		{
+			name:     "should return ErrUnknownPasswordHash for malformed hash",
+			hash:     "$argon2id$v=19$m=16,t=2,p=1",
+			password: "password",
+			wantErr:  ErrUnknownPasswordHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := testArgon2idHasher.Verify(tt.hash, tt.password)
+
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func Test_Argon2idHasher_NeedsRehash(t *testing.T) {
+	hash, err := testArgon2idHasher.Hash("test password")
+	assert.NoError(t, err)
+
+	strongerHasher := testArgon2idHasher
+	strongerHasher.Iterations = 2
+
+	assert.False(t, testArgon2idHasher.NeedsRehash(hash))
+	assert.True(t, strongerHasher.NeedsRehash(hash))
+	assert.True(t, testArgon2idHasher.NeedsRehash("$argon2id$invalid"))
+}
+
+func Test_BcryptHasher_NeedsRehash(t *testing.T) {
+	hasher := BcryptHasher{Cost: bcrypt.MinCost}
+	hash, err := hasher.Hash("test password")
+	assert.NoError(t, err)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, BcryptHasher{Cost: bcrypt.MinCost + 1}.NeedsRehash(hash))
+}
+
+func Test_PasswordHashNeedsUpdate(t *testing.T) {
+	argon2idHash, err := GetHashForPassword("test password")
+	assert.NoError(t, err)
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("test password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	outdatedArgon2idHash, err := testArgon2idHasher.Hash("test password")
+	assert.NoError(t, err)
+
+	assert.False(t, PasswordHashNeedsUpdate(argon2idHash))
+	assert.True(t, PasswordHashNeedsUpdate(string(bcryptHash)))
+	assert.True(t, PasswordHashNeedsUpdate(outdatedArgon2idHash))
+}
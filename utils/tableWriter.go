@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TableWriter writes the rows of a table to an underlying writer in some file format
+type TableWriter interface {
+	// WriteRow writes the next row of the table
+	WriteRow(cells []string) error
+	// Close finishes the file, it must be called once all rows have been written
+	Close() error
+}
+
+type csvTableWriter struct {
+	writer *csv.Writer
+}
+
+// NewCSVTableWriter creates a TableWriter producing CSV files.
+// Cells which spreadsheet applications would interpret as formulas are prefixed with a single quote
+func NewCSVTableWriter(w io.Writer) TableWriter {
+	return &csvTableWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvTableWriter) WriteRow(cells []string) error {
+	escapedCells := make([]string, len(cells))
+	for i, cell := range cells {
+		if len(cell) > 0 && strings.ContainsAny(cell[:1], "=+-@\t\r") {
+			cell = "'" + cell
+		}
+		escapedCells[i] = cell
+	}
+
+	return w.writer.Write(escapedCells)
+}
+
+func (w *csvTableWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRootRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	xlsxWorkbookRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+
+	// xlsxMaxSheetNameLength is the longest sheet name spreadsheet applications accept
+	xlsxMaxSheetNameLength = 31
+)
+
+type xlsxTableWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	rows    int
+}
+
+// NewXLSXTableWriter creates a TableWriter producing XLSX workbooks with a single sheet with the given name.
+// All cells are written as strings
+func NewXLSXTableWriter(w io.Writer, sheetName string) (TableWriter, error) {
+	if len(sheetName) > xlsxMaxSheetNameLength {
+		sheetName = sheetName[:xlsxMaxSheetNameLength]
+	}
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{name: "[Content_Types].xml", content: xlsxContentTypes},
+		{name: "_rels/.rels", content: xlsxRootRelationships},
+		{name: "xl/workbook.xml", content: fmt.Sprintf(xlsxWorkbook, escapeXML(sheetName))},
+		{name: "xl/_rels/workbook.xml.rels", content: xlsxWorkbookRelationships},
+	}
+	for _, file := range files {
+		fileWriter, err := archive.Create(file.name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create %s", file.name)
+		}
+		_, err = io.WriteString(fileWriter, file.content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not write %s", file.name)
+		}
+	}
+
+	// the sheet is the last file in the archive so that rows can be streamed into it
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create sheet")
+	}
+	_, err = io.WriteString(sheet, xlsxSheetStart)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not write sheet")
+	}
+
+	return &xlsxTableWriter{archive: archive, sheet: sheet}, nil
+}
+
+func (w *xlsxTableWriter) WriteRow(cells []string) error {
+	w.rows++
+	rowNumber := strconv.Itoa(w.rows)
+
+	var row strings.Builder
+	row.WriteString(`<row r="` + rowNumber + `">`)
+	for i, cell := range cells {
+		row.WriteString(`<c r="` + xlsxColumnName(i) + rowNumber + `" t="inlineStr"><is><t xml:space="preserve">`)
+		row.WriteString(escapeXML(cell))
+		row.WriteString(`</t></is></c>`)
+	}
+	row.WriteString(`</row>`)
+
+	_, err := io.WriteString(w.sheet, row.String())
+	return err
+}
+
+func (w *xlsxTableWriter) Close() error {
+	_, err := io.WriteString(w.sheet, xlsxSheetEnd)
+	if err != nil {
+		return errors.Wrap(err, "could not write sheet")
+	}
+	return w.archive.Close()
+}
+
+// xlsxColumnName returns the name of the column with the given zero-based index, e.g. A, Z, AA
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func escapeXML(text string) string {
+	var escaped strings.Builder
+	// writes to a strings.Builder never fail
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CSVTableWriter__should_write_rows(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewCSVTableWriter(&buf)
+
+	assert.NoError(t, writer.WriteRow([]string{"name", "email"}))
+	assert.NoError(t, writer.WriteRow([]string{"Bob, Jr.", "bob@test.com"}))
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, "name,email\n\"Bob, Jr.\",bob@test.com\n", buf.String())
+}
+
+func Test_CSVTableWriter__should_escape_formulas(t *testing.T) {
+	tests := []struct {
+		cell         string
+		expectedCell string
+	}{
+		{cell: "=1+1", expectedCell: "'=1+1"},
+		{cell: "+44", expectedCell: "'+44"},
+		{cell: "-1", expectedCell: "'-1"},
+		{cell: "@SUM(A1)", expectedCell: "'@SUM(A1)"},
+		{cell: "a=b", expectedCell: "a=b"},
+		{cell: "", expectedCell: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cell, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := NewCSVTableWriter(&buf)
+
+			assert.NoError(t, writer.WriteRow([]string{tt.cell}))
+			assert.NoError(t, writer.Close())
+
+			assert.Equal(t, tt.expectedCell+"\n", buf.String())
+		})
+	}
+}
+
+func Test_XLSXTableWriter__should_write_valid_workbook(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewXLSXTableWriter(&buf, "Users")
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.WriteRow([]string{"name", "email"}))
+	assert.NoError(t, writer.WriteRow([]string{"Bob & <Rob>", "bob@test.com"}))
+	assert.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err)
+		files[file.Name] = string(content)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "_rels/.rels")
+	assert.Contains(t, files, "xl/_rels/workbook.xml.rels")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Users" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"],
+		`<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve">Bob &amp; &lt;Rob&gt;</t></is></c>`+
+			`<c r="B2" t="inlineStr"><is><t xml:space="preserve">bob@test.com</t></is></c></row></sheetData></worksheet>`)
+}
+
+func Test_xlsxColumnName__should_return_expected_name(t *testing.T) {
+	tests := []struct {
+		index        int
+		expectedName string
+	}{
+		{index: 0, expectedName: "A"},
+		{index: 25, expectedName: "Z"},
+		{index: 26, expectedName: "AA"},
+		{index: 701, expectedName: "ZZ"},
+		{index: 702, expectedName: "AAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectedName, func(t *testing.T) {
+			assert.Equal(t, tt.expectedName, xlsxColumnName(tt.index))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpSecretBytes = 20
+	totpDigits      = 6
+	totpPeriod      = 30
+	// number of time steps before and after the current one in which a code is still accepted,
+	// to allow for clock drift and for the time it takes the user to type the code
+	totpAllowedSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a base32 encoded secret for RFC 6238 one-time passwords
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretBytes)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// GetTOTPStep returns the number of the TOTP time step the given time falls into
+func GetTOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// GetTOTPCode returns the one-time password for the given base32 encoded secret and time step
+func GetTOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// ValidateTOTPCode checks whether code is a valid one-time password for the given secret at the given time.
+// Returns the time step the code was generated for, so that callers can reject codes that were already used.
+func ValidateTOTPCode(secret, code string, t time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	currentStep := GetTOTPStep(t)
+	for step := currentStep - totpAllowedSkew; step <= currentStep+totpAllowedSkew; step++ {
+		expected, err := GetTOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// MakeTOTPURI returns the otpauth URI that authenticator apps use to register the given secret
+func MakeTOTPURI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+
+	return fmt.Sprintf("otpauth://totp/%s:%s?%s", url.PathEscape(issuer), url.PathEscape(account), params.Encode())
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the secret used by the test vectors in RFC 6238
+var rfcTestSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func Test_GenerateTOTPSecret__should_return_decodable_secret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	key, err := totpEncoding.DecodeString(secret)
+	assert.NoError(t, err)
+	assert.Len(t, key, totpSecretBytes)
+}
+
+func Test_GetTOTPCode__should_return_expected_code(t *testing.T) {
+	tests := []struct {
+		time         int64
+		expectedCode string
+	}{
+		{time: 59, expectedCode: "287082"},
+		{time: 1111111109, expectedCode: "081804"},
+		{time: 1234567890, expectedCode: "005924"},
+		{time: 2000000000, expectedCode: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectedCode, func(t *testing.T) {
+			code, err := GetTOTPCode(rfcTestSecret, GetTOTPStep(time.Unix(tt.time, 0)))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, code)
+		})
+	}
+}
+
+func Test_GetTOTPCode__should_return_error_for_invalid_secret(t *testing.T) {
+	_, err := GetTOTPCode("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func Test_ValidateTOTPCode__should_accept_codes_from_adjacent_steps(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := GetTOTPStep(now)
+
+	for _, codeStep := range []int64{step - 1, step, step + 1} {
+		code, err := GetTOTPCode(rfcTestSecret, codeStep)
+		assert.NoError(t, err)
+
+		validatedStep, ok := ValidateTOTPCode(rfcTestSecret, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, codeStep, validatedStep)
+	}
+}
+
+func Test_ValidateTOTPCode__should_reject_invalid_codes(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	oldCode, err := GetTOTPCode(rfcTestSecret, GetTOTPStep(now)-2)
+	assert.NoError(t, err)
+
+	for _, code := range []string{oldCode, "", "12345", "1234567"} {
+		_, ok := ValidateTOTPCode(rfcTestSecret, code, now)
+		assert.False(t, ok)
+	}
+}
+
+func Test_MakeTOTPURI__should_return_expected_uri(t *testing.T) {
+	uri := MakeTOTPURI("HS Auth", "bob@email.com", "SECRET")
+
+	assert.Equal(t, "otpauth://totp/HS%20Auth:bob@email.com?digits=6&issuer=HS+Auth&period=30&secret=SECRET", uri)
+}
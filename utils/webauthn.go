@@ -0,0 +1,403 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+)
+
+// COSE algorithm identifiers of the public key types accepted for WebAuthn credentials
+const (
+	WebAuthnAlgES256 = -7
+	WebAuthnAlgEdDSA = -8
+	WebAuthnAlgRS256 = -257
+)
+
+// WebAuthnAlgorithms are the algorithms offered to authenticators when registering a credential, in order of preference
+var WebAuthnAlgorithms = []int{WebAuthnAlgES256, WebAuthnAlgEdDSA, WebAuthnAlgRS256}
+
+// Types of the client data collected by the browser during a WebAuthn ceremony
+const (
+	WebAuthnTypeCreate = "webauthn.create"
+	WebAuthnTypeGet    = "webauthn.get"
+)
+
+// Flags of the authenticator data
+const (
+	webAuthnFlagUserPresent        = 0x01
+	webAuthnFlagUserVerified       = 0x04
+	webAuthnFlagAttestedCredential = 0x40
+)
+
+// COSE key parameters, see RFC 8152
+const (
+	coseKeyType       = 1
+	coseKeyAlg        = 3
+	coseKeyCurve      = -1
+	coseKeyX          = -2
+	coseKeyY          = -3
+	coseKeyRSAModulus = -1
+	coseKeyRSAExp     = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+var (
+	// ErrInvalidWebAuthnData is returned when the data sent by an authenticator cannot be parsed
+	ErrInvalidWebAuthnData = errors.New("invalid WebAuthn data")
+	// ErrUnsupportedWebAuthnKey is returned when a credential's public key uses an algorithm that is not supported
+	ErrUnsupportedWebAuthnKey = errors.New("unsupported WebAuthn public key")
+	// ErrInvalidWebAuthnSignature is returned when an assertion's signature does not match the credential's public key
+	ErrInvalidWebAuthnSignature = errors.New("invalid WebAuthn signature")
+)
+
+var webAuthnEncoding = base64.RawURLEncoding
+
+// EncodeWebAuthnBytes returns the unpadded base64url encoding used for binary data by WebAuthn
+func EncodeWebAuthnBytes(data []byte) string {
+	return webAuthnEncoding.EncodeToString(data)
+}
+
+// DecodeWebAuthnBytes decodes base64url encoded binary data, with or without padding
+func DecodeWebAuthnBytes(data string) ([]byte, error) {
+	return webAuthnEncoding.DecodeString(strings.TrimRight(data, "="))
+}
+
+// WebAuthnBytes is binary data that is base64url encoded in JSON, as in the JSON serialisation of WebAuthn objects
+type WebAuthnBytes []byte
+
+// MarshalJSON encodes the bytes as a base64url string
+func (b WebAuthnBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(EncodeWebAuthnBytes(b))
+}
+
+// UnmarshalJSON decodes the bytes from a base64url string
+func (b *WebAuthnBytes) UnmarshalJSON(data []byte) error {
+	var encoded string
+	err := json.Unmarshal(data, &encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := DecodeWebAuthnBytes(encoded)
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
+
+// WebAuthnRelyingParty identifies hs_auth to authenticators
+type WebAuthnRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebAuthnUser identifies the owner of a new credential to authenticators
+type WebAuthnUser struct {
+	ID          WebAuthnBytes `json:"id"`
+	Name        string        `json:"name"`
+	DisplayName string        `json:"displayName"`
+}
+
+// WebAuthnCredentialParameters is a type of credential accepted by hs_auth
+type WebAuthnCredentialParameters struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// WebAuthnCredentialDescriptor identifies an existing credential
+type WebAuthnCredentialDescriptor struct {
+	Type string        `json:"type"`
+	ID   WebAuthnBytes `json:"id"`
+}
+
+// WebAuthnAuthenticatorSelection specifies the authenticators that can be used to create a credential
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions are the options passed to navigator.credentials.create() to register a new credential
+type WebAuthnCreationOptions struct {
+	Challenge              WebAuthnBytes                  `json:"challenge"`
+	RP                     WebAuthnRelyingParty           `json:"rp"`
+	User                   WebAuthnUser                   `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameters `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                         `json:"attestation"`
+}
+
+// WebAuthnRequestOptions are the options passed to navigator.credentials.get() to log in with a credential
+type WebAuthnRequestOptions struct {
+	Challenge        WebAuthnBytes                  `json:"challenge"`
+	Timeout          int64                          `json:"timeout"`
+	RPID             string                         `json:"rpId"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+// WebAuthnAuthenticatorResponse is the response of an authenticator to navigator.credentials.create() or
+// navigator.credentials.get(). AttestationObject is only set for the former, AuthenticatorData, Signature
+// and UserHandle only for the latter.
+type WebAuthnAuthenticatorResponse struct {
+	ClientDataJSON    WebAuthnBytes `json:"clientDataJSON"`
+	AttestationObject WebAuthnBytes `json:"attestationObject,omitempty"`
+	AuthenticatorData WebAuthnBytes `json:"authenticatorData,omitempty"`
+	Signature         WebAuthnBytes `json:"signature,omitempty"`
+	UserHandle        WebAuthnBytes `json:"userHandle,omitempty"`
+}
+
+// WebAuthnCredentialResponse is the JSON serialisation of the PublicKeyCredential returned by the browser
+type WebAuthnCredentialResponse struct {
+	ID       string                        `json:"id"`
+	RawID    WebAuthnBytes                 `json:"rawId"`
+	Type     string                        `json:"type"`
+	Response WebAuthnAuthenticatorResponse `json:"response"`
+}
+
+// ParseWebAuthnCredentialResponse parses the JSON serialisation of a PublicKeyCredential
+func ParseWebAuthnCredentialResponse(data string) (*WebAuthnCredentialResponse, error) {
+	var credential WebAuthnCredentialResponse
+	err := json.Unmarshal([]byte(data), &credential)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidWebAuthnData, err.Error())
+	}
+
+	if len(credential.RawID) == 0 || len(credential.Response.ClientDataJSON) == 0 {
+		return nil, ErrInvalidWebAuthnData
+	}
+
+	return &credential, nil
+}
+
+// WebAuthnClientData is the data collected by the browser during a WebAuthn ceremony
+type WebAuthnClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ParseWebAuthnClientData parses the clientDataJSON of an authenticator response
+func ParseWebAuthnClientData(clientDataJSON []byte) (*WebAuthnClientData, error) {
+	var clientData WebAuthnClientData
+	err := json.Unmarshal(clientDataJSON, &clientData)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidWebAuthnData, err.Error())
+	}
+
+	return &clientData, nil
+}
+
+// WebAuthnAuthenticatorData is the data signed by an authenticator. CredentialID and PublicKey
+// are only set when a new credential is created, PublicKey is PKIX, ASN.1 DER encoded.
+type WebAuthnAuthenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// UserPresent returns whether the user interacted with the authenticator
+func (d *WebAuthnAuthenticatorData) UserPresent() bool {
+	return d.Flags&webAuthnFlagUserPresent != 0
+}
+
+// UserVerified returns whether the authenticator verified the user, e.g. with a PIN or a fingerprint
+func (d *WebAuthnAuthenticatorData) UserVerified() bool {
+	return d.Flags&webAuthnFlagUserVerified != 0
+}
+
+// MatchesRPID returns whether the authenticator data was created for the given relying party id
+func (d *WebAuthnAuthenticatorData) MatchesRPID(rpID string) bool {
+	hash := sha256.Sum256([]byte(rpID))
+	return bytes.Equal(hash[:], d.RPIDHash)
+}
+
+// ParseWebAuthnAuthenticatorData parses authenticator data as described in https://www.w3.org/TR/webauthn/#sctn-authenticator-data
+func ParseWebAuthnAuthenticatorData(data []byte) (*WebAuthnAuthenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidWebAuthnData
+	}
+
+	authData := &WebAuthnAuthenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	if authData.Flags&webAuthnFlagAttestedCredential == 0 {
+		return authData, nil
+	}
+
+	// attested credential data: 16 byte AAGUID, 2 byte credential id length, credential id, COSE public key
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, ErrInvalidWebAuthnData
+	}
+	credentialIDLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if credentialIDLength == 0 || len(rest) < credentialIDLength {
+		return nil, ErrInvalidWebAuthnData
+	}
+	authData.CredentialID = rest[:credentialIDLength]
+
+	publicKey, err := parseCOSEKey(rest[credentialIDLength:])
+	if err != nil {
+		return nil, err
+	}
+	authData.PublicKey = publicKey
+
+	return authData, nil
+}
+
+// ParseWebAuthnAttestationObject returns the authenticator data of the attestation object sent when a
+// credential is created. The attestation statement is not verified, as hs_auth does not request attestation.
+func ParseWebAuthnAttestationObject(attestationObject []byte) (*WebAuthnAuthenticatorData, error) {
+	var object struct {
+		Format   string `codec:"fmt"`
+		AuthData []byte `codec:"authData"`
+	}
+	err := codec.NewDecoderBytes(attestationObject, new(codec.CborHandle)).Decode(&object)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidWebAuthnData, err.Error())
+	}
+
+	authData, err := ParseWebAuthnAuthenticatorData(object.AuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(authData.CredentialID) == 0 {
+		return nil, ErrInvalidWebAuthnData
+	}
+
+	return authData, nil
+}
+
+// VerifyWebAuthnSignature checks the signature of an assertion made with the credential with the given
+// PKIX encoded public key. The signature is made over the authenticator data and the hash of the client data.
+func VerifyWebAuthnSignature(publicKey, authenticatorData, clientDataJSON, signature []byte) error {
+	key, err := x509.ParsePKIXPublicKey(publicKey)
+	if err != nil {
+		return errors.Wrap(ErrUnsupportedWebAuthnKey, err.Error())
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, authenticatorData...), clientDataHash[:]...)
+	signedDataHash := sha256.Sum256(signedData)
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		var sig struct {
+			R, S *big.Int
+		}
+		rest, err := asn1.Unmarshal(signature, &sig)
+		if err != nil || len(rest) != 0 || sig.R == nil || sig.S == nil {
+			return ErrInvalidWebAuthnSignature
+		}
+		if !ecdsa.Verify(key, signedDataHash[:], sig.R, sig.S) {
+			return ErrInvalidWebAuthnSignature
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, signedDataHash[:], signature) != nil {
+			return ErrInvalidWebAuthnSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, signedData, signature) {
+			return ErrInvalidWebAuthnSignature
+		}
+	default:
+		return ErrUnsupportedWebAuthnKey
+	}
+
+	return nil
+}
+
+// parseCOSEKey converts the COSE encoded public key of a credential into a PKIX encoded one
+func parseCOSEKey(data []byte) ([]byte, error) {
+	var key map[int]interface{}
+	err := codec.NewDecoderBytes(data, new(codec.CborHandle)).Decode(&key)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidWebAuthnData, err.Error())
+	}
+
+	keyType, _ := coseInt(key[coseKeyType])
+	alg, _ := coseInt(key[coseKeyAlg])
+
+	var publicKey interface{}
+	switch {
+	case keyType == coseKeyTypeEC2 && alg == WebAuthnAlgES256:
+		curve, _ := coseInt(key[coseKeyCurve])
+		x, xOk := key[coseKeyX].([]byte)
+		y, yOk := key[coseKeyY].([]byte)
+		if curve != coseCurveP256 || !xOk || !yOk {
+			return nil, ErrUnsupportedWebAuthnKey
+		}
+		ecKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !ecKey.Curve.IsOnCurve(ecKey.X, ecKey.Y) {
+			return nil, ErrUnsupportedWebAuthnKey
+		}
+		publicKey = ecKey
+	case keyType == coseKeyTypeOKP && alg == WebAuthnAlgEdDSA:
+		curve, _ := coseInt(key[coseKeyCurve])
+		x, ok := key[coseKeyX].([]byte)
+		if curve != coseCurveEd25519 || !ok || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedWebAuthnKey
+		}
+		publicKey = ed25519.PublicKey(x)
+	case keyType == coseKeyTypeRSA && alg == WebAuthnAlgRS256:
+		n, nOk := key[coseKeyRSAModulus].([]byte)
+		e, eOk := key[coseKeyRSAExp].([]byte)
+		if !nOk || !eOk || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedWebAuthnKey
+		}
+		publicKey = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	default:
+		return nil, ErrUnsupportedWebAuthnKey
+	}
+
+	return x509.MarshalPKIXPublicKey(publicKey)
+}
+
+// coseInt converts an integer decoded from CBOR, which can be signed or unsigned, to an int
+func coseInt(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int64:
+		return int(value), true
+	case uint64:
+		return int(value), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"github.com/unicsmcr/hs_auth/testutils"
+)
+
+const (
+	testRPID   = "auth.unicsmcr.com"
+	testOrigin = "https://auth.unicsmcr.com"
+)
+
+var testChallenge = []byte("test challenge")
+
+func registerTestAuthenticator(t *testing.T) (*testutils.WebAuthnAuthenticator, *WebAuthnAuthenticatorData) {
+	authenticator := testutils.NewWebAuthnAuthenticator()
+	credential, err := ParseWebAuthnCredentialResponse(
+		authenticator.MakeCredential(testRPID, testOrigin, testChallenge, testutils.WebAuthnFlagUserPresent))
+	assert.NoError(t, err)
+
+	authData, err := ParseWebAuthnAttestationObject(credential.Response.AttestationObject)
+	assert.NoError(t, err)
+
+	return authenticator, authData
+}
+
+func encodeCBOR(value interface{}) []byte {
+	var data []byte
+	codec.NewEncoderBytes(&data, new(codec.CborHandle)).MustEncode(value)
+	return data
+}
+
+func Test_WebAuthnBytes__should_be_base64url_encoded_in_JSON(t *testing.T) {
+	data, err := json.Marshal(WebAuthnBytes{0xfb, 0xff})
+	assert.NoError(t, err)
+	assert.Equal(t, `"-_8"`, string(data))
+
+	var decoded WebAuthnBytes
+	assert.NoError(t, json.Unmarshal([]byte(`"-_8="`), &decoded))
+	assert.Equal(t, WebAuthnBytes{0xfb, 0xff}, decoded)
+}
+
+func Test_ParseWebAuthnCredentialResponse__should_return_error_for_invalid_credential(t *testing.T) {
+	for _, data := range []string{"", "{}", `{"rawId": "not base64!"}`} {
+		_, err := ParseWebAuthnCredentialResponse(data)
+		assert.Error(t, err)
+	}
+}
+
+func Test_ParseWebAuthnClientData__should_return_client_data(t *testing.T) {
+	clientData, err := ParseWebAuthnClientData([]byte(`{"type":"webauthn.get","challenge":"abc","origin":"https://test.com"}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, WebAuthnClientData{Type: WebAuthnTypeGet, Challenge: "abc", Origin: "https://test.com"}, *clientData)
+}
+
+func Test_ParseWebAuthnAttestationObject__should_return_credential(t *testing.T) {
+	authenticator, authData := registerTestAuthenticator(t)
+
+	assert.Equal(t, authenticator.CredentialID, authData.CredentialID)
+	assert.True(t, authData.MatchesRPID(testRPID))
+	assert.False(t, authData.MatchesRPID("unicsmcr.com"))
+	assert.True(t, authData.UserPresent())
+	assert.False(t, authData.UserVerified())
+
+	key, err := x509.ParsePKIXPublicKey(authData.PublicKey)
+	assert.NoError(t, err)
+	assert.IsType(t, &ecdsa.PublicKey{}, key)
+}
+
+func Test_ParseWebAuthnAttestationObject__should_return_error_for_invalid_data(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		[]byte("not cbor"),
+		encodeCBOR(map[string]interface{}{"fmt": "none", "authData": []byte{1, 2, 3}}),
+		// authenticator data without a credential
+		encodeCBOR(map[string]interface{}{"fmt": "none", "authData": make([]byte, 37)}),
+	} {
+		_, err := ParseWebAuthnAttestationObject(data)
+		assert.Error(t, err)
+	}
+}
+
+func Test_VerifyWebAuthnSignature__should_accept_valid_assertion(t *testing.T) {
+	authenticator, authData := registerTestAuthenticator(t)
+
+	credential, err := ParseWebAuthnCredentialResponse(authenticator.GetAssertion(testRPID, testOrigin, testChallenge,
+		testutils.WebAuthnFlagUserPresent|testutils.WebAuthnFlagUserVerified, nil))
+	assert.NoError(t, err)
+
+	err = VerifyWebAuthnSignature(authData.PublicKey, credential.Response.AuthenticatorData,
+		credential.Response.ClientDataJSON, credential.Response.Signature)
+	assert.NoError(t, err)
+
+	assertionAuthData, err := ParseWebAuthnAuthenticatorData(credential.Response.AuthenticatorData)
+	assert.NoError(t, err)
+	assert.True(t, assertionAuthData.UserVerified())
+	assert.Equal(t, uint32(1), assertionAuthData.SignCount)
+}
+
+func Test_VerifyWebAuthnSignature__should_reject_tampered_assertion(t *testing.T) {
+	authenticator, authData := registerTestAuthenticator(t)
+
+	credential, err := ParseWebAuthnCredentialResponse(authenticator.GetAssertion(testRPID, testOrigin, testChallenge,
+		testutils.WebAuthnFlagUserPresent, nil))
+	assert.NoError(t, err)
+
+	err = VerifyWebAuthnSignature(authData.PublicKey, credential.Response.AuthenticatorData,
+		[]byte(`{"type":"webauthn.get"}`), credential.Response.Signature)
+	assert.Equal(t, ErrInvalidWebAuthnSignature, err)
+
+	_, otherAuthData := registerTestAuthenticator(t)
+	err = VerifyWebAuthnSignature(otherAuthData.PublicKey, credential.Response.AuthenticatorData,
+		credential.Response.ClientDataJSON, credential.Response.Signature)
+	assert.Equal(t, ErrInvalidWebAuthnSignature, err)
+}
+
+func Test_parseCOSEKey__should_parse_supported_keys(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		coseKey  map[int]interface{}
+		wantType interface{}
+	}{
+		{
+			name:     "Ed25519",
+			coseKey:  map[int]interface{}{1: 1, 3: -8, -1: 6, -2: []byte(edKey)},
+			wantType: ed25519.PublicKey{},
+		},
+		{
+			name:     "RSA",
+			coseKey:  map[int]interface{}{1: 3, 3: -257, -1: rsaKey.N.Bytes(), -2: big.NewInt(int64(rsaKey.E)).Bytes()},
+			wantType: &rsa.PublicKey{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publicKey, err := parseCOSEKey(encodeCBOR(tt.coseKey))
+			assert.NoError(t, err)
+
+			key, err := x509.ParsePKIXPublicKey(publicKey)
+			assert.NoError(t, err)
+			assert.IsType(t, tt.wantType, key)
+		})
+	}
+}
+
+func Test_parseCOSEKey__should_return_error_for_unsupported_key(t *testing.T) {
+	_, err := parseCOSEKey(encodeCBOR(map[int]interface{}{1: 2, 3: -35, -1: 2}))
+	assert.Equal(t, ErrUnsupportedWebAuthnKey, err)
+
+	_, err = parseCOSEKey(encodeCBOR(map[int]interface{}{1: 2, 3: -7, -1: 1, -2: make([]byte, 32), -3: make([]byte, 32)}))
+	assert.Equal(t, ErrUnsupportedWebAuthnKey, err)
+}
@@ -7,12 +7,15 @@ import (
 	authV2 "github.com/unicsmcr/hs_auth/authorization/v2"
 	"github.com/unicsmcr/hs_auth/config"
 	"github.com/unicsmcr/hs_auth/environment"
+	"github.com/unicsmcr/hs_auth/migrations"
 	"github.com/unicsmcr/hs_auth/repositories"
 	"github.com/unicsmcr/hs_auth/routers"
 	v2 "github.com/unicsmcr/hs_auth/routers/api/v2"
 	"github.com/unicsmcr/hs_auth/routers/frontend"
 	"github.com/unicsmcr/hs_auth/services/mongo"
 	"github.com/unicsmcr/hs_auth/services/multiplexers"
+	"github.com/unicsmcr/hs_auth/services/oauth"
+	"github.com/unicsmcr/hs_auth/services/password"
 	"github.com/unicsmcr/hs_auth/utils"
 )
 
@@ -25,13 +28,38 @@ func InitializeServer() (Server, error) {
 		mongo.NewMongoTokenService,
 		mongo.NewMongoTeamService,
 		mongo.NewMongoUserService,
+		mongo.NewMongoAuditService,
+		mongo.NewMongoWebhookService,
+		mongo.NewMongoLoginAttemptService,
+		mongo.NewMongoTwoFactorService,
+		mongo.NewMongoWebAuthnService,
+		mongo.NewMongoExternalIdentityService,
+		mongo.NewMongoInvitationService,
+		mongo.NewMongoTeamJoinRequestService,
+		mongo.NewMongoMatchmakingService,
+		oauth.NewOAuthProviders,
+		password.NewPolicy,
 		multiplexers.NewEmailServiceV2,
 		repositories.NewUserRepository,
 		repositories.NewTeamRepository,
 		repositories.NewTokenRepository,
+		repositories.NewMigrationRepository,
+		repositories.NewAuditEventRepository,
+		repositories.NewWebhookRepository,
+		repositories.NewWebhookDeliveryRepository,
+		repositories.NewLoginAttemptsRepository,
+		repositories.NewWebAuthnCredentialRepository,
+		repositories.NewWebAuthnChallengeRepository,
+		repositories.NewExternalIdentityRepository,
+		repositories.NewInvitationRepository,
+		repositories.NewTeamJoinRequestRepository,
+		repositories.NewMatchmakingProfileRepository,
+		repositories.NewTeamAdvertRepository,
+		migrations.ApplyMigrations,
 		utils.NewDatabase,
 		utils.NewSendgridClient,
 		utils.NewSMTPClient,
+		utils.NewHTTPClient,
 		environment.NewEnv,
 		utils.NewLogger,
 		config.NewAppConfig,
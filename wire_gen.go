@@ -9,12 +9,15 @@ import (
 	"github.com/unicsmcr/hs_auth/authorization/v2"
 	"github.com/unicsmcr/hs_auth/config"
 	"github.com/unicsmcr/hs_auth/environment"
+	"github.com/unicsmcr/hs_auth/migrations"
 	"github.com/unicsmcr/hs_auth/repositories"
 	"github.com/unicsmcr/hs_auth/routers"
 	v2_2 "github.com/unicsmcr/hs_auth/routers/api/v2"
 	"github.com/unicsmcr/hs_auth/routers/frontend"
 	"github.com/unicsmcr/hs_auth/services/mongo"
 	"github.com/unicsmcr/hs_auth/services/multiplexers"
+	"github.com/unicsmcr/hs_auth/services/oauth"
+	"github.com/unicsmcr/hs_auth/services/password"
 	"github.com/unicsmcr/hs_auth/utils"
 )
 
@@ -44,22 +47,89 @@ func InitializeServer() (Server, error) {
 	if err != nil {
 		return Server{}, err
 	}
-	userService := mongo.NewMongoUserService(logger, env, appConfig, userRepository)
-	authorizer := v2.NewAuthorizer(timeProvider, appConfig, env, logger, tokenService, userService)
+	httpClient := utils.NewHTTPClient()
+	webhookRepository, err := repositories.NewWebhookRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	webhookDeliveryRepository, err := repositories.NewWebhookDeliveryRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	webhookService := mongo.NewMongoWebhookService(logger, appConfig, timeProvider, httpClient, webhookRepository, webhookDeliveryRepository)
+	passwordPolicy, err := password.NewPolicy(appConfig)
+	if err != nil {
+		return Server{}, err
+	}
+	userService := mongo.NewMongoUserService(logger, env, appConfig, userRepository, webhookService, passwordPolicy)
+	auditEventRepository, err := repositories.NewAuditEventRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	auditService := mongo.NewMongoAuditService(logger, timeProvider, auditEventRepository)
+	authorizer := v2.NewAuthorizer(timeProvider, appConfig, env, logger, tokenService, userService, auditService)
 	teamRepository, err := repositories.NewTeamRepository(database)
 	if err != nil {
 		return Server{}, err
 	}
-	teamService := mongo.NewMongoTeamService(logger, env, teamRepository, userService)
+	loginAttemptsRepository, err := repositories.NewLoginAttemptsRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	teamJoinRequestRepository, err := repositories.NewTeamJoinRequestRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	teamAdvertRepository, err := repositories.NewTeamAdvertRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	teamService := mongo.NewMongoTeamService(logger, env, appConfig, timeProvider, teamRepository, loginAttemptsRepository, teamJoinRequestRepository, teamAdvertRepository, userService, webhookService)
 	smtpClient := utils.NewSMTPClient()
 	client := utils.NewSendgridClient(env)
 	emailServiceV2, err := multiplexers.NewEmailServiceV2(appConfig, env, smtpClient, client, userService, authorizer, timeProvider)
 	if err != nil {
 		return Server{}, err
 	}
-	apiv2Router := v2_2.NewAPIV2Router(logger, appConfig, authorizer, userService, teamService, tokenService, emailServiceV2, timeProvider)
-	router := frontend.NewRouter(logger, appConfig, env, userService, teamService, authorizer, timeProvider, emailServiceV2)
+	loginAttemptService := mongo.NewMongoLoginAttemptService(logger, appConfig, timeProvider, loginAttemptsRepository, userService, emailServiceV2)
+	twoFactorService := mongo.NewMongoTwoFactorService(logger, appConfig, timeProvider, userRepository, userService)
+	webAuthnCredentialRepository, err := repositories.NewWebAuthnCredentialRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	webAuthnChallengeRepository, err := repositories.NewWebAuthnChallengeRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	webAuthnService := mongo.NewMongoWebAuthnService(logger, appConfig, timeProvider, webAuthnCredentialRepository, webAuthnChallengeRepository, userService)
+	externalIdentityRepository, err := repositories.NewExternalIdentityRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	v := oauth.NewOAuthProviders(logger, appConfig, env, httpClient)
+	externalIdentityService := mongo.NewMongoExternalIdentityService(logger, timeProvider, externalIdentityRepository, userService, v)
+	invitationRepository, err := repositories.NewInvitationRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	invitationService := mongo.NewMongoInvitationService(logger, timeProvider, invitationRepository)
+	teamJoinRequestService := mongo.NewMongoTeamJoinRequestService(logger, timeProvider, teamJoinRequestRepository, teamService, userService, emailServiceV2)
+	matchmakingProfileRepository, err := repositories.NewMatchmakingProfileRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	matchmakingService := mongo.NewMongoMatchmakingService(logger, appConfig, timeProvider, matchmakingProfileRepository, teamAdvertRepository, userService, teamService, teamJoinRequestService)
+	apiv2Router := v2_2.NewAPIV2Router(logger, appConfig, authorizer, userService, teamService, tokenService, emailServiceV2, auditService, webhookService, loginAttemptService, twoFactorService, webAuthnService, externalIdentityService, invitationService, teamJoinRequestService, matchmakingService, timeProvider)
+	router := frontend.NewRouter(logger, appConfig, env, userService, teamService, authorizer, timeProvider, emailServiceV2, auditService, loginAttemptService, twoFactorService, webAuthnService, externalIdentityService, invitationService, teamJoinRequestService, matchmakingService)
 	mainRouter := routers.NewMainRouter(logger, apiv2Router, router)
-	server := NewServer(mainRouter, env)
+	migrationRepository, err := repositories.NewMigrationRepository(database)
+	if err != nil {
+		return Server{}, err
+	}
+	schemaVersion, err := migrations.ApplyMigrations(logger, database, migrationRepository, timeProvider)
+	if err != nil {
+		return Server{}, err
+	}
+	server := NewServer(mainRouter, env, schemaVersion, webhookService)
 	return server, nil
 }